@@ -1,29 +1,219 @@
 package auth
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
 	"time"
 
+	"finala/api/config"
+	"finala/serverutil"
+
 	"github.com/golang-jwt/jwt/v5"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// defaultExpiry is the token expiry when no expiry is configured
+	defaultExpiry = 24 * time.Hour
+
+	// minSigningKeyLength is the minimum length of a HS256 signing key
+	minSigningKeyLength = 32
+
+	// generatedSigningKeyLength is the length of the signing key generated when no signing key is configured
+	generatedSigningKeyLength = 64
+
+	// issuer is the issuer of the tokens signed by the api
+	issuer = "finala-api"
+
+	// bearerPrefix is the authorization header prefix of bearer tokens
+	bearerPrefix = "Bearer "
+
+	// AccessTokenParameter is the query parameter of the bearer token, for the clients which cannot set headers
+	AccessTokenParameter = "access_token"
 )
 
-var jwtSecretKey = []byte("your-super-secret-and-long-enough-key-please-change-this-ASAP")
+var (
+	// ErrMissingToken is returned when the request has no bearer token
+	ErrMissingToken = errors.New("missing bearer token")
+
+	// ErrExpiredToken is returned when the token has expired
+	ErrExpiredToken = errors.New("token has expired")
+
+	// ErrInvalidToken is returned when the token could not be verified
+	ErrInvalidToken = errors.New("invalid token")
+)
+
+// claimsContextKey is the request context key of the authenticated token claims
+type claimsContextKey struct{}
+
+// Claims describes the api token claims
+type Claims struct {
+	Role string `json:"role,omitempty"`
+	jwt.RegisteredClaims
+}
+
+// JWTManager signs and validates the api tokens
+type JWTManager struct {
+	signingKeys []config.JWTSigningKeyConfig
+	expiry      time.Duration
+}
 
-// GenerateJWT creates a new JWT for a given username.
-func GenerateJWT(username string) (string, time.Time, error) {
-	expirationTime := time.Now().Add(24 * time.Hour) // Token valid for 24 hours
+// NewJWTManager creates new jwt manager from the given configuration.
+// When no signing key is configured a random signing key is generated, and the issued tokens are valid until the api restarts
+func NewJWTManager(jwtConfig config.JWTConfig) (*JWTManager, error) {
 
-	claims := &jwt.RegisteredClaims{
-		Subject:   username,
-		ExpiresAt: jwt.NewNumericDate(expirationTime),
-		IssuedAt:  jwt.NewNumericDate(time.Now()),
-		Issuer:    "finala-api",
+	signingKeys := jwtConfig.SigningKeys
+	if len(signingKeys) == 0 {
+		generatedKey, err := serverutil.GenerateRandomPassword(generatedSigningKeyLength)
+		if err != nil {
+			return nil, err
+		}
+		log.Warn("jwt signing keys are not configured, using a generated signing key. Tokens will be invalidated when the api restarts")
+		signingKeys = []config.JWTSigningKeyConfig{{Key: generatedKey}}
 	}
 
+	keyIDs := map[string]bool{}
+	for _, signingKey := range signingKeys {
+		if len(signingKey.Key) < minSigningKeyLength {
+			return nil, fmt.Errorf("jwt signing key %q must be at least %d characters long", signingKey.ID, minSigningKeyLength)
+		}
+		if keyIDs[signingKey.ID] {
+			return nil, fmt.Errorf("jwt signing key id %q is configured more than once", signingKey.ID)
+		}
+		keyIDs[signingKey.ID] = true
+	}
+
+	expiry := jwtConfig.Expiry
+	if expiry == 0 {
+		expiry = defaultExpiry
+	}
+	if expiry < 0 {
+		return nil, fmt.Errorf("jwt expiry must be positive, got %s", expiry)
+	}
+
+	return &JWTManager{
+		signingKeys: signingKeys,
+		expiry:      expiry,
+	}, nil
+}
+
+// GenerateJWT creates a new JWT for a given username and role, signed by the first signing key.
+func (jm *JWTManager) GenerateJWT(username, role string) (string, time.Time, error) {
+	now := time.Now()
+	expirationTime := now.Add(jm.expiry)
+
+	claims := &Claims{
+		Role: role,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   username,
+			ExpiresAt: jwt.NewNumericDate(expirationTime),
+			IssuedAt:  jwt.NewNumericDate(now),
+			Issuer:    issuer,
+		},
+	}
+
+	signingKey := jm.signingKeys[0]
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	tokenString, err := token.SignedString(jwtSecretKey)
+	if signingKey.ID != "" {
+		token.Header["kid"] = signingKey.ID
+	}
+
+	tokenString, err := token.SignedString([]byte(signingKey.Key))
 	if err != nil {
 		return "", time.Time{}, err
 	}
 
 	return tokenString, expirationTime, nil
 }
+
+// ValidateJWT verifies the given token with the signing key of its key id.
+// Tokens without a key id are verified against all the signing keys
+func (jm *JWTManager) ValidateJWT(tokenString string) (*Claims, error) {
+
+	claims := &Claims{}
+	_, err := jwt.ParseWithClaims(tokenString, claims, jm.verificationKey,
+		jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}),
+		jwt.WithIssuer(issuer),
+		jwt.WithExpirationRequired(),
+	)
+	if errors.Is(err, jwt.ErrTokenExpired) {
+		return nil, ErrExpiredToken
+	}
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	return claims, nil
+}
+
+// verificationKey returns the verification key of the given token by its key id
+func (jm *JWTManager) verificationKey(token *jwt.Token) (interface{}, error) {
+
+	keyID, _ := token.Header["kid"].(string)
+	if keyID == "" {
+		keySet := jwt.VerificationKeySet{}
+		for _, signingKey := range jm.signingKeys {
+			keySet.Keys = append(keySet.Keys, []byte(signingKey.Key))
+		}
+		return keySet, nil
+	}
+
+	for _, signingKey := range jm.signingKeys {
+		if signingKey.ID == keyID {
+			return []byte(signingKey.Key), nil
+		}
+	}
+	return nil, fmt.Errorf("unknown signing key id %q", keyID)
+}
+
+// Authenticate validates the bearer token of the request, and returns its claims
+func (jm *JWTManager) Authenticate(req *http.Request) (*Claims, error) {
+
+	authorization := req.Header.Get("Authorization")
+	if authorization == "" {
+		return nil, ErrMissingToken
+	}
+
+	if len(authorization) < len(bearerPrefix) || !strings.EqualFold(authorization[:len(bearerPrefix)], bearerPrefix) {
+		return nil, ErrMissingToken
+	}
+
+	tokenString := strings.TrimSpace(authorization[len(bearerPrefix):])
+	if tokenString == "" {
+		return nil, ErrMissingToken
+	}
+
+	return jm.ValidateJWT(tokenString)
+}
+
+// AuthenticateWithQuery validates the bearer token of the request like Authenticate, or the access_token query
+// parameter when the request has no authorization header. Browsers cannot set the headers of an EventSource,
+// so only the event streams accept the query token
+func (jm *JWTManager) AuthenticateWithQuery(req *http.Request) (*Claims, error) {
+
+	claims, err := jm.Authenticate(req)
+	if !errors.Is(err, ErrMissingToken) || req.Header.Get("Authorization") != "" {
+		return claims, err
+	}
+
+	tokenString := req.URL.Query().Get(AccessTokenParameter)
+	if tokenString == "" {
+		return nil, ErrMissingToken
+	}
+
+	return jm.ValidateJWT(tokenString)
+}
+
+// ContextWithClaims returns a copy of the given context with the authenticated token claims
+func ContextWithClaims(ctx context.Context, claims *Claims) context.Context {
+	return context.WithValue(ctx, claimsContextKey{}, claims)
+}
+
+// ClaimsFromContext returns the authenticated token claims of the request context
+func ClaimsFromContext(ctx context.Context) (*Claims, bool) {
+	claims, ok := ctx.Value(claimsContextKey{}).(*Claims)
+	return claims, ok
+}
@@ -0,0 +1,272 @@
+package auth_test
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"finala/api/auth"
+	"finala/api/config"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var (
+	currentSigningKey  = config.JWTSigningKeyConfig{ID: "current", Key: "current-signing-key-of-at-least-32-bytes"}
+	previousSigningKey = config.JWTSigningKeyConfig{ID: "previous", Key: "previous-signing-key-of-at-least-32-bytes"}
+)
+
+func newJWTManager(t *testing.T, signingKeys ...config.JWTSigningKeyConfig) *auth.JWTManager {
+	jwtManager, err := auth.NewJWTManager(config.JWTConfig{SigningKeys: signingKeys})
+	if err != nil {
+		t.Fatalf("unexpected jwt manager error: %v", err)
+	}
+	return jwtManager
+}
+
+func TestNewJWTManager(t *testing.T) {
+
+	testCases := []struct {
+		name        string
+		config      config.JWTConfig
+		expectedErr bool
+	}{
+		{"generated signing key", config.JWTConfig{}, false},
+		{"configured signing keys", config.JWTConfig{SigningKeys: []config.JWTSigningKeyConfig{currentSigningKey, previousSigningKey}, Expiry: time.Hour}, false},
+		{"short signing key", config.JWTConfig{SigningKeys: []config.JWTSigningKeyConfig{{ID: "short", Key: "short"}}}, true},
+		{"duplicate key id", config.JWTConfig{SigningKeys: []config.JWTSigningKeyConfig{currentSigningKey, currentSigningKey}}, true},
+		{"negative expiry", config.JWTConfig{SigningKeys: []config.JWTSigningKeyConfig{currentSigningKey}, Expiry: -time.Hour}, true},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			jwtManager, err := auth.NewJWTManager(test.config)
+			if test.expectedErr {
+				if err == nil {
+					t.Fatalf("unexpected error, got nil expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			token, _, err := jwtManager.GenerateJWT("admin", "admin")
+			if err != nil {
+				t.Fatalf("unexpected generate error: %v", err)
+			}
+			if _, err := jwtManager.ValidateJWT(token); err != nil {
+				t.Fatalf("unexpected validate error: %v", err)
+			}
+		})
+	}
+}
+
+func TestGenerateJWT(t *testing.T) {
+
+	jwtManager, err := auth.NewJWTManager(config.JWTConfig{
+		SigningKeys: []config.JWTSigningKeyConfig{currentSigningKey, previousSigningKey},
+		Expiry:      time.Hour,
+	})
+	if err != nil {
+		t.Fatalf("unexpected jwt manager error: %v", err)
+	}
+
+	token, expirationTime, err := jwtManager.GenerateJWT("admin", "admin")
+	if err != nil {
+		t.Fatalf("unexpected generate error: %v", err)
+	}
+
+	if time.Until(expirationTime).Round(time.Minute) != time.Hour {
+		t.Fatalf("unexpected token expiration time, got %s expected %s", expirationTime, time.Hour)
+	}
+
+	claims := jwt.MapClaims{}
+	parsedToken, _, err := jwt.NewParser().ParseUnverified(token, claims)
+	if err != nil {
+		t.Fatalf("unexpected parse error: %v", err)
+	}
+
+	// New tokens are signed by the first signing key
+	if parsedToken.Header["kid"] != currentSigningKey.ID {
+		t.Fatalf("unexpected token key id, got %v expected %s", parsedToken.Header["kid"], currentSigningKey.ID)
+	}
+	if claims["sub"] != "admin" {
+		t.Fatalf("unexpected token subject, got %v expected %s", claims["sub"], "admin")
+	}
+	if role, _ := claims["role"].(string); role != "admin" {
+		t.Fatalf("unexpected token role, got %v expected %s", claims["role"], "admin")
+	}
+}
+
+func TestValidateJWT(t *testing.T) {
+
+	jwtManager := newJWTManager(t, currentSigningKey, previousSigningKey)
+
+	previousToken, _, err := newJWTManager(t, previousSigningKey).GenerateJWT("admin", "admin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	unknownToken, _, err := newJWTManager(t, config.JWTSigningKeyConfig{ID: "unknown", Key: "unknown-signing-key-of-at-least-32-bytes"}).GenerateJWT("admin", "admin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	withoutKeyIDToken, _, err := newJWTManager(t, config.JWTSigningKeyConfig{Key: previousSigningKey.Key}).GenerateJWT("admin", "admin")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	signToken := func(method jwt.SigningMethod, claims jwt.RegisteredClaims, key interface{}) string {
+		token := jwt.NewWithClaims(method, claims)
+		token.Header["kid"] = currentSigningKey.ID
+		tokenString, err := token.SignedString(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return tokenString
+	}
+
+	expiredToken := signToken(jwt.SigningMethodHS256, jwt.RegisteredClaims{
+		Subject:   "admin",
+		Issuer:    "finala-api",
+		ExpiresAt: jwt.NewNumericDate(time.Now().Add(-time.Minute)),
+	}, []byte(currentSigningKey.Key))
+
+	withoutExpiryToken := signToken(jwt.SigningMethodHS256, jwt.RegisteredClaims{
+		Subject: "admin",
+		Issuer:  "finala-api",
+	}, []byte(currentSigningKey.Key))
+
+	otherIssuerToken := signToken(jwt.SigningMethodHS256, jwt.RegisteredClaims{
+		Subject:   "admin",
+		Issuer:    "foo",
+		ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute)),
+	}, []byte(currentSigningKey.Key))
+
+	noneToken := signToken(jwt.SigningMethodNone, jwt.RegisteredClaims{
+		Subject:   "admin",
+		Issuer:    "finala-api",
+		ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute)),
+	}, jwt.UnsafeAllowNoneSignatureType)
+
+	testCases := []struct {
+		name        string
+		token       string
+		expectedErr error
+	}{
+		{"previous signing key", previousToken, nil},
+		{"without key id", withoutKeyIDToken, nil},
+		{"unknown signing key", unknownToken, auth.ErrInvalidToken},
+		{"expired", expiredToken, auth.ErrExpiredToken},
+		{"without expiry", withoutExpiryToken, auth.ErrInvalidToken},
+		{"other issuer", otherIssuerToken, auth.ErrInvalidToken},
+		{"none signing method", noneToken, auth.ErrInvalidToken},
+		{"malformed", "foo", auth.ErrInvalidToken},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			claims, err := jwtManager.ValidateJWT(test.token)
+			if !errors.Is(err, test.expectedErr) {
+				t.Fatalf("unexpected validate error, got %v expected %v", err, test.expectedErr)
+			}
+			if test.expectedErr == nil && claims.Subject != "admin" {
+				t.Fatalf("unexpected token subject, got %s expected %s", claims.Subject, "admin")
+			}
+		})
+	}
+}
+
+func TestAuthenticate(t *testing.T) {
+
+	jwtManager := newJWTManager(t, currentSigningKey)
+	token, _, err := jwtManager.GenerateJWT("admin", "admin")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		name          string
+		authorization string
+		expectedErr   error
+	}{
+		{"missing header", "", auth.ErrMissingToken},
+		{"basic authorization", "Basic YWRtaW46YWRtaW4=", auth.ErrMissingToken},
+		{"empty bearer token", "Bearer ", auth.ErrMissingToken},
+		{"invalid bearer token", "Bearer foo", auth.ErrInvalidToken},
+		{"valid bearer token", "Bearer " + token, nil},
+		{"lowercase bearer scheme", "bearer " + token, nil},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			req, err := http.NewRequest("GET", "/api/v1/executions", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if test.authorization != "" {
+				req.Header.Set("Authorization", test.authorization)
+			}
+
+			claims, err := jwtManager.Authenticate(req)
+			if !errors.Is(err, test.expectedErr) {
+				t.Fatalf("unexpected authenticate error, got %v expected %v", err, test.expectedErr)
+			}
+			if test.expectedErr != nil {
+				return
+			}
+
+			ctxClaims, ok := auth.ClaimsFromContext(auth.ContextWithClaims(req.Context(), claims))
+			if !ok || ctxClaims.Subject != "admin" {
+				t.Fatalf("unexpected context claims, got %+v", ctxClaims)
+			}
+		})
+	}
+}
+
+func TestAuthenticateWithQuery(t *testing.T) {
+
+	jwtManager := newJWTManager(t, currentSigningKey)
+	token, _, err := jwtManager.GenerateJWT("admin", "admin")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		name          string
+		authorization string
+		accessToken   string
+		expectedErr   error
+	}{
+		{"missing token", "", "", auth.ErrMissingToken},
+		{"invalid query token", "", "foo", auth.ErrInvalidToken},
+		{"valid query token", "", token, nil},
+		{"valid bearer token", "Bearer " + token, "", nil},
+		{"basic authorization ignores the query token", "Basic YWRtaW46YWRtaW4=", token, auth.ErrMissingToken},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			req, err := http.NewRequest("GET", "/api/v1/executions/1/events", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if test.authorization != "" {
+				req.Header.Set("Authorization", test.authorization)
+			}
+			if test.accessToken != "" {
+				req.URL.RawQuery = url.Values{auth.AccessTokenParameter: {test.accessToken}}.Encode()
+			}
+
+			claims, err := jwtManager.AuthenticateWithQuery(req)
+			if !errors.Is(err, test.expectedErr) {
+				t.Fatalf("unexpected authenticate error, got %v expected %v", err, test.expectedErr)
+			}
+			if test.expectedErr == nil && claims.Subject != "admin" {
+				t.Fatalf("unexpected claims subject, got %s expected %s", claims.Subject, "admin")
+			}
+		})
+	}
+}
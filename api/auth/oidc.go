@@ -0,0 +1,218 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"finala/api/config"
+	"finala/api/users"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"golang.org/x/oauth2"
+)
+
+const (
+	// defaultUsernameClaim is the id token claim of the username when no claim is configured
+	defaultUsernameClaim = "email"
+
+	// defaultGroupsClaim is the id token claim the role mappings match when the mapping has no claim
+	defaultGroupsClaim = "groups"
+)
+
+var (
+	// ErrOIDCNonce is returned when the id token nonce does not match the login nonce
+	ErrOIDCNonce = errors.New("id token nonce does not match")
+
+	// ErrOIDCNoRole is returned when none of the role mappings match the user, and there is no default role
+	ErrOIDCNoRole = errors.New("the user is not mapped to any role")
+)
+
+// defaultOIDCScopes are the scopes requested when no scopes are configured
+var defaultOIDCScopes = []string{oidc.ScopeOpenID, "profile", "email"}
+
+// OIDCIdentity describes a user signed in by the identity provider
+type OIDCIdentity struct {
+	Username string
+	Role     users.Role
+}
+
+// OIDCProvider signs users in with the OIDC authorization code flow of the configured identity provider
+type OIDCProvider struct {
+	config   config.OIDCConfig
+	oauth2   oauth2.Config
+	verifier *oidc.IDTokenVerifier
+}
+
+// NewOIDCProvider discovers the configured identity provider endpoints and signing keys
+func NewOIDCProvider(ctx context.Context, oidcConfig config.OIDCConfig) (*OIDCProvider, error) {
+
+	if oidcConfig.ClientID == "" || oidcConfig.RedirectURL == "" || oidcConfig.UIRedirectURL == "" {
+		return nil, errors.New("oidc client_id, redirect_url and ui_redirect_url are required")
+	}
+	for _, mapping := range oidcConfig.RoleMappings {
+		if !users.Role(mapping.Role).IsValid() {
+			return nil, fmt.Errorf("oidc role mapping of %q has an invalid role %q", mapping.Value, mapping.Role)
+		}
+	}
+	if oidcConfig.DefaultRole != "" && !users.Role(oidcConfig.DefaultRole).IsValid() {
+		return nil, fmt.Errorf("oidc default role %q is invalid", oidcConfig.DefaultRole)
+	}
+	if oidcConfig.UsernameClaim == "" {
+		oidcConfig.UsernameClaim = defaultUsernameClaim
+	}
+	if oidcConfig.GroupsClaim == "" {
+		oidcConfig.GroupsClaim = defaultGroupsClaim
+	}
+
+	scopes := oidcConfig.Scopes
+	if len(scopes) == 0 {
+		scopes = defaultOIDCScopes
+	}
+	if !containsString(scopes, oidc.ScopeOpenID) {
+		scopes = append([]string{oidc.ScopeOpenID}, scopes...)
+	}
+
+	provider, err := oidc.NewProvider(ctx, oidcConfig.IssuerURL)
+	if err != nil {
+		return nil, fmt.Errorf("could not discover the oidc provider %s: %w", oidcConfig.IssuerURL, err)
+	}
+
+	return &OIDCProvider{
+		config: oidcConfig,
+		oauth2: oauth2.Config{
+			ClientID:     oidcConfig.ClientID,
+			ClientSecret: oidcConfig.ClientSecret,
+			RedirectURL:  oidcConfig.RedirectURL,
+			Endpoint:     provider.Endpoint(),
+			Scopes:       scopes,
+		},
+		verifier: provider.Verifier(&oidc.Config{ClientID: oidcConfig.ClientID}),
+	}, nil
+}
+
+// PasswordLoginDisabled returns true if the users are allowed to sign in only through the identity provider
+func (op *OIDCProvider) PasswordLoginDisabled() bool {
+	return op.config.DisablePasswordLogin
+}
+
+// RedirectURL returns the api callback url the identity provider redirects to
+func (op *OIDCProvider) RedirectURL() string {
+	return op.config.RedirectURL
+}
+
+// SecureCookies returns true if the callback is served over https, and the sign-in cookies must be sent only over https
+func (op *OIDCProvider) SecureCookies() bool {
+	redirectURL, err := url.Parse(op.config.RedirectURL)
+	if err != nil {
+		return false
+	}
+	return strings.EqualFold(redirectURL.Scheme, "https")
+}
+
+// UIRedirectURL returns the ui url the users are redirected to after the sign-in
+func (op *OIDCProvider) UIRedirectURL() string {
+	return op.config.UIRedirectURL
+}
+
+// AuthCodeURL returns the identity provider url the users sign in at.
+// The code exchange is protected by the given PKCE verifier, and the id token is bound to the given nonce
+func (op *OIDCProvider) AuthCodeURL(state, nonce, verifier string) string {
+	return op.oauth2.AuthCodeURL(state, oidc.Nonce(nonce), oauth2.S256ChallengeOption(verifier))
+}
+
+// Exchange exchanges the authorization code for an id token, validates it, and returns the signed in identity
+func (op *OIDCProvider) Exchange(ctx context.Context, code, nonce, verifier string) (OIDCIdentity, error) {
+
+	token, err := op.oauth2.Exchange(ctx, code, oauth2.VerifierOption(verifier))
+	if err != nil {
+		return OIDCIdentity{}, fmt.Errorf("could not exchange the authorization code: %w", err)
+	}
+
+	rawIDToken, ok := token.Extra("id_token").(string)
+	if !ok || rawIDToken == "" {
+		return OIDCIdentity{}, errors.New("the token response has no id token")
+	}
+
+	idToken, err := op.verifier.Verify(ctx, rawIDToken)
+	if err != nil {
+		return OIDCIdentity{}, fmt.Errorf("invalid id token: %w", err)
+	}
+	if idToken.Nonce != nonce {
+		return OIDCIdentity{}, ErrOIDCNonce
+	}
+
+	claims := map[string]interface{}{}
+	err = idToken.Claims(&claims)
+	if err != nil {
+		return OIDCIdentity{}, err
+	}
+
+	return op.identity(claims)
+}
+
+// identity returns the username and the mapped role of the given id token claims
+func (op *OIDCProvider) identity(claims map[string]interface{}) (OIDCIdentity, error) {
+
+	username, _ := claims[op.config.UsernameClaim].(string)
+	if username == "" {
+		return OIDCIdentity{}, fmt.Errorf("the id token has no %s claim", op.config.UsernameClaim)
+	}
+
+	// An unverified email could belong to anyone, and must not be trusted as a username
+	if op.config.UsernameClaim == "email" {
+		if verified, found := claims["email_verified"].(bool); found && !verified {
+			return OIDCIdentity{}, errors.New("the id token email is not verified")
+		}
+	}
+
+	role := users.Role(op.config.DefaultRole)
+	for _, mapping := range op.config.RoleMappings {
+		claim := mapping.Claim
+		if claim == "" {
+			claim = op.config.GroupsClaim
+		}
+		mappingRole := users.Role(mapping.Role)
+		if containsString(claimValues(claims[claim]), mapping.Value) && !role.Allows(mappingRole) {
+			role = mappingRole
+		}
+	}
+	if role == "" {
+		return OIDCIdentity{}, ErrOIDCNoRole
+	}
+
+	return OIDCIdentity{
+		Username: username,
+		Role:     role,
+	}, nil
+}
+
+// claimValues returns the string values of a single value or a list claim
+func claimValues(claim interface{}) []string {
+	switch value := claim.(type) {
+	case string:
+		return []string{value}
+	case []interface{}:
+		values := []string{}
+		for _, item := range value {
+			values = append(values, fmt.Sprintf("%v", item))
+		}
+		return values
+	case nil:
+		return nil
+	default:
+		return []string{fmt.Sprintf("%v", value)}
+	}
+}
+
+// containsString returns true if the given values contain the given value
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
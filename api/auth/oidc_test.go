@@ -0,0 +1,209 @@
+package auth_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"finala/api/auth"
+	"finala/api/config"
+	"finala/api/testutils"
+	"finala/api/users"
+
+	"golang.org/x/oauth2"
+)
+
+// newMockOIDCProvider starts a local identity provider, and returns it with its configuration
+func newMockOIDCProvider(t *testing.T) (*testutils.MockOIDCProvider, config.OIDCConfig) {
+	mockProvider, err := testutils.NewMockOIDCProvider()
+	if err != nil {
+		t.Fatalf("unexpected mock oidc provider error: %v", err)
+	}
+	t.Cleanup(mockProvider.Server.Close)
+
+	return mockProvider, config.OIDCConfig{
+		IssuerURL:     mockProvider.IssuerURL(),
+		ClientID:      testutils.MockOIDCClientID,
+		ClientSecret:  testutils.MockOIDCClientSecret,
+		RedirectURL:   "http://127.0.0.1:8089/api/v1/auth/oidc/callback",
+		UIRedirectURL: "http://127.0.0.1:8080/login",
+		RoleMappings: []config.OIDCRoleMappingConfig{
+			{Value: "finala-viewers", Role: "viewer"},
+			{Value: "finala-admins", Role: "admin"},
+			{Claim: "department", Value: "finops", Role: "analyst"},
+		},
+	}
+}
+
+// authorizationCode signs in at the given authorization url, and returns the authorization code of the redirect
+func authorizationCode(t *testing.T, authCodeURL string) string {
+	client := &http.Client{
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+	resp, err := client.Get(authCodeURL)
+	if err != nil {
+		t.Fatalf("unexpected authorize error: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusFound {
+		t.Fatalf("unexpected authorize status code, got %d expected %d", resp.StatusCode, http.StatusFound)
+	}
+
+	location, err := url.Parse(resp.Header.Get("Location"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return location.Query().Get("code")
+}
+
+func TestNewOIDCProvider(t *testing.T) {
+
+	_, validConfig := newMockOIDCProvider(t)
+
+	withoutClientID := validConfig
+	withoutClientID.ClientID = ""
+
+	invalidMappingRole := validConfig
+	invalidMappingRole.RoleMappings = []config.OIDCRoleMappingConfig{{Value: "finala-admins", Role: "root"}}
+
+	invalidDefaultRole := validConfig
+	invalidDefaultRole.DefaultRole = "root"
+
+	unknownIssuer := validConfig
+	unknownIssuer.IssuerURL = validConfig.IssuerURL + "/unknown"
+
+	testCases := []struct {
+		name        string
+		config      config.OIDCConfig
+		expectedErr bool
+	}{
+		{"valid", validConfig, false},
+		{"without client id", withoutClientID, true},
+		{"invalid mapping role", invalidMappingRole, true},
+		{"invalid default role", invalidDefaultRole, true},
+		{"unknown issuer", unknownIssuer, true},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := auth.NewOIDCProvider(context.Background(), test.config)
+			if test.expectedErr && err == nil {
+				t.Fatalf("unexpected error, got nil expected error")
+			}
+			if !test.expectedErr && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestOIDCExchange(t *testing.T) {
+
+	mockProvider, oidcConfig := newMockOIDCProvider(t)
+
+	testCases := []struct {
+		name         string
+		defaultRole  string
+		claims       map[string]interface{}
+		tokenNonce   string
+		expectedRole users.Role
+		expectedErr  error
+	}{
+		{
+			name:         "group mapping",
+			claims:       map[string]interface{}{"email": "viewer@example.com", "groups": []interface{}{"finala-viewers"}},
+			expectedRole: users.RoleViewer,
+		},
+		{
+			name:         "highest mapped role",
+			claims:       map[string]interface{}{"email": "admin@example.com", "groups": []interface{}{"finala-viewers", "finala-admins"}},
+			expectedRole: users.RoleAdmin,
+		},
+		{
+			name:         "claim mapping",
+			claims:       map[string]interface{}{"email": "analyst@example.com", "department": "finops"},
+			expectedRole: users.RoleAnalyst,
+		},
+		{
+			name:         "default role",
+			defaultRole:  "viewer",
+			claims:       map[string]interface{}{"email": "other@example.com", "groups": []interface{}{"other"}},
+			expectedRole: users.RoleViewer,
+		},
+		{
+			name:        "not mapped",
+			claims:      map[string]interface{}{"email": "other@example.com", "groups": []interface{}{"other"}},
+			expectedErr: auth.ErrOIDCNoRole,
+		},
+		{
+			name:        "nonce mismatch",
+			claims:      map[string]interface{}{"email": "admin@example.com", "groups": []interface{}{"finala-admins"}},
+			tokenNonce:  "other-nonce",
+			expectedErr: auth.ErrOIDCNonce,
+		},
+		{
+			name:        "unverified email",
+			claims:      map[string]interface{}{"email": "admin@example.com", "email_verified": false, "groups": []interface{}{"finala-admins"}},
+			expectedErr: errors.New("the id token email is not verified"),
+		},
+		{
+			name:        "missing username",
+			claims:      map[string]interface{}{"groups": []interface{}{"finala-admins"}},
+			expectedErr: errors.New("the id token has no email claim"),
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			testConfig := oidcConfig
+			testConfig.DefaultRole = test.defaultRole
+			provider, err := auth.NewOIDCProvider(context.Background(), testConfig)
+			if err != nil {
+				t.Fatalf("unexpected oidc provider error: %v", err)
+			}
+
+			mockProvider.SetClaims(test.claims)
+			mockProvider.SetNonce(test.tokenNonce)
+
+			verifier := oauth2.GenerateVerifier()
+			code := authorizationCode(t, provider.AuthCodeURL("state", "nonce", verifier))
+
+			identity, err := provider.Exchange(context.Background(), code, "nonce", verifier)
+			if test.expectedErr != nil {
+				if err == nil || (!errors.Is(err, test.expectedErr) && err.Error() != test.expectedErr.Error()) {
+					t.Fatalf("unexpected exchange error, got %v expected %v", err, test.expectedErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected exchange error: %v", err)
+			}
+			if identity.Username != test.claims["email"] {
+				t.Fatalf("unexpected username, got %s expected %s", identity.Username, test.claims["email"])
+			}
+			if identity.Role != test.expectedRole {
+				t.Fatalf("unexpected role, got %s expected %s", identity.Role, test.expectedRole)
+			}
+		})
+	}
+}
+
+func TestOIDCExchangeWrongVerifier(t *testing.T) {
+
+	mockProvider, oidcConfig := newMockOIDCProvider(t)
+	provider, err := auth.NewOIDCProvider(context.Background(), oidcConfig)
+	if err != nil {
+		t.Fatalf("unexpected oidc provider error: %v", err)
+	}
+	mockProvider.SetClaims(map[string]interface{}{"email": "admin@example.com", "groups": []interface{}{"finala-admins"}})
+
+	code := authorizationCode(t, provider.AuthCodeURL("state", "nonce", oauth2.GenerateVerifier()))
+	_, err = provider.Exchange(context.Background(), code, "nonce", oauth2.GenerateVerifier())
+	if err == nil {
+		t.Fatalf("unexpected exchange error, got nil expected error")
+	}
+}
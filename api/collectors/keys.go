@@ -0,0 +1,273 @@
+package collectors
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"finala/interpolation"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	// KeyHeader is the request header of the collector key
+	KeyHeader = "X-Collector-Key"
+
+	// keyIDLength is the number of random bytes of a key id
+	keyIDLength = 8
+
+	// keySecretLength is the number of random bytes of a key secret
+	keySecretLength = 32
+
+	// keySeparator separates the key id from the key secret
+	keySeparator = "."
+)
+
+var (
+	// ErrInvalidKey is returned when the collector key is malformed, unknown or revoked
+	ErrInvalidKey = errors.New("invalid collector key")
+
+	// ErrKeyNotFound is returned when the collector key id does not exist
+	ErrKeyNotFound = errors.New("collector key not found")
+)
+
+// ValidationError is returned when the collector key fields are invalid
+type ValidationError struct {
+	Message string
+}
+
+func (e *ValidationError) Error() string {
+	return e.Message
+}
+
+// Key describes a collector api key. Only the secret hash is stored, the secret is returned once when the key is issued
+type Key struct {
+	ID            string    `yaml:"id" json:"id"`
+	CollectorName string    `yaml:"collector_name" json:"collector_name"`
+	SecretHash    string    `yaml:"secret_hash" json:"-"`
+	CreatedAt     time.Time `yaml:"created_at" json:"created_at"`
+}
+
+// keysFile describes the collector keys file structure
+type keysFile struct {
+	Keys []Key `yaml:"keys"`
+}
+
+// KeyStore manages the collector api keys, and persists them with their secret hashes to a yaml file
+type KeyStore struct {
+	mu   sync.RWMutex
+	path string
+	keys map[string]Key
+}
+
+// NewKeyStore loads the collector keys from the given file path. A missing file is loaded as an empty store
+func NewKeyStore(path string) (*KeyStore, error) {
+
+	store := &KeyStore{
+		path: path,
+		keys: map[string]Key{},
+	}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return store, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	file := keysFile{}
+	err = yaml.Unmarshal(data, &file)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse collector keys file %s: %w", path, err)
+	}
+
+	for _, key := range file.Keys {
+		store.keys[key.ID] = key
+	}
+
+	return store, nil
+}
+
+// Issue creates a new key of the given collector name, and returns it with its secret value.
+// The secret value is not stored, and can not be returned again
+func (ks *KeyStore) Issue(collectorName string) (Key, string, error) {
+
+	collectorName = strings.TrimSpace(collectorName)
+	if collectorName == "" || strings.ContainsAny(collectorName, " \t\r\n") {
+		return Key{}, "", &ValidationError{Message: "collector_name is required and must not contain whitespaces"}
+	}
+
+	id, err := randomString(keyIDLength, hex.EncodeToString)
+	if err != nil {
+		return Key{}, "", err
+	}
+	secret, err := randomString(keySecretLength, base64.RawURLEncoding.EncodeToString)
+	if err != nil {
+		return Key{}, "", err
+	}
+
+	key := Key{
+		ID:            id,
+		CollectorName: collectorName,
+		SecretHash:    hashSecret(secret),
+		CreatedAt:     time.Now().UTC(),
+	}
+
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+
+	ks.keys[id] = key
+	err = ks.save()
+	if err != nil {
+		delete(ks.keys, id)
+		return Key{}, "", err
+	}
+
+	return key, id + keySeparator + secret, nil
+}
+
+// Authenticate returns the key of the given secret value
+func (ks *KeyStore) Authenticate(value string) (Key, error) {
+
+	id, secret, found := strings.Cut(value, keySeparator)
+	if !found || id == "" || secret == "" {
+		return Key{}, ErrInvalidKey
+	}
+
+	ks.mu.RLock()
+	key, found := ks.keys[id]
+	ks.mu.RUnlock()
+	if !found {
+		return Key{}, ErrInvalidKey
+	}
+
+	if subtle.ConstantTimeCompare([]byte(key.SecretHash), []byte(hashSecret(secret))) != 1 {
+		return Key{}, ErrInvalidKey
+	}
+	return key, nil
+}
+
+// List returns all the keys sorted by their collector name and creation time
+func (ks *KeyStore) List() []Key {
+
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+
+	keys := make([]Key, 0, len(ks.keys))
+	for _, key := range ks.keys {
+		keys = append(keys, key)
+	}
+	sortKeys(keys)
+	return keys
+}
+
+// Revoke removes the key of the given id, the collectors using it are rejected immediately
+func (ks *KeyStore) Revoke(id string) error {
+
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+
+	key, found := ks.keys[id]
+	if !found {
+		return ErrKeyNotFound
+	}
+	delete(ks.keys, id)
+
+	err := ks.save()
+	if err != nil {
+		ks.keys[id] = key
+		return err
+	}
+	return nil
+}
+
+// save writes the keys to the keys file, the caller must hold the lock.
+// The file is replaced atomically, and is readable only by its owner
+func (ks *KeyStore) save() error {
+
+	file := keysFile{Keys: make([]Key, 0, len(ks.keys))}
+	for _, key := range ks.keys {
+		file.Keys = append(file.Keys, key)
+	}
+	sortKeys(file.Keys)
+
+	data, err := yaml.Marshal(&file)
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(filepath.Dir(ks.path), 0700)
+	if err != nil {
+		return err
+	}
+
+	tmpFile, err := os.CreateTemp(filepath.Dir(ks.path), ".collector-keys-*.yaml")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmpFile.Name())
+
+	_, err = tmpFile.Write(data)
+	if closeErr := tmpFile.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(tmpFile.Name(), ks.path)
+}
+
+// ExecutionCollectorName returns the collector name of the given execution id.
+// The collectors name their executions `<collector name>_<unix time>`, so the execution ids which do not end with a
+// unix time have no collector name. Otherwise the key of the `prod` collector would match the `prod_staging` executions
+func ExecutionCollectorName(executionID string) string {
+	name, err := interpolation.ExtractExecutionName(executionID)
+	if err != nil {
+		return ""
+	}
+	// ParseUint accepts only digits, unlike ParseInt which accepts a sign too
+	timestamp, err := strconv.ParseUint(executionID[len(name)+1:], 10, 64)
+	if err != nil || timestamp == 0 {
+		return ""
+	}
+	return name
+}
+
+// sortKeys sorts the given keys by their collector name and creation time
+func sortKeys(keys []Key) {
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].CollectorName != keys[j].CollectorName {
+			return keys[i].CollectorName < keys[j].CollectorName
+		}
+		return keys[i].CreatedAt.Before(keys[j].CreatedAt)
+	})
+}
+
+// hashSecret returns the sha256 hash of the given key secret. The secrets are random, so they need no slow hash
+func hashSecret(secret string) string {
+	hash := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(hash[:])
+}
+
+// randomString returns the encoding of the given number of random bytes
+func randomString(length int, encode func([]byte) string) (string, error) {
+	b := make([]byte, length)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", err
+	}
+	return encode(b), nil
+}
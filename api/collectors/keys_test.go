@@ -0,0 +1,145 @@
+package collectors_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"finala/api/collectors"
+)
+
+func newKeyStore(t *testing.T) (*collectors.KeyStore, string) {
+	path := filepath.Join(t.TempDir(), "collector_keys.yaml")
+	store, err := collectors.NewKeyStore(path)
+	if err != nil {
+		t.Fatalf("unexpected key store error: %v", err)
+	}
+	return store, path
+}
+
+func TestIssue(t *testing.T) {
+
+	store, path := newKeyStore(t)
+
+	key, secret, err := store.Issue("general")
+	if err != nil {
+		t.Fatalf("unexpected issue error: %v", err)
+	}
+	if key.CollectorName != "general" || key.ID == "" || !strings.HasPrefix(secret, key.ID+".") {
+		t.Fatalf("unexpected issued key, got %+v %s", key, secret)
+	}
+
+	// Only the secret hash is written to the keys file
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("unexpected read error: %v", err)
+	}
+	if strings.Contains(string(data), strings.TrimPrefix(secret, key.ID+".")) {
+		t.Fatalf("keys file contains the key secret")
+	}
+
+	reloaded, err := collectors.NewKeyStore(path)
+	if err != nil {
+		t.Fatalf("unexpected key store error: %v", err)
+	}
+	authenticated, err := reloaded.Authenticate(secret)
+	if err != nil {
+		t.Fatalf("unexpected authenticate error: %v", err)
+	}
+	if authenticated.CollectorName != "general" {
+		t.Fatalf("unexpected collector name, got %s expected %s", authenticated.CollectorName, "general")
+	}
+
+	var validationErr *collectors.ValidationError
+	if _, _, err := store.Issue("my collector"); !errors.As(err, &validationErr) {
+		t.Fatalf("unexpected issue error, got %v expected validation error", err)
+	}
+}
+
+func TestAuthenticateKey(t *testing.T) {
+
+	store, _ := newKeyStore(t)
+	key, secret, err := store.Issue("general")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		name        string
+		value       string
+		expectedErr error
+	}{
+		{"valid key", secret, nil},
+		{"wrong secret", key.ID + ".wrong", collectors.ErrInvalidKey},
+		{"unknown id", "unknown." + strings.TrimPrefix(secret, key.ID+"."), collectors.ErrInvalidKey},
+		{"malformed", "malformed", collectors.ErrInvalidKey},
+		{"empty", "", collectors.ErrInvalidKey},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := store.Authenticate(test.value)
+			if !errors.Is(err, test.expectedErr) {
+				t.Fatalf("unexpected authenticate error, got %v expected %v", err, test.expectedErr)
+			}
+		})
+	}
+}
+
+func TestRevoke(t *testing.T) {
+
+	store, path := newKeyStore(t)
+	key, secret, err := store.Issue("general")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := store.Issue("staging"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := store.Revoke(key.ID); err != nil {
+		t.Fatalf("unexpected revoke error: %v", err)
+	}
+	if _, err := store.Authenticate(secret); !errors.Is(err, collectors.ErrInvalidKey) {
+		t.Fatalf("unexpected authenticate error, got %v expected %v", err, collectors.ErrInvalidKey)
+	}
+	if err := store.Revoke(key.ID); !errors.Is(err, collectors.ErrKeyNotFound) {
+		t.Fatalf("unexpected revoke error, got %v expected %v", err, collectors.ErrKeyNotFound)
+	}
+
+	reloaded, err := collectors.NewKeyStore(path)
+	if err != nil {
+		t.Fatalf("unexpected key store error: %v", err)
+	}
+	keys := reloaded.List()
+	if len(keys) != 1 || keys[0].CollectorName != "staging" {
+		t.Fatalf("unexpected keys after revoke, got %+v", keys)
+	}
+}
+
+func TestExecutionCollectorName(t *testing.T) {
+
+	testCases := []struct {
+		executionID  string
+		expectedName string
+	}{
+		{"general_1600000000", "general"},
+		{"prod_us_1600000000", "prod_us"},
+		{"general", ""},
+		{"_1600000000", ""},
+		{"prod_staging", ""},
+		{"prod_1600000000_staging", ""},
+		{"prod_-1600000000", ""},
+		{"prod_+1600000000", ""},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.executionID, func(t *testing.T) {
+			if name := collectors.ExecutionCollectorName(test.executionID); name != test.expectedName {
+				t.Fatalf("unexpected collector name, got %q expected %q", name, test.expectedName)
+			}
+		})
+	}
+}
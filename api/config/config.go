@@ -3,6 +3,7 @@ package config
 import (
 	"os"
 	"strings"
+	"time"
 
 	"gopkg.in/yaml.v2"
 
@@ -36,11 +37,58 @@ type EmailConfig struct {
 	SMTPPort   string `yaml:"smtpPort"`
 }
 
+// JWTSigningKeyConfig describe a single jwt signing key
+type JWTSigningKeyConfig struct {
+	ID  string `yaml:"id"`
+	Key string `yaml:"key"`
+}
+
+// JWTConfig describe the api jwt authentication configuration.
+// The first signing key signs new tokens, all the keys are accepted when validating tokens
+type JWTConfig struct {
+	SigningKeys []JWTSigningKeyConfig `yaml:"signing_keys"`
+	Expiry      time.Duration         `yaml:"expiry"`
+}
+
+// AuthConfig describe the api users and collector keys configuration.
+// The username and the password are used only to create the first admin user, when the users file has no users
+type AuthConfig struct {
+	Username          string `yaml:"username"`
+	Password          string `yaml:"password"`
+	UsersFile         string `yaml:"users_file"`
+	CollectorKeysFile string `yaml:"collector_keys_file"`
+}
+
+// OIDCRoleMappingConfig maps the users with the given claim value to a role
+type OIDCRoleMappingConfig struct {
+	Claim string `yaml:"claim"`
+	Value string `yaml:"value"`
+	Role  string `yaml:"role"`
+}
+
+// OIDCConfig describe the OIDC single sign-on configuration, the sign-on is enabled when an issuer url is configured
+type OIDCConfig struct {
+	IssuerURL            string                  `yaml:"issuer_url"`
+	ClientID             string                  `yaml:"client_id"`
+	ClientSecret         string                  `yaml:"client_secret"`
+	RedirectURL          string                  `yaml:"redirect_url"`
+	UIRedirectURL        string                  `yaml:"ui_redirect_url"`
+	Scopes               []string                `yaml:"scopes"`
+	UsernameClaim        string                  `yaml:"username_claim"`
+	GroupsClaim          string                  `yaml:"groups_claim"`
+	RoleMappings         []OIDCRoleMappingConfig `yaml:"role_mappings"`
+	DefaultRole          string                  `yaml:"default_role"`
+	DisablePasswordLogin bool                    `yaml:"disable_password_login"`
+}
+
 // APIConfig present the application config
 type APIConfig struct {
 	LogLevel string        `yaml:"log_level"`
 	Storage  StorageConfig `yaml:"storage"`
 	SMTPConf EmailConfig   `yaml:"smtp"`
+	Auth     AuthConfig    `yaml:"auth"`
+	JWT      JWTConfig     `yaml:"jwt"`
+	OIDC     OIDCConfig    `yaml:"oidc"`
 }
 
 // SendEmail struct describes the email sending parameters
@@ -83,5 +131,39 @@ func LoadAPI(location string) (APIConfig, error) {
 		config.Storage.Meilisearch.Password = overrideStoragePassword
 	}
 
+	overrideOIDCClientSecret := os.Getenv("OVERRIDE_OIDC_CLIENT_SECRET")
+	if overrideOIDCClientSecret != "" {
+		log.WithFields(log.Fields{
+			"environment_variable": "OVERRIDE_OIDC_CLIENT_SECRET",
+		}).Info("override oidc client secret")
+		config.OIDC.ClientSecret = overrideOIDCClientSecret
+	}
+
+	overrideJWTSigningKeys := os.Getenv("OVERRIDE_JWT_SIGNING_KEYS")
+	if overrideJWTSigningKeys != "" {
+		log.WithFields(log.Fields{
+			"environment_variable": "OVERRIDE_JWT_SIGNING_KEYS",
+		}).Info("override jwt signing keys")
+		config.JWT.SigningKeys = parseJWTSigningKeys(overrideJWTSigningKeys)
+	}
+
 	return config, nil
 }
+
+// parseJWTSigningKeys parses a comma separated list of signing keys, each key is given as `id:key` or as a key without an id
+func parseJWTSigningKeys(value string) []JWTSigningKeyConfig {
+	signingKeys := []JWTSigningKeyConfig{}
+	for _, signingKey := range strings.Split(value, ",") {
+		signingKey = strings.TrimSpace(signingKey)
+		if signingKey == "" {
+			continue
+		}
+		keyParts := strings.SplitN(signingKey, ":", 2)
+		if len(keyParts) == 1 {
+			signingKeys = append(signingKeys, JWTSigningKeyConfig{Key: keyParts[0]})
+			continue
+		}
+		signingKeys = append(signingKeys, JWTSigningKeyConfig{ID: keyParts[0], Key: keyParts[1]})
+	}
+	return signingKeys
+}
@@ -6,6 +6,7 @@ import (
 	"reflect"
 	"runtime"
 	"testing"
+	"time"
 
 	"finala/api/config"
 )
@@ -28,6 +29,68 @@ func TestConfig(t *testing.T) {
 		}
 	})
 
+	t.Run("jwt", func(t *testing.T) {
+		apiConfig, err := config.LoadAPI(fmt.Sprintf("%s/testutil/mock/config.yaml", currentFolderPath))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if apiConfig.JWT.Expiry != 12*time.Hour {
+			t.Fatalf("unexpected jwt expiry, got %s expected %s", apiConfig.JWT.Expiry, 12*time.Hour)
+		}
+		if len(apiConfig.JWT.SigningKeys) != 2 || apiConfig.JWT.SigningKeys[0].ID != "current" || apiConfig.JWT.SigningKeys[1].ID != "previous" {
+			t.Fatalf("unexpected jwt signing keys, got %+v", apiConfig.JWT.SigningKeys)
+		}
+	})
+
+	t.Run("jwt_signing_keys_override", func(t *testing.T) {
+		t.Setenv("OVERRIDE_JWT_SIGNING_KEYS", "new:new-signing-key, old:old:signing-key,no-id-signing-key")
+
+		apiConfig, err := config.LoadAPI(fmt.Sprintf("%s/testutil/mock/config.yaml", currentFolderPath))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		expectedSigningKeys := []config.JWTSigningKeyConfig{
+			{ID: "new", Key: "new-signing-key"},
+			{ID: "old", Key: "old:signing-key"},
+			{Key: "no-id-signing-key"},
+		}
+		if !reflect.DeepEqual(apiConfig.JWT.SigningKeys, expectedSigningKeys) {
+			t.Fatalf("unexpected jwt signing keys, got %+v expected %+v", apiConfig.JWT.SigningKeys, expectedSigningKeys)
+		}
+	})
+
+	t.Run("oidc", func(t *testing.T) {
+		apiConfig, err := config.LoadAPI(fmt.Sprintf("%s/testutil/mock/config.yaml", currentFolderPath))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		expectedRoleMappings := []config.OIDCRoleMappingConfig{
+			{Value: "finala-admins", Role: "admin"},
+			{Claim: "department", Value: "finops", Role: "analyst"},
+		}
+		if !reflect.DeepEqual(apiConfig.OIDC.RoleMappings, expectedRoleMappings) {
+			t.Fatalf("unexpected oidc role mappings, got %+v expected %+v", apiConfig.OIDC.RoleMappings, expectedRoleMappings)
+		}
+		if apiConfig.OIDC.DefaultRole != "viewer" || apiConfig.OIDC.ClientSecret != "finala-secret" {
+			t.Fatalf("unexpected oidc configuration, got %+v", apiConfig.OIDC)
+		}
+	})
+
+	t.Run("oidc_client_secret_override", func(t *testing.T) {
+		t.Setenv("OVERRIDE_OIDC_CLIENT_SECRET", "override-secret")
+
+		apiConfig, err := config.LoadAPI(fmt.Sprintf("%s/testutil/mock/config.yaml", currentFolderPath))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if apiConfig.OIDC.ClientSecret != "override-secret" {
+			t.Fatalf("unexpected oidc client secret, got %s expected %s", apiConfig.OIDC.ClientSecret, "override-secret")
+		}
+	})
+
 	t.Run("invalid_config", func(t *testing.T) {
 		_, err := config.LoadAPI(fmt.Sprintf("%s/testutil/mock/config1.yaml", currentFolderPath))
 
@@ -25,12 +25,17 @@ const (
 	marginH = 10.0
 	lineHt  = 3.0
 	cellGap = 0.5
+
+	// consoleURLColumn describes the resource aws console deep link column, presented as a link
+	consoleURLColumn = "ConsoleURL"
+	consoleURLText   = "Open in console"
 )
 
 type cellType struct {
 	str  string
 	list [][]byte
 	ht   float64
+	link string
 }
 
 var cell cellType
@@ -95,6 +100,11 @@ func CreatePDF(pdfFileName, description string, data []map[string]interface{}, s
 				var cellList []cellType
 				for colJ, orderKey := range orderKeys {
 					cell.str = fmt.Sprintf("%v", colValues[orderKey])
+					cell.link = ""
+					if consoleURL, ok := colValues[orderKey].(string); ok && orderKey == consoleURLColumn && consoleURL != "" {
+						cell.str = consoleURLText
+						cell.link = consoleURL
+					}
 					cell.list = pdf.SplitLines([]byte(cell.str), columnWidths[colJ]-cellGap-cellGap)
 					cell.ht = (float64(len(cell.list)) * lineHt) + 3
 					if cell.ht > maxHt {
@@ -116,6 +126,9 @@ func CreatePDF(pdfFileName, description string, data []map[string]interface{}, s
 						cellY = y + cellGap + (maxHt-cell.ht)/2
 					}
 					pdf.Rect(x, y, columnWidths[colJ], maxHt+cellGap+cellGap, "D")
+					if cell.link != "" {
+						pdf.LinkString(x, y, columnWidths[colJ], maxHt+cellGap+cellGap, cell.link)
+					}
 
 					for splitJ := 0; splitJ < len(cell.list); splitJ++ {
 						pdf.SetXY(x+cellGap, cellY)
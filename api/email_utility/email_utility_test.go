@@ -1,6 +1,10 @@
 package email_utility
 
 import (
+	"finala/api/config"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -19,3 +23,25 @@ func TestSendEmail(t *testing.T) {
 		t.Errorf("Expected no error , got %v", err)
 	}
 }
+
+func TestCreatePDF(t *testing.T) {
+	pdfFileName := filepath.Join(t.TempDir(), "report.pdf")
+	data := []map[string]interface{}{
+		{
+			"Data": map[string]interface{}{
+				"ResourceID": "i-1",
+				"ConsoleURL": "https://console.aws.amazon.com/ec2/home?region=us-east-1#InstanceDetails:instanceId=i-1",
+			},
+		},
+	}
+
+	CreatePDF(pdfFileName, "description", data, config.SendEmailInfo{ResourceType: "aws_ec2"})
+
+	content, err := os.ReadFile(pdfFileName)
+	if err != nil {
+		t.Fatalf("unexpected error reading the pdf, got %v expected nil", err)
+	}
+	if !strings.Contains(string(content), "InstanceDetails:instanceId=i-1") {
+		t.Fatalf("unexpected pdf content, expected console url link")
+	}
+}
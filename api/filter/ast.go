@@ -0,0 +1,146 @@
+package filter
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Operator describes the comparison operator of a field and a value
+type Operator string
+
+const (
+	// OperatorEqual matches the fields which are equal to the value
+	OperatorEqual Operator = "="
+
+	// OperatorNotEqual matches the fields which are not equal to the value
+	OperatorNotEqual Operator = "!="
+
+	// OperatorGreater matches the fields which are greater than the number
+	OperatorGreater Operator = ">"
+
+	// OperatorGreaterOrEqual matches the fields which are greater than or equal to the number
+	OperatorGreaterOrEqual Operator = ">="
+
+	// OperatorLess matches the fields which are less than the number
+	OperatorLess Operator = "<"
+
+	// OperatorLessOrEqual matches the fields which are less than or equal to the number
+	OperatorLessOrEqual Operator = "<="
+)
+
+// IsOrdering returns true if the operator compares the order of numbers
+func (operator Operator) IsOrdering() bool {
+	switch operator {
+	case OperatorGreater, OperatorGreaterOrEqual, OperatorLess, OperatorLessOrEqual:
+		return true
+	}
+	return false
+}
+
+// ValueKind describes the type of a filter value
+type ValueKind int
+
+const (
+	// StringValue describes a quoted value, or a word which is not a number
+	StringValue ValueKind = iota
+
+	// NumberValue describes an unquoted number
+	NumberValue
+)
+
+// Value describes a literal value of a filter. Text holds the value as written, without its quotes
+type Value struct {
+	Kind   ValueKind
+	Text   string
+	Number float64
+}
+
+// String returns the value in the filter syntax
+func (value Value) String() string {
+	if value.Kind == NumberValue {
+		return strconv.FormatFloat(value.Number, 'f', -1, 64)
+	}
+	return strconv.Quote(value.Text)
+}
+
+// Node describes a node of a parsed filter expression. String returns the node in the filter syntax
+type Node interface {
+	String() string
+}
+
+// And matches the resources both of its nodes match
+type And struct {
+	Left  Node
+	Right Node
+}
+
+// String returns the node in the filter syntax
+func (node And) String() string {
+	return fmt.Sprintf("(%s AND %s)", node.Left, node.Right)
+}
+
+// Or matches the resources either of its nodes match
+type Or struct {
+	Left  Node
+	Right Node
+}
+
+// String returns the node in the filter syntax
+func (node Or) String() string {
+	return fmt.Sprintf("(%s OR %s)", node.Left, node.Right)
+}
+
+// Not matches the resources its node does not match
+type Not struct {
+	Node Node
+}
+
+// String returns the node in the filter syntax
+func (node Not) String() string {
+	return fmt.Sprintf("NOT %s", node.Node)
+}
+
+// Comparison matches the resources whose field compares to the value by the operator
+type Comparison struct {
+	Field    string
+	Operator Operator
+	Value    Value
+}
+
+// String returns the node in the filter syntax
+func (node Comparison) String() string {
+	return fmt.Sprintf("%s %s %s", node.Field, node.Operator, node.Value)
+}
+
+// In matches the resources whose field is equal to one of the values
+type In struct {
+	Field  string
+	Values []Value
+}
+
+// String returns the node in the filter syntax
+func (node In) String() string {
+	values := make([]string, len(node.Values))
+	for i, value := range node.Values {
+		values[i] = value.String()
+	}
+	return fmt.Sprintf("%s IN (%s)", node.Field, strings.Join(values, ", "))
+}
+
+// All returns a node which matches the resources all the given nodes match, ignoring the nil nodes.
+// It returns nil when there are no nodes
+func All(nodes ...Node) Node {
+	var all Node
+	for _, node := range nodes {
+		if node == nil {
+			continue
+		}
+		if all == nil {
+			all = node
+		} else {
+			all = And{Left: all, Right: node}
+		}
+	}
+	return all
+}
@@ -0,0 +1,50 @@
+package filter_test
+
+import (
+	"finala/api/filter"
+	"testing"
+)
+
+func TestAll(t *testing.T) {
+
+	a := filter.Comparison{Field: "Data.A", Operator: filter.OperatorEqual, Value: filter.Value{Kind: filter.StringValue, Text: "a"}}
+	b := filter.Comparison{Field: "Data.B", Operator: filter.OperatorLess, Value: filter.Value{Kind: filter.NumberValue, Text: "2", Number: 2}}
+
+	testCases := []struct {
+		name     string
+		nodes    []filter.Node
+		expected string
+	}{
+		{"none", []filter.Node{}, ""},
+		{"nil nodes", []filter.Node{nil, nil}, ""},
+		{"single", []filter.Node{nil, a}, `Data.A = "a"`},
+		{"multiple", []filter.Node{a, nil, b}, `(Data.A = "a" AND Data.B < 2)`},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			node := filter.All(test.nodes...)
+			result := ""
+			if node != nil {
+				result = node.String()
+			}
+			if result != test.expected {
+				t.Fatalf("unexpected filter, got %s expected %s", result, test.expected)
+			}
+		})
+	}
+}
+
+func TestOperatorIsOrdering(t *testing.T) {
+
+	for _, operator := range []filter.Operator{filter.OperatorGreater, filter.OperatorGreaterOrEqual, filter.OperatorLess, filter.OperatorLessOrEqual} {
+		if !operator.IsOrdering() {
+			t.Fatalf("expected %s to be an ordering operator", operator)
+		}
+	}
+	for _, operator := range []filter.Operator{filter.OperatorEqual, filter.OperatorNotEqual} {
+		if operator.IsOrdering() {
+			t.Fatalf("expected %s not to be an ordering operator", operator)
+		}
+	}
+}
@@ -0,0 +1,457 @@
+package filter
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	// maxExpressionLength defines the maximum length of a filter expression
+	maxExpressionLength = 4096
+
+	// maxDepth defines the maximum nesting of the parentheses and the NOT operators of a filter expression
+	maxDepth = 32
+
+	// dataPrefix prefixes the fields of the detected resources data
+	dataPrefix = "Data."
+)
+
+// eventFields defines the fields of the events which are not prefixed by the data prefix
+var eventFields = map[string]struct{}{
+	"ExecutionID":  {},
+	"ResourceName": {},
+	"EventType":    {},
+}
+
+// ParseError is returned when a filter expression is invalid. Position is the 1-based character position of the error
+type ParseError struct {
+	Position int
+	Message  string
+}
+
+// Error returns the parse error message with its position
+func (err *ParseError) Error() string {
+	return fmt.Sprintf("%s at position %d", err.Message, err.Position)
+}
+
+// tokenKind describes the type of a filter expression token
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenWord
+	tokenString
+	tokenOperator
+	tokenOpen
+	tokenClose
+	tokenComma
+)
+
+// token describes a token of a filter expression, and its 1-based character position
+type token struct {
+	kind     tokenKind
+	text     string
+	position int
+}
+
+// describe returns the token as it is described in the parse errors
+func (t token) describe() string {
+	if t.kind == tokenEOF {
+		return "end of filter"
+	}
+	if t.kind == tokenString {
+		return strconv.Quote(t.text)
+	}
+	return fmt.Sprintf("%q", t.text)
+}
+
+// isKeyword returns true if the token is the given keyword, regardless of its case
+func (t token) isKeyword(keyword string) bool {
+	return t.kind == tokenWord && strings.EqualFold(t.text, keyword)
+}
+
+// Parse parses a filter expression such as `Data.PricePerMonth > 100 AND Region IN (us-east-1, eu-west-1)`.
+// The fields are the detected resources data fields, with an optional "Data." prefix. The values are numbers,
+// quoted strings or unquoted words. It returns nil when the expression is empty
+func Parse(expression string) (Node, error) {
+	if len(expression) > maxExpressionLength {
+		return nil, &ParseError{Position: maxExpressionLength + 1, Message: fmt.Sprintf("filter is longer than %d characters", maxExpressionLength)}
+	}
+
+	tokens, err := tokenize(expression)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 1 {
+		return nil, nil
+	}
+
+	p := &parser{tokens: tokens}
+	node, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if next := p.peek(); next.kind != tokenEOF {
+		return nil, p.errorf(next, "unexpected %s", next.describe())
+	}
+	return node, nil
+}
+
+// FromEquals returns a node which matches the resources whose fields are equal to the given values.
+// A value of comma separated values matches any of them. It returns nil when there are no filters
+func FromEquals(filters map[string]string) (Node, error) {
+	fields := make([]string, 0, len(filters))
+	for field := range filters {
+		fields = append(fields, field)
+	}
+	// The nodes are sorted by their field, so the same filters return the same node
+	sort.Strings(fields)
+
+	nodes := []Node{}
+	for _, field := range fields {
+		if !isField(field) {
+			return nil, fmt.Errorf("invalid filter field %q", field)
+		}
+		values := []Value{}
+		for _, text := range strings.Split(filters[field], ",") {
+			if text = strings.TrimSpace(text); text != "" {
+				values = append(values, Value{Kind: StringValue, Text: text})
+			}
+		}
+		switch len(values) {
+		case 0:
+			continue
+		case 1:
+			nodes = append(nodes, Comparison{Field: normalizeField(field), Operator: OperatorEqual, Value: values[0]})
+		default:
+			nodes = append(nodes, In{Field: normalizeField(field), Values: values})
+		}
+	}
+	return All(nodes...), nil
+}
+
+// parser is a recursive descent parser of the filter expression tokens
+type parser struct {
+	tokens []token
+	next   int
+	depth  int
+}
+
+// peek returns the next token without consuming it
+func (p *parser) peek() token {
+	return p.tokens[p.next]
+}
+
+// consume returns the next token, and moves to the token after it
+func (p *parser) consume() token {
+	t := p.tokens[p.next]
+	if t.kind != tokenEOF {
+		p.next++
+	}
+	return t
+}
+
+// errorf returns a parse error at the position of the given token
+func (p *parser) errorf(t token, format string, args ...interface{}) error {
+	return &ParseError{Position: t.position, Message: fmt.Sprintf(format, args...)}
+}
+
+// enter increases the nesting depth, and returns an error when the expression is nested too deep
+func (p *parser) enter(t token) error {
+	p.depth++
+	if p.depth > maxDepth {
+		return p.errorf(t, "filter is nested deeper than %d levels", maxDepth)
+	}
+	return nil
+}
+
+// parseOr parses the OR separated expressions
+func (p *parser) parseOr() (Node, error) {
+	node, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().isKeyword("OR") {
+		p.consume()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		node = Or{Left: node, Right: right}
+	}
+	return node, nil
+}
+
+// parseAnd parses the AND separated expressions
+func (p *parser) parseAnd() (Node, error) {
+	node, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().isKeyword("AND") {
+		p.consume()
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		node = And{Left: node, Right: right}
+	}
+	return node, nil
+}
+
+// parseUnary parses a negated expression, a parenthesized expression or a comparison
+func (p *parser) parseUnary() (Node, error) {
+	next := p.peek()
+	switch {
+	case next.isKeyword("NOT"):
+		p.consume()
+		if err := p.enter(next); err != nil {
+			return nil, err
+		}
+		node, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		p.depth--
+		return Not{Node: node}, nil
+	case next.kind == tokenOpen:
+		p.consume()
+		if err := p.enter(next); err != nil {
+			return nil, err
+		}
+		node, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if closing := p.consume(); closing.kind != tokenClose {
+			return nil, p.errorf(closing, "expected \")\" but found %s", closing.describe())
+		}
+		p.depth--
+		return node, nil
+	}
+	return p.parseComparison()
+}
+
+// parseComparison parses a field compared to a value, or a field in a list of values
+func (p *parser) parseComparison() (Node, error) {
+	fieldToken := p.consume()
+	if fieldToken.kind != tokenWord || isKeyword(fieldToken.text) || !isField(fieldToken.text) {
+		return nil, p.errorf(fieldToken, "expected a field but found %s", fieldToken.describe())
+	}
+	field := normalizeField(fieldToken.text)
+
+	operatorToken := p.consume()
+	switch {
+	case operatorToken.kind == tokenOperator:
+		operator := Operator(operatorToken.text)
+		valueToken := p.peek()
+		value, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		if operator.IsOrdering() && value.Kind != NumberValue {
+			return nil, p.errorf(valueToken, "operator %s expects a number but found %s", operator, valueToken.describe())
+		}
+		return Comparison{Field: field, Operator: operator, Value: value}, nil
+	case operatorToken.isKeyword("IN"):
+		values, err := p.parseValues()
+		if err != nil {
+			return nil, err
+		}
+		return In{Field: field, Values: values}, nil
+	case operatorToken.isKeyword("NOT") && p.peek().isKeyword("IN"):
+		p.consume()
+		values, err := p.parseValues()
+		if err != nil {
+			return nil, err
+		}
+		return Not{Node: In{Field: field, Values: values}}, nil
+	}
+	return nil, p.errorf(operatorToken, "expected an operator but found %s", operatorToken.describe())
+}
+
+// parseValues parses a parenthesized list of comma separated values
+func (p *parser) parseValues() ([]Value, error) {
+	if open := p.consume(); open.kind != tokenOpen {
+		return nil, p.errorf(open, "expected \"(\" but found %s", open.describe())
+	}
+	values := []Value{}
+	for {
+		value, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+
+		separator := p.consume()
+		if separator.kind == tokenClose {
+			return values, nil
+		}
+		if separator.kind != tokenComma {
+			return nil, p.errorf(separator, "expected \",\" or \")\" but found %s", separator.describe())
+		}
+	}
+}
+
+// parseValue parses a quoted string, a number or an unquoted word
+func (p *parser) parseValue() (Value, error) {
+	t := p.consume()
+	switch {
+	case t.kind == tokenString:
+		return Value{Kind: StringValue, Text: t.text}, nil
+	case t.kind == tokenWord && !isKeyword(t.text):
+		if number, ok := parseNumber(t.text); ok {
+			return Value{Kind: NumberValue, Text: t.text, Number: number}, nil
+		}
+		return Value{Kind: StringValue, Text: t.text}, nil
+	}
+	return Value{}, p.errorf(t, "expected a value but found %s", t.describe())
+}
+
+// tokenize splits the filter expression into tokens, ending with an end of filter token
+func tokenize(expression string) ([]token, error) {
+	tokens := []token{}
+	position := 1
+	for i := 0; i < len(expression); {
+		r, size := utf8.DecodeRuneInString(expression[i:])
+		start := position
+		switch {
+		case r == ' ' || r == '\t' || r == '\n' || r == '\r':
+			i += size
+			position++
+			continue
+		case r == '(':
+			tokens = append(tokens, token{kind: tokenOpen, text: "(", position: start})
+		case r == ')':
+			tokens = append(tokens, token{kind: tokenClose, text: ")", position: start})
+		case r == ',':
+			tokens = append(tokens, token{kind: tokenComma, text: ",", position: start})
+		case r == '=':
+			tokens = append(tokens, token{kind: tokenOperator, text: "=", position: start})
+		case r == '!' || r == '>' || r == '<':
+			operator := string(r)
+			if i+1 < len(expression) && expression[i+1] == '=' {
+				operator += "="
+			}
+			if operator == "!" {
+				return nil, &ParseError{Position: start, Message: "expected \"!=\""}
+			}
+			tokens = append(tokens, token{kind: tokenOperator, text: operator, position: start})
+			i += len(operator)
+			position += len(operator)
+			continue
+		case r == '"':
+			text, length, characters, err := readString(expression[i:], start)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, token{kind: tokenString, text: text, position: start})
+			i += length
+			position += characters
+			continue
+		case isWordRune(r):
+			length, characters := 0, 0
+			for i+length < len(expression) {
+				wordRune, wordSize := utf8.DecodeRuneInString(expression[i+length:])
+				if !isWordRune(wordRune) {
+					break
+				}
+				length += wordSize
+				characters++
+			}
+			tokens = append(tokens, token{kind: tokenWord, text: expression[i : i+length], position: start})
+			i += length
+			position += characters
+			continue
+		default:
+			return nil, &ParseError{Position: start, Message: fmt.Sprintf("unexpected character %q", r)}
+		}
+		i += size
+		position++
+	}
+	return append(tokens, token{kind: tokenEOF, position: position}), nil
+}
+
+// readString reads a double quoted string with backslash escaped quotes and backslashes. It returns the unquoted
+// string, and the length of the quoted string in bytes and in characters
+func readString(expression string, position int) (string, int, int, error) {
+	var text strings.Builder
+	characters := 1
+	for i := 1; i < len(expression); {
+		r, size := utf8.DecodeRuneInString(expression[i:])
+		characters++
+		switch r {
+		case '"':
+			return text.String(), i + size, characters, nil
+		case '\\':
+			if i+size >= len(expression) {
+				return "", 0, 0, &ParseError{Position: position, Message: "unterminated string"}
+			}
+			escaped, escapedSize := utf8.DecodeRuneInString(expression[i+size:])
+			if escaped != '"' && escaped != '\\' {
+				return "", 0, 0, &ParseError{Position: position + characters - 1, Message: fmt.Sprintf("invalid escape \"\\%c\"", escaped)}
+			}
+			text.WriteRune(escaped)
+			characters++
+			i += size + escapedSize
+			continue
+		}
+		text.WriteRune(r)
+		i += size
+	}
+	return "", 0, 0, &ParseError{Position: position, Message: "unterminated string"}
+}
+
+// isWordRune returns true if the rune is part of a field or of an unquoted value
+func isWordRune(r rune) bool {
+	return r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' ||
+		r == '_' || r == '.' || r == '-' || r == ':' || r == '/' || r == '@' || r == '+'
+}
+
+// isField returns true if the word is a valid field name
+func isField(word string) bool {
+	if word == "" {
+		return false
+	}
+	first := word[0]
+	if !(first >= 'a' && first <= 'z' || first >= 'A' && first <= 'Z' || first == '_') {
+		return false
+	}
+	for _, r := range word {
+		if !isWordRune(r) || r == '@' || r == '+' {
+			return false
+		}
+	}
+	return !strings.HasSuffix(word, ".") && !strings.Contains(word, "..")
+}
+
+// isKeyword returns true if the word is a reserved keyword of the filter syntax
+func isKeyword(word string) bool {
+	for _, keyword := range []string{"AND", "OR", "NOT", "IN"} {
+		if strings.EqualFold(word, keyword) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseNumber parses an unquoted word which starts like a number
+func parseNumber(word string) (float64, bool) {
+	digits := strings.TrimPrefix(word, "-")
+	if digits == "" || digits[0] < '0' || digits[0] > '9' {
+		return 0, false
+	}
+	number, err := strconv.ParseFloat(word, 64)
+	return number, err == nil
+}
+
+// normalizeField returns the field with the data prefix, unless it is a field of the events
+func normalizeField(field string) string {
+	if _, found := eventFields[field]; found || strings.HasPrefix(field, dataPrefix) {
+		return field
+	}
+	return dataPrefix + field
+}
@@ -0,0 +1,116 @@
+package filter_test
+
+import (
+	"errors"
+	"finala/api/filter"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+
+	testCases := []struct {
+		name       string
+		expression string
+		expected   string
+	}{
+		{"empty", "  ", ""},
+		{"equal", `Region = us-east-1`, `Data.Region = "us-east-1"`},
+		{"number", `Data.PricePerMonth > 100`, `Data.PricePerMonth > 100`},
+		{"negative number", `Data.PricePerMonth >= -1.5`, `Data.PricePerMonth >= -1.5`},
+		{"quoted number", `Data.AccountID = "123"`, `Data.AccountID = "123"`},
+		{"quoted string", `Data.Tag.team != "infra \"core\" \\ ops"`, `Data.Tag.team != "infra \"core\" \\ ops"`},
+		{"event field", `ResourceName = aws_ec2`, `ResourceName = "aws_ec2"`},
+		{"tag key with colon", `Tag.aws:cloudformation:stack-name = web`, `Data.Tag.aws:cloudformation:stack-name = "web"`},
+		{"in", `Region IN (us-east-1, "eu-west-1")`, `Data.Region IN ("us-east-1", "eu-west-1")`},
+		{"not in", `Region not in (us-east-1)`, `NOT Data.Region IN ("us-east-1")`},
+		{"precedence", `A = 1 OR B = 2 AND C = 3`, `(Data.A = 1 OR (Data.B = 2 AND Data.C = 3))`},
+		{"parentheses", `(A = 1 OR B = 2) AND NOT C = 3`, `((Data.A = 1 OR Data.B = 2) AND NOT Data.C = 3)`},
+		{"request example", `Data.PricePerMonth > 100 AND Region IN (us-east-1, eu-west-1) AND Data.Tag.team != "infra"`,
+			`((Data.PricePerMonth > 100 AND Data.Region IN ("us-east-1", "eu-west-1")) AND Data.Tag.team != "infra")`},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			node, err := filter.Parse(test.expression)
+			if err != nil {
+				t.Fatalf("unexpected error, got %v", err)
+			}
+			result := ""
+			if node != nil {
+				result = node.String()
+			}
+			if result != test.expected {
+				t.Fatalf("unexpected filter, got %s expected %s", result, test.expected)
+			}
+		})
+	}
+}
+
+func TestParseError(t *testing.T) {
+
+	testCases := []struct {
+		name             string
+		expression       string
+		expectedPosition int
+		expectedMessage  string
+	}{
+		{"missing value", `Region =`, 9, "expected a value but found end of filter"},
+		{"missing operator", `Region us-east-1`, 8, `expected an operator but found "us-east-1"`},
+		{"ordering string", `PricePerMonth > high`, 17, `operator > expects a number but found "high"`},
+		{"invalid field", `1Region = a`, 1, `expected a field but found "1Region"`},
+		{"keyword field", `AND = a`, 1, `expected a field but found "AND"`},
+		{"unclosed parenthesis", `(A = 1 OR B = 2`, 16, `expected ")" but found end of filter`},
+		{"unclosed list", `Region IN (a, b`, 16, `expected "," or ")" but found end of filter`},
+		{"trailing token", `A = 1 B = 2`, 7, `unexpected "B"`},
+		{"unexpected character", `A = 1 AND B ~ 2`, 13, `unexpected character '~'`},
+		{"single bang", `A ! 1`, 3, `expected "!="`},
+		{"unterminated string", `A = "abc`, 5, "unterminated string"},
+		{"invalid escape", `A = "a\nb"`, 7, `invalid escape "\n"`},
+		{"multibyte position", `Name = "é" AND`, 15, "expected a field but found end of filter"},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := filter.Parse(test.expression)
+			var parseErr *filter.ParseError
+			if !errors.As(err, &parseErr) {
+				t.Fatalf("unexpected error, got %v expected a parse error", err)
+			}
+			if parseErr.Position != test.expectedPosition || parseErr.Message != test.expectedMessage {
+				t.Fatalf("unexpected parse error, got %d %q expected %d %q", parseErr.Position, parseErr.Message, test.expectedPosition, test.expectedMessage)
+			}
+		})
+	}
+
+	t.Run("nested too deep", func(t *testing.T) {
+		expression := ""
+		for i := 0; i < 40; i++ {
+			expression += "NOT "
+		}
+		_, err := filter.Parse(expression + "A = 1")
+		var parseErr *filter.ParseError
+		if !errors.As(err, &parseErr) || parseErr.Position != 129 {
+			t.Fatalf("unexpected error, got %v", err)
+		}
+	})
+}
+
+func TestFromEquals(t *testing.T) {
+
+	node, err := filter.FromEquals(map[string]string{"Data.Tag.team": "data, infra", "Region": "us-east-1", "Data.AccountID": ""})
+	if err != nil {
+		t.Fatalf("unexpected error, got %v", err)
+	}
+	expected := `(Data.Tag.team IN ("data", "infra") AND Data.Region = "us-east-1")`
+	if node.String() != expected {
+		t.Fatalf("unexpected filter, got %s expected %s", node, expected)
+	}
+
+	if node, err := filter.FromEquals(map[string]string{}); node != nil || err != nil {
+		t.Fatalf("unexpected filter, got %v %v expected none", node, err)
+	}
+
+	if _, err := filter.FromEquals(map[string]string{"Region=1 OR A": "1"}); err == nil {
+		t.Fatalf("expected an invalid field error")
+	}
+}
@@ -5,15 +5,24 @@ import (
 	"log"
 	"net/http"
 	"strings"
+	"time"
 
 	"finala/api/auth"
 	"finala/api/models"
-	"finala/config"
+	"finala/api/users"
 	"finala/serverutil"
 )
 
-// LoginHandler handles user login requests.
-func LoginHandler(w http.ResponseWriter, r *http.Request) {
+// LoginHandler returns the handler of user login requests. The users are authenticated against the given user store,
+// and the issued tokens are signed by the given jwt manager.
+func LoginHandler(userStore *users.Store, jwtManager *auth.JWTManager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		login(userStore, jwtManager, w, r)
+	}
+}
+
+// login handles user login requests.
+func login(userStore *users.Store, jwtManager *auth.JWTManager, w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		serverutil.RespondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
 		return
@@ -37,20 +46,24 @@ func LoginHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if req.Username == config.AppCredentials.Username && req.Password == config.AppCredentials.Password {
-		tokenString, _, err := auth.GenerateJWT(req.Username)
-		if err != nil {
-			log.Printf("ERROR: Generating JWT: %v", err)
-			serverutil.RespondWithError(w, http.StatusInternalServerError, "Could not generate token")
-			return
-		}
-
-		serverutil.RespondWithJSON(w, http.StatusOK, models.LoginResponse{
-			Token:   tokenString,
-			Message: "Login successful",
-		})
-	} else {
+	user, err := userStore.Authenticate(req.Username, req.Password)
+	if err != nil {
 		log.Printf("WARN: Failed login attempt for username: %s", req.Username)
 		serverutil.RespondWithError(w, http.StatusUnauthorized, "Invalid username or password")
+		return
 	}
+
+	tokenString, expirationTime, err := jwtManager.GenerateJWT(user.Username, string(user.Role))
+	if err != nil {
+		log.Printf("ERROR: Generating JWT: %v", err)
+		serverutil.RespondWithError(w, http.StatusInternalServerError, "Could not generate token")
+		return
+	}
+
+	serverutil.RespondWithJSON(w, http.StatusOK, models.LoginResponse{
+		Token:     tokenString,
+		ExpiresIn: int64(time.Until(expirationTime).Round(time.Second).Seconds()),
+		Role:      string(user.Role),
+		Message:   "Login successful",
+	})
 }
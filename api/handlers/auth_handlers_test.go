@@ -5,29 +5,39 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"path/filepath"
 	"strings"
 	"testing"
 
+	"finala/api/auth"
+	apiconfig "finala/api/config"
 	"finala/api/handlers"
 	"finala/api/models"
-	"finala/config"
-	// "finala/serverutil" // Not directly used here but handlers depend on it
+	"finala/api/users"
 )
 
+// newUserStore returns a user store in a temporary directory with the given users, their password is `<username>-password`
+func newUserStore(t *testing.T, usersRoles map[string]users.Role) *users.Store {
+	userStore, err := users.NewStore(filepath.Join(t.TempDir(), "users.yaml"))
+	if err != nil {
+		t.Fatalf("unexpected user store error: %v", err)
+	}
+	for username, role := range usersRoles {
+		if _, err := userStore.Create(username, username+"-password", role); err != nil {
+			t.Fatalf("unexpected create user error: %v", err)
+		}
+	}
+	return userStore
+}
+
 func TestLoginHandler(t *testing.T) {
-	// Store original AppCredentials and JWT secret if any, and restore after test
-	originalAppCreds := config.AppCredentials
-	// originalJWTSecret := auth.jwtSecretKey // If jwtSecretKey was public, otherwise can't easily mock it without DI
-
-	defer func() {
-		config.AppCredentials = originalAppCreds
-		// auth.jwtSecretKey = originalJWTSecret
-	}()
-
-	// Setup mock credentials for testing
-	config.AppCredentials = config.AuthCredentialsConfig{
-		Username: "testuser",
-		Password: "testpassword",
+	userStore := newUserStore(t, map[string]users.Role{"testuser": users.RoleAnalyst})
+
+	jwtManager, err := auth.NewJWTManager(apiconfig.JWTConfig{
+		SigningKeys: []apiconfig.JWTSigningKeyConfig{{ID: "test", Key: "test-signing-key-of-at-least-32-bytes"}},
+	})
+	if err != nil {
+		t.Fatalf("unexpected jwt manager error: %v", err)
 	}
 
 	tests := []struct {
@@ -43,7 +53,7 @@ func TestLoginHandler(t *testing.T) {
 			method: http.MethodPost,
 			payload: models.LoginRequest{
 				Username: "testuser",
-				Password: "testpassword",
+				Password: "testuser-password",
 			},
 			expectedStatusCode: http.StatusOK,
 			expectToken:        true,
@@ -63,7 +73,7 @@ func TestLoginHandler(t *testing.T) {
 			method: http.MethodPost,
 			payload: models.LoginRequest{
 				Username: "wronguser",
-				Password: "testpassword",
+				Password: "testuser-password",
 			},
 			expectedStatusCode: http.StatusUnauthorized,
 			expectErrorMsg:     "Invalid username or password",
@@ -85,7 +95,7 @@ func TestLoginHandler(t *testing.T) {
 			name:   "Missing Username",
 			method: http.MethodPost,
 			payload: models.LoginRequest{
-				Password: "testpassword",
+				Password: "testuser-password",
 			},
 			expectedStatusCode: http.StatusBadRequest,
 			expectErrorMsg:     "Username is required",
@@ -123,7 +133,7 @@ func TestLoginHandler(t *testing.T) {
 			}
 
 			rr := httptest.NewRecorder()
-			h := http.HandlerFunc(handlers.LoginHandler)
+			h := handlers.LoginHandler(userStore, jwtManager)
 			h.ServeHTTP(rr, req)
 
 			if status := rr.Code; status != tt.expectedStatusCode {
@@ -141,6 +151,21 @@ func TestLoginHandler(t *testing.T) {
 					t.Errorf("Expected token in response, got none. Body: %s", rr.Body.String())
 				}
 
+				claims, err := jwtManager.ValidateJWT(resp.Token)
+				if err != nil {
+					t.Errorf("Expected valid token in response, got error: %v", err)
+				} else if claims.Subject != "testuser" || claims.Role != string(users.RoleAnalyst) {
+					t.Errorf("Expected token subject testuser with analyst role, got: %s %s", claims.Subject, claims.Role)
+				}
+
+				if resp.Role != string(users.RoleAnalyst) {
+					t.Errorf("Expected analyst role in response, got: %s", resp.Role)
+				}
+
+				if resp.ExpiresIn != 86400 {
+					t.Errorf("Expected token to expire in 86400 seconds, got: %d", resp.ExpiresIn)
+				}
+
 				if !strings.Contains(resp.Message, "Login successful") {
 					t.Errorf("Expected success message, got: %s", resp.Message)
 				}
@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"finala/api/collectors"
+	"finala/api/models"
+	"finala/serverutil"
+)
+
+// CollectorKeyHandlers handles the collector api keys management requests
+type CollectorKeyHandlers struct {
+	keyStore *collectors.KeyStore
+}
+
+// NewCollectorKeyHandlers returns the collector keys management handlers of the given key store
+func NewCollectorKeyHandlers(keyStore *collectors.KeyStore) *CollectorKeyHandlers {
+	return &CollectorKeyHandlers{
+		keyStore: keyStore,
+	}
+}
+
+// ListCollectorKeys returns all the collector keys without their secrets
+func (ch *CollectorKeyHandlers) ListCollectorKeys(w http.ResponseWriter, r *http.Request) {
+	serverutil.RespondWithJSON(w, http.StatusOK, ch.keyStore.List())
+}
+
+// IssueCollectorKey issues a new key of a collector, the key is returned only in this response
+func (ch *CollectorKeyHandlers) IssueCollectorKey(w http.ResponseWriter, r *http.Request) {
+
+	var req models.IssueCollectorKeyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		serverutil.RespondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+	defer r.Body.Close()
+
+	key, secret, err := ch.keyStore.Issue(req.CollectorName)
+	if err != nil {
+		respondWithCollectorKeyError(w, err)
+		return
+	}
+
+	log.Printf("INFO: Issued collector key %s of collector %s", key.ID, key.CollectorName)
+	serverutil.RespondWithJSON(w, http.StatusCreated, models.IssueCollectorKeyResponse{
+		ID:            key.ID,
+		CollectorName: key.CollectorName,
+		CreatedAt:     key.CreatedAt,
+		Key:           secret,
+	})
+}
+
+// RevokeCollectorKey revokes a collector key
+func (ch *CollectorKeyHandlers) RevokeCollectorKey(w http.ResponseWriter, r *http.Request) {
+
+	keyID := r.PathValue("keyID")
+	err := ch.keyStore.Revoke(keyID)
+	if err != nil {
+		respondWithCollectorKeyError(w, err)
+		return
+	}
+
+	log.Printf("INFO: Revoked collector key %s", keyID)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// respondWithCollectorKeyError responds with the status code of the given key store error
+func respondWithCollectorKeyError(w http.ResponseWriter, err error) {
+
+	var validationErr *collectors.ValidationError
+	switch {
+	case errors.As(err, &validationErr):
+		serverutil.RespondWithError(w, http.StatusBadRequest, validationErr.Error())
+	case errors.Is(err, collectors.ErrKeyNotFound):
+		serverutil.RespondWithError(w, http.StatusNotFound, err.Error())
+	default:
+		log.Printf("ERROR: Managing collector keys: %v", err)
+		serverutil.RespondWithError(w, http.StatusInternalServerError, "Could not manage collector keys")
+	}
+}
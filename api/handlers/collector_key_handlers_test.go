@@ -0,0 +1,88 @@
+package handlers_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"finala/api/collectors"
+	"finala/api/handlers"
+	"finala/api/models"
+)
+
+func TestCollectorKeyHandlers(t *testing.T) {
+
+	keyStore, err := collectors.NewKeyStore(filepath.Join(t.TempDir(), "collector_keys.yaml"))
+	if err != nil {
+		t.Fatalf("unexpected key store error: %v", err)
+	}
+	keyHandlers := handlers.NewCollectorKeyHandlers(keyStore)
+
+	router := http.NewServeMux()
+	router.HandleFunc("GET /collector-keys", keyHandlers.ListCollectorKeys)
+	router.HandleFunc("POST /collector-keys", keyHandlers.IssueCollectorKey)
+	router.HandleFunc("DELETE /collector-keys/{keyID}", keyHandlers.RevokeCollectorKey)
+
+	serve := func(method, endpoint string, payload interface{}) *httptest.ResponseRecorder {
+		var reqBody []byte
+		if strPayload, ok := payload.(string); ok {
+			reqBody = []byte(strPayload)
+		} else if payload != nil {
+			reqBody, err = json.Marshal(payload)
+			if err != nil {
+				t.Fatalf("Failed to marshal payload: %v", err)
+			}
+		}
+		req := httptest.NewRequest(method, endpoint, bytes.NewBuffer(reqBody))
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	rr := serve(http.MethodPost, "/collector-keys", models.IssueCollectorKeyRequest{CollectorName: "general"})
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("unexpected issue status code, got %d expected %d", rr.Code, http.StatusCreated)
+	}
+	var issued models.IssueCollectorKeyResponse
+	if err := json.NewDecoder(rr.Body).Decode(&issued); err != nil {
+		t.Fatalf("could not decode response: %v", err)
+	}
+	if issued.CollectorName != "general" || !strings.HasPrefix(issued.Key, issued.ID+".") {
+		t.Fatalf("unexpected issued key, got %+v", issued)
+	}
+
+	// The listed keys have no secrets
+	rr = serve(http.MethodGet, "/collector-keys", nil)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("unexpected list status code, got %d expected %d", rr.Code, http.StatusOK)
+	}
+	if strings.Contains(rr.Body.String(), "secret") || strings.Contains(rr.Body.String(), issued.Key) {
+		t.Fatalf("listed keys contain secrets: %s", rr.Body.String())
+	}
+
+	tests := []struct {
+		name               string
+		method             string
+		endpoint           string
+		payload            interface{}
+		expectedStatusCode int
+	}{
+		{"issue key without collector name", http.MethodPost, "/collector-keys", models.IssueCollectorKeyRequest{}, http.StatusBadRequest},
+		{"issue key with invalid payload", http.MethodPost, "/collector-keys", "not-json", http.StatusBadRequest},
+		{"revoke key", http.MethodDelete, "/collector-keys/" + issued.ID, nil, http.StatusNoContent},
+		{"revoke unknown key", http.MethodDelete, "/collector-keys/" + issued.ID, nil, http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := serve(tt.method, tt.endpoint, tt.payload)
+			if rr.Code != tt.expectedStatusCode {
+				t.Fatalf("handler returned wrong status code: got %v want %v, body: %s", rr.Code, tt.expectedStatusCode, rr.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,235 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+
+	"finala/api/auth"
+	"finala/api/models"
+	"finala/api/users"
+	"finala/serverutil"
+
+	"golang.org/x/oauth2"
+)
+
+const (
+	// oidcStateCookie is the cookie binding the sign-in to the browser that started it
+	oidcStateCookie = "finala_oidc_state"
+
+	// oidcCookiePath limits the state cookie to the sign-in routes
+	oidcCookiePath = "/api/v1/auth/oidc"
+
+	// oidcLoginTTL is how long a started sign-in can be completed
+	oidcLoginTTL = 10 * time.Minute
+
+	// oidcMaxPendingLogins caps the started sign-ins kept in memory, the sign-ins which expire first are dropped
+	oidcMaxPendingLogins = 1000
+)
+
+// pendingLogin describes a sign-in that was redirected to the identity provider and has not returned yet
+type pendingLogin struct {
+	nonce    string
+	verifier string
+	expires  time.Time
+}
+
+// OIDCHandlers handles the OIDC single sign-on requests
+type OIDCHandlers struct {
+	provider   *auth.OIDCProvider
+	userStore  *users.Store
+	jwtManager *auth.JWTManager
+
+	mu      sync.Mutex
+	pending map[string]pendingLogin
+}
+
+// NewOIDCHandlers returns the single sign-on handlers of the given identity provider.
+// The signed in users are synced to the given user store, and are issued tokens signed by the given jwt manager
+func NewOIDCHandlers(provider *auth.OIDCProvider, userStore *users.Store, jwtManager *auth.JWTManager) *OIDCHandlers {
+	return &OIDCHandlers{
+		provider:   provider,
+		userStore:  userStore,
+		jwtManager: jwtManager,
+		pending:    map[string]pendingLogin{},
+	}
+}
+
+// Login redirects the user to sign in at the identity provider
+func (oh *OIDCHandlers) Login(w http.ResponseWriter, r *http.Request) {
+
+	state, err := randomToken()
+	if err != nil {
+		log.Printf("ERROR: Generating OIDC state: %v", err)
+		serverutil.RespondWithError(w, http.StatusInternalServerError, "Could not start the sign-in")
+		return
+	}
+	nonce, err := randomToken()
+	if err != nil {
+		log.Printf("ERROR: Generating OIDC nonce: %v", err)
+		serverutil.RespondWithError(w, http.StatusInternalServerError, "Could not start the sign-in")
+		return
+	}
+	verifier := oauth2.GenerateVerifier()
+
+	oh.mu.Lock()
+	now := time.Now()
+	oh.prunePending(now)
+	oh.pending[state] = pendingLogin{
+		nonce:    nonce,
+		verifier: verifier,
+		expires:  now.Add(oidcLoginTTL),
+	}
+	oh.mu.Unlock()
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     oidcStateCookie,
+		Value:    state,
+		Path:     oidcCookiePath,
+		MaxAge:   int(oidcLoginTTL.Seconds()),
+		HttpOnly: true,
+		Secure:   oh.provider.SecureCookies(),
+		SameSite: http.SameSiteLaxMode,
+	})
+	http.Redirect(w, r, oh.provider.AuthCodeURL(state, nonce, verifier), http.StatusFound)
+}
+
+// prunePending removes the expired sign-ins, and when the pending sign-ins are still at the cap, the sign-in which
+// expires first, so the started sign-ins can not grow without bound. The caller must hold the lock
+func (oh *OIDCHandlers) prunePending(now time.Time) {
+
+	for pendingState, login := range oh.pending {
+		if now.After(login.expires) {
+			delete(oh.pending, pendingState)
+		}
+	}
+
+	for len(oh.pending) >= oidcMaxPendingLogins {
+		var oldestState string
+		var oldestExpires time.Time
+		for pendingState, login := range oh.pending {
+			if oldestState == "" || login.expires.Before(oldestExpires) {
+				oldestState = pendingState
+				oldestExpires = login.expires
+			}
+		}
+		delete(oh.pending, oldestState)
+	}
+}
+
+// Callback completes the sign-in the identity provider redirected back, and redirects the user to the ui with a
+// Finala token. Failed sign-ins are redirected to the ui with the error
+func (oh *OIDCHandlers) Callback(w http.ResponseWriter, r *http.Request) {
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     oidcStateCookie,
+		Path:     oidcCookiePath,
+		MaxAge:   -1,
+		HttpOnly: true,
+		Secure:   oh.provider.SecureCookies(),
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	query := r.URL.Query()
+	if idpError := query.Get("error"); idpError != "" {
+		log.Printf("WARN: OIDC sign-in was rejected by the identity provider: %s %s", idpError, query.Get("error_description"))
+		oh.redirectToUI(w, r, url.Values{"error": {"The identity provider rejected the sign-in"}})
+		return
+	}
+
+	state := query.Get("state")
+	cookie, err := r.Cookie(oidcStateCookie)
+	if err != nil || state == "" || subtle.ConstantTimeCompare([]byte(cookie.Value), []byte(state)) != 1 {
+		log.Printf("WARN: OIDC callback with an invalid state")
+		oh.redirectToUI(w, r, url.Values{"error": {"Invalid sign-in state, please sign in again"}})
+		return
+	}
+
+	oh.mu.Lock()
+	login, found := oh.pending[state]
+	delete(oh.pending, state)
+	oh.mu.Unlock()
+	if !found || time.Now().After(login.expires) {
+		log.Printf("WARN: OIDC callback of an unknown or expired sign-in")
+		oh.redirectToUI(w, r, url.Values{"error": {"The sign-in has expired, please sign in again"}})
+		return
+	}
+
+	identity, err := oh.provider.Exchange(r.Context(), query.Get("code"), login.nonce, login.verifier)
+	if errors.Is(err, auth.ErrOIDCNoRole) {
+		log.Printf("WARN: OIDC user is not mapped to any role")
+		oh.redirectToUI(w, r, url.Values{"error": {"Your account is not allowed to access Finala"}})
+		return
+	}
+	if err != nil {
+		log.Printf("WARN: OIDC sign-in failed: %v", err)
+		oh.redirectToUI(w, r, url.Values{"error": {"Could not complete the sign-in"}})
+		return
+	}
+
+	user, err := oh.userStore.SyncExternal(identity.Username, identity.Role, users.SourceOIDC)
+	if errors.Is(err, users.ErrUserExists) {
+		log.Printf("WARN: OIDC user %s conflicts with a local user", identity.Username)
+		oh.redirectToUI(w, r, url.Values{"error": {"A local user with the same username already exists"}})
+		return
+	}
+	if err != nil {
+		log.Printf("ERROR: Syncing OIDC user %s: %v", identity.Username, err)
+		oh.redirectToUI(w, r, url.Values{"error": {"Could not complete the sign-in"}})
+		return
+	}
+
+	tokenString, expirationTime, err := oh.jwtManager.GenerateJWT(user.Username, string(user.Role))
+	if err != nil {
+		log.Printf("ERROR: Generating JWT: %v", err)
+		oh.redirectToUI(w, r, url.Values{"error": {"Could not generate token"}})
+		return
+	}
+
+	log.Printf("INFO: OIDC user %s signed in with role %s", user.Username, user.Role)
+	oh.redirectToUI(w, r, url.Values{
+		"token":      {tokenString},
+		"expires_in": {strconv.FormatInt(int64(time.Until(expirationTime).Round(time.Second).Seconds()), 10)},
+		"role":       {string(user.Role)},
+	})
+}
+
+// redirectToUI redirects the user to the ui with the given values in the url fragment.
+// The fragment is not sent to servers, so the token does not reach access logs or referrers
+func (oh *OIDCHandlers) redirectToUI(w http.ResponseWriter, r *http.Request, values url.Values) {
+	w.Header().Set("Cache-Control", "no-store")
+	http.Redirect(w, r, oh.provider.UIRedirectURL()+"#"+values.Encode(), http.StatusFound)
+}
+
+// AuthProvidersHandler returns the handler of the enabled sign-in methods request, the ui shows the sign-in options by it.
+// The given provider is nil when single sign-on is not configured
+func AuthProvidersHandler(provider *auth.OIDCProvider) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		serverutil.RespondWithJSON(w, http.StatusOK, models.AuthProvidersResponse{
+			Password: provider == nil || !provider.PasswordLoginDisabled(),
+			OIDC:     provider != nil,
+		})
+	}
+}
+
+// PasswordLoginDisabledHandler responds to password login requests when the users must sign in through the identity provider
+func PasswordLoginDisabledHandler(w http.ResponseWriter, r *http.Request) {
+	serverutil.RespondWithError(w, http.StatusForbidden, "Password login is disabled, sign in with single sign-on")
+}
+
+// randomToken returns a random url safe token
+func randomToken() (string, error) {
+	b := make([]byte, 32)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
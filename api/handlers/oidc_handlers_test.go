@@ -0,0 +1,245 @@
+package handlers_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"finala/api/auth"
+	apiconfig "finala/api/config"
+	"finala/api/handlers"
+	"finala/api/models"
+	"finala/api/testutils"
+	"finala/api/users"
+)
+
+const mockUIRedirectURL = "http://127.0.0.1:8080/login"
+
+// newOIDCHandlers returns single sign-on handlers of a local identity provider, which signs in users with the given claims
+func newOIDCHandlers(t *testing.T, userStore *users.Store, claims map[string]interface{}) (*handlers.OIDCHandlers, *auth.JWTManager) {
+	mockProvider, err := testutils.NewMockOIDCProvider()
+	if err != nil {
+		t.Fatalf("unexpected mock oidc provider error: %v", err)
+	}
+	t.Cleanup(mockProvider.Server.Close)
+	mockProvider.SetClaims(claims)
+
+	provider, err := auth.NewOIDCProvider(context.Background(), apiconfig.OIDCConfig{
+		IssuerURL:     mockProvider.IssuerURL(),
+		ClientID:      testutils.MockOIDCClientID,
+		ClientSecret:  testutils.MockOIDCClientSecret,
+		RedirectURL:   "http://127.0.0.1:8089/api/v1/auth/oidc/callback",
+		UIRedirectURL: mockUIRedirectURL,
+		RoleMappings:  []apiconfig.OIDCRoleMappingConfig{{Value: "finala-admins", Role: "admin"}},
+	})
+	if err != nil {
+		t.Fatalf("unexpected oidc provider error: %v", err)
+	}
+
+	jwtManager, err := auth.NewJWTManager(apiconfig.JWTConfig{
+		SigningKeys: []apiconfig.JWTSigningKeyConfig{{ID: "test", Key: "test-signing-key-of-at-least-32-bytes"}},
+	})
+	if err != nil {
+		t.Fatalf("unexpected jwt manager error: %v", err)
+	}
+
+	return handlers.NewOIDCHandlers(provider, userStore, jwtManager), jwtManager
+}
+
+// startOIDCLogin starts a sign-in, and returns the callback url the identity provider redirected to with the state cookie
+func startOIDCLogin(t *testing.T, oidcHandlers *handlers.OIDCHandlers) (string, *http.Cookie) {
+	rr := httptest.NewRecorder()
+	oidcHandlers.Login(rr, httptest.NewRequest(http.MethodGet, "/api/v1/auth/oidc/login", nil))
+	if rr.Code != http.StatusFound {
+		t.Fatalf("unexpected login status code, got %d expected %d", rr.Code, http.StatusFound)
+	}
+
+	cookies := rr.Result().Cookies()
+	if len(cookies) != 1 || !cookies[0].HttpOnly {
+		t.Fatalf("unexpected login cookies, got %+v", cookies)
+	}
+
+	client := &http.Client{
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+	resp, err := client.Get(rr.Header().Get("Location"))
+	if err != nil {
+		t.Fatalf("unexpected authorize error: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusFound {
+		t.Fatalf("unexpected authorize status code, got %d expected %d", resp.StatusCode, http.StatusFound)
+	}
+
+	return resp.Header.Get("Location"), cookies[0]
+}
+
+// callbackFragment completes the sign-in, and returns the ui redirect fragment values
+func callbackFragment(t *testing.T, oidcHandlers *handlers.OIDCHandlers, callbackURL string, cookie *http.Cookie) url.Values {
+	req := httptest.NewRequest(http.MethodGet, callbackURL, nil)
+	if cookie != nil {
+		req.AddCookie(cookie)
+	}
+	rr := httptest.NewRecorder()
+	oidcHandlers.Callback(rr, req)
+	if rr.Code != http.StatusFound {
+		t.Fatalf("unexpected callback status code, got %d expected %d", rr.Code, http.StatusFound)
+	}
+
+	location := rr.Header().Get("Location")
+	if !strings.HasPrefix(location, mockUIRedirectURL+"#") {
+		t.Fatalf("unexpected callback redirect, got %s expected %s", location, mockUIRedirectURL)
+	}
+	fragment, err := url.ParseQuery(strings.TrimPrefix(location, mockUIRedirectURL+"#"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return fragment
+}
+
+func TestOIDCLoginFlow(t *testing.T) {
+
+	userStore := newUserStore(t, map[string]users.Role{})
+	oidcHandlers, jwtManager := newOIDCHandlers(t, userStore, map[string]interface{}{
+		"email":  "admin@example.com",
+		"groups": []interface{}{"finala-admins"},
+	})
+
+	callbackURL, cookie := startOIDCLogin(t, oidcHandlers)
+	fragment := callbackFragment(t, oidcHandlers, callbackURL, cookie)
+	if fragment.Get("error") != "" {
+		t.Fatalf("unexpected sign-in error: %s", fragment.Get("error"))
+	}
+	if fragment.Get("role") != string(users.RoleAdmin) {
+		t.Fatalf("unexpected role, got %s expected %s", fragment.Get("role"), users.RoleAdmin)
+	}
+
+	claims, err := jwtManager.ValidateJWT(fragment.Get("token"))
+	if err != nil {
+		t.Fatalf("unexpected token error: %v", err)
+	}
+	if claims.Subject != "admin@example.com" {
+		t.Fatalf("unexpected token subject, got %s expected %s", claims.Subject, "admin@example.com")
+	}
+
+	user, err := userStore.Get("admin@example.com")
+	if err != nil {
+		t.Fatalf("unexpected user error: %v", err)
+	}
+	if user.Source != users.SourceOIDC || user.Role != users.RoleAdmin {
+		t.Fatalf("unexpected synced user, got %+v", user)
+	}
+
+	// The sign-in can not be completed twice
+	fragment = callbackFragment(t, oidcHandlers, callbackURL, cookie)
+	if fragment.Get("error") == "" || fragment.Get("token") != "" {
+		t.Fatalf("unexpected replayed sign-in, got %v", fragment)
+	}
+}
+
+func TestOIDCPendingLoginsCap(t *testing.T) {
+
+	userStore := newUserStore(t, map[string]users.Role{})
+	oidcHandlers, _ := newOIDCHandlers(t, userStore, map[string]interface{}{
+		"email":  "admin@example.com",
+		"groups": []interface{}{"finala-admins"},
+	})
+
+	callbackURL, cookie := startOIDCLogin(t, oidcHandlers)
+
+	// Flooding the login drops the sign-in which expires first, instead of growing the pending sign-ins
+	for i := 0; i < 1000; i++ {
+		oidcHandlers.Login(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/v1/auth/oidc/login", nil))
+	}
+
+	fragment := callbackFragment(t, oidcHandlers, callbackURL, cookie)
+	if fragment.Get("error") == "" {
+		t.Fatalf("unexpected dropped sign-in completed, got %v", fragment)
+	}
+
+	callbackURL, cookie = startOIDCLogin(t, oidcHandlers)
+	fragment = callbackFragment(t, oidcHandlers, callbackURL, cookie)
+	if fragment.Get("error") != "" {
+		t.Fatalf("unexpected sign-in error: %s", fragment.Get("error"))
+	}
+}
+
+func TestOIDCCallbackErrors(t *testing.T) {
+
+	testCases := []struct {
+		name     string
+		users    map[string]users.Role
+		claims   map[string]interface{}
+		callback func(callbackURL string, cookie *http.Cookie) (string, *http.Cookie)
+	}{
+		{
+			name:   "missing state cookie",
+			claims: map[string]interface{}{"email": "admin@example.com", "groups": []interface{}{"finala-admins"}},
+			callback: func(callbackURL string, cookie *http.Cookie) (string, *http.Cookie) {
+				return callbackURL, nil
+			},
+		},
+		{
+			name:   "other state cookie",
+			claims: map[string]interface{}{"email": "admin@example.com", "groups": []interface{}{"finala-admins"}},
+			callback: func(callbackURL string, cookie *http.Cookie) (string, *http.Cookie) {
+				return callbackURL, &http.Cookie{Name: cookie.Name, Value: "other"}
+			},
+		},
+		{
+			name:   "identity provider error",
+			claims: map[string]interface{}{"email": "admin@example.com", "groups": []interface{}{"finala-admins"}},
+			callback: func(callbackURL string, cookie *http.Cookie) (string, *http.Cookie) {
+				return "/api/v1/auth/oidc/callback?error=access_denied&state=" + cookie.Value, cookie
+			},
+		},
+		{
+			name:   "not mapped user",
+			claims: map[string]interface{}{"email": "other@example.com", "groups": []interface{}{"other"}},
+			callback: func(callbackURL string, cookie *http.Cookie) (string, *http.Cookie) {
+				return callbackURL, cookie
+			},
+		},
+		{
+			name:   "local user conflict",
+			users:  map[string]users.Role{"admin@example.com": users.RoleViewer},
+			claims: map[string]interface{}{"email": "admin@example.com", "groups": []interface{}{"finala-admins"}},
+			callback: func(callbackURL string, cookie *http.Cookie) (string, *http.Cookie) {
+				return callbackURL, cookie
+			},
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			userStore := newUserStore(t, test.users)
+			oidcHandlers, _ := newOIDCHandlers(t, userStore, test.claims)
+
+			callbackURL, cookie := test.callback(startOIDCLogin(t, oidcHandlers))
+			fragment := callbackFragment(t, oidcHandlers, callbackURL, cookie)
+			if fragment.Get("error") == "" || fragment.Get("token") != "" {
+				t.Fatalf("unexpected sign-in result, got %v expected error", fragment)
+			}
+		})
+	}
+}
+
+func TestAuthProvidersHandler(t *testing.T) {
+
+	rr := httptest.NewRecorder()
+	handlers.AuthProvidersHandler(nil)(rr, httptest.NewRequest(http.MethodGet, "/api/v1/auth/providers", nil))
+
+	var resp models.AuthProvidersResponse
+	if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil {
+		t.Fatalf("could not decode response: %v", err)
+	}
+	if !resp.Password || resp.OIDC {
+		t.Fatalf("unexpected providers without single sign-on, got %+v", resp)
+	}
+}
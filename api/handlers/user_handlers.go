@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"finala/api/models"
+	"finala/api/users"
+	"finala/serverutil"
+)
+
+// UserHandlers handles the users management requests
+type UserHandlers struct {
+	userStore *users.Store
+}
+
+// NewUserHandlers returns the users management handlers of the given user store
+func NewUserHandlers(userStore *users.Store) *UserHandlers {
+	return &UserHandlers{
+		userStore: userStore,
+	}
+}
+
+// ListUsers returns all the users
+func (uh *UserHandlers) ListUsers(w http.ResponseWriter, r *http.Request) {
+	serverutil.RespondWithJSON(w, http.StatusOK, uh.userStore.List())
+}
+
+// CreateUser creates a new user
+func (uh *UserHandlers) CreateUser(w http.ResponseWriter, r *http.Request) {
+
+	var req models.CreateUserRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		serverutil.RespondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+	defer r.Body.Close()
+
+	user, err := uh.userStore.Create(req.Username, req.Password, users.Role(req.Role))
+	if err != nil {
+		respondWithUserError(w, err)
+		return
+	}
+
+	log.Printf("INFO: Created user %s with role %s", user.Username, user.Role)
+	serverutil.RespondWithJSON(w, http.StatusCreated, user)
+}
+
+// UpdateUser changes the password and/or the role of a user
+func (uh *UserHandlers) UpdateUser(w http.ResponseWriter, r *http.Request) {
+
+	var req models.UpdateUserRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		serverutil.RespondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+	defer r.Body.Close()
+
+	if req.Password == "" && req.Role == "" {
+		serverutil.RespondWithError(w, http.StatusBadRequest, "Password or role is required")
+		return
+	}
+
+	user, err := uh.userStore.Update(r.PathValue("username"), req.Password, users.Role(req.Role))
+	if err != nil {
+		respondWithUserError(w, err)
+		return
+	}
+
+	log.Printf("INFO: Updated user %s", user.Username)
+	serverutil.RespondWithJSON(w, http.StatusOK, user)
+}
+
+// DeleteUser deletes a user
+func (uh *UserHandlers) DeleteUser(w http.ResponseWriter, r *http.Request) {
+
+	username := r.PathValue("username")
+	err := uh.userStore.Delete(username)
+	if err != nil {
+		respondWithUserError(w, err)
+		return
+	}
+
+	log.Printf("INFO: Deleted user %s", username)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// respondWithUserError responds with the status code of the given user store error
+func respondWithUserError(w http.ResponseWriter, err error) {
+
+	var validationErr *users.ValidationError
+	switch {
+	case errors.As(err, &validationErr):
+		serverutil.RespondWithError(w, http.StatusBadRequest, validationErr.Error())
+	case errors.Is(err, users.ErrUserNotFound):
+		serverutil.RespondWithError(w, http.StatusNotFound, err.Error())
+	case errors.Is(err, users.ErrUserExists), errors.Is(err, users.ErrLastAdmin):
+		serverutil.RespondWithError(w, http.StatusConflict, err.Error())
+	default:
+		log.Printf("ERROR: Managing users: %v", err)
+		serverutil.RespondWithError(w, http.StatusInternalServerError, "Could not manage users")
+	}
+}
@@ -0,0 +1,106 @@
+package handlers_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"finala/api/handlers"
+	"finala/api/models"
+	"finala/api/users"
+)
+
+func TestUserHandlers(t *testing.T) {
+
+	userStore := newUserStore(t, map[string]users.Role{
+		"admin": users.RoleAdmin,
+		"alice": users.RoleViewer,
+	})
+	userHandlers := handlers.NewUserHandlers(userStore)
+
+	router := http.NewServeMux()
+	router.HandleFunc("GET /users", userHandlers.ListUsers)
+	router.HandleFunc("POST /users", userHandlers.CreateUser)
+	router.HandleFunc("PUT /users/{username}", userHandlers.UpdateUser)
+	router.HandleFunc("DELETE /users/{username}", userHandlers.DeleteUser)
+
+	tests := []struct {
+		name               string
+		method             string
+		endpoint           string
+		payload            interface{}
+		expectedStatusCode int
+	}{
+		{"list users", http.MethodGet, "/users", nil, http.StatusOK},
+		{"create user", http.MethodPost, "/users", models.CreateUserRequest{Username: "bob", Password: "bob-password", Role: "analyst"}, http.StatusCreated},
+		{"create existing user", http.MethodPost, "/users", models.CreateUserRequest{Username: "alice", Password: "alice-password", Role: "viewer"}, http.StatusConflict},
+		{"create user with invalid role", http.MethodPost, "/users", models.CreateUserRequest{Username: "carol", Password: "carol-password", Role: "owner"}, http.StatusBadRequest},
+		{"create user with short password", http.MethodPost, "/users", models.CreateUserRequest{Username: "carol", Password: "short", Role: "viewer"}, http.StatusBadRequest},
+		{"create user with invalid payload", http.MethodPost, "/users", "not-json", http.StatusBadRequest},
+		{"update user role", http.MethodPut, "/users/alice", models.UpdateUserRequest{Role: "analyst"}, http.StatusOK},
+		{"update user without changes", http.MethodPut, "/users/alice", models.UpdateUserRequest{}, http.StatusBadRequest},
+		{"update unknown user", http.MethodPut, "/users/dave", models.UpdateUserRequest{Role: "viewer"}, http.StatusNotFound},
+		{"demote last admin", http.MethodPut, "/users/admin", models.UpdateUserRequest{Role: "viewer"}, http.StatusConflict},
+		{"delete user", http.MethodDelete, "/users/alice", nil, http.StatusNoContent},
+		{"delete unknown user", http.MethodDelete, "/users/alice", nil, http.StatusNotFound},
+		{"delete last admin", http.MethodDelete, "/users/admin", nil, http.StatusConflict},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var reqBody []byte
+			if strPayload, ok := tt.payload.(string); ok {
+				reqBody = []byte(strPayload)
+			} else if tt.payload != nil {
+				var err error
+				reqBody, err = json.Marshal(tt.payload)
+				if err != nil {
+					t.Fatalf("Failed to marshal payload: %v", err)
+				}
+			}
+
+			req, err := http.NewRequest(tt.method, tt.endpoint, bytes.NewBuffer(reqBody))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			if rr.Code != tt.expectedStatusCode {
+				t.Fatalf("handler returned wrong status code: got %v want %v. Body: %s", rr.Code, tt.expectedStatusCode, rr.Body.String())
+			}
+		})
+	}
+
+	usersList := userStore.List()
+	if len(usersList) != 2 || usersList[0].Username != "admin" || usersList[1].Username != "bob" || usersList[1].Role != users.RoleAnalyst {
+		t.Fatalf("unexpected users after the requests, got %+v", usersList)
+	}
+}
+
+func TestListUsersHidesPasswordHashes(t *testing.T) {
+
+	userStore := newUserStore(t, map[string]users.Role{"admin": users.RoleAdmin})
+
+	req, err := http.NewRequest(http.MethodGet, "/users", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rr := httptest.NewRecorder()
+	handlers.NewUserHandlers(userStore).ListUsers(rr, req)
+
+	if bytes.Contains(rr.Body.Bytes(), []byte("$2")) || bytes.Contains(rr.Body.Bytes(), []byte("Password")) {
+		t.Fatalf("unexpected password hash in the users response: %s", rr.Body.String())
+	}
+
+	var response []map[string]string
+	if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+		t.Fatal(err)
+	}
+	if len(response) != 1 || response[0]["username"] != "admin" || response[0]["role"] != "admin" {
+		t.Fatalf("unexpected users response, got %v", response)
+	}
+}
@@ -8,6 +8,14 @@ type LoginRequest struct {
 
 // LoginResponse defines the structure for the JSON response on successful login.
 type LoginResponse struct {
-	Token   string `json:"token"`
-	Message string `json:"message,omitempty"`
+	Token     string `json:"token"`
+	ExpiresIn int64  `json:"expires_in"`
+	Role      string `json:"role"`
+	Message   string `json:"message,omitempty"`
+}
+
+// AuthProvidersResponse defines the structure for the JSON response of the enabled sign-in methods.
+type AuthProvidersResponse struct {
+	Password bool `json:"password"`
+	OIDC     bool `json:"oidc"`
 }
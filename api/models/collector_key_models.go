@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+// IssueCollectorKeyRequest defines the structure for the JSON body expected in issue collector key requests.
+type IssueCollectorKeyRequest struct {
+	CollectorName string `json:"collector_name"`
+}
+
+// IssueCollectorKeyResponse defines the structure for the JSON response of an issued collector key.
+// The key is returned only once, it is not stored by the api.
+type IssueCollectorKeyResponse struct {
+	ID            string    `json:"id"`
+	CollectorName string    `json:"collector_name"`
+	CreatedAt     time.Time `json:"created_at"`
+	Key           string    `json:"key"`
+}
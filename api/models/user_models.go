@@ -0,0 +1,15 @@
+package models
+
+// CreateUserRequest defines the structure for the JSON body expected in create user requests.
+type CreateUserRequest struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+	Role     string `json:"role"`
+}
+
+// UpdateUserRequest defines the structure for the JSON body expected in update user requests.
+// Empty fields are not changed.
+type UpdateUserRequest struct {
+	Password string `json:"password,omitempty"`
+	Role     string `json:"role,omitempty"`
+}
@@ -1,6 +1,21 @@
 package api
 
-import "net/url"
+import (
+	"finala/api/storage"
+	"net/url"
+	"time"
+)
+
+const (
+	// ExecutionStatusRunning describes an execution which did not finish, and sent events recently
+	ExecutionStatusRunning = "running"
+
+	// ExecutionStatusFinished describes an execution its collector closed
+	ExecutionStatusFinished = "finished"
+
+	// ExecutionStatusCrashed describes an execution which did not finish, and did not send events for a long time
+	ExecutionStatusCrashed = "crashed"
+)
 
 // HealthResponse is returned when healtcheck requested
 type HealthResponse struct {
@@ -9,6 +24,30 @@ type HealthResponse struct {
 
 // HttpErrorResponse is returned on error
 type HttpErrorResponse struct {
-	Error      string     `json:"error"`
-	ErrorQuery url.Values `json:"errorQuery"`
+	Error         string     `json:"error"`
+	ErrorQuery    url.Values `json:"errorQuery"`
+	ErrorPosition int        `json:"errorPosition,omitempty"`
+}
+
+// ExecutionProgress describes the collection status of the execution resource types
+type ExecutionProgress struct {
+	ResourceTypes int
+	Collecting    int
+	Finished      int
+	Failed        int
+}
+
+// ExecutionResponse is returned with the execution status and progress
+type ExecutionResponse struct {
+	storage.Execution
+	Status       string
+	LastActivity time.Time
+	Progress     ExecutionProgress
+}
+
+// StreamEvent is streamed with the service status changes and the detected resources of an execution
+type StreamEvent struct {
+	ResourceName string
+	EventTime    int64
+	Data         interface{}
 }
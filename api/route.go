@@ -2,10 +2,18 @@ package api
 
 import (
 	"encoding/json"
+	"errors"
+	"finala/api/auth"
+	"finala/api/collectors"
 	"finala/api/config"
 	"finala/api/email_utility"
+	"finala/api/filter"
 	"finala/api/httpparameters"
 	"finala/api/storage"
+	"finala/api/stream"
+	"finala/api/users"
+	"finala/events"
+	"fmt"
 	"io"
 	"net/http"
 	"net/url"
@@ -17,16 +25,18 @@ import (
 
 const (
 	queryParamFilterPrefix     = "filter_"
+	queryParamFilter           = "filter"
 	resourceTrendsLimitDefault = 60
-)
 
-// DetectEventsInfo describes the incoming HTTP events
-type DetectEventsInfo struct {
-	ResourceName string
-	EventType    string
-	EventTime    int64
-	Data         interface{}
-}
+	// executionStaleTimeout is the duration without events after which a running execution is reported as crashed
+	executionStaleTimeout = time.Hour
+
+	// streamKeepAliveInterval is the interval of the keep-alive comments of the idle execution streams
+	streamKeepAliveInterval = 15 * time.Second
+
+	// streamEventExecutionFinished is the execution stream event of a finished execution
+	streamEventExecutionFinished = "execution_finished"
+)
 
 type ReportAPIResponse struct {
 	Message string     `json:"message"`
@@ -38,9 +48,26 @@ type ReportAPIResponse struct {
 func (server *Server) GetSummary(resp http.ResponseWriter, req *http.Request) {
 	queryParams := req.URL.Query()
 	executionID := req.PathValue("executionID")
-	filters := httpparameters.GetFilterQueryParamWithOutPrefix(queryParamFilterPrefix, queryParams)
+	resourcesFilter, err := queryFilter(queryParams)
+	if err != nil {
+		server.JSONWrite(resp, http.StatusBadRequest, filterErrorResponse(err))
+		return
+	}
+
+	response, err := server.storage.GetSummary(executionID, resourcesFilter)
+	if err != nil {
+		server.JSONWrite(resp, http.StatusInternalServerError, HttpErrorResponse{Error: err.Error()})
+		return
+
+	}
+	server.JSONWrite(resp, http.StatusOK, response)
+}
+
+// GetWasteSummary return the execution waste ratio of the inventory cost
+func (server *Server) GetWasteSummary(resp http.ResponseWriter, req *http.Request) {
+	executionID := req.PathValue("executionID")
 
-	response, err := server.storage.GetSummary(executionID, filters)
+	response, err := server.storage.GetWasteSummary(executionID)
 	if err != nil {
 		server.JSONWrite(resp, http.StatusInternalServerError, HttpErrorResponse{Error: err.Error()})
 		return
@@ -49,6 +76,30 @@ func (server *Server) GetSummary(resp http.ResponseWriter, req *http.Request) {
 	server.JSONWrite(resp, http.StatusOK, response)
 }
 
+// queryFilter returns the resources filter of the filter query param expression, and of the filter_ prefixed query
+// params, which match the resources whose fields are equal to their values
+func queryFilter(queryParams url.Values) (filter.Node, error) {
+	equals, err := filter.FromEquals(httpparameters.GetFilterQueryParamWithOutPrefix(queryParamFilterPrefix, queryParams))
+	if err != nil {
+		return nil, err
+	}
+	expression, err := filter.Parse(queryParams.Get(queryParamFilter))
+	if err != nil {
+		return nil, err
+	}
+	return filter.All(equals, expression), nil
+}
+
+// filterErrorResponse returns the response of an invalid filter, with the position of the filter expression error
+func filterErrorResponse(err error) HttpErrorResponse {
+	response := HttpErrorResponse{ErrorQuery: url.Values{queryParamFilter: {err.Error()}}}
+	var parseErr *filter.ParseError
+	if errors.As(err, &parseErr) {
+		response.ErrorPosition = parseErr.Position
+	}
+	return response
+}
+
 // GetExecutions return list collector executions
 func (server *Server) GetExecutions(resp http.ResponseWriter, req *http.Request) {
 	querylimit, _ := strconv.Atoi(httpparameters.QueryParamWithDefault(req, "querylimit", storage.GetExecutionsQueryLimit))
@@ -61,25 +112,59 @@ func (server *Server) GetExecutions(resp http.ResponseWriter, req *http.Request)
 	server.JSONWrite(resp, http.StatusOK, results)
 }
 
-// GetResourceData return resuts details by resource type
+// GetResourceData return resuts details by resource type, a page at a time.
+// The resources are optionally sorted by price, launch time or name, and searched by their name, id and tags
 func (server *Server) GetResourceData(resp http.ResponseWriter, req *http.Request) {
 	queryParams := req.URL.Query()
 	queryErrs := url.Values{}
 	resourceType := req.PathValue("type")
-	var search string
-	filters := httpparameters.GetFilterQueryParamWithOutPrefix(queryParamFilterPrefix, queryParams)
+	resourcesFilter, err := queryFilter(queryParams)
+	if err != nil {
+		server.JSONWrite(resp, http.StatusBadRequest, filterErrorResponse(err))
+		return
+	}
 
 	executionID := req.URL.Query().Get("executionID")
 	if executionID == "" {
 		queryErrs.Add("executionID", "executionID field is mandatory")
 	}
 
+	query := storage.ResourcesQuery{
+		Filter:    resourcesFilter,
+		Search:    queryParams.Get("search"),
+		SortBy:    queryParams.Get("sort_by"),
+		SortOrder: httpparameters.QueryParamWithDefault(req, "sort_order", storage.SortOrderAsc),
+		Page:      1,
+		Limit:     storage.DefaultResourcesPageLimit,
+	}
+
+	if page := queryParams.Get("page"); page != "" {
+		var err error
+		query.Page, err = strconv.Atoi(page)
+		if err != nil || query.Page < 1 {
+			queryErrs.Add("page", "page field must be a positive number")
+		}
+	}
+	if limit := queryParams.Get("limit"); limit != "" {
+		var err error
+		query.Limit, err = strconv.Atoi(limit)
+		if err != nil || query.Limit < 1 || query.Limit > storage.MaxResourcesPageLimit {
+			queryErrs.Add("limit", fmt.Sprintf("limit field must be a number between 1 and %d", storage.MaxResourcesPageLimit))
+		}
+	}
+	if query.SortBy != "" && query.SortBy != storage.SortByPrice && query.SortBy != storage.SortByLaunchTime && query.SortBy != storage.SortByName {
+		queryErrs.Add("sort_by", fmt.Sprintf("sort_by field must be %s, %s or %s", storage.SortByPrice, storage.SortByLaunchTime, storage.SortByName))
+	}
+	if query.SortOrder != storage.SortOrderAsc && query.SortOrder != storage.SortOrderDesc {
+		queryErrs.Add("sort_order", fmt.Sprintf("sort_order field must be %s or %s", storage.SortOrderAsc, storage.SortOrderDesc))
+	}
+
 	if len(queryErrs) > 0 {
 		server.JSONWrite(resp, http.StatusBadRequest, HttpErrorResponse{ErrorQuery: queryErrs})
 		return
 	}
 
-	response, err := server.storage.GetResources(resourceType, executionID, filters, search)
+	response, err := server.storage.GetResources(resourceType, executionID, query)
 	if err != nil {
 		server.JSONWrite(resp, http.StatusInternalServerError, HttpErrorResponse{Error: err.Error()})
 		return
@@ -88,15 +173,18 @@ func (server *Server) GetResourceData(resp http.ResponseWriter, req *http.Reques
 	server.JSONWrite(resp, http.StatusOK, response)
 }
 
-// GetResourceTrends return trends by resource type, id, region and metric
+// GetResourceTrends return trends by resource type, id, region and metric, optionally grouped by account or region
 func (server *Server) GetResourceTrends(resp http.ResponseWriter, req *http.Request) {
 	queryParams := req.URL.Query()
 	resourceType := req.PathValue("type")
-	filters := httpparameters.GetFilterQueryParamWithOutPrefix(queryParamFilterPrefix, queryParams)
+	resourcesFilter, err := queryFilter(queryParams)
+	if err != nil {
+		server.JSONWrite(resp, http.StatusBadRequest, filterErrorResponse(err))
+		return
+	}
 
 	limitString := req.URL.Query().Get("limit")
 	var limit int = resourceTrendsLimitDefault
-	var err error
 	if limitString != "" {
 		limit, err = strconv.Atoi(limitString)
 		if err != nil || limit < 1 {
@@ -104,7 +192,15 @@ func (server *Server) GetResourceTrends(resp http.ResponseWriter, req *http.Requ
 		}
 	}
 
-	trends, err := server.storage.GetResourceTrends(resourceType, filters, limit)
+	groupBy := queryParams.Get("group_by")
+	if groupBy != "" && groupBy != storage.GroupByAccount && groupBy != storage.GroupByRegion {
+		queryErrs := url.Values{}
+		queryErrs.Add("group_by", fmt.Sprintf("group_by field must be %s or %s", storage.GroupByAccount, storage.GroupByRegion))
+		server.JSONWrite(resp, http.StatusBadRequest, HttpErrorResponse{ErrorQuery: queryErrs})
+		return
+	}
+
+	trends, err := server.storage.GetResourceTrends(resourceType, resourcesFilter, groupBy, limit)
 	if err != nil {
 		server.JSONWrite(resp, http.StatusInternalServerError, HttpErrorResponse{Error: err.Error()})
 		return
@@ -126,10 +222,14 @@ func (server *Server) GetExecutionTags(resp http.ResponseWriter, req *http.Reque
 	server.JSONWrite(resp, http.StatusOK, response)
 }
 
-// DetectEvents save collectors events data
+// DetectEvents save collectors events data. The collector key must belong to the collector of the execution
 func (server *Server) DetectEvents(resp http.ResponseWriter, req *http.Request) {
 	executionID := req.PathValue("executionID")
 
+	if !server.authenticateCollector(resp, req, executionID) {
+		return
+	}
+
 	buf, bodyErr := io.ReadAll(req.Body)
 
 	if bodyErr != nil {
@@ -137,34 +237,313 @@ func (server *Server) DetectEvents(resp http.ResponseWriter, req *http.Request)
 		return
 	}
 
-	var detectEventsInfo []DetectEventsInfo
-	err := json.Unmarshal(buf, &detectEventsInfo)
+	var rawEvents []json.RawMessage
+	err := json.Unmarshal(buf, &rawEvents)
 	if err != nil {
 		server.JSONWrite(resp, http.StatusBadRequest, HttpErrorResponse{Error: err.Error()})
 		return
 	}
 
+	ingest := events.IngestResponse{Errors: []events.EventError{}}
+	rows := []storage.EventRow{}
+	for index, raw := range rawEvents {
+
+		event, err := events.Parse(raw)
+		if err != nil {
+			ingest.Rejected++
+			ingest.Errors = append(ingest.Errors, events.EventError{
+				Index:        index,
+				ResourceName: event.ResourceName,
+				Error:        err.Error(),
+			})
+			continue
+		}
+
+		rows = append(rows, storage.EventRow{
+			ExecutionID:   executionID,
+			SchemaVersion: event.SchemaVersion,
+			ResourceName:  event.ResourceName,
+			EventType:     event.EventType,
+			EventTime:     event.EventTime,
+			Timestamp:     time.Now(),
+			Data:          event.Data,
+		})
+	}
+
+	// With wait, the handler waits for the Meilisearch indexing task to finish before responding,
+	// so the collector only treats a batch as sent once it is stored, and sends it again otherwise
+	wait := req.URL.Query().Get("wait") == "true"
+	err = server.storage.SaveBatch(rows, wait)
+	if err != nil {
+		log.WithError(err).WithFields(log.Fields{
+			"execution_id": executionID,
+			"events":       len(rows),
+		}).Error("could not save the collector events")
+		server.JSONWrite(resp, http.StatusInternalServerError, HttpErrorResponse{Error: "could not save the collector events"})
+		return
+	}
+	ingest.Accepted = len(rows)
+	server.publishEvents(rows)
+
 	log.WithFields(log.Fields{
-		"events": len(detectEventsInfo),
+		"execution_id": executionID,
+		"accepted":     ingest.Accepted,
+		"rejected":     ingest.Rejected,
 	}).Info("Got bulk events")
 
-	go func() {
-		for _, event := range detectEventsInfo {
+	if ingest.Rejected > 0 {
+		log.WithFields(log.Fields{
+			"execution_id": executionID,
+			"errors":       ingest.Errors,
+		}).Warn("rejected collector events by the event schema")
+	}
+
+	server.JSONWrite(resp, http.StatusAccepted, ingest)
+}
 
-			rowData := storage.EventRow{
-				ExecutionID:  executionID,
-				ResourceName: event.ResourceName,
-				EventType:    event.EventType,
-				EventTime:    event.EventTime,
-				Timestamp:    time.Now(),
-				Data:         event.Data,
+// StartExecution registers the execution of the collector, before it starts collecting
+func (server *Server) StartExecution(resp http.ResponseWriter, req *http.Request) {
+	executionID := req.PathValue("executionID")
+	if !server.authenticateCollector(resp, req, executionID) {
+		return
+	}
+
+	var start events.ExecutionStart
+	err := json.NewDecoder(req.Body).Decode(&start)
+	if err != nil {
+		server.JSONWrite(resp, http.StatusBadRequest, HttpErrorResponse{Error: err.Error()})
+		return
+	}
+
+	execution, err := server.storage.GetExecution(executionID)
+	switch {
+	case errors.Is(err, storage.ErrExecutionNotFound):
+		execution = storage.Execution{
+			ExecutionID: executionID,
+			Name:        collectors.ExecutionCollectorName(executionID),
+			StartTime:   time.Now().UTC(),
+		}
+	case err != nil:
+		server.JSONWrite(resp, http.StatusInternalServerError, HttpErrorResponse{Error: err.Error()})
+		return
+	case execution.EndTime != nil:
+		server.JSONWrite(resp, http.StatusConflict, HttpErrorResponse{Error: fmt.Sprintf("the execution %s already finished", executionID)})
+		return
+	}
+
+	// A collector which registers again updates the metadata, and keeps the start time
+	execution.Accounts = start.Accounts
+	execution.Regions = start.Regions
+	execution.ConfigHash = start.ConfigHash
+	execution.CollectorVersion = start.CollectorVersion
+	err = server.storage.SaveExecution(execution)
+	if err != nil {
+		server.JSONWrite(resp, http.StatusInternalServerError, HttpErrorResponse{Error: err.Error()})
+		return
+	}
+
+	log.WithFields(log.Fields{
+		"execution_id":      executionID,
+		"collector_version": execution.CollectorVersion,
+	}).Info("collector execution started")
+	server.JSONWrite(resp, http.StatusCreated, server.executionResponse(execution))
+}
+
+// FinishExecution closes the execution of the collector, after it sent all its events
+func (server *Server) FinishExecution(resp http.ResponseWriter, req *http.Request) {
+	executionID := req.PathValue("executionID")
+	if !server.authenticateCollector(resp, req, executionID) {
+		return
+	}
+
+	execution, ok := server.getExecution(resp, executionID)
+	if !ok {
+		return
+	}
+
+	if execution.EndTime == nil {
+		endTime := time.Now().UTC()
+		execution.EndTime = &endTime
+		err := server.storage.SaveExecution(execution)
+		if err != nil {
+			server.JSONWrite(resp, http.StatusInternalServerError, HttpErrorResponse{Error: err.Error()})
+			return
+		}
+		log.WithField("execution_id", executionID).Info("collector execution finished")
+	}
+
+	response := server.executionResponse(execution)
+	if err := server.broker.Publish(executionID, streamEventExecutionFinished, response); err != nil {
+		log.WithError(err).WithField("execution_id", executionID).Error("could not publish the execution stream event")
+	}
+	server.JSONWrite(resp, http.StatusOK, response)
+}
+
+// GetExecution returns the execution with its status and progress
+func (server *Server) GetExecution(resp http.ResponseWriter, req *http.Request) {
+	execution, ok := server.getExecution(resp, req.PathValue("executionID"))
+	if !ok {
+		return
+	}
+	server.JSONWrite(resp, http.StatusOK, server.executionResponse(execution))
+}
+
+// getExecution returns the execution of the given id, and responds with an error when it could not be returned
+func (server *Server) getExecution(resp http.ResponseWriter, executionID string) (storage.Execution, bool) {
+	execution, err := server.storage.GetExecution(executionID)
+	if errors.Is(err, storage.ErrExecutionNotFound) {
+		server.JSONWrite(resp, http.StatusNotFound, HttpErrorResponse{Error: fmt.Sprintf("the execution %s was not found", executionID)})
+		return execution, false
+	}
+	if err != nil {
+		server.JSONWrite(resp, http.StatusInternalServerError, HttpErrorResponse{Error: err.Error()})
+		return execution, false
+	}
+	return execution, true
+}
+
+// executionResponse returns the execution with its progress by the service status events of its resource types.
+// An execution which did not finish is running until it did not send events for executionStaleTimeout
+func (server *Server) executionResponse(execution storage.Execution) ExecutionResponse {
+
+	response := ExecutionResponse{
+		Execution:    execution,
+		LastActivity: execution.StartTime,
+	}
+
+	summary, err := server.storage.GetSummary(execution.ExecutionID, nil)
+	if err != nil {
+		log.WithError(err).WithField("execution_id", execution.ExecutionID).Warn("could not get the execution progress")
+	}
+	for _, resource := range summary {
+		response.Progress.ResourceTypes++
+		switch resource.Status {
+		case storage.ServiceStatusFetch:
+			response.Progress.Collecting++
+		case storage.ServiceStatusError:
+			response.Progress.Failed++
+		case storage.ServiceStatusFinish:
+			response.Progress.Finished++
+		}
+		if eventTime := time.Unix(0, resource.EventTime).UTC(); eventTime.After(response.LastActivity) {
+			response.LastActivity = eventTime
+		}
+	}
+
+	switch {
+	case execution.EndTime != nil:
+		response.Status = ExecutionStatusFinished
+	case time.Since(response.LastActivity) > executionStaleTimeout:
+		response.Status = ExecutionStatusCrashed
+	default:
+		response.Status = ExecutionStatusRunning
+	}
+	return response
+}
+
+// StreamExecutionEvents streams the service status changes and the detected resources of the execution as server-sent events,
+// as they are ingested. A client which reconnects with the Last-Event-ID header resumes after its last event.
+// The detected resources are streamed only to the users which are allowed to read them
+func (server *Server) StreamExecutionEvents(resp http.ResponseWriter, req *http.Request) {
+	executionID := req.PathValue("executionID")
+
+	// The broker buffers the events of the subscribed executions, so only the registered executions are subscribed
+	if _, ok := server.getExecution(resp, executionID); !ok {
+		return
+	}
+
+	streamDetections := false
+	if claims, ok := auth.ClaimsFromContext(req.Context()); ok {
+		streamDetections = users.Role(claims.Role).Allows(users.RoleAnalyst)
+	}
+
+	subscription := server.broker.Subscribe(executionID, req.Header.Get("Last-Event-ID"))
+	defer server.broker.Unsubscribe(subscription)
+
+	controller := http.NewResponseController(resp)
+	resp.Header().Set("Content-Type", "text/event-stream")
+	resp.Header().Set("Cache-Control", "no-cache")
+	resp.Header().Set("X-Accel-Buffering", "no")
+	resp.WriteHeader(http.StatusOK)
+
+	write := func(event stream.Event) error {
+		if event.Type == events.EventResourceDetected && !streamDetections {
+			return nil
+		}
+		return stream.Write(resp, event)
+	}
+
+	for _, event := range subscription.Replay {
+		if err := write(event); err != nil {
+			return
+		}
+	}
+	if err := controller.Flush(); err != nil {
+		log.WithError(err).WithField("execution_id", executionID).Error("could not flush the execution stream")
+		return
+	}
+
+	keepAlive := time.NewTicker(streamKeepAliveInterval)
+	defer keepAlive.Stop()
+	for {
+		var err error
+		select {
+		case event, ok := <-subscription.Events:
+			if !ok {
+				// The client fell behind or the server is shutting down, the client resumes when it reconnects
+				return
 			}
-			bolB, _ := json.Marshal(rowData)
-			server.storage.Save(string(bolB))
+			err = write(event)
+		case <-keepAlive.C:
+			err = stream.WriteKeepAlive(resp)
+		case <-req.Context().Done():
+			return
+		}
+		if err == nil {
+			err = controller.Flush()
 		}
-	}()
+		if err != nil {
+			return
+		}
+	}
+}
+
+// publishEvents publishes the service status changes and the detected resources of the saved events to the execution streams
+func (server *Server) publishEvents(rows []storage.EventRow) {
+	for _, row := range rows {
+		if row.EventType != events.EventServiceStatus && row.EventType != events.EventResourceDetected {
+			continue
+		}
+		err := server.broker.Publish(row.ExecutionID, row.EventType, StreamEvent{
+			ResourceName: row.ResourceName,
+			EventTime:    row.EventTime,
+			Data:         row.Data,
+		})
+		if err != nil {
+			log.WithError(err).WithField("execution_id", row.ExecutionID).Error("could not publish the execution stream event")
+		}
+	}
+}
 
-	server.JSONWrite(resp, http.StatusAccepted, nil)
+// authenticateCollector returns true when the request collector key was issued to the collector of the given execution,
+// and responds with 401 to requests without a valid key, and with 403 to the keys of other collectors
+func (server *Server) authenticateCollector(resp http.ResponseWriter, req *http.Request, executionID string) bool {
+	key, err := server.keyStore.Authenticate(req.Header.Get(collectors.KeyHeader))
+	if err != nil {
+		log.WithError(err).WithField("execution_id", executionID).Warn("rejected collector request")
+		server.JSONWrite(resp, http.StatusUnauthorized, HttpErrorResponse{Error: err.Error()})
+		return false
+	}
+	if collectorName := collectors.ExecutionCollectorName(executionID); collectorName != key.CollectorName {
+		log.WithFields(log.Fields{
+			"execution_id":   executionID,
+			"key_id":         key.ID,
+			"collector_name": key.CollectorName,
+		}).Warn("rejected collector request of another collector")
+		server.JSONWrite(resp, http.StatusForbidden, HttpErrorResponse{Error: fmt.Sprintf("the collector key of %s can not write executions of other collectors", key.CollectorName)})
+		return false
+	}
+	return true
 }
 
 // NotFoundRoute return when route not found
@@ -187,6 +566,22 @@ func (server *Server) VersionHandler(resp http.ResponseWriter, req *http.Request
 	server.JSONWrite(resp, http.StatusOK, version)
 }
 
+// allResources returns the detected resources of all the pages of the given query
+func (server *Server) allResources(resourceType string, executionID string, query storage.ResourcesQuery) ([]map[string]interface{}, error) {
+	resources := []map[string]interface{}{}
+	query.Limit = storage.MaxResourcesPageLimit
+	for query.Page = 1; ; query.Page++ {
+		page, err := server.storage.GetResources(resourceType, executionID, query)
+		if err != nil {
+			return nil, err
+		}
+		resources = append(resources, page.Resources...)
+		if int64(query.Page) >= page.Pages || len(page.Resources) == 0 {
+			return resources, nil
+		}
+	}
+}
+
 // send pdf report via mail
 func (server *Server) SendReport(resp http.ResponseWriter, req *http.Request) {
 
@@ -219,10 +614,15 @@ func (server *Server) SendReport(resp http.ResponseWriter, req *http.Request) {
 	responseMsg := "Email sent successfully"
 	statusCode := 200
 
-	//queryParams := req.URL.Query()
-	// filters := map[string]string{}
-	//filters := httpparameters.GetFilterQueryParamWithOutPrefix(queryParamFilterPrefix, queryParams)
-	responseData, err := server.storage.GetResources(resourceType, executionID, sendEmailInfo.Filters, sendEmailInfo.Search)
+	resourcesFilter, err := filter.FromEquals(sendEmailInfo.Filters)
+	if err != nil {
+		server.JSONWrite(resp, http.StatusBadRequest, HttpErrorResponse{Error: err.Error()})
+		return
+	}
+	responseData, err := server.allResources(resourceType, executionID, storage.ResourcesQuery{
+		Filter: resourcesFilter,
+		Search: sendEmailInfo.Search,
+	})
 	if err != nil {
 		server.JSONWrite(resp, http.StatusInternalServerError, HttpErrorResponse{Error: err.Error()})
 		return
@@ -3,6 +3,7 @@ package api
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"time"
@@ -11,8 +12,12 @@ import (
 
 	log "github.com/sirupsen/logrus"
 
+	"finala/api/auth"
+	"finala/api/collectors"
 	authhandlers "finala/api/handlers"
 	"finala/api/storage"
+	"finala/api/stream"
+	"finala/api/users"
 	"finala/serverutil"
 	"finala/version"
 )
@@ -20,6 +25,12 @@ import (
 const (
 	// DrainTimeout is how long to wait until the server is drained before closing it
 	DrainTimeout = time.Second * 30
+
+	// streamBufferSize is the number of recent events of each execution, which the clients resume from
+	streamBufferSize = 1000
+
+	// streamSubscriberBuffer is the number of events a client may fall behind, before its stream is closed
+	streamSubscriberBuffer = 256
 )
 
 // Server is the API server struct
@@ -28,21 +39,32 @@ type Server struct {
 	httpserver *http.Server
 	storage    storage.StorageDescriber
 	version    version.VersionManagerDescriptor
+	jwtManager *auth.JWTManager
+	userStore  *users.Store
+	keyStore   *collectors.KeyStore
+	// oidcProvider is nil when single sign-on is not configured
+	oidcProvider *auth.OIDCProvider
+	broker       *stream.Broker
 }
 
 // NewServer returns a new Server
-func NewServer(port int, storage storage.StorageDescriber, version version.VersionManagerDescriptor) *Server {
+func NewServer(port int, storage storage.StorageDescriber, version version.VersionManagerDescriptor, jwtManager *auth.JWTManager, userStore *users.Store, keyStore *collectors.KeyStore, oidcProvider *auth.OIDCProvider) *Server {
 
 	router := http.NewServeMux()
 	// Define more specific CORS options
 	allowedOrigins := handlers.AllowedOrigins([]string{"http://localhost:8080"})
 	allowedMethods := handlers.AllowedMethods([]string{"GET", "POST", "PUT", "DELETE", "OPTIONS"})
-	allowedHeaders := handlers.AllowedHeaders([]string{"Content-Type", "Authorization", "X-Requested-With"})
+	allowedHeaders := handlers.AllowedHeaders([]string{"Content-Type", "Authorization", "X-Requested-With", "Last-Event-ID"})
 
 	return &Server{
-		router:  router,
-		storage: storage,
-		version: version,
+		router:       router,
+		storage:      storage,
+		version:      version,
+		jwtManager:   jwtManager,
+		userStore:    userStore,
+		keyStore:     keyStore,
+		oidcProvider: oidcProvider,
+		broker:       stream.NewBroker(streamBufferSize, streamSubscriberBuffer, executionStaleTimeout),
 		httpserver: &http.Server{
 			// Apply the more specific CORS options
 			Handler: handlers.CORS(allowedOrigins, allowedMethods, allowedHeaders)(router),
@@ -59,6 +81,8 @@ func (server *Server) Serve() serverutil.StopFunc {
 	stopped := make(chan bool)
 	go func() {
 		<-ctx.Done()
+		// The execution streams do not end by themselves, so they are closed before draining the server
+		server.broker.Close()
 		serverCtx, serverCancelFn := context.WithTimeout(context.Background(), DrainTimeout)
 		err := server.httpserver.Shutdown(serverCtx)
 		if err != nil {
@@ -84,23 +108,102 @@ func (server *Server) Serve() serverutil.StopFunc {
 // BindEndpoints sets up the router to handle API endpoints
 func (server *Server) BindEndpoints() {
 	// Add pattern handlers using Go 1.22's ServeMux
-	server.router.HandleFunc("GET /api/v1/summary/{executionID}", server.GetSummary)
-	server.router.HandleFunc("GET /api/v1/executions", server.GetExecutions)
-	server.router.HandleFunc("GET /api/v1/resources/{type}", server.GetResourceData)
-	server.router.HandleFunc("GET /api/v1/trends/{type}", server.GetResourceTrends)
-	server.router.HandleFunc("GET /api/v1/tags/{executionID}", server.GetExecutionTags)
+	server.router.HandleFunc("GET /api/v1/summary/{executionID}", server.authorized(users.RoleViewer, server.GetSummary))
+	server.router.HandleFunc("GET /api/v1/summary/{executionID}/waste", server.authorized(users.RoleViewer, server.GetWasteSummary))
+	server.router.HandleFunc("GET /api/v1/executions", server.authorized(users.RoleViewer, server.GetExecutions))
+	server.router.HandleFunc("GET /api/v1/executions/{executionID}", server.authorized(users.RoleViewer, server.GetExecution))
+	server.router.HandleFunc("GET /api/v1/executions/{executionID}/events", server.authorizedStream(users.RoleViewer, server.StreamExecutionEvents))
+	server.router.HandleFunc("GET /api/v1/tags/{executionID}", server.authorized(users.RoleViewer, server.GetExecutionTags))
+	server.router.HandleFunc("GET /api/v1/resources/{type}", server.authorized(users.RoleAnalyst, server.GetResourceData))
+	server.router.HandleFunc("GET /api/v1/trends/{type}", server.authorized(users.RoleAnalyst, server.GetResourceTrends))
+	server.router.HandleFunc("POST /api/v1/send-report", server.authorized(users.RoleAdmin, server.SendReport))
+
+	// Users management
+	userHandlers := authhandlers.NewUserHandlers(server.userStore)
+	server.router.HandleFunc("GET /api/v1/users", server.authorized(users.RoleAdmin, userHandlers.ListUsers))
+	server.router.HandleFunc("POST /api/v1/users", server.authorized(users.RoleAdmin, userHandlers.CreateUser))
+	server.router.HandleFunc("PUT /api/v1/users/{username}", server.authorized(users.RoleAdmin, userHandlers.UpdateUser))
+	server.router.HandleFunc("DELETE /api/v1/users/{username}", server.authorized(users.RoleAdmin, userHandlers.DeleteUser))
+
+	// Collector keys management
+	keyHandlers := authhandlers.NewCollectorKeyHandlers(server.keyStore)
+	server.router.HandleFunc("GET /api/v1/collector-keys", server.authorized(users.RoleAdmin, keyHandlers.ListCollectorKeys))
+	server.router.HandleFunc("POST /api/v1/collector-keys", server.authorized(users.RoleAdmin, keyHandlers.IssueCollectorKey))
+	server.router.HandleFunc("DELETE /api/v1/collector-keys/{keyID}", server.authorized(users.RoleAdmin, keyHandlers.RevokeCollectorKey))
+
+	// The collectors are not users of the api, and are authenticated by their collector key instead of a user token
 	server.router.HandleFunc("POST /api/v1/detect-events/{executionID}", server.DetectEvents)
-	server.router.HandleFunc("POST /api/v1/send-report", server.SendReport)
+	server.router.HandleFunc("POST /api/v1/executions/{executionID}/start", server.StartExecution)
+	server.router.HandleFunc("POST /api/v1/executions/{executionID}/finish", server.FinishExecution)
+
+	// Public routes
 	server.router.HandleFunc("GET /api/v1/version", server.VersionHandler)
 	server.router.HandleFunc("GET /api/v1/health", server.HealthCheckHandler)
+	server.router.HandleFunc("GET /api/v1/auth/providers", authhandlers.AuthProvidersHandler(server.oidcProvider))
+	if server.oidcProvider != nil && server.oidcProvider.PasswordLoginDisabled() {
+		server.router.HandleFunc("POST /api/v1/auth/login", authhandlers.PasswordLoginDisabledHandler)
+	} else {
+		server.router.HandleFunc("POST /api/v1/auth/login", authhandlers.LoginHandler(server.userStore, server.jwtManager))
+	}
 
-	// ADDED: Login route
-	server.router.HandleFunc("POST /api/v1/auth/login", authhandlers.LoginHandler)
+	// Single sign-on
+	if server.oidcProvider != nil {
+		oidcHandlers := authhandlers.NewOIDCHandlers(server.oidcProvider, server.userStore, server.jwtManager)
+		server.router.HandleFunc("GET /api/v1/auth/oidc/login", oidcHandlers.Login)
+		server.router.HandleFunc("GET /api/v1/auth/oidc/callback", oidcHandlers.Callback)
+	}
 
 	// Add a catch-all handler for not found routes
 	server.router.HandleFunc("/", server.NotFoundRoute)
 }
 
+// authorized wraps the given handler, and responds with 401 to requests without a valid bearer token,
+// and with 403 to users without the required role. The role is taken from the user store, so role changes
+// and deleted users take effect before their tokens expire
+func (server *Server) authorized(role users.Role, handler http.HandlerFunc) http.HandlerFunc {
+	return server.authorizedBy(server.jwtManager.Authenticate, role, handler)
+}
+
+// authorizedStream wraps the given event stream handler like authorized, and also accepts the bearer token in the
+// access_token query parameter, since browsers cannot set the headers of an EventSource
+func (server *Server) authorizedStream(role users.Role, handler http.HandlerFunc) http.HandlerFunc {
+	return server.authorizedBy(server.jwtManager.AuthenticateWithQuery, role, handler)
+}
+
+// authorizedBy wraps the given handler with the token claims of the given authentication
+func (server *Server) authorizedBy(authenticate func(*http.Request) (*auth.Claims, error), role users.Role, handler http.HandlerFunc) http.HandlerFunc {
+	return func(resp http.ResponseWriter, req *http.Request) {
+		claims, err := authenticate(req)
+		if err == nil {
+			var user users.User
+			user, err = server.userStore.Get(claims.Subject)
+			claims.Role = string(user.Role)
+		}
+		if err != nil {
+			log.WithError(err).WithField("path", req.URL.Path).Debug("unauthenticated request")
+			challenge := `Bearer realm="finala"`
+			if !errors.Is(err, auth.ErrMissingToken) {
+				challenge = fmt.Sprintf(`%s, error="invalid_token", error_description=%q`, challenge, err.Error())
+			}
+			resp.Header().Set("WWW-Authenticate", challenge)
+			server.JSONWrite(resp, http.StatusUnauthorized, HttpErrorResponse{Error: err.Error()})
+			return
+		}
+
+		if !users.Role(claims.Role).Allows(role) {
+			log.WithFields(log.Fields{
+				"path":     req.URL.Path,
+				"username": claims.Subject,
+				"role":     claims.Role,
+			}).Debug("unauthorized request")
+			server.JSONWrite(resp, http.StatusForbidden, HttpErrorResponse{Error: fmt.Sprintf("the %s role is required", role)})
+			return
+		}
+
+		handler(resp, req.WithContext(auth.ContextWithClaims(req.Context(), claims)))
+	}
+}
+
 // Router returns the Go ServeMux HTTP router defined for this server
 func (server *Server) Router() *http.ServeMux {
 	return server.router
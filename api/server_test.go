@@ -1,29 +1,112 @@
 package api_test
 
 import (
-	"bytes"
+	"bufio"
+	"context"
 	"encoding/json"
+	"errors"
 	"finala/api"
+	"finala/api/auth"
+	"finala/api/collectors"
+	apiconfig "finala/api/config"
 	"finala/api/storage"
 	"finala/api/testutils"
+	"finala/api/users"
+	"finala/events"
+	"fmt"
 	"io"
 	"log"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
 	"time"
 
 	notifier "github.com/similarweb/client-notifier"
 )
 
+const (
+	mockSigningKeyID = "current"
+	mockSigningKey   = "current-signing-key-of-at-least-32-bytes"
+)
+
+func MockJWTManager(signingKeys ...apiconfig.JWTSigningKeyConfig) *auth.JWTManager {
+	if len(signingKeys) == 0 {
+		signingKeys = []apiconfig.JWTSigningKeyConfig{{ID: mockSigningKeyID, Key: mockSigningKey}}
+	}
+	jwtManager, err := auth.NewJWTManager(apiconfig.JWTConfig{SigningKeys: signingKeys})
+	if err != nil {
+		log.Fatal(err)
+	}
+	return jwtManager
+}
+
+// mockUserStore holds a user of each role, the users are named after their role
+var mockUserStore *users.Store
+
+// mockKeyStore holds a key of the general collector, mockCollectorKey
+var mockKeyStore *collectors.KeyStore
+
+// mockCollectorKey is the key of the general collector
+var mockCollectorKey string
+
+func TestMain(m *testing.M) {
+	usersDir, err := os.MkdirTemp("", "finala-users")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	mockUserStore, err = users.NewStore(filepath.Join(usersDir, "users.yaml"))
+	if err != nil {
+		log.Fatal(err)
+	}
+	for _, role := range []users.Role{users.RoleViewer, users.RoleAnalyst, users.RoleAdmin} {
+		_, err = mockUserStore.Create(string(role), fmt.Sprintf("%s-password", role), role)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	mockKeyStore, err = collectors.NewKeyStore(filepath.Join(usersDir, "collector_keys.yaml"))
+	if err != nil {
+		log.Fatal(err)
+	}
+	_, mockCollectorKey, err = mockKeyStore.Issue("general")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	code := m.Run()
+	os.RemoveAll(usersDir)
+	os.Exit(code)
+}
+
 func MockServer() (*api.Server, *testutils.MockStorage) {
 	version := testutils.NewMockVersion()
 
 	mockStorage := testutils.NewMockStorage()
-	server := api.NewServer(9090, mockStorage, version)
+	server := api.NewServer(9090, mockStorage, version, MockJWTManager(), mockUserStore, mockKeyStore, nil)
 	return server, mockStorage
 }
 
+// newAuthenticatedRequest returns a new request with a valid bearer token
+func newAuthenticatedRequest(method, url string, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequest(method, url, body)
+	if err != nil {
+		return nil, err
+	}
+	token, _, err := MockJWTManager().GenerateJWT("admin", "admin")
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
+	return req, nil
+}
+
 func TestInvalidRoue(t *testing.T) {
 
 	ms, _ := MockServer()
@@ -82,24 +165,31 @@ func TestHealthRequest(t *testing.T) {
 }
 
 func TestGetSummary(t *testing.T) {
-	ms, _ := MockServer()
+	ms, mockStorage := MockServer()
 	ms.Serve()
 
+	expression := url.QueryEscape(`PricePerMonth > 100 AND Region IN (us-east-1, eu-west-1)`)
+
 	testCases := []struct {
 		endpoint           string
 		expectedStatusCode int
 		Count              int
+		expectedFilter     string
+		expectedPosition   int
 	}{
-		{"/api/v1/summary", http.StatusNotFound, 0},
-		{"/api/v1/summary/1", http.StatusOK, 2},
-		{"/api/v1/summary/err", http.StatusInternalServerError, 2},
+		{"/api/v1/summary", http.StatusNotFound, 0, "", 0},
+		{"/api/v1/summary/1", http.StatusOK, 2, "", 0},
+		{"/api/v1/summary/1?filter_Data.Tag.team=data,infra&filter=" + expression, http.StatusOK, 2, `(Data.Tag.team IN ("data", "infra") AND (Data.PricePerMonth > 100 AND Data.Region IN ("us-east-1", "eu-west-1")))`, 0},
+		{"/api/v1/summary/1?filter=" + url.QueryEscape(`PricePerMonth > high`), http.StatusBadRequest, 0, "", 17},
+		{"/api/v1/summary/1?filter_Region%3D1=1", http.StatusBadRequest, 0, "", 0},
+		{"/api/v1/summary/err", http.StatusInternalServerError, 2, "", 0},
 	}
 
 	for _, test := range testCases {
 		t.Run(test.endpoint, func(t *testing.T) {
 
 			rr := httptest.NewRecorder()
-			req, err := http.NewRequest("GET", test.endpoint, nil)
+			req, err := newAuthenticatedRequest("GET", test.endpoint, nil)
 			if err != nil {
 				t.Fatal(err)
 			}
@@ -124,6 +214,22 @@ func TestGetSummary(t *testing.T) {
 				if len(summaryData) != test.Count {
 					t.Fatalf("unexpected resources summary response, got %d expected %d", len(summaryData), test.Count)
 				}
+
+				resourcesFilter := ""
+				if mockStorage.Filter != nil {
+					resourcesFilter = mockStorage.Filter.String()
+				}
+				if resourcesFilter != test.expectedFilter {
+					t.Fatalf("unexpected resources filter, got %s expected %s", resourcesFilter, test.expectedFilter)
+				}
+			} else if test.expectedStatusCode == http.StatusBadRequest {
+				var errorResponse api.HttpErrorResponse
+				if err := json.NewDecoder(rr.Body).Decode(&errorResponse); err != nil {
+					t.Fatalf("could not decode response: %v", err)
+				}
+				if errorResponse.ErrorQuery.Get("filter") == "" || errorResponse.ErrorPosition != test.expectedPosition {
+					t.Fatalf("unexpected filter error, got %+v expected position %d", errorResponse, test.expectedPosition)
+				}
 			} else {
 				if test.expectedStatusCode != rr.Code {
 					t.Fatalf("unexpected status code, got %d expected %d", rr.Code, test.expectedStatusCode)
@@ -134,31 +240,90 @@ func TestGetSummary(t *testing.T) {
 
 }
 
-func TestGetResourcesData(t *testing.T) {
+func TestGetWasteSummary(t *testing.T) {
 	ms, _ := MockServer()
 	ms.Serve()
 
+	testCases := []struct {
+		endpoint           string
+		expectedStatusCode int
+		resourceTypesCount int
+	}{
+		{"/api/v1/summary/1/waste", http.StatusOK, 2},
+		{"/api/v1/summary/err/waste", http.StatusInternalServerError, 0},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.endpoint, func(t *testing.T) {
+
+			rr := httptest.NewRecorder()
+			req, err := newAuthenticatedRequest("GET", test.endpoint, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			ms.Router().ServeHTTP(rr, req)
+			if rr.Code != test.expectedStatusCode {
+				t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, test.expectedStatusCode)
+			}
+
+			if test.expectedStatusCode != http.StatusOK {
+				return
+			}
+
+			body, err := io.ReadAll(rr.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			wasteData := storage.WasteSummary{}
+			err = json.Unmarshal(body, &wasteData)
+			if err != nil {
+				t.Fatalf("Could not parse http response")
+			}
+
+			if len(wasteData.ResourceTypes) != test.resourceTypesCount {
+				t.Fatalf("unexpected waste resource types response, got %d expected %d", len(wasteData.ResourceTypes), test.resourceTypesCount)
+			}
+
+			if wasteData.Total.WasteRatio != 0.25 {
+				t.Fatalf("unexpected waste ratio response, got %f expected %f", wasteData.Total.WasteRatio, 0.25)
+			}
+		})
+	}
+
+}
+
+func TestGetResourcesData(t *testing.T) {
+	ms, mockStorage := MockServer()
+	ms.Serve()
+
 	testCases := []struct {
 		endpoint           string
 		expectedStatusCode int
 		Count              int
+		expectedQuery      storage.ResourcesQuery
 	}{
-		{"/api/v1/resources/table", http.StatusBadRequest, 0},
-		{"/api/v1/resources/table?executionID=1", http.StatusOK, 2},
-		{"/api/v1/resources/table?executionID=err", http.StatusInternalServerError, 0},
+		{"/api/v1/resources/table", http.StatusBadRequest, 0, storage.ResourcesQuery{}},
+		{"/api/v1/resources/table?executionID=1", http.StatusOK, 2, storage.ResourcesQuery{SortOrder: storage.SortOrderAsc, Page: 1, Limit: storage.DefaultResourcesPageLimit}},
+		{"/api/v1/resources/table?executionID=1&page=3&limit=10&sort_by=price&sort_order=desc&search=web", http.StatusOK, 2, storage.ResourcesQuery{Search: "web", SortBy: storage.SortByPrice, SortOrder: storage.SortOrderDesc, Page: 3, Limit: 10}},
+		{"/api/v1/resources/table?executionID=1&page=0", http.StatusBadRequest, 0, storage.ResourcesQuery{}},
+		{"/api/v1/resources/table?executionID=1&limit=5000", http.StatusBadRequest, 0, storage.ResourcesQuery{}},
+		{"/api/v1/resources/table?executionID=1&sort_by=cost", http.StatusBadRequest, 0, storage.ResourcesQuery{}},
+		{"/api/v1/resources/table?executionID=1&sort_order=up", http.StatusBadRequest, 0, storage.ResourcesQuery{}},
+		{"/api/v1/resources/table?executionID=err", http.StatusInternalServerError, 0, storage.ResourcesQuery{}},
 	}
 
 	for _, test := range testCases {
 		t.Run(test.endpoint, func(t *testing.T) {
 			rr := httptest.NewRecorder()
-			req, err := http.NewRequest("GET", test.endpoint, nil)
+			req, err := newAuthenticatedRequest("GET", test.endpoint, nil)
 			if err != nil {
 				t.Fatal(err)
 			}
 
 			ms.Router().ServeHTTP(rr, req)
 			if rr.Code != test.expectedStatusCode {
-				t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusOK)
+				t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, test.expectedStatusCode)
 			}
 
 			if test.expectedStatusCode == http.StatusOK {
@@ -168,20 +333,22 @@ func TestGetResourcesData(t *testing.T) {
 					t.Fatal(err)
 				}
 
-				resourceData := &[]map[string]interface{}{}
-				err = json.Unmarshal(body, resourceData)
+				resourcesPage := &storage.ResourcesPage{}
+				err = json.Unmarshal(body, resourcesPage)
 				if err != nil {
 					t.Fatalf("Could not parse http response")
 				}
 
-				if len(*resourceData) != test.Count {
-					t.Fatalf("unexpected resources data response, got %d expected %d", len(*resourceData), test.Count)
+				if len(resourcesPage.Resources) != test.Count || resourcesPage.Total != int64(test.Count) {
+					t.Fatalf("unexpected resources data response, got %d expected %d", len(resourcesPage.Resources), test.Count)
 				}
 
-			} else {
-				if test.expectedStatusCode != rr.Code {
-					t.Fatalf("unexpected status code, got %d expected %d", rr.Code, test.expectedStatusCode)
+				query := mockStorage.ResourcesQuery
+				query.Filter = nil
+				if !reflect.DeepEqual(query, test.expectedQuery) {
+					t.Fatalf("unexpected resources query, got %+v expected %+v", query, test.expectedQuery)
 				}
+
 			}
 
 		})
@@ -203,7 +370,7 @@ func TestGetExecutions(t *testing.T) {
 	for _, test := range testCases {
 		t.Run(test.endpoint, func(t *testing.T) {
 			rr := httptest.NewRecorder()
-			req, err := http.NewRequest("GET", test.endpoint, nil)
+			req, err := newAuthenticatedRequest("GET", test.endpoint, nil)
 			if err != nil {
 				t.Fatal(err)
 			}
@@ -236,55 +403,184 @@ func TestSave(t *testing.T) {
 	ms, mockStorage := MockServer()
 	ms.Serve()
 
-	type tempBodyData struct {
-		Resource string
+	_, otherCollectorKey, err := mockKeyStore.Issue("other")
+	if err != nil {
+		t.Fatal(err)
 	}
 
+	serviceStatus := `{"SchemaVersion":2,"ResourceName":"aws_ec2","EventType":"service_status","EventTime":1600000000000000000,"Data":{"Status":2,"ErrorMessage":""}}`
+	detected := `{"SchemaVersion":2,"ResourceName":"aws_ec2","EventType":"resource_detected","EventTime":1600000000000000000,"Data":{"Metric":"CPU","ResourceID":"i-1","PricePerMonth":10.5,"AccountID":"1","AccountName":"prod","Region":"us-east-1"}}`
+	detectedV1 := `{"ResourceName":"aws_ec2","EventType":"resource_detected","EventTime":1600000000000000000,"Data":{"Metric":"CPU","ResourceID":"i-2","PricePerMonth":10.5}}`
+	detectedV2WithoutAccount := `{"SchemaVersion":2,"ResourceName":"aws_ec2","EventType":"resource_detected","EventTime":1600000000000000000,"Data":{"Metric":"CPU","ResourceID":"i-3","PricePerMonth":10.5}}`
+	unknownEventType := `{"SchemaVersion":2,"ResourceName":"aws_ec2","EventType":"unknown","EventTime":1600000000000000000,"Data":{}}`
+	unsupportedVersion := `{"SchemaVersion":9,"ResourceName":"aws_ec2","EventType":"service_status","EventTime":1600000000000000000,"Data":{"Status":2}}`
+
 	testCases := []struct {
+		name               string
 		endpoint           string
+		collectorKey       string
+		body               string
+		storageErr         error
 		expectedStatusCode int
-		BodyRequest        []tempBodyData
+		expectedAccepted   int
+		expectedRejected   int
+		expectedWait       bool
 	}{
-		{"/api/v1/detect-events/executionID=1", http.StatusAccepted, []tempBodyData{
-			{Resource: "resource_1"},
-			{Resource: "resource_2"},
-		}},
+		{"collector key", "/api/v1/detect-events/general_1600000000", mockCollectorKey, "[" + serviceStatus + "," + detected + "]", nil, http.StatusAccepted, 2, 0, false},
+		{"wait for storage", "/api/v1/detect-events/general_1600000000?wait=true", mockCollectorKey, "[" + serviceStatus + "]", nil, http.StatusAccepted, 1, 0, true},
+		{"previous schema version", "/api/v1/detect-events/general_1600000000", mockCollectorKey, "[" + detectedV1 + "]", nil, http.StatusAccepted, 1, 0, false},
+		{"invalid events", "/api/v1/detect-events/general_1600000000", mockCollectorKey, "[" + detected + "," + detectedV2WithoutAccount + "," + unknownEventType + "," + unsupportedVersion + `,"event"]`, nil, http.StatusAccepted, 1, 4, false},
+		{"storage error", "/api/v1/detect-events/general_1600000000?wait=true", mockCollectorKey, "[" + serviceStatus + "]", errors.New("unavailable"), http.StatusInternalServerError, 0, 0, false},
+		{"not an events array", "/api/v1/detect-events/general_1600000000", mockCollectorKey, serviceStatus, nil, http.StatusBadRequest, 0, 0, false},
+		{"missing collector key", "/api/v1/detect-events/general_1600000000", "", "[" + serviceStatus + "]", nil, http.StatusUnauthorized, 0, 0, false},
+		{"invalid collector key", "/api/v1/detect-events/general_1600000000", "foo.bar", "[" + serviceStatus + "]", nil, http.StatusUnauthorized, 0, 0, false},
+		{"other collector key", "/api/v1/detect-events/general_1600000000", otherCollectorKey, "[" + serviceStatus + "]", nil, http.StatusForbidden, 0, 0, false},
 	}
 
 	for _, test := range testCases {
-		t.Run(test.endpoint, func(t *testing.T) {
+		t.Run(test.name, func(t *testing.T) {
 			rr := httptest.NewRecorder()
 
-			buf, err := json.Marshal(test.BodyRequest)
-			if err != nil {
-				log.Fatal(err)
-			}
-
-			req, err := http.NewRequest("POST", test.endpoint, bytes.NewBuffer(buf))
+			req, err := http.NewRequest("POST", test.endpoint, strings.NewReader(test.body))
 			if err != nil {
 				t.Fatal(err)
 			}
+			if test.collectorKey != "" {
+				req.Header.Set(collectors.KeyHeader, test.collectorKey)
+			}
 
+			savedEvents := mockStorage.Events
+			mockStorage.SaveBatchErr = test.storageErr
 			ms.Router().ServeHTTP(rr, req)
+			mockStorage.SaveBatchErr = nil
 			if rr.Code != test.expectedStatusCode {
-				t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusOK)
+				t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, test.expectedStatusCode)
+			}
+
+			// Only the accepted events are saved
+			if mockStorage.Events-savedEvents != test.expectedAccepted {
+				t.Fatalf("unexpected saved events, got %d expected %d", mockStorage.Events-savedEvents, test.expectedAccepted)
 			}
 
-			time.Sleep(time.Second * 1)
-			if rr.Code == http.StatusAccepted {
-				if mockStorage.Events != len(test.BodyRequest) {
-					t.Fatalf("unexpected saved data, got %d expected %d", rr.Code, test.expectedStatusCode)
+			if rr.Code != http.StatusAccepted {
+				return
+			}
+			if mockStorage.Waited != test.expectedWait {
+				t.Fatalf("unexpected storage wait, got %t expected %t", mockStorage.Waited, test.expectedWait)
+			}
 
-				}
+			var ingest events.IngestResponse
+			if err := json.NewDecoder(rr.Body).Decode(&ingest); err != nil {
+				t.Fatalf("could not decode response: %v", err)
+			}
+			if ingest.Accepted != test.expectedAccepted || ingest.Rejected != test.expectedRejected || len(ingest.Errors) != test.expectedRejected {
+				t.Fatalf("unexpected ingest response, got %+v expected %d accepted and %d rejected", ingest, test.expectedAccepted, test.expectedRejected)
+			}
+		})
+	}
+
+}
+
+func TestExecutionLifecycle(t *testing.T) {
+	ms, mockStorage := MockServer()
+	ms.Serve()
+
+	_, otherCollectorKey, err := mockKeyStore.Issue("other")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	start := `{"accounts":["prod"],"regions":["us-east-1"],"config_hash":"abc","collector_version":"1.0.0"}`
+
+	testCases := []struct {
+		name               string
+		method             string
+		endpoint           string
+		collectorKey       string
+		authenticated      bool
+		body               string
+		expectedStatusCode int
+		expectedStatus     string
+	}{
+		{"start", "POST", "/api/v1/executions/general_1600000000/start", mockCollectorKey, false, start, http.StatusCreated, api.ExecutionStatusRunning},
+		{"start again", "POST", "/api/v1/executions/general_1600000000/start", mockCollectorKey, false, start, http.StatusCreated, api.ExecutionStatusRunning},
+		{"start without collector key", "POST", "/api/v1/executions/general_1600000000/start", "", false, start, http.StatusUnauthorized, ""},
+		{"start other collector execution", "POST", "/api/v1/executions/general_1600000000/start", otherCollectorKey, false, start, http.StatusForbidden, ""},
+		{"start invalid body", "POST", "/api/v1/executions/general_1600000001/start", mockCollectorKey, false, "[", http.StatusBadRequest, ""},
+		{"get", "GET", "/api/v1/executions/general_1600000000", "", true, "", http.StatusOK, api.ExecutionStatusRunning},
+		{"get without token", "GET", "/api/v1/executions/general_1600000000", "", false, "", http.StatusUnauthorized, ""},
+		{"get unknown execution", "GET", "/api/v1/executions/general_1", "", true, "", http.StatusNotFound, ""},
+		{"finish", "POST", "/api/v1/executions/general_1600000000/finish", mockCollectorKey, false, "", http.StatusOK, api.ExecutionStatusFinished},
+		{"finish again", "POST", "/api/v1/executions/general_1600000000/finish", mockCollectorKey, false, "", http.StatusOK, api.ExecutionStatusFinished},
+		{"finish unknown execution", "POST", "/api/v1/executions/general_1/finish", mockCollectorKey, false, "", http.StatusNotFound, ""},
+		{"start finished execution", "POST", "/api/v1/executions/general_1600000000/start", mockCollectorKey, false, start, http.StatusConflict, ""},
+		{"get finished execution", "GET", "/api/v1/executions/general_1600000000", "", true, "", http.StatusOK, api.ExecutionStatusFinished},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			var req *http.Request
+			var err error
+			if test.authenticated {
+				req, err = newAuthenticatedRequest(test.method, test.endpoint, strings.NewReader(test.body))
 			} else {
-				if test.expectedStatusCode != rr.Code {
-					t.Fatalf("unexpected status code, got %d expected %d", rr.Code, test.expectedStatusCode)
-				}
+				req, err = http.NewRequest(test.method, test.endpoint, strings.NewReader(test.body))
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if test.collectorKey != "" {
+				req.Header.Set(collectors.KeyHeader, test.collectorKey)
 			}
 
+			rr := httptest.NewRecorder()
+			ms.Router().ServeHTTP(rr, req)
+			if rr.Code != test.expectedStatusCode {
+				t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, test.expectedStatusCode)
+			}
+			if test.expectedStatus == "" {
+				return
+			}
+
+			var execution api.ExecutionResponse
+			if err := json.NewDecoder(rr.Body).Decode(&execution); err != nil {
+				t.Fatalf("could not decode response: %v", err)
+			}
+			if execution.ExecutionID != "general_1600000000" || execution.Name != "general" || execution.CollectorVersion != "1.0.0" {
+				t.Fatalf("unexpected execution, got %+v", execution.Execution)
+			}
+			if execution.Status != test.expectedStatus {
+				t.Fatalf("unexpected execution status, got %s expected %s", execution.Status, test.expectedStatus)
+			}
+			if execution.Progress.ResourceTypes != 2 || execution.Progress.Failed != 2 {
+				t.Fatalf("unexpected execution progress, got %+v", execution.Progress)
+			}
 		})
 	}
 
+	if execution := mockStorage.Executions["general_1600000000"]; execution.EndTime == nil || len(execution.Regions) != 1 {
+		t.Fatalf("unexpected saved execution, got %+v", execution)
+	}
+
+	// An execution without events since the stale timeout is reported as crashed
+	mockStorage.Executions["general_1500000000"] = storage.Execution{
+		ExecutionID: "general_1500000000",
+		Name:        "general",
+		StartTime:   time.Now().Add(-2 * time.Hour),
+	}
+	req, err := newAuthenticatedRequest("GET", "/api/v1/executions/general_1500000000", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rr := httptest.NewRecorder()
+	ms.Router().ServeHTTP(rr, req)
+	var execution api.ExecutionResponse
+	if err := json.NewDecoder(rr.Body).Decode(&execution); err != nil {
+		t.Fatalf("could not decode response: %v", err)
+	}
+	if execution.Status != api.ExecutionStatusCrashed {
+		t.Fatalf("unexpected execution status, got %s expected %s", execution.Status, api.ExecutionStatusCrashed)
+	}
 }
 
 func TestGetExecutionTags(t *testing.T) {
@@ -303,7 +599,7 @@ func TestGetExecutionTags(t *testing.T) {
 	for _, test := range testCases {
 		t.Run(test.endpoint, func(t *testing.T) {
 			rr := httptest.NewRecorder()
-			req, err := http.NewRequest("GET", test.endpoint, nil)
+			req, err := newAuthenticatedRequest("GET", test.endpoint, nil)
 			if err != nil {
 				t.Fatal(err)
 			}
@@ -350,13 +646,15 @@ func TestGetResourceTrends(t *testing.T) {
 	}{
 		{"/api/v1/trends/aws_elbv2", http.StatusOK, 2},
 		{"/api/v1/trends/aws_elbv2?limit=1", http.StatusOK, 1},
+		{"/api/v1/trends/aws_elbv2?group_by=account", http.StatusOK, 2},
+		{"/api/v1/trends/aws_elbv2?group_by=foo", http.StatusBadRequest, 0},
 		{"/api/v1/trends/err", http.StatusInternalServerError, 0},
 	}
 
 	for _, test := range testCases {
 		t.Run(test.endpoint, func(t *testing.T) {
 			rr := httptest.NewRecorder()
-			req, err := http.NewRequest("GET", test.endpoint, nil)
+			req, err := newAuthenticatedRequest("GET", test.endpoint, nil)
 			if err != nil {
 				t.Fatal(err)
 			}
@@ -451,3 +749,314 @@ func TestVersion(t *testing.T) {
 	}
 
 }
+
+func TestAuthentication(t *testing.T) {
+	ms, _ := MockServer()
+	ms.Serve()
+
+	tokens := map[string]string{}
+	for _, username := range []string{"viewer", "analyst", "admin", "deleted"} {
+		token, _, err := MockJWTManager().GenerateJWT(username, "admin")
+		if err != nil {
+			t.Fatal(err)
+		}
+		tokens[username] = fmt.Sprintf("Bearer %s", token)
+	}
+	validToken := tokens["admin"]
+	previousKeyToken, _, err := MockJWTManager(apiconfig.JWTSigningKeyConfig{ID: "previous", Key: "previous-signing-key-of-at-least-32-bytes"}).GenerateJWT("admin", "admin")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The server accepts tokens signed by the previous key while it is configured
+	rotatedJWTManager := MockJWTManager(
+		apiconfig.JWTSigningKeyConfig{ID: mockSigningKeyID, Key: mockSigningKey},
+		apiconfig.JWTSigningKeyConfig{ID: "previous", Key: "previous-signing-key-of-at-least-32-bytes"},
+	)
+	rotatedServer := api.NewServer(9090, testutils.NewMockStorage(), testutils.NewMockVersion(), rotatedJWTManager, mockUserStore, mockKeyStore, nil)
+	rotatedServer.BindEndpoints()
+
+	// The single sign-on server signs in only through the identity provider
+	mockOIDCProvider, err := testutils.NewMockOIDCProvider()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mockOIDCProvider.Server.Close()
+	oidcProvider, err := auth.NewOIDCProvider(context.Background(), apiconfig.OIDCConfig{
+		IssuerURL:            mockOIDCProvider.IssuerURL(),
+		ClientID:             testutils.MockOIDCClientID,
+		ClientSecret:         testutils.MockOIDCClientSecret,
+		RedirectURL:          "http://127.0.0.1:9090/api/v1/auth/oidc/callback",
+		UIRedirectURL:        "http://127.0.0.1:8080/login",
+		DefaultRole:          "viewer",
+		DisablePasswordLogin: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ssoServer := api.NewServer(9090, testutils.NewMockStorage(), testutils.NewMockVersion(), MockJWTManager(), mockUserStore, mockKeyStore, oidcProvider)
+	ssoServer.BindEndpoints()
+
+	testCases := []struct {
+		name               string
+		server             *api.Server
+		method             string
+		endpoint           string
+		authorization      string
+		expectedStatusCode int
+		expectedError      string
+	}{
+		{"health is public", ms, "GET", "/api/v1/health", "", http.StatusOK, ""},
+		{"version is public", ms, "GET", "/api/v1/version", "", http.StatusOK, ""},
+		{"login is public", ms, "POST", "/api/v1/auth/login", "", http.StatusBadRequest, ""},
+		{"auth providers is public", ms, "GET", "/api/v1/auth/providers", "", http.StatusOK, ""},
+		{"single sign-on is not configured", ms, "GET", "/api/v1/auth/oidc/login", "", http.StatusNotFound, ""},
+		{"single sign-on login is public", ssoServer, "GET", "/api/v1/auth/oidc/login", "", http.StatusFound, ""},
+		{"password login is disabled", ssoServer, "POST", "/api/v1/auth/login", "", http.StatusForbidden, "Password login is disabled, sign in with single sign-on"},
+		{"missing token", ms, "GET", "/api/v1/executions", "", http.StatusUnauthorized, auth.ErrMissingToken.Error()},
+		{"not a bearer token", ms, "GET", "/api/v1/executions", "Basic YWRtaW46YWRtaW4=", http.StatusUnauthorized, auth.ErrMissingToken.Error()},
+		{"invalid token", ms, "POST", "/api/v1/send-report", "Bearer foo", http.StatusUnauthorized, auth.ErrInvalidToken.Error()},
+		{"unknown signing key", ms, "GET", "/api/v1/executions", fmt.Sprintf("Bearer %s", previousKeyToken), http.StatusUnauthorized, auth.ErrInvalidToken.Error()},
+		{"valid token", ms, "GET", "/api/v1/executions", validToken, http.StatusOK, ""},
+		{"deleted user", ms, "GET", "/api/v1/executions", tokens["deleted"], http.StatusUnauthorized, users.ErrUserNotFound.Error()},
+		{"viewer summary", ms, "GET", "/api/v1/summary/1", tokens["viewer"], http.StatusOK, ""},
+		// The role is taken from the user store, not from the token role claim
+		{"viewer resources", ms, "GET", "/api/v1/resources/aws_ec2?executionID=1", tokens["viewer"], http.StatusForbidden, "the analyst role is required"},
+		{"analyst resources", ms, "GET", "/api/v1/resources/aws_ec2?executionID=1", tokens["analyst"], http.StatusOK, ""},
+		{"analyst send report", ms, "POST", "/api/v1/send-report", tokens["analyst"], http.StatusForbidden, "the admin role is required"},
+		{"analyst users", ms, "GET", "/api/v1/users", tokens["analyst"], http.StatusForbidden, "the admin role is required"},
+		{"admin users", ms, "GET", "/api/v1/users", tokens["admin"], http.StatusOK, ""},
+		{"analyst collector keys", ms, "GET", "/api/v1/collector-keys", tokens["analyst"], http.StatusForbidden, "the admin role is required"},
+		{"admin collector keys", ms, "GET", "/api/v1/collector-keys", tokens["admin"], http.StatusOK, ""},
+		{"rotated signing key", rotatedServer, "GET", "/api/v1/executions", fmt.Sprintf("Bearer %s", previousKeyToken), http.StatusOK, ""},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			req, err := http.NewRequest(test.method, test.endpoint, http.NoBody)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if test.authorization != "" {
+				req.Header.Set("Authorization", test.authorization)
+			}
+			test.server.Router().ServeHTTP(rr, req)
+			if rr.Code != test.expectedStatusCode {
+				t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, test.expectedStatusCode)
+			}
+
+			if test.expectedStatusCode == http.StatusForbidden {
+				errorResponse := api.HttpErrorResponse{}
+				err = json.NewDecoder(rr.Body).Decode(&errorResponse)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if errorResponse.Error != test.expectedError {
+					t.Fatalf("unexpected error response, got %q expected %q", errorResponse.Error, test.expectedError)
+				}
+				return
+			}
+
+			if test.expectedStatusCode != http.StatusUnauthorized {
+				return
+			}
+
+			if !strings.HasPrefix(rr.Header().Get("WWW-Authenticate"), "Bearer") {
+				t.Fatalf("unexpected authenticate challenge, got %q", rr.Header().Get("WWW-Authenticate"))
+			}
+
+			errorResponse := api.HttpErrorResponse{}
+			err = json.NewDecoder(rr.Body).Decode(&errorResponse)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if errorResponse.Error != test.expectedError {
+				t.Fatalf("unexpected error response, got %q expected %q", errorResponse.Error, test.expectedError)
+			}
+		})
+	}
+}
+
+// streamEvent describes a server-sent event read from an execution stream
+type streamEvent struct {
+	ID   string
+	Type string
+	Data string
+}
+
+// subscribeExecution opens the events stream of the execution with a token of the given role
+func subscribeExecution(t *testing.T, serverURL, executionID string, role users.Role, lastEventID string) (*bufio.Reader, func()) {
+	req, err := http.NewRequest("GET", fmt.Sprintf("%s/api/v1/executions/%s/events", serverURL, executionID), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	token, _, err := MockJWTManager().GenerateJWT(string(role), string(role))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
+	if lastEventID != "" {
+		req.Header.Set("Last-Event-ID", lastEventID)
+	}
+
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.StatusCode != http.StatusOK || res.Header.Get("Content-Type") != "text/event-stream" {
+		t.Fatalf("unexpected stream response, got %d %s", res.StatusCode, res.Header.Get("Content-Type"))
+	}
+	return bufio.NewReader(res.Body), func() { res.Body.Close() }
+}
+
+// readStreamEvent returns the next event of the stream, skipping the comments
+func readStreamEvent(t *testing.T, reader *bufio.Reader) streamEvent {
+	event := streamEvent{}
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			t.Fatalf("could not read the stream: %v", err)
+		}
+		line = strings.TrimSuffix(line, "\n")
+		switch {
+		case line == "" && event.Type != "":
+			return event
+		case strings.HasPrefix(line, "id: "):
+			event.ID = strings.TrimPrefix(line, "id: ")
+		case strings.HasPrefix(line, "event: "):
+			event.Type = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			event.Data = strings.TrimPrefix(line, "data: ")
+		}
+	}
+}
+
+func TestStreamExecutionEvents(t *testing.T) {
+	ms, mockStorage := MockServer()
+	ms.BindEndpoints()
+	testServer := httptest.NewServer(ms.Router())
+	defer testServer.Close()
+
+	executionID := "general_1600000000"
+	mockStorage.Executions[executionID] = storage.Execution{ExecutionID: executionID, Name: "general", StartTime: time.Now()}
+
+	analyst, closeAnalyst := subscribeExecution(t, testServer.URL, executionID, users.RoleAnalyst, "")
+	viewer, closeViewer := subscribeExecution(t, testServer.URL, executionID, users.RoleViewer, "")
+	defer closeViewer()
+
+	serviceStatus := `{"SchemaVersion":2,"ResourceName":"aws_ec2","EventType":"service_status","EventTime":1600000000000000000,"Data":{"Status":0,"ErrorMessage":""}}`
+	detected := `{"SchemaVersion":2,"ResourceName":"aws_ec2","EventType":"resource_detected","EventTime":1600000000000000000,"Data":{"Metric":"CPU","ResourceID":"i-1","PricePerMonth":10.5,"AccountID":"1","AccountName":"prod","Region":"us-east-1"}}`
+	inventory := `{"SchemaVersion":2,"ResourceName":"aws_ec2","EventType":"resource_inventory","EventTime":1600000000000000000,"Data":{"ResourceID":"i-1","ResourceType":"t3.large","PricePerMonth":60.5,"AccountID":"1","AccountName":"prod","Region":"us-east-1"}}`
+	for _, endpoint := range []string{"detect-events/" + executionID, "executions/" + executionID + "/finish"} {
+		body := ""
+		if strings.HasPrefix(endpoint, "detect-events") {
+			body = "[" + serviceStatus + "," + inventory + "," + detected + "]"
+		}
+		req, err := http.NewRequest("POST", fmt.Sprintf("%s/api/v1/%s", testServer.URL, endpoint), strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set(collectors.KeyHeader, mockCollectorKey)
+		res, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res.Body.Close()
+		if res.StatusCode != http.StatusAccepted && res.StatusCode != http.StatusOK {
+			t.Fatalf("unexpected %s status code, got %d", endpoint, res.StatusCode)
+		}
+	}
+
+	t.Run("analyst", func(t *testing.T) {
+		status := readStreamEvent(t, analyst)
+		if status.Type != events.EventServiceStatus || !strings.Contains(status.Data, `"ResourceName":"aws_ec2"`) {
+			t.Fatalf("unexpected event, got %+v", status)
+		}
+		if event := readStreamEvent(t, analyst); event.Type != events.EventResourceDetected || !strings.Contains(event.Data, `"ResourceID":"i-1"`) {
+			t.Fatalf("unexpected event, got %+v", event)
+		}
+		if event := readStreamEvent(t, analyst); event.Type != "execution_finished" || !strings.Contains(event.Data, `"Status":"finished"`) {
+			t.Fatalf("unexpected event, got %+v", event)
+		}
+		closeAnalyst()
+
+		// A client which reconnects resumes after its last event
+		resumed, closeResumed := subscribeExecution(t, testServer.URL, executionID, users.RoleAnalyst, status.ID)
+		defer closeResumed()
+		if event := readStreamEvent(t, resumed); event.Type != events.EventResourceDetected {
+			t.Fatalf("unexpected resumed event, got %+v", event)
+		}
+		if event := readStreamEvent(t, resumed); event.Type != "execution_finished" {
+			t.Fatalf("unexpected resumed event, got %+v", event)
+		}
+	})
+
+	t.Run("viewer", func(t *testing.T) {
+		// The detected resources are not streamed to the viewers
+		if event := readStreamEvent(t, viewer); event.Type != events.EventServiceStatus {
+			t.Fatalf("unexpected event, got %+v", event)
+		}
+		if event := readStreamEvent(t, viewer); event.Type != "execution_finished" {
+			t.Fatalf("unexpected event, got %+v", event)
+		}
+	})
+
+	t.Run("unknown last event id", func(t *testing.T) {
+		resumed, closeResumed := subscribeExecution(t, testServer.URL, executionID, users.RoleAnalyst, "other-1")
+		defer closeResumed()
+		if event := readStreamEvent(t, resumed); event.Type != "resync" || event.ID != "" {
+			t.Fatalf("unexpected event, got %+v", event)
+		}
+	})
+
+	t.Run("without token", func(t *testing.T) {
+		res, err := http.Get(fmt.Sprintf("%s/api/v1/executions/%s/events", testServer.URL, executionID))
+		if err != nil {
+			t.Fatal(err)
+		}
+		res.Body.Close()
+		if res.StatusCode != http.StatusUnauthorized {
+			t.Fatalf("unexpected status code, got %d", res.StatusCode)
+		}
+	})
+
+	token, _, err := MockJWTManager().GenerateJWT(string(users.RoleViewer), string(users.RoleViewer))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("query token", func(t *testing.T) {
+		// Browsers cannot set the authorization header of an EventSource
+		res, err := http.Get(fmt.Sprintf("%s/api/v1/executions/%s/events?access_token=%s", testServer.URL, executionID, token))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer res.Body.Close()
+		if res.StatusCode != http.StatusOK || res.Header.Get("Content-Type") != "text/event-stream" {
+			t.Fatalf("unexpected stream response, got %d %s", res.StatusCode, res.Header.Get("Content-Type"))
+		}
+	})
+
+	t.Run("query token of other routes", func(t *testing.T) {
+		res, err := http.Get(fmt.Sprintf("%s/api/v1/executions/%s?access_token=%s", testServer.URL, executionID, token))
+		if err != nil {
+			t.Fatal(err)
+		}
+		res.Body.Close()
+		if res.StatusCode != http.StatusUnauthorized {
+			t.Fatalf("unexpected status code, got %d", res.StatusCode)
+		}
+	})
+
+	t.Run("unknown execution", func(t *testing.T) {
+		res, err := http.Get(fmt.Sprintf("%s/api/v1/executions/%s/events?access_token=%s", testServer.URL, "general_1700000000", token))
+		if err != nil {
+			t.Fatal(err)
+		}
+		res.Body.Close()
+		if res.StatusCode != http.StatusNotFound {
+			t.Fatalf("unexpected status code, got %d", res.StatusCode)
+		}
+	})
+}
@@ -1,13 +1,23 @@
 package meilisearch
 
 import (
+	"context"
 	"fmt"
+	"time"
 
 	"finala/api/config"
 	ms "github.com/meilisearch/meilisearch-go"
 	log "github.com/sirupsen/logrus"
 )
 
+const (
+	// taskPollInterval is the interval of polling the status of a Meilisearch task
+	taskPollInterval = 50 * time.Millisecond
+
+	// maxTotalHits is the maximum number of hits a search pages through, Meilisearch defaults to 1000
+	maxTotalHits = 1000000
+)
+
 // meilisearchClient is a wrapper around the Meilisearch client
 type meilisearchClient struct {
 	client ms.ServiceManager // Changed from *ms.Client
@@ -17,8 +27,11 @@ type meilisearchClient struct {
 type Client interface {
 	Connect(conf config.MeilisearchConfig) error
 	Index(index string, document interface{}) error
+	IndexBatch(index string, documents []map[string]interface{}) (*ms.TaskInfo, error)
+	WaitForTask(ctx context.Context, taskUID int64) (*ms.Task, error)
 	Search(index string, query interface{}) (*ms.SearchResponse, error)
 	CreateIndex(name string) error
+	ConfigureIndex(name string) error
 	DeleteIndex(name string) (bool, error)
 	GetIndex(name string) (ms.IndexManager, error) // Changed from *ms.Index
 	ListIndexes() (*ms.IndexesResults, error)
@@ -89,6 +102,17 @@ func (m *meilisearchClient) Index(index string, document interface{}) error {
 	return err
 }
 
+// IndexBatch adds or replaces the given documents in the specified index with a single request, and returns the indexing task
+func (m *meilisearchClient) IndexBatch(index string, documents []map[string]interface{}) (*ms.TaskInfo, error) {
+	idx := m.client.Index(index)
+	return idx.AddDocuments(documents, "id")
+}
+
+// WaitForTask waits until the given task is processed or the context is done, and returns the processed task
+func (m *meilisearchClient) WaitForTask(ctx context.Context, taskUID int64) (*ms.Task, error) {
+	return m.client.WaitForTaskWithContext(ctx, taskUID, taskPollInterval)
+}
+
 // Search performs a search query on the specified index.
 func (m *meilisearchClient) Search(index string, query interface{}) (*ms.SearchResponse, error) {
 	// Convert the query params from the interface
@@ -102,10 +126,26 @@ func (m *meilisearchClient) Search(index string, query interface{}) (*ms.SearchR
 	if queryStr, ok := searchParams["q"].(string); ok {
 		q = queryStr
 	}
+	// Override the default limit if present
+	if limit, ok := searchParams["limit"].(int); ok && limit > 0 {
+		searchRequest.Limit = int64(limit)
+	}
 	// Add filter if present
 	if filterVal, ok := searchParams["filter_by"].(string); ok && filterVal != "" {
 		searchRequest.Filter = filterVal
 	}
+	// Request a numbered page with the exhaustive number of hits, instead of the limit
+	if page, ok := searchParams["page"].(int); ok && page > 0 {
+		searchRequest.Page = int64(page)
+		searchRequest.HitsPerPage = searchRequest.Limit
+		searchRequest.Limit = 0
+	}
+	if sort, ok := searchParams["sort"].([]string); ok && len(sort) > 0 {
+		searchRequest.Sort = sort
+	}
+	if attributes, ok := searchParams["search_on"].([]string); ok && len(attributes) > 0 {
+		searchRequest.AttributesToSearchOn = attributes
+	}
 	// Execute the search
 	idx := m.client.Index(index) // Returns IndexManager
 	return idx.Search(q, searchRequest)
@@ -125,11 +165,18 @@ func (m *meilisearchClient) CreateIndex(name string) error {
 	return m.configureIndexSettings(name)
 }
 
+// ConfigureIndex updates the settings of an existing index, which may have been created with older settings
+func (m *meilisearchClient) ConfigureIndex(name string) error {
+	return m.configureIndexSettings(name)
+}
+
 // configureIndexSettings sets filterable attributes for an index
 func (m *meilisearchClient) configureIndexSettings(indexName string) error {
 	idx := m.client.Index(indexName) // Returns IndexManager
 	settings := ms.Settings{
-		FilterableAttributes: []string{"ExecutionID", "ResourceName", "EventType", "tags", "Collector"},
+		FilterableAttributes: []string{"ExecutionID", "ResourceName", "EventType", "tags", "Collector", "Data"},
+		SortableAttributes:   sortableAttributes,
+		Pagination:           &ms.Pagination{MaxTotalHits: maxTotalHits},
 	}
 	// IndexManager.UpdateSettings returns (*TaskInfo, error)
 	_, err := idx.UpdateSettings(&settings)
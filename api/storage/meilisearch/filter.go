@@ -0,0 +1,76 @@
+package meilisearch
+
+import (
+	"finala/api/filter"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// filterValueEscaper escapes the quotes and the backslashes of the quoted Meilisearch filter fields and values
+var filterValueEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`)
+
+// quoteFilterValue returns the field or the value quoted for a Meilisearch filter expression
+func quoteFilterValue(value string) string {
+	return fmt.Sprintf(`"%s"`, filterValueEscaper.Replace(value))
+}
+
+// translateFilter returns the Meilisearch filter expression of the given filter node. The fields and the values are
+// always quoted, since Meilisearch allows only letters, digits, underscores, dashes and dots in unquoted fields
+func translateFilter(node filter.Node) (string, error) {
+	switch node := node.(type) {
+	case filter.And:
+		return translateBinaryFilter(node.Left, node.Right, "AND")
+	case filter.Or:
+		return translateBinaryFilter(node.Left, node.Right, "OR")
+	case filter.Not:
+		inner, err := translateFilter(node.Node)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("NOT (%s)", inner), nil
+	case filter.Comparison:
+		return fmt.Sprintf("%s %s %s", quoteFilterValue(node.Field), node.Operator, translateFilterValue(node.Value, node.Operator.IsOrdering())), nil
+	case filter.In:
+		values := make([]string, len(node.Values))
+		for i, value := range node.Values {
+			values[i] = translateFilterValue(value, false)
+		}
+		return fmt.Sprintf("%s IN [%s]", quoteFilterValue(node.Field), strings.Join(values, ", ")), nil
+	}
+	return "", fmt.Errorf("unsupported filter node %T", node)
+}
+
+// translateBinaryFilter returns the Meilisearch filter expression of two nodes joined by the given operator
+func translateBinaryFilter(left, right filter.Node, operator string) (string, error) {
+	leftFilter, err := translateFilter(left)
+	if err != nil {
+		return "", err
+	}
+	rightFilter, err := translateFilter(right)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("(%s) %s (%s)", leftFilter, operator, rightFilter), nil
+}
+
+// translateFilterValue returns the Meilisearch filter value. The values compared by order are numbers, and the other
+// values are quoted, which matches both the string and the number fields
+func translateFilterValue(value filter.Value, ordering bool) string {
+	if ordering && value.Kind == filter.NumberValue {
+		return strconv.FormatFloat(value.Number, 'f', -1, 64)
+	}
+	return quoteFilterValue(value.Text)
+}
+
+// withFilter returns the given Meilisearch filter expression, restricted by the given filter node
+func withFilter(filterStr string, node filter.Node) (string, error) {
+	if node == nil {
+		return filterStr, nil
+	}
+	nodeFilter, err := translateFilter(node)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s AND (%s)", filterStr, nodeFilter), nil
+}
@@ -0,0 +1,53 @@
+package meilisearch
+
+import (
+	"finala/api/filter"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestTranslateFilter tests the Meilisearch filter expressions of the parsed filters.
+func TestTranslateFilter(t *testing.T) {
+	testCases := []struct {
+		name       string
+		expression string
+		expected   string
+	}{
+		{"equal", `Region = us-east-1`, `"Data.Region" = "us-east-1"`},
+		{"equal number", `AccountID = 123456789012`, `"Data.AccountID" = "123456789012"`},
+		{"ordering", `PricePerMonth >= 10.5`, `"Data.PricePerMonth" >= 10.5`},
+		{"in", `Region IN (us-east-1, eu-west-1)`, `"Data.Region" IN ["us-east-1", "eu-west-1"]`},
+		{"not in", `Region NOT IN (us-east-1)`, `NOT ("Data.Region" IN ["us-east-1"])`},
+		{"and or", `A = 1 OR B != 2 AND C < 3`, `("Data.A" = "1") OR (("Data.B" != "2") AND ("Data.C" < 3))`},
+		{"colon tag key", `Tag.aws:cloudformation:stack-name = web`, `"Data.Tag.aws:cloudformation:stack-name" = "web"`},
+		{"tag key with slash", `Tag.owner/team IN (data)`, `"Data.Tag.owner/team" IN ["data"]`},
+		{"escaped value", `Tag.team = "infra\" OR ExecutionID != \"x"`, `"Data.Tag.team" = "infra\" OR ExecutionID != \"x"`},
+		{"escaped backslash", `Tag.path = "a\\"`, `"Data.Tag.path" = "a\\"`},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			node, err := filter.Parse(test.expression)
+			assert.NoError(t, err)
+			result, err := translateFilter(node)
+			assert.NoError(t, err)
+			assert.Equal(t, test.expected, result)
+		})
+	}
+}
+
+// TestWithFilter tests the Meilisearch filter expressions are restricted by the parsed filters.
+func TestWithFilter(t *testing.T) {
+	result, err := withFilter(`ExecutionID="1"`, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, `ExecutionID="1"`, result)
+
+	node, err := filter.Parse(`A = 1 OR B = 2`)
+	assert.NoError(t, err)
+	result, err = withFilter(`ExecutionID="1"`, node)
+	assert.NoError(t, err)
+	assert.Equal(t, `ExecutionID="1" AND (("Data.A" = "1") OR ("Data.B" = "2"))`, result)
+
+	assert.Equal(t, `"a\"b\\c"`, quoteFilterValue(`a"b\c`))
+}
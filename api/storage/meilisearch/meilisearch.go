@@ -1,14 +1,19 @@
 package meilisearch
 
 import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"finala/api/config"
+	"finala/api/filter"
 	"finala/api/storage"
 	"finala/interpolation"
 	"fmt"
 	"time"
 
+	ms "github.com/meilisearch/meilisearch-go"
 	log "github.com/sirupsen/logrus"
 )
 
@@ -20,8 +25,36 @@ var (
 const (
 	// prefixDayIndex defines the index name of the current day
 	prefixIndexName = "finala-%s"
+
+	// executionsIndexName defines the index of the executions the collectors registered, which is not rotated daily
+	executionsIndexName = "finala-executions"
+
+	// saveBatchTimeout defines the maximum duration of waiting for a batch indexing task
+	saveBatchTimeout = 30 * time.Second
+
+	// searchAllPageLimit defines the page size of the searches which read all the matching hits
+	searchAllPageLimit = 1000
 )
 
+// sortableAttributes defines the detected resources data attributes the resources are sorted by
+var sortableAttributes = []string{"Data.PricePerMonth", "Data.LaunchTime", "Data.Name", "Data.ResourceID"}
+
+// resourcesSortAttributes defines the detected resources data attributes of each sort, in their order of precedence
+var resourcesSortAttributes = map[string][]string{
+	storage.SortByPrice:      {"Data.PricePerMonth"},
+	storage.SortByLaunchTime: {"Data.LaunchTime"},
+	storage.SortByName:       {"Data.Name", "Data.ResourceID"},
+}
+
+// resourcesSearchAttributes defines the detected resources data attributes the free-text search matches
+var resourcesSearchAttributes = []string{"Data.Name", "Data.ResourceID", "Data.Tag"}
+
+// trendsGroupByAttributes defines the detected resources data attribute of each trends group
+var trendsGroupByAttributes = map[string]string{
+	storage.GroupByAccount: "AccountID",
+	storage.GroupByRegion:  "Region",
+}
+
 // StorageManager describes meilisearchStorage
 type StorageManager struct {
 	client          Client
@@ -44,6 +77,10 @@ func NewStorageManager(conf config.MeilisearchConfig) (*StorageManager, error) {
 		return nil, errors.New("could not create initial index")
 	}
 
+	if !storageManager.createIndex(executionsIndexName) {
+		return nil, errors.New("could not create executions index")
+	}
+
 	go func() {
 		for {
 			now := time.Now().In(time.UTC)
@@ -72,22 +109,33 @@ func (sm *StorageManager) setCreateCurrentIndexDay() bool {
 	today := time.Now().In(time.UTC).Format("2006-01-02")
 	sm.currentIndexDay = fmt.Sprintf(prefixIndexName, today)
 
-	exists, err := sm.client.IndexExists(sm.currentIndexDay)
+	return sm.createIndex(sm.currentIndexDay)
+}
+
+// createIndex creates the given index when it does not exist. The settings of an existing index are updated, since
+// the index may have been created by an older version with fewer filterable or sortable attributes
+func (sm *StorageManager) createIndex(name string) bool {
+	exists, err := sm.client.IndexExists(name)
 	if err != nil {
-		log.WithError(err).WithField("index", sm.currentIndexDay).Error("Failed to check if index exists")
+		log.WithError(err).WithField("index", name).Error("Failed to check if index exists")
 		return false
 	}
 
 	if !exists {
-		log.WithField("index", sm.currentIndexDay).Info("Index does not exist, creating...")
-		err := sm.client.CreateIndex(sm.currentIndexDay)
+		log.WithField("index", name).Info("Index does not exist, creating...")
+		err := sm.client.CreateIndex(name)
 		if err != nil {
-			log.WithError(err).WithField("index", sm.currentIndexDay).Error("Failed to create index")
+			log.WithError(err).WithField("index", name).Error("Failed to create index")
 			return false
 		}
-		log.WithField("index", sm.currentIndexDay).Info("Index created successfully")
+		log.WithField("index", name).Info("Index created successfully")
 	} else {
-		log.WithField("index", sm.currentIndexDay).Info("Index already exists")
+		log.WithField("index", name).Info("Index already exists")
+		err := sm.client.ConfigureIndex(name)
+		if err != nil {
+			log.WithError(err).WithField("index", name).Error("Failed to configure index")
+			return false
+		}
 	}
 	return true
 }
@@ -117,14 +165,118 @@ func (sm *StorageManager) Save(data string) bool {
 	return true
 }
 
+// SaveBatch saves the given events with a single indexing request. The documents ids are derived from the events,
+// so saving the same events again replaces them. When wait is true, it returns after the indexing task is processed
+func (sm *StorageManager) SaveBatch(rows []storage.EventRow, wait bool) error {
+
+	if len(rows) == 0 {
+		return nil
+	}
+
+	documents := make([]map[string]interface{}, 0, len(rows))
+	for _, row := range rows {
+		buf, err := json.Marshal(row)
+		if err != nil {
+			return err
+		}
+		var doc map[string]interface{}
+		if err := json.Unmarshal(buf, &doc); err != nil {
+			return err
+		}
+		doc["id"] = row.DocumentID()
+		documents = append(documents, doc)
+	}
+
+	index := sm.currentIndexDay
+	task, err := sm.client.IndexBatch(index, documents)
+	if err != nil {
+		log.WithError(err).WithFields(log.Fields{
+			"index":     index,
+			"documents": len(documents),
+		}).Error("Fail to save documents")
+		return err
+	}
+
+	if !wait {
+		return nil
+	}
+
+	return sm.waitForTask(task.TaskUID)
+}
+
+// waitForTask waits until the given indexing task is processed, and returns an error when it did not succeed
+func (sm *StorageManager) waitForTask(taskUID int64) error {
+	ctx, cancel := context.WithTimeout(context.Background(), saveBatchTimeout)
+	defer cancel()
+	processed, err := sm.client.WaitForTask(ctx, taskUID)
+	if err != nil {
+		return fmt.Errorf("could not wait for indexing task %d: %w", taskUID, err)
+	}
+	if processed.Status != ms.TaskStatusSucceeded {
+		return fmt.Errorf("indexing task %d %s: %s", taskUID, processed.Status, processed.Error.Message)
+	}
+
+	return nil
+}
+
+// SaveExecution creates or replaces the given execution, and returns after it is stored
+func (sm *StorageManager) SaveExecution(execution storage.Execution) error {
+
+	buf, err := json.Marshal(execution)
+	if err != nil {
+		return err
+	}
+	var doc map[string]interface{}
+	if err := json.Unmarshal(buf, &doc); err != nil {
+		return err
+	}
+	hash := sha256.Sum256([]byte(execution.ExecutionID))
+	doc["id"] = hex.EncodeToString(hash[:])
+
+	task, err := sm.client.IndexBatch(executionsIndexName, []map[string]interface{}{doc})
+	if err != nil {
+		log.WithError(err).WithField("execution_id", execution.ExecutionID).Error("Fail to save execution")
+		return err
+	}
+
+	return sm.waitForTask(task.TaskUID)
+}
+
+// GetExecution returns the execution of the given id
+func (sm *StorageManager) GetExecution(executionID string) (storage.Execution, error) {
+
+	execution := storage.Execution{}
+	result, err := sm.client.Search(executionsIndexName, map[string]interface{}{
+		"q":         "",
+		"filter_by": fmt.Sprintf("ExecutionID=%s", quoteFilterValue(executionID)),
+		"limit":     1,
+	})
+	if err != nil {
+		log.WithError(err).WithField("execution_id", executionID).Error("error when trying to get execution")
+		return execution, err
+	}
+
+	if len(result.Hits) == 0 {
+		return execution, storage.ErrExecutionNotFound
+	}
+
+	err = sm.unmarshalHit(result.Hits[0], &execution)
+	return execution, err
+}
+
 // GetSummary returns executions summary
-func (sm *StorageManager) GetSummary(executionID string, filters map[string]string) (map[string]storage.CollectorsSummary, error) {
+func (sm *StorageManager) GetSummary(executionID string, resourcesFilter filter.Node) (map[string]storage.CollectorsSummary, error) {
 	summary := make(map[string]storage.CollectorsSummary)
 
+	detectedFilter, err := withFilter(fmt.Sprintf("EventType=resource_detected AND ExecutionID=%s", quoteFilterValue(executionID)), resourcesFilter)
+	if err != nil {
+		return summary, err
+	}
+
 	// 1. Fetch and process service_status events for status and error messages
 	serviceStatusEvents, err := sm.client.Search(sm.currentIndexDay, map[string]interface{}{
 		"q":         "",
-		"filter_by": fmt.Sprintf("EventType=service_status AND ExecutionID=%s", executionID),
+		"filter_by": fmt.Sprintf("EventType=service_status AND ExecutionID=%s", quoteFilterValue(executionID)),
 		// Potentially add limit if there can be many status events per service, though unlikely for summary.
 		// Default MeiliSearch limit is 20, might need to be higher if many resource types.
 		// For now, assuming default limit is sufficient or all relevant statuses are captured.
@@ -164,11 +316,7 @@ func (sm *StorageManager) GetSummary(executionID string, filters map[string]stri
 	}
 
 	// 2. Fetch and process resource_detected events for costs and counts
-	resourceDetectedEvents, err := sm.client.Search(sm.currentIndexDay, map[string]interface{}{
-		"q":         "",
-		"filter_by": fmt.Sprintf("EventType=resource_detected AND ExecutionID=%s", executionID),
-		"limit":     1000, // Assuming up to 1000 resources per execution for summary. Adjust if necessary.
-	})
+	resourceDetectedEvents, err := sm.searchAll(detectedFilter)
 
 	if err != nil {
 		log.WithError(err).Error("error when trying to get resource_detected summary data")
@@ -179,7 +327,7 @@ func (sm *StorageManager) GetSummary(executionID string, filters map[string]stri
 	}
 
 	if resourceDetectedEvents != nil {
-		for _, hit := range resourceDetectedEvents.Hits {
+		for _, hit := range resourceDetectedEvents {
 			var eventDataMap map[string]interface{}
 			hitData, err := json.Marshal(hit)
 			if err != nil {
@@ -204,11 +352,10 @@ func (sm *StorageManager) GetSummary(executionID string, filters map[string]stri
 			}
 
 			// PriceDetectedFields are embedded, so PricePerMonth should be a top-level field in Data
-			pricePerMonth, ppmOK := dataField["PricePerMonth"].(float64)
-			hasPricing := ppmOK && pricePerMonth > 0
+			pricePerMonth, hasPricing := getPotentialSaving(dataField)
 
 			if !hasPricing {
-				// Some resources like Lambda might not have PricePerMonth directly.
+				// Some resources like IAM users do not have PricePerMonth at all.
 				// Handle this gracefully, e.g. by attempting to get PricePerHour or setting to 0.
 				// For now, we log and continue with pricing set to 0.
 				log.WithFields(log.Fields{
@@ -224,11 +371,37 @@ func (sm *StorageManager) GetSummary(executionID string, filters map[string]stri
 			currentSummary.TotalSpent += pricePerMonth
 			currentSummary.HasPricing = hasPricing
 
-			// Categorize based on pricing data
-			if hasPricing {
-				currentSummary.Category = "potential_cost_saving"
+			// Categorize based on the detector category, or on pricing data when not set
+			if category, ok := dataField["Category"].(string); ok && category != "" {
+				currentSummary.Category = category
+			} else if hasPricing {
+				currentSummary.Category = storage.CategoryPotentialCostSaving
 			} else {
-				currentSummary.Category = "unused_resource"
+				currentSummary.Category = storage.CategoryUnusedResource
+			}
+
+			// Group by the account and the region of the detected resource
+			accountID, _ := dataField["AccountID"].(string)
+			accountName, _ := dataField["AccountName"].(string)
+			region, _ := dataField["Region"].(string)
+			if accountID != "" {
+				if currentSummary.Accounts == nil {
+					currentSummary.Accounts = map[string]storage.DimensionSummary{}
+				}
+				accountSummary := currentSummary.Accounts[accountID]
+				accountSummary.Name = accountName
+				accountSummary.ResourceCount++
+				accountSummary.TotalSpent += pricePerMonth
+				currentSummary.Accounts[accountID] = accountSummary
+			}
+			if region != "" {
+				if currentSummary.Regions == nil {
+					currentSummary.Regions = map[string]storage.DimensionSummary{}
+				}
+				regionSummary := currentSummary.Regions[region]
+				regionSummary.ResourceCount++
+				regionSummary.TotalSpent += pricePerMonth
+				currentSummary.Regions[region] = regionSummary
 			}
 
 			// Status, ErrorMessage, EventTime are already set from service_status or will be default if no status event.
@@ -240,7 +413,7 @@ func (sm *StorageManager) GetSummary(executionID string, filters map[string]stri
 	// Fill in any missing ResourceNames for services that had detected resources but no explicit status event
 	// (though typically a CollectStart/Finish should exist)
 	if resourceDetectedEvents != nil {
-		for _, hit := range resourceDetectedEvents.Hits {
+		for _, hit := range resourceDetectedEvents {
 			var eventDataMap map[string]interface{}
 			hitData, err := json.Marshal(hit)
 			if err != nil {
@@ -266,6 +439,125 @@ func (sm *StorageManager) GetSummary(executionID string, filters map[string]stri
 	return summary, nil
 }
 
+// GetWasteSummary returns the cost of the detected resources out of the inventory cost,
+// in total, by resource type, by account and by tag key and value
+func (sm *StorageManager) GetWasteSummary(executionID string) (storage.WasteSummary, error) {
+	summary := storage.WasteSummary{
+		ResourceTypes: map[string]storage.WasteRatio{},
+		Accounts:      map[string]storage.WasteRatio{},
+		Tags:          map[string]map[string]storage.WasteRatio{},
+	}
+
+	resourceDetectedEvents, err := sm.searchAll(fmt.Sprintf("EventType=resource_detected AND ExecutionID=%s", quoteFilterValue(executionID)))
+	if err != nil {
+		log.WithError(err).Error("error when trying to get resource_detected waste data")
+		return summary, err
+	}
+
+	// Resources detected by categories which are not waste, like modernization, are not counted.
+	// A rightsizing recommendation wastes the saving of the recommended type, other resources waste their whole price
+	wasted := map[string]float64{}
+	for _, hit := range resourceDetectedEvents {
+		var detected struct {
+			ResourceName string `json:"ResourceName"`
+			Data         struct {
+				ResourceID                string  `json:"ResourceID"`
+				Category                  string  `json:"Category"`
+				RecommendedSavingPerMonth float64 `json:"RecommendedSavingPerMonth"`
+			} `json:"Data"`
+		}
+		if err := sm.unmarshalHit(hit, &detected); err != nil {
+			continue
+		}
+
+		switch detected.Data.Category {
+		case "", storage.CategoryPotentialCostSaving, storage.CategoryUnusedResource:
+			wasted[fmt.Sprintf("%s/%s", detected.ResourceName, detected.Data.ResourceID)] = detected.Data.RecommendedSavingPerMonth
+		}
+	}
+
+	resourceInventoryEvents, err := sm.searchAll(fmt.Sprintf("EventType=resource_inventory AND ExecutionID=%s", quoteFilterValue(executionID)))
+	if err != nil {
+		log.WithError(err).Error("error when trying to get resource_inventory waste data")
+		return summary, err
+	}
+
+	for _, hit := range resourceInventoryEvents {
+		var inventory struct {
+			ResourceName string `json:"ResourceName"`
+			Data         struct {
+				ResourceID    string            `json:"ResourceID"`
+				AccountID     string            `json:"AccountID"`
+				PricePerMonth float64           `json:"PricePerMonth"`
+				Tag           map[string]string `json:"Tag"`
+			} `json:"Data"`
+		}
+		if err := sm.unmarshalHit(hit, &inventory); err != nil {
+			continue
+		}
+
+		recommendedSaving, isWaste := wasted[fmt.Sprintf("%s/%s", inventory.ResourceName, inventory.Data.ResourceID)]
+		wasteCost := inventory.Data.PricePerMonth
+		if recommendedSaving > 0 && recommendedSaving < wasteCost {
+			wasteCost = recommendedSaving
+		}
+		addWaste := func(ratio storage.WasteRatio) storage.WasteRatio {
+			ratio.InventoryCount++
+			ratio.InventoryCost += inventory.Data.PricePerMonth
+			if isWaste {
+				ratio.WasteCount++
+				ratio.WasteCost += wasteCost
+			}
+			if ratio.InventoryCost > 0 {
+				ratio.WasteRatio = ratio.WasteCost / ratio.InventoryCost
+			}
+			return ratio
+		}
+
+		summary.Total = addWaste(summary.Total)
+		summary.ResourceTypes[inventory.ResourceName] = addWaste(summary.ResourceTypes[inventory.ResourceName])
+		summary.Accounts[inventory.Data.AccountID] = addWaste(summary.Accounts[inventory.Data.AccountID])
+		for key, value := range inventory.Data.Tag {
+			if _, found := summary.Tags[key]; !found {
+				summary.Tags[key] = map[string]storage.WasteRatio{}
+			}
+			summary.Tags[key][value] = addWaste(summary.Tags[key][value])
+		}
+	}
+
+	return summary, nil
+}
+
+// getPotentialSaving returns the monthly saving of the detected resource data, and whether the resource has a price.
+// A rightsizing recommendation saves the price difference of the recommended type, not the resource whole price
+func getPotentialSaving(data map[string]interface{}) (float64, bool) {
+
+	if saving, ok := data["RecommendedSavingPerMonth"].(float64); ok && saving > 0 {
+		return saving, true
+	}
+
+	pricePerMonth, ok := data["PricePerMonth"].(float64)
+	if !ok || pricePerMonth <= 0 {
+		return 0, false
+	}
+
+	return pricePerMonth, true
+}
+
+// unmarshalHit parses the search result hit into the given struct
+func (sm *StorageManager) unmarshalHit(hit interface{}, v interface{}) error {
+	hitData, err := json.Marshal(hit)
+	if err != nil {
+		log.WithError(err).Error("could not marshal search result hit")
+		return err
+	}
+	if err := json.Unmarshal(hitData, v); err != nil {
+		log.WithError(err).Error("could not parse search result hit")
+		return err
+	}
+	return nil
+}
+
 // GetExecutions returns list of executions
 func (sm *StorageManager) GetExecutions(queryLimit int) ([]storage.Executions, error) {
 	executions := []storage.Executions{}
@@ -314,54 +606,86 @@ func (sm *StorageManager) GetExecutions(queryLimit int) ([]storage.Executions, e
 	return executions, nil
 }
 
-// GetResources returns list of resources
-func (sm *StorageManager) GetResources(resourceType string, executionID string, filters map[string]string, search string) ([]map[string]interface{}, error) {
-	var resources []map[string]interface{}
+// GetResources returns a page of the detected resources, sorted and searched by the given query
+func (sm *StorageManager) GetResources(resourceType string, executionID string, query storage.ResourcesQuery) (storage.ResourcesPage, error) {
+	page := storage.ResourcesPage{
+		Resources: []map[string]interface{}{},
+		Page:      query.Page,
+		Limit:     query.Limit,
+	}
 
-	searchQuery := ""
-	if search != "" {
-		searchQuery = search
+	filterStr, err := withFilter(fmt.Sprintf("EventType=resource_detected AND ExecutionID=%s AND ResourceName=%s", quoteFilterValue(executionID), quoteFilterValue(resourceType)), query.Filter)
+	if err != nil {
+		return page, err
 	}
 
 	searchParams := map[string]interface{}{
-		"q":         searchQuery,
-		"filter_by": fmt.Sprintf("EventType=resource_detected AND ExecutionID=%s AND ResourceName=%s", executionID, resourceType),
+		"q":         query.Search,
+		"filter_by": filterStr,
+		"page":      query.Page,
+		"limit":     query.Limit,
+	}
+	if query.Search != "" {
+		searchParams["search_on"] = resourcesSearchAttributes
+	}
+	if attributes, found := resourcesSortAttributes[query.SortBy]; found {
+		order := storage.SortOrderAsc
+		if query.SortOrder == storage.SortOrderDesc {
+			order = storage.SortOrderDesc
+		}
+		sort := []string{}
+		for _, attribute := range attributes {
+			sort = append(sort, fmt.Sprintf("%s:%s", attribute, order))
+		}
+		searchParams["sort"] = sort
 	}
 
 	result, err := sm.client.Search(sm.currentIndexDay, searchParams)
 	if err != nil {
 		log.WithError(err).Error("meilisearch query error")
-		return resources, err
+		return page, err
 	}
 
 	for _, hit := range result.Hits {
 		rowData := make(map[string]interface{})
-		hitData, err := json.Marshal(hit)
-		if err != nil {
-			log.WithError(err).Error("error when trying to marshal document")
+		if err := sm.unmarshalHit(hit, &rowData); err != nil {
 			continue
 		}
-		if err := json.Unmarshal(hitData, &rowData); err != nil {
-			log.WithError(err).Error("error when trying to parse search result hits data")
-			continue
-		}
-
-		resources = append(resources, rowData)
+		page.Resources = append(page.Resources, rowData)
 	}
+	page.Total = result.TotalHits
+	page.Pages = result.TotalPages
 
-	return resources, nil
+	return page, nil
 }
 
-// GetResourceTrends returns resource trends
-func (sm *StorageManager) GetResourceTrends(resourceType string, filters map[string]string, limit int) ([]storage.ExecutionCost, error) {
-	var resources []storage.ExecutionCost
+// searchAll returns all the hits matching the given filter, reading them a page at a time
+func (sm *StorageManager) searchAll(filter string) ([]interface{}, error) {
+	hits := []interface{}{}
+	for page := 1; ; page++ {
+		result, err := sm.client.Search(sm.currentIndexDay, map[string]interface{}{
+			"q":         "",
+			"filter_by": filter,
+			"page":      page,
+			"limit":     searchAllPageLimit,
+		})
+		if err != nil {
+			return hits, err
+		}
+		hits = append(hits, result.Hits...)
+		if int64(page) >= result.TotalPages || len(result.Hits) == 0 {
+			return hits, nil
+		}
+	}
+}
 
-	// Build filter string for Meilisearch
-	filterStr := fmt.Sprintf("ResourceName=%s AND EventType!=service_status", resourceType)
+// GetResourceTrends returns resource trends, grouped by execution and optionally by the account or the region of the resources
+func (sm *StorageManager) GetResourceTrends(resourceType string, resourcesFilter filter.Node, groupBy string, limit int) ([]storage.ExecutionCost, error) {
+	var resources []storage.ExecutionCost
 
-	// Add additional filters if any
-	for key, value := range filters {
-		filterStr += fmt.Sprintf(" AND %s=%s", key, value)
+	filterStr, err := withFilter(fmt.Sprintf("EventType=resource_detected AND ResourceName=%s", quoteFilterValue(resourceType)), resourcesFilter)
+	if err != nil {
+		return resources, err
 	}
 
 	searchParams := map[string]interface{}{
@@ -376,7 +700,11 @@ func (sm *StorageManager) GetResourceTrends(resourceType string, filters map[str
 	}
 
 	// Group by ExecutionID manually since Meilisearch doesn't support group by
-	executionCosts := make(map[string]float64)
+	type trendKey struct {
+		executionID string
+		group       string
+	}
+	executionCosts := make(map[trendKey]float64)
 	for _, hit := range result.Hits {
 		var execData struct {
 			ExecutionID string                 `json:"ExecutionID"`
@@ -392,15 +720,21 @@ func (sm *StorageManager) GetResourceTrends(resourceType string, filters map[str
 			continue
 		}
 
+		key := trendKey{executionID: execData.ExecutionID}
+		if groupAttribute, found := trendsGroupByAttributes[groupBy]; found {
+			key.group, _ = execData.Data[groupAttribute].(string)
+		}
+
 		if priceData, ok := execData.Data["PricePerMonth"]; ok {
-			if price, ok := priceData.(float64); ok {
-				executionCosts[execData.ExecutionID] += price
+			if _, ok := priceData.(float64); ok {
+				saving, _ := getPotentialSaving(execData.Data)
+				executionCosts[key] += saving
 			} else {
 				// Handle case where PricePerMonth might be a string or other type
 				priceStr, ok := priceData.(string)
 				if ok {
 					if priceVal, err := fmt.Sscanf(priceStr, "%f", new(float64)); err == nil {
-						executionCosts[execData.ExecutionID] += float64(priceVal)
+						executionCosts[key] += float64(priceVal)
 					}
 				}
 			}
@@ -408,16 +742,17 @@ func (sm *StorageManager) GetResourceTrends(resourceType string, filters map[str
 	}
 
 	// Convert to array and sort by timestamp
-	for execID, costSum := range executionCosts {
-		timestamp, err := interpolation.ExtractTimestamp(execID)
+	for key, costSum := range executionCosts {
+		timestamp, err := interpolation.ExtractTimestamp(key.executionID)
 		if err != nil {
 			timestamp = 0
 		}
 
 		resources = append(resources, storage.ExecutionCost{
-			ExecutionID:        execID,
+			ExecutionID:        key.executionID,
 			ExtractedTimestamp: timestamp,
 			CostSum:            costSum,
+			Group:              key.group,
 		})
 	}
 
@@ -430,7 +765,7 @@ func (sm *StorageManager) GetExecutionTags(executionID string) (map[string][]str
 
 	searchParams := map[string]interface{}{
 		"q":         "",
-		"filter_by": fmt.Sprintf("EventType=resource_detected AND ExecutionID=%s", executionID),
+		"filter_by": fmt.Sprintf("EventType=resource_detected AND ExecutionID=%s", quoteFilterValue(executionID)),
 	}
 
 	result, err := sm.client.Search(sm.currentIndexDay, searchParams)
@@ -2,10 +2,15 @@ package meilisearch
 
 import (
 	"errors"
+	"finala/api/filter"
+	"finala/api/storage"
+	"strings"
 	"testing"
 	"time"
 
+	ms "github.com/meilisearch/meilisearch-go"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 )
 
 // TestNewStorageManager_Success is skipped. Directly testing NewStorageManager is complex
@@ -30,6 +35,7 @@ func TestStorageManager_setCreateCurrentIndexDay_IndexExists(t *testing.T) {
 	expectedIndexName := "finala-" + time.Now().Format("2006-01-02")
 
 	mockClient.On("IndexExists", expectedIndexName).Return(true, nil).Once()
+	mockClient.On("ConfigureIndex", expectedIndexName).Return(nil).Once()
 
 	success := sm.setCreateCurrentIndexDay()
 	assert.True(t, success, "setCreateCurrentIndexDay should succeed if index exists")
@@ -37,6 +43,23 @@ func TestStorageManager_setCreateCurrentIndexDay_IndexExists(t *testing.T) {
 	mockClient.AssertExpectations(t)
 }
 
+// TestStorageManager_setCreateCurrentIndexDay_ConfigureIndexFails tests failure when configuring the existing index.
+func TestStorageManager_setCreateCurrentIndexDay_ConfigureIndexFails(t *testing.T) {
+	mockClient := new(MockClient)
+	sm := &StorageManager{
+		client: mockClient,
+	}
+	expectedIndexName := "finala-" + time.Now().Format("2006-01-02")
+	expectedError := errors.New("failed to update settings")
+
+	mockClient.On("IndexExists", expectedIndexName).Return(true, nil).Once()
+	mockClient.On("ConfigureIndex", expectedIndexName).Return(expectedError).Once()
+
+	success := sm.setCreateCurrentIndexDay()
+	assert.False(t, success, "setCreateCurrentIndexDay should fail if ConfigureIndex fails")
+	mockClient.AssertExpectations(t)
+}
+
 // TestStorageManager_setCreateCurrentIndexDay_CreatesNewIndex tests when the daily index does not exist and is created.
 func TestStorageManager_setCreateCurrentIndexDay_CreatesNewIndex(t *testing.T) {
 	mockClient := new(MockClient)
@@ -107,6 +130,285 @@ func TestStorageManager_Save_Success(t *testing.T) {
 	mockClient.AssertExpectations(t)
 }
 
+// TestStorageManager_SaveBatch tests the events are saved with a single request, with ids derived from the events.
+func TestStorageManager_SaveBatch(t *testing.T) {
+	mockClient := new(MockClient)
+	currentIndex := "finala-2023-01-01"
+	sm := &StorageManager{
+		client:          mockClient,
+		currentIndexDay: currentIndex,
+	}
+	rows := []storage.EventRow{
+		{ExecutionID: "general_1", ResourceName: "aws_ec2", EventType: "resource_detected", EventTime: 1, Data: map[string]interface{}{"ResourceID": "i-1"}},
+		{ExecutionID: "general_1", ResourceName: "aws_ec2", EventType: "resource_detected", EventTime: 2, Data: map[string]interface{}{"ResourceID": "i-2"}},
+	}
+
+	var ids []string
+	mockClient.On("IndexBatch", currentIndex, mock.MatchedBy(func(documents []map[string]interface{}) bool {
+		ids = []string{}
+		for _, doc := range documents {
+			ids = append(ids, doc["id"].(string))
+		}
+		return len(documents) == len(rows)
+	})).Return(&ms.TaskInfo{TaskUID: 7}, nil).Twice()
+	mockClient.On("WaitForTask", int64(7)).Return(&ms.Task{Status: ms.TaskStatusSucceeded}, nil).Once()
+
+	assert.NoError(t, sm.SaveBatch(rows, true), "SaveBatch should not return an error on success")
+	firstIDs := ids
+	assert.NotEqual(t, firstIDs[0], firstIDs[1], "the events should have different ids")
+
+	// Saving the events again replaces the same documents
+	assert.NoError(t, sm.SaveBatch(rows, false), "SaveBatch should not return an error on success")
+	assert.Equal(t, firstIDs, ids, "the events ids should be deterministic")
+	mockClient.AssertExpectations(t)
+}
+
+// TestStorageManager_SaveBatch_Failure tests the errors of the indexing request and the indexing task.
+func TestStorageManager_SaveBatch_Failure(t *testing.T) {
+	rows := []storage.EventRow{{ExecutionID: "general_1", ResourceName: "aws_ec2", EventType: "service_status", EventTime: 1}}
+
+	mockClient := new(MockClient)
+	sm := &StorageManager{client: mockClient, currentIndexDay: "finala-2023-01-01"}
+	mockClient.On("IndexBatch", "finala-2023-01-01", mock.Anything).Return(nil, errors.New("unavailable")).Once()
+	assert.Error(t, sm.SaveBatch(rows, true), "SaveBatch should fail if the indexing request fails")
+
+	mockClient = new(MockClient)
+	sm = &StorageManager{client: mockClient, currentIndexDay: "finala-2023-01-01"}
+	mockClient.On("IndexBatch", "finala-2023-01-01", mock.Anything).Return(&ms.TaskInfo{TaskUID: 8}, nil).Once()
+	mockClient.On("WaitForTask", int64(8)).Return(&ms.Task{Status: ms.TaskStatusFailed}, nil).Once()
+	assert.Error(t, sm.SaveBatch(rows, true), "SaveBatch should fail if the indexing task fails")
+
+	assert.NoError(t, sm.SaveBatch(nil, true), "SaveBatch should skip an empty batch")
+	mockClient.AssertExpectations(t)
+}
+
+// TestStorageManager_SaveExecution tests the execution is saved to the executions index, and waited for.
+func TestStorageManager_SaveExecution(t *testing.T) {
+	mockClient := new(MockClient)
+	sm := &StorageManager{client: mockClient, currentIndexDay: "finala-2023-01-01"}
+
+	mockClient.On("IndexBatch", executionsIndexName, mock.MatchedBy(func(documents []map[string]interface{}) bool {
+		return len(documents) == 1 && documents[0]["ExecutionID"] == "general_1" && documents[0]["id"] != ""
+	})).Return(&ms.TaskInfo{TaskUID: 9}, nil).Once()
+	mockClient.On("WaitForTask", int64(9)).Return(&ms.Task{Status: ms.TaskStatusSucceeded}, nil).Once()
+
+	err := sm.SaveExecution(storage.Execution{ExecutionID: "general_1", Name: "general", StartTime: time.Now()})
+	assert.NoError(t, err, "SaveExecution should not return an error on success")
+	mockClient.AssertExpectations(t)
+}
+
+// TestStorageManager_GetExecution tests the execution is returned from the executions index.
+func TestStorageManager_GetExecution(t *testing.T) {
+	mockClient := new(MockClient)
+	sm := &StorageManager{client: mockClient, currentIndexDay: "finala-2023-01-01"}
+
+	mockClient.On("Search", executionsIndexName, map[string]interface{}{"q": "", "filter_by": `ExecutionID="general_1"`, "limit": 1}).Return(&ms.SearchResponse{
+		Hits: []interface{}{map[string]interface{}{"ExecutionID": "general_1", "Name": "general", "ConfigHash": "abc"}},
+	}, nil).Once()
+	mockClient.On("Search", executionsIndexName, map[string]interface{}{"q": "", "filter_by": `ExecutionID="general_2"`, "limit": 1}).Return(&ms.SearchResponse{
+		Hits: []interface{}{},
+	}, nil).Once()
+
+	execution, err := sm.GetExecution("general_1")
+	assert.NoError(t, err, "GetExecution should not return an error on success")
+	assert.Equal(t, "abc", execution.ConfigHash)
+	assert.Nil(t, execution.EndTime, "a running execution should not have an end time")
+
+	_, err = sm.GetExecution("general_2")
+	assert.ErrorIs(t, err, storage.ErrExecutionNotFound)
+	mockClient.AssertExpectations(t)
+}
+
+// TestStorageManager_GetSummary_Category tests the summary category of the detected resources.
+func TestStorageManager_GetSummary_Category(t *testing.T) {
+	mockClient := new(MockClient)
+	currentIndex := "finala-2023-01-01"
+	sm := &StorageManager{
+		client:          mockClient,
+		currentIndexDay: currentIndex,
+	}
+
+	resourceDetected := &ms.SearchResponse{Hits: []interface{}{
+		map[string]interface{}{"ResourceName": "aws_ec2", "Data": map[string]interface{}{"PricePerMonth": 10.0}},
+		map[string]interface{}{"ResourceName": "aws_iam", "Data": map[string]interface{}{}},
+		map[string]interface{}{"ResourceName": "aws_modernization", "Data": map[string]interface{}{"PricePerMonth": 10.0, "Category": "modernization"}},
+		map[string]interface{}{"ResourceName": "aws_tags_compliance", "Data": map[string]interface{}{"PricePerMonth": 10.0, "Category": "tags_compliance"}},
+	}}
+
+	mockClient.On("Search", currentIndex, mock.MatchedBy(func(query map[string]interface{}) bool {
+		return query["filter_by"] == `EventType=service_status AND ExecutionID="1"`
+	})).Return(&ms.SearchResponse{}, nil).Once()
+	mockClient.On("Search", currentIndex, mock.MatchedBy(func(query map[string]interface{}) bool {
+		return query["filter_by"] == `EventType=resource_detected AND ExecutionID="1"`
+	})).Return(resourceDetected, nil).Once()
+
+	summary, err := sm.GetSummary("1", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, storage.CategoryPotentialCostSaving, summary["aws_ec2"].Category)
+	assert.Equal(t, storage.CategoryUnusedResource, summary["aws_iam"].Category)
+	assert.Equal(t, storage.CategoryModernization, summary["aws_modernization"].Category)
+	assert.Equal(t, storage.CategoryTagsCompliance, summary["aws_tags_compliance"].Category)
+	mockClient.AssertExpectations(t)
+}
+
+// TestStorageManager_GetSummary_RecommendedSaving tests the summary adds the saving of the rightsizing recommendations.
+func TestStorageManager_GetSummary_RecommendedSaving(t *testing.T) {
+	mockClient := new(MockClient)
+	currentIndex := "finala-2023-01-01"
+	sm := &StorageManager{
+		client:          mockClient,
+		currentIndexDay: currentIndex,
+	}
+
+	resourceDetected := &ms.SearchResponse{Hits: []interface{}{
+		map[string]interface{}{"ResourceName": "aws_ec2", "Data": map[string]interface{}{"PricePerMonth": 100.0, "RecommendedSavingPerMonth": 40.0, "AccountID": "1"}},
+		map[string]interface{}{"ResourceName": "aws_ec2", "Data": map[string]interface{}{"PricePerMonth": 10.0, "RecommendedSavingPerMonth": 0.0, "AccountID": "1"}},
+	}}
+
+	mockClient.On("Search", currentIndex, mock.MatchedBy(func(query map[string]interface{}) bool {
+		return query["filter_by"] == `EventType=service_status AND ExecutionID="1"`
+	})).Return(&ms.SearchResponse{}, nil).Once()
+	mockClient.On("Search", currentIndex, mock.MatchedBy(func(query map[string]interface{}) bool {
+		return query["filter_by"] == `EventType=resource_detected AND ExecutionID="1"`
+	})).Return(resourceDetected, nil).Once()
+
+	summary, err := sm.GetSummary("1", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 50.0, summary["aws_ec2"].TotalSpent)
+	assert.Equal(t, 50.0, summary["aws_ec2"].Accounts["1"].TotalSpent)
+	mockClient.AssertExpectations(t)
+}
+
+// TestStorageManager_GetSummary_AccountDimensions tests the summary filters and groups by account and region.
+func TestStorageManager_GetSummary_AccountDimensions(t *testing.T) {
+	mockClient := new(MockClient)
+	currentIndex := "finala-2023-01-01"
+	sm := &StorageManager{
+		client:          mockClient,
+		currentIndexDay: currentIndex,
+	}
+
+	resourceDetected := &ms.SearchResponse{Hits: []interface{}{
+		map[string]interface{}{"ResourceName": "aws_ec2", "Data": map[string]interface{}{"PricePerMonth": 10.0, "AccountID": "1", "AccountName": "production", "Region": "us-east-1"}},
+		map[string]interface{}{"ResourceName": "aws_ec2", "Data": map[string]interface{}{"PricePerMonth": 20.0, "AccountID": "1", "AccountName": "production", "Region": "eu-west-1"}},
+	}}
+
+	mockClient.On("Search", currentIndex, mock.MatchedBy(func(query map[string]interface{}) bool {
+		return query["filter_by"] == `EventType=service_status AND ExecutionID="1"`
+	})).Return(&ms.SearchResponse{}, nil).Once()
+	mockClient.On("Search", currentIndex, mock.MatchedBy(func(query map[string]interface{}) bool {
+		return query["filter_by"] == `EventType=resource_detected AND ExecutionID="1" AND (("Data.AccountName" = "production") AND ("Data.Tag.team" = "data"))`
+	})).Return(resourceDetected, nil).Once()
+
+	summary, err := sm.GetSummary("1", mustParseFilter(t, `AccountName = production AND Tag.team = "data"`))
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]storage.DimensionSummary{"1": {Name: "production", ResourceCount: 2, TotalSpent: 30}}, summary["aws_ec2"].Accounts)
+	assert.Equal(t, map[string]storage.DimensionSummary{"us-east-1": {ResourceCount: 1, TotalSpent: 10}, "eu-west-1": {ResourceCount: 1, TotalSpent: 20}}, summary["aws_ec2"].Regions)
+	mockClient.AssertExpectations(t)
+}
+
+// TestStorageManager_GetResourceTrends_GroupBy tests the resource trends grouped by the resources region.
+func TestStorageManager_GetResourceTrends_GroupBy(t *testing.T) {
+	mockClient := new(MockClient)
+	currentIndex := "finala-2023-01-01"
+	sm := &StorageManager{
+		client:          mockClient,
+		currentIndexDay: currentIndex,
+	}
+
+	resourceDetected := &ms.SearchResponse{Hits: []interface{}{
+		map[string]interface{}{"ExecutionID": "1", "Data": map[string]interface{}{"PricePerMonth": 10.0, "AccountID": "1", "Region": "us-east-1"}},
+		map[string]interface{}{"ExecutionID": "1", "Data": map[string]interface{}{"PricePerMonth": 20.0, "AccountID": "1", "Region": "eu-west-1"}},
+		map[string]interface{}{"ExecutionID": "1", "Data": map[string]interface{}{"PricePerMonth": 5.0, "AccountID": "1", "Region": "us-east-1"}},
+	}}
+
+	mockClient.On("Search", currentIndex, mock.MatchedBy(func(query map[string]interface{}) bool {
+		return query["filter_by"] == `EventType=resource_detected AND ResourceName="aws_ec2" AND ("Data.AccountID" = "1")`
+	})).Return(resourceDetected, nil).Once()
+
+	trends, err := sm.GetResourceTrends("aws_ec2", mustParseFilter(t, "AccountID = 1"), storage.GroupByRegion, 10)
+	assert.NoError(t, err)
+
+	costs := map[string]float64{}
+	for _, trend := range trends {
+		costs[trend.Group] = trend.CostSum
+	}
+	assert.Equal(t, map[string]float64{"us-east-1": 15, "eu-west-1": 20}, costs)
+	mockClient.AssertExpectations(t)
+}
+
+// TestStorageManager_GetResourceTrends_Inventory tests the resource trends count only the detected resources,
+// and not the inventory events of the same resource name.
+func TestStorageManager_GetResourceTrends_Inventory(t *testing.T) {
+	mockClient := new(MockClient)
+	currentIndex := "finala-2023-01-01"
+	sm := &StorageManager{
+		client:          mockClient,
+		currentIndexDay: currentIndex,
+	}
+
+	resourceDetected := map[string]interface{}{"ExecutionID": "1", "EventType": "resource_detected", "Data": map[string]interface{}{"ResourceID": "i-1", "PricePerMonth": 30.0}}
+	executionEvents := []interface{}{
+		resourceDetected,
+		map[string]interface{}{"ExecutionID": "1", "EventType": "resource_inventory", "Data": map[string]interface{}{"ResourceID": "i-1", "PricePerMonth": 30.0}},
+		map[string]interface{}{"ExecutionID": "1", "EventType": "resource_inventory", "Data": map[string]interface{}{"ResourceID": "i-2", "PricePerMonth": 10.0}},
+	}
+
+	mockClient.On("Search", currentIndex, mock.MatchedBy(func(query map[string]interface{}) bool {
+		return strings.HasPrefix(query["filter_by"].(string), "EventType=resource_detected AND ")
+	})).Return(&ms.SearchResponse{Hits: []interface{}{resourceDetected}}, nil)
+	mockClient.On("Search", currentIndex, mock.Anything).Return(&ms.SearchResponse{Hits: executionEvents}, nil).Maybe()
+
+	trends, err := sm.GetResourceTrends("aws_ec2", nil, "", 10)
+	assert.NoError(t, err)
+	assert.Len(t, trends, 1)
+	assert.Equal(t, 30.0, trends[0].CostSum)
+}
+
+// TestStorageManager_GetWasteSummary tests the waste ratio of the inventory cost.
+func TestStorageManager_GetWasteSummary(t *testing.T) {
+	mockClient := new(MockClient)
+	currentIndex := "finala-2023-01-01"
+	sm := &StorageManager{
+		client:          mockClient,
+		currentIndexDay: currentIndex,
+	}
+
+	resourceDetected := &ms.SearchResponse{Hits: []interface{}{
+		map[string]interface{}{"ResourceName": "aws_ec2", "Data": map[string]interface{}{"ResourceID": "i-1", "PricePerMonth": 30.0}},
+		map[string]interface{}{"ResourceName": "aws_rds", "Data": map[string]interface{}{"ResourceID": "db-2", "PricePerMonth": 40.0, "RecommendedSavingPerMonth": 10.0}},
+		map[string]interface{}{"ResourceName": "aws_modernization", "Data": map[string]interface{}{"ResourceID": "i-2", "Category": "modernization"}},
+		map[string]interface{}{"ResourceName": "aws_tags_compliance", "Data": map[string]interface{}{"ResourceID": "i-2", "Category": "tags_compliance"}},
+	}}
+
+	resourceInventory := &ms.SearchResponse{Hits: []interface{}{
+		map[string]interface{}{"ResourceName": "aws_ec2", "Data": map[string]interface{}{"ResourceID": "i-1", "AccountID": "1", "PricePerMonth": 30.0, "Tag": map[string]interface{}{"team": "data"}}},
+		map[string]interface{}{"ResourceName": "aws_ec2", "Data": map[string]interface{}{"ResourceID": "i-2", "AccountID": "1", "PricePerMonth": 10.0, "Tag": map[string]interface{}{"team": "web"}}},
+		map[string]interface{}{"ResourceName": "aws_rds", "Data": map[string]interface{}{"ResourceID": "db-1", "AccountID": "2", "PricePerMonth": 60.0, "Tag": map[string]interface{}{"team": "data"}}},
+		map[string]interface{}{"ResourceName": "aws_rds", "Data": map[string]interface{}{"ResourceID": "db-2", "AccountID": "2", "PricePerMonth": 40.0}},
+	}}
+
+	mockClient.On("Search", currentIndex, mock.MatchedBy(func(query map[string]interface{}) bool {
+		return query["filter_by"] == `EventType=resource_detected AND ExecutionID="1"`
+	})).Return(resourceDetected, nil).Once()
+	mockClient.On("Search", currentIndex, mock.MatchedBy(func(query map[string]interface{}) bool {
+		return query["filter_by"] == `EventType=resource_inventory AND ExecutionID="1"`
+	})).Return(resourceInventory, nil).Once()
+
+	summary, err := sm.GetWasteSummary("1")
+	assert.NoError(t, err)
+
+	// The rightsized db-2 wastes its recommended saving, not its whole price
+	assert.Equal(t, storage.WasteRatio{InventoryCount: 4, InventoryCost: 140, WasteCount: 2, WasteCost: 40, WasteRatio: 40 / 140.0}, summary.Total)
+	assert.Equal(t, storage.WasteRatio{InventoryCount: 2, InventoryCost: 40, WasteCount: 1, WasteCost: 30, WasteRatio: 0.75}, summary.ResourceTypes["aws_ec2"])
+	assert.Equal(t, storage.WasteRatio{InventoryCount: 2, InventoryCost: 100, WasteCount: 1, WasteCost: 10, WasteRatio: 0.1}, summary.ResourceTypes["aws_rds"])
+	assert.Equal(t, 0.75, summary.Accounts["1"].WasteRatio)
+	assert.Equal(t, 0.1, summary.Accounts["2"].WasteRatio)
+	assert.Equal(t, 1/3.0, summary.Tags["team"]["data"].WasteRatio)
+	assert.Equal(t, 0.0, summary.Tags["team"]["web"].WasteRatio)
+	mockClient.AssertExpectations(t)
+}
+
 // Remaining AddEvent and SearchEvents tests commented out as these methods don't exist in the actual StorageManager.
 // The actual StorageManager has Save() method for saving data and various Get methods for querying.
 /*
@@ -246,3 +548,115 @@ func TestGetTimeFromIndexDateString_Invalid(t *testing.T) {
 	assert.Error(t, err, "Should return an error for invalid date format")
 }
 */
+
+// TestStorageManager_GetResources tests the page, the sort and the search of the detected resources.
+func TestStorageManager_GetResources(t *testing.T) {
+	mockClient := new(MockClient)
+	currentIndex := "finala-2023-01-01"
+	sm := &StorageManager{
+		client:          mockClient,
+		currentIndexDay: currentIndex,
+	}
+
+	resourceDetected := &ms.SearchResponse{
+		Hits: []interface{}{
+			map[string]interface{}{"ResourceName": "aws_ec2", "Data": map[string]interface{}{"ResourceID": "i-1", "PricePerMonth": 20.0}},
+			map[string]interface{}{"ResourceName": "aws_ec2", "Data": map[string]interface{}{"ResourceID": "i-2", "PricePerMonth": 10.0}},
+		},
+		TotalHits:  12,
+		TotalPages: 6,
+	}
+
+	mockClient.On("Search", currentIndex, mock.MatchedBy(func(query map[string]interface{}) bool {
+		return query["filter_by"] == `EventType=resource_detected AND ExecutionID="1" AND ResourceName="aws_ec2" AND ("Data.Region" = "us-east-1")` &&
+			query["q"] == "web" && query["page"] == 2 && query["limit"] == 2 &&
+			assert.ObjectsAreEqual([]string{"Data.Name:desc", "Data.ResourceID:desc"}, query["sort"]) &&
+			assert.ObjectsAreEqual(resourcesSearchAttributes, query["search_on"])
+	})).Return(resourceDetected, nil).Once()
+
+	page, err := sm.GetResources("aws_ec2", "1", storage.ResourcesQuery{
+		Filter:    mustParseFilter(t, "Region = us-east-1"),
+		Search:    "web",
+		SortBy:    storage.SortByName,
+		SortOrder: storage.SortOrderDesc,
+		Page:      2,
+		Limit:     2,
+	})
+	assert.NoError(t, err)
+	assert.Len(t, page.Resources, 2)
+	assert.Equal(t, int64(12), page.Total)
+	assert.Equal(t, int64(6), page.Pages)
+	assert.Equal(t, 2, page.Page)
+	mockClient.AssertExpectations(t)
+}
+
+// TestStorageManager_GetSummary_Pages tests the summary reads all the pages of the detected resources.
+func TestStorageManager_GetSummary_Pages(t *testing.T) {
+	mockClient := new(MockClient)
+	currentIndex := "finala-2023-01-01"
+	sm := &StorageManager{
+		client:          mockClient,
+		currentIndexDay: currentIndex,
+	}
+
+	hit := map[string]interface{}{"ResourceName": "aws_ec2", "Data": map[string]interface{}{"PricePerMonth": 10.0}}
+
+	mockClient.On("Search", currentIndex, mock.MatchedBy(func(query map[string]interface{}) bool {
+		return query["filter_by"] == `EventType=service_status AND ExecutionID="1"`
+	})).Return(&ms.SearchResponse{}, nil).Once()
+	for page := 1; page <= 3; page++ {
+		page := page
+		mockClient.On("Search", currentIndex, mock.MatchedBy(func(query map[string]interface{}) bool {
+			return query["filter_by"] == `EventType=resource_detected AND ExecutionID="1"` && query["page"] == page
+		})).Return(&ms.SearchResponse{Hits: []interface{}{hit, hit}, TotalPages: 3}, nil).Once()
+	}
+
+	summary, err := sm.GetSummary("1", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(6), summary["aws_ec2"].ResourceCount)
+	assert.Equal(t, 60.0, summary["aws_ec2"].TotalSpent)
+	mockClient.AssertExpectations(t)
+}
+
+// TestStorageManager_GetWasteSummary_Pages tests the waste summary reads all the pages of the execution events.
+func TestStorageManager_GetWasteSummary_Pages(t *testing.T) {
+	mockClient := new(MockClient)
+	currentIndex := "finala-2023-01-01"
+	sm := &StorageManager{
+		client:          mockClient,
+		currentIndexDay: currentIndex,
+	}
+
+	detected := map[string]interface{}{"ResourceName": "aws_ec2", "Data": map[string]interface{}{"ResourceID": "i-1"}}
+	inventory := func(resourceID string) map[string]interface{} {
+		return map[string]interface{}{"ResourceName": "aws_ec2", "Data": map[string]interface{}{"ResourceID": resourceID, "AccountID": "1", "PricePerMonth": 10.0}}
+	}
+
+	mockClient.On("Search", currentIndex, mock.MatchedBy(func(query map[string]interface{}) bool {
+		return query["filter_by"] == `EventType=resource_detected AND ExecutionID="1"` && query["page"] == 1
+	})).Return(&ms.SearchResponse{Hits: []interface{}{detected}, TotalPages: 1}, nil).Once()
+	for page, resourceIDs := range [][]string{{"i-1", "i-2"}, {"i-3"}} {
+		hits := []interface{}{}
+		for _, resourceID := range resourceIDs {
+			hits = append(hits, inventory(resourceID))
+		}
+		page := page + 1
+		mockClient.On("Search", currentIndex, mock.MatchedBy(func(query map[string]interface{}) bool {
+			return query["filter_by"] == `EventType=resource_inventory AND ExecutionID="1"` && query["page"] == page
+		})).Return(&ms.SearchResponse{Hits: hits, TotalPages: 2}, nil).Once()
+	}
+
+	summary, err := sm.GetWasteSummary("1")
+	assert.NoError(t, err)
+	assert.Equal(t, storage.WasteRatio{InventoryCount: 3, InventoryCost: 30, WasteCount: 1, WasteCost: 10, WasteRatio: 1 / 3.0}, summary.Total)
+	mockClient.AssertExpectations(t)
+}
+
+// mustParseFilter returns the parsed filter expression, and fails the test when it is invalid
+func mustParseFilter(t *testing.T, expression string) filter.Node {
+	node, err := filter.Parse(expression)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return node
+}
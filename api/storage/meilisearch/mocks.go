@@ -1236,6 +1236,22 @@ func (m *MockClient) Index(indexName string, document interface{}) error {
 	return args.Error(0)
 }
 
+func (m *MockClient) IndexBatch(indexName string, documents []map[string]interface{}) (*ms.TaskInfo, error) {
+	args := m.Called(indexName, documents)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*ms.TaskInfo), args.Error(1)
+}
+
+func (m *MockClient) WaitForTask(ctx context.Context, taskUID int64) (*ms.Task, error) {
+	args := m.Called(taskUID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*ms.Task), args.Error(1)
+}
+
 func (m *MockClient) Search(indexName string, query interface{}) (*ms.SearchResponse, error) {
 	args := m.Called(indexName, query)
 	if args.Get(0) == nil {
@@ -1249,6 +1265,11 @@ func (m *MockClient) CreateIndex(indexName string) error {
 	return args.Error(0)
 }
 
+func (m *MockClient) ConfigureIndex(indexName string) error {
+	args := m.Called(indexName)
+	return args.Error(0)
+}
+
 func (m *MockClient) DeleteIndex(indexName string) (bool, error) {
 	args := m.Called(indexName)
 	return args.Bool(0), args.Error(1)
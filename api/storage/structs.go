@@ -1,9 +1,18 @@
 package storage
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"finala/api/filter"
+	"fmt"
 	"time"
 )
 
+// ErrExecutionNotFound is returned when the execution was not registered by its collector
+var ErrExecutionNotFound = errors.New("execution not found")
+
 const (
 	// GetExecutionsQueryLimit Describes the query limit results for GetExecutions API
 	GetExecutionsQueryLimit = "20"
@@ -11,11 +20,15 @@ const (
 
 type StorageDescriber interface {
 	Save(data string) bool
-	GetSummary(executionID string, filters map[string]string) (map[string]CollectorsSummary, error)
+	SaveBatch(rows []EventRow, wait bool) error
+	GetSummary(executionID string, resourcesFilter filter.Node) (map[string]CollectorsSummary, error)
 	GetExecutions(querylimit int) ([]Executions, error)
-	GetResources(resourceType string, executionID string, filters map[string]string, search string) ([]map[string]interface{}, error)
-	GetResourceTrends(resourceType string, filters map[string]string, limit int) ([]ExecutionCost, error)
+	SaveExecution(execution Execution) error
+	GetExecution(executionID string) (Execution, error)
+	GetResources(resourceType string, executionID string, query ResourcesQuery) (ResourcesPage, error)
+	GetResourceTrends(resourceType string, resourcesFilter filter.Node, groupBy string, limit int) ([]ExecutionCost, error)
 	GetExecutionTags(executionID string) (map[string][]string, error)
+	GetWasteSummary(executionID string) (WasteSummary, error)
 }
 
 // Executions defines the collectors execution  data
@@ -25,24 +38,144 @@ type Executions struct {
 	Time time.Time
 }
 
+// Execution defines a collector execution, registered by the collector when it starts and closed when it ends
+type Execution struct {
+	ExecutionID      string
+	Name             string
+	StartTime        time.Time
+	EndTime          *time.Time `json:"EndTime,omitempty"`
+	Accounts         []string
+	Regions          []string
+	ConfigHash       string
+	CollectorVersion string
+}
+
+const (
+	// SortByPrice sorts the detected resources by their monthly price
+	SortByPrice = "price"
+
+	// SortByLaunchTime sorts the detected resources by their launch time
+	SortByLaunchTime = "launch_time"
+
+	// SortByName sorts the detected resources by their name, and by their id when they have no name
+	SortByName = "name"
+
+	// SortOrderAsc sorts the detected resources in ascending order
+	SortOrderAsc = "asc"
+
+	// SortOrderDesc sorts the detected resources in descending order
+	SortOrderDesc = "desc"
+)
+
+const (
+	// DefaultResourcesPageLimit defines the number of detected resources of a page when the limit is not given
+	DefaultResourcesPageLimit = 50
+
+	// MaxResourcesPageLimit defines the maximum number of detected resources of a page
+	MaxResourcesPageLimit = 1000
+)
+
+// ResourcesQuery describes the page of the detected resources to return, their filter, their order and a free-text
+// search over their name, id and tags. Page starts at 1
+type ResourcesQuery struct {
+	Filter    filter.Node
+	Search    string
+	SortBy    string
+	SortOrder string
+	Page      int
+	Limit     int
+}
+
+// ResourcesPage defines a page of the detected resources, with the total number of resources matching the query
+type ResourcesPage struct {
+	Resources []map[string]interface{}
+	Page      int
+	Limit     int
+	Total     int64
+	Pages     int64
+}
+
 type ExecutionCost struct {
 	ExecutionID        string
 	ExtractedTimestamp int64
 	CostSum            float64
+	Group              string `json:"Group,omitempty"`
 }
 
+const (
+	// GroupByAccount groups the resource trends by the account id of the detected resources
+	GroupByAccount = "account"
+
+	// GroupByRegion groups the resource trends by the region of the detected resources
+	GroupByRegion = "region"
+)
+
+const (
+	// CategoryPotentialCostSaving describes detected resources with pricing data
+	CategoryPotentialCostSaving = "potential_cost_saving"
+
+	// CategoryUnusedResource describes detected resources without pricing data
+	CategoryUnusedResource = "unused_resource"
+
+	// CategoryModernization describes resources running on previous generation types
+	CategoryModernization = "modernization"
+
+	// CategoryUnusedReservation describes reservations without matching running resources
+	CategoryUnusedReservation = "unused_reservation"
+
+	// CategoryTagsCompliance describes resources missing required tags, priced by their current cost
+	CategoryTagsCompliance = "tags_compliance"
+)
+
 // CollectorsSummary defines unused resource summary
 type CollectorsSummary struct {
-	ResourceName  string  `json:"ResourceName"`
+	ResourceName  string                      `json:"ResourceName"`
+	ResourceCount int64                       `json:"ResourceCount"`
+	TotalSpent    float64                     `json:"TotalSpent"`
+	Status        int                         `json:"Status"`
+	ErrorMessage  string                      `json:"ErrorMessage"`
+	EventTime     int64                       `json:"-"`
+	HasPricing    bool                        `json:"HasPricing"`
+	Category      string                      `json:"Category"`
+	Accounts      map[string]DimensionSummary `json:"Accounts"`
+	Regions       map[string]DimensionSummary `json:"Regions"`
+}
+
+// DimensionSummary defines the detected resources summary of a single account or region
+type DimensionSummary struct {
+	Name          string  `json:"Name,omitempty"`
 	ResourceCount int64   `json:"ResourceCount"`
 	TotalSpent    float64 `json:"TotalSpent"`
-	Status        int     `json:"Status"`
-	ErrorMessage  string  `json:"ErrorMessage"`
-	EventTime     int64   `json:"-"`
-	HasPricing    bool    `json:"HasPricing"`
-	Category      string  `json:"Category"`
 }
 
+// WasteRatio defines the cost of the detected resources out of the inventory cost
+type WasteRatio struct {
+	InventoryCount int64   `json:"InventoryCount"`
+	InventoryCost  float64 `json:"InventoryCost"`
+	WasteCount     int64   `json:"WasteCount"`
+	WasteCost      float64 `json:"WasteCost"`
+	WasteRatio     float64 `json:"WasteRatio"`
+}
+
+// WasteSummary defines the execution waste ratio in total, by resource type, by account and by tag key and value
+type WasteSummary struct {
+	Total         WasteRatio                       `json:"Total"`
+	ResourceTypes map[string]WasteRatio            `json:"ResourceTypes"`
+	Accounts      map[string]WasteRatio            `json:"Accounts"`
+	Tags          map[string]map[string]WasteRatio `json:"Tags"`
+}
+
+const (
+	// ServiceStatusFetch describes a resource type the collector started to collect
+	ServiceStatusFetch = iota
+
+	// ServiceStatusError describes a resource type the collector failed to collect
+	ServiceStatusError
+
+	// ServiceStatusFinish describes a resource type the collector finished to collect
+	ServiceStatusFinish
+)
+
 type SummaryData struct {
 	Status       int    `json:"Status"`
 	ErrorMessage string `json:"ErrorMessage"`
@@ -56,12 +189,23 @@ type Summary struct {
 }
 
 type EventRow struct {
-	ExecutionID  string
-	ResourceName string
-	EventType    string
-	EventTime    int64
-	Timestamp    time.Time
-	Data         interface{}
+	ExecutionID   string
+	SchemaVersion int
+	ResourceName  string
+	EventType     string
+	EventTime     int64
+	Timestamp     time.Time
+	Data          interface{}
+}
+
+// DocumentID returns the event document id, derived from the execution, the resource and the event.
+// A batch the collector sends again is stored with the same ids, so it replaces the documents instead of duplicating them
+func (row EventRow) DocumentID() string {
+	data, _ := json.Marshal(row.Data)
+	hash := sha256.New()
+	fmt.Fprintf(hash, "%s\x00%s\x00%s\x00%d\x00", row.ExecutionID, row.ResourceName, row.EventType, row.EventTime)
+	hash.Write(data)
+	return hex.EncodeToString(hash.Sum(nil))
 }
 
 type ResourceData struct {
@@ -0,0 +1,240 @@
+package stream
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// EventResync is sent to a client which resumed after events which are no longer buffered,
+	// the client should reload the execution instead of applying the missed events
+	EventResync = "resync"
+
+	// idSeparator separates the broker epoch from the event sequence in the event ids
+	idSeparator = "-"
+)
+
+// Event describes a server-sent event of an execution
+type Event struct {
+	ID   string
+	Type string
+	Data json.RawMessage
+}
+
+// Subscription describes the events of an execution a client receives. Replay holds the buffered events after the
+// client last event id, and Events the published events after them. Events is closed when the client is too slow
+// to receive the published events, or when the broker is closed
+type Subscription struct {
+	Replay []Event
+	Events <-chan Event
+
+	executionID string
+	events      chan Event
+}
+
+// executionBuffer holds the recent events of an execution and its subscriptions. The events of the execution
+// up to the since sequence are no longer buffered
+type executionBuffer struct {
+	since         uint64
+	events        []Event
+	subscriptions map[*Subscription]struct{}
+	lastPublish   time.Time
+}
+
+// Broker publishes the events of the executions to their subscriptions, and buffers the recent events of each
+// execution, so a client which reconnects resumes from its last event id. The event ids are a sequence of the broker
+// prefixed by its start time, so the ids of the events before an api restart are not resumed.
+// Publishing never blocks on a slow client, the subscription of a client which did not receive its buffered events
+// is closed instead
+type Broker struct {
+	mu               sync.Mutex
+	epoch            string
+	sequence         uint64
+	bufferSize       int
+	subscriberBuffer int
+	retention        time.Duration
+	executions       map[string]*executionBuffer
+	closed           bool
+}
+
+// NewBroker returns a broker which buffers the given number of recent events of each execution, and the given number
+// of events of each subscription. Executions without subscriptions are released after the retention without events
+func NewBroker(bufferSize, subscriberBuffer int, retention time.Duration) *Broker {
+	return &Broker{
+		epoch:            strconv.FormatInt(time.Now().UnixNano(), 36),
+		bufferSize:       bufferSize,
+		subscriberBuffer: subscriberBuffer,
+		retention:        retention,
+		executions:       map[string]*executionBuffer{},
+	}
+}
+
+// Publish sends the given event to the subscriptions of the execution, and buffers it for the clients which resume
+func (b *Broker) Publish(executionID, eventType string, data interface{}) error {
+
+	buf, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return nil
+	}
+	b.release()
+
+	execution := b.execution(executionID)
+	b.sequence++
+	execution.lastPublish = time.Now()
+	event := Event{
+		ID:   fmt.Sprintf("%s%s%d", b.epoch, idSeparator, b.sequence),
+		Type: eventType,
+		Data: buf,
+	}
+
+	execution.events = append(execution.events, event)
+	if len(execution.events) > b.bufferSize {
+		trimmed := len(execution.events) - b.bufferSize
+		execution.since = b.sequenceOf(execution.events[trimmed-1])
+		execution.events = execution.events[trimmed:]
+	}
+
+	for subscription := range execution.subscriptions {
+		select {
+		case subscription.events <- event:
+		default:
+			// The client is too slow, it resumes from its last event id when it reconnects
+			delete(execution.subscriptions, subscription)
+			close(subscription.events)
+		}
+	}
+	return nil
+}
+
+// Subscribe returns a subscription of the execution events after the given last event id.
+// Without a last event id, only the events published after the subscription are received
+func (b *Broker) Subscribe(executionID, lastEventID string) *Subscription {
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	events := make(chan Event, b.subscriberBuffer)
+	subscription := &Subscription{
+		Events:      events,
+		executionID: executionID,
+		events:      events,
+	}
+	if b.closed {
+		close(events)
+		return subscription
+	}
+
+	execution := b.execution(executionID)
+	if lastEventID != "" {
+		subscription.Replay = b.replay(execution, lastEventID)
+	}
+	execution.subscriptions[subscription] = struct{}{}
+
+	return subscription
+}
+
+// Unsubscribe removes the given subscription
+func (b *Broker) Unsubscribe(subscription *Subscription) {
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	execution, found := b.executions[subscription.executionID]
+	if !found {
+		return
+	}
+	if _, found := execution.subscriptions[subscription]; found {
+		delete(execution.subscriptions, subscription)
+		close(subscription.events)
+	}
+
+	// The execution was subscribed before any of its events was published
+	if len(execution.subscriptions) == 0 && len(execution.events) == 0 {
+		delete(b.executions, subscription.executionID)
+	}
+}
+
+// Close closes all the subscriptions, and ignores the events published after it
+func (b *Broker) Close() {
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.closed = true
+	for _, execution := range b.executions {
+		for subscription := range execution.subscriptions {
+			close(subscription.events)
+		}
+	}
+	b.executions = map[string]*executionBuffer{}
+}
+
+// execution returns the buffer of the given execution, the caller must hold the lock
+func (b *Broker) execution(executionID string) *executionBuffer {
+	execution, found := b.executions[executionID]
+	if !found {
+		execution = &executionBuffer{
+			since:         b.sequence,
+			subscriptions: map[*Subscription]struct{}{},
+			lastPublish:   time.Now(),
+		}
+		b.executions[executionID] = execution
+	}
+	return execution
+}
+
+// replay returns the buffered events after the given last event id, the caller must hold the lock.
+// A resync event is returned first when events after the last event id are no longer buffered
+func (b *Broker) replay(execution *executionBuffer, lastEventID string) []Event {
+
+	epoch, sequence, found := strings.Cut(lastEventID, idSeparator)
+	lastSequence, err := strconv.ParseUint(sequence, 10, 64)
+	if !found || err != nil || epoch != b.epoch {
+		// The event id was sent by another broker, before the api restarted
+		return append([]Event{b.resyncEvent()}, execution.events...)
+	}
+
+	replay := []Event{}
+	if lastSequence < execution.since {
+		replay = append(replay, b.resyncEvent())
+	}
+	for _, event := range execution.events {
+		if b.sequenceOf(event) > lastSequence {
+			replay = append(replay, event)
+		}
+	}
+	return replay
+}
+
+// release removes the buffers of the executions without subscriptions and without events for the retention,
+// the caller must hold the lock
+func (b *Broker) release() {
+	for executionID, execution := range b.executions {
+		if len(execution.subscriptions) == 0 && time.Since(execution.lastPublish) > b.retention {
+			delete(b.executions, executionID)
+		}
+	}
+}
+
+// resyncEvent returns an event which asks the client to reload the execution
+func (b *Broker) resyncEvent() Event {
+	return Event{Type: EventResync, Data: json.RawMessage("{}")}
+}
+
+// sequenceOf returns the sequence of the given event id of this broker
+func (b *Broker) sequenceOf(event Event) uint64 {
+	_, sequence, _ := strings.Cut(event.ID, idSeparator)
+	value, _ := strconv.ParseUint(sequence, 10, 64)
+	return value
+}
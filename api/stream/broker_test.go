@@ -0,0 +1,134 @@
+package stream_test
+
+import (
+	"testing"
+	"time"
+
+	"finala/api/stream"
+)
+
+// receive returns the next event of the subscription
+func receive(t *testing.T, subscription *stream.Subscription) stream.Event {
+	t.Helper()
+	select {
+	case event, ok := <-subscription.Events:
+		if !ok {
+			t.Fatalf("unexpected closed subscription")
+		}
+		return event
+	case <-time.After(time.Second):
+		t.Fatalf("no event was received")
+	}
+	return stream.Event{}
+}
+
+func TestPublish(t *testing.T) {
+
+	broker := stream.NewBroker(10, 10, time.Hour)
+	subscription := broker.Subscribe("general_1", "")
+	other := broker.Subscribe("general_2", "")
+
+	if err := broker.Publish("general_1", "service_status", map[string]int{"Status": 0}); err != nil {
+		t.Fatalf("unexpected publish error: %v", err)
+	}
+
+	event := receive(t, subscription)
+	if event.Type != "service_status" || string(event.Data) != `{"Status":0}` || event.ID == "" {
+		t.Fatalf("unexpected event, got %+v", event)
+	}
+	if len(subscription.Replay) != 0 {
+		t.Fatalf("unexpected replay without a last event id, got %d events", len(subscription.Replay))
+	}
+
+	// The subscriptions receive only the events of their execution
+	select {
+	case event := <-other.Events:
+		t.Fatalf("unexpected event of another execution, got %+v", event)
+	default:
+	}
+
+	broker.Unsubscribe(subscription)
+	if _, ok := <-subscription.Events; ok {
+		t.Fatalf("unexpected open subscription after unsubscribe")
+	}
+}
+
+func TestResume(t *testing.T) {
+
+	broker := stream.NewBroker(3, 10, time.Hour)
+	subscription := broker.Subscribe("general_1", "")
+	ids := []string{}
+	for i := 0; i < 5; i++ {
+		if err := broker.Publish("general_1", "resource_detected", i); err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, receive(t, subscription).ID)
+	}
+	broker.Unsubscribe(subscription)
+
+	testCases := []struct {
+		name           string
+		lastEventID    string
+		expectedReplay []string
+	}{
+		{"buffered events", ids[2], []string{"3", "4"}},
+		{"last event", ids[4], []string{}},
+		{"events no longer buffered", ids[0], []string{stream.EventResync, "2", "3", "4"}},
+		{"event id of a previous api run", "previous-3", []string{stream.EventResync, "2", "3", "4"}},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			resumed := broker.Subscribe("general_1", test.lastEventID)
+			defer broker.Unsubscribe(resumed)
+
+			replay := []string{}
+			for _, event := range resumed.Replay {
+				if event.Type == stream.EventResync {
+					replay = append(replay, event.Type)
+					continue
+				}
+				replay = append(replay, string(event.Data))
+			}
+			if len(replay) != len(test.expectedReplay) {
+				t.Fatalf("unexpected replay, got %v expected %v", replay, test.expectedReplay)
+			}
+			for i := range replay {
+				if replay[i] != test.expectedReplay[i] {
+					t.Fatalf("unexpected replay, got %v expected %v", replay, test.expectedReplay)
+				}
+			}
+		})
+	}
+}
+
+func TestSlowSubscription(t *testing.T) {
+
+	broker := stream.NewBroker(10, 2, time.Hour)
+	slow := broker.Subscribe("general_1", "")
+	fast := broker.Subscribe("general_1", "")
+
+	for i := 0; i < 3; i++ {
+		if err := broker.Publish("general_1", "resource_detected", i); err != nil {
+			t.Fatal(err)
+		}
+		receive(t, fast)
+	}
+
+	// The slow subscription is closed instead of blocking the publisher, after its buffered events
+	received := 0
+	for range slow.Events {
+		received++
+	}
+	if received != 2 {
+		t.Fatalf("unexpected slow subscription events, got %d expected %d", received, 2)
+	}
+
+	broker.Close()
+	if _, ok := <-fast.Events; ok {
+		t.Fatalf("unexpected open subscription after close")
+	}
+	if err := broker.Publish("general_1", "resource_detected", 4); err != nil {
+		t.Fatalf("unexpected publish error after close: %v", err)
+	}
+}
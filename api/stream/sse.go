@@ -0,0 +1,24 @@
+package stream
+
+import (
+	"fmt"
+	"io"
+)
+
+// Write writes the given event in the server-sent events format. The events without an id do not change
+// the client last event id
+func Write(w io.Writer, event Event) error {
+	if event.ID != "" {
+		if _, err := fmt.Fprintf(w, "id: %s\n", event.ID); err != nil {
+			return err
+		}
+	}
+	_, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event.Type, event.Data)
+	return err
+}
+
+// WriteKeepAlive writes a comment, which keeps idle connections open through proxies
+func WriteKeepAlive(w io.Writer) error {
+	_, err := io.WriteString(w, ": keep-alive\n\n")
+	return err
+}
@@ -0,0 +1,38 @@
+package stream_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"finala/api/stream"
+)
+
+func TestWrite(t *testing.T) {
+
+	testCases := []struct {
+		name     string
+		event    stream.Event
+		expected string
+	}{
+		{"event", stream.Event{ID: "a-1", Type: "service_status", Data: json.RawMessage(`{"Status":2}`)}, "id: a-1\nevent: service_status\ndata: {\"Status\":2}\n\n"},
+		{"event without id", stream.Event{Type: stream.EventResync, Data: json.RawMessage(`{}`)}, "event: resync\ndata: {}\n\n"},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := stream.Write(&buf, test.event); err != nil {
+				t.Fatalf("unexpected write error: %v", err)
+			}
+			if buf.String() != test.expected {
+				t.Fatalf("unexpected event format, got %q expected %q", buf.String(), test.expected)
+			}
+		})
+	}
+
+	var buf bytes.Buffer
+	if err := stream.WriteKeepAlive(&buf); err != nil || buf.String() != ": keep-alive\n\n" {
+		t.Fatalf("unexpected keep-alive, got %q %v", buf.String(), err)
+	}
+}
@@ -0,0 +1,225 @@
+package testutils
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	// MockOIDCClientID is the client id the mock identity provider issues id tokens to
+	MockOIDCClientID = "finala"
+
+	// MockOIDCClientSecret is the client secret the mock identity provider accepts
+	MockOIDCClientSecret = "finala-secret"
+
+	// mockOIDCKeyID is the key id of the mock identity provider signing key
+	mockOIDCKeyID = "mock-key"
+)
+
+// mockAuthorization describes an issued authorization code
+type mockAuthorization struct {
+	nonce         string
+	codeChallenge string
+	claims        map[string]interface{}
+}
+
+// MockOIDCProvider is a local OIDC identity provider. Every authorization request is signed in as the user of the
+// configured claims without prompting
+type MockOIDCProvider struct {
+	Server *httptest.Server
+
+	mu             sync.Mutex
+	claims         map[string]interface{}
+	nonce          string
+	signingKey     *rsa.PrivateKey
+	authorizations map[string]mockAuthorization
+}
+
+// NewMockOIDCProvider starts a local identity provider, the caller must close its server
+func NewMockOIDCProvider() (*MockOIDCProvider, error) {
+
+	signingKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return nil, err
+	}
+
+	provider := &MockOIDCProvider{
+		signingKey:     signingKey,
+		authorizations: map[string]mockAuthorization{},
+	}
+
+	router := http.NewServeMux()
+	router.HandleFunc("GET /.well-known/openid-configuration", provider.discovery)
+	router.HandleFunc("GET /keys", provider.keys)
+	router.HandleFunc("GET /authorize", provider.authorize)
+	router.HandleFunc("POST /token", provider.token)
+	provider.Server = httptest.NewServer(router)
+
+	return provider, nil
+}
+
+// IssuerURL returns the issuer url of the identity provider
+func (mp *MockOIDCProvider) IssuerURL() string {
+	return mp.Server.URL
+}
+
+// SetClaims sets the id token claims of the next sign-ins, the issuer, audience, expiry and nonce claims are added
+func (mp *MockOIDCProvider) SetClaims(claims map[string]interface{}) {
+	mp.mu.Lock()
+	defer mp.mu.Unlock()
+	mp.claims = claims
+}
+
+// SetNonce overrides the nonce of the next id tokens, when empty the nonce of the authorization request is used
+func (mp *MockOIDCProvider) SetNonce(nonce string) {
+	mp.mu.Lock()
+	defer mp.mu.Unlock()
+	mp.nonce = nonce
+}
+
+// discovery returns the provider metadata
+func (mp *MockOIDCProvider) discovery(w http.ResponseWriter, r *http.Request) {
+	mp.writeJSON(w, http.StatusOK, map[string]interface{}{
+		"issuer":                                mp.Server.URL,
+		"authorization_endpoint":                mp.Server.URL + "/authorize",
+		"token_endpoint":                        mp.Server.URL + "/token",
+		"jwks_uri":                              mp.Server.URL + "/keys",
+		"response_types_supported":              []string{"code"},
+		"subject_types_supported":               []string{"public"},
+		"id_token_signing_alg_values_supported": []string{"RS256"},
+		"code_challenge_methods_supported":      []string{"S256"},
+	})
+}
+
+// keys returns the public signing key of the id tokens
+func (mp *MockOIDCProvider) keys(w http.ResponseWriter, r *http.Request) {
+	publicKey := mp.signingKey.PublicKey
+	mp.writeJSON(w, http.StatusOK, map[string]interface{}{
+		"keys": []map[string]string{{
+			"kty": "RSA",
+			"alg": "RS256",
+			"use": "sig",
+			"kid": mockOIDCKeyID,
+			"n":   base64.RawURLEncoding.EncodeToString(publicKey.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(publicKey.E)).Bytes()),
+		}},
+	})
+}
+
+// authorize signs in the configured user, and redirects back to the client with an authorization code
+func (mp *MockOIDCProvider) authorize(w http.ResponseWriter, r *http.Request) {
+
+	query := r.URL.Query()
+	if query.Get("client_id") != MockOIDCClientID || query.Get("response_type") != "code" {
+		http.Error(w, "invalid authorization request", http.StatusBadRequest)
+		return
+	}
+	if query.Get("code_challenge_method") != "S256" || query.Get("code_challenge") == "" {
+		http.Error(w, "pkce is required", http.StatusBadRequest)
+		return
+	}
+
+	redirectURL, err := url.Parse(query.Get("redirect_uri"))
+	if err != nil {
+		http.Error(w, "invalid redirect uri", http.StatusBadRequest)
+		return
+	}
+
+	code := fmt.Sprintf("code-%d", time.Now().UnixNano())
+	mp.mu.Lock()
+	mp.authorizations[code] = mockAuthorization{
+		nonce:         query.Get("nonce"),
+		codeChallenge: query.Get("code_challenge"),
+		claims:        mp.claims,
+	}
+	mp.mu.Unlock()
+
+	values := redirectURL.Query()
+	values.Set("code", code)
+	values.Set("state", query.Get("state"))
+	redirectURL.RawQuery = values.Encode()
+	http.Redirect(w, r, redirectURL.String(), http.StatusFound)
+}
+
+// token exchanges an authorization code for a signed id token
+func (mp *MockOIDCProvider) token(w http.ResponseWriter, r *http.Request) {
+
+	err := r.ParseForm()
+	if err != nil {
+		mp.writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_request"})
+		return
+	}
+
+	clientID, clientSecret, ok := r.BasicAuth()
+	if !ok {
+		clientID, clientSecret = r.PostForm.Get("client_id"), r.PostForm.Get("client_secret")
+	}
+	if clientID != MockOIDCClientID || clientSecret != MockOIDCClientSecret {
+		mp.writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "invalid_client"})
+		return
+	}
+
+	mp.mu.Lock()
+	authorization, found := mp.authorizations[r.PostForm.Get("code")]
+	delete(mp.authorizations, r.PostForm.Get("code"))
+	nonce := mp.nonce
+	mp.mu.Unlock()
+	if !found {
+		mp.writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant"})
+		return
+	}
+
+	challenge := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+	if base64.RawURLEncoding.EncodeToString(challenge[:]) != authorization.codeChallenge {
+		mp.writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant"})
+		return
+	}
+
+	if nonce == "" {
+		nonce = authorization.nonce
+	}
+	claims := jwt.MapClaims{
+		"iss":   mp.Server.URL,
+		"aud":   MockOIDCClientID,
+		"iat":   time.Now().Unix(),
+		"exp":   time.Now().Add(time.Hour).Unix(),
+		"nonce": nonce,
+	}
+	for key, value := range authorization.claims {
+		claims[key] = value
+	}
+
+	idToken := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	idToken.Header["kid"] = mockOIDCKeyID
+	signedIDToken, err := idToken.SignedString(mp.signingKey)
+	if err != nil {
+		mp.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "server_error"})
+		return
+	}
+
+	mp.writeJSON(w, http.StatusOK, map[string]interface{}{
+		"access_token": "mock-access-token",
+		"token_type":   "Bearer",
+		"expires_in":   3600,
+		"id_token":     signedIDToken,
+	})
+}
+
+// writeJSON writes the given data as a json response
+func (mp *MockOIDCProvider) writeJSON(w http.ResponseWriter, statusCode int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	_ = json.NewEncoder(w).Encode(data)
+}
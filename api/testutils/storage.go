@@ -2,18 +2,35 @@ package testutils
 
 import (
 	"errors"
+	"finala/api/filter"
 	"finala/api/storage"
 	"time"
 )
 
 type MockStorage struct {
 	Events int
+
+	// SaveBatchErr is returned by SaveBatch, without saving the events
+	SaveBatchErr error
+
+	// Waited is true when the last saved batch waited for the storage
+	Waited bool
+
+	// Executions are the saved executions by their id
+	Executions map[string]storage.Execution
+
+	// ResourcesQuery is the query of the last requested resources page
+	ResourcesQuery storage.ResourcesQuery
+
+	// Filter is the resources filter of the last requested summary or trends
+	Filter filter.Node
 }
 
 func NewMockStorage() *MockStorage {
 
 	return &MockStorage{
-		Events: 0,
+		Events:     0,
+		Executions: map[string]storage.Execution{},
 	}
 }
 
@@ -22,7 +39,17 @@ func (ms *MockStorage) Save(data string) bool {
 	return true
 }
 
-func (ms *MockStorage) GetSummary(executionID string, filters map[string]string) (map[string]storage.CollectorsSummary, error) {
+func (ms *MockStorage) SaveBatch(rows []storage.EventRow, wait bool) error {
+	if ms.SaveBatchErr != nil {
+		return ms.SaveBatchErr
+	}
+	ms.Events += len(rows)
+	ms.Waited = wait
+	return nil
+}
+
+func (ms *MockStorage) GetSummary(executionID string, resourcesFilter filter.Node) (map[string]storage.CollectorsSummary, error) {
+	ms.Filter = resourcesFilter
 
 	if executionID == "err" {
 		return nil, errors.New("error")
@@ -69,12 +96,26 @@ func (ms *MockStorage) GetExecutions(queryLimit int) ([]storage.Executions, erro
 	return response, nil
 }
 
-func (ms *MockStorage) GetResources(resourceType string, executionID string, filters map[string]string, search string) ([]map[string]interface{}, error) {
+func (ms *MockStorage) SaveExecution(execution storage.Execution) error {
+	ms.Executions[execution.ExecutionID] = execution
+	return nil
+}
+
+func (ms *MockStorage) GetExecution(executionID string) (storage.Execution, error) {
+	execution, found := ms.Executions[executionID]
+	if !found {
+		return execution, storage.ErrExecutionNotFound
+	}
+	return execution, nil
+}
+
+func (ms *MockStorage) GetResources(resourceType string, executionID string, query storage.ResourcesQuery) (storage.ResourcesPage, error) {
 
 	var response []map[string]interface{}
+	ms.ResourcesQuery = query
 
 	if executionID == "err" {
-		return nil, errors.New("error")
+		return storage.ResourcesPage{}, errors.New("error")
 	}
 
 	type tempStruct struct {
@@ -88,11 +129,18 @@ func (ms *MockStorage) GetResources(resourceType string, executionID string, fil
 	response = append(response, rowData)
 	response = append(response, rowData1)
 
-	return response, nil
+	return storage.ResourcesPage{
+		Resources: response,
+		Page:      query.Page,
+		Limit:     query.Limit,
+		Total:     int64(len(response)),
+		Pages:     1,
+	}, nil
 
 }
 
-func (ms *MockStorage) GetResourceTrends(resourceType string, filters map[string]string, limit int) ([]storage.ExecutionCost, error) {
+func (ms *MockStorage) GetResourceTrends(resourceType string, resourcesFilter filter.Node, groupBy string, limit int) ([]storage.ExecutionCost, error) {
+	ms.Filter = resourcesFilter
 	var response []storage.ExecutionCost
 
 	if resourceType == "err" {
@@ -110,6 +158,12 @@ func (ms *MockStorage) GetResourceTrends(resourceType string, filters map[string
 		CostSum:            19,
 	})
 
+	if groupBy != "" {
+		for i := range response {
+			response[i].Group = "dummy_group"
+		}
+	}
+
 	if len(response) > limit {
 		response = response[0:limit]
 	}
@@ -132,3 +186,28 @@ func (ms *MockStorage) GetExecutionTags(executionID string) (map[string][]string
 	return response, nil
 
 }
+
+func (ms *MockStorage) GetWasteSummary(executionID string) (storage.WasteSummary, error) {
+
+	if executionID == "err" {
+		return storage.WasteSummary{}, errors.New("error")
+	}
+
+	response := storage.WasteSummary{
+		Total: storage.WasteRatio{InventoryCount: 4, InventoryCost: 200, WasteCount: 1, WasteCost: 50, WasteRatio: 0.25},
+		ResourceTypes: map[string]storage.WasteRatio{
+			"aws_ec2": {InventoryCount: 3, InventoryCost: 150, WasteCount: 1, WasteCost: 50, WasteRatio: 1.0 / 3},
+			"aws_rds": {InventoryCount: 1, InventoryCost: 50},
+		},
+		Accounts: map[string]storage.WasteRatio{
+			"1234": {InventoryCount: 4, InventoryCost: 200, WasteCount: 1, WasteCost: 50, WasteRatio: 0.25},
+		},
+		Tags: map[string]map[string]storage.WasteRatio{
+			"team": {
+				"data": {InventoryCount: 2, InventoryCost: 100, WasteCount: 1, WasteCost: 50, WasteRatio: 0.5},
+			},
+		},
+	}
+
+	return response, nil
+}
@@ -0,0 +1,36 @@
+package users
+
+// Role describes the access level of a user
+type Role string
+
+const (
+	// RoleViewer can view the executions cost summaries
+	RoleViewer Role = "viewer"
+
+	// RoleAnalyst can also drill down into the detected resources and their trends
+	RoleAnalyst Role = "analyst"
+
+	// RoleAdmin can also send reports and manage the users
+	RoleAdmin Role = "admin"
+)
+
+// roleLevels defines the access level of each role, a role is granted everything the lower levels are granted
+var roleLevels = map[Role]int{
+	RoleViewer:  1,
+	RoleAnalyst: 2,
+	RoleAdmin:   3,
+}
+
+// IsValid returns true if the role is a known role
+func (r Role) IsValid() bool {
+	_, found := roleLevels[r]
+	return found
+}
+
+// Allows returns true if the role grants the access of the required role
+func (r Role) Allows(required Role) bool {
+	if !r.IsValid() || !required.IsValid() {
+		return false
+	}
+	return roleLevels[r] >= roleLevels[required]
+}
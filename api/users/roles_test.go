@@ -0,0 +1,36 @@
+package users_test
+
+import (
+	"testing"
+
+	"finala/api/users"
+)
+
+func TestRoleAllows(t *testing.T) {
+
+	testCases := []struct {
+		role     users.Role
+		required users.Role
+		expected bool
+	}{
+		{users.RoleViewer, users.RoleViewer, true},
+		{users.RoleViewer, users.RoleAnalyst, false},
+		{users.RoleViewer, users.RoleAdmin, false},
+		{users.RoleAnalyst, users.RoleViewer, true},
+		{users.RoleAnalyst, users.RoleAnalyst, true},
+		{users.RoleAnalyst, users.RoleAdmin, false},
+		{users.RoleAdmin, users.RoleViewer, true},
+		{users.RoleAdmin, users.RoleAdmin, true},
+		{users.Role("foo"), users.RoleViewer, false},
+		{users.Role(""), users.RoleViewer, false},
+		{users.RoleAdmin, users.Role("foo"), false},
+	}
+
+	for _, test := range testCases {
+		t.Run(string(test.role)+"_"+string(test.required), func(t *testing.T) {
+			if test.role.Allows(test.required) != test.expected {
+				t.Fatalf("unexpected %q allows %q, got %t expected %t", test.role, test.required, !test.expected, test.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,459 @@
+package users
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"finala/serverutil"
+
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/crypto/bcrypt"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	// minPasswordLength is the minimum length of a user password
+	minPasswordLength = 8
+
+	// maxPasswordLength is the maximum length of a password bcrypt hashes
+	maxPasswordLength = 72
+
+	// generatedPasswordLength is the length of the bootstrap admin password when no password is configured
+	generatedPasswordLength = 20
+
+	// defaultAdminUsername is the username of the bootstrap admin when no username is configured
+	defaultAdminUsername = "admin"
+
+	// SourceOIDC is the source of the users signed in by the OIDC identity provider
+	SourceOIDC = "oidc"
+)
+
+var (
+	// ErrInvalidCredentials is returned when the username or the password are wrong
+	ErrInvalidCredentials = errors.New("invalid username or password")
+
+	// ErrUserNotFound is returned when the user does not exist
+	ErrUserNotFound = errors.New("user not found")
+
+	// ErrUserExists is returned when creating a user that already exists
+	ErrUserExists = errors.New("user already exists")
+
+	// ErrLastAdmin is returned when deleting or demoting the last admin
+	ErrLastAdmin = errors.New("at least one admin user is required")
+
+	// generatedPasswordOutput is where the generated bootstrap admin password is printed, outside of the structured logs
+	generatedPasswordOutput io.Writer = os.Stderr
+)
+
+// ValidationError is returned when the user fields are invalid
+type ValidationError struct {
+	Message string
+}
+
+func (e *ValidationError) Error() string {
+	return e.Message
+}
+
+// User describes an api user. Users with a source are managed by an external identity provider, and have no password
+type User struct {
+	Username     string `yaml:"username" json:"username"`
+	PasswordHash string `yaml:"password_hash,omitempty" json:"-"`
+	Role         Role   `yaml:"role" json:"role"`
+	Source       string `yaml:"source,omitempty" json:"source,omitempty"`
+}
+
+// usersFile describes the users file structure
+type usersFile struct {
+	Users []User `yaml:"users"`
+}
+
+// Store manages the api users, and persists them with their password hashes to a yaml file
+type Store struct {
+	mu    sync.RWMutex
+	path  string
+	users map[string]User
+
+	// dummyHash is compared against when the user does not exist, so unknown users take as long as wrong passwords
+	dummyHash []byte
+}
+
+// NewStore loads the users from the given file path. A missing file is loaded as an empty store
+func NewStore(path string) (*Store, error) {
+
+	dummyHash, err := bcrypt.GenerateFromPassword([]byte("finala-dummy-password"), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, err
+	}
+
+	store := &Store{
+		path:      path,
+		users:     map[string]User{},
+		dummyHash: dummyHash,
+	}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return store, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	file := usersFile{}
+	err = yaml.Unmarshal(data, &file)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse users file %s: %w", path, err)
+	}
+
+	for _, user := range file.Users {
+		if !user.Role.IsValid() {
+			return nil, fmt.Errorf("user %q has an invalid role %q", user.Username, user.Role)
+		}
+		store.users[user.Username] = user
+	}
+
+	return store, nil
+}
+
+// Bootstrap creates the first admin user when the store is empty.
+// When no password is given a random password is generated and printed once to stderr, only its hash is persisted
+func (s *Store) Bootstrap(username, password string) error {
+
+	s.mu.RLock()
+	empty := len(s.users) == 0
+	s.mu.RUnlock()
+	if !empty {
+		if password != "" {
+			log.Warn("auth.password is ignored once users exist, remove it from the configuration file")
+		}
+		return nil
+	}
+
+	if username == "" {
+		username = defaultAdminUsername
+	}
+
+	generated := password == ""
+	if generated {
+		randomPassword, err := serverutil.GenerateRandomPassword(generatedPasswordLength)
+		if err != nil {
+			return err
+		}
+		password = randomPassword
+	}
+
+	// Configured passwords shorter than the minimum length were accepted by earlier versions, so they are
+	// still used to create the first admin user instead of failing the api startup
+	hash := hashPassword
+	if !generated && len(password) < minPasswordLength {
+		log.WithField("username", username).Warnf("auth.password is shorter than %d characters, change the password of the first admin user after the first login", minPasswordLength)
+		hash = hashBootstrapPassword
+	}
+
+	_, err := s.create(username, password, RoleAdmin, hash)
+	if err != nil {
+		return err
+	}
+
+	if generated {
+		// The password is kept out of the structured logs, which are usually shipped and retained
+		fmt.Fprintf(generatedPasswordOutput, "Generated password of the first admin user %q: %s\n", username, password)
+		log.WithField("username", username).Warn("created the first admin user with a generated password printed to stderr, change it after the first login")
+	} else {
+		log.WithField("username", username).Info("created the first admin user from the configured credentials, remove auth.password from the configuration file")
+	}
+	return nil
+}
+
+// Authenticate returns the user of the given credentials
+func (s *Store) Authenticate(username, password string) (User, error) {
+
+	s.mu.RLock()
+	user, found := s.users[username]
+	s.mu.RUnlock()
+
+	if !found {
+		_ = bcrypt.CompareHashAndPassword(s.dummyHash, []byte(password))
+		return User{}, ErrInvalidCredentials
+	}
+
+	if user.Source != "" {
+		_ = bcrypt.CompareHashAndPassword(s.dummyHash, []byte(password))
+		return User{}, ErrInvalidCredentials
+	}
+
+	err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password))
+	if err != nil {
+		return User{}, ErrInvalidCredentials
+	}
+	return user, nil
+}
+
+// Get returns the user of the given username
+func (s *Store) Get(username string) (User, error) {
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	user, found := s.users[username]
+	if !found {
+		return User{}, ErrUserNotFound
+	}
+	return user, nil
+}
+
+// List returns all the users sorted by their username
+func (s *Store) List() []User {
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	users := make([]User, 0, len(s.users))
+	for _, user := range s.users {
+		users = append(users, user)
+	}
+	sort.Slice(users, func(i, j int) bool {
+		return users[i].Username < users[j].Username
+	})
+	return users
+}
+
+// Create adds a new user with the given password and role
+func (s *Store) Create(username, password string, role Role) (User, error) {
+	return s.create(username, password, role, hashPassword)
+}
+
+// create adds a new user with the password hashed by the given hash function
+func (s *Store) create(username, password string, role Role, hash func(string) (string, error)) (User, error) {
+
+	username = strings.TrimSpace(username)
+	if username == "" || strings.ContainsAny(username, " \t\r\n") {
+		return User{}, &ValidationError{Message: "username is required and must not contain whitespaces"}
+	}
+	if !role.IsValid() {
+		return User{}, &ValidationError{Message: fmt.Sprintf("invalid role %q", role)}
+	}
+
+	passwordHash, err := hash(password)
+	if err != nil {
+		return User{}, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, found := s.users[username]; found {
+		return User{}, ErrUserExists
+	}
+
+	user := User{
+		Username:     username,
+		PasswordHash: passwordHash,
+		Role:         role,
+	}
+	s.users[username] = user
+
+	err = s.save()
+	if err != nil {
+		delete(s.users, username)
+		return User{}, err
+	}
+	return user, nil
+}
+
+// Update changes the password and/or the role of the given user, empty values are not changed
+func (s *Store) Update(username, password string, role Role) (User, error) {
+
+	if role != "" && !role.IsValid() {
+		return User{}, &ValidationError{Message: fmt.Sprintf("invalid role %q", role)}
+	}
+
+	passwordHash := ""
+	if password != "" {
+		hash, err := hashPassword(password)
+		if err != nil {
+			return User{}, err
+		}
+		passwordHash = hash
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	previous, found := s.users[username]
+	if !found {
+		return User{}, ErrUserNotFound
+	}
+
+	if passwordHash != "" && previous.Source != "" {
+		return User{}, &ValidationError{Message: fmt.Sprintf("the password of %s users is managed by the identity provider", previous.Source)}
+	}
+
+	user := previous
+	if passwordHash != "" {
+		user.PasswordHash = passwordHash
+	}
+	if role != "" {
+		if previous.Role == RoleAdmin && role != RoleAdmin && s.countAdmins() == 1 {
+			return User{}, ErrLastAdmin
+		}
+		user.Role = role
+	}
+	s.users[username] = user
+
+	err := s.save()
+	if err != nil {
+		s.users[username] = previous
+		return User{}, err
+	}
+	return user, nil
+}
+
+// SyncExternal creates or updates a user of an external identity provider with the role the provider granted.
+// The identity provider is the source of truth of its users roles, so the last admin check does not apply
+func (s *Store) SyncExternal(username string, role Role, source string) (User, error) {
+
+	if username == "" || strings.ContainsAny(username, " \t\r\n") {
+		return User{}, &ValidationError{Message: "username is required and must not contain whitespaces"}
+	}
+	if !role.IsValid() {
+		return User{}, &ValidationError{Message: fmt.Sprintf("invalid role %q", role)}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	previous, found := s.users[username]
+	if found && previous.Source != source {
+		return User{}, ErrUserExists
+	}
+
+	user := User{
+		Username: username,
+		Role:     role,
+		Source:   source,
+	}
+	if found && previous == user {
+		return user, nil
+	}
+	s.users[username] = user
+
+	err := s.save()
+	if err != nil {
+		if found {
+			s.users[username] = previous
+		} else {
+			delete(s.users, username)
+		}
+		return User{}, err
+	}
+	return user, nil
+}
+
+// Delete removes the given user
+func (s *Store) Delete(username string) error {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, found := s.users[username]
+	if !found {
+		return ErrUserNotFound
+	}
+	if user.Role == RoleAdmin && s.countAdmins() == 1 {
+		return ErrLastAdmin
+	}
+	delete(s.users, username)
+
+	err := s.save()
+	if err != nil {
+		s.users[username] = user
+		return err
+	}
+	return nil
+}
+
+// countAdmins returns the number of admin users, the caller must hold the lock
+func (s *Store) countAdmins() int {
+	admins := 0
+	for _, user := range s.users {
+		if user.Role == RoleAdmin {
+			admins++
+		}
+	}
+	return admins
+}
+
+// save writes the users to the users file, the caller must hold the lock.
+// The file is replaced atomically, and is readable only by its owner
+func (s *Store) save() error {
+
+	file := usersFile{Users: make([]User, 0, len(s.users))}
+	for _, user := range s.users {
+		file.Users = append(file.Users, user)
+	}
+	sort.Slice(file.Users, func(i, j int) bool {
+		return file.Users[i].Username < file.Users[j].Username
+	})
+
+	data, err := yaml.Marshal(&file)
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(filepath.Dir(s.path), 0700)
+	if err != nil {
+		return err
+	}
+
+	tmpFile, err := os.CreateTemp(filepath.Dir(s.path), ".users-*.yaml")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmpFile.Name())
+
+	_, err = tmpFile.Write(data)
+	if closeErr := tmpFile.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(tmpFile.Name(), s.path)
+}
+
+// hashPassword returns the bcrypt hash of the given password
+func hashPassword(password string) (string, error) {
+
+	if len(password) < minPasswordLength || len(password) > maxPasswordLength {
+		return "", &ValidationError{Message: fmt.Sprintf("password must be between %d and %d characters long", minPasswordLength, maxPasswordLength)}
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
+
+// hashBootstrapPassword returns the bcrypt hash of a configured bootstrap password, which may be shorter than the
+// minimum password length
+func hashBootstrapPassword(password string) (string, error) {
+
+	if len(password) == 0 || len(password) > maxPasswordLength {
+		return "", &ValidationError{Message: fmt.Sprintf("password must be between 1 and %d characters long", maxPasswordLength)}
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
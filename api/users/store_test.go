@@ -0,0 +1,330 @@
+package users_test
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"finala/api/users"
+
+	log "github.com/sirupsen/logrus"
+)
+
+func newStore(t *testing.T) (*users.Store, string) {
+	path := filepath.Join(t.TempDir(), "users.yaml")
+	store, err := users.NewStore(path)
+	if err != nil {
+		t.Fatalf("unexpected store error: %v", err)
+	}
+	return store, path
+}
+
+func TestBootstrap(t *testing.T) {
+
+	t.Run("configured credentials", func(t *testing.T) {
+		store, path := newStore(t)
+		err := store.Bootstrap("root", "root-password")
+		if err != nil {
+			t.Fatalf("unexpected bootstrap error: %v", err)
+		}
+
+		user, err := store.Authenticate("root", "root-password")
+		if err != nil {
+			t.Fatalf("unexpected authenticate error: %v", err)
+		}
+		if user.Role != users.RoleAdmin {
+			t.Fatalf("unexpected bootstrap user role, got %s expected %s", user.Role, users.RoleAdmin)
+		}
+
+		// Only the password hash is written to the users file
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if strings.Contains(string(data), "root-password") || !strings.Contains(string(data), "password_hash: $2") {
+			t.Fatalf("unexpected users file content, got %s", string(data))
+		}
+
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if info.Mode().Perm() != 0600 {
+			t.Fatalf("unexpected users file permissions, got %s expected %s", info.Mode().Perm(), os.FileMode(0600))
+		}
+	})
+
+	t.Run("generated password", func(t *testing.T) {
+		store, _ := newStore(t)
+
+		var logs bytes.Buffer
+		log.SetOutput(&logs)
+		defer log.SetOutput(os.Stderr)
+
+		err := store.Bootstrap("", "")
+		if err != nil {
+			t.Fatalf("unexpected bootstrap error: %v", err)
+		}
+
+		// The generated password is printed outside of the structured logs
+		if strings.Contains(logs.String(), "password=") {
+			t.Fatalf("unexpected generated password in the logs, got %s", logs.String())
+		}
+
+		user, err := store.Get("admin")
+		if err != nil || user.Role != users.RoleAdmin {
+			t.Fatalf("unexpected bootstrap user, got %+v, %v", user, err)
+		}
+	})
+
+	t.Run("existing users", func(t *testing.T) {
+		store, path := newStore(t)
+		if _, err := store.Create("alice", "alice-password", users.RoleAdmin); err != nil {
+			t.Fatal(err)
+		}
+
+		reloadedStore, err := users.NewStore(path)
+		if err != nil {
+			t.Fatalf("unexpected store error: %v", err)
+		}
+		err = reloadedStore.Bootstrap("admin", "admin-password")
+		if err != nil {
+			t.Fatalf("unexpected bootstrap error: %v", err)
+		}
+
+		if len(reloadedStore.List()) != 1 {
+			t.Fatalf("unexpected users count, got %d expected %d", len(reloadedStore.List()), 1)
+		}
+		if _, err := reloadedStore.Authenticate("alice", "alice-password"); err != nil {
+			t.Fatalf("unexpected authenticate error after reload: %v", err)
+		}
+	})
+
+	t.Run("short configured password", func(t *testing.T) {
+		store, _ := newStore(t)
+
+		// Short configured passwords of earlier versions still create the first admin user, with a warning
+		err := store.Bootstrap("admin", "test")
+		if err != nil {
+			t.Fatalf("unexpected bootstrap error: %v", err)
+		}
+		if _, err := store.Authenticate("admin", "test"); err != nil {
+			t.Fatalf("unexpected authenticate error: %v", err)
+		}
+
+		// Only the bootstrap accepts short passwords
+		_, err = store.Create("alice", "short", users.RoleViewer)
+		var validationErr *users.ValidationError
+		if !errors.As(err, &validationErr) {
+			t.Fatalf("unexpected create error, got %v expected validation error", err)
+		}
+	})
+}
+
+func TestNewStoreInvalidFile(t *testing.T) {
+
+	path := filepath.Join(t.TempDir(), "users.yaml")
+	err := os.WriteFile(path, []byte("users:\n  - username: alice\n    role: owner\n"), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = users.NewStore(path)
+	if err == nil {
+		t.Fatalf("unexpected store error, got nil expected invalid role error")
+	}
+}
+
+func TestAuthenticate(t *testing.T) {
+
+	store, _ := newStore(t)
+	if _, err := store.Create("alice", "alice-password", users.RoleAnalyst); err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		name        string
+		username    string
+		password    string
+		expectedErr error
+	}{
+		{"valid credentials", "alice", "alice-password", nil},
+		{"wrong password", "alice", "wrong-password", users.ErrInvalidCredentials},
+		{"unknown user", "bob", "alice-password", users.ErrInvalidCredentials},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			user, err := store.Authenticate(test.username, test.password)
+			if !errors.Is(err, test.expectedErr) {
+				t.Fatalf("unexpected authenticate error, got %v expected %v", err, test.expectedErr)
+			}
+			if test.expectedErr == nil && user.Role != users.RoleAnalyst {
+				t.Fatalf("unexpected user role, got %s expected %s", user.Role, users.RoleAnalyst)
+			}
+		})
+	}
+}
+
+func TestCreate(t *testing.T) {
+
+	store, _ := newStore(t)
+	if _, err := store.Create("alice", "alice-password", users.RoleViewer); err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		name               string
+		username           string
+		password           string
+		role               users.Role
+		expectedErr        error
+		expectedValidation bool
+	}{
+		{"valid user", "bob", "bob-password", users.RoleAnalyst, nil, false},
+		{"existing user", "alice", "alice-password", users.RoleViewer, users.ErrUserExists, false},
+		{"empty username", " ", "bob-password", users.RoleViewer, nil, true},
+		{"username with whitespaces", "bob smith", "bob-password", users.RoleViewer, nil, true},
+		{"short password", "carol", "short", users.RoleViewer, nil, true},
+		{"long password", "carol", strings.Repeat("a", 73), users.RoleViewer, nil, true},
+		{"invalid role", "carol", "carol-password", users.Role("owner"), nil, true},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := store.Create(test.username, test.password, test.role)
+
+			var validationErr *users.ValidationError
+			if test.expectedValidation {
+				if !errors.As(err, &validationErr) {
+					t.Fatalf("unexpected create error, got %v expected validation error", err)
+				}
+				return
+			}
+			if !errors.Is(err, test.expectedErr) {
+				t.Fatalf("unexpected create error, got %v expected %v", err, test.expectedErr)
+			}
+		})
+	}
+
+	usersList := store.List()
+	if len(usersList) != 2 || usersList[0].Username != "alice" || usersList[1].Username != "bob" {
+		t.Fatalf("unexpected users list, got %+v", usersList)
+	}
+}
+
+func TestUpdate(t *testing.T) {
+
+	store, path := newStore(t)
+	if _, err := store.Create("admin", "admin-password", users.RoleAdmin); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Create("alice", "alice-password", users.RoleViewer); err != nil {
+		t.Fatal(err)
+	}
+
+	user, err := store.Update("alice", "new-alice-password", users.RoleAnalyst)
+	if err != nil {
+		t.Fatalf("unexpected update error: %v", err)
+	}
+	if user.Role != users.RoleAnalyst {
+		t.Fatalf("unexpected updated role, got %s expected %s", user.Role, users.RoleAnalyst)
+	}
+
+	// Changes are persisted to the users file
+	reloadedStore, err := users.NewStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := reloadedStore.Authenticate("alice", "new-alice-password"); err != nil {
+		t.Fatalf("unexpected authenticate error with the updated password: %v", err)
+	}
+
+	// Empty values are not changed
+	user, err = store.Update("alice", "", "")
+	if err != nil || user.Role != users.RoleAnalyst {
+		t.Fatalf("unexpected update without changes, got %+v, %v", user, err)
+	}
+
+	if _, err := store.Update("bob", "bob-password", users.RoleViewer); !errors.Is(err, users.ErrUserNotFound) {
+		t.Fatalf("unexpected update error, got %v expected %v", err, users.ErrUserNotFound)
+	}
+
+	if _, err := store.Update("admin", "", users.RoleViewer); !errors.Is(err, users.ErrLastAdmin) {
+		t.Fatalf("unexpected demote error, got %v expected %v", err, users.ErrLastAdmin)
+	}
+}
+
+func TestDelete(t *testing.T) {
+
+	store, _ := newStore(t)
+	if _, err := store.Create("admin", "admin-password", users.RoleAdmin); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Create("alice", "alice-password", users.RoleViewer); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := store.Delete("alice"); err != nil {
+		t.Fatalf("unexpected delete error: %v", err)
+	}
+	if _, err := store.Get("alice"); !errors.Is(err, users.ErrUserNotFound) {
+		t.Fatalf("unexpected get error, got %v expected %v", err, users.ErrUserNotFound)
+	}
+
+	if err := store.Delete("alice"); !errors.Is(err, users.ErrUserNotFound) {
+		t.Fatalf("unexpected delete error, got %v expected %v", err, users.ErrUserNotFound)
+	}
+
+	if err := store.Delete("admin"); !errors.Is(err, users.ErrLastAdmin) {
+		t.Fatalf("unexpected delete error, got %v expected %v", err, users.ErrLastAdmin)
+	}
+}
+
+func TestSyncExternal(t *testing.T) {
+
+	store, path := newStore(t)
+	if _, err := store.Create("alice", "alice-password", users.RoleAdmin); err != nil {
+		t.Fatal(err)
+	}
+
+	user, err := store.SyncExternal("bob@example.com", users.RoleAdmin, users.SourceOIDC)
+	if err != nil {
+		t.Fatalf("unexpected sync error: %v", err)
+	}
+	if user.Role != users.RoleAdmin || user.Source != users.SourceOIDC || user.PasswordHash != "" {
+		t.Fatalf("unexpected synced user, got %+v", user)
+	}
+
+	// The identity provider role replaces the previous role
+	if _, err := store.SyncExternal("bob@example.com", users.RoleViewer, users.SourceOIDC); err != nil {
+		t.Fatalf("unexpected sync error: %v", err)
+	}
+	reloaded, err := users.NewStore(path)
+	if err != nil {
+		t.Fatalf("unexpected store error: %v", err)
+	}
+	if user, _ := reloaded.Get("bob@example.com"); user.Role != users.RoleViewer || user.Source != users.SourceOIDC {
+		t.Fatalf("unexpected reloaded user, got %+v", user)
+	}
+
+	// External users have no password
+	if _, err := store.Authenticate("bob@example.com", ""); !errors.Is(err, users.ErrInvalidCredentials) {
+		t.Fatalf("unexpected authenticate error, got %v expected %v", err, users.ErrInvalidCredentials)
+	}
+	var validationErr *users.ValidationError
+	if _, err := store.Update("bob@example.com", "bob-password", ""); !errors.As(err, &validationErr) {
+		t.Fatalf("unexpected update error, got %v expected validation error", err)
+	}
+
+	// Local users are not taken over by the identity provider
+	if _, err := store.SyncExternal("alice", users.RoleAdmin, users.SourceOIDC); !errors.Is(err, users.ErrUserExists) {
+		t.Fatalf("unexpected sync error, got %v expected %v", err, users.ErrUserExists)
+	}
+	if _, err := store.SyncExternal("bob@example.com", "root", users.SourceOIDC); !errors.As(err, &validationErr) {
+		t.Fatalf("unexpected sync error, got %v expected validation error", err)
+	}
+}
@@ -1,20 +1,30 @@
 package cmd
 
 import (
+	"context"
 	"finala/api"
+	"finala/api/auth"
+	"finala/api/collectors"
 	apiconfig "finala/api/config"
 	"finala/api/storage/meilisearch"
+	"finala/api/users"
 	"finala/serverutil"
 	"finala/visibility"
 	"os"
 	"os/signal"
 
-	appcfg "finala/config"
-
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 )
 
+const (
+	// defaultUsersFile is the api users file path when no path is configured
+	defaultUsersFile = "/var/lib/finala/users.yaml"
+
+	// defaultCollectorKeysFile is the collector keys file path when no path is configured
+	defaultCollectorKeysFile = "/var/lib/finala/collector_keys.yaml"
+)
+
 var (
 	// port of the api
 	port int
@@ -26,9 +36,6 @@ var apiServer = &cobra.Command{
 	Short: "Launch RESTful API",
 	Long:  ``,
 	Run: func(cmd *cobra.Command, args []string) {
-		// Load authentication credentials first
-		appcfg.LoadCredentials("/etc/finala/config.yaml")
-
 		// Loading configuration file
 		configStruct, err := apiconfig.LoadAPI(cfgFile)
 		if err != nil {
@@ -44,7 +51,51 @@ var apiServer = &cobra.Command{
 			os.Exit(1)
 		}
 
-		apiManager := api.NewServer(port, storage, versionManager)
+		jwtManager, err := auth.NewJWTManager(configStruct.JWT)
+		if err != nil {
+			log.WithError(err).Error("could not initialize jwt authentication")
+			os.Exit(1)
+		}
+
+		usersFile := configStruct.Auth.UsersFile
+		if usersFile == "" {
+			usersFile = defaultUsersFile
+		}
+		userStore, err := users.NewStore(usersFile)
+		if err != nil {
+			log.WithError(err).Error("could not load the api users")
+			os.Exit(1)
+		}
+
+		collectorKeysFile := configStruct.Auth.CollectorKeysFile
+		if collectorKeysFile == "" {
+			collectorKeysFile = defaultCollectorKeysFile
+		}
+		keyStore, err := collectors.NewKeyStore(collectorKeysFile)
+		if err != nil {
+			log.WithError(err).Error("could not load the collector keys")
+			os.Exit(1)
+		}
+
+		var oidcProvider *auth.OIDCProvider
+		if configStruct.OIDC.IssuerURL != "" {
+			oidcProvider, err = auth.NewOIDCProvider(context.Background(), configStruct.OIDC)
+			if err != nil {
+				log.WithError(err).Error("could not initialize single sign-on")
+				os.Exit(1)
+			}
+		}
+
+		// The users sign in only through the identity provider, so there is no first admin to create
+		if oidcProvider == nil || !oidcProvider.PasswordLoginDisabled() {
+			err = userStore.Bootstrap(configStruct.Auth.Username, configStruct.Auth.Password)
+			if err != nil {
+				log.WithError(err).Error("could not create the first admin user")
+				os.Exit(1)
+			}
+		}
+
+		apiManager := api.NewServer(port, storage, versionManager, jwtManager, userStore, keyStore, oidcProvider)
 
 		apiStopper := serverutil.RunAll(apiManager).StopFunc
 
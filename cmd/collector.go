@@ -2,11 +2,16 @@ package cmd
 
 import (
 	"context"
+	"errors"
 	"finala/collector"
 	"finala/collector/aws"
 	"finala/collector/config"
+	"finala/events"
+	"finala/interpolation"
 	"finala/request"
+	"finala/version"
 	"finala/visibility"
+	"net/http"
 	"os"
 	"sync"
 
@@ -38,28 +43,70 @@ var collectorCMD = &cobra.Command{
 			log.Error("Providers not found")
 		}
 
+		if configStruct.APIServer.APIKey == "" {
+			log.Warn("api_server.api_key is not configured, the api rejects collector events without a collector key")
+		}
+
 		// Create HTTP client request
 		req := request.NewHTTPClient()
 
 		// Init collector manager
-		collectorManager := collector.NewCollectorManager(ctx, &wg, req, configStruct.APIServer.BulkInterval, configStruct.Name, configStruct.APIServer.Addr)
+		collectorManager := collector.NewCollectorManager(ctx, &wg, req, configStruct.APIServer.BulkInterval, configStruct.Name, configStruct.APIServer.Addr, configStruct.APIServer.APIKey)
 
 		// Starting collect data
 		awsProvider := configStruct.Providers["aws"]
 
+		// Register the execution, the api accepts the events of executions which were not registered by older collectors
+		err = collectorManager.StartExecution(executionStart(configStruct, awsProvider))
+		var httpErr *request.HttpError
+		if errors.As(err, &httpErr) && (httpErr.StatusCode == http.StatusUnauthorized || httpErr.StatusCode == http.StatusForbidden) {
+			log.WithError(err).Error("the api rejected the collector key, check api_server.api_key is a key issued to the collector name")
+			os.Exit(1)
+		}
+		if err != nil {
+			log.WithError(err).Warn("could not register the collector execution")
+		}
+
 		// init metric manager
 		metricManager := collector.NewMetricManager(awsProvider)
 
-		awsManager := aws.NewAnalyzeManager(collectorManager, metricManager, awsProvider.Accounts)
+		awsManager := aws.NewAnalyzeManager(collectorManager, metricManager, awsProvider.Accounts, awsProvider.TagsCompliance, awsProvider.Inventory)
 
 		awsManager.All()
 
 		log.Info("Collector Done. Starting graceful shutdown")
 		cancelFn()
 		wg.Wait()
+
+		if collectorManager.Unauthorized() {
+			log.Error("the api rejected the collector key, the collector events were dropped")
+			os.Exit(1)
+		}
+
+		err = collectorManager.FinishExecution()
+		if err != nil {
+			log.WithError(err).Warn("could not finish the collector execution")
+		}
 	},
 }
 
+// executionStart returns the execution metadata of the given configuration
+func executionStart(configStruct config.CollectorConfig, awsProvider config.ProviderConfig) events.ExecutionStart {
+	accounts := []string{}
+	regions := []string{}
+	for _, account := range awsProvider.Accounts {
+		accounts = append(accounts, account.Name)
+		regions = append(regions, account.Regions...)
+	}
+
+	return events.ExecutionStart{
+		Accounts:         accounts,
+		Regions:          interpolation.UniqueStr(regions),
+		ConfigHash:       configStruct.Hash(),
+		CollectorVersion: version.GetFormattedVersion(),
+	}
+}
+
 // init will add aws command
 func init() {
 	rootCmd.AddCommand(collectorCMD)
@@ -51,6 +51,14 @@ var notifierCMD = &cobra.Command{
 		request := request.NewHTTPClient()
 		dataFetcherManager := notifiers.NewDataFetcherManager(request, *notifierLog, notifierConfig.APIServerAddr)
 
+		if notifierConfig.APIAuth.Username != "" {
+			err = dataFetcherManager.Login(notifierConfig.APIAuth.Username, notifierConfig.APIAuth.Password)
+			if err != nil {
+				notifierLog.WithError(err).Error("could not login to Finala api")
+				os.Exit(1)
+			}
+		}
+
 		notifierLog.Info("The command has started it's work")
 		// bring all data and executionID
 		notifierLog.Debug("Going to get the latest execution from Finala API")
@@ -81,13 +89,27 @@ var notifierCMD = &cobra.Command{
 					}).WithError(err).Error("could get execution summary from Finala api")
 				}
 
+				latestExecutionResourcesData := map[string][]notifiersCommon.NotifierResource{}
+				for _, executionData := range latestExecutionSummaryData {
+					if executionData.TotalSpent == 0 || executionData.TotalSpent <= notificationGroupSettings.MinimumCostToPresent {
+						continue
+					}
+					resources, err := dataFetcherManager.GetExecutionResources(latestExecutionID, executionData.ResourceName, notificationGroupSettings.Tags)
+					if err != nil {
+						notifierLog.WithField("resource", executionData.ResourceName).WithError(err).Error("could get execution resources from Finala api")
+						continue
+					}
+					latestExecutionResourcesData[executionData.ResourceName] = resources
+				}
+
 				notifier.Send(notifiersCommon.NotifierReport{
-					GroupName:            groupName,
-					NotifyByTag:          notificationGroupSettings,
-					ExecutionID:          latestExecutionID,
-					UIAddr:               notifierConfig.UIAddr,
-					ExecutionSummaryData: latestExecutionSummaryData,
-					Log:                  *notifierLog,
+					GroupName:              groupName,
+					NotifyByTag:            notificationGroupSettings,
+					ExecutionID:            latestExecutionID,
+					UIAddr:                 notifierConfig.UIAddr,
+					ExecutionSummaryData:   latestExecutionSummaryData,
+					ExecutionResourcesData: latestExecutionResourcesData,
+					Log:                    *notifierLog,
 				})
 			}
 		}
@@ -82,6 +82,20 @@ func (cw *CloudwatchManager) GetMetric(metricInput *awsCloudwatch.GetMetricStati
 
 }
 
+// GetDatapoints returns the datapoints of the given metric statistic. Unlike GetMetric, it lets the caller tell a
+// metric without datapoints from a metric whose value is zero
+func (cw *CloudwatchManager) GetDatapoints(metricInput *awsCloudwatch.GetMetricStatisticsInput, metric config.MetricDataConfiguration) ([]*awsCloudwatch.Datapoint, error) {
+
+	metricInput.MetricName = awsClient.String(metric.Name)
+	metricInput.Statistics = []*string{&metric.Statistic}
+	metricData, err := cw.client.GetMetricStatistics(metricInput)
+	if err != nil {
+		return nil, err
+	}
+
+	return metricData.Datapoints, nil
+}
+
 // SumDatapoint return datapoint sum
 func (cw *CloudwatchManager) SumDatapoint(statisticOutput *awsCloudwatch.GetMetricStatisticsOutput) float64 {
 
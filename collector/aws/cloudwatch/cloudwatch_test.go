@@ -171,3 +171,43 @@ func TestDatapointMath(t *testing.T) {
 	})
 
 }
+
+func TestGetDatapoints(t *testing.T) {
+
+	cloudWatchMetrics := map[string]cloudwatch.GetMetricStatisticsOutput{
+		"a": {
+			Datapoints: []*cloudwatch.Datapoint{
+				{Maximum: testutils.Float64Pointer(0)},
+			},
+		},
+		"b": {
+			Datapoints: []*cloudwatch.Datapoint{},
+		},
+	}
+	cloutwatchManager := awsTestutils.NewMockCloudwatch(&cloudWatchMetrics)
+
+	testCases := []struct {
+		name               string
+		expectedDatapoints int
+		expectedErr        bool
+	}{
+		{"a", 1, false},
+		{"b", 0, false},
+		{"c", 0, true},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			datapoints, err := cloutwatchManager.GetDatapoints(&cloudwatch.GetMetricStatisticsInput{}, config.MetricDataConfiguration{
+				Name:      test.name,
+				Statistic: "Maximum",
+			})
+			if (err != nil) != test.expectedErr {
+				t.Fatalf("unexpected get datapoints error, got %v expected error %t", err, test.expectedErr)
+			}
+			if len(datapoints) != test.expectedDatapoints {
+				t.Fatalf("unexpected datapoints count, got %d expected %d", len(datapoints), test.expectedDatapoints)
+			}
+		})
+	}
+}
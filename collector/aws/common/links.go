@@ -0,0 +1,50 @@
+package common
+
+import (
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws/endpoints"
+)
+
+// consoleHosts defines the aws console host of each partition
+var consoleHosts = map[string]string{
+	endpoints.AwsPartitionID:      "console.aws.amazon.com",
+	endpoints.AwsCnPartitionID:    "console.amazonaws.cn",
+	endpoints.AwsUsGovPartitionID: "console.amazonaws-us-gov.com",
+}
+
+// GetPartition returns the aws partition of the given region, the default partition is returned for unknown regions
+func GetPartition(region string) string {
+
+	partition, found := endpoints.PartitionForRegion(endpoints.DefaultPartitions(), region)
+	if !found {
+		return endpoints.AwsPartitionID
+	}
+	return partition.ID()
+}
+
+// GetARN returns the arn of a regional resource
+func GetARN(region, service, accountID, resource string) string {
+	return fmt.Sprintf("arn:%s:%s:%s:%s:%s", GetPartition(region), service, region, accountID, resource)
+}
+
+// GetGlobalARN returns the arn of a global resource in the partition of the given region.
+// Resources without an account, like s3 buckets, are built with an empty account id
+func GetGlobalARN(region, service, accountID, resource string) string {
+	return fmt.Sprintf("arn:%s:%s::%s:%s", GetPartition(region), service, accountID, resource)
+}
+
+// GetConsoleURL returns the aws console deep link of the given console path and fragment, in the partition and the region of the resource
+func GetConsoleURL(region, path, fragment string) string {
+
+	host, found := consoleHosts[GetPartition(region)]
+	if !found {
+		host = consoleHosts[endpoints.AwsPartitionID]
+	}
+
+	consoleURL := fmt.Sprintf("https://%s/%s?region=%s", host, path, region)
+	if fragment != "" {
+		consoleURL = fmt.Sprintf("%s#%s", consoleURL, fragment)
+	}
+	return consoleURL
+}
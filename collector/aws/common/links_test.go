@@ -0,0 +1,63 @@
+package common
+
+import (
+	"testing"
+)
+
+func TestGetPartition(t *testing.T) {
+
+	testCases := []struct {
+		region            string
+		expectedPartition string
+	}{
+		{"us-east-1", "aws"},
+		{"cn-north-1", "aws-cn"},
+		{"us-gov-west-1", "aws-us-gov"},
+		{"unknown", "aws"},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.region, func(t *testing.T) {
+			partition := GetPartition(test.region)
+			if partition != test.expectedPartition {
+				t.Fatalf("unexpected partition, got %s expected %s", partition, test.expectedPartition)
+			}
+		})
+	}
+}
+
+func TestGetARN(t *testing.T) {
+
+	arn := GetARN("cn-north-1", "ec2", "1234", "instance/i-1")
+	if arn != "arn:aws-cn:ec2:cn-north-1:1234:instance/i-1" {
+		t.Fatalf("unexpected arn, got %s expected %s", arn, "arn:aws-cn:ec2:cn-north-1:1234:instance/i-1")
+	}
+
+	globalARN := GetGlobalARN("us-gov-west-1", "s3", "", "bucket")
+	if globalARN != "arn:aws-us-gov:s3:::bucket" {
+		t.Fatalf("unexpected global arn, got %s expected %s", globalARN, "arn:aws-us-gov:s3:::bucket")
+	}
+}
+
+func TestGetConsoleURL(t *testing.T) {
+
+	testCases := []struct {
+		region      string
+		path        string
+		fragment    string
+		expectedURL string
+	}{
+		{"us-east-1", "ec2/home", "InstanceDetails:instanceId=i-1", "https://console.aws.amazon.com/ec2/home?region=us-east-1#InstanceDetails:instanceId=i-1"},
+		{"cn-north-1", "ec2/home", "InstanceDetails:instanceId=i-1", "https://console.amazonaws.cn/ec2/home?region=cn-north-1#InstanceDetails:instanceId=i-1"},
+		{"us-gov-west-1", "s3/buckets/bucket", "", "https://console.amazonaws-us-gov.com/s3/buckets/bucket?region=us-gov-west-1"},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.region, func(t *testing.T) {
+			consoleURL := GetConsoleURL(test.region, test.path, test.fragment)
+			if consoleURL != test.expectedURL {
+				t.Fatalf("unexpected console url, got %s expected %s", consoleURL, test.expectedURL)
+			}
+		})
+	}
+}
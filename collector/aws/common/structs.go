@@ -28,6 +28,10 @@ type AWSManager interface {
 	GetRegion() string
 	GetSession() (*session.Session, *aws.Config)
 	GetAccountIdentity() *sts.GetCallerIdentityOutput
+	GetAccountDimensions() collector.AccountDimensions
+	GetARN(service, resource string) string
+	GetConsoleURL(path, fragment string) string
 	SetGlobal(resourceName collector.ResourceIdentifier)
 	IsGlobalSet(resourceName collector.ResourceIdentifier) bool
+	ExamineResource(resourceName collector.ResourceIdentifier, getResource func() collector.PriceDetectedFields)
 }
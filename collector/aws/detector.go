@@ -3,6 +3,7 @@ package aws
 import (
 	"finala/collector"
 	"finala/collector/aws/cloudwatch"
+	"finala/collector/aws/common"
 	"finala/collector/aws/pricing"
 	"finala/collector/config"
 	"fmt"
@@ -23,6 +24,10 @@ type DetectorDescriptor interface {
 	GetRegion() string
 	GetSession() (*session.Session, *awsClient.Config)
 	GetAccountIdentity() *sts.GetCallerIdentityOutput
+	GetAccountDimensions() collector.AccountDimensions
+	GetARN(service, resource string) string
+	GetConsoleURL(path, fragment string) string
+	ExamineResource(resourceName collector.ResourceIdentifier, getResource func() collector.PriceDetectedFields)
 }
 
 const (
@@ -38,12 +43,15 @@ type DetectorManager struct {
 	session          *session.Session
 	awsConfig        *awsClient.Config
 	accountIdentity  *sts.GetCallerIdentityOutput
+	accountName      string
 	region           string
 	global           map[string]struct{}
+	tagsCompliance   config.TagsComplianceConfig
+	inventory        config.InventoryConfig
 }
 
 // NewDetectorManager create new instance of detector manager
-func NewDetectorManager(awsAuth AuthDescriptor, collector collector.CollectorDescriber, account config.AWSAccount, stsManager *STSManager, global map[string]struct{}, region string) *DetectorManager {
+func NewDetectorManager(awsAuth AuthDescriptor, collector collector.CollectorDescriber, account config.AWSAccount, stsManager *STSManager, global map[string]struct{}, tagsCompliance config.TagsComplianceConfig, inventory config.InventoryConfig, region string) *DetectorManager {
 
 	priceSession, _ := awsAuth.Login(defaultRegionPrice)
 	pricingManager := pricing.NewPricingManager(awsPricing.New(priceSession), defaultRegionPrice)
@@ -60,7 +68,10 @@ func NewDetectorManager(awsAuth AuthDescriptor, collector collector.CollectorDes
 		session:          regionSession,
 		awsConfig:        regionConfig,
 		accountIdentity:  callerIdentityOutput,
+		accountName:      account.Name,
 		global:           global,
+		tagsCompliance:   tagsCompliance,
+		inventory:        inventory,
 	}
 }
 
@@ -99,6 +110,31 @@ func (dm *DetectorManager) GetAccountIdentity() *sts.GetCallerIdentityOutput {
 	return dm.accountIdentity
 }
 
+// GetAccountDimensions returns the account id, the configured account name and the region of the detected resources
+func (dm *DetectorManager) GetAccountDimensions() collector.AccountDimensions {
+
+	var accountID string
+	if dm.accountIdentity != nil {
+		accountID = awsClient.StringValue(dm.accountIdentity.Account)
+	}
+
+	return collector.AccountDimensions{
+		AccountID:   accountID,
+		AccountName: dm.accountName,
+		Region:      dm.region,
+	}
+}
+
+// GetARN returns the arn of a resource in the current account and region
+func (dm *DetectorManager) GetARN(service, resource string) string {
+	return common.GetARN(dm.region, service, dm.GetAccountDimensions().AccountID, resource)
+}
+
+// GetConsoleURL returns the aws console deep link of a resource in the current region
+func (dm *DetectorManager) GetConsoleURL(path, fragment string) string {
+	return common.GetConsoleURL(dm.region, path, fragment)
+}
+
 // SetGlobal marked resource as global
 func (dm *DetectorManager) SetGlobal(resourceName collector.ResourceIdentifier) {
 	dm.global[string(resourceName)] = struct{}{}
@@ -109,3 +145,50 @@ func (dm *DetectorManager) IsGlobalSet(resourceName collector.ResourceIdentifier
 	_, isExists := dm.global[string(resourceName)]
 	return isExists
 }
+
+// ExamineResource adds to the inventory and checks the required tags of a resource the detector described, regardless of its detection.
+// The resource is fetched only when the inventory or the tags compliance check is enabled, since it may require additional API calls
+func (dm *DetectorManager) ExamineResource(resourceName collector.ResourceIdentifier, getResource func() collector.PriceDetectedFields) {
+
+	if !dm.inventory.Enable && !dm.tagsCompliance.Enable {
+		return
+	}
+
+	resource := getResource()
+	resource.AccountDimensions = dm.GetAccountDimensions()
+
+	if dm.inventory.Enable {
+		dm.collector.AddInventory(collector.EventCollector{
+			ResourceName: resourceName,
+			Data: collector.ResourceInventory{
+				ResourceType:        resourceName,
+				PriceDetectedFields: resource,
+			},
+		})
+	}
+
+	if dm.tagsCompliance.Enable {
+		dm.checkRequiredTags(resourceName, resource)
+	}
+}
+
+// checkRequiredTags adds a tags compliance finding when the resource is missing required tags, or with tag values outside the allowed values
+func (dm *DetectorManager) checkRequiredTags(resourceName collector.ResourceIdentifier, resource collector.PriceDetectedFields) {
+
+	missingTags, invalidTags := collector.CheckRequiredTags(dm.tagsCompliance.RequiredTags, resource.Tag)
+	if len(missingTags) == 0 && len(invalidTags) == 0 {
+		return
+	}
+
+	dm.collector.AddResource(collector.EventCollector{
+		ResourceName: dm.GetResourceIdentifier(collector.TagsComplianceName),
+		Data: collector.DetectedTagsCompliance{
+			Metric:              collector.TagsComplianceMetric,
+			Category:            collector.TagsComplianceCategory,
+			ResourceType:        resourceName,
+			MissingTags:         missingTags,
+			InvalidTags:         invalidTags,
+			PriceDetectedFields: resource,
+		},
+	})
+}
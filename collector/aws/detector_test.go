@@ -1,6 +1,7 @@
 package aws
 
 import (
+	"finala/collector"
 	"finala/collector/config"
 	collectorTestutils "finala/collector/testutils"
 	"testing"
@@ -55,7 +56,7 @@ func TestDetector(t *testing.T) {
 	mockSTS := NewMockSTS()
 	collector := collectorTestutils.NewMockCollector()
 	global := make(map[string]struct{})
-	detector := NewDetectorManager(mockAuth, collector, account, mockSTS, global, region)
+	detector := NewDetectorManager(mockAuth, collector, account, mockSTS, global, config.TagsComplianceConfig{}, config.InventoryConfig{}, region)
 
 	if detector.GetRegion() != region {
 		t.Fatalf("unexpected collector region, got %s expected %s", detector.GetRegion(), region)
@@ -70,4 +71,122 @@ func TestDetector(t *testing.T) {
 		t.Fatalf("unexpected account identifier, got %s expected %s", *accountIdentity.Account, "foo")
 	}
 
+	accountDimensions := detector.GetAccountDimensions()
+	if accountDimensions.AccountID != "foo" || accountDimensions.AccountName != account.Name || accountDimensions.Region != region {
+		t.Fatalf("unexpected account dimensions, got %+v", accountDimensions)
+	}
+
+}
+
+func TestExamineResource(t *testing.T) {
+
+	account := config.AWSAccount{
+		Name:    "foo",
+		Regions: []string{"bar"},
+	}
+	tagsCompliance := config.TagsComplianceConfig{
+		Enable: true,
+		RequiredTags: []config.RequiredTagConfig{
+			{Key: "team"},
+			{Key: "environment", AllowedValues: []string{"production"}},
+		},
+	}
+
+	testCases := []struct {
+		name           string
+		tagsCompliance config.TagsComplianceConfig
+		tags           map[string]string
+		expectedEvents int
+	}{
+		{"disabled", config.TagsComplianceConfig{}, map[string]string{}, 0},
+		{"compliant", tagsCompliance, map[string]string{"team": "data", "environment": "production"}, 0},
+		{"missing tags", tagsCompliance, map[string]string{"environment": "production"}, 1},
+		{"invalid tags", tagsCompliance, map[string]string{"team": "data", "environment": "dev"}, 1},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+
+			collectorManager := collectorTestutils.NewMockCollector()
+			detector := NewDetectorManager(&mockAuth{}, collectorManager, account, NewMockSTS(), make(map[string]struct{}), test.tagsCompliance, config.InventoryConfig{}, "bar")
+
+			detector.ExamineResource(detector.GetResourceIdentifier("foo"), func() collector.PriceDetectedFields {
+				return collector.PriceDetectedFields{
+					ResourceID:    "i-1",
+					PricePerMonth: 73,
+					Tag:           test.tags,
+				}
+			})
+
+			if len(collectorManager.Events) != test.expectedEvents {
+				t.Fatalf("unexpected collector tags compliance events, got %d expected %d", len(collectorManager.Events), test.expectedEvents)
+			}
+
+			if test.expectedEvents == 0 {
+				return
+			}
+
+			event := collectorManager.Events[0]
+			if event.ResourceName != "aws_tags_compliance" {
+				t.Fatalf("unexpected tags compliance resource name, got %s expected %s", event.ResourceName, "aws_tags_compliance")
+			}
+
+			data, ok := event.Data.(collector.DetectedTagsCompliance)
+			if !ok {
+				t.Fatalf("unexpected tags compliance event data, got %T expected %s", event.Data, "collector.DetectedTagsCompliance")
+			}
+			if data.ResourceType != "aws_foo" || data.Category != collector.TagsComplianceCategory || data.PricePerMonth != 73 {
+				t.Fatalf("unexpected tags compliance event data, got %+v", data)
+			}
+		})
+	}
+}
+
+func TestExamineResourceInventory(t *testing.T) {
+
+	account := config.AWSAccount{
+		Name:    "production",
+		Regions: []string{"bar"},
+	}
+
+	testCases := []struct {
+		name              string
+		inventory         config.InventoryConfig
+		expectedInventory int
+	}{
+		{"disabled", config.InventoryConfig{}, 0},
+		{"enabled", config.InventoryConfig{Enable: true}, 1},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+
+			collectorManager := collectorTestutils.NewMockCollector()
+			detector := NewDetectorManager(&mockAuth{}, collectorManager, account, NewMockSTS(), make(map[string]struct{}), config.TagsComplianceConfig{}, test.inventory, "bar")
+
+			detector.ExamineResource(detector.GetResourceIdentifier("foo"), func() collector.PriceDetectedFields {
+				return collector.PriceDetectedFields{
+					ResourceID:    "i-1",
+					PricePerMonth: 73,
+					Tag:           map[string]string{"team": "data"},
+				}
+			})
+
+			if len(collectorManager.Inventory) != test.expectedInventory {
+				t.Fatalf("unexpected collector inventory events, got %d expected %d", len(collectorManager.Inventory), test.expectedInventory)
+			}
+
+			if test.expectedInventory == 0 {
+				return
+			}
+
+			data, ok := collectorManager.Inventory[0].Data.(collector.ResourceInventory)
+			if !ok {
+				t.Fatalf("unexpected inventory event data, got %T expected %s", collectorManager.Inventory[0].Data, "collector.ResourceInventory")
+			}
+			if data.ResourceType != "aws_foo" || data.Region != "bar" || data.AccountID != "foo" || data.AccountName != "production" || data.ResourceID != "i-1" || data.PricePerMonth != 73 {
+				t.Fatalf("unexpected inventory event data, got %+v", data)
+			}
+		})
+	}
 }
@@ -4,7 +4,10 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"math"
+	"sort"
 	"strconv"
+	"strings"
 
 	awsClient "github.com/aws/aws-sdk-go/aws"
 	awsPricing "github.com/aws/aws-sdk-go/service/pricing"
@@ -82,9 +85,11 @@ type PricingOfferTerm struct {
 	PriceDimensions map[string]*PriceRateCode `json:"priceDimensions"`
 }
 
-// PriceRateCode describe the product price
+// PriceRateCode describe the product price. A tiered price has a rate code per tier, and BeginRange is the usage the
+// tier starts at
 type PriceRateCode struct {
 	Unit         string            `json:"unit"`
+	BeginRange   string            `json:"beginRange"`
 	PricePerUnit PriceCurrencyCode `json:"pricePerUnit"`
 }
 
@@ -172,36 +177,63 @@ func (p *PricingManager) GetPrice(filters awsPricing.GetProductsInput, rateCode
 		return 0, fmt.Errorf("failed to unmarshal product: %v", err)
 	}
 
-	// Get the price
-	var price float64
-	var priceFound bool
-
-	// Get the price from the terms
-	for _, term := range pricingResponse.Terms.OnDemand {
-		// Get the price from the price dimensions
-		for _, priceDimension := range term.PriceDimensions {
-			// Get the price from the price per unit
-			if priceDimension.PricePerUnit.USD != "" {
-				price, err = strconv.ParseFloat(priceDimension.PricePerUnit.USD, 64)
-				if err != nil {
-					return 0, fmt.Errorf("failed to parse price: %v", err)
-				}
-				priceFound = true
-				break
-			}
-		}
-		if priceFound {
-			break
-		}
+	priceDimension := getPriceDimension(pricingResponse.Terms.OnDemand, rateCode)
+	if priceDimension == nil {
+		return 0, fmt.Errorf("no price found for the given filters")
 	}
 
-	if !priceFound {
-		return 0, fmt.Errorf("no price found for the given filters")
+	price, err := strconv.ParseFloat(priceDimension.PricePerUnit.USD, 64)
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse price: %v", err)
 	}
 
 	return price, nil
 }
 
+// getPriceDimension returns the price dimension of the given rate code. Without a rate code, or when no dimension
+// matches it, it returns the first tier of the price, whose range begins at the lowest usage. The dimensions are a
+// map, so picking the first one iterated would return a random tier of a tiered price
+func getPriceDimension(terms map[string]*PricingOfferTerm, rateCode string) *PriceRateCode {
+
+	var firstTier *PriceRateCode
+	firstTierBegin := math.Inf(1)
+	for _, termCode := range sortedKeys(terms) {
+		dimensions := terms[termCode].PriceDimensions
+		for _, dimensionCode := range sortedKeys(dimensions) {
+			dimension := dimensions[dimensionCode]
+			if dimension == nil || dimension.PricePerUnit.USD == "" {
+				continue
+			}
+
+			if rateCode != "" && strings.HasSuffix(dimensionCode, fmt.Sprintf(".%s", rateCode)) {
+				return dimension
+			}
+
+			// The dimensions of prices without tiers have no begin range
+			begin, err := strconv.ParseFloat(dimension.BeginRange, 64)
+			if err != nil {
+				begin = 0
+			}
+			if begin < firstTierBegin {
+				firstTier = dimension
+				firstTierBegin = begin
+			}
+		}
+	}
+
+	return firstTier
+}
+
+// sortedKeys returns the keys of the given map in order, so the price dimensions are selected deterministically
+func sortedKeys[T any](values map[string]T) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
 // GetRegionPrefix will return the prefix for a
 // pricing filter value according to a given region.
 // For example:
@@ -245,4 +245,61 @@ func TestGetRegionPrefix(t *testing.T) {
 			}
 		})
 	}
+
+	t.Run("tiered price", func(t *testing.T) {
+
+		mockResponse := []awsClient.JSONValue{{
+			"product": PricingProduct{
+				SKU: "WP9ANXZGBYYSGJEA",
+			},
+			"Terms": PricingTerms{
+				OnDemand: map[string]*PricingOfferTerm{
+					"WP9ANXZGBYYSGJEA.JRTCKXETXF": {
+						PriceDimensions: map[string]*PriceRateCode{
+							"WP9ANXZGBYYSGJEA.JRTCKXETXF.3XH8WVRDZM": {
+								Unit:         "GB-Mo",
+								BeginRange:   "512000",
+								PricePerUnit: PriceCurrencyCode{USD: "0.021"},
+							},
+							"WP9ANXZGBYYSGJEA.JRTCKXETXF.PGHJ3S3EYE": {
+								Unit:         "GB-Mo",
+								BeginRange:   "0",
+								PricePerUnit: PriceCurrencyCode{USD: "0.023"},
+							},
+							"WP9ANXZGBYYSGJEA.JRTCKXETXF.D42MF2PVJS": {
+								Unit:         "GB-Mo",
+								BeginRange:   "51200",
+								PricePerUnit: PriceCurrencyCode{USD: "0.022"},
+							},
+						},
+					},
+				},
+			},
+		},
+		}
+
+		testCases := []struct {
+			name          string
+			rateCode      string
+			expectedPrice float64
+		}{
+			{"first tier", "", 0.023},
+			{"pinned tier", "D42MF2PVJS", 0.022},
+			{"unknown rate code", "1234", 0.023},
+		}
+
+		for _, test := range testCases {
+			t.Run(test.name, func(t *testing.T) {
+				pricingManager := NewPricingManager(newMockPricing(mockResponse), "us-east-1")
+				result, err := pricingManager.GetPrice(pricing.GetProductsInput{}, test.rateCode, "us-east-1")
+				if err != nil {
+					t.Fatalf("unexpected getPrice error, got %v expected nil", err)
+				}
+				if result != test.expectedPrice {
+					t.Fatalf("unexpected tiered price, got %f expected %f", result, test.expectedPrice)
+				}
+			})
+		}
+	})
+
 }
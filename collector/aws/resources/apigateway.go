@@ -7,11 +7,13 @@ import (
 	"finala/collector/aws/register"
 	"finala/collector/config"
 	"finala/expression"
+	"fmt"
 	"time"
 
 	awsClient "github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/apigateway"
 	awsCloudwatch "github.com/aws/aws-sdk-go/service/cloudwatch"
+	"github.com/aws/aws-sdk-go/service/pricing"
 	log "github.com/sirupsen/logrus"
 )
 
@@ -22,20 +24,22 @@ type APIGatewayClientDescreptor interface {
 
 // APIGatewayManager will hold the apigateway Manger strcut
 type APIGatewayManager struct {
-	client     APIGatewayClientDescreptor
-	awsManager common.AWSManager
-	namespace  string
-	Name       collector.ResourceIdentifier
+	client             APIGatewayClientDescreptor
+	awsManager         common.AWSManager
+	namespace          string
+	servicePricingCode string
+	Name               collector.ResourceIdentifier
 }
 
-// DetectedAPIGateway defines the detected AWS apigateway
+// DetectedAPIGateway defines the detected AWS apigateway.
+// The price is estimated from the API calls count of the metric period, PriceUnavailable is true when the price of
+// the API calls was not found
 type DetectedAPIGateway struct {
-	Metric     string
-	Region     string
-	ResourceID string
-	Name       string
-	LaunchTime time.Time
-	Tag        map[string]string
+	Metric           string
+	Name             string
+	RequestsPerMonth float64
+	PriceUnavailable bool
+	collector.PriceDetectedFields
 }
 
 func init() {
@@ -55,10 +59,11 @@ func NewAPIGatewayManager(awsManager common.AWSManager, client interface{}) (com
 	}
 
 	return &APIGatewayManager{
-		client:     apiGatewayClient,
-		awsManager: awsManager,
-		namespace:  "AWS/ApiGateway",
-		Name:       awsManager.GetResourceIdentifier("apigateway"),
+		client:             apiGatewayClient,
+		awsManager:         awsManager,
+		namespace:          "AWS/ApiGateway",
+		servicePricingCode: "AmazonApiGateway",
+		Name:               awsManager.GetResourceIdentifier("apigateway"),
 	}, nil
 
 }
@@ -80,10 +85,51 @@ func (ag *APIGatewayManager) Detect(metrics []config.MetricConfig) (interface{},
 		return detectAPIGateway, err
 	}
 
+	pricingRegionPrefix, err := ag.awsManager.GetPricingClient().GetRegionPrefix(ag.awsManager.GetRegion())
+	if err != nil {
+		log.WithError(err).WithFields(log.Fields{
+			"region": ag.awsManager.GetRegion(),
+		}).Error("Could not get pricing region prefix")
+		ag.awsManager.GetCollector().CollectError(ag.Name, err)
+		return detectAPIGateway, err
+	}
+
+	pricingFilters := ag.getPricingFilterInput(pricingRegionPrefix)
+	// Get the price of a single API call, the apigateways are reported without a price when not found
+	requestPrice, err := ag.awsManager.GetPricingClient().GetPrice(pricingFilters, "", ag.awsManager.GetRegion())
+	priceUnavailable := err != nil
+	if err != nil {
+		log.WithError(err).WithFields(log.Fields{
+			"region":        ag.awsManager.GetRegion(),
+			"price_filters": pricingFilters,
+		}).Error("could not get apigateway price")
+	}
+
 	now := time.Now()
 
 	for _, api := range apigateways {
 		log.WithField("name", *api.Name).Debug("checking apigateway")
+
+		tagsData := map[string]string{}
+		for key, value := range api.Tags {
+			tagsData[key] = *value
+		}
+
+		ag.awsManager.ExamineResource(ag.Name, func() collector.PriceDetectedFields {
+			// The api usage is extrapolated from the period of the first metric
+			var pricePerMonth float64
+			if len(metrics) > 0 {
+				pricePerMonth = ag.getMonthlyRequests(api, metrics[0], now) * requestPrice
+			}
+			return collector.PriceDetectedFields{
+				ResourceID:    *api.Id,
+				LaunchTime:    *api.CreatedDate,
+				PricePerHour:  pricePerMonth / collector.TotalMonthHours,
+				PricePerMonth: pricePerMonth,
+				Tag:           tagsData,
+			}
+		})
+
 		for _, metric := range metrics {
 
 			log.WithFields(log.Fields{
@@ -133,20 +179,24 @@ func (ag *APIGatewayManager) Detect(metrics []config.MetricConfig) (interface{},
 					"region":              ag.awsManager.GetRegion(),
 				}).Info("APIGateway detected as unused resource")
 
-				tagsData := map[string]string{}
-				if err == nil {
-					for key, value := range api.Tags {
-						tagsData[key] = *value
-					}
-				}
+				requests := ag.getMonthlyRequests(api, metric, now)
+				pricePerMonth := requests * requestPrice
 
 				detect := DetectedAPIGateway{
-					Region:     ag.awsManager.GetRegion(),
-					Metric:     metric.Description,
-					ResourceID: *api.Id,
-					Name:       *api.Name,
-					LaunchTime: *api.CreatedDate,
-					Tag:        tagsData,
+					Metric:           metric.Description,
+					Name:             *api.Name,
+					RequestsPerMonth: requests,
+					PriceUnavailable: priceUnavailable,
+					PriceDetectedFields: collector.PriceDetectedFields{
+						ResourceID:        *api.Id,
+						ARN:               common.GetARN(ag.awsManager.GetRegion(), "apigateway", "", fmt.Sprintf("/restapis/%s", *api.Id)),
+						ConsoleURL:        ag.awsManager.GetConsoleURL("apigateway/home", fmt.Sprintf("/apis/%s/resources", *api.Id)),
+						LaunchTime:        *api.CreatedDate,
+						PricePerHour:      pricePerMonth / collector.TotalMonthHours,
+						PricePerMonth:     pricePerMonth,
+						Tag:               tagsData,
+						AccountDimensions: ag.awsManager.GetAccountDimensions(),
+					},
 				}
 
 				ag.awsManager.GetCollector().AddResource(collector.EventCollector{
@@ -165,6 +215,66 @@ func (ag *APIGatewayManager) Detect(metrics []config.MetricConfig) (interface{},
 	return detectAPIGateway, nil
 }
 
+// getMonthlyRequests returns the monthly API calls count, extrapolated from the cloudwatch metrics of the metric period
+func (ag *APIGatewayManager) getMonthlyRequests(api *apigateway.RestApi, metric config.MetricConfig, now time.Time) float64 {
+
+	if metric.StartTime <= 0 {
+		return 0
+	}
+
+	period := int64(metric.Period.Seconds())
+	metricEndTime := now.Add(time.Duration(-metric.StartTime))
+	metricInput := awsCloudwatch.GetMetricStatisticsInput{
+		Namespace: &ag.namespace,
+		Period:    &period,
+		StartTime: &metricEndTime,
+		EndTime:   &now,
+		Dimensions: []*awsCloudwatch.Dimension{
+			{
+				Name:  awsClient.String("ApiName"),
+				Value: api.Name,
+			},
+		},
+	}
+
+	requests, _, err := ag.awsManager.GetCloudWatchClient().GetMetric(&metricInput, config.MetricConfig{
+		Data: []config.MetricDataConfiguration{
+			{
+				Name:      "Count",
+				Statistic: "Sum",
+			},
+		},
+	})
+	if err != nil {
+		log.WithError(err).WithFields(log.Fields{
+			"name":        *api.Name,
+			"metric_name": "Count",
+		}).Error("Could not get cloudwatch metric data")
+		return 0
+	}
+
+	return requests * collector.TotalMonthHours / metric.StartTime.Hours()
+}
+
+// getPricingFilterInput prepares the apigateway REST API calls pricing filter
+func (ag *APIGatewayManager) getPricingFilterInput(pricingRegionPrefix string) pricing.GetProductsInput {
+	return pricing.GetProductsInput{
+		ServiceCode: &ag.servicePricingCode,
+		Filters: []*pricing.Filter{
+			{
+				Type:  awsClient.String("TERM_MATCH"),
+				Field: awsClient.String("termType"),
+				Value: awsClient.String("OnDemand"),
+			},
+			{
+				Type:  awsClient.String("TERM_MATCH"),
+				Field: awsClient.String("usagetype"),
+				Value: awsClient.String(fmt.Sprintf("%sApiGatewayRequest", pricingRegionPrefix)),
+			},
+		},
+	}
+}
+
 // getRestApis will return all apigatways rest apis
 func (ag *APIGatewayManager) getRestApis(position *string, restApis []*apigateway.RestApi) ([]*apigateway.RestApi, error) {
 
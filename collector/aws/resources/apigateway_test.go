@@ -2,10 +2,12 @@ package resources
 
 import (
 	"errors"
+	"finala/collector/aws/pricing"
 	awsTestutils "finala/collector/aws/testutils"
 	"finala/collector/config"
 	"finala/collector/testutils"
 	collectorTestutils "finala/collector/testutils"
+	"fmt"
 	"reflect"
 	"testing"
 	"time"
@@ -197,6 +199,104 @@ func TestDetect(t *testing.T) {
 
 }
 
+func TestDetectAPIGatewayPricing(t *testing.T) {
+
+	mockClient := mockAPIGatewayCLient{
+		response: mockApiGatways,
+	}
+	collector := collectorTestutils.NewMockCollector()
+	mockCloudwatch := awsTestutils.NewMockCloudwatch(&map[string]cloudwatch.GetMetricStatisticsOutput{
+		"Count": {
+			Datapoints: []*cloudwatch.Datapoint{
+				{Sum: awsClient.Float64(500)},
+				{Sum: awsClient.Float64(1000)},
+			},
+		},
+	})
+	mockPrice := pricing.NewPricingManager(&MockFilterPricingClient{prices: map[string]float64{
+		"ApiGatewayRequest": 0.001,
+	}}, "us-east-1")
+	detector := awsTestutils.AWSManager(collector, mockCloudwatch, mockPrice, "us-east-1")
+
+	apigateway, err := NewAPIGatewayManager(detector, &mockClient)
+	if err != nil {
+		t.Fatalf("unexpected apigateway manager error happened, got %v expected %v", err, nil)
+	}
+
+	response, err := apigateway.Detect([]config.MetricConfig{
+		{
+			Description: "API calls",
+			Data: []config.MetricDataConfiguration{
+				{
+					Name:      "Count",
+					Statistic: "Sum",
+				},
+			},
+			Constraint: config.MetricConstraintConfig{
+				Operator: "<",
+				Value:    2000,
+			},
+			Period:    24 * time.Hour,
+			StartTime: 146 * time.Hour,
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected apigatway detect error happened, got %v expected %v", err, nil)
+	}
+
+	apiGatewatResponse, ok := response.([]DetectedAPIGateway)
+	if !ok {
+		t.Fatalf("unexpected apigatway response struct, got %s expected %s", reflect.TypeOf(response), "[]DetectedAPIGateway")
+	}
+
+	if len(apiGatewatResponse) != 2 {
+		t.Fatalf("unexpected apigateway response count, got %d expected %d", len(apiGatewatResponse), 2)
+	}
+
+	// The metric period is fifth of a month
+	expectedRequests := float64(1500 * 5)
+	expectedPrice := expectedRequests * 0.001
+	for _, detected := range apiGatewatResponse {
+		if fmt.Sprintf("%.2f", detected.RequestsPerMonth) != fmt.Sprintf("%.2f", expectedRequests) {
+			t.Fatalf("unexpected requests per month, got %f expected %f", detected.RequestsPerMonth, expectedRequests)
+		}
+		if fmt.Sprintf("%.2f", detected.PricePerMonth) != fmt.Sprintf("%.2f", expectedPrice) {
+			t.Fatalf("unexpected price per month, got %f expected %f", detected.PricePerMonth, expectedPrice)
+		}
+	}
+}
+
+func TestDetectAPIGatewayPriceUnavailable(t *testing.T) {
+
+	mockClient := mockAPIGatewayCLient{
+		response: mockApiGatways,
+	}
+	collector := collectorTestutils.NewMockCollector()
+	mockCloudwatch := awsTestutils.NewMockCloudwatch(nil)
+	mockPrice := pricing.NewPricingManager(&MockFilterPricingClient{prices: map[string]float64{}}, "us-east-1")
+	detector := awsTestutils.AWSManager(collector, mockCloudwatch, mockPrice, "us-east-1")
+
+	apigateway, err := NewAPIGatewayManager(detector, &mockClient)
+	if err != nil {
+		t.Fatalf("unexpected apigateway manager error happened, got %v expected %v", err, nil)
+	}
+
+	response, err := apigateway.Detect(awsTestutils.DefaultMetricConfig)
+	if err != nil {
+		t.Fatalf("unexpected apigatway detect error happened, got %v expected %v", err, nil)
+	}
+
+	apiGatewatResponse := response.([]DetectedAPIGateway)
+	if len(apiGatewatResponse) != 2 {
+		t.Fatalf("unexpected apigateway response count, got %d expected %d", len(apiGatewatResponse), 2)
+	}
+	for _, detected := range apiGatewatResponse {
+		if !detected.PriceUnavailable || detected.PricePerMonth != 0 {
+			t.Fatalf("unexpected apigateway price, got %f with price unavailable %t expected no price", detected.PricePerMonth, detected.PriceUnavailable)
+		}
+	}
+}
+
 func TestDetectErrors(t *testing.T) {
 
 	t.Run("getRestApis error", func(t *testing.T) {
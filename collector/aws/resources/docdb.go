@@ -7,6 +7,7 @@ import (
 	"finala/collector/aws/register"
 	"finala/collector/config"
 	"finala/expression"
+	"fmt"
 	"time"
 
 	awsClient "github.com/aws/aws-sdk-go/aws"
@@ -34,7 +35,6 @@ type DocumentDBManager struct {
 // DetectedDocumentDB define the detected AWS documentDB instances
 type DetectedDocumentDB struct {
 	Metric       string
-	Region       string
 	InstanceType string
 	MultiAZ      bool
 	Engine       string
@@ -91,6 +91,16 @@ func (dd *DocumentDBManager) Detect(metrics []config.MetricConfig) (interface{},
 
 		price, _ := dd.awsManager.GetPricingClient().GetPrice(dd.getPricingFilterInput(instance), "", dd.awsManager.GetRegion())
 
+		dd.awsManager.ExamineResource(dd.Name, func() collector.PriceDetectedFields {
+			return collector.PriceDetectedFields{
+				ResourceID:    *instance.DBInstanceArn,
+				LaunchTime:    *instance.InstanceCreateTime,
+				PricePerHour:  price,
+				PricePerMonth: price * collector.TotalMonthHours,
+				Tag:           dd.getTags(instance.DBInstanceArn),
+			}
+		})
+
 		for _, metric := range metrics {
 			log.WithFields(log.Fields{
 				"name":        *instance.DBInstanceIdentifier,
@@ -139,28 +149,21 @@ func (dd *DocumentDBManager) Detect(metrics []config.MetricConfig) (interface{},
 					"region":              dd.awsManager.GetRegion(),
 				}).Info("DocumentDB instance detected as unutilized resource")
 
-				tags, err := dd.client.ListTagsForResource(&docdb.ListTagsForResourceInput{
-					ResourceName: instance.DBInstanceArn,
-				})
-
-				tagsData := map[string]string{}
-				if err == nil {
-					for _, tag := range tags.TagList {
-						tagsData[*tag.Key] = *tag.Value
-					}
-				}
+				tagsData := dd.getTags(instance.DBInstanceArn)
 
 				docDB := DetectedDocumentDB{
-					Region:       dd.awsManager.GetRegion(),
 					Metric:       metric.Description,
 					InstanceType: *instance.DBInstanceClass,
 					Engine:       *instance.Engine,
 					PriceDetectedFields: collector.PriceDetectedFields{
-						ResourceID:    *instance.DBInstanceArn,
-						LaunchTime:    *instance.InstanceCreateTime,
-						PricePerHour:  price,
-						PricePerMonth: price * collector.TotalMonthHours,
-						Tag:           tagsData,
+						ResourceID:        *instance.DBInstanceArn,
+						ARN:               *instance.DBInstanceArn,
+						ConsoleURL:        dd.awsManager.GetConsoleURL("docdb/home", fmt.Sprintf("instance-details/%s", *instance.DBInstanceIdentifier)),
+						LaunchTime:        *instance.InstanceCreateTime,
+						PricePerHour:      price,
+						PricePerMonth:     price * collector.TotalMonthHours,
+						Tag:               tagsData,
+						AccountDimensions: dd.awsManager.GetAccountDimensions(),
 					},
 				}
 
@@ -182,6 +185,24 @@ func (dd *DocumentDBManager) Detect(metrics []config.MetricConfig) (interface{},
 
 }
 
+// getTags returns the documentDB instance tags
+func (dd *DocumentDBManager) getTags(resourceName *string) map[string]string {
+
+	tagsData := map[string]string{}
+	tags, err := dd.client.ListTagsForResource(&docdb.ListTagsForResourceInput{
+		ResourceName: resourceName,
+	})
+	if err != nil {
+		log.WithError(err).WithField("resource", *resourceName).Error("could not list documentDB instance tags")
+		return tagsData
+	}
+
+	for _, tag := range tags.TagList {
+		tagsData[*tag.Key] = *tag.Value
+	}
+	return tagsData
+}
+
 // getPricingFilterInput prepare document db pricing filter
 func (dd *DocumentDBManager) getPricingFilterInput(instance *docdb.DBInstance) pricing.GetProductsInput {
 
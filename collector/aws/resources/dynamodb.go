@@ -7,6 +7,7 @@ import (
 	"finala/collector/aws/register"
 	"finala/collector/config"
 	"finala/expression"
+	"fmt"
 	"strings"
 	"time"
 
@@ -38,7 +39,6 @@ type DynamoDBManager struct {
 
 // DetectedAWSDynamoDB define the detected AWS RDS instances
 type DetectedAWSDynamoDB struct {
-	Region string
 	Metric string
 	Name   string
 	collector.PriceDetectedFields
@@ -108,6 +108,21 @@ func (dd *DynamoDBManager) Detect(metrics []config.MetricConfig) (interface{}, e
 
 		log.WithField("table_name", *table.TableName).Debug("checking dynamodb table")
 
+		dd.awsManager.ExamineResource(dd.Name, func() collector.PriceDetectedFields {
+			var pricePerHour float64
+			if table.ProvisionedThroughput != nil {
+				pricePerHour = float64(awsClient.Int64Value(table.ProvisionedThroughput.WriteCapacityUnits))*writePricePerHour +
+					float64(awsClient.Int64Value(table.ProvisionedThroughput.ReadCapacityUnits))*readPricePerHour
+			}
+			return collector.PriceDetectedFields{
+				ResourceID:    *table.TableArn,
+				LaunchTime:    *table.CreationDateTime,
+				PricePerHour:  pricePerHour,
+				PricePerMonth: pricePerHour * collector.TotalMonthHours,
+				Tag:           dd.getTags(table.TableArn),
+			}
+		})
+
 		for _, metric := range metrics {
 			log.WithFields(log.Fields{
 				"table_name":  *table.TableName,
@@ -177,27 +192,20 @@ func (dd *DynamoDBManager) Detect(metrics []config.MetricConfig) (interface{}, e
 					continue
 				}
 
-				tags, err := dd.client.ListTagsOfResource(&dynamodb.ListTagsOfResourceInput{
-					ResourceArn: table.TableArn,
-				})
-
-				tagsData := map[string]string{}
-				if err == nil {
-					for _, tag := range tags.Tags {
-						tagsData[*tag.Key] = *tag.Value
-					}
-				}
+				tagsData := dd.getTags(table.TableArn)
 
 				detectedDynamoDBTable := DetectedAWSDynamoDB{
-					Region: dd.awsManager.GetRegion(),
 					Metric: metric.Description,
 					Name:   *table.TableName,
 					PriceDetectedFields: collector.PriceDetectedFields{
-						ResourceID:    *table.TableArn,
-						LaunchTime:    *table.CreationDateTime,
-						PricePerHour:  pricePerHour,
-						PricePerMonth: pricePerMonth,
-						Tag:           tagsData,
+						ResourceID:        *table.TableArn,
+						ARN:               *table.TableArn,
+						ConsoleURL:        dd.awsManager.GetConsoleURL("dynamodbv2/home", fmt.Sprintf("table?name=%s", *table.TableName)),
+						LaunchTime:        *table.CreationDateTime,
+						PricePerHour:      pricePerHour,
+						PricePerMonth:     pricePerMonth,
+						Tag:               tagsData,
+						AccountDimensions: dd.awsManager.GetAccountDimensions(),
 					},
 				}
 
@@ -218,6 +226,24 @@ func (dd *DynamoDBManager) Detect(metrics []config.MetricConfig) (interface{}, e
 
 }
 
+// getTags returns the dynamoDB table tags
+func (dd *DynamoDBManager) getTags(tableArn *string) map[string]string {
+
+	tagsData := map[string]string{}
+	tags, err := dd.client.ListTagsOfResource(&dynamodb.ListTagsOfResourceInput{
+		ResourceArn: tableArn,
+	})
+	if err != nil {
+		log.WithError(err).WithField("table_arn", *tableArn).Error("could not list dynamoDB table tags")
+		return tagsData
+	}
+
+	for _, tag := range tags.Tags {
+		tagsData[*tag.Key] = *tag.Value
+	}
+	return tagsData
+}
+
 // getPricingWriteFilterInput return write capacity unit price filter per hour
 func (dd *DynamoDBManager) getPricingWriteFilterInput() pricing.GetProductsInput {
 
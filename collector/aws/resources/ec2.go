@@ -7,6 +7,7 @@ import (
 	"finala/collector/aws/register"
 	"finala/collector/config"
 	"finala/expression"
+	"fmt"
 	"strings"
 	"time"
 
@@ -20,6 +21,7 @@ import (
 // EC2ClientDescreptor is an interface defining the aws ec2 client
 type EC2ClientDescreptor interface {
 	DescribeInstances(*ec2.DescribeInstancesInput) (*ec2.DescribeInstancesOutput, error)
+	DescribeInstanceTypes(*ec2.DescribeInstanceTypesInput) (*ec2.DescribeInstanceTypesOutput, error)
 }
 
 // EC2Manager describes EC2 struct
@@ -28,15 +30,17 @@ type EC2Manager struct {
 	awsManager         common.AWSManager
 	namespace          string
 	servicePricingCode string
+	instanceTypes      map[string]*ec2.InstanceTypeInfo
+	prices             map[string]float64
 	Name               collector.ResourceIdentifier
 }
 
 // DetectedEC2 define the detected AWS EC2 instances
 type DetectedEC2 struct {
-	Region       string
 	Metric       string
 	Name         string
 	InstanceType string
+	EC2Recommendation
 	collector.PriceDetectedFields
 }
 
@@ -61,11 +65,13 @@ func NewEC2Manager(awsManager common.AWSManager, client interface{}) (common.Res
 		awsManager:         awsManager,
 		namespace:          "AWS/EC2",
 		servicePricingCode: "AmazonEC2",
+		instanceTypes:      map[string]*ec2.InstanceTypeInfo{},
+		prices:             map[string]float64{},
 		Name:               awsManager.GetResourceIdentifier("ec2"),
 	}, nil
 }
 
-// Detect EC2 instance is under utilized
+// Detect EC2 instance is under utilized, and recommends a smaller instance type for the detected instances
 func (ec *EC2Manager) Detect(metrics []config.MetricConfig) (interface{}, error) {
 
 	log.WithFields(log.Fields{
@@ -89,6 +95,20 @@ func (ec *EC2Manager) Detect(metrics []config.MetricConfig) (interface{}, error)
 
 		price, _ := ec.awsManager.GetPricingClient().GetPrice(ec.getPricingFilterInput(instance), "", ec.awsManager.GetRegion())
 
+		ec.awsManager.ExamineResource(ec.Name, func() collector.PriceDetectedFields {
+			tagsData := map[string]string{}
+			for _, tag := range instance.Tags {
+				tagsData[*tag.Key] = *tag.Value
+			}
+			return collector.PriceDetectedFields{
+				ResourceID:    *instance.InstanceId,
+				LaunchTime:    *instance.LaunchTime,
+				PricePerHour:  price,
+				PricePerMonth: price * collector.TotalMonthHours,
+				Tag:           tagsData,
+			}
+		})
+
 		for _, metric := range metrics {
 			log.WithFields(log.Fields{
 				"instance_id": *instance.InstanceId,
@@ -153,16 +173,19 @@ func (ec *EC2Manager) Detect(metrics []config.MetricConfig) (interface{}, error)
 				}
 
 				ec2 := DetectedEC2{
-					Region:       ec.awsManager.GetRegion(),
-					Metric:       metric.Description,
-					Name:         name,
-					InstanceType: *instance.InstanceType,
+					Metric:            metric.Description,
+					Name:              name,
+					InstanceType:      *instance.InstanceType,
+					EC2Recommendation: ec.getRecommendation(instance, price, now),
 					PriceDetectedFields: collector.PriceDetectedFields{
-						ResourceID:    *instance.InstanceId,
-						LaunchTime:    *instance.LaunchTime,
-						PricePerHour:  price,
-						PricePerMonth: price * collector.TotalMonthHours,
-						Tag:           tagsData,
+						ResourceID:        *instance.InstanceId,
+						ARN:               ec.awsManager.GetARN("ec2", fmt.Sprintf("instance/%s", *instance.InstanceId)),
+						ConsoleURL:        ec.awsManager.GetConsoleURL("ec2/home", fmt.Sprintf("InstanceDetails:instanceId=%s", *instance.InstanceId)),
+						LaunchTime:        *instance.LaunchTime,
+						PricePerHour:      price,
+						PricePerMonth:     price * collector.TotalMonthHours,
+						Tag:               tagsData,
+						AccountDimensions: ec.awsManager.GetAccountDimensions(),
 					},
 				}
 
@@ -193,8 +216,15 @@ func (ec *EC2Manager) getPricingFilterInput(instance *ec2.Instance) pricing.GetP
 		platform = *instance.Platform
 	}
 
+	return getEC2InstanceTypePricingFilterInput(ec.servicePricingCode, *instance.InstanceType, platform)
+
+}
+
+// getEC2InstanceTypePricingFilterInput return the on demand price filters of the given EC2 instance type and platform.
+func getEC2InstanceTypePricingFilterInput(servicePricingCode, instanceType, platform string) pricing.GetProductsInput {
+
 	input := pricing.GetProductsInput{
-		ServiceCode: &ec.servicePricingCode,
+		ServiceCode: &servicePricingCode,
 		Filters: []*pricing.Filter{
 			{
 				Type:  awsClient.String("TERM_MATCH"),
@@ -224,7 +254,7 @@ func (ec *EC2Manager) getPricingFilterInput(instance *ec2.Instance) pricing.GetP
 			{
 				Type:  awsClient.String("TERM_MATCH"),
 				Field: awsClient.String("instanceType"),
-				Value: instance.InstanceType,
+				Value: &instanceType,
 			},
 			{
 				Type:  awsClient.String("TERM_MATCH"),
@@ -29,8 +29,10 @@ var defaultEC2Mock = ec2.DescribeInstancesOutput{
 }
 
 type MockAWSEC2Client struct {
-	responseDescribeInstances ec2.DescribeInstancesOutput
-	err                       error
+	responseDescribeInstances     ec2.DescribeInstancesOutput
+	responseDescribeInstanceTypes ec2.DescribeInstanceTypesOutput
+	describeInstanceTypesCalls    int
+	err                           error
 }
 
 func (r *MockAWSEC2Client) DescribeInstances(*ec2.DescribeInstancesInput) (*ec2.DescribeInstancesOutput, error) {
@@ -39,6 +41,13 @@ func (r *MockAWSEC2Client) DescribeInstances(*ec2.DescribeInstancesInput) (*ec2.
 
 }
 
+func (r *MockAWSEC2Client) DescribeInstanceTypes(*ec2.DescribeInstanceTypesInput) (*ec2.DescribeInstanceTypesOutput, error) {
+
+	r.describeInstanceTypesCalls++
+	return &r.responseDescribeInstanceTypes, r.err
+
+}
+
 func TestEC2DescribeInstances(t *testing.T) {
 
 	collector := collectorTestutils.NewMockCollector()
@@ -151,4 +160,14 @@ func TestDetectEC2(t *testing.T) {
 		t.Fatalf("unexpected resource status events count, got %d expected %d", len(collector.EventsCollectionStatus), 2)
 	}
 
+	expectedARN := "arn:aws:ec2:us-east-1:1234:instance/1"
+	if ec2Response[0].ARN != expectedARN {
+		t.Fatalf("unexpected ec2 arn, got %s expected %s", ec2Response[0].ARN, expectedARN)
+	}
+
+	expectedConsoleURL := "https://console.aws.amazon.com/ec2/home?region=us-east-1#InstanceDetails:instanceId=1"
+	if ec2Response[0].ConsoleURL != expectedConsoleURL {
+		t.Fatalf("unexpected ec2 console url, got %s expected %s", ec2Response[0].ConsoleURL, expectedConsoleURL)
+	}
+
 }
@@ -0,0 +1,337 @@
+package resources
+
+import (
+	"finala/collector"
+	"finala/collector/config"
+	"fmt"
+	"strings"
+	"time"
+
+	awsClient "github.com/aws/aws-sdk-go/aws"
+	awsCloudwatch "github.com/aws/aws-sdk-go/service/cloudwatch"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// ec2RightsizingLookback defines the period of the utilization used for the recommendation
+	ec2RightsizingLookback = 336 * time.Hour
+
+	// ec2RightsizingPeriod defines the cloudwatch period of the rightsizing metrics
+	ec2RightsizingPeriod = time.Hour
+
+	// ec2RightsizingSamplePeriod is the basic monitoring sample period of the ec2 network metrics
+	ec2RightsizingSamplePeriod = 5 * time.Minute
+
+	// ec2RightsizingDetailedSamplePeriod is the detailed monitoring sample period of the ec2 network metrics
+	ec2RightsizingDetailedSamplePeriod = time.Minute
+
+	// ec2RightsizingTargetUtilization is the maximum utilization percentage allowed on the recommended type
+	ec2RightsizingTargetUtilization = 70
+)
+
+// ec2InstanceSizes is the ordered list of the ec2 instance sizes, from the smallest to the largest
+var ec2InstanceSizes = []string{
+	"nano", "micro", "small", "medium", "large", "xlarge", "2xlarge", "3xlarge", "4xlarge", "6xlarge",
+	"8xlarge", "9xlarge", "10xlarge", "12xlarge", "16xlarge", "18xlarge", "24xlarge", "32xlarge", "48xlarge",
+}
+
+// ec2GravitonFamilies maps x86 instance families to their Graviton equivalent
+var ec2GravitonFamilies = map[string]string{
+	"t2":  "t4g",
+	"t3":  "t4g",
+	"t3a": "t4g",
+	"m4":  "m6g",
+	"m5":  "m6g",
+	"m5a": "m6g",
+	"m6i": "m7g",
+	"m6a": "m7g",
+	"m7i": "m7g",
+	"m7a": "m7g",
+	"c4":  "c6g",
+	"c5":  "c6g",
+	"c5a": "c6g",
+	"c6i": "c7g",
+	"c6a": "c7g",
+	"c7i": "c7g",
+	"c7a": "c7g",
+	"r4":  "r6g",
+	"r5":  "r6g",
+	"r5a": "r6g",
+	"r6i": "r7g",
+	"r6a": "r7g",
+	"r7i": "r7g",
+	"r7a": "r7g",
+}
+
+// EC2Recommendation defines the recommended instance type of an under utilized instance
+type EC2Recommendation struct {
+	MaxCPUUtilization         float64
+	MaxNetworkGbps            float64
+	RecommendedType           string
+	RecommendedPricePerMonth  float64
+	RecommendedSavingPerMonth float64
+}
+
+// getRecommendation returns the cheapest smaller instance type of the same family, or its Graviton equivalent,
+// that can serve the instance max CPU and network utilization.
+// An empty recommended type is returned when no cheaper type was found.
+func (ec *EC2Manager) getRecommendation(instance *ec2.Instance, pricePerHour float64, now time.Time) EC2Recommendation {
+
+	recommendation := EC2Recommendation{}
+
+	maxCPUUtilization, err := ec.getMaxUtilizationMetric(instance, "CPUUtilization", now)
+	if err != nil {
+		log.WithError(err).WithField("instance_id", *instance.InstanceId).Debug("could not get instance max cpu utilization")
+		return recommendation
+	}
+	recommendation.MaxCPUUtilization = maxCPUUtilization
+
+	var maxNetworkBytes float64
+	for _, metricName := range []string{"NetworkIn", "NetworkOut"} {
+		networkBytes, err := ec.getMaxUtilizationMetric(instance, metricName, now)
+		if err != nil {
+			log.WithError(err).WithField("instance_id", *instance.InstanceId).Debug("could not get instance max network utilization")
+			return recommendation
+		}
+		maxNetworkBytes += networkBytes
+	}
+	recommendation.MaxNetworkGbps = maxNetworkBytes * 8 / getEC2NetworkSamplePeriod(instance).Seconds() / 1e9
+
+	platform := "Linux"
+	if instance.Platform != nil {
+		platform = *instance.Platform
+	}
+
+	candidates := getEC2RightsizingCandidates(*instance.InstanceType, platform == "Linux")
+	if len(candidates) == 0 {
+		return recommendation
+	}
+
+	instanceTypes, err := ec.getInstanceTypes(append(candidates, *instance.InstanceType))
+	if err != nil {
+		return recommendation
+	}
+
+	current := instanceTypes[*instance.InstanceType]
+	if current == nil || current.VCpuInfo == nil {
+		return recommendation
+	}
+
+	requiredVCPU := float64(awsClient.Int64Value(current.VCpuInfo.DefaultVCpus)) * recommendation.MaxCPUUtilization / ec2RightsizingTargetUtilization
+	requiredNetworkGbps := recommendation.MaxNetworkGbps * 100 / ec2RightsizingTargetUtilization
+
+	recommendedPricePerHour := pricePerHour
+	for _, candidate := range candidates {
+		candidateInfo := instanceTypes[candidate]
+		if candidateInfo == nil || candidateInfo.VCpuInfo == nil {
+			continue
+		}
+
+		if float64(awsClient.Int64Value(candidateInfo.VCpuInfo.DefaultVCpus)) < requiredVCPU {
+			continue
+		}
+
+		if baselineBandwidth, ok := getEC2BaselineBandwidthInGbps(candidateInfo); ok && baselineBandwidth < requiredNetworkGbps {
+			continue
+		}
+
+		price, err := ec.getInstanceTypePrice(candidate, platform)
+		if err != nil {
+			log.WithError(err).WithField("instance_type", candidate).Debug("could not get rightsizing candidate price")
+			continue
+		}
+
+		if price < recommendedPricePerHour {
+			recommendedPricePerHour = price
+			recommendation.RecommendedType = candidate
+		}
+	}
+
+	if recommendation.RecommendedType != "" {
+		recommendation.RecommendedPricePerMonth = recommendedPricePerHour * collector.TotalMonthHours
+		recommendation.RecommendedSavingPerMonth = (pricePerHour - recommendedPricePerHour) * collector.TotalMonthHours
+	}
+
+	return recommendation
+}
+
+// getMaxUtilizationMetric returns the maximum value of the given instance metric over the rightsizing lookback
+func (ec *EC2Manager) getMaxUtilizationMetric(instance *ec2.Instance, metricName string, now time.Time) (float64, error) {
+
+	period := int64(ec2RightsizingPeriod.Seconds())
+	startTime := now.Add(-ec2RightsizingLookback)
+
+	metricInput := awsCloudwatch.GetMetricStatisticsInput{
+		Namespace: &ec.namespace,
+		Period:    &period,
+		StartTime: &startTime,
+		EndTime:   &now,
+		Dimensions: []*awsCloudwatch.Dimension{
+			{
+				Name:  awsClient.String("InstanceId"),
+				Value: instance.InstanceId,
+			},
+		},
+	}
+
+	value, _, err := ec.awsManager.GetCloudWatchClient().GetMetric(&metricInput, config.MetricConfig{
+		Data: []config.MetricDataConfiguration{
+			{
+				Name:      metricName,
+				Statistic: "Maximum",
+			},
+		},
+	})
+
+	return value, err
+}
+
+// getInstanceTypes returns the given instance types by instance type, the types which are not offered are nil.
+// The instance types are cached for the whole detection, only the types which were not described yet are requested
+func (ec *EC2Manager) getInstanceTypes(instanceTypes []string) (map[string]*ec2.InstanceTypeInfo, error) {
+
+	missing := []string{}
+	for _, instanceType := range instanceTypes {
+		if _, found := ec.instanceTypes[instanceType]; !found {
+			missing = append(missing, instanceType)
+		}
+	}
+
+	if len(missing) > 0 {
+		described, err := ec.describeInstanceTypes(missing, nil, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, instanceType := range missing {
+			ec.instanceTypes[instanceType] = described[instanceType]
+		}
+	}
+
+	result := map[string]*ec2.InstanceTypeInfo{}
+	for _, instanceType := range instanceTypes {
+		result[instanceType] = ec.instanceTypes[instanceType]
+	}
+
+	return result, nil
+}
+
+// getInstanceTypePrice returns the hourly price of the given instance type and platform. The found prices are cached
+// for the whole detection, and the prices which were not found are requested again for the next instance
+func (ec *EC2Manager) getInstanceTypePrice(instanceType, platform string) (float64, error) {
+
+	key := fmt.Sprintf("%s|%s", instanceType, platform)
+	if price, found := ec.prices[key]; found {
+		return price, nil
+	}
+
+	price, err := ec.awsManager.GetPricingClient().GetPrice(getEC2InstanceTypePricingFilterInput(ec.servicePricingCode, instanceType, platform), "", ec.awsManager.GetRegion())
+	if err != nil {
+		return 0, err
+	}
+
+	ec.prices[key] = price
+	return price, nil
+}
+
+// describeInstanceTypes returns the existing instance types from the given list, by instance type
+func (ec *EC2Manager) describeInstanceTypes(instanceTypes []string, nextToken *string, instanceTypesInfo map[string]*ec2.InstanceTypeInfo) (map[string]*ec2.InstanceTypeInfo, error) {
+
+	// Filtering by instance type ignores types which are not offered, unlike the InstanceTypes parameter
+	input := &ec2.DescribeInstanceTypesInput{
+		NextToken: nextToken,
+		Filters: []*ec2.Filter{
+			{
+				Name:   awsClient.String("instance-type"),
+				Values: awsClient.StringSlice(instanceTypes),
+			},
+		},
+	}
+
+	resp, err := ec.client.DescribeInstanceTypes(input)
+	if err != nil {
+		log.WithField("error", err).Error("could not describe ec2 instance types")
+		return nil, err
+	}
+
+	if instanceTypesInfo == nil {
+		instanceTypesInfo = map[string]*ec2.InstanceTypeInfo{}
+	}
+
+	for _, instanceTypeInfo := range resp.InstanceTypes {
+		instanceTypesInfo[*instanceTypeInfo.InstanceType] = instanceTypeInfo
+	}
+
+	if resp.NextToken != nil {
+		return ec.describeInstanceTypes(instanceTypes, resp.NextToken, instanceTypesInfo)
+	}
+
+	return instanceTypesInfo, nil
+}
+
+// getEC2RightsizingCandidates returns the smaller instance types of the same family,
+// and when allowed the Graviton equivalent types up to the same size
+func getEC2RightsizingCandidates(instanceType string, allowGraviton bool) []string {
+
+	candidates := []string{}
+
+	parts := strings.SplitN(instanceType, ".", 2)
+	if len(parts) != 2 {
+		return candidates
+	}
+	family, size := parts[0], parts[1]
+
+	sizeIndex := -1
+	for i, s := range ec2InstanceSizes {
+		if s == size {
+			sizeIndex = i
+			break
+		}
+	}
+	if sizeIndex == -1 {
+		return candidates
+	}
+
+	for i := 0; i < sizeIndex; i++ {
+		candidates = append(candidates, fmt.Sprintf("%s.%s", family, ec2InstanceSizes[i]))
+	}
+
+	gravitonFamily, found := ec2GravitonFamilies[family]
+	if allowGraviton && found {
+		for i := 0; i <= sizeIndex; i++ {
+			candidates = append(candidates, fmt.Sprintf("%s.%s", gravitonFamily, ec2InstanceSizes[i]))
+		}
+	}
+
+	return candidates
+}
+
+// getEC2NetworkSamplePeriod returns the sample period of the instance network metrics. The maximum statistic is the
+// bytes of the largest sample, which covers 1 minute with detailed monitoring and 5 minutes otherwise
+func getEC2NetworkSamplePeriod(instance *ec2.Instance) time.Duration {
+
+	if instance.Monitoring != nil && awsClient.StringValue(instance.Monitoring.State) == ec2.MonitoringStateEnabled {
+		return ec2RightsizingDetailedSamplePeriod
+	}
+
+	return ec2RightsizingSamplePeriod
+}
+
+// getEC2BaselineBandwidthInGbps returns the instance type baseline network bandwidth of all its network cards
+func getEC2BaselineBandwidthInGbps(instanceTypeInfo *ec2.InstanceTypeInfo) (float64, bool) {
+
+	if instanceTypeInfo.NetworkInfo == nil || len(instanceTypeInfo.NetworkInfo.NetworkCards) == 0 {
+		return 0, false
+	}
+
+	var baselineBandwidth float64
+	for _, networkCard := range instanceTypeInfo.NetworkInfo.NetworkCards {
+		if networkCard.BaselineBandwidthInGbps == nil {
+			return 0, false
+		}
+		baselineBandwidth += *networkCard.BaselineBandwidthInGbps
+	}
+
+	return baselineBandwidth, true
+}
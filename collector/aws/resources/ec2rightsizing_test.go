@@ -0,0 +1,283 @@
+package resources
+
+import (
+	"finala/collector/aws/pricing"
+	awsTestutils "finala/collector/aws/testutils"
+	"finala/collector/testutils"
+	collectorTestutils "finala/collector/testutils"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	awsClient "github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudwatch"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	awsPricing "github.com/aws/aws-sdk-go/service/pricing"
+)
+
+// MockFilterPricingClient returns the configured price of the requested usage type, instance type or volume type
+type MockFilterPricingClient struct {
+	prices map[string]float64
+}
+
+func (r *MockFilterPricingClient) GetProducts(input *awsPricing.GetProductsInput) (*awsPricing.GetProductsOutput, error) {
+
+	filters := map[string]string{}
+	for _, filter := range input.Filters {
+		filters[*filter.Field] = *filter.Value
+	}
+
+	var priceKey string
+	for _, field := range []string{"usagetype", "instanceType", "volumeApiName"} {
+		if value, found := filters[field]; found {
+			priceKey = value
+			break
+		}
+	}
+
+	price, found := r.prices[priceKey]
+	if !found {
+		return &awsPricing.GetProductsOutput{}, nil
+	}
+
+	return &awsPricing.GetProductsOutput{
+		PriceList: []awsClient.JSONValue{
+			{
+				"Terms": pricing.PricingTerms{
+					OnDemand: map[string]*pricing.PricingOfferTerm{
+						"SKU.TERM": {
+							PriceDimensions: map[string]*pricing.PriceRateCode{
+								"SKU.TERM.RATE": {
+									Unit:         "USD",
+									PricePerUnit: pricing.PriceCurrencyCode{USD: fmt.Sprintf("%f", price)},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}, nil
+}
+
+func newEC2InstanceTypeInfo(instanceType string, vCPUs int64, baselineBandwidth float64) *ec2.InstanceTypeInfo {
+	return &ec2.InstanceTypeInfo{
+		InstanceType: awsClient.String(instanceType),
+		VCpuInfo:     &ec2.VCpuInfo{DefaultVCpus: awsClient.Int64(vCPUs)},
+		NetworkInfo: &ec2.NetworkInfo{
+			NetworkCards: []*ec2.NetworkCardInfo{
+				{BaselineBandwidthInGbps: awsClient.Float64(baselineBandwidth)},
+			},
+		},
+	}
+}
+
+func newEC2RightsizingCloudwatchMetrics(maxCPU, maxNetworkBytes float64) map[string]cloudwatch.GetMetricStatisticsOutput {
+	return map[string]cloudwatch.GetMetricStatisticsOutput{
+		"TestMetric": {
+			Datapoints: []*cloudwatch.Datapoint{
+				{Sum: testutils.Float64Pointer(5)},
+			},
+		},
+		"CPUUtilization": {
+			Datapoints: []*cloudwatch.Datapoint{
+				{Maximum: testutils.Float64Pointer(maxCPU)},
+			},
+		},
+		"NetworkIn": {
+			Datapoints: []*cloudwatch.Datapoint{
+				{Maximum: testutils.Float64Pointer(maxNetworkBytes)},
+			},
+		},
+		"NetworkOut": {
+			Datapoints: []*cloudwatch.Datapoint{
+				{Maximum: testutils.Float64Pointer(0)},
+			},
+		},
+	}
+}
+
+func TestGetEC2RightsizingCandidates(t *testing.T) {
+
+	testCases := []struct {
+		instanceType       string
+		allowGraviton      bool
+		expectedCandidates []string
+	}{
+		{"m5.large", true, []string{"m5.nano", "m5.micro", "m5.small", "m5.medium", "m6g.nano", "m6g.micro", "m6g.small", "m6g.medium", "m6g.large"}},
+		{"m5.large", false, []string{"m5.nano", "m5.micro", "m5.small", "m5.medium"}},
+		{"m6g.micro", true, []string{"m6g.nano"}},
+		{"m5.metal", true, []string{}},
+		{"invalid", true, []string{}},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.instanceType, func(t *testing.T) {
+			candidates := getEC2RightsizingCandidates(test.instanceType, test.allowGraviton)
+			if !reflect.DeepEqual(candidates, test.expectedCandidates) {
+				t.Fatalf("unexpected rightsizing candidates, got %v expected %v", candidates, test.expectedCandidates)
+			}
+		})
+	}
+}
+
+func TestEC2Recommendation(t *testing.T) {
+
+	instanceTypes := ec2.DescribeInstanceTypesOutput{
+		InstanceTypes: []*ec2.InstanceTypeInfo{
+			newEC2InstanceTypeInfo("m5.2xlarge", 8, 2.5),
+			newEC2InstanceTypeInfo("m5.xlarge", 4, 1.25),
+			newEC2InstanceTypeInfo("m5.large", 2, 0.75),
+			newEC2InstanceTypeInfo("m6g.xlarge", 4, 1.25),
+			newEC2InstanceTypeInfo("m6g.large", 2, 0.75),
+		},
+	}
+
+	prices := map[string]float64{
+		"m5.2xlarge": 0.384,
+		"m5.xlarge":  0.192,
+		"m5.large":   0.096,
+		"m6g.xlarge": 0.154,
+		"m6g.large":  0.077,
+	}
+
+	// 0.8 Gbps of network traffic in a single 5 minutes sample
+	networkSample := 0.8 * 1e9 / 8 * ec2RightsizingSamplePeriod.Seconds()
+
+	// The same sample covers a single minute with detailed monitoring, which is 4 Gbps
+	detailedMonitoring := &ec2.Monitoring{State: awsClient.String(ec2.MonitoringStateEnabled)}
+
+	testCases := []struct {
+		name                string
+		maxCPU              float64
+		maxNetworkBytes     float64
+		platform            *string
+		monitoring          *ec2.Monitoring
+		expectedType        string
+		expectedSavingMonth float64
+	}{
+		{"graviton", 10, 0, nil, nil, "m6g.large", (0.384 - 0.077) * 730},
+		{"cpu bound", 30, 0, nil, nil, "m6g.xlarge", (0.384 - 0.154) * 730},
+		{"network bound", 10, networkSample, nil, nil, "m6g.xlarge", (0.384 - 0.154) * 730},
+		{"detailed monitoring network bound", 10, networkSample, nil, detailedMonitoring, "", 0},
+		{"windows", 10, 0, awsClient.String("windows"), nil, "m5.large", (0.384 - 0.096) * 730},
+		{"no recommendation", 90, 0, nil, nil, "", 0},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+
+			cloudWatchMetrics := newEC2RightsizingCloudwatchMetrics(test.maxCPU, test.maxNetworkBytes)
+
+			collector := collectorTestutils.NewMockCollector()
+			mockCloudwatch := awsTestutils.NewMockCloudwatch(&cloudWatchMetrics)
+			mockPrice := pricing.NewPricingManager(&MockFilterPricingClient{prices: prices}, "us-east-1")
+			detector := awsTestutils.AWSManager(collector, mockCloudwatch, mockPrice, "us-east-1")
+
+			mockClient := MockAWSEC2Client{
+				responseDescribeInstances: ec2.DescribeInstancesOutput{
+					Reservations: []*ec2.Reservation{
+						{
+							Instances: []*ec2.Instance{
+								{
+									InstanceId:   awsClient.String("i-1"),
+									InstanceType: awsClient.String("m5.2xlarge"),
+									Platform:     test.platform,
+									Monitoring:   test.monitoring,
+									LaunchTime:   testutils.TimePointer(time.Now()),
+								},
+							},
+						},
+					},
+				},
+				responseDescribeInstanceTypes: instanceTypes,
+			}
+
+			ec2Manager, err := NewEC2Manager(detector, &mockClient)
+			if err != nil {
+				t.Fatalf("unexpected ec2 manager error happened, got %v expected %v", err, nil)
+			}
+
+			response, err := ec2Manager.Detect(awsTestutils.DefaultMetricConfig)
+			if err != nil {
+				t.Fatalf("unexpected ec2 detection error happened, got %v expected %v", err, nil)
+			}
+
+			ec2Response := response.([]DetectedEC2)
+			if len(ec2Response) != 1 {
+				t.Fatalf("unexpected ec2 detected, got %d expected %d", len(ec2Response), 1)
+			}
+
+			recommendation := ec2Response[0].EC2Recommendation
+			if recommendation.RecommendedType != test.expectedType {
+				t.Fatalf("unexpected recommended type, got %s expected %s", recommendation.RecommendedType, test.expectedType)
+			}
+
+			if fmt.Sprintf("%.2f", recommendation.RecommendedSavingPerMonth) != fmt.Sprintf("%.2f", test.expectedSavingMonth) {
+				t.Fatalf("unexpected recommended saving per month, got %f expected %f", recommendation.RecommendedSavingPerMonth, test.expectedSavingMonth)
+			}
+		})
+	}
+}
+
+func TestEC2RecommendationCache(t *testing.T) {
+
+	cloudWatchMetrics := newEC2RightsizingCloudwatchMetrics(10, 0)
+	pricingClient := &MockFilterPricingClient{prices: map[string]float64{
+		"m5.xlarge": 0.192,
+		"m5.large":  0.096,
+	}}
+
+	collector := collectorTestutils.NewMockCollector()
+	mockCloudwatch := awsTestutils.NewMockCloudwatch(&cloudWatchMetrics)
+	mockPrice := pricing.NewPricingManager(pricingClient, "us-east-1")
+	detector := awsTestutils.AWSManager(collector, mockCloudwatch, mockPrice, "us-east-1")
+
+	instances := []*ec2.Instance{}
+	for _, instanceID := range []string{"i-1", "i-2", "i-3"} {
+		instances = append(instances, &ec2.Instance{
+			InstanceId:   awsClient.String(instanceID),
+			InstanceType: awsClient.String("m5.xlarge"),
+			Platform:     awsClient.String("windows"),
+			LaunchTime:   testutils.TimePointer(time.Now()),
+		})
+	}
+
+	mockClient := MockAWSEC2Client{
+		responseDescribeInstances: ec2.DescribeInstancesOutput{
+			Reservations: []*ec2.Reservation{{Instances: instances}},
+		},
+		responseDescribeInstanceTypes: ec2.DescribeInstanceTypesOutput{
+			InstanceTypes: []*ec2.InstanceTypeInfo{
+				newEC2InstanceTypeInfo("m5.xlarge", 4, 1.25),
+				newEC2InstanceTypeInfo("m5.large", 2, 0.75),
+			},
+		},
+	}
+
+	ec2Manager, err := NewEC2Manager(detector, &mockClient)
+	if err != nil {
+		t.Fatalf("unexpected ec2 manager error happened, got %v expected %v", err, nil)
+	}
+
+	response, err := ec2Manager.Detect(awsTestutils.DefaultMetricConfig)
+	if err != nil {
+		t.Fatalf("unexpected ec2 detection error happened, got %v expected %v", err, nil)
+	}
+
+	for _, detected := range response.([]DetectedEC2) {
+		if detected.RecommendedType != "m5.large" {
+			t.Fatalf("unexpected recommended type, got %s expected %s", detected.RecommendedType, "m5.large")
+		}
+	}
+
+	// The instance types of the same instance type are described once for the whole detection
+	if mockClient.describeInstanceTypesCalls != 1 {
+		t.Fatalf("unexpected describe instance types calls, got %d expected %d", mockClient.describeInstanceTypesCalls, 1)
+	}
+
+	if len(ec2Manager.(*EC2Manager).prices) != 1 {
+		t.Fatalf("unexpected cached prices, got %d expected %d", len(ec2Manager.(*EC2Manager).prices), 1)
+	}
+}
@@ -0,0 +1,401 @@
+package resources
+
+import (
+	"errors"
+	"finala/collector"
+	"finala/collector/aws/common"
+	"finala/collector/aws/register"
+	"finala/collector/config"
+	"finala/expression"
+	"finala/interpolation"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	awsClient "github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/pricing"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// ec2StoppedDescribeVolumesLimit is the maximum instance ids of a single volumes attachment filter
+	ec2StoppedDescribeVolumesLimit = 200
+
+	// ec2StateTransitionTimeLayout is the time layout of the instance state transition reason
+	ec2StateTransitionTimeLayout = "2006-01-02 15:04:05 MST"
+)
+
+// ec2StateTransitionTimeRegexp extracts the time of a state transition reason, e.g. "User initiated (2024-01-01 10:00:00 GMT)"
+var ec2StateTransitionTimeRegexp = regexp.MustCompile(`\((\d{4}-\d{2}-\d{2} \d{2}:\d{2}:\d{2} [A-Z]+)\)`)
+
+// EC2StoppedClientDescreptor is an interface defining the aws ec2 client
+type EC2StoppedClientDescreptor interface {
+	DescribeInstances(*ec2.DescribeInstancesInput) (*ec2.DescribeInstancesOutput, error)
+	DescribeVolumes(*ec2.DescribeVolumesInput) (*ec2.DescribeVolumesOutput, error)
+	DescribeAddresses(*ec2.DescribeAddressesInput) (*ec2.DescribeAddressesOutput, error)
+}
+
+// EC2StoppedManager describes the stopped EC2 instances struct
+type EC2StoppedManager struct {
+	client           EC2StoppedClientDescreptor
+	awsManager       common.AWSManager
+	volumeManager    *EC2VolumeManager
+	elasticIPManager *ElasticIPManager
+	Name             collector.ResourceIdentifier
+}
+
+// DetectedStoppedEC2 defines the detected stopped AWS EC2 instances.
+// The price fields are the attached volumes and associated elastic ips charges, not the compute price.
+type DetectedStoppedEC2 struct {
+	Metric                  string
+	Name                    string
+	InstanceType            string
+	StoppedTime             time.Time
+	StoppedDays             float64
+	VolumeIDs               []string
+	ElasticIPs              []string
+	VolumesPricePerMonth    float64
+	ElasticIPsPricePerMonth float64
+	collector.PriceDetectedFields
+}
+
+func init() {
+	register.Registry("ec2_stopped", NewEC2StoppedManager)
+}
+
+// NewEC2StoppedManager implements AWS GO SDK
+func NewEC2StoppedManager(awsManager common.AWSManager, client interface{}) (common.ResourceDetection, error) {
+
+	if client == nil {
+		client = ec2.New(awsManager.GetSession())
+	}
+
+	ec2Client, ok := client.(EC2StoppedClientDescreptor)
+	if !ok {
+		return nil, errors.New("invalid ec2 stopped instances client")
+	}
+
+	return &EC2StoppedManager{
+		client:     ec2Client,
+		awsManager: awsManager,
+		volumeManager: &EC2VolumeManager{
+			awsManager:         awsManager,
+			servicePricingCode: "AmazonEC2",
+		},
+		elasticIPManager: &ElasticIPManager{
+			awsManager:         awsManager,
+			servicePricingCode: "AmazonVPC",
+			rateCode:           "6YS6EN2CT7",
+		},
+		Name: awsManager.GetResourceIdentifier("ec2_stopped"),
+	}, nil
+}
+
+// Detect EC2 instances which are stopped for longer than the metric constraint days
+func (es *EC2StoppedManager) Detect(metrics []config.MetricConfig) (interface{}, error) {
+
+	// This resource support only one metric
+	metric := metrics[0]
+
+	log.WithFields(log.Fields{
+		"region":   es.awsManager.GetRegion(),
+		"resource": "ec2_stopped_instances",
+	}).Info("starting to analyze resource")
+
+	es.awsManager.GetCollector().CollectStart(es.Name)
+
+	detected := []DetectedStoppedEC2{}
+
+	instances, err := es.describeInstances(nil, nil)
+	if err != nil {
+		es.awsManager.GetCollector().CollectError(es.Name, err)
+		return detected, err
+	}
+
+	// Every stopped instance is priced for the inventory, only the instances stopped for long enough are detected
+	now := time.Now()
+	stoppedInstances := map[string]*ec2.Instance{}
+	stoppedTimes := map[string]time.Time{}
+	instanceIDs := []*string{}
+	for _, instance := range instances {
+		stoppedInstances[*instance.InstanceId] = instance
+		instanceIDs = append(instanceIDs, instance.InstanceId)
+
+		stoppedTime, ok := getEC2StateTransitionTime(awsClient.StringValue(instance.StateTransitionReason))
+		if !ok {
+			log.WithFields(log.Fields{
+				"instance_id":             *instance.InstanceId,
+				"state_transition_reason": awsClient.StringValue(instance.StateTransitionReason),
+			}).Debug("could not parse the instance state transition time")
+			continue
+		}
+
+		stoppedDays := now.Sub(stoppedTime).Hours() / 24
+		expression, err := expression.BoolExpression(stoppedDays, metric.Constraint.Value, metric.Constraint.Operator)
+		if err != nil || !expression {
+			continue
+		}
+
+		stoppedTimes[*instance.InstanceId] = stoppedTime
+	}
+
+	if len(instanceIDs) == 0 {
+		es.awsManager.GetCollector().CollectFinish(es.Name)
+		return detected, nil
+	}
+
+	volumes, err := es.describeAttachedVolumes(instanceIDs)
+	if err != nil {
+		es.awsManager.GetCollector().CollectError(es.Name, err)
+		return detected, err
+	}
+
+	addresses, err := es.describeAddresses()
+	if err != nil {
+		es.awsManager.GetCollector().CollectError(es.Name, err)
+		return detected, err
+	}
+
+	instancesVolumes := map[string][]*ec2.Volume{}
+	for _, volume := range volumes {
+		for _, attachment := range volume.Attachments {
+			instanceID := awsClient.StringValue(attachment.InstanceId)
+			if _, found := stoppedInstances[instanceID]; found {
+				instancesVolumes[instanceID] = append(instancesVolumes[instanceID], volume)
+			}
+		}
+	}
+
+	instancesAddresses := map[string][]*ec2.Address{}
+	for _, address := range addresses {
+		instanceID := awsClient.StringValue(address.InstanceId)
+		if _, found := stoppedInstances[instanceID]; found {
+			instancesAddresses[instanceID] = append(instancesAddresses[instanceID], address)
+		}
+	}
+
+	var elasticIPPrice float64
+	if len(instancesAddresses) > 0 {
+		elasticIPPrice, err = es.awsManager.GetPricingClient().GetPrice(es.elasticIPManager.getPricingFilterInput(), es.elasticIPManager.rateCode, es.awsManager.GetRegion())
+		if err != nil {
+			log.WithError(err).Error("could not get elastic ip price")
+		}
+	}
+
+	for _, instanceID := range instanceIDs {
+		instance := stoppedInstances[*instanceID]
+
+		var volumesPricePerMonth float64
+		volumeIDs := []string{}
+		for _, volume := range instancesVolumes[*instanceID] {
+			volumeIDs = append(volumeIDs, *volume.VolumeId)
+			volumesPricePerMonth += es.getVolumePrice(volume)
+		}
+
+		elasticIPs := []string{}
+		for _, address := range instancesAddresses[*instanceID] {
+			elasticIPs = append(elasticIPs, awsClient.StringValue(address.PublicIp))
+		}
+		elasticIPsPricePerMonth := elasticIPPrice * float64(len(elasticIPs)) * collector.TotalMonthHours
+
+		var name string
+		tagsData := map[string]string{}
+		for _, tag := range instance.Tags {
+			tagsData[*tag.Key] = *tag.Value
+			if strings.ToLower(*tag.Key) == "name" {
+				name = *tag.Value
+			}
+		}
+
+		pricePerMonth := volumesPricePerMonth + elasticIPsPricePerMonth
+
+		es.awsManager.ExamineResource(es.Name, func() collector.PriceDetectedFields {
+			return collector.PriceDetectedFields{
+				ResourceID:    *instance.InstanceId,
+				LaunchTime:    awsClient.TimeValue(instance.LaunchTime),
+				PricePerHour:  pricePerMonth / collector.TotalMonthHours,
+				PricePerMonth: pricePerMonth,
+				Tag:           tagsData,
+			}
+		})
+
+		stoppedTime, found := stoppedTimes[*instanceID]
+		if !found {
+			continue
+		}
+
+		log.WithFields(log.Fields{
+			"instance_id":     *instance.InstanceId,
+			"stopped_time":    stoppedTime,
+			"price_per_month": pricePerMonth,
+			"region":          es.awsManager.GetRegion(),
+		}).Info("EC2 instance detected as stopped resource")
+
+		stoppedEC2 := DetectedStoppedEC2{
+			Metric:                  metric.Description,
+			Name:                    name,
+			InstanceType:            *instance.InstanceType,
+			StoppedTime:             stoppedTime,
+			StoppedDays:             now.Sub(stoppedTime).Hours() / 24,
+			VolumeIDs:               volumeIDs,
+			ElasticIPs:              elasticIPs,
+			VolumesPricePerMonth:    volumesPricePerMonth,
+			ElasticIPsPricePerMonth: elasticIPsPricePerMonth,
+			PriceDetectedFields: collector.PriceDetectedFields{
+				ResourceID:        *instance.InstanceId,
+				ARN:               es.awsManager.GetARN("ec2", fmt.Sprintf("instance/%s", *instance.InstanceId)),
+				ConsoleURL:        es.awsManager.GetConsoleURL("ec2/home", fmt.Sprintf("InstanceDetails:instanceId=%s", *instance.InstanceId)),
+				LaunchTime:        awsClient.TimeValue(instance.LaunchTime),
+				PricePerHour:      pricePerMonth / collector.TotalMonthHours,
+				PricePerMonth:     pricePerMonth,
+				Tag:               tagsData,
+				AccountDimensions: es.awsManager.GetAccountDimensions(),
+			},
+		}
+
+		es.awsManager.GetCollector().AddResource(collector.EventCollector{
+			ResourceName: es.Name,
+			Data:         stoppedEC2,
+		})
+
+		detected = append(detected, stoppedEC2)
+	}
+
+	es.awsManager.GetCollector().CollectFinish(es.Name)
+
+	return detected, nil
+}
+
+// getVolumePrice returns the monthly price of the given volume
+func (es *EC2StoppedManager) getVolumePrice(volume *ec2.Volume) float64 {
+
+	filters := []*pricing.Filter{
+		{
+			Type:  awsClient.String("TERM_MATCH"),
+			Field: awsClient.String("productFamily"),
+			Value: awsClient.String("Storage"),
+		},
+	}
+
+	price, err := es.awsManager.GetPricingClient().GetPrice(es.volumeManager.getBasePricingFilterInput(volume, filters), "", es.awsManager.GetRegion())
+	if err != nil {
+		log.WithError(err).WithField("volume_id", *volume.VolumeId).Error("Error when trying to get volume price")
+		return 0
+	}
+
+	return es.volumeManager.getCalculatedPrice(volume, price)
+}
+
+// getEC2StateTransitionTime returns the time of the instance state transition reason
+func getEC2StateTransitionTime(stateTransitionReason string) (time.Time, bool) {
+
+	match := ec2StateTransitionTimeRegexp.FindStringSubmatch(stateTransitionReason)
+	if len(match) != 2 {
+		return time.Time{}, false
+	}
+
+	transitionTime, err := time.Parse(ec2StateTransitionTimeLayout, match[1])
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	return transitionTime, true
+}
+
+// describeInstances returns a list of the stopped instances
+func (es *EC2StoppedManager) describeInstances(nextToken *string, instances []*ec2.Instance) ([]*ec2.Instance, error) {
+
+	input := &ec2.DescribeInstancesInput{
+		NextToken: nextToken,
+		Filters: []*ec2.Filter{
+			{
+				Name:   awsClient.String("instance-state-name"),
+				Values: []*string{awsClient.String("stopped")},
+			},
+		},
+	}
+
+	resp, err := es.client.DescribeInstances(input)
+	if err != nil {
+		log.WithField("error", err).Error("could not describe ec2 instances")
+		return nil, err
+	}
+
+	if instances == nil {
+		instances = []*ec2.Instance{}
+	}
+
+	for _, reservations := range resp.Reservations {
+		instances = append(instances, reservations.Instances...)
+	}
+
+	if resp.NextToken != nil {
+		return es.describeInstances(resp.NextToken, instances)
+	}
+
+	return instances, nil
+}
+
+// describeAttachedVolumes returns the volumes attached to the given instances
+func (es *EC2StoppedManager) describeAttachedVolumes(instanceIDs []*string) ([]*ec2.Volume, error) {
+
+	volumes := []*ec2.Volume{}
+	nextBatch := interpolation.ChunkIterator(instanceIDs, ec2StoppedDescribeVolumesLimit)
+	for batch := nextBatch(); batch != nil; batch = nextBatch() {
+		batchVolumes, err := es.describeVolumes(batch, nil, nil)
+		if err != nil {
+			return nil, err
+		}
+		volumes = append(volumes, batchVolumes...)
+	}
+
+	return volumes, nil
+}
+
+// describeVolumes returns the volumes attached to the given instances batch
+func (es *EC2StoppedManager) describeVolumes(instanceIDs []*string, nextToken *string, volumes []*ec2.Volume) ([]*ec2.Volume, error) {
+
+	input := &ec2.DescribeVolumesInput{
+		NextToken: nextToken,
+		Filters: []*ec2.Filter{
+			{
+				Name:   awsClient.String("attachment.instance-id"),
+				Values: instanceIDs,
+			},
+		},
+	}
+
+	resp, err := es.client.DescribeVolumes(input)
+	if err != nil {
+		log.WithField("error", err).Error("could not describe ec2 volumes")
+		return nil, err
+	}
+
+	if volumes == nil {
+		volumes = []*ec2.Volume{}
+	}
+
+	volumes = append(volumes, resp.Volumes...)
+
+	if resp.NextToken != nil {
+		return es.describeVolumes(instanceIDs, resp.NextToken, volumes)
+	}
+
+	return volumes, nil
+}
+
+// describeAddresses returns the elastic ips which are associated to an instance
+func (es *EC2StoppedManager) describeAddresses() ([]*ec2.Address, error) {
+
+	input := &ec2.DescribeAddressesInput{}
+
+	resp, err := es.client.DescribeAddresses(input)
+	if err != nil {
+		log.WithField("error", err).Error("could not describe elastic ips addresses")
+		return nil, err
+	}
+
+	return resp.Addresses, nil
+}
@@ -0,0 +1,230 @@
+package resources
+
+import (
+	"errors"
+	"finala/collector/aws/pricing"
+	awsTestutils "finala/collector/aws/testutils"
+	"finala/collector/config"
+	collectorTestutils "finala/collector/testutils"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	awsClient "github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+)
+
+// newEC2StoppedTransitionReason returns a stop transition reason of the given days ago
+func newEC2StoppedTransitionReason(days int) *string {
+	stoppedTime := time.Now().UTC().Add(-time.Duration(days) * 24 * time.Hour)
+	return awsClient.String(fmt.Sprintf("User initiated (%s GMT)", stoppedTime.Format("2006-01-02 15:04:05")))
+}
+
+var defaultStoppedInstancesMock = ec2.DescribeInstancesOutput{
+	Reservations: []*ec2.Reservation{
+		{
+			Instances: []*ec2.Instance{
+				{
+					InstanceId:            awsClient.String("i-stopped"),
+					InstanceType:          awsClient.String("m5.large"),
+					StateTransitionReason: newEC2StoppedTransitionReason(45),
+					Tags: []*ec2.Tag{
+						{Key: awsClient.String("Name"), Value: awsClient.String("old-instance")},
+					},
+				},
+				{
+					InstanceId:            awsClient.String("i-recently-stopped"),
+					InstanceType:          awsClient.String("m5.large"),
+					StateTransitionReason: newEC2StoppedTransitionReason(3),
+				},
+				{
+					InstanceId:            awsClient.String("i-unknown"),
+					InstanceType:          awsClient.String("m5.large"),
+					StateTransitionReason: awsClient.String(""),
+				},
+			},
+		},
+	},
+}
+
+var defaultStoppedVolumesMock = ec2.DescribeVolumesOutput{
+	Volumes: []*ec2.Volume{
+		{
+			VolumeId:   awsClient.String("vol-1"),
+			VolumeType: awsClient.String("gp3"),
+			Size:       awsClient.Int64(100),
+			Iops:       awsClient.Int64(3000),
+			Throughput: awsClient.Int64(125),
+			Attachments: []*ec2.VolumeAttachment{
+				{InstanceId: awsClient.String("i-stopped")},
+			},
+		},
+		{
+			VolumeId:   awsClient.String("vol-2"),
+			VolumeType: awsClient.String("gp2"),
+			Size:       awsClient.Int64(50),
+			Attachments: []*ec2.VolumeAttachment{
+				{InstanceId: awsClient.String("i-stopped")},
+			},
+		},
+	},
+}
+
+var defaultStoppedAddressesMock = ec2.DescribeAddressesOutput{
+	Addresses: []*ec2.Address{
+		{
+			PublicIp:   awsClient.String("1.1.1.1"),
+			InstanceId: awsClient.String("i-stopped"),
+		},
+		{
+			PublicIp: awsClient.String("2.2.2.2"),
+		},
+	},
+}
+
+type MockAWSEC2StoppedClient struct {
+	err error
+}
+
+func (r *MockAWSEC2StoppedClient) DescribeInstances(*ec2.DescribeInstancesInput) (*ec2.DescribeInstancesOutput, error) {
+	return &defaultStoppedInstancesMock, r.err
+}
+
+func (r *MockAWSEC2StoppedClient) DescribeVolumes(*ec2.DescribeVolumesInput) (*ec2.DescribeVolumesOutput, error) {
+	return &defaultStoppedVolumesMock, r.err
+}
+
+func (r *MockAWSEC2StoppedClient) DescribeAddresses(*ec2.DescribeAddressesInput) (*ec2.DescribeAddressesOutput, error) {
+	return &defaultStoppedAddressesMock, r.err
+}
+
+func TestNewEC2StoppedManager(t *testing.T) {
+
+	collector := collectorTestutils.NewMockCollector()
+	detector := awsTestutils.AWSManager(collector, nil, nil, "us-east-1")
+
+	stoppedManager, err := NewEC2StoppedManager(detector, &MockEmptyClient{})
+	if err == nil {
+		t.Fatalf("unexpected error happened, got nil expected error")
+	}
+	if stoppedManager != nil {
+		t.Fatalf("unexpected ec2 stopped manager instance, got %v expected nil", reflect.TypeOf(stoppedManager))
+	}
+}
+
+func TestGetEC2StateTransitionTime(t *testing.T) {
+
+	testCases := []struct {
+		reason        string
+		expectedTime  time.Time
+		expectedFound bool
+	}{
+		{"User initiated (2024-01-02 10:20:30 GMT)", time.Date(2024, 1, 2, 10, 20, 30, 0, time.UTC), true},
+		{"Server.ScheduledStop (2023-12-31 00:00:00 GMT)", time.Date(2023, 12, 31, 0, 0, 0, 0, time.UTC), true},
+		{"User initiated", time.Time{}, false},
+		{"", time.Time{}, false},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.reason, func(t *testing.T) {
+			transitionTime, found := getEC2StateTransitionTime(test.reason)
+			if found != test.expectedFound || !transitionTime.Equal(test.expectedTime) {
+				t.Fatalf("unexpected state transition time, got %v/%t expected %v/%t", transitionTime, found, test.expectedTime, test.expectedFound)
+			}
+		})
+	}
+}
+
+func TestDetectEC2Stopped(t *testing.T) {
+
+	metrics := []config.MetricConfig{
+		{
+			Description: "Stopped days",
+			Enable:      true,
+			Constraint: config.MetricConstraintConfig{
+				Operator: ">=",
+				Value:    30,
+			},
+		},
+	}
+
+	t.Run("detect", func(t *testing.T) {
+
+		collector := collectorTestutils.NewMockCollector()
+		mockPrice := pricing.NewPricingManager(&MockFilterPricingClient{prices: map[string]float64{
+			"gp3":                    0.08,
+			"gp2":                    0.1,
+			"PublicIPv4:IdleAddress": 0.005,
+		}}, "us-east-1")
+		detector := awsTestutils.AWSManager(collector, nil, mockPrice, "us-east-1")
+
+		stoppedManager, err := NewEC2StoppedManager(detector, &MockAWSEC2StoppedClient{})
+		if err != nil {
+			t.Fatalf("unexpected ec2 stopped manager error happened, got %v expected %v", err, nil)
+		}
+
+		response, err := stoppedManager.Detect(metrics)
+		if err != nil {
+			t.Fatalf("unexpected ec2 stopped detection error happened, got %v expected %v", err, nil)
+		}
+
+		stoppedResponse, ok := response.([]DetectedStoppedEC2)
+		if !ok {
+			t.Fatalf("unexpected ec2 stopped struct, got %s expected %s", reflect.TypeOf(response), "[]DetectedStoppedEC2")
+		}
+
+		if len(stoppedResponse) != 1 {
+			t.Fatalf("unexpected ec2 stopped detected, got %d expected %d", len(stoppedResponse), 1)
+		}
+
+		if len(collector.Events) != 1 {
+			t.Fatalf("unexpected collector ec2 stopped events, got %d expected %d", len(collector.Events), 1)
+		}
+
+		// Every stopped instance is examined, also the instances which are not stopped for long enough
+		if len(detector.ExaminedResources["aws_ec2_stopped"]) != 3 {
+			t.Fatalf("unexpected examined ec2 stopped instances, got %d expected %d", len(detector.ExaminedResources["aws_ec2_stopped"]), 3)
+		}
+
+		stopped := stoppedResponse[0]
+		if stopped.ResourceID != "i-stopped" || stopped.Name != "old-instance" {
+			t.Fatalf("unexpected detected instance, got %s/%s expected %s/%s", stopped.ResourceID, stopped.Name, "i-stopped", "old-instance")
+		}
+
+		if !reflect.DeepEqual(stopped.VolumeIDs, []string{"vol-1", "vol-2"}) {
+			t.Fatalf("unexpected volume ids, got %v expected %v", stopped.VolumeIDs, []string{"vol-1", "vol-2"})
+		}
+
+		if !reflect.DeepEqual(stopped.ElasticIPs, []string{"1.1.1.1"}) {
+			t.Fatalf("unexpected elastic ips, got %v expected %v", stopped.ElasticIPs, []string{"1.1.1.1"})
+		}
+
+		expectedVolumesPrice := 100*0.08 + 50*0.1
+		expectedElasticIPsPrice := 0.005 * 730
+		if fmt.Sprintf("%.2f", stopped.PricePerMonth) != fmt.Sprintf("%.2f", expectedVolumesPrice+expectedElasticIPsPrice) {
+			t.Fatalf("unexpected price per month, got %f expected %f", stopped.PricePerMonth, expectedVolumesPrice+expectedElasticIPsPrice)
+		}
+
+		if fmt.Sprintf("%.2f", stopped.ElasticIPsPricePerMonth) != fmt.Sprintf("%.2f", expectedElasticIPsPrice) {
+			t.Fatalf("unexpected elastic ips price per month, got %f expected %f", stopped.ElasticIPsPricePerMonth, expectedElasticIPsPrice)
+		}
+	})
+
+	t.Run("detection error", func(t *testing.T) {
+
+		collector := collectorTestutils.NewMockCollector()
+		mockPrice := awsTestutils.NewMockPricing(nil)
+		detector := awsTestutils.AWSManager(collector, nil, mockPrice, "us-east-1")
+
+		stoppedManager, err := NewEC2StoppedManager(detector, &MockAWSEC2StoppedClient{err: errors.New("error")})
+		if err != nil {
+			t.Fatalf("unexpected ec2 stopped manager error happened, got %v expected %v", err, nil)
+		}
+
+		_, err = stoppedManager.Detect(metrics)
+		if err == nil {
+			t.Fatalf("unexpected detection ec2 stopped manager error, got nil expected error message")
+		}
+	})
+}
@@ -6,6 +6,7 @@ import (
 	"finala/collector/aws/common"
 	"finala/collector/aws/register"
 	"finala/collector/config"
+	"fmt"
 
 	awsClient "github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/ec2"
@@ -13,6 +14,14 @@ import (
 	log "github.com/sirupsen/logrus"
 )
 
+const (
+	// gp3BaselineIOPS is the IOPS included in the gp3 storage price
+	gp3BaselineIOPS = 3000
+
+	// gp3BaselineThroughput is the throughput (MiB/s) included in the gp3 storage price
+	gp3BaselineThroughput = 125
+)
+
 // EC2VolumeClientDescriptor is an interface defining the AWS EC2
 type EC2VolumeClientDescriptor interface {
 	DescribeVolumes(input *ec2.DescribeVolumesInput) (*ec2.DescribeVolumesOutput, error)
@@ -30,12 +39,14 @@ type EC2VolumeManager struct {
 // DetectedAWSEC2Volume define the detected volume data
 type DetectedAWSEC2Volume struct {
 	Metric        string
-	Region        string
 	ResourceID    string
+	ARN           string
+	ConsoleURL    string
 	Type          string
 	Size          int64
 	PricePerMonth float64
 	Tag           map[string]string
+	collector.AccountDimensions
 }
 
 func init() {
@@ -116,14 +127,28 @@ func (ev *EC2VolumeManager) Detect(metrics []config.MetricConfig) (interface{},
 		}
 
 		volumeSize := *vol.Size
+		pricePerMonth := ev.getCalculatedPrice(vol, price)
+
+		ev.awsManager.ExamineResource(ev.Name, func() collector.PriceDetectedFields {
+			return collector.PriceDetectedFields{
+				ResourceID:    *vol.VolumeId,
+				LaunchTime:    awsClient.TimeValue(vol.CreateTime),
+				PricePerHour:  pricePerMonth / collector.TotalMonthHours,
+				PricePerMonth: pricePerMonth,
+				Tag:           tagsData,
+			}
+		})
+
 		dEBS := DetectedAWSEC2Volume{
-			Region:        ev.awsManager.GetRegion(),
-			Metric:        metric.Description,
-			ResourceID:    *vol.VolumeId,
-			Type:          *vol.VolumeType,
-			Size:          volumeSize,
-			PricePerMonth: ev.getCalculatedPrice(vol, price),
-			Tag:           tagsData,
+			Metric:            metric.Description,
+			ResourceID:        *vol.VolumeId,
+			ARN:               ev.awsManager.GetARN("ec2", fmt.Sprintf("volume/%s", *vol.VolumeId)),
+			ConsoleURL:        ev.awsManager.GetConsoleURL("ec2/home", fmt.Sprintf("VolumeDetails:volumeId=%s", *vol.VolumeId)),
+			Type:              *vol.VolumeType,
+			Size:              volumeSize,
+			PricePerMonth:     pricePerMonth,
+			Tag:               tagsData,
+			AccountDimensions: ev.awsManager.GetAccountDimensions(),
 		}
 
 		ev.awsManager.GetCollector().AddResource(collector.EventCollector{
@@ -161,12 +186,50 @@ func (ev *EC2VolumeManager) getCalculatedPrice(vol *ec2.Volume, basePrice float6
 			iopsPrice = 0
 		}
 		return basePrice*float64(volumeSize) + iopsPrice*float64(*vol.Iops)
+	case "gp3":
+		// For gp3 we need to add the IOPs and throughput provisioned above the baseline to the price (https://aws.amazon.com/ebs/pricing).
+		price := basePrice * float64(volumeSize)
+		if iops := awsClient.Int64Value(vol.Iops); iops > gp3BaselineIOPS {
+			price += ev.getProvisionedPerformancePrice(vol, "EBS:VolumeP-IOPS.gp3") * float64(iops-gp3BaselineIOPS)
+		}
+		if throughput := awsClient.Int64Value(vol.Throughput); throughput > gp3BaselineThroughput {
+			price += ev.getProvisionedPerformancePrice(vol, "EBS:VolumeP-Throughput.gp3") * float64(throughput-gp3BaselineThroughput)
+		}
+		return price
 	default:
 		return basePrice * float64(volumeSize)
 	}
 
 }
 
+// getProvisionedPerformancePrice returns the price of a single provisioned performance unit of the given usage type
+func (ev *EC2VolumeManager) getProvisionedPerformancePrice(vol *ec2.Volume, usageType string) float64 {
+
+	pricingRegionPrefix, err := ev.awsManager.GetPricingClient().GetRegionPrefix(ev.awsManager.GetRegion())
+	if err != nil {
+		return 0
+	}
+
+	extraFilter := []*pricing.Filter{
+		{
+			Type:  awsClient.String("TERM_MATCH"),
+			Field: awsClient.String("usagetype"),
+			Value: awsClient.String(fmt.Sprintf("%s%s", pricingRegionPrefix, usageType)),
+		},
+	}
+
+	price, err := ev.awsManager.GetPricingClient().GetPrice(ev.getBasePricingFilterInput(vol, extraFilter), "", ev.awsManager.GetRegion())
+	if err != nil {
+		log.WithError(err).WithFields(log.Fields{
+			"volume_type": *vol.VolumeType,
+			"usage_type":  usageType,
+		}).Error("Error when trying to get volume provisioned performance price")
+		return 0
+	}
+
+	return price
+}
+
 // getBasePricingFilterInput set the pricing product filters
 func (ev *EC2VolumeManager) getBasePricingFilterInput(vol *ec2.Volume, extraFilters []*pricing.Filter) pricing.GetProductsInput {
 
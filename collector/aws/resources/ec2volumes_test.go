@@ -166,3 +166,37 @@ func TestDetectVolumes(t *testing.T) {
 	}
 
 }
+
+func TestVolumesCalculatedPrice(t *testing.T) {
+
+	collector := collectorTestutils.NewMockCollector()
+	mockPrice := awsTestutils.NewMockPricing(nil)
+	detector := awsTestutils.AWSManager(collector, nil, mockPrice, "us-east-1")
+
+	volume, err := NewVolumesManager(detector, &MockAWSVolumeClient{})
+	if err != nil {
+		t.Fatalf("unexpected ec2 volumes manager error happened, got %v expected %v", err, nil)
+	}
+
+	volumeManager := volume.(*EC2VolumeManager)
+
+	testCases := []struct {
+		name          string
+		volume        *ec2.Volume
+		expectedPrice float64
+	}{
+		{"gp2", &ec2.Volume{VolumeType: awsClient.String("gp2"), Size: awsClient.Int64(100), Iops: awsClient.Int64(300)}, 100},
+		{"io1", &ec2.Volume{VolumeType: awsClient.String("io1"), Size: awsClient.Int64(100), Iops: awsClient.Int64(300)}, 400},
+		{"gp3 baseline", &ec2.Volume{VolumeType: awsClient.String("gp3"), Size: awsClient.Int64(100), Iops: awsClient.Int64(3000), Throughput: awsClient.Int64(125)}, 100},
+		{"gp3 provisioned", &ec2.Volume{VolumeType: awsClient.String("gp3"), Size: awsClient.Int64(100), Iops: awsClient.Int64(4000), Throughput: awsClient.Int64(200)}, 1175},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			price := volumeManager.getCalculatedPrice(test.volume, 1)
+			if price != test.expectedPrice {
+				t.Fatalf("unexpected volume price, got %f expected %f", price, test.expectedPrice)
+			}
+		})
+	}
+}
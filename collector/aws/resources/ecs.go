@@ -0,0 +1,525 @@
+package resources
+
+import (
+	"errors"
+	"finala/collector"
+	"finala/collector/aws/common"
+	"finala/collector/aws/register"
+	"finala/collector/config"
+	"finala/expression"
+	"finala/interpolation"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	awsClient "github.com/aws/aws-sdk-go/aws"
+	awsCloudwatch "github.com/aws/aws-sdk-go/service/cloudwatch"
+	"github.com/aws/aws-sdk-go/service/ecs"
+	"github.com/aws/aws-sdk-go/service/pricing"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// ecsCapacityProviderMetric is the managed scaling metric of ecs capacity providers
+	ecsCapacityProviderMetric = "CapacityProviderReservation"
+
+	// ecsDescribeServicesLimit is the maximum services count of a single describe services call
+	ecsDescribeServicesLimit = 10
+
+	// ecsDescribeClustersLimit is the maximum clusters count of a single describe clusters call
+	ecsDescribeClustersLimit = 100
+
+	// ecsFargateLaunchType is the fargate launch type and capacity provider prefix
+	ecsFargateLaunchType = "FARGATE"
+)
+
+// ECSClientDescreptor is an interface defining the aws ecs client
+type ECSClientDescreptor interface {
+	ListClusters(*ecs.ListClustersInput) (*ecs.ListClustersOutput, error)
+	DescribeClusters(*ecs.DescribeClustersInput) (*ecs.DescribeClustersOutput, error)
+	ListServices(*ecs.ListServicesInput) (*ecs.ListServicesOutput, error)
+	DescribeServices(*ecs.DescribeServicesInput) (*ecs.DescribeServicesOutput, error)
+	DescribeTaskDefinition(*ecs.DescribeTaskDefinitionInput) (*ecs.DescribeTaskDefinitionOutput, error)
+}
+
+// ECSManager describes ECS struct
+type ECSManager struct {
+	client                    ECSClientDescreptor
+	awsManager                common.AWSManager
+	namespace                 string
+	capacityProviderNamespace string
+	servicePricingCode        string
+	Name                      collector.ResourceIdentifier
+}
+
+// DetectedECS defines the detected AWS ECS service or capacity provider.
+// ServiceName is empty when a cluster capacity provider was detected.
+type DetectedECS struct {
+	Metric           string
+	ClusterName      string
+	ServiceName      string
+	CapacityProvider string
+	LaunchType       string
+	DesiredCount     int64
+	CPU              float64
+	MemoryGB         float64
+	collector.PriceDetectedFields
+}
+
+// ecsFargatePrices holds the fargate hourly prices
+type ecsFargatePrices struct {
+	vCPU     float64
+	memoryGB float64
+}
+
+func init() {
+	register.Registry("ecs", NewECSManager)
+}
+
+// NewECSManager implements AWS GO SDK
+func NewECSManager(awsManager common.AWSManager, client interface{}) (common.ResourceDetection, error) {
+
+	if client == nil {
+		client = ecs.New(awsManager.GetSession())
+	}
+
+	ecsClient, ok := client.(ECSClientDescreptor)
+	if !ok {
+		return nil, errors.New("invalid ecs client")
+	}
+
+	return &ECSManager{
+		client:                    ecsClient,
+		awsManager:                awsManager,
+		namespace:                 "AWS/ECS",
+		capacityProviderNamespace: "AWS/ECS/ManagedScaling",
+		servicePricingCode:        "AmazonECS",
+		Name:                      awsManager.GetResourceIdentifier("ecs"),
+	}, nil
+}
+
+// Detect checks which ECS services and capacity providers are under utilized.
+// The CapacityProviderReservation metric is checked against the cluster capacity providers,
+// and all the other metrics against the services with a desired count above zero.
+func (ec *ECSManager) Detect(metrics []config.MetricConfig) (interface{}, error) {
+
+	log.WithFields(log.Fields{
+		"region":   ec.awsManager.GetRegion(),
+		"resource": "ecs",
+	}).Info("starting to analyze resource")
+
+	ec.awsManager.GetCollector().CollectStart(ec.Name)
+
+	detected := []DetectedECS{}
+
+	fargatePrices, err := ec.getFargatePrices()
+	if err != nil {
+		ec.awsManager.GetCollector().CollectError(ec.Name, err)
+		return detected, err
+	}
+
+	clusters, err := ec.describeClusters()
+	if err != nil {
+		ec.awsManager.GetCollector().CollectError(ec.Name, err)
+		return detected, err
+	}
+
+	now := time.Now()
+
+	for _, cluster := range clusters {
+		log.WithField("name", *cluster.ClusterName).Debug("checking ecs cluster")
+
+		services, err := ec.describeServices(cluster.ClusterArn)
+		if err != nil {
+			log.WithError(err).WithField("name", *cluster.ClusterName).Error("could not describe ecs services")
+			continue
+		}
+
+		for _, service := range services {
+			ec.awsManager.ExamineResource(ec.Name, func() collector.PriceDetectedFields {
+				price := ec.getServiceHourlyPrice(service, fargatePrices)
+				return collector.PriceDetectedFields{
+					ResourceID:    awsClient.StringValue(service.ServiceArn),
+					LaunchTime:    awsClient.TimeValue(service.CreatedAt),
+					PricePerHour:  price,
+					PricePerMonth: price * collector.TotalMonthHours,
+					Tag:           ec.getTags(service.Tags),
+				}
+			})
+		}
+
+		for _, metric := range metrics {
+			period := int64(metric.Period.Seconds())
+			metricEndTime := now.Add(time.Duration(-metric.StartTime))
+
+			if ec.isCapacityProviderMetric(metric) {
+				for _, capacityProvider := range cluster.CapacityProviders {
+					if strings.HasPrefix(*capacityProvider, ecsFargateLaunchType) {
+						continue
+					}
+
+					metricInput := awsCloudwatch.GetMetricStatisticsInput{
+						Namespace: &ec.capacityProviderNamespace,
+						Period:    &period,
+						StartTime: &metricEndTime,
+						EndTime:   &now,
+						Dimensions: []*awsCloudwatch.Dimension{
+							{
+								Name:  awsClient.String("ClusterName"),
+								Value: cluster.ClusterName,
+							},
+							{
+								Name:  awsClient.String("CapacityProviderName"),
+								Value: capacityProvider,
+							},
+						},
+					}
+
+					if !ec.isMetricMatched(&metricInput, metric, *cluster.ClusterName, *capacityProvider) {
+						continue
+					}
+
+					ecsCapacityProvider := DetectedECS{
+						Metric:           metric.Description,
+						ClusterName:      *cluster.ClusterName,
+						CapacityProvider: *capacityProvider,
+						PriceDetectedFields: collector.PriceDetectedFields{
+							ResourceID:        fmt.Sprintf("%s/%s", *cluster.ClusterArn, *capacityProvider),
+							ARN:               ec.awsManager.GetARN("ecs", fmt.Sprintf("capacity-provider/%s", *capacityProvider)),
+							ConsoleURL:        ec.awsManager.GetConsoleURL(fmt.Sprintf("ecs/v2/clusters/%s/infrastructure", *cluster.ClusterName), ""),
+							Tag:               ec.getTags(cluster.Tags),
+							AccountDimensions: ec.awsManager.GetAccountDimensions(),
+						},
+					}
+
+					ec.awsManager.GetCollector().AddResource(collector.EventCollector{
+						ResourceName: ec.Name,
+						Data:         ecsCapacityProvider,
+					})
+
+					detected = append(detected, ecsCapacityProvider)
+				}
+				continue
+			}
+
+			for _, service := range services {
+				if awsClient.Int64Value(service.DesiredCount) == 0 {
+					continue
+				}
+
+				metricInput := awsCloudwatch.GetMetricStatisticsInput{
+					Namespace: &ec.namespace,
+					Period:    &period,
+					StartTime: &metricEndTime,
+					EndTime:   &now,
+					Dimensions: []*awsCloudwatch.Dimension{
+						{
+							Name:  awsClient.String("ClusterName"),
+							Value: cluster.ClusterName,
+						},
+						{
+							Name:  awsClient.String("ServiceName"),
+							Value: service.ServiceName,
+						},
+					},
+				}
+
+				if !ec.isMetricMatched(&metricInput, metric, *cluster.ClusterName, *service.ServiceName) {
+					continue
+				}
+
+				launchType := ec.getLaunchType(service)
+				cpu, memoryGB := ec.getTaskDefinitionResources(service.TaskDefinition)
+				price := ec.getServiceHourlyPrice(service, fargatePrices)
+
+				ecsService := DetectedECS{
+					Metric:       metric.Description,
+					ClusterName:  *cluster.ClusterName,
+					ServiceName:  *service.ServiceName,
+					LaunchType:   launchType,
+					DesiredCount: *service.DesiredCount,
+					CPU:          cpu,
+					MemoryGB:     memoryGB,
+					PriceDetectedFields: collector.PriceDetectedFields{
+						ResourceID:        awsClient.StringValue(service.ServiceArn),
+						ARN:               awsClient.StringValue(service.ServiceArn),
+						ConsoleURL:        ec.awsManager.GetConsoleURL(fmt.Sprintf("ecs/v2/clusters/%s/services/%s/health", *cluster.ClusterName, *service.ServiceName), ""),
+						LaunchTime:        awsClient.TimeValue(service.CreatedAt),
+						PricePerHour:      price,
+						PricePerMonth:     price * collector.TotalMonthHours,
+						Tag:               ec.getTags(service.Tags),
+						AccountDimensions: ec.awsManager.GetAccountDimensions(),
+					},
+				}
+
+				ec.awsManager.GetCollector().AddResource(collector.EventCollector{
+					ResourceName: ec.Name,
+					Data:         ecsService,
+				})
+
+				detected = append(detected, ecsService)
+			}
+		}
+	}
+
+	ec.awsManager.GetCollector().CollectFinish(ec.Name)
+
+	return detected, nil
+}
+
+// isCapacityProviderMetric returns true when the metric describes the capacity providers managed scaling
+func (ec *ECSManager) isCapacityProviderMetric(metric config.MetricConfig) bool {
+	for _, data := range metric.Data {
+		if data.Name == ecsCapacityProviderMetric {
+			return true
+		}
+	}
+	return false
+}
+
+// isMetricMatched returns true when the cloudwatch metric value matches the metric constraint
+func (ec *ECSManager) isMetricMatched(metricInput *awsCloudwatch.GetMetricStatisticsInput, metric config.MetricConfig, clusterName, name string) bool {
+
+	log.WithFields(log.Fields{
+		"cluster_name": clusterName,
+		"name":         name,
+		"metric_name":  metric.Description,
+	}).Debug("check metric")
+
+	formulaValue, _, err := ec.awsManager.GetCloudWatchClient().GetMetric(metricInput, metric)
+	if err != nil {
+		log.WithError(err).WithFields(log.Fields{
+			"cluster_name": clusterName,
+			"name":         name,
+			"metric_name":  metric.Description,
+		}).Error("Could not get cloudwatch metric data")
+		return false
+	}
+
+	expression, err := expression.BoolExpression(formulaValue, metric.Constraint.Value, metric.Constraint.Operator)
+	if err != nil || !expression {
+		return false
+	}
+
+	log.WithFields(log.Fields{
+		"metric_name":         metric.Description,
+		"constraint_operator": metric.Constraint.Operator,
+		"constraint_Value":    metric.Constraint.Value,
+		"formula_value":       formulaValue,
+		"cluster_name":        clusterName,
+		"name":                name,
+		"region":              ec.awsManager.GetRegion(),
+	}).Info("ECS resource detected as unutilized resource")
+
+	return true
+}
+
+// getLaunchType returns the service launch type, resolved from the capacity provider strategy when not set
+func (ec *ECSManager) getLaunchType(service *ecs.Service) string {
+	if service.LaunchType != nil {
+		return *service.LaunchType
+	}
+	for _, strategy := range service.CapacityProviderStrategy {
+		if strings.HasPrefix(awsClient.StringValue(strategy.CapacityProvider), ecsFargateLaunchType) {
+			return ecsFargateLaunchType
+		}
+	}
+	return ecs.LaunchTypeEc2
+}
+
+// getServiceHourlyPrice returns the hourly price of the service desired tasks.
+// Services running on EC2 capacity are charged by their container instances
+func (ec *ECSManager) getServiceHourlyPrice(service *ecs.Service, fargatePrices ecsFargatePrices) float64 {
+
+	if ec.getLaunchType(service) != ecsFargateLaunchType {
+		return 0
+	}
+
+	cpu, memoryGB := ec.getTaskDefinitionResources(service.TaskDefinition)
+	return float64(awsClient.Int64Value(service.DesiredCount)) * (cpu*fargatePrices.vCPU + memoryGB*fargatePrices.memoryGB)
+}
+
+// getTaskDefinitionResources returns the task definition vCPU and memory GB
+func (ec *ECSManager) getTaskDefinitionResources(taskDefinition *string) (float64, float64) {
+
+	resp, err := ec.client.DescribeTaskDefinition(&ecs.DescribeTaskDefinitionInput{
+		TaskDefinition: taskDefinition,
+	})
+	if err != nil || resp.TaskDefinition == nil {
+		log.WithError(err).WithField("task_definition", awsClient.StringValue(taskDefinition)).Error("could not describe ecs task definition")
+		return 0, 0
+	}
+
+	// Task definition cpu is set in cpu units and memory in MiB
+	cpuUnits, _ := strconv.ParseFloat(awsClient.StringValue(resp.TaskDefinition.Cpu), 64)
+	memoryMB, _ := strconv.ParseFloat(awsClient.StringValue(resp.TaskDefinition.Memory), 64)
+
+	return cpuUnits / 1024, memoryMB / 1024
+}
+
+// getFargatePrices returns the fargate vCPU and memory GB hourly prices
+func (ec *ECSManager) getFargatePrices() (ecsFargatePrices, error) {
+
+	prices := ecsFargatePrices{}
+
+	pricingRegionPrefix, err := ec.awsManager.GetPricingClient().GetRegionPrefix(ec.awsManager.GetRegion())
+	if err != nil {
+		log.WithError(err).WithFields(log.Fields{
+			"region": ec.awsManager.GetRegion(),
+		}).Error("Could not get pricing region prefix")
+		return prices, err
+	}
+
+	vCPUFilters := ec.getPricingFilterInput(fmt.Sprintf("%sFargate-vCPU-Hours:perCPU", pricingRegionPrefix))
+	prices.vCPU, err = ec.awsManager.GetPricingClient().GetPrice(vCPUFilters, "", ec.awsManager.GetRegion())
+	if err != nil {
+		log.WithError(err).WithFields(log.Fields{
+			"region":        ec.awsManager.GetRegion(),
+			"price_filters": vCPUFilters,
+		}).Error("could not get fargate vCPU price")
+		return prices, err
+	}
+
+	memoryFilters := ec.getPricingFilterInput(fmt.Sprintf("%sFargate-GB-Hours", pricingRegionPrefix))
+	prices.memoryGB, err = ec.awsManager.GetPricingClient().GetPrice(memoryFilters, "", ec.awsManager.GetRegion())
+	if err != nil {
+		log.WithError(err).WithFields(log.Fields{
+			"region":        ec.awsManager.GetRegion(),
+			"price_filters": memoryFilters,
+		}).Error("could not get fargate memory price")
+		return prices, err
+	}
+
+	return prices, nil
+}
+
+// getTags returns the ecs resource tags
+func (ec *ECSManager) getTags(tags []*ecs.Tag) map[string]string {
+	tagsData := map[string]string{}
+	for _, tag := range tags {
+		tagsData[*tag.Key] = *tag.Value
+	}
+	return tagsData
+}
+
+// getPricingFilterInput prepares the fargate pricing filter of the given usage type
+func (ec *ECSManager) getPricingFilterInput(usageType string) pricing.GetProductsInput {
+	return pricing.GetProductsInput{
+		ServiceCode: &ec.servicePricingCode,
+		Filters: []*pricing.Filter{
+			{
+				Type:  awsClient.String("TERM_MATCH"),
+				Field: awsClient.String("termType"),
+				Value: awsClient.String("OnDemand"),
+			},
+			{
+				Type:  awsClient.String("TERM_MATCH"),
+				Field: awsClient.String("usagetype"),
+				Value: awsClient.String(usageType),
+			},
+		},
+	}
+}
+
+// describeClusters returns a list of ecs clusters
+func (ec *ECSManager) describeClusters() ([]*ecs.Cluster, error) {
+
+	clusterArns, err := ec.listClusters(nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	clusters := []*ecs.Cluster{}
+	nextBatch := interpolation.ChunkIterator(clusterArns, ecsDescribeClustersLimit)
+	for batch := nextBatch(); batch != nil; batch = nextBatch() {
+		resp, err := ec.client.DescribeClusters(&ecs.DescribeClustersInput{
+			Clusters: batch,
+			Include:  awsClient.StringSlice([]string{ecs.ClusterFieldTags}),
+		})
+		if err != nil {
+			log.WithField("error", err).Error("could not describe ecs clusters")
+			return nil, err
+		}
+		clusters = append(clusters, resp.Clusters...)
+	}
+
+	return clusters, nil
+}
+
+// listClusters returns a list of ecs cluster arns
+func (ec *ECSManager) listClusters(nextToken *string, clusterArns []*string) ([]*string, error) {
+
+	input := &ecs.ListClustersInput{
+		NextToken: nextToken,
+	}
+
+	resp, err := ec.client.ListClusters(input)
+	if err != nil {
+		log.WithField("error", err).Error("could not list ecs clusters")
+		return nil, err
+	}
+
+	if clusterArns == nil {
+		clusterArns = []*string{}
+	}
+
+	clusterArns = append(clusterArns, resp.ClusterArns...)
+
+	if resp.NextToken != nil {
+		return ec.listClusters(resp.NextToken, clusterArns)
+	}
+
+	return clusterArns, nil
+}
+
+// describeServices returns a list of the given cluster services
+func (ec *ECSManager) describeServices(clusterArn *string) ([]*ecs.Service, error) {
+
+	serviceArns, err := ec.listServices(clusterArn, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	services := []*ecs.Service{}
+	nextBatch := interpolation.ChunkIterator(serviceArns, ecsDescribeServicesLimit)
+	for batch := nextBatch(); batch != nil; batch = nextBatch() {
+		resp, err := ec.client.DescribeServices(&ecs.DescribeServicesInput{
+			Cluster:  clusterArn,
+			Services: batch,
+			Include:  awsClient.StringSlice([]string{ecs.ServiceFieldTags}),
+		})
+		if err != nil {
+			return nil, err
+		}
+		services = append(services, resp.Services...)
+	}
+
+	return services, nil
+}
+
+// listServices returns a list of the given cluster service arns
+func (ec *ECSManager) listServices(clusterArn *string, nextToken *string, serviceArns []*string) ([]*string, error) {
+
+	input := &ecs.ListServicesInput{
+		Cluster:   clusterArn,
+		NextToken: nextToken,
+	}
+
+	resp, err := ec.client.ListServices(input)
+	if err != nil {
+		return nil, err
+	}
+
+	if serviceArns == nil {
+		serviceArns = []*string{}
+	}
+
+	serviceArns = append(serviceArns, resp.ServiceArns...)
+
+	if resp.NextToken != nil {
+		return ec.listServices(clusterArn, resp.NextToken, serviceArns)
+	}
+
+	return serviceArns, nil
+}
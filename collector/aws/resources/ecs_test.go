@@ -0,0 +1,302 @@
+package resources
+
+import (
+	"errors"
+	awsTestutils "finala/collector/aws/testutils"
+	"finala/collector/config"
+	"finala/collector/testutils"
+	collectorTestutils "finala/collector/testutils"
+	"reflect"
+	"testing"
+	"time"
+
+	awsClient "github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudwatch"
+	"github.com/aws/aws-sdk-go/service/ecs"
+)
+
+var defaultECSClustersMock = ecs.DescribeClustersOutput{
+	Clusters: []*ecs.Cluster{
+		{
+			ClusterName:       awsClient.String("cluster-1"),
+			ClusterArn:        awsClient.String("arn:aws:ecs:us-east-1:1234:cluster/cluster-1"),
+			CapacityProviders: awsClient.StringSlice([]string{"FARGATE", "FARGATE_SPOT", "asg-provider"}),
+			Tags: []*ecs.Tag{
+				{Key: awsClient.String("team"), Value: awsClient.String("platform")},
+			},
+		},
+	},
+}
+
+var defaultECSServicesMock = ecs.DescribeServicesOutput{
+	Services: []*ecs.Service{
+		{
+			ServiceName:    awsClient.String("fargate-service"),
+			ServiceArn:     awsClient.String("arn:aws:ecs:us-east-1:1234:service/cluster-1/fargate-service"),
+			LaunchType:     awsClient.String("FARGATE"),
+			DesiredCount:   awsClient.Int64(2),
+			TaskDefinition: awsClient.String("fargate-task:1"),
+			CreatedAt:      testutils.TimePointer(time.Now()),
+		},
+		{
+			ServiceName:    awsClient.String("ec2-service"),
+			ServiceArn:     awsClient.String("arn:aws:ecs:us-east-1:1234:service/cluster-1/ec2-service"),
+			LaunchType:     awsClient.String("EC2"),
+			DesiredCount:   awsClient.Int64(1),
+			TaskDefinition: awsClient.String("ec2-task:1"),
+			CreatedAt:      testutils.TimePointer(time.Now()),
+		},
+		{
+			ServiceName:  awsClient.String("stopped-service"),
+			ServiceArn:   awsClient.String("arn:aws:ecs:us-east-1:1234:service/cluster-1/stopped-service"),
+			DesiredCount: awsClient.Int64(0),
+		},
+	},
+}
+
+type MockAWSECSClient struct {
+	responseDescribeClusters ecs.DescribeClustersOutput
+	responseDescribeServices ecs.DescribeServicesOutput
+	err                      error
+}
+
+func (r *MockAWSECSClient) ListClusters(*ecs.ListClustersInput) (*ecs.ListClustersOutput, error) {
+	clusterArns := []*string{}
+	for _, cluster := range r.responseDescribeClusters.Clusters {
+		clusterArns = append(clusterArns, cluster.ClusterArn)
+	}
+	return &ecs.ListClustersOutput{ClusterArns: clusterArns}, r.err
+}
+
+func (r *MockAWSECSClient) DescribeClusters(*ecs.DescribeClustersInput) (*ecs.DescribeClustersOutput, error) {
+	return &r.responseDescribeClusters, r.err
+}
+
+func (r *MockAWSECSClient) ListServices(*ecs.ListServicesInput) (*ecs.ListServicesOutput, error) {
+	serviceArns := []*string{}
+	for _, service := range r.responseDescribeServices.Services {
+		serviceArns = append(serviceArns, service.ServiceArn)
+	}
+	return &ecs.ListServicesOutput{ServiceArns: serviceArns}, r.err
+}
+
+func (r *MockAWSECSClient) DescribeServices(*ecs.DescribeServicesInput) (*ecs.DescribeServicesOutput, error) {
+	return &r.responseDescribeServices, r.err
+}
+
+func (r *MockAWSECSClient) DescribeTaskDefinition(*ecs.DescribeTaskDefinitionInput) (*ecs.DescribeTaskDefinitionOutput, error) {
+	return &ecs.DescribeTaskDefinitionOutput{
+		TaskDefinition: &ecs.TaskDefinition{
+			Cpu:    awsClient.String("512"),
+			Memory: awsClient.String("1024"),
+		},
+	}, r.err
+}
+
+func TestNewECSManager(t *testing.T) {
+
+	collector := collectorTestutils.NewMockCollector()
+	detector := awsTestutils.AWSManager(collector, nil, nil, "us-east-1")
+
+	ecsManager, err := NewECSManager(detector, &MockEmptyClient{})
+	if err == nil {
+		t.Fatalf("unexpected error happened, got nil expected error")
+	}
+	if ecsManager != nil {
+		t.Fatalf("unexpected ecs manager instance, got %v expected nil", reflect.TypeOf(ecsManager))
+	}
+}
+
+func TestDescribeECSServices(t *testing.T) {
+
+	collector := collectorTestutils.NewMockCollector()
+	detector := awsTestutils.AWSManager(collector, nil, nil, "us-east-1")
+
+	t.Run("valid", func(t *testing.T) {
+
+		mockClient := MockAWSECSClient{
+			responseDescribeClusters: defaultECSClustersMock,
+			responseDescribeServices: defaultECSServicesMock,
+		}
+
+		ecsInterface, err := NewECSManager(detector, &mockClient)
+		if err != nil {
+			t.Fatalf("unexpected ecs manager error happened, got %v expected %v", err, nil)
+		}
+
+		ecsManager, ok := ecsInterface.(*ECSManager)
+		if !ok {
+			t.Fatalf("unexpected ecs struct, got %s expected %s", reflect.TypeOf(ecsInterface), "*ECSManager")
+		}
+
+		result, err := ecsManager.describeServices(awsClient.String("cluster-1"))
+		if err != nil {
+			t.Fatalf("unexpected error happened, got %v expected %v", err, nil)
+		}
+
+		if len(result) != len(defaultECSServicesMock.Services) {
+			t.Fatalf("unexpected ecs services count, got %d expected %d", len(result), len(defaultECSServicesMock.Services))
+		}
+	})
+
+	t.Run("error", func(t *testing.T) {
+
+		mockClient := MockAWSECSClient{
+			responseDescribeClusters: defaultECSClustersMock,
+			responseDescribeServices: defaultECSServicesMock,
+			err:                      errors.New("error"),
+		}
+
+		ecsInterface, err := NewECSManager(detector, &mockClient)
+		if err != nil {
+			t.Fatalf("unexpected ecs manager error happened, got %v expected %v", err, nil)
+		}
+
+		ecsManager, ok := ecsInterface.(*ECSManager)
+		if !ok {
+			t.Fatalf("unexpected ecs struct, got %s expected %s", reflect.TypeOf(ecsInterface), "*ECSManager")
+		}
+
+		_, err = ecsManager.describeServices(awsClient.String("cluster-1"))
+		if err == nil {
+			t.Fatalf("unexpected describe ecs services error, return empty")
+		}
+	})
+}
+
+func TestDetectECS(t *testing.T) {
+
+	cloudWatchMetrics := map[string]cloudwatch.GetMetricStatisticsOutput{
+		"CPUUtilization": {
+			Datapoints: []*cloudwatch.Datapoint{
+				{Maximum: testutils.Float64Pointer(1)},
+			},
+		},
+		ecsCapacityProviderMetric: {
+			Datapoints: []*cloudwatch.Datapoint{
+				{Maximum: testutils.Float64Pointer(1)},
+			},
+		},
+	}
+
+	testCases := []struct {
+		name              string
+		metricName        string
+		expectedResources []string
+	}{
+		{"services", "CPUUtilization", []string{"fargate-service", "ec2-service"}},
+		{"capacity providers", ecsCapacityProviderMetric, []string{"asg-provider"}},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+
+			collector := collectorTestutils.NewMockCollector()
+			mockCloudwatch := awsTestutils.NewMockCloudwatch(&cloudWatchMetrics)
+			mockPrice := awsTestutils.NewMockPricing(nil)
+			detector := awsTestutils.AWSManager(collector, mockCloudwatch, mockPrice, "us-east-1")
+
+			mockClient := MockAWSECSClient{
+				responseDescribeClusters: defaultECSClustersMock,
+				responseDescribeServices: defaultECSServicesMock,
+			}
+
+			ecsManager, err := NewECSManager(detector, &mockClient)
+			if err != nil {
+				t.Fatalf("unexpected ecs manager error happened, got %v expected %v", err, nil)
+			}
+
+			response, err := ecsManager.Detect([]config.MetricConfig{
+				{
+					Description: "Low utilization",
+					Data: []config.MetricDataConfiguration{
+						{
+							Name:      test.metricName,
+							Statistic: "Maximum",
+						},
+					},
+					Constraint: config.MetricConstraintConfig{
+						Operator: "<",
+						Value:    5,
+					},
+				},
+			})
+			if err != nil {
+				t.Fatalf("unexpected ecs detection error happened, got %v expected %v", err, nil)
+			}
+
+			ecsResponse, ok := response.([]DetectedECS)
+			if !ok {
+				t.Fatalf("unexpected ecs struct, got %s expected %s", reflect.TypeOf(response), "[]DetectedECS")
+			}
+
+			if len(ecsResponse) != len(test.expectedResources) {
+				t.Fatalf("unexpected ecs detection count, got %d expected %d", len(ecsResponse), len(test.expectedResources))
+			}
+
+			if len(collector.Events) != len(test.expectedResources) {
+				t.Fatalf("unexpected collector ecs events, got %d expected %d", len(collector.Events), len(test.expectedResources))
+			}
+
+			for i, resource := range ecsResponse {
+				name := resource.ServiceName
+				if name == "" {
+					name = resource.CapacityProvider
+				}
+				if name != test.expectedResources[i] {
+					t.Fatalf("unexpected detected ecs resource, got %s expected %s", name, test.expectedResources[i])
+				}
+			}
+		})
+	}
+
+	t.Run("fargate pricing", func(t *testing.T) {
+
+		collector := collectorTestutils.NewMockCollector()
+		mockCloudwatch := awsTestutils.NewMockCloudwatch(nil)
+		mockPrice := awsTestutils.NewMockPricing(nil)
+		detector := awsTestutils.AWSManager(collector, mockCloudwatch, mockPrice, "us-east-1")
+
+		mockClient := MockAWSECSClient{
+			responseDescribeClusters: defaultECSClustersMock,
+			responseDescribeServices: defaultECSServicesMock,
+		}
+
+		ecsManager, _ := NewECSManager(detector, &mockClient)
+		response, _ := ecsManager.Detect(awsTestutils.DefaultMetricConfig)
+		ecsResponse := response.([]DetectedECS)
+
+		// 2 tasks * (0.5 vCPU + 1 GB) with a price of 1 per unit
+		if ecsResponse[0].PricePerHour != 3 {
+			t.Fatalf("unexpected fargate service price per hour, got %f expected %d", ecsResponse[0].PricePerHour, 3)
+		}
+
+		if ecsResponse[1].PricePerHour != 0 {
+			t.Fatalf("unexpected ec2 service price per hour, got %f expected %d", ecsResponse[1].PricePerHour, 0)
+		}
+	})
+
+	t.Run("detection error", func(t *testing.T) {
+
+		collector := collectorTestutils.NewMockCollector()
+		mockCloudwatch := awsTestutils.NewMockCloudwatch(nil)
+		mockPrice := awsTestutils.NewMockPricing(nil)
+		detector := awsTestutils.AWSManager(collector, mockCloudwatch, mockPrice, "us-east-1")
+
+		mockClient := MockAWSECSClient{
+			responseDescribeClusters: defaultECSClustersMock,
+			responseDescribeServices: defaultECSServicesMock,
+			err:                      errors.New("error"),
+		}
+
+		ecsManager, err := NewECSManager(detector, &mockClient)
+		if err != nil {
+			t.Fatalf("unexpected ecs manager error happened, got %v expected %v", err, nil)
+		}
+
+		_, err = ecsManager.Detect(awsTestutils.DefaultMetricConfig)
+		if err == nil {
+			t.Fatalf("unexpected detection ecs manager error, got nil expected error message")
+		}
+	})
+}
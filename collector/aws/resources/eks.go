@@ -0,0 +1,456 @@
+package resources
+
+import (
+	"errors"
+	"finala/collector"
+	"finala/collector/aws/common"
+	"finala/collector/aws/register"
+	"finala/collector/config"
+	"finala/expression"
+	"fmt"
+	"time"
+
+	awsClient "github.com/aws/aws-sdk-go/aws"
+	awsCloudwatch "github.com/aws/aws-sdk-go/service/cloudwatch"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/eks"
+	"github.com/aws/aws-sdk-go/service/pricing"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// eksNoNodesMetric describes the detection of a cluster without nodes
+	eksNoNodesMetric = "No nodes"
+
+	// eksClusterTagKeyPrefix is the tag key prefix of the instances joined to a cluster, followed by the cluster name
+	eksClusterTagKeyPrefix = "kubernetes.io/cluster/"
+)
+
+// EKSClientDescreptor is an interface defining the aws eks client
+type EKSClientDescreptor interface {
+	ListClusters(*eks.ListClustersInput) (*eks.ListClustersOutput, error)
+	DescribeCluster(*eks.DescribeClusterInput) (*eks.DescribeClusterOutput, error)
+	ListNodegroups(*eks.ListNodegroupsInput) (*eks.ListNodegroupsOutput, error)
+	DescribeNodegroup(*eks.DescribeNodegroupInput) (*eks.DescribeNodegroupOutput, error)
+	ListFargateProfiles(*eks.ListFargateProfilesInput) (*eks.ListFargateProfilesOutput, error)
+	DescribeInstances(*ec2.DescribeInstancesInput) (*ec2.DescribeInstancesOutput, error)
+}
+
+// eksClient combines the aws eks and ec2 clients
+type eksClient struct {
+	*eks.EKS
+	*ec2.EC2
+}
+
+// EKSManager describes EKS struct
+type EKSManager struct {
+	client                EKSClientDescreptor
+	awsManager            common.AWSManager
+	namespace             string
+	servicePricingCode    string
+	ec2ServicePricingCode string
+	Name                  collector.ResourceIdentifier
+}
+
+// DetectedEKS defines the detected AWS EKS cluster or node group.
+// NodeGroup is empty when the whole cluster was detected.
+type DetectedEKS struct {
+	Metric        string
+	ClusterName   string
+	Version       string
+	NodeGroup     string
+	InstanceTypes []string
+	DesiredSize   int64
+	collector.PriceDetectedFields
+}
+
+func init() {
+	register.Registry("eks", NewEKSManager)
+}
+
+// NewEKSManager implements AWS GO SDK
+func NewEKSManager(awsManager common.AWSManager, client interface{}) (common.ResourceDetection, error) {
+
+	if client == nil {
+		client = &eksClient{
+			EKS: eks.New(awsManager.GetSession()),
+			EC2: ec2.New(awsManager.GetSession()),
+		}
+	}
+
+	eksClient, ok := client.(EKSClientDescreptor)
+	if !ok {
+		return nil, errors.New("invalid eks client")
+	}
+
+	return &EKSManager{
+		client:                eksClient,
+		awsManager:            awsManager,
+		namespace:             "AWS/EC2",
+		servicePricingCode:    "AmazonEKS",
+		ec2ServicePricingCode: "AmazonEC2",
+		Name:                  awsManager.GetResourceIdentifier("eks"),
+	}, nil
+}
+
+// Detect checks which EKS clusters have no nodes and which node groups are under utilized
+func (ek *EKSManager) Detect(metrics []config.MetricConfig) (interface{}, error) {
+
+	log.WithFields(log.Fields{
+		"region":   ek.awsManager.GetRegion(),
+		"resource": "eks",
+	}).Info("starting to analyze resource")
+
+	ek.awsManager.GetCollector().CollectStart(ek.Name)
+
+	detected := []DetectedEKS{}
+
+	pricingRegionPrefix, err := ek.awsManager.GetPricingClient().GetRegionPrefix(ek.awsManager.GetRegion())
+	if err != nil {
+		log.WithError(err).WithFields(log.Fields{
+			"region": ek.awsManager.GetRegion(),
+		}).Error("Could not get pricing region prefix")
+		ek.awsManager.GetCollector().CollectError(ek.Name, err)
+		return detected, err
+	}
+
+	pricingFilters := ek.getPricingFilterInput(pricingRegionPrefix)
+	controlPlanePrice, err := ek.awsManager.GetPricingClient().GetPrice(pricingFilters, "", ek.awsManager.GetRegion())
+	if err != nil {
+		log.WithError(err).WithFields(log.Fields{
+			"region":        ek.awsManager.GetRegion(),
+			"price_filters": pricingFilters,
+		}).Error("could not get eks control plane price")
+		ek.awsManager.GetCollector().CollectError(ek.Name, err)
+		return detected, err
+	}
+
+	clusterNames, err := ek.listClusters(nil, nil)
+	if err != nil {
+		ek.awsManager.GetCollector().CollectError(ek.Name, err)
+		return detected, err
+	}
+
+	now := time.Now()
+
+	for _, clusterName := range clusterNames {
+		log.WithField("name", *clusterName).Debug("checking eks cluster")
+
+		cluster, err := ek.client.DescribeCluster(&eks.DescribeClusterInput{Name: clusterName})
+		if err != nil {
+			log.WithError(err).WithField("name", *clusterName).Error("could not describe eks cluster")
+			continue
+		}
+
+		// The node groups instances are examined by the ec2 instances detector
+		ek.awsManager.ExamineResource(ek.Name, func() collector.PriceDetectedFields {
+			return collector.PriceDetectedFields{
+				ResourceID:    awsClient.StringValue(cluster.Cluster.Arn),
+				LaunchTime:    awsClient.TimeValue(cluster.Cluster.CreatedAt),
+				PricePerHour:  controlPlanePrice,
+				PricePerMonth: controlPlanePrice * collector.TotalMonthHours,
+				Tag:           ek.getTags(cluster.Cluster.Tags),
+			}
+		})
+
+		nodeGroups, err := ek.describeNodegroups(clusterName, nil, nil)
+		if err != nil {
+			log.WithError(err).WithField("name", *clusterName).Error("could not describe eks node groups")
+			continue
+		}
+
+		fargateProfiles, err := ek.client.ListFargateProfiles(&eks.ListFargateProfilesInput{ClusterName: clusterName})
+		if err != nil {
+			log.WithError(err).WithField("name", *clusterName).Error("could not list eks fargate profiles")
+			continue
+		}
+
+		var totalDesiredSize int64
+		for _, nodeGroup := range nodeGroups {
+			totalDesiredSize += ek.getDesiredSize(nodeGroup)
+		}
+
+		// Self managed nodes are not listed by the cluster, and are found by the cluster tag of their instances
+		hasNodes := totalDesiredSize > 0 || len(fargateProfiles.FargateProfileNames) > 0
+		if !hasNodes {
+			hasNodes, err = ek.hasSelfManagedNodes(*clusterName, nil)
+			if err != nil {
+				log.WithError(err).WithField("name", *clusterName).Error("could not describe eks self managed nodes")
+				continue
+			}
+		}
+
+		if !hasNodes {
+
+			log.WithFields(log.Fields{
+				"name":   *clusterName,
+				"region": ek.awsManager.GetRegion(),
+			}).Info("EKS cluster detected without nodes")
+
+			eksCluster := DetectedEKS{
+				Metric:      eksNoNodesMetric,
+				ClusterName: *clusterName,
+				Version:     awsClient.StringValue(cluster.Cluster.Version),
+				PriceDetectedFields: collector.PriceDetectedFields{
+					ResourceID:        awsClient.StringValue(cluster.Cluster.Arn),
+					ARN:               awsClient.StringValue(cluster.Cluster.Arn),
+					ConsoleURL:        ek.awsManager.GetConsoleURL("eks/home", fmt.Sprintf("/clusters/%s", *clusterName)),
+					LaunchTime:        awsClient.TimeValue(cluster.Cluster.CreatedAt),
+					PricePerHour:      controlPlanePrice,
+					PricePerMonth:     controlPlanePrice * collector.TotalMonthHours,
+					Tag:               ek.getTags(cluster.Cluster.Tags),
+					AccountDimensions: ek.awsManager.GetAccountDimensions(),
+				},
+			}
+
+			ek.awsManager.GetCollector().AddResource(collector.EventCollector{
+				ResourceName: ek.Name,
+				Data:         eksCluster,
+			})
+
+			detected = append(detected, eksCluster)
+			continue
+		}
+
+		for _, nodeGroup := range nodeGroups {
+			if nodeGroup.Resources == nil || len(nodeGroup.Resources.AutoScalingGroups) == 0 || ek.getDesiredSize(nodeGroup) == 0 {
+				continue
+			}
+
+			price := ek.getNodeGroupHourlyPrice(nodeGroup)
+
+			for _, metric := range metrics {
+				log.WithFields(log.Fields{
+					"cluster_name": *clusterName,
+					"node_group":   *nodeGroup.NodegroupName,
+					"metric_name":  metric.Description,
+				}).Debug("check metric")
+
+				period := int64(metric.Period.Seconds())
+				metricEndTime := now.Add(time.Duration(-metric.StartTime))
+				metricInput := awsCloudwatch.GetMetricStatisticsInput{
+					Namespace: &ek.namespace,
+					Period:    &period,
+					StartTime: &metricEndTime,
+					EndTime:   &now,
+					Dimensions: []*awsCloudwatch.Dimension{
+						{
+							Name:  awsClient.String("AutoScalingGroupName"),
+							Value: nodeGroup.Resources.AutoScalingGroups[0].Name,
+						},
+					},
+				}
+
+				formulaValue, _, err := ek.awsManager.GetCloudWatchClient().GetMetric(&metricInput, metric)
+				if err != nil {
+					log.WithError(err).WithFields(log.Fields{
+						"cluster_name": *clusterName,
+						"node_group":   *nodeGroup.NodegroupName,
+						"metric_name":  metric.Description,
+					}).Error("Could not get cloudwatch metric data")
+					continue
+				}
+
+				expression, err := expression.BoolExpression(formulaValue, metric.Constraint.Value, metric.Constraint.Operator)
+				if err != nil {
+					continue
+				}
+
+				if expression {
+
+					log.WithFields(log.Fields{
+						"metric_name":         metric.Description,
+						"constraint_operator": metric.Constraint.Operator,
+						"constraint_Value":    metric.Constraint.Value,
+						"formula_value":       formulaValue,
+						"cluster_name":        *clusterName,
+						"node_group":          *nodeGroup.NodegroupName,
+						"region":              ek.awsManager.GetRegion(),
+					}).Info("EKS node group detected as unutilized resource")
+
+					eksNodeGroup := DetectedEKS{
+						Metric:        metric.Description,
+						ClusterName:   *clusterName,
+						Version:       awsClient.StringValue(nodeGroup.Version),
+						NodeGroup:     *nodeGroup.NodegroupName,
+						InstanceTypes: awsClient.StringValueSlice(nodeGroup.InstanceTypes),
+						DesiredSize:   ek.getDesiredSize(nodeGroup),
+						PriceDetectedFields: collector.PriceDetectedFields{
+							ResourceID:        awsClient.StringValue(nodeGroup.NodegroupArn),
+							ARN:               awsClient.StringValue(nodeGroup.NodegroupArn),
+							ConsoleURL:        ek.awsManager.GetConsoleURL("eks/home", fmt.Sprintf("/clusters/%s/nodegroups/%s", *clusterName, *nodeGroup.NodegroupName)),
+							LaunchTime:        awsClient.TimeValue(nodeGroup.CreatedAt),
+							PricePerHour:      price,
+							PricePerMonth:     price * collector.TotalMonthHours,
+							Tag:               ek.getTags(nodeGroup.Tags),
+							AccountDimensions: ek.awsManager.GetAccountDimensions(),
+						},
+					}
+
+					ek.awsManager.GetCollector().AddResource(collector.EventCollector{
+						ResourceName: ek.Name,
+						Data:         eksNodeGroup,
+					})
+
+					detected = append(detected, eksNodeGroup)
+				}
+			}
+		}
+	}
+
+	ek.awsManager.GetCollector().CollectFinish(ek.Name)
+
+	return detected, nil
+}
+
+// getDesiredSize returns the node group desired nodes count
+func (ek *EKSManager) getDesiredSize(nodeGroup *eks.Nodegroup) int64 {
+	if nodeGroup.ScalingConfig == nil {
+		return 0
+	}
+	return awsClient.Int64Value(nodeGroup.ScalingConfig.DesiredSize)
+}
+
+// getNodeGroupHourlyPrice returns the hourly on demand price of all the node group desired instances
+func (ek *EKSManager) getNodeGroupHourlyPrice(nodeGroup *eks.Nodegroup) float64 {
+
+	if len(nodeGroup.InstanceTypes) == 0 {
+		return 0
+	}
+
+	pricingFilters := getEC2InstanceTypePricingFilterInput(ek.ec2ServicePricingCode, *nodeGroup.InstanceTypes[0], "Linux")
+	price, err := ek.awsManager.GetPricingClient().GetPrice(pricingFilters, "", ek.awsManager.GetRegion())
+	if err != nil {
+		log.WithError(err).WithFields(log.Fields{
+			"node_group":    *nodeGroup.NodegroupName,
+			"instance_type": *nodeGroup.InstanceTypes[0],
+		}).Error("could not get eks node group instance price")
+		return 0
+	}
+
+	return price * float64(ek.getDesiredSize(nodeGroup))
+}
+
+// getTags returns the eks resource tags
+func (ek *EKSManager) getTags(tags map[string]*string) map[string]string {
+	tagsData := map[string]string{}
+	for key, value := range tags {
+		tagsData[key] = *value
+	}
+	return tagsData
+}
+
+// getPricingFilterInput prepares the eks control plane pricing filter
+func (ek *EKSManager) getPricingFilterInput(pricingRegionPrefix string) pricing.GetProductsInput {
+	return pricing.GetProductsInput{
+		ServiceCode: &ek.servicePricingCode,
+		Filters: []*pricing.Filter{
+			{
+				Type:  awsClient.String("TERM_MATCH"),
+				Field: awsClient.String("termType"),
+				Value: awsClient.String("OnDemand"),
+			},
+			{
+				Type:  awsClient.String("TERM_MATCH"),
+				Field: awsClient.String("usagetype"),
+				Value: awsClient.String(fmt.Sprintf("%sAmazonEKS-Hours:perCluster", pricingRegionPrefix)),
+			},
+		},
+	}
+}
+
+// listClusters returns a list of eks cluster names
+func (ek *EKSManager) listClusters(nextToken *string, clusters []*string) ([]*string, error) {
+
+	input := &eks.ListClustersInput{
+		NextToken: nextToken,
+	}
+
+	resp, err := ek.client.ListClusters(input)
+	if err != nil {
+		log.WithField("error", err).Error("could not list eks clusters")
+		return nil, err
+	}
+
+	if clusters == nil {
+		clusters = []*string{}
+	}
+
+	clusters = append(clusters, resp.Clusters...)
+
+	if resp.NextToken != nil {
+		return ek.listClusters(resp.NextToken, clusters)
+	}
+
+	return clusters, nil
+}
+
+// describeNodegroups returns a list of the given cluster node groups
+func (ek *EKSManager) describeNodegroups(clusterName *string, nextToken *string, nodeGroups []*eks.Nodegroup) ([]*eks.Nodegroup, error) {
+
+	input := &eks.ListNodegroupsInput{
+		ClusterName: clusterName,
+		NextToken:   nextToken,
+	}
+
+	resp, err := ek.client.ListNodegroups(input)
+	if err != nil {
+		return nil, err
+	}
+
+	if nodeGroups == nil {
+		nodeGroups = []*eks.Nodegroup{}
+	}
+
+	for _, nodeGroupName := range resp.Nodegroups {
+		nodeGroup, err := ek.client.DescribeNodegroup(&eks.DescribeNodegroupInput{
+			ClusterName:   clusterName,
+			NodegroupName: nodeGroupName,
+		})
+		if err != nil {
+			return nil, err
+		}
+		nodeGroups = append(nodeGroups, nodeGroup.Nodegroup)
+	}
+
+	if resp.NextToken != nil {
+		return ek.describeNodegroups(clusterName, resp.NextToken, nodeGroups)
+	}
+
+	return nodeGroups, nil
+}
+
+// hasSelfManagedNodes returns true when ec2 instances tagged with the cluster tag are pending or running
+func (ek *EKSManager) hasSelfManagedNodes(clusterName string, nextToken *string) (bool, error) {
+
+	input := &ec2.DescribeInstancesInput{
+		NextToken: nextToken,
+		Filters: []*ec2.Filter{
+			{
+				Name:   awsClient.String("tag-key"),
+				Values: awsClient.StringSlice([]string{eksClusterTagKeyPrefix + clusterName}),
+			},
+			{
+				Name:   awsClient.String("instance-state-name"),
+				Values: awsClient.StringSlice([]string{ec2.InstanceStateNamePending, ec2.InstanceStateNameRunning}),
+			},
+		},
+	}
+
+	resp, err := ek.client.DescribeInstances(input)
+	if err != nil {
+		return false, err
+	}
+
+	for _, reservation := range resp.Reservations {
+		if len(reservation.Instances) > 0 {
+			return true, nil
+		}
+	}
+
+	if resp.NextToken != nil {
+		return ek.hasSelfManagedNodes(clusterName, resp.NextToken)
+	}
+
+	return false, nil
+}
@@ -0,0 +1,289 @@
+package resources
+
+import (
+	"errors"
+	awsTestutils "finala/collector/aws/testutils"
+	"finala/collector/testutils"
+	collectorTestutils "finala/collector/testutils"
+	"reflect"
+	"testing"
+	"time"
+
+	awsClient "github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/eks"
+)
+
+type MockAWSEKSClient struct {
+	clusters        map[string]*eks.Cluster
+	nodeGroups      map[string][]*eks.Nodegroup
+	fargateProfiles map[string][]*string
+	clusterTagKeys  []string
+	err             error
+}
+
+func newDefaultMockAWSEKSClient() MockAWSEKSClient {
+	return MockAWSEKSClient{
+		clusters: map[string]*eks.Cluster{
+			"empty": {
+				Name:      awsClient.String("empty"),
+				Arn:       awsClient.String("arn:aws:eks:us-east-1:1234:cluster/empty"),
+				Version:   awsClient.String("1.29"),
+				CreatedAt: testutils.TimePointer(time.Now()),
+				Tags:      map[string]*string{"team": awsClient.String("platform")},
+			},
+			"fargate": {
+				Name:      awsClient.String("fargate"),
+				Arn:       awsClient.String("arn:aws:eks:us-east-1:1234:cluster/fargate"),
+				CreatedAt: testutils.TimePointer(time.Now()),
+			},
+			"nodes": {
+				Name:      awsClient.String("nodes"),
+				Arn:       awsClient.String("arn:aws:eks:us-east-1:1234:cluster/nodes"),
+				CreatedAt: testutils.TimePointer(time.Now()),
+			},
+		},
+		nodeGroups: map[string][]*eks.Nodegroup{
+			"empty": {
+				{
+					NodegroupName: awsClient.String("scaled-down"),
+					ScalingConfig: &eks.NodegroupScalingConfig{DesiredSize: awsClient.Int64(0)},
+				},
+			},
+			"nodes": {
+				{
+					NodegroupName: awsClient.String("workers"),
+					NodegroupArn:  awsClient.String("arn:aws:eks:us-east-1:1234:nodegroup/nodes/workers"),
+					InstanceTypes: awsClient.StringSlice([]string{"m5.large"}),
+					ScalingConfig: &eks.NodegroupScalingConfig{DesiredSize: awsClient.Int64(3)},
+					Resources: &eks.NodegroupResources{
+						AutoScalingGroups: []*eks.AutoScalingGroup{
+							{Name: awsClient.String("eks-workers")},
+						},
+					},
+				},
+			},
+		},
+		fargateProfiles: map[string][]*string{
+			"fargate": awsClient.StringSlice([]string{"default"}),
+		},
+		clusterTagKeys: []string{"kubernetes.io/cluster/nodes"},
+	}
+}
+
+func (r *MockAWSEKSClient) ListClusters(*eks.ListClustersInput) (*eks.ListClustersOutput, error) {
+	return &eks.ListClustersOutput{
+		Clusters: awsClient.StringSlice([]string{"empty", "fargate", "nodes"}),
+	}, r.err
+}
+
+func (r *MockAWSEKSClient) DescribeCluster(input *eks.DescribeClusterInput) (*eks.DescribeClusterOutput, error) {
+	return &eks.DescribeClusterOutput{Cluster: r.clusters[*input.Name]}, r.err
+}
+
+func (r *MockAWSEKSClient) ListNodegroups(input *eks.ListNodegroupsInput) (*eks.ListNodegroupsOutput, error) {
+	names := []*string{}
+	for _, nodeGroup := range r.nodeGroups[*input.ClusterName] {
+		names = append(names, nodeGroup.NodegroupName)
+	}
+	return &eks.ListNodegroupsOutput{Nodegroups: names}, r.err
+}
+
+func (r *MockAWSEKSClient) DescribeNodegroup(input *eks.DescribeNodegroupInput) (*eks.DescribeNodegroupOutput, error) {
+	for _, nodeGroup := range r.nodeGroups[*input.ClusterName] {
+		if *nodeGroup.NodegroupName == *input.NodegroupName {
+			return &eks.DescribeNodegroupOutput{Nodegroup: nodeGroup}, r.err
+		}
+	}
+	return nil, errors.New("node group not found")
+}
+
+func (r *MockAWSEKSClient) ListFargateProfiles(input *eks.ListFargateProfilesInput) (*eks.ListFargateProfilesOutput, error) {
+	return &eks.ListFargateProfilesOutput{FargateProfileNames: r.fargateProfiles[*input.ClusterName]}, r.err
+}
+
+func (r *MockAWSEKSClient) DescribeInstances(input *ec2.DescribeInstancesInput) (*ec2.DescribeInstancesOutput, error) {
+	output := &ec2.DescribeInstancesOutput{}
+	for _, filter := range input.Filters {
+		if *filter.Name != "tag-key" {
+			continue
+		}
+		for _, tagKey := range r.clusterTagKeys {
+			if tagKey == *filter.Values[0] {
+				output.Reservations = append(output.Reservations, &ec2.Reservation{
+					Instances: []*ec2.Instance{{InstanceId: awsClient.String("i-1")}},
+				})
+			}
+		}
+	}
+	return output, r.err
+}
+
+func TestNewEKSManager(t *testing.T) {
+
+	collector := collectorTestutils.NewMockCollector()
+	detector := awsTestutils.AWSManager(collector, nil, nil, "us-east-1")
+
+	eksManager, err := NewEKSManager(detector, &MockEmptyClient{})
+	if err == nil {
+		t.Fatalf("unexpected error happened, got nil expected error")
+	}
+	if eksManager != nil {
+		t.Fatalf("unexpected eks manager instance, got %v expected nil", reflect.TypeOf(eksManager))
+	}
+}
+
+func TestListEKSClusters(t *testing.T) {
+
+	collector := collectorTestutils.NewMockCollector()
+	detector := awsTestutils.AWSManager(collector, nil, nil, "us-east-1")
+
+	t.Run("valid", func(t *testing.T) {
+
+		mockClient := newDefaultMockAWSEKSClient()
+
+		eksInterface, err := NewEKSManager(detector, &mockClient)
+		if err != nil {
+			t.Fatalf("unexpected eks manager error happened, got %v expected %v", err, nil)
+		}
+
+		eksManager, ok := eksInterface.(*EKSManager)
+		if !ok {
+			t.Fatalf("unexpected eks struct, got %s expected %s", reflect.TypeOf(eksInterface), "*EKSManager")
+		}
+
+		result, err := eksManager.listClusters(nil, nil)
+		if err != nil {
+			t.Fatalf("unexpected error happened, got %v expected %v", err, nil)
+		}
+
+		if len(result) != 3 {
+			t.Fatalf("unexpected eks clusters count, got %d expected %d", len(result), 3)
+		}
+	})
+
+	t.Run("error", func(t *testing.T) {
+
+		mockClient := newDefaultMockAWSEKSClient()
+		mockClient.err = errors.New("error")
+
+		eksInterface, err := NewEKSManager(detector, &mockClient)
+		if err != nil {
+			t.Fatalf("unexpected eks manager error happened, got %v expected %v", err, nil)
+		}
+
+		eksManager, ok := eksInterface.(*EKSManager)
+		if !ok {
+			t.Fatalf("unexpected eks struct, got %s expected %s", reflect.TypeOf(eksInterface), "*EKSManager")
+		}
+
+		_, err = eksManager.listClusters(nil, nil)
+		if err == nil {
+			t.Fatalf("unexpected list eks clusters error, return empty")
+		}
+	})
+}
+
+func TestDetectEKS(t *testing.T) {
+
+	collector := collectorTestutils.NewMockCollector()
+	mockCloudwatch := awsTestutils.NewMockCloudwatch(nil)
+	mockPrice := awsTestutils.NewMockPricing(nil)
+	detector := awsTestutils.AWSManager(collector, mockCloudwatch, mockPrice, "us-east-1")
+
+	mockClient := newDefaultMockAWSEKSClient()
+
+	eksManager, err := NewEKSManager(detector, &mockClient)
+	if err != nil {
+		t.Fatalf("unexpected eks manager error happened, got %v expected %v", err, nil)
+	}
+
+	response, err := eksManager.Detect(awsTestutils.DefaultMetricConfig)
+	if err != nil {
+		t.Fatalf("unexpected eks detection error happened, got %v expected %v", err, nil)
+	}
+
+	eksResponse, ok := response.([]DetectedEKS)
+	if !ok {
+		t.Fatalf("unexpected eks struct, got %s expected %s", reflect.TypeOf(response), "[]DetectedEKS")
+	}
+
+	if len(eksResponse) != 2 {
+		t.Fatalf("unexpected eks detection count, got %d expected %d", len(eksResponse), 2)
+	}
+
+	if len(collector.Events) != 2 {
+		t.Fatalf("unexpected collector eks events, got %d expected %d", len(collector.Events), 2)
+	}
+
+	cluster := eksResponse[0]
+	if cluster.ClusterName != "empty" || cluster.Metric != eksNoNodesMetric {
+		t.Fatalf("unexpected detected eks cluster, got %s/%s expected %s/%s", cluster.ClusterName, cluster.Metric, "empty", eksNoNodesMetric)
+	}
+
+	if cluster.PricePerHour != 1 {
+		t.Fatalf("unexpected eks cluster price per hour, got %f expected %d", cluster.PricePerHour, 1)
+	}
+
+	if len(cluster.Tag) != 1 {
+		t.Fatalf("unexpected tags count, got %d expected %d", len(cluster.Tag), 1)
+	}
+
+	nodeGroup := eksResponse[1]
+	if nodeGroup.NodeGroup != "workers" {
+		t.Fatalf("unexpected detected eks node group, got %s expected %s", nodeGroup.NodeGroup, "workers")
+	}
+
+	if nodeGroup.PricePerHour != 3 {
+		t.Fatalf("unexpected eks node group price per hour, got %f expected %d", nodeGroup.PricePerHour, 3)
+	}
+}
+
+func TestDetectEKSSelfManagedNodes(t *testing.T) {
+
+	collector := collectorTestutils.NewMockCollector()
+	mockCloudwatch := awsTestutils.NewMockCloudwatch(nil)
+	mockPrice := awsTestutils.NewMockPricing(nil)
+	detector := awsTestutils.AWSManager(collector, mockCloudwatch, mockPrice, "us-east-1")
+
+	// The empty cluster has no node groups nor fargate profiles, and runs only self managed nodes
+	mockClient := newDefaultMockAWSEKSClient()
+	mockClient.clusterTagKeys = append(mockClient.clusterTagKeys, "kubernetes.io/cluster/empty")
+
+	eksManager, err := NewEKSManager(detector, &mockClient)
+	if err != nil {
+		t.Fatalf("unexpected eks manager error happened, got %v expected %v", err, nil)
+	}
+
+	response, err := eksManager.Detect(awsTestutils.DefaultMetricConfig)
+	if err != nil {
+		t.Fatalf("unexpected eks detection error happened, got %v expected %v", err, nil)
+	}
+
+	for _, detected := range response.([]DetectedEKS) {
+		if detected.Metric == eksNoNodesMetric {
+			t.Fatalf("unexpected eks cluster detected without nodes, got %s", detected.ClusterName)
+		}
+	}
+}
+
+func TestDetectEKSError(t *testing.T) {
+
+	collector := collectorTestutils.NewMockCollector()
+	mockCloudwatch := awsTestutils.NewMockCloudwatch(nil)
+	mockPrice := awsTestutils.NewMockPricing(nil)
+	detector := awsTestutils.AWSManager(collector, mockCloudwatch, mockPrice, "us-east-1")
+
+	mockClient := newDefaultMockAWSEKSClient()
+	mockClient.err = errors.New("error")
+
+	eksManager, err := NewEKSManager(detector, &mockClient)
+	if err != nil {
+		t.Fatalf("unexpected eks manager error happened, got %v expected %v", err, nil)
+	}
+
+	_, err = eksManager.Detect(awsTestutils.DefaultMetricConfig)
+	if err == nil {
+		t.Fatalf("unexpected detection eks manager error, got nil expected error message")
+	}
+}
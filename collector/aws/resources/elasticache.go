@@ -7,6 +7,7 @@ import (
 	"finala/collector/aws/register"
 	"finala/collector/config"
 	"finala/expression"
+	"fmt"
 	"time"
 
 	awsClient "github.com/aws/aws-sdk-go/aws"
@@ -33,7 +34,6 @@ type ElasticacheManager struct {
 
 // DetectedElasticache define the detected AWS Elasticache instances
 type DetectedElasticache struct {
-	Region        string
 	Metric        string
 	CacheEngine   string
 	CacheNodeType string
@@ -91,6 +91,16 @@ func (ec *ElasticacheManager) Detect(metrics []config.MetricConfig) (interface{}
 
 		price, _ := ec.awsManager.GetPricingClient().GetPrice(ec.getPricingFilterInput(instance), "", ec.awsManager.GetRegion())
 
+		ec.awsManager.ExamineResource(ec.Name, func() collector.PriceDetectedFields {
+			return collector.PriceDetectedFields{
+				LaunchTime:    *instance.CacheClusterCreateTime,
+				ResourceID:    *instance.CacheClusterId,
+				PricePerHour:  price,
+				PricePerMonth: price * collector.TotalMonthHours,
+				Tag:           ec.getTags(instance.CacheClusterId),
+			}
+		})
+
 		for _, metric := range metrics {
 			log.WithFields(log.Fields{
 				"cluster_id":  *instance.CacheClusterId,
@@ -139,29 +149,22 @@ func (ec *ElasticacheManager) Detect(metrics []config.MetricConfig) (interface{}
 					"region":              ec.awsManager.GetRegion(),
 				}).Info("Elasticache instance detected as unutilized resource")
 
-				tags, err := ec.client.ListTagsForResource(&elasticache.ListTagsForResourceInput{
-					ResourceName: instance.CacheClusterId,
-				})
-
-				tagsData := map[string]string{}
-				if err == nil {
-					for _, tag := range tags.TagList {
-						tagsData[*tag.Key] = *tag.Value
-					}
-				}
+				tagsData := ec.getTags(instance.CacheClusterId)
 
 				es := DetectedElasticache{
-					Region:        ec.awsManager.GetRegion(),
 					Metric:        metric.Description,
 					CacheEngine:   *instance.Engine,
 					CacheNodeType: *instance.CacheNodeType,
 					CacheNodes:    len(instance.CacheNodes),
 					PriceDetectedFields: collector.PriceDetectedFields{
-						LaunchTime:    *instance.CacheClusterCreateTime,
-						ResourceID:    *instance.CacheClusterId,
-						PricePerHour:  price,
-						PricePerMonth: price * collector.TotalMonthHours,
-						Tag:           tagsData,
+						LaunchTime:        *instance.CacheClusterCreateTime,
+						ResourceID:        *instance.CacheClusterId,
+						ARN:               awsClient.StringValue(instance.ARN),
+						ConsoleURL:        ec.awsManager.GetConsoleURL("elasticache/home", fmt.Sprintf("/%s/%s", *instance.Engine, *instance.CacheClusterId)),
+						PricePerHour:      price,
+						PricePerMonth:     price * collector.TotalMonthHours,
+						Tag:               tagsData,
+						AccountDimensions: ec.awsManager.GetAccountDimensions(),
 					},
 				}
 
@@ -180,6 +183,24 @@ func (ec *ElasticacheManager) Detect(metrics []config.MetricConfig) (interface{}
 	return detectedelasticache, nil
 }
 
+// getTags returns the elasticache cluster tags
+func (ec *ElasticacheManager) getTags(resourceName *string) map[string]string {
+
+	tagsData := map[string]string{}
+	tags, err := ec.client.ListTagsForResource(&elasticache.ListTagsForResourceInput{
+		ResourceName: resourceName,
+	})
+	if err != nil {
+		log.WithError(err).WithField("resource", *resourceName).Error("could not list elasticache cluster tags")
+		return tagsData
+	}
+
+	for _, tag := range tags.TagList {
+		tagsData[*tag.Key] = *tag.Value
+	}
+	return tagsData
+}
+
 // getPricingFilterInput prepare document elasticache pricing filter
 func (ec *ElasticacheManager) getPricingFilterInput(instance *elasticache.CacheCluster) pricing.GetProductsInput {
 
@@ -32,12 +32,15 @@ type ElasticIPManager struct {
 
 // DetectedElasticIP defines the detected AWS elastic ip
 type DetectedElasticIP struct {
-	Region        string
 	Metric        string
+	ResourceID    string
 	IP            string
+	ARN           string
+	ConsoleURL    string
 	PricePerHour  float64
 	PricePerMonth float64
 	Tag           map[string]string
+	collector.AccountDimensions
 }
 
 func init() {
@@ -99,21 +102,39 @@ func (ei *ElasticIPManager) Detect(metrics []config.MetricConfig) (interface{},
 	}
 
 	for _, ip := range ips {
-		if ip.PrivateIpAddress == nil && ip.AssociationId == nil && ip.InstanceId == nil && ip.NetworkInterfaceId == nil {
-			tagsData := map[string]string{}
-			if err == nil {
-				for _, tag := range ip.Tags {
-					tagsData[*tag.Key] = *tag.Value
-				}
+		tagsData := map[string]string{}
+		for _, tag := range ip.Tags {
+			tagsData[*tag.Key] = *tag.Value
+		}
+
+		unattached := ip.PrivateIpAddress == nil && ip.AssociationId == nil && ip.InstanceId == nil && ip.NetworkInterfaceId == nil
+
+		ei.awsManager.ExamineResource(ei.Name, func() collector.PriceDetectedFields {
+			// Attached elastic ips are charged by the resource they are attached to
+			var pricePerHour float64
+			if unattached {
+				pricePerHour = price
 			}
+			return collector.PriceDetectedFields{
+				ResourceID:    *ip.PublicIp,
+				PricePerHour:  pricePerHour,
+				PricePerMonth: pricePerHour * collector.TotalMonthHours,
+				Tag:           tagsData,
+			}
+		})
+
+		if unattached {
 
 			eIP := DetectedElasticIP{
-				Region:        ei.awsManager.GetRegion(),
-				Metric:        metric.Description,
-				IP:            *ip.PublicIp,
-				PricePerHour:  price,
-				PricePerMonth: price * collector.TotalMonthHours,
-				Tag:           tagsData,
+				Metric:            metric.Description,
+				ResourceID:        *ip.PublicIp,
+				IP:                *ip.PublicIp,
+				ARN:               ei.awsManager.GetARN("ec2", fmt.Sprintf("elastic-ip/%s", awsClient.StringValue(ip.AllocationId))),
+				ConsoleURL:        ei.awsManager.GetConsoleURL("ec2/home", fmt.Sprintf("ElasticIpDetails:AllocationId=%s", awsClient.StringValue(ip.AllocationId))),
+				PricePerHour:      price,
+				PricePerMonth:     price * collector.TotalMonthHours,
+				Tag:               tagsData,
+				AccountDimensions: ei.awsManager.GetAccountDimensions(),
 			}
 
 			ei.awsManager.GetCollector().AddResource(collector.EventCollector{
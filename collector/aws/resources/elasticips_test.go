@@ -178,4 +178,11 @@ func TestDetectElasticIP(t *testing.T) {
 	if len(elasticIPResponse) != 2 {
 		t.Fatalf("unexpected detect elastic ip addresses, got %d expected %d", len(elasticIPResponse), 2)
 	}
+
+	// The detected address is matched to its inventory event by the resource id
+	for _, eIP := range elasticIPResponse {
+		if eIP.ResourceID != eIP.IP {
+			t.Fatalf("unexpected elastic ip resource id, got %s expected %s", eIP.ResourceID, eIP.IP)
+		}
+	}
 }
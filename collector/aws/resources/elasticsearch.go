@@ -8,6 +8,7 @@ import (
 	"finala/collector/config"
 	"finala/expression"
 	"finala/interpolation"
+	"fmt"
 	"time"
 
 	awsClient "github.com/aws/aws-sdk-go/aws"
@@ -42,7 +43,6 @@ type ElasticSearchManager struct {
 // DetectedElasticSearch defines the detected AWS Elasticsearch cluster
 type DetectedElasticSearch struct {
 	Metric        string
-	Region        string
 	InstanceType  string
 	InstanceCount int64
 	collector.PriceDetectedFields
@@ -143,6 +143,18 @@ func (esm *ElasticSearchManager) Detect(metrics []config.MetricConfig) (interfac
 			"ebs_hour_price":      hourlyEBSVolumePrice,
 			"region":              esm.awsManager.GetRegion()}).Debug("Found the following price list")
 
+		hourlyClusterPrice := instancePrice*float64(*cluster.ElasticsearchClusterConfig.InstanceCount) + hourlyEBSVolumePrice
+
+		esm.awsManager.ExamineResource(esm.Name, func() collector.PriceDetectedFields {
+			tagsData, _ := esm.getTags(cluster.ARN)
+			return collector.PriceDetectedFields{
+				ResourceID:    *cluster.ARN,
+				PricePerHour:  hourlyClusterPrice,
+				PricePerMonth: hourlyClusterPrice * collector.TotalMonthHours,
+				Tag:           tagsData,
+			}
+		})
+
 		for _, metric := range metrics {
 			log.WithFields(log.Fields{
 				"cluster_arn": *cluster.ARN,
@@ -185,7 +197,6 @@ func (esm *ElasticSearchManager) Detect(metrics []config.MetricConfig) (interfac
 			}
 
 			if expression {
-				hourlyClusterPrice := instancePrice*float64(*cluster.ElasticsearchClusterConfig.InstanceCount) + hourlyEBSVolumePrice
 				log.WithFields(log.Fields{
 					"metric_name":         metric.Description,
 					"constraint_operator": metric.Constraint.Operator,
@@ -196,29 +207,23 @@ func (esm *ElasticSearchManager) Detect(metrics []config.MetricConfig) (interfac
 					"region":              esm.awsManager.GetRegion(),
 				}).Info("ElasticSearch cluster detected as unutilized resource")
 
-				tags, err := esm.client.ListTags(&elasticsearch.ListTagsInput{
-					ARN: cluster.ARN,
-				})
+				tagsData, err := esm.getTags(cluster.ARN)
 				if err != nil {
-					log.WithField("error", err).Error("could not list tags")
 					continue
 				}
 
-				tagsData := map[string]string{}
-				for _, tag := range tags.TagList {
-					tagsData[*tag.Key] = *tag.Value
-				}
-
 				elasticsearch := DetectedElasticSearch{
-					Region:        esm.awsManager.GetRegion(),
 					Metric:        metric.Description,
 					InstanceType:  *cluster.ElasticsearchClusterConfig.InstanceType,
 					InstanceCount: *cluster.ElasticsearchClusterConfig.InstanceCount,
 					PriceDetectedFields: collector.PriceDetectedFields{
-						ResourceID:    *cluster.ARN,
-						PricePerHour:  hourlyClusterPrice,
-						PricePerMonth: hourlyClusterPrice * collector.TotalMonthHours,
-						Tag:           tagsData,
+						ResourceID:        *cluster.ARN,
+						ARN:               *cluster.ARN,
+						ConsoleURL:        esm.awsManager.GetConsoleURL("aos/home", fmt.Sprintf("opensearch/domains/%s", *cluster.DomainName)),
+						PricePerHour:      hourlyClusterPrice,
+						PricePerMonth:     hourlyClusterPrice * collector.TotalMonthHours,
+						Tag:               tagsData,
+						AccountDimensions: esm.awsManager.GetAccountDimensions(),
 					},
 				}
 
@@ -237,6 +242,24 @@ func (esm *ElasticSearchManager) Detect(metrics []config.MetricConfig) (interfac
 	return detectedElasticSearchClusters, nil
 }
 
+// getTags returns the elasticsearch cluster tags
+func (esm *ElasticSearchManager) getTags(arn *string) (map[string]string, error) {
+
+	tagsData := map[string]string{}
+	tags, err := esm.client.ListTags(&elasticsearch.ListTagsInput{
+		ARN: arn,
+	})
+	if err != nil {
+		log.WithField("error", err).Error("could not list tags")
+		return tagsData, err
+	}
+
+	for _, tag := range tags.TagList {
+		tagsData[*tag.Key] = *tag.Value
+	}
+	return tagsData, nil
+}
+
 // getPricingFilterInput prepares Elasticsearch pricing filter
 func (esm *ElasticSearchManager) getPricingFilterInput(extraFilters []*pricing.Filter) pricing.GetProductsInput {
 	filters := []*pricing.Filter{
@@ -35,7 +35,6 @@ type ELBManager struct {
 // DetectedELB define the detected AWS ELB instances
 type DetectedELB struct {
 	Metric string
-	Region string
 	collector.PriceDetectedFields
 }
 
@@ -103,6 +102,16 @@ func (el *ELBManager) Detect(metrics []config.MetricConfig) (interface{}, error)
 			},
 		}), "", el.awsManager.GetRegion())
 
+		el.awsManager.ExamineResource(el.Name, func() collector.PriceDetectedFields {
+			return collector.PriceDetectedFields{
+				ResourceID:    *instance.LoadBalancerName,
+				LaunchTime:    *instance.CreatedTime,
+				PricePerHour:  price,
+				PricePerMonth: price * collector.TotalMonthHours,
+				Tag:           el.getTags(instance.LoadBalancerName),
+			}
+		})
+
 		for _, metric := range metrics {
 
 			log.WithFields(log.Fields{
@@ -152,29 +161,19 @@ func (el *ELBManager) Detect(metrics []config.MetricConfig) (interface{}, error)
 					"region":              el.awsManager.GetRegion(),
 				}).Info("LoadBalancer detected as unutilized resource")
 
-				tags, err := el.client.DescribeTags(&elb.DescribeTagsInput{
-					LoadBalancerNames: []*string{instance.LoadBalancerName},
-				})
-
-				tagsData := map[string]string{}
-				if err == nil {
-					for _, tags := range tags.TagDescriptions {
-						for _, tag := range tags.Tags {
-							tagsData[*tag.Key] = *tag.Value
-						}
-
-					}
-				}
+				tagsData := el.getTags(instance.LoadBalancerName)
 
 				elb := DetectedELB{
-					Region: el.awsManager.GetRegion(),
 					Metric: metric.Description,
 					PriceDetectedFields: collector.PriceDetectedFields{
-						ResourceID:    *instance.LoadBalancerName,
-						LaunchTime:    *instance.CreatedTime,
-						PricePerHour:  price,
-						PricePerMonth: price * collector.TotalMonthHours,
-						Tag:           tagsData,
+						ResourceID:        *instance.LoadBalancerName,
+						ARN:               el.awsManager.GetARN("elasticloadbalancing", fmt.Sprintf("loadbalancer/%s", *instance.LoadBalancerName)),
+						ConsoleURL:        el.awsManager.GetConsoleURL("ec2/home", fmt.Sprintf("LoadBalancers:search=%s", *instance.LoadBalancerName)),
+						LaunchTime:        *instance.CreatedTime,
+						PricePerHour:      price,
+						PricePerMonth:     price * collector.TotalMonthHours,
+						Tag:               tagsData,
+						AccountDimensions: el.awsManager.GetAccountDimensions(),
 					},
 				}
 
@@ -196,6 +195,26 @@ func (el *ELBManager) Detect(metrics []config.MetricConfig) (interface{}, error)
 
 }
 
+// getTags returns the elb tags
+func (el *ELBManager) getTags(loadBalancer *string) map[string]string {
+
+	tagsData := map[string]string{}
+	tags, err := el.client.DescribeTags(&elb.DescribeTagsInput{
+		LoadBalancerNames: []*string{loadBalancer},
+	})
+	if err != nil {
+		log.WithError(err).WithField("load_balancer", *loadBalancer).Error("could not describe elb tags")
+		return tagsData
+	}
+
+	for _, tags := range tags.TagDescriptions {
+		for _, tag := range tags.Tags {
+			tagsData[*tag.Key] = *tag.Value
+		}
+	}
+	return tagsData
+}
+
 // getPricingFilterInput prepare document elb pricing filter
 func (el *ELBManager) getPricingFilterInput(extraFilters []*pricing.Filter) pricing.GetProductsInput {
 	filters := []*pricing.Filter{
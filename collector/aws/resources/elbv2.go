@@ -9,6 +9,7 @@ import (
 	"finala/expression"
 	"fmt"
 	"regexp"
+	"strings"
 	"time"
 
 	awsClient "github.com/aws/aws-sdk-go/aws"
@@ -22,6 +23,8 @@ import (
 type ELBV2ClientDescreptor interface {
 	DescribeLoadBalancers(*elbv2.DescribeLoadBalancersInput) (*elbv2.DescribeLoadBalancersOutput, error)
 	DescribeTags(*elbv2.DescribeTagsInput) (*elbv2.DescribeTagsOutput, error)
+	DescribeTargetGroups(*elbv2.DescribeTargetGroupsInput) (*elbv2.DescribeTargetGroupsOutput, error)
+	DescribeTargetHealth(*elbv2.DescribeTargetHealthInput) (*elbv2.DescribeTargetHealthOutput, error)
 }
 
 // ELBV2Manager describe ELB struct
@@ -34,12 +37,21 @@ type ELBV2Manager struct {
 
 // DetectedELBV2 defines the detected AWS ELB instances
 type DetectedELBV2 struct {
-	Metric string
-	Region string
-	Type   string
+	Metric       string
+	Type         string
+	TargetGroups []ELBV2TargetGroup
 	collector.PriceDetectedFields
 }
 
+// ELBV2TargetGroup defines the registered and healthy targets of a load balancer target group
+type ELBV2TargetGroup struct {
+	Name              string
+	ARN               string
+	TargetType        string
+	RegisteredTargets int
+	HealthyTargets    int
+}
+
 // loadBalancerConfig defines loadbalancer's configuration of metrics and pricing
 type loadBalancerConfig struct {
 	cloudWatchNamespace string
@@ -163,6 +175,21 @@ func (el *ELBV2Manager) Detect(metrics []config.MetricConfig) (interface{}, erro
 				})
 			price, _ = el.awsManager.GetPricingClient().GetPrice(el.getPricingFilterInput(currentPricingFilters), "", el.awsManager.GetRegion())
 		}
+
+		targetGroups, targetGroupsErr := el.describeTargetGroupsHealth(instance.LoadBalancerArn)
+
+		el.awsManager.ExamineResource(el.Name, func() collector.PriceDetectedFields {
+			return collector.PriceDetectedFields{
+				ResourceID:    *instance.LoadBalancerName,
+				LaunchTime:    *instance.CreatedTime,
+				PricePerHour:  price,
+				PricePerMonth: price * collector.TotalMonthHours,
+				Tag:           el.getTags(instance.LoadBalancerArn),
+			}
+		})
+
+		// A load balancer is detected once with all its matched metrics, so its price is counted once
+		matchedMetrics := []string{}
 		for _, metric := range metrics {
 
 			log.WithFields(log.Fields{
@@ -170,36 +197,25 @@ func (el *ELBV2Manager) Detect(metrics []config.MetricConfig) (interface{}, erro
 				"metric_name": metric.Description,
 			}).Debug("check metric")
 
-			period := int64(metric.Period.Seconds())
-
-			metricEndTime := now.Add(time.Duration(-metric.StartTime))
-
-			regx, _ := regexp.Compile(".*loadbalancer/")
-
-			elbv2Name := regx.ReplaceAllString(*instance.LoadBalancerArn, "")
-
-			metricInput := awsCloudwatch.GetMetricStatisticsInput{
-				Namespace:  &cloudWatchNameSpace,
-				MetricName: &metric.Description,
-				Period:     &period,
-				StartTime:  &metricEndTime,
-				EndTime:    &now,
-				Dimensions: []*awsCloudwatch.Dimension{
-					{
-						Name:  awsClient.String("LoadBalancer"),
-						Value: &elbv2Name,
-					},
-				},
-			}
-
-			formulaValue, _, err := el.awsManager.GetCloudWatchClient().GetMetric(&metricInput, metric)
-
-			if err != nil {
-				log.WithError(err).WithFields(log.Fields{
-					"name":        *instance.LoadBalancerName,
-					"metric_name": metric.Description,
-				}).Error("Could not get cloudwatch metric data")
-				continue
+			var formulaValue float64
+			// A metric without cloudwatch metrics checks the healthy targets count of the load balancer
+			if len(metric.Data) == 0 {
+				if targetGroupsErr != nil {
+					continue
+				}
+				for _, targetGroup := range targetGroups {
+					formulaValue += float64(targetGroup.HealthyTargets)
+				}
+			} else {
+				value, err := el.getMetricValue(instance, cloudWatchNameSpace, metric, now)
+				if err != nil {
+					log.WithError(err).WithFields(log.Fields{
+						"name":        *instance.LoadBalancerName,
+						"metric_name": metric.Description,
+					}).Error("Could not get cloudwatch metric data")
+					continue
+				}
+				formulaValue = value
 			}
 
 			expression, err := expression.BoolExpression(formulaValue, metric.Constraint.Value, metric.Constraint.Operator)
@@ -218,40 +234,38 @@ func (el *ELBV2Manager) Detect(metrics []config.MetricConfig) (interface{}, erro
 					"region":              el.awsManager.GetRegion(),
 				}).Info("LoadBalancer detected as unutilized resource")
 
-				tags, err := el.client.DescribeTags(&elbv2.DescribeTagsInput{
-					ResourceArns: []*string{instance.LoadBalancerArn},
-				})
-				tagsData := map[string]string{}
-				if err == nil {
-					for _, tags := range tags.TagDescriptions {
-						for _, tag := range tags.Tags {
-							tagsData[*tag.Key] = *tag.Value
-						}
-
-					}
-				}
-
-				elbv2 := DetectedELBV2{
-					Region: el.awsManager.GetRegion(),
-					Metric: metric.Description,
-					Type:   *instance.Type,
-					PriceDetectedFields: collector.PriceDetectedFields{
-						ResourceID:    *instance.LoadBalancerName,
-						LaunchTime:    *instance.CreatedTime,
-						PricePerHour:  price,
-						PricePerMonth: price * collector.TotalMonthHours,
-						Tag:           tagsData,
-					},
-				}
+				matchedMetrics = append(matchedMetrics, metric.Description)
+			}
+		}
 
-				el.awsManager.GetCollector().AddResource(collector.EventCollector{
-					ResourceName: el.Name,
-					Data:         elbv2,
-				})
+		if len(matchedMetrics) == 0 {
+			continue
+		}
 
-				detectedELBV2 = append(detectedELBV2, elbv2)
-			}
+		tagsData := el.getTags(instance.LoadBalancerArn)
+
+		elbv2 := DetectedELBV2{
+			Metric:       strings.Join(matchedMetrics, ", "),
+			Type:         *instance.Type,
+			TargetGroups: targetGroups,
+			PriceDetectedFields: collector.PriceDetectedFields{
+				ResourceID:        *instance.LoadBalancerName,
+				ARN:               *instance.LoadBalancerArn,
+				ConsoleURL:        el.awsManager.GetConsoleURL("ec2/home", fmt.Sprintf("LoadBalancer:loadBalancerArn=%s", *instance.LoadBalancerArn)),
+				LaunchTime:        *instance.CreatedTime,
+				PricePerHour:      price,
+				PricePerMonth:     price * collector.TotalMonthHours,
+				Tag:               tagsData,
+				AccountDimensions: el.awsManager.GetAccountDimensions(),
+			},
 		}
+
+		el.awsManager.GetCollector().AddResource(collector.EventCollector{
+			ResourceName: el.Name,
+			Data:         elbv2,
+		})
+
+		detectedELBV2 = append(detectedELBV2, elbv2)
 	}
 
 	el.awsManager.GetCollector().CollectFinish(el.Name)
@@ -260,6 +274,120 @@ func (el *ELBV2Manager) Detect(metrics []config.MetricConfig) (interface{}, erro
 
 }
 
+// getMetricValue returns the cloudwatch metric formula value of the given load balancer
+func (el *ELBV2Manager) getMetricValue(instance *elbv2.LoadBalancer, cloudWatchNameSpace string, metric config.MetricConfig, now time.Time) (float64, error) {
+
+	period := int64(metric.Period.Seconds())
+
+	metricEndTime := now.Add(time.Duration(-metric.StartTime))
+
+	regx, _ := regexp.Compile(".*loadbalancer/")
+
+	elbv2Name := regx.ReplaceAllString(*instance.LoadBalancerArn, "")
+
+	metricInput := awsCloudwatch.GetMetricStatisticsInput{
+		Namespace:  &cloudWatchNameSpace,
+		MetricName: &metric.Description,
+		Period:     &period,
+		StartTime:  &metricEndTime,
+		EndTime:    &now,
+		Dimensions: []*awsCloudwatch.Dimension{
+			{
+				Name:  awsClient.String("LoadBalancer"),
+				Value: &elbv2Name,
+			},
+		},
+	}
+
+	formulaValue, _, err := el.awsManager.GetCloudWatchClient().GetMetric(&metricInput, metric)
+
+	return formulaValue, err
+}
+
+// describeTargetGroupsHealth returns the load balancer target groups with their registered and healthy targets count
+func (el *ELBV2Manager) describeTargetGroupsHealth(loadBalancerArn *string) ([]ELBV2TargetGroup, error) {
+
+	targetGroups, err := el.describeTargetGroups(loadBalancerArn, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	targetGroupsHealth := []ELBV2TargetGroup{}
+	for _, targetGroup := range targetGroups {
+		resp, err := el.client.DescribeTargetHealth(&elbv2.DescribeTargetHealthInput{
+			TargetGroupArn: targetGroup.TargetGroupArn,
+		})
+		if err != nil {
+			log.WithError(err).WithField("target_group", *targetGroup.TargetGroupArn).Error("could not describe target group health")
+			return nil, err
+		}
+
+		targetGroupHealth := ELBV2TargetGroup{
+			Name:              awsClient.StringValue(targetGroup.TargetGroupName),
+			ARN:               *targetGroup.TargetGroupArn,
+			TargetType:        awsClient.StringValue(targetGroup.TargetType),
+			RegisteredTargets: len(resp.TargetHealthDescriptions),
+		}
+
+		for _, targetHealth := range resp.TargetHealthDescriptions {
+			if targetHealth.TargetHealth != nil && awsClient.StringValue(targetHealth.TargetHealth.State) == elbv2.TargetHealthStateEnumHealthy {
+				targetGroupHealth.HealthyTargets++
+			}
+		}
+
+		targetGroupsHealth = append(targetGroupsHealth, targetGroupHealth)
+	}
+
+	return targetGroupsHealth, nil
+}
+
+// describeTargetGroups returns the target groups of the given load balancer
+func (el *ELBV2Manager) describeTargetGroups(loadBalancerArn *string, marker *string, targetGroups []*elbv2.TargetGroup) ([]*elbv2.TargetGroup, error) {
+
+	input := &elbv2.DescribeTargetGroupsInput{
+		LoadBalancerArn: loadBalancerArn,
+		Marker:          marker,
+	}
+
+	resp, err := el.client.DescribeTargetGroups(input)
+	if err != nil {
+		log.WithField("error", err).Error("could not describe load balancer target groups")
+		return nil, err
+	}
+
+	if targetGroups == nil {
+		targetGroups = []*elbv2.TargetGroup{}
+	}
+
+	targetGroups = append(targetGroups, resp.TargetGroups...)
+
+	if resp.NextMarker != nil {
+		return el.describeTargetGroups(loadBalancerArn, resp.NextMarker, targetGroups)
+	}
+
+	return targetGroups, nil
+}
+
+// getTags returns the elbV2 tags
+func (el *ELBV2Manager) getTags(loadBalancer *string) map[string]string {
+
+	tagsData := map[string]string{}
+	tags, err := el.client.DescribeTags(&elbv2.DescribeTagsInput{
+		ResourceArns: []*string{loadBalancer},
+	})
+	if err != nil {
+		log.WithError(err).WithField("load_balancer", *loadBalancer).Error("could not describe elbV2 tags")
+		return tagsData
+	}
+
+	for _, tags := range tags.TagDescriptions {
+		for _, tag := range tags.Tags {
+			tagsData[*tag.Key] = *tag.Value
+		}
+	}
+	return tagsData
+}
+
 // getPricingFilterInput prepare document elb pricing filter
 func (el *ELBV2Manager) getPricingFilterInput(extraFilters []*pricing.Filter) pricing.GetProductsInput {
 	filters := []*pricing.Filter{
@@ -28,6 +28,8 @@ var defaultELBV2Mock = elbv2.DescribeLoadBalancersOutput{
 
 type MockAWSELBV2Client struct {
 	responseDescribeLoadBalancers elbv2.DescribeLoadBalancersOutput
+	responseDescribeTargetGroups  elbv2.DescribeTargetGroupsOutput
+	responseDescribeTargetHealth  map[string]elbv2.DescribeTargetHealthOutput
 	err                           error
 }
 
@@ -43,6 +45,19 @@ func (r *MockAWSELBV2Client) DescribeTags(*elbv2.DescribeTagsInput) (*elbv2.Desc
 
 }
 
+func (r *MockAWSELBV2Client) DescribeTargetGroups(*elbv2.DescribeTargetGroupsInput) (*elbv2.DescribeTargetGroupsOutput, error) {
+
+	return &r.responseDescribeTargetGroups, r.err
+
+}
+
+func (r *MockAWSELBV2Client) DescribeTargetHealth(input *elbv2.DescribeTargetHealthInput) (*elbv2.DescribeTargetHealthOutput, error) {
+
+	response := r.responseDescribeTargetHealth[*input.TargetGroupArn]
+	return &response, r.err
+
+}
+
 func TestDescribeLoadBalancersV2(t *testing.T) {
 
 	collector := collectorTestutils.NewMockCollector()
@@ -226,3 +241,162 @@ func TestDetectELBV2Error(t *testing.T) {
 		})
 	}
 }
+
+func TestDetectELBV2MultipleMetrics(t *testing.T) {
+
+	metricConfig := []config.MetricConfig{
+		{
+			Description: "Request count",
+			Data: []config.MetricDataConfiguration{
+				{
+					Name:      "TestMetric",
+					Statistic: "Sum",
+				},
+			},
+			Constraint: config.MetricConstraintConfig{
+				Operator: "==",
+				Value:    5,
+			},
+			Period:    1,
+			StartTime: 1,
+		},
+		{
+			Description: "Healthy targets",
+			Constraint: config.MetricConstraintConfig{
+				Operator: "==",
+				Value:    0,
+			},
+		},
+	}
+
+	collector := collectorTestutils.NewMockCollector()
+	mockCloudwatch := awsTestutils.NewMockCloudwatch(nil)
+	mockPrice := awsTestutils.NewMockPricing(nil)
+	detector := awsTestutils.AWSManager(collector, mockCloudwatch, mockPrice, "us-east-1")
+
+	mockClient := MockAWSELBV2Client{
+		responseDescribeLoadBalancers: defaultELBV2Mock,
+	}
+
+	elbManager, err := NewELBV2Manager(detector, &mockClient)
+	if err != nil {
+		t.Fatalf("unexpected elbv2 manager error happened, got %v expected %v", err, nil)
+	}
+
+	response, err := elbManager.Detect(metricConfig)
+	if err != nil {
+		t.Fatalf("unexpected elbv2 detection error happened, got %v expected %v", err, nil)
+	}
+
+	// The load balancer matches both metrics, and is detected once so its price is counted once
+	elbv2Response := response.([]DetectedELBV2)
+	if len(elbv2Response) != 1 {
+		t.Fatalf("unexpected elbv2 detected, got %d expected %d", len(elbv2Response), 1)
+	}
+
+	if len(collector.Events) != 1 {
+		t.Fatalf("unexpected collector elbv2 resources, got %d expected %d", len(collector.Events), 1)
+	}
+
+	if elbv2Response[0].Metric != "Request count, Healthy targets" {
+		t.Fatalf("unexpected elbv2 metric, got %s expected %s", elbv2Response[0].Metric, "Request count, Healthy targets")
+	}
+}
+
+func TestDetectELBV2TargetHealth(t *testing.T) {
+
+	metricConfig := []config.MetricConfig{
+		{
+			Description: "Healthy targets",
+			Constraint: config.MetricConstraintConfig{
+				Operator: "==",
+				Value:    0,
+			},
+		},
+	}
+
+	targetGroups := elbv2.DescribeTargetGroupsOutput{
+		TargetGroups: []*elbv2.TargetGroup{
+			{
+				TargetGroupName: awsClient.String("empty"),
+				TargetGroupArn:  awsClient.String("tg-empty"),
+				TargetType:      awsClient.String("instance"),
+			},
+			{
+				TargetGroupName: awsClient.String("unhealthy"),
+				TargetGroupArn:  awsClient.String("tg-unhealthy"),
+				TargetType:      awsClient.String("ip"),
+			},
+		},
+	}
+
+	newTargetHealth := func(states ...string) elbv2.DescribeTargetHealthOutput {
+		output := elbv2.DescribeTargetHealthOutput{}
+		for _, state := range states {
+			output.TargetHealthDescriptions = append(output.TargetHealthDescriptions, &elbv2.TargetHealthDescription{
+				TargetHealth: &elbv2.TargetHealth{State: awsClient.String(state)},
+			})
+		}
+		return output
+	}
+
+	testCases := []struct {
+		name                   string
+		unhealthyGroupStates   []string
+		expectedDetected       int
+		expectedRegistered     int
+		expectedHealthyTargets int
+	}{
+		{"no healthy targets", []string{"unhealthy", "draining"}, 1, 2, 0},
+		{"healthy target", []string{"unhealthy", "healthy"}, 0, 2, 1},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+
+			collector := collectorTestutils.NewMockCollector()
+			mockPrice := awsTestutils.NewMockPricing(nil)
+			detector := awsTestutils.AWSManager(collector, nil, mockPrice, "us-east-1")
+
+			mockClient := MockAWSELBV2Client{
+				responseDescribeLoadBalancers: defaultELBV2Mock,
+				responseDescribeTargetGroups:  targetGroups,
+				responseDescribeTargetHealth: map[string]elbv2.DescribeTargetHealthOutput{
+					"tg-unhealthy": newTargetHealth(test.unhealthyGroupStates...),
+				},
+			}
+
+			elbManager, err := NewELBV2Manager(detector, &mockClient)
+			if err != nil {
+				t.Fatalf("unexpected elbv2 manager error happened, got %v expected %v", err, nil)
+			}
+
+			response, err := elbManager.Detect(metricConfig)
+			if err != nil {
+				t.Fatalf("unexpected elbv2 detection error happened, got %v expected %v", err, nil)
+			}
+
+			elbv2Response := response.([]DetectedELBV2)
+			if len(elbv2Response) != test.expectedDetected {
+				t.Fatalf("unexpected elbv2 detected, got %d expected %d", len(elbv2Response), test.expectedDetected)
+			}
+
+			if test.expectedDetected == 0 {
+				return
+			}
+
+			detectedTargetGroups := elbv2Response[0].TargetGroups
+			if len(detectedTargetGroups) != 2 {
+				t.Fatalf("unexpected target groups count, got %d expected %d", len(detectedTargetGroups), 2)
+			}
+
+			if detectedTargetGroups[0].RegisteredTargets != 0 {
+				t.Fatalf("unexpected empty target group registered targets, got %d expected %d", detectedTargetGroups[0].RegisteredTargets, 0)
+			}
+
+			if detectedTargetGroups[1].RegisteredTargets != test.expectedRegistered || detectedTargetGroups[1].HealthyTargets != test.expectedHealthyTargets {
+				t.Fatalf("unexpected target group targets, got %d/%d expected %d/%d", detectedTargetGroups[1].RegisteredTargets, detectedTargetGroups[1].HealthyTargets, test.expectedRegistered, test.expectedHealthyTargets)
+			}
+		})
+	}
+}
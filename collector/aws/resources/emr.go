@@ -0,0 +1,345 @@
+package resources
+
+import (
+	"errors"
+	"finala/collector"
+	"finala/collector/aws/common"
+	"finala/collector/aws/register"
+	"finala/collector/config"
+	"finala/expression"
+	"fmt"
+	"time"
+
+	awsClient "github.com/aws/aws-sdk-go/aws"
+	awsCloudwatch "github.com/aws/aws-sdk-go/service/cloudwatch"
+	"github.com/aws/aws-sdk-go/service/emr"
+	"github.com/aws/aws-sdk-go/service/pricing"
+	log "github.com/sirupsen/logrus"
+)
+
+// EMRClientDescreptor is an interface defining the aws emr client
+type EMRClientDescreptor interface {
+	ListClusters(*emr.ListClustersInput) (*emr.ListClustersOutput, error)
+	DescribeCluster(*emr.DescribeClusterInput) (*emr.DescribeClusterOutput, error)
+	ListInstances(*emr.ListInstancesInput) (*emr.ListInstancesOutput, error)
+}
+
+// EMRManager describes the EMR clusters struct
+type EMRManager struct {
+	client                EMRClientDescreptor
+	awsManager            common.AWSManager
+	namespace             string
+	servicePricingCode    string
+	ec2ServicePricingCode string
+	prices                map[string]float64
+	Name                  collector.ResourceIdentifier
+}
+
+// DetectedEMR defines the detected AWS EMR clusters
+type DetectedEMR struct {
+	Metric        string
+	Name          string
+	ClusterID     string
+	InstanceTypes map[string]int64
+	collector.PriceDetectedFields
+}
+
+func init() {
+	register.Registry("emr", NewEMRManager)
+}
+
+// NewEMRManager implements AWS GO SDK
+func NewEMRManager(awsManager common.AWSManager, client interface{}) (common.ResourceDetection, error) {
+
+	if client == nil {
+		client = emr.New(awsManager.GetSession())
+	}
+
+	emrClient, ok := client.(EMRClientDescreptor)
+	if !ok {
+		return nil, errors.New("invalid emr client")
+	}
+
+	return &EMRManager{
+		client:                emrClient,
+		awsManager:            awsManager,
+		namespace:             "AWS/ElasticMapReduce",
+		servicePricingCode:    "ElasticMapReduce",
+		ec2ServicePricingCode: "AmazonEC2",
+		prices:                map[string]float64{},
+		Name:                  awsManager.GetResourceIdentifier("emr"),
+	}, nil
+}
+
+// Detect checks which EMR clusters are idle
+func (em *EMRManager) Detect(metrics []config.MetricConfig) (interface{}, error) {
+
+	log.WithFields(log.Fields{
+		"region":   em.awsManager.GetRegion(),
+		"resource": "emr",
+	}).Info("starting to analyze resource")
+
+	em.awsManager.GetCollector().CollectStart(em.Name)
+
+	detected := []DetectedEMR{}
+
+	pricingRegionPrefix, err := em.awsManager.GetPricingClient().GetRegionPrefix(em.awsManager.GetRegion())
+	if err != nil {
+		log.WithError(err).WithFields(log.Fields{
+			"region": em.awsManager.GetRegion(),
+		}).Error("Could not get pricing region prefix")
+		em.awsManager.GetCollector().CollectError(em.Name, err)
+		return detected, err
+	}
+
+	clusters, err := em.listClusters(nil, nil)
+	if err != nil {
+		em.awsManager.GetCollector().CollectError(em.Name, err)
+		return detected, err
+	}
+
+	now := time.Now()
+
+	for _, cluster := range clusters {
+		log.WithField("cluster_id", *cluster.Id).Debug("checking emr cluster")
+
+		var launchTime time.Time
+		if cluster.Status != nil && cluster.Status.Timeline != nil {
+			launchTime = awsClient.TimeValue(cluster.Status.Timeline.CreationDateTime)
+		}
+
+		em.awsManager.ExamineResource(em.Name, func() collector.PriceDetectedFields {
+			_, price := em.getClusterInstances(pricingRegionPrefix, cluster.Id)
+			return collector.PriceDetectedFields{
+				ResourceID:    awsClient.StringValue(cluster.ClusterArn),
+				LaunchTime:    launchTime,
+				PricePerHour:  price,
+				PricePerMonth: price * collector.TotalMonthHours,
+				Tag:           em.getTags(cluster.Id),
+			}
+		})
+
+		for _, metric := range metrics {
+			log.WithFields(log.Fields{
+				"cluster_id":  *cluster.Id,
+				"metric_name": metric.Description,
+			}).Debug("check metric")
+
+			period := int64(metric.Period.Seconds())
+			metricEndTime := now.Add(time.Duration(-metric.StartTime))
+			metricInput := awsCloudwatch.GetMetricStatisticsInput{
+				Namespace: &em.namespace,
+				Period:    &period,
+				StartTime: &metricEndTime,
+				EndTime:   &now,
+				Dimensions: []*awsCloudwatch.Dimension{
+					{
+						Name:  awsClient.String("JobFlowId"),
+						Value: cluster.Id,
+					},
+				},
+			}
+
+			formulaValue, _, err := em.awsManager.GetCloudWatchClient().GetMetric(&metricInput, metric)
+			if err != nil {
+				log.WithError(err).WithFields(log.Fields{
+					"cluster_id":  *cluster.Id,
+					"metric_name": metric.Description,
+				}).Error("Could not get cloudwatch metric data")
+				continue
+			}
+
+			expression, err := expression.BoolExpression(formulaValue, metric.Constraint.Value, metric.Constraint.Operator)
+			if err != nil {
+				log.WithField("error", err).Error("could not parse expression")
+				continue
+			}
+
+			if expression {
+
+				log.WithFields(log.Fields{
+					"metric_name":         metric.Description,
+					"constraint_operator": metric.Constraint.Operator,
+					"constraint_Value":    metric.Constraint.Value,
+					"formula_value":       formulaValue,
+					"cluster_id":          *cluster.Id,
+					"region":              em.awsManager.GetRegion(),
+				}).Info("EMR cluster detected as idle resource")
+
+				instanceTypes, price := em.getClusterInstances(pricingRegionPrefix, cluster.Id)
+
+				detectedCluster := DetectedEMR{
+					Metric:        metric.Description,
+					Name:          awsClient.StringValue(cluster.Name),
+					ClusterID:     *cluster.Id,
+					InstanceTypes: instanceTypes,
+					PriceDetectedFields: collector.PriceDetectedFields{
+						ResourceID:        awsClient.StringValue(cluster.ClusterArn),
+						ARN:               awsClient.StringValue(cluster.ClusterArn),
+						ConsoleURL:        em.awsManager.GetConsoleURL("emr/home", fmt.Sprintf("/clusterDetails/%s", *cluster.Id)),
+						LaunchTime:        launchTime,
+						PricePerHour:      price,
+						PricePerMonth:     price * collector.TotalMonthHours,
+						Tag:               em.getTags(cluster.Id),
+						AccountDimensions: em.awsManager.GetAccountDimensions(),
+					},
+				}
+
+				em.awsManager.GetCollector().AddResource(collector.EventCollector{
+					ResourceName: em.Name,
+					Data:         detectedCluster,
+				})
+
+				detected = append(detected, detectedCluster)
+			}
+		}
+	}
+
+	em.awsManager.GetCollector().CollectFinish(em.Name)
+
+	return detected, nil
+}
+
+// getClusterInstances returns the running instances count by instance type, and the cluster hourly price
+func (em *EMRManager) getClusterInstances(pricingRegionPrefix string, clusterID *string) (map[string]int64, float64) {
+
+	instances, err := em.listInstances(clusterID, nil, nil)
+	if err != nil {
+		log.WithError(err).WithField("cluster_id", *clusterID).Error("could not list emr cluster instances")
+	}
+
+	instanceTypes := map[string]int64{}
+	var price float64
+	for _, instance := range instances {
+		instanceType := awsClient.StringValue(instance.InstanceType)
+		instanceTypes[instanceType]++
+		price += em.getInstanceHourlyPrice(pricingRegionPrefix, instance)
+	}
+
+	return instanceTypes, price
+}
+
+// getInstanceHourlyPrice returns the EMR charge of the instance with its EC2 on demand price.
+// Spot instances are charged only by the EMR charge.
+func (em *EMRManager) getInstanceHourlyPrice(pricingRegionPrefix string, instance *emr.Instance) float64 {
+
+	instanceType := awsClient.StringValue(instance.InstanceType)
+
+	price := em.getCachedPrice(fmt.Sprintf("emr:%s", instanceType), em.getPricingFilterInput(pricingRegionPrefix, instanceType))
+	if awsClient.StringValue(instance.Market) != emr.MarketTypeSpot {
+		price += em.getCachedPrice(fmt.Sprintf("ec2:%s", instanceType), getEC2InstanceTypePricingFilterInput(em.ec2ServicePricingCode, instanceType, "Linux"))
+	}
+
+	return price
+}
+
+// getCachedPrice returns the price of the given filters, the prices are cached by key for the whole detection
+func (em *EMRManager) getCachedPrice(key string, pricingFilters pricing.GetProductsInput) float64 {
+
+	if price, found := em.prices[key]; found {
+		return price
+	}
+
+	price, err := em.awsManager.GetPricingClient().GetPrice(pricingFilters, "", em.awsManager.GetRegion())
+	if err != nil {
+		log.WithError(err).WithFields(log.Fields{
+			"region":        em.awsManager.GetRegion(),
+			"price_filters": pricingFilters,
+		}).Error("could not get emr instance price")
+	}
+
+	em.prices[key] = price
+	return price
+}
+
+// getTags returns the emr cluster tags
+func (em *EMRManager) getTags(clusterID *string) map[string]string {
+
+	tagsData := map[string]string{}
+	resp, err := em.client.DescribeCluster(&emr.DescribeClusterInput{ClusterId: clusterID})
+	if err != nil {
+		log.WithError(err).WithField("cluster_id", *clusterID).Error("could not describe emr cluster")
+		return tagsData
+	}
+
+	for _, tag := range resp.Cluster.Tags {
+		tagsData[*tag.Key] = *tag.Value
+	}
+	return tagsData
+}
+
+// getPricingFilterInput prepares the EMR charge pricing filter of the given instance type
+func (em *EMRManager) getPricingFilterInput(pricingRegionPrefix, instanceType string) pricing.GetProductsInput {
+	return pricing.GetProductsInput{
+		ServiceCode: &em.servicePricingCode,
+		Filters: []*pricing.Filter{
+			{
+				Type:  awsClient.String("TERM_MATCH"),
+				Field: awsClient.String("termType"),
+				Value: awsClient.String("OnDemand"),
+			},
+			{
+				Type:  awsClient.String("TERM_MATCH"),
+				Field: awsClient.String("usagetype"),
+				Value: awsClient.String(fmt.Sprintf("%sBoxUsage:%s", pricingRegionPrefix, instanceType)),
+			},
+		},
+	}
+}
+
+// listClusters returns a list of the running and waiting emr clusters
+func (em *EMRManager) listClusters(marker *string, clusters []*emr.ClusterSummary) ([]*emr.ClusterSummary, error) {
+
+	input := &emr.ListClustersInput{
+		Marker: marker,
+		ClusterStates: []*string{
+			awsClient.String(emr.ClusterStateRunning),
+			awsClient.String(emr.ClusterStateWaiting),
+		},
+	}
+
+	resp, err := em.client.ListClusters(input)
+	if err != nil {
+		log.WithField("error", err).Error("could not list emr clusters")
+		return nil, err
+	}
+
+	if clusters == nil {
+		clusters = []*emr.ClusterSummary{}
+	}
+
+	clusters = append(clusters, resp.Clusters...)
+
+	if resp.Marker != nil {
+		return em.listClusters(resp.Marker, clusters)
+	}
+
+	return clusters, nil
+}
+
+// listInstances returns a list of the running instances of the emr cluster
+func (em *EMRManager) listInstances(clusterID *string, marker *string, instances []*emr.Instance) ([]*emr.Instance, error) {
+
+	input := &emr.ListInstancesInput{
+		ClusterId:      clusterID,
+		Marker:         marker,
+		InstanceStates: []*string{awsClient.String(emr.InstanceStateRunning)},
+	}
+
+	resp, err := em.client.ListInstances(input)
+	if err != nil {
+		return nil, err
+	}
+
+	if instances == nil {
+		instances = []*emr.Instance{}
+	}
+
+	instances = append(instances, resp.Instances...)
+
+	if resp.Marker != nil {
+		return em.listInstances(clusterID, resp.Marker, instances)
+	}
+
+	return instances, nil
+}
@@ -0,0 +1,194 @@
+package resources
+
+import (
+	"errors"
+	"finala/collector/aws/pricing"
+	awsTestutils "finala/collector/aws/testutils"
+	"finala/collector/config"
+	collectorTestutils "finala/collector/testutils"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	awsClient "github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudwatch"
+	"github.com/aws/aws-sdk-go/service/emr"
+)
+
+var defaultEMRClustersMock = emr.ListClustersOutput{
+	Clusters: []*emr.ClusterSummary{
+		{
+			Id:         awsClient.String("j-idle"),
+			Name:       awsClient.String("idle-cluster"),
+			ClusterArn: awsClient.String("arn:aws:elasticmapreduce:us-east-1:1:cluster/j-idle"),
+			Status: &emr.ClusterStatus{
+				Timeline: &emr.ClusterTimeline{
+					CreationDateTime: awsClient.Time(time.Now()),
+				},
+			},
+		},
+	},
+}
+
+var defaultEMRInstancesMock = emr.ListInstancesOutput{
+	Instances: []*emr.Instance{
+		{
+			InstanceType: awsClient.String("m5.xlarge"),
+			Market:       awsClient.String(emr.MarketTypeOnDemand),
+		},
+		{
+			InstanceType: awsClient.String("m5.xlarge"),
+			Market:       awsClient.String(emr.MarketTypeOnDemand),
+		},
+		{
+			InstanceType: awsClient.String("r5.2xlarge"),
+			Market:       awsClient.String(emr.MarketTypeSpot),
+		},
+	},
+}
+
+type MockAWSEMRClient struct {
+	err error
+}
+
+func (r *MockAWSEMRClient) ListClusters(*emr.ListClustersInput) (*emr.ListClustersOutput, error) {
+	return &defaultEMRClustersMock, r.err
+}
+
+func (r *MockAWSEMRClient) DescribeCluster(*emr.DescribeClusterInput) (*emr.DescribeClusterOutput, error) {
+	return &emr.DescribeClusterOutput{
+		Cluster: &emr.Cluster{
+			Tags: []*emr.Tag{
+				{Key: awsClient.String("team"), Value: awsClient.String("data")},
+			},
+		},
+	}, r.err
+}
+
+func (r *MockAWSEMRClient) ListInstances(*emr.ListInstancesInput) (*emr.ListInstancesOutput, error) {
+	return &defaultEMRInstancesMock, r.err
+}
+
+func TestNewEMRManager(t *testing.T) {
+
+	collector := collectorTestutils.NewMockCollector()
+	detector := awsTestutils.AWSManager(collector, nil, nil, "us-east-1")
+
+	emrManager, err := NewEMRManager(detector, &MockEmptyClient{})
+	if err == nil {
+		t.Fatalf("unexpected error happened, got nil expected error")
+	}
+	if emrManager != nil {
+		t.Fatalf("unexpected emr manager instance, got %v expected nil", reflect.TypeOf(emrManager))
+	}
+}
+
+func TestDetectEMR(t *testing.T) {
+
+	metrics := []config.MetricConfig{
+		{
+			Description: "Idle cluster",
+			Data: []config.MetricDataConfiguration{
+				{
+					Name:      "IsIdle",
+					Statistic: "Average",
+				},
+			},
+			Constraint: config.MetricConstraintConfig{
+				Operator: ">=",
+				Value:    1,
+			},
+			Period:    time.Hour,
+			StartTime: 24 * time.Hour,
+		},
+	}
+
+	t.Run("detect", func(t *testing.T) {
+
+		collector := collectorTestutils.NewMockCollector()
+		mockCloudwatch := awsTestutils.NewMockCloudwatch(&map[string]cloudwatch.GetMetricStatisticsOutput{
+			"IsIdle": {
+				Datapoints: []*cloudwatch.Datapoint{
+					{Average: awsClient.Float64(1)},
+					{Average: awsClient.Float64(1)},
+				},
+			},
+		})
+		mockPrice := pricing.NewPricingManager(&MockFilterPricingClient{prices: map[string]float64{
+			"m5.xlarge":           0.2,
+			"BoxUsage:m5.xlarge":  0.05,
+			"r5.2xlarge":          0.5,
+			"BoxUsage:r5.2xlarge": 0.1,
+		}}, "us-east-1")
+		detector := awsTestutils.AWSManager(collector, mockCloudwatch, mockPrice, "us-east-1")
+
+		emrManager, err := NewEMRManager(detector, &MockAWSEMRClient{})
+		if err != nil {
+			t.Fatalf("unexpected emr manager error happened, got %v expected %v", err, nil)
+		}
+
+		response, err := emrManager.Detect(metrics)
+		if err != nil {
+			t.Fatalf("unexpected emr detection error happened, got %v expected %v", err, nil)
+		}
+
+		emrResponse, ok := response.([]DetectedEMR)
+		if !ok {
+			t.Fatalf("unexpected emr struct, got %s expected %s", reflect.TypeOf(response), "[]DetectedEMR")
+		}
+
+		if len(emrResponse) != 1 {
+			t.Fatalf("unexpected emr detected, got %d expected %d", len(emrResponse), 1)
+		}
+
+		if len(collector.Events) != 1 {
+			t.Fatalf("unexpected collector emr events, got %d expected %d", len(collector.Events), 1)
+		}
+
+		cluster := emrResponse[0]
+		expectedInstanceTypes := map[string]int64{"m5.xlarge": 2, "r5.2xlarge": 1}
+		if !reflect.DeepEqual(cluster.InstanceTypes, expectedInstanceTypes) {
+			t.Fatalf("unexpected emr instance types, got %v expected %v", cluster.InstanceTypes, expectedInstanceTypes)
+		}
+
+		// Spot instances are charged only by the EMR charge
+		expectedPrice := 2*(0.2+0.05) + 0.1
+		if fmt.Sprintf("%.2f", cluster.PricePerHour) != fmt.Sprintf("%.2f", expectedPrice) {
+			t.Fatalf("unexpected emr price per hour, got %f expected %f", cluster.PricePerHour, expectedPrice)
+		}
+
+		if cluster.Tag["team"] != "data" {
+			t.Fatalf("unexpected emr tags, got %v expected %s", cluster.Tag, "team=data")
+		}
+
+		if cluster.AccountID != "1234" || cluster.Region != "us-east-1" {
+			t.Fatalf("unexpected emr account dimensions, got %+v expected %s", cluster.AccountDimensions, "1234/us-east-1")
+		}
+
+		examined := detector.ExaminedResources["aws_emr"]
+		if len(examined) != 1 {
+			t.Fatalf("unexpected examined emr clusters, got %d expected %d", len(examined), 1)
+		}
+		if fmt.Sprintf("%.2f", examined[0].PricePerHour) != fmt.Sprintf("%.2f", expectedPrice) {
+			t.Fatalf("unexpected examined emr price per hour, got %f expected %f", examined[0].PricePerHour, expectedPrice)
+		}
+	})
+
+	t.Run("detection error", func(t *testing.T) {
+
+		collector := collectorTestutils.NewMockCollector()
+		mockPrice := awsTestutils.NewMockPricing(nil)
+		detector := awsTestutils.AWSManager(collector, nil, mockPrice, "us-east-1")
+
+		emrManager, err := NewEMRManager(detector, &MockAWSEMRClient{err: errors.New("error")})
+		if err != nil {
+			t.Fatalf("unexpected emr manager error happened, got %v expected %v", err, nil)
+		}
+
+		_, err = emrManager.Detect(metrics)
+		if err == nil {
+			t.Fatalf("unexpected detection emr manager error, got nil expected error message")
+		}
+	})
+}
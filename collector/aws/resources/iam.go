@@ -7,9 +7,11 @@ import (
 	"finala/collector/aws/register"
 	"finala/collector/config"
 	"finala/expression"
+	"fmt"
 	"strconv"
 	"time"
 
+	awsClient "github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/iam"
 	log "github.com/sirupsen/logrus"
 )
@@ -34,6 +36,9 @@ type DetectedAWSLastActivity struct {
 	AccessKey    string
 	LastUsedDate time.Time
 	LastActivity string
+	ARN          string
+	ConsoleURL   string
+	collector.AccountDimensions
 }
 
 func init() {
@@ -129,10 +134,13 @@ func (im *IAMManager) Detect(metrics []config.MetricConfig) (interface{}, error)
 				}).Info("user detected")
 
 				userData := DetectedAWSLastActivity{
-					UserName:     *user.UserName,
-					AccessKey:    *accessKeyData.AccessKeyId,
-					LastUsedDate: lastUsedDate,
-					LastActivity: lastActivity,
+					UserName:          *user.UserName,
+					AccessKey:         *accessKeyData.AccessKeyId,
+					LastUsedDate:      lastUsedDate,
+					LastActivity:      lastActivity,
+					ARN:               awsClient.StringValue(user.Arn),
+					ConsoleURL:        im.awsManager.GetConsoleURL("iam/home", fmt.Sprintf("/users/details/%s", *user.UserName)),
+					AccountDimensions: im.awsManager.GetAccountDimensions(),
 				}
 
 				im.awsManager.GetCollector().AddResource(collector.EventCollector{
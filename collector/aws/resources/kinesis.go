@@ -7,6 +7,7 @@ import (
 	"finala/collector/aws/register"
 	"finala/collector/config"
 	"finala/expression"
+	"fmt"
 	"time"
 
 	awsClient "github.com/aws/aws-sdk-go/aws"
@@ -36,7 +37,6 @@ type KinesisManager struct {
 // DetectedKinesis defines the detected AWS Kinesis data streams
 type DetectedKinesis struct {
 	Metric string
-	Region string
 	collector.PriceDetectedFields
 }
 
@@ -115,6 +115,26 @@ func (km *KinesisManager) Detect(metrics []config.MetricConfig) (interface{}, er
 	now := time.Now()
 	for _, stream := range streams {
 		log.WithField("stream_name", *stream.StreamName).Debug("checking kinesis stearm")
+
+		// AWS Kinesis charges for extended data retention bigger than the deafult
+		// which is 24 Hours
+		var finalExtendedRetentionPrice float64
+		if *stream.RetentionPeriodHours > int64(24) {
+			finalExtendedRetentionPrice = extendedRetentionPrice
+		}
+
+		totalShardsPerHourPrice := (shardPrice + finalExtendedRetentionPrice) * float64(len(stream.Shards))
+
+		km.awsManager.ExamineResource(km.Name, func() collector.PriceDetectedFields {
+			return collector.PriceDetectedFields{
+				ResourceID:    *stream.StreamName,
+				LaunchTime:    *stream.StreamCreationTimestamp,
+				PricePerHour:  totalShardsPerHourPrice,
+				PricePerMonth: totalShardsPerHourPrice * collector.TotalMonthHours,
+				Tag:           km.getTags(stream.StreamName),
+			}
+		})
+
 		for _, metric := range metrics {
 
 			log.WithFields(log.Fields{
@@ -163,35 +183,19 @@ func (km *KinesisManager) Detect(metrics []config.MetricConfig) (interface{}, er
 					"region":              km.awsManager.GetRegion(),
 				}).Info("Kinesis stream was detected as unutilized resource")
 
-				tags, err := km.client.ListTagsForStream(&kinesis.ListTagsForStreamInput{
-					StreamName: stream.StreamName,
-				})
-
-				tagsData := map[string]string{}
-				if err == nil {
-					for _, tag := range tags.Tags {
-						tagsData[*tag.Key] = *tag.Value
-					}
-				}
-
-				// AWS Kinesis charges for extended data retention bigger than the deafult
-				// which is 24 Hours
-				var finalExtendedRetentionPrice float64
-				if *stream.RetentionPeriodHours > int64(24) {
-					finalExtendedRetentionPrice = extendedRetentionPrice
-				}
-
-				totalShardsPerHourPrice := (shardPrice + finalExtendedRetentionPrice) * float64(len(stream.Shards))
+				tagsData := km.getTags(stream.StreamName)
 
 				stream := DetectedKinesis{
-					Region: km.awsManager.GetRegion(),
 					Metric: metric.Description,
 					PriceDetectedFields: collector.PriceDetectedFields{
-						ResourceID:    *stream.StreamName,
-						LaunchTime:    *stream.StreamCreationTimestamp,
-						PricePerHour:  totalShardsPerHourPrice,
-						PricePerMonth: totalShardsPerHourPrice * collector.TotalMonthHours,
-						Tag:           tagsData,
+						ResourceID:        *stream.StreamName,
+						ARN:               awsClient.StringValue(stream.StreamARN),
+						ConsoleURL:        km.awsManager.GetConsoleURL("kinesis/home", fmt.Sprintf("/streams/details/%s/monitoring", *stream.StreamName)),
+						LaunchTime:        *stream.StreamCreationTimestamp,
+						PricePerHour:      totalShardsPerHourPrice,
+						PricePerMonth:     totalShardsPerHourPrice * collector.TotalMonthHours,
+						Tag:               tagsData,
+						AccountDimensions: km.awsManager.GetAccountDimensions(),
 					},
 				}
 
@@ -208,6 +212,24 @@ func (km *KinesisManager) Detect(metrics []config.MetricConfig) (interface{}, er
 	return detectedStreams, nil
 }
 
+// getTags returns the kinesis stream tags
+func (km *KinesisManager) getTags(streamName *string) map[string]string {
+
+	tagsData := map[string]string{}
+	tags, err := km.client.ListTagsForStream(&kinesis.ListTagsForStreamInput{
+		StreamName: streamName,
+	})
+	if err != nil {
+		log.WithError(err).WithField("stream_name", *streamName).Error("could not list kinesis stream tags")
+		return tagsData
+	}
+
+	for _, tag := range tags.Tags {
+		tagsData[*tag.Key] = *tag.Value
+	}
+	return tagsData
+}
+
 // getPricingFilterInput prepares kinesis pricing filter
 func (km *KinesisManager) getPricingFilterInput(extraFilters []*pricing.Filter) pricing.GetProductsInput {
 	filters := []*pricing.Filter{
@@ -7,11 +7,13 @@ import (
 	"finala/collector/aws/register"
 	"finala/collector/config"
 	"finala/expression"
+	"fmt"
 	"time"
 
 	awsClient "github.com/aws/aws-sdk-go/aws"
 	awsCloudwatch "github.com/aws/aws-sdk-go/service/cloudwatch"
 	"github.com/aws/aws-sdk-go/service/lambda"
+	"github.com/aws/aws-sdk-go/service/pricing"
 	log "github.com/sirupsen/logrus"
 )
 
@@ -19,23 +21,32 @@ import (
 type LambdaClientDescreptor interface {
 	ListFunctions(input *lambda.ListFunctionsInput) (*lambda.ListFunctionsOutput, error)
 	ListTags(input *lambda.ListTagsInput) (*lambda.ListTagsOutput, error)
+	ListProvisionedConcurrencyConfigs(input *lambda.ListProvisionedConcurrencyConfigsInput) (*lambda.ListProvisionedConcurrencyConfigsOutput, error)
 }
 
 // LambdaManager describe lambda manager
 type LambdaManager struct {
-	client     LambdaClientDescreptor
-	awsManager common.AWSManager
-	namespace  string
-	Name       collector.ResourceIdentifier
+	client             LambdaClientDescreptor
+	awsManager         common.AWSManager
+	namespace          string
+	servicePricingCode string
+	prices             map[string]float64
+	Name               collector.ResourceIdentifier
 }
 
-// DetectedAWSLambda define the detected AWS Lambda instances
+// DetectedAWSLambda define the detected AWS Lambda instances.
+// The price is estimated from the invocations and duration of the metric period,
+// with the provisioned concurrency charges of the function.
 type DetectedAWSLambda struct {
-	Metric     string
-	Region     string
-	ResourceID string
-	Name       string
-	Tag        map[string]string
+	Metric                 string
+	Name                   string
+	Architecture           string
+	MemorySize             int64
+	ProvisionedConcurrency int64
+	InvocationsPerMonth    float64
+	GBSecondsPerMonth      float64
+	PriceUnavailable       bool
+	collector.PriceDetectedFields
 }
 
 func init() {
@@ -49,16 +60,18 @@ func NewLambdaManager(awsManager common.AWSManager, client interface{}) (common.
 		client = lambda.New(awsManager.GetSession())
 	}
 
-	kinesisClient, ok := client.(LambdaClientDescreptor)
+	lambdaClient, ok := client.(LambdaClientDescreptor)
 	if !ok {
 		return nil, errors.New("invalid lambda volumes client")
 	}
 
 	return &LambdaManager{
-		client:     kinesisClient,
-		awsManager: awsManager,
-		namespace:  "AWS/Lambda",
-		Name:       awsManager.GetResourceIdentifier("lambda"),
+		client:             lambdaClient,
+		awsManager:         awsManager,
+		namespace:          "AWS/Lambda",
+		servicePricingCode: "AWSLambda",
+		prices:             map[string]float64{},
+		Name:               awsManager.GetResourceIdentifier("lambda"),
 	}, nil
 }
 
@@ -80,11 +93,35 @@ func (lm *LambdaManager) Detect(metrics []config.MetricConfig) (interface{}, err
 		return detected, err
 	}
 
+	pricingRegionPrefix, err := lm.awsManager.GetPricingClient().GetRegionPrefix(lm.awsManager.GetRegion())
+	if err != nil {
+		log.WithError(err).WithFields(log.Fields{
+			"region": lm.awsManager.GetRegion(),
+		}).Error("Could not get pricing region prefix")
+		lm.awsManager.GetCollector().CollectError(lm.Name, err)
+		return detected, err
+	}
+
 	now := time.Now()
 	for _, fun := range functions {
 
 		log.WithField("name", *fun.FunctionName).Debug("checking lambda")
 
+		lm.awsManager.ExamineResource(lm.Name, func() collector.PriceDetectedFields {
+			// The function usage is extrapolated from the period of the first metric
+			var pricePerMonth float64
+			if len(metrics) > 0 {
+				invocations, gbSeconds := lm.getMonthlyUsage(fun, metrics[0], now)
+				pricePerMonth, _ = lm.getMonthlyPrice(pricingRegionPrefix, lm.getArchitecture(fun), awsClient.Int64Value(fun.MemorySize), invocations, gbSeconds, lm.getProvisionedConcurrency(fun))
+			}
+			return collector.PriceDetectedFields{
+				ResourceID:    *fun.FunctionArn,
+				PricePerHour:  pricePerMonth / collector.TotalMonthHours,
+				PricePerMonth: pricePerMonth,
+				Tag:           lm.getTags(fun.FunctionArn),
+			}
+		})
+
 		for _, metric := range metrics {
 			log.WithFields(log.Fields{
 				"name":        *fun.FunctionName,
@@ -132,23 +169,33 @@ func (lm *LambdaManager) Detect(metrics []config.MetricConfig) (interface{}, err
 					"region":              lm.awsManager.GetRegion(),
 				}).Info("Lambda function detected as unutilized resource")
 
-				tags, err := lm.client.ListTags(&lambda.ListTagsInput{
-					Resource: fun.FunctionArn,
-				})
+				tagsData := lm.getTags(fun.FunctionArn)
+				architecture := lm.getArchitecture(fun)
 
-				tagsData := map[string]string{}
-				if err == nil {
-					for key, value := range tags.Tags {
-						tagsData[key] = *value
-					}
-				}
+				invocations, gbSeconds := lm.getMonthlyUsage(fun, metric, now)
+				provisionedConcurrency := lm.getProvisionedConcurrency(fun)
+				// The function is reported without a price when one of its prices is not found, rather than with a partial price
+				pricePerMonth, err := lm.getMonthlyPrice(pricingRegionPrefix, architecture, awsClient.Int64Value(fun.MemorySize), invocations, gbSeconds, provisionedConcurrency)
+				priceUnavailable := err != nil
 
 				lambdaData := DetectedAWSLambda{
-					Region:     lm.awsManager.GetRegion(),
-					Metric:     metric.Description,
-					ResourceID: *fun.FunctionArn,
-					Name:       *fun.FunctionName,
-					Tag:        tagsData,
+					Metric:                 metric.Description,
+					Name:                   *fun.FunctionName,
+					Architecture:           architecture,
+					MemorySize:             awsClient.Int64Value(fun.MemorySize),
+					ProvisionedConcurrency: provisionedConcurrency,
+					InvocationsPerMonth:    invocations,
+					GBSecondsPerMonth:      gbSeconds,
+					PriceUnavailable:       priceUnavailable,
+					PriceDetectedFields: collector.PriceDetectedFields{
+						ResourceID:        *fun.FunctionArn,
+						ARN:               *fun.FunctionArn,
+						ConsoleURL:        lm.awsManager.GetConsoleURL("lambda/home", fmt.Sprintf("/functions/%s", *fun.FunctionName)),
+						PricePerHour:      pricePerMonth / collector.TotalMonthHours,
+						PricePerMonth:     pricePerMonth,
+						Tag:               tagsData,
+						AccountDimensions: lm.awsManager.GetAccountDimensions(),
+					},
 				}
 
 				lm.awsManager.GetCollector().AddResource(collector.EventCollector{
@@ -190,3 +237,184 @@ func (lm *LambdaManager) describe(marker *string, functions []*lambda.FunctionCo
 
 	return functions, nil
 }
+
+// getTags returns the lambda function tags
+func (lm *LambdaManager) getTags(functionArn *string) map[string]string {
+
+	tagsData := map[string]string{}
+	tags, err := lm.client.ListTags(&lambda.ListTagsInput{
+		Resource: functionArn,
+	})
+	if err != nil {
+		log.WithError(err).WithField("function_arn", *functionArn).Error("could not list lambda function tags")
+		return tagsData
+	}
+
+	for key, value := range tags.Tags {
+		tagsData[key] = *value
+	}
+	return tagsData
+}
+
+// getArchitecture returns the function instruction set architecture
+func (lm *LambdaManager) getArchitecture(fun *lambda.FunctionConfiguration) string {
+	if len(fun.Architectures) > 0 {
+		return *fun.Architectures[0]
+	}
+	return lambda.ArchitectureX8664
+}
+
+// getMonthlyUsage returns the monthly invocations and GB-seconds of the function,
+// extrapolated from the cloudwatch metrics of the metric period
+func (lm *LambdaManager) getMonthlyUsage(fun *lambda.FunctionConfiguration, metric config.MetricConfig, now time.Time) (float64, float64) {
+
+	if metric.StartTime <= 0 {
+		return 0, 0
+	}
+
+	period := int64(metric.Period.Seconds())
+	metricEndTime := now.Add(time.Duration(-metric.StartTime))
+	monthlyRatio := collector.TotalMonthHours / metric.StartTime.Hours()
+
+	usage := map[string]float64{}
+	for _, metricName := range []string{"Invocations", "Duration"} {
+		metricInput := awsCloudwatch.GetMetricStatisticsInput{
+			Namespace: &lm.namespace,
+			Period:    &period,
+			StartTime: &metricEndTime,
+			EndTime:   &now,
+			Dimensions: []*awsCloudwatch.Dimension{
+				{
+					Name:  awsClient.String("FunctionName"),
+					Value: fun.FunctionName,
+				},
+			},
+		}
+
+		value, _, err := lm.awsManager.GetCloudWatchClient().GetMetric(&metricInput, config.MetricConfig{
+			Data: []config.MetricDataConfiguration{
+				{
+					Name:      metricName,
+					Statistic: "Sum",
+				},
+			},
+		})
+		if err != nil {
+			log.WithError(err).WithFields(log.Fields{
+				"name":        *fun.FunctionName,
+				"metric_name": metricName,
+			}).Error("Could not get cloudwatch metric data")
+			continue
+		}
+		usage[metricName] = value * monthlyRatio
+	}
+
+	// Duration is reported in milliseconds and memory size in MB
+	gbSeconds := usage["Duration"] / 1000 * float64(awsClient.Int64Value(fun.MemorySize)) / 1024
+
+	return usage["Invocations"], gbSeconds
+}
+
+// getProvisionedConcurrency returns the allocated provisioned concurrency of all the function aliases and versions
+func (lm *LambdaManager) getProvisionedConcurrency(fun *lambda.FunctionConfiguration) int64 {
+
+	var provisionedConcurrency int64
+	var marker *string
+	for {
+		resp, err := lm.client.ListProvisionedConcurrencyConfigs(&lambda.ListProvisionedConcurrencyConfigsInput{
+			FunctionName: fun.FunctionName,
+			Marker:       marker,
+		})
+		if err != nil {
+			log.WithError(err).WithField("name", *fun.FunctionName).Error("could not list lambda provisioned concurrency configs")
+			return provisionedConcurrency
+		}
+
+		for _, provisionedConfig := range resp.ProvisionedConcurrencyConfigs {
+			provisionedConcurrency += awsClient.Int64Value(provisionedConfig.AllocatedProvisionedConcurrentExecutions)
+		}
+
+		if resp.NextMarker == nil {
+			return provisionedConcurrency
+		}
+		marker = resp.NextMarker
+	}
+}
+
+// getMonthlyPrice returns the estimated monthly price of the function requests, compute duration and provisioned
+// concurrency. It returns an error when one of the prices is not found
+func (lm *LambdaManager) getMonthlyPrice(pricingRegionPrefix, architecture string, memorySize int64, invocations, gbSeconds float64, provisionedConcurrency int64) (float64, error) {
+
+	usageTypeSuffix := ""
+	if architecture == lambda.ArchitectureArm64 {
+		usageTypeSuffix = "-ARM"
+	}
+
+	durationUsageType := "Lambda-GB-Second"
+	if provisionedConcurrency > 0 {
+		durationUsageType = "Lambda-Provisioned-GB-Second"
+	}
+
+	requestPrice, err := lm.getUsageTypePrice(fmt.Sprintf("%sRequest%s", pricingRegionPrefix, usageTypeSuffix))
+	if err != nil {
+		return 0, err
+	}
+	durationPrice, err := lm.getUsageTypePrice(fmt.Sprintf("%s%s%s", pricingRegionPrefix, durationUsageType, usageTypeSuffix))
+	if err != nil {
+		return 0, err
+	}
+	pricePerMonth := invocations*requestPrice + gbSeconds*durationPrice
+
+	if provisionedConcurrency > 0 {
+		// Provisioned concurrency is charged per GB-second of the configured concurrency, for the whole month
+		provisionedGBSeconds := float64(provisionedConcurrency) * float64(memorySize) / 1024 * 3600 * collector.TotalMonthHours
+		provisionedPrice, err := lm.getUsageTypePrice(fmt.Sprintf("%sLambda-Provisioned-Concurrency%s", pricingRegionPrefix, usageTypeSuffix))
+		if err != nil {
+			return 0, err
+		}
+		pricePerMonth += provisionedGBSeconds * provisionedPrice
+	}
+
+	return pricePerMonth, nil
+}
+
+// getUsageTypePrice returns the price of the given usage type. The found prices are cached for the whole detection,
+// and the prices which were not found are requested again for the next function
+func (lm *LambdaManager) getUsageTypePrice(usageType string) (float64, error) {
+
+	if price, found := lm.prices[usageType]; found {
+		return price, nil
+	}
+
+	pricingFilters := lm.getPricingFilterInput(usageType)
+	price, err := lm.awsManager.GetPricingClient().GetPrice(pricingFilters, "", lm.awsManager.GetRegion())
+	if err != nil {
+		log.WithError(err).WithFields(log.Fields{
+			"region":        lm.awsManager.GetRegion(),
+			"price_filters": pricingFilters,
+		}).Error("could not get lambda price")
+		return 0, err
+	}
+
+	lm.prices[usageType] = price
+	return price, nil
+}
+
+// getPricingFilterInput prepares the lambda pricing filter of the given usage type
+func (lm *LambdaManager) getPricingFilterInput(usageType string) pricing.GetProductsInput {
+	return pricing.GetProductsInput{
+		ServiceCode: &lm.servicePricingCode,
+		Filters: []*pricing.Filter{
+			{
+				Type:  awsClient.String("TERM_MATCH"),
+				Field: awsClient.String("termType"),
+				Value: awsClient.String("OnDemand"),
+			},
+			{
+				Type:  awsClient.String("TERM_MATCH"),
+				Field: awsClient.String("usagetype"),
+				Value: awsClient.String(usageType),
+			},
+		},
+	}
+}
@@ -2,13 +2,17 @@ package resources
 
 import (
 	"errors"
+	"finala/collector/aws/pricing"
 	awsTestutils "finala/collector/aws/testutils"
 	"finala/collector/config"
 	collectorTestutils "finala/collector/testutils"
+	"fmt"
 	"reflect"
 	"testing"
+	"time"
 
 	awsClient "github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudwatch"
 	"github.com/aws/aws-sdk-go/service/lambda"
 )
 
@@ -26,8 +30,9 @@ var defaultLambdaMock = lambda.ListFunctionsOutput{
 }
 
 type MockAWSLambdaClient struct {
-	responseDescribeDBInstances lambda.ListFunctionsOutput
-	err                         error
+	responseDescribeDBInstances    lambda.ListFunctionsOutput
+	responseProvisionedConcurrency map[string]lambda.ListProvisionedConcurrencyConfigsOutput
+	err                            error
 }
 
 func (r *MockAWSLambdaClient) ListFunctions(input *lambda.ListFunctionsInput) (*lambda.ListFunctionsOutput, error) {
@@ -42,6 +47,13 @@ func (r *MockAWSLambdaClient) ListTags(input *lambda.ListTagsInput) (*lambda.Lis
 
 }
 
+func (r *MockAWSLambdaClient) ListProvisionedConcurrencyConfigs(input *lambda.ListProvisionedConcurrencyConfigsInput) (*lambda.ListProvisionedConcurrencyConfigsOutput, error) {
+
+	response := r.responseProvisionedConcurrency[*input.FunctionName]
+	return &response, r.err
+
+}
+
 func TestDescribeLambdaInstances(t *testing.T) {
 
 	collector := collectorTestutils.NewMockCollector()
@@ -221,3 +233,178 @@ func TestDetectLambdaError(t *testing.T) {
 	})
 
 }
+
+func TestDetectLambdaPricing(t *testing.T) {
+
+	metricConfig := []config.MetricConfig{
+		{
+			Description: "Invocations count",
+			Data: []config.MetricDataConfiguration{
+				{
+					Name:      "TestMetric",
+					Statistic: "Sum",
+				},
+			},
+			Constraint: config.MetricConstraintConfig{
+				Operator: "==",
+				Value:    5,
+			},
+			Period:    24 * time.Hour,
+			StartTime: 365 * time.Hour,
+		},
+	}
+
+	collector := collectorTestutils.NewMockCollector()
+	mockCloudwatch := awsTestutils.NewMockCloudwatch(&map[string]cloudwatch.GetMetricStatisticsOutput{
+		"TestMetric": {
+			Datapoints: []*cloudwatch.Datapoint{
+				{Sum: awsClient.Float64(5)},
+			},
+		},
+		"Invocations": {
+			Datapoints: []*cloudwatch.Datapoint{
+				{Sum: awsClient.Float64(40)},
+				{Sum: awsClient.Float64(10)},
+			},
+		},
+		"Duration": {
+			Datapoints: []*cloudwatch.Datapoint{
+				{Sum: awsClient.Float64(5000)},
+			},
+		},
+	})
+	mockPrice := pricing.NewPricingManager(&MockFilterPricingClient{prices: map[string]float64{
+		"Request":                            0.01,
+		"Request-ARM":                        0.008,
+		"Lambda-GB-Second":                   0.02,
+		"Lambda-Provisioned-GB-Second-ARM":   0.01,
+		"Lambda-Provisioned-Concurrency-ARM": 0.001,
+	}}, "us-east-1")
+	detector := awsTestutils.AWSManager(collector, mockCloudwatch, mockPrice, "us-east-1")
+
+	mockClient := MockAWSLambdaClient{
+		responseDescribeDBInstances: lambda.ListFunctionsOutput{
+			Functions: []*lambda.FunctionConfiguration{
+				{
+					FunctionArn:  awsClient.String("arn:aws:lambda:us-east-1:1:foo"),
+					FunctionName: awsClient.String("foo"),
+					MemorySize:   awsClient.Int64(1024),
+				},
+				{
+					FunctionArn:   awsClient.String("arn:aws:lambda:us-east-1:1:bar"),
+					FunctionName:  awsClient.String("bar"),
+					MemorySize:    awsClient.Int64(512),
+					Architectures: []*string{awsClient.String(lambda.ArchitectureArm64)},
+				},
+			},
+		},
+		responseProvisionedConcurrency: map[string]lambda.ListProvisionedConcurrencyConfigsOutput{
+			"bar": {
+				ProvisionedConcurrencyConfigs: []*lambda.ProvisionedConcurrencyConfigListItem{
+					{AllocatedProvisionedConcurrentExecutions: awsClient.Int64(2)},
+				},
+			},
+		},
+	}
+
+	lambdaManager, err := NewLambdaManager(detector, &mockClient)
+	if err != nil {
+		t.Fatalf("unexpected lambda error happened, got %v expected %v", err, nil)
+	}
+
+	response, err := lambdaManager.Detect(metricConfig)
+	if err != nil {
+		t.Fatalf("unexpected lambda detection error happened, got %v expected %v", err, nil)
+	}
+
+	lambdaResponse, ok := response.([]DetectedAWSLambda)
+	if !ok {
+		t.Fatalf("unexpected lambda struct, got %s expected %s", reflect.TypeOf(response), "[]DetectedAWSLambda")
+	}
+
+	if len(lambdaResponse) != 2 {
+		t.Fatalf("unexpected lambda detected, got %d expected %d", len(lambdaResponse), 2)
+	}
+
+	// The metric period is half a month, so the usage is doubled
+	testCases := []struct {
+		name                   string
+		provisionedConcurrency int64
+		invocations            float64
+		gbSeconds              float64
+		pricePerMonth          float64
+	}{
+		{"foo", 0, 100, 10, 100*0.01 + 10*0.02},
+		{"bar", 2, 100, 5, 100*0.008 + 5*0.01 + 2*0.5*3600*730*0.001},
+	}
+
+	for i, test := range testCases {
+		detected := lambdaResponse[i]
+		if detected.Name != test.name {
+			t.Fatalf("unexpected lambda name, got %s expected %s", detected.Name, test.name)
+		}
+		if detected.ProvisionedConcurrency != test.provisionedConcurrency {
+			t.Fatalf("unexpected %s provisioned concurrency, got %d expected %d", test.name, detected.ProvisionedConcurrency, test.provisionedConcurrency)
+		}
+		if fmt.Sprintf("%.2f", detected.InvocationsPerMonth) != fmt.Sprintf("%.2f", test.invocations) {
+			t.Fatalf("unexpected %s invocations per month, got %f expected %f", test.name, detected.InvocationsPerMonth, test.invocations)
+		}
+		if fmt.Sprintf("%.2f", detected.GBSecondsPerMonth) != fmt.Sprintf("%.2f", test.gbSeconds) {
+			t.Fatalf("unexpected %s GB-seconds per month, got %f expected %f", test.name, detected.GBSecondsPerMonth, test.gbSeconds)
+		}
+		if fmt.Sprintf("%.2f", detected.PricePerMonth) != fmt.Sprintf("%.2f", test.pricePerMonth) {
+			t.Fatalf("unexpected %s price per month, got %f expected %f", test.name, detected.PricePerMonth, test.pricePerMonth)
+		}
+	}
+}
+
+func TestDetectLambdaPriceUnavailable(t *testing.T) {
+
+	collector := collectorTestutils.NewMockCollector()
+	mockCloudwatch := awsTestutils.NewMockCloudwatch(nil)
+	mockPricing := &MockFilterPricingClient{prices: map[string]float64{
+		"Request": 0.01,
+	}}
+	detector := awsTestutils.AWSManager(collector, mockCloudwatch, pricing.NewPricingManager(mockPricing, "us-east-1"), "us-east-1")
+
+	mockClient := MockAWSLambdaClient{
+		responseDescribeDBInstances: lambda.ListFunctionsOutput{
+			Functions: []*lambda.FunctionConfiguration{
+				{
+					FunctionArn:  awsClient.String("arn:aws:lambda:us-east-1:1:foo"),
+					FunctionName: awsClient.String("foo"),
+					MemorySize:   awsClient.Int64(1024),
+				},
+			},
+		},
+	}
+
+	lambdaInterface, err := NewLambdaManager(detector, &mockClient)
+	if err != nil {
+		t.Fatalf("unexpected lambda error happened, got %v expected %v", err, nil)
+	}
+
+	response, err := lambdaInterface.Detect(awsTestutils.DefaultMetricConfig)
+	if err != nil {
+		t.Fatalf("unexpected lambda detection error happened, got %v expected %v", err, nil)
+	}
+
+	lambdaResponse := response.([]DetectedAWSLambda)
+	if len(lambdaResponse) != 1 {
+		t.Fatalf("unexpected lambda detected, got %d expected %d", len(lambdaResponse), 1)
+	}
+	if !lambdaResponse[0].PriceUnavailable || lambdaResponse[0].PricePerMonth != 0 {
+		t.Fatalf("unexpected lambda price, got %f with price unavailable %t expected no price", lambdaResponse[0].PricePerMonth, lambdaResponse[0].PriceUnavailable)
+	}
+
+	// The price which was not found is requested again
+	lambdaManager := lambdaInterface.(*LambdaManager)
+	mockPricing.prices["Lambda-GB-Second"] = 0.02
+	price, err := lambdaManager.getUsageTypePrice("Lambda-GB-Second")
+	if err != nil {
+		t.Fatalf("unexpected lambda price error, got %v expected %v", err, nil)
+	}
+	if fmt.Sprintf("%.2f", price) != "0.02" {
+		t.Fatalf("unexpected lambda price, got %f expected %f", price, 0.02)
+	}
+}
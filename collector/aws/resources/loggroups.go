@@ -0,0 +1,331 @@
+package resources
+
+import (
+	"errors"
+	"finala/collector"
+	"finala/collector/aws/common"
+	"finala/collector/aws/register"
+	"finala/collector/config"
+	"finala/expression"
+	"fmt"
+	"strings"
+	"time"
+
+	awsClient "github.com/aws/aws-sdk-go/aws"
+	awsCloudwatch "github.com/aws/aws-sdk-go/service/cloudwatch"
+	"github.com/aws/aws-sdk-go/service/cloudwatchlogs"
+	"github.com/aws/aws-sdk-go/service/pricing"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// logGroupIncomingBytesLookback defines the period of the reported recent ingestion
+	logGroupIncomingBytesLookback = 168 * time.Hour
+)
+
+// LogGroupsClientDescreptor is an interface defining the aws cloudwatch logs client
+type LogGroupsClientDescreptor interface {
+	DescribeLogGroups(*cloudwatchlogs.DescribeLogGroupsInput) (*cloudwatchlogs.DescribeLogGroupsOutput, error)
+	ListTagsForResource(*cloudwatchlogs.ListTagsForResourceInput) (*cloudwatchlogs.ListTagsForResourceOutput, error)
+}
+
+// LogGroupsManager describes the cloudwatch log groups struct
+type LogGroupsManager struct {
+	client             LogGroupsClientDescreptor
+	awsManager         common.AWSManager
+	namespace          string
+	servicePricingCode string
+	Name               collector.ResourceIdentifier
+}
+
+// DetectedLogGroup defines the detected AWS cloudwatch log group
+type DetectedLogGroup struct {
+	Metric          string
+	Name            string
+	StoredBytes     int64
+	RetentionInDays int64
+	NeverExpire     bool
+	IncomingBytes   float64
+	collector.PriceDetectedFields
+}
+
+func init() {
+	register.Registry("log_groups", NewLogGroupsManager)
+}
+
+// NewLogGroupsManager implements AWS GO SDK
+func NewLogGroupsManager(awsManager common.AWSManager, client interface{}) (common.ResourceDetection, error) {
+
+	if client == nil {
+		client = cloudwatchlogs.New(awsManager.GetSession())
+	}
+
+	logsClient, ok := client.(LogGroupsClientDescreptor)
+	if !ok {
+		return nil, errors.New("invalid cloudwatch logs client")
+	}
+
+	return &LogGroupsManager{
+		client:             logsClient,
+		awsManager:         awsManager,
+		namespace:          "AWS/Logs",
+		servicePricingCode: "AmazonCloudWatch",
+		Name:               awsManager.GetResourceIdentifier("log_groups"),
+	}, nil
+}
+
+// Detect checks which log groups never expire and are large, or have no recent ingestion.
+// A metric without cloudwatch metrics data is evaluated against the stored GB of never expiring log groups.
+func (lg *LogGroupsManager) Detect(metrics []config.MetricConfig) (interface{}, error) {
+
+	log.WithFields(log.Fields{
+		"region":   lg.awsManager.GetRegion(),
+		"resource": "log_groups",
+	}).Info("starting to analyze resource")
+
+	lg.awsManager.GetCollector().CollectStart(lg.Name)
+
+	detected := []DetectedLogGroup{}
+
+	pricingRegionPrefix, err := lg.awsManager.GetPricingClient().GetRegionPrefix(lg.awsManager.GetRegion())
+	if err != nil {
+		log.WithError(err).WithFields(log.Fields{
+			"region": lg.awsManager.GetRegion(),
+		}).Error("Could not get pricing region prefix")
+		lg.awsManager.GetCollector().CollectError(lg.Name, err)
+		return detected, err
+	}
+
+	pricingFilters := lg.getPricingFilterInput(pricingRegionPrefix)
+	price, err := lg.awsManager.GetPricingClient().GetPrice(pricingFilters, "", lg.awsManager.GetRegion())
+	if err != nil {
+		log.WithError(err).WithFields(log.Fields{
+			"region":        lg.awsManager.GetRegion(),
+			"price_filters": pricingFilters,
+		}).Error("could not get log groups archived storage price")
+		lg.awsManager.GetCollector().CollectError(lg.Name, err)
+		return detected, err
+	}
+
+	logGroups, err := lg.describeLogGroups(nil, nil)
+	if err != nil {
+		lg.awsManager.GetCollector().CollectError(lg.Name, err)
+		return detected, err
+	}
+
+	now := time.Now()
+
+	for _, logGroup := range logGroups {
+		log.WithField("name", *logGroup.LogGroupName).Debug("checking log group")
+
+		var storedBytes, retentionInDays int64
+		if logGroup.StoredBytes != nil {
+			storedBytes = *logGroup.StoredBytes
+		}
+		if logGroup.RetentionInDays != nil {
+			retentionInDays = *logGroup.RetentionInDays
+		}
+		neverExpire := logGroup.RetentionInDays == nil
+		storedGB := float64(storedBytes) / bytesInGB
+		pricePerMonth := storedGB * price
+
+		var launchTime time.Time
+		if logGroup.CreationTime != nil {
+			launchTime = time.Unix(0, *logGroup.CreationTime*int64(time.Millisecond))
+		}
+
+		lg.awsManager.ExamineResource(lg.Name, func() collector.PriceDetectedFields {
+			return collector.PriceDetectedFields{
+				ResourceID:    *logGroup.LogGroupName,
+				LaunchTime:    launchTime,
+				PricePerHour:  pricePerMonth / collector.TotalMonthHours,
+				PricePerMonth: pricePerMonth,
+				Tag:           lg.getTags(logGroup),
+			}
+		})
+
+		for _, metric := range metrics {
+			log.WithFields(log.Fields{
+				"name":        *logGroup.LogGroupName,
+				"metric_name": metric.Description,
+			}).Debug("check metric")
+
+			var formulaValue float64
+			if len(metric.Data) == 0 {
+				if !neverExpire {
+					continue
+				}
+				formulaValue = storedGB
+			} else {
+				period := int64(metric.Period.Seconds())
+				metricEndTime := now.Add(time.Duration(-metric.StartTime))
+				metricInput := lg.getMetricInput(logGroup, period, metricEndTime, now)
+
+				formulaValue, _, err = lg.awsManager.GetCloudWatchClient().GetMetric(&metricInput, metric)
+				if err != nil {
+					log.WithError(err).WithFields(log.Fields{
+						"name":        *logGroup.LogGroupName,
+						"metric_name": metric.Description,
+					}).Error("Could not get cloudwatch metric data")
+					continue
+				}
+			}
+
+			expression, err := expression.BoolExpression(formulaValue, metric.Constraint.Value, metric.Constraint.Operator)
+			if err != nil {
+				continue
+			}
+
+			if expression {
+
+				log.WithFields(log.Fields{
+					"metric_name":         metric.Description,
+					"constraint_operator": metric.Constraint.Operator,
+					"constraint_Value":    metric.Constraint.Value,
+					"formula_value":       formulaValue,
+					"name":                *logGroup.LogGroupName,
+					"never_expire":        neverExpire,
+					"region":              lg.awsManager.GetRegion(),
+				}).Info("Log group detected as unutilized resource")
+
+				logGroupData := DetectedLogGroup{
+					Metric:          metric.Description,
+					Name:            *logGroup.LogGroupName,
+					StoredBytes:     storedBytes,
+					RetentionInDays: retentionInDays,
+					NeverExpire:     neverExpire,
+					IncomingBytes:   lg.getIncomingBytes(logGroup, now),
+					PriceDetectedFields: collector.PriceDetectedFields{
+						ResourceID:        *logGroup.LogGroupName,
+						ARN:               lg.awsManager.GetARN("logs", fmt.Sprintf("log-group:%s", *logGroup.LogGroupName)),
+						ConsoleURL:        lg.awsManager.GetConsoleURL("cloudwatch/home", fmt.Sprintf("logsV2:log-groups/log-group/%s", strings.ReplaceAll(*logGroup.LogGroupName, "/", "$252F"))),
+						LaunchTime:        launchTime,
+						PricePerHour:      pricePerMonth / collector.TotalMonthHours,
+						PricePerMonth:     pricePerMonth,
+						Tag:               lg.getTags(logGroup),
+						AccountDimensions: lg.awsManager.GetAccountDimensions(),
+					},
+				}
+
+				lg.awsManager.GetCollector().AddResource(collector.EventCollector{
+					ResourceName: lg.Name,
+					Data:         logGroupData,
+				})
+
+				detected = append(detected, logGroupData)
+			}
+		}
+	}
+
+	lg.awsManager.GetCollector().CollectFinish(lg.Name)
+
+	return detected, nil
+}
+
+// getMetricInput returns the cloudwatch metric input of the given log group
+func (lg *LogGroupsManager) getMetricInput(logGroup *cloudwatchlogs.LogGroup, period int64, startTime, endTime time.Time) awsCloudwatch.GetMetricStatisticsInput {
+	return awsCloudwatch.GetMetricStatisticsInput{
+		Namespace: &lg.namespace,
+		Period:    &period,
+		StartTime: &startTime,
+		EndTime:   &endTime,
+		Dimensions: []*awsCloudwatch.Dimension{
+			{
+				Name:  awsClient.String("LogGroupName"),
+				Value: logGroup.LogGroupName,
+			},
+		},
+	}
+}
+
+// getIncomingBytes returns the log group ingested bytes of the last week
+func (lg *LogGroupsManager) getIncomingBytes(logGroup *cloudwatchlogs.LogGroup, now time.Time) float64 {
+
+	period := int64(logGroupIncomingBytesLookback.Seconds())
+	metricInput := lg.getMetricInput(logGroup, period, now.Add(-logGroupIncomingBytesLookback), now)
+
+	incomingBytes, _, err := lg.awsManager.GetCloudWatchClient().GetMetric(&metricInput, config.MetricConfig{
+		Data: []config.MetricDataConfiguration{
+			{
+				Name:      "IncomingBytes",
+				Statistic: "Sum",
+			},
+		},
+	})
+	if err != nil {
+		log.WithError(err).WithField("name", *logGroup.LogGroupName).Debug("could not get log group incoming bytes")
+		return 0
+	}
+
+	return incomingBytes
+}
+
+// getTags returns the log group tags
+func (lg *LogGroupsManager) getTags(logGroup *cloudwatchlogs.LogGroup) map[string]string {
+
+	tagsData := map[string]string{}
+	if logGroup.LogGroupArn == nil {
+		return tagsData
+	}
+
+	tags, err := lg.client.ListTagsForResource(&cloudwatchlogs.ListTagsForResourceInput{
+		ResourceArn: logGroup.LogGroupArn,
+	})
+	if err == nil {
+		for key, value := range tags.Tags {
+			tagsData[key] = *value
+		}
+	}
+
+	return tagsData
+}
+
+// getPricingFilterInput prepares the log groups archived storage pricing filter
+func (lg *LogGroupsManager) getPricingFilterInput(pricingRegionPrefix string) pricing.GetProductsInput {
+	return pricing.GetProductsInput{
+		ServiceCode: &lg.servicePricingCode,
+		Filters: []*pricing.Filter{
+			{
+				Type:  awsClient.String("TERM_MATCH"),
+				Field: awsClient.String("productFamily"),
+				Value: awsClient.String("Storage Snapshot"),
+			},
+			{
+				Type:  awsClient.String("TERM_MATCH"),
+				Field: awsClient.String("termType"),
+				Value: awsClient.String("OnDemand"),
+			},
+			{
+				Type:  awsClient.String("TERM_MATCH"),
+				Field: awsClient.String("usagetype"),
+				Value: awsClient.String(fmt.Sprintf("%sTimedStorage-ByteHrs", pricingRegionPrefix)),
+			},
+		},
+	}
+}
+
+// describeLogGroups returns a list of log groups
+func (lg *LogGroupsManager) describeLogGroups(nextToken *string, logGroups []*cloudwatchlogs.LogGroup) ([]*cloudwatchlogs.LogGroup, error) {
+
+	input := &cloudwatchlogs.DescribeLogGroupsInput{
+		NextToken: nextToken,
+	}
+
+	resp, err := lg.client.DescribeLogGroups(input)
+	if err != nil {
+		log.WithField("error", err).Error("could not describe log groups")
+		return nil, err
+	}
+
+	if logGroups == nil {
+		logGroups = []*cloudwatchlogs.LogGroup{}
+	}
+
+	logGroups = append(logGroups, resp.LogGroups...)
+
+	if resp.NextToken != nil {
+		return lg.describeLogGroups(resp.NextToken, logGroups)
+	}
+
+	return logGroups, nil
+}
@@ -0,0 +1,259 @@
+package resources
+
+import (
+	"errors"
+	awsTestutils "finala/collector/aws/testutils"
+	"finala/collector/config"
+	"finala/collector/testutils"
+	collectorTestutils "finala/collector/testutils"
+	"reflect"
+	"testing"
+
+	awsClient "github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudwatch"
+	"github.com/aws/aws-sdk-go/service/cloudwatchlogs"
+)
+
+var defaultLogGroupsMock = cloudwatchlogs.DescribeLogGroupsOutput{
+	LogGroups: []*cloudwatchlogs.LogGroup{
+		{
+			LogGroupName: awsClient.String("/aws/lambda/never-expire"),
+			LogGroupArn:  awsClient.String("arn:aws:logs:us-east-1:1234:log-group:/aws/lambda/never-expire"),
+			CreationTime: awsClient.Int64(1577836800000),
+			StoredBytes:  awsClient.Int64(100 * bytesInGB),
+		},
+		{
+			LogGroupName:    awsClient.String("/aws/lambda/retention"),
+			LogGroupArn:     awsClient.String("arn:aws:logs:us-east-1:1234:log-group:/aws/lambda/retention"),
+			CreationTime:    awsClient.Int64(1577836800000),
+			StoredBytes:     awsClient.Int64(200 * bytesInGB),
+			RetentionInDays: awsClient.Int64(30),
+		},
+	},
+}
+
+type MockAWSLogGroupsClient struct {
+	responseDescribeLogGroups cloudwatchlogs.DescribeLogGroupsOutput
+	err                       error
+}
+
+func (r *MockAWSLogGroupsClient) DescribeLogGroups(*cloudwatchlogs.DescribeLogGroupsInput) (*cloudwatchlogs.DescribeLogGroupsOutput, error) {
+	return &r.responseDescribeLogGroups, r.err
+}
+
+func (r *MockAWSLogGroupsClient) ListTagsForResource(*cloudwatchlogs.ListTagsForResourceInput) (*cloudwatchlogs.ListTagsForResourceOutput, error) {
+	return &cloudwatchlogs.ListTagsForResourceOutput{
+		Tags: map[string]*string{
+			"team": awsClient.String("logs"),
+		},
+	}, r.err
+}
+
+func TestNewLogGroupsManager(t *testing.T) {
+
+	collector := collectorTestutils.NewMockCollector()
+	detector := awsTestutils.AWSManager(collector, nil, nil, "us-east-1")
+
+	logGroupsManager, err := NewLogGroupsManager(detector, &MockEmptyClient{})
+	if err == nil {
+		t.Fatalf("unexpected error happened, got nil expected error")
+	}
+	if logGroupsManager != nil {
+		t.Fatalf("unexpected log groups manager instance, got %v expected nil", reflect.TypeOf(logGroupsManager))
+	}
+}
+
+func TestDescribeLogGroups(t *testing.T) {
+
+	collector := collectorTestutils.NewMockCollector()
+	detector := awsTestutils.AWSManager(collector, nil, nil, "us-east-1")
+
+	t.Run("valid", func(t *testing.T) {
+
+		mockClient := MockAWSLogGroupsClient{
+			responseDescribeLogGroups: defaultLogGroupsMock,
+		}
+
+		logGroupsInterface, err := NewLogGroupsManager(detector, &mockClient)
+		if err != nil {
+			t.Fatalf("unexpected log groups manager error happened, got %v expected %v", err, nil)
+		}
+
+		logGroupsManager, ok := logGroupsInterface.(*LogGroupsManager)
+		if !ok {
+			t.Fatalf("unexpected log groups struct, got %s expected %s", reflect.TypeOf(logGroupsInterface), "*LogGroupsManager")
+		}
+
+		result, err := logGroupsManager.describeLogGroups(nil, nil)
+		if err != nil {
+			t.Fatalf("unexpected error happened, got %v expected %v", err, nil)
+		}
+
+		if len(result) != len(defaultLogGroupsMock.LogGroups) {
+			t.Fatalf("unexpected log groups count, got %d expected %d", len(result), len(defaultLogGroupsMock.LogGroups))
+		}
+	})
+
+	t.Run("error", func(t *testing.T) {
+
+		mockClient := MockAWSLogGroupsClient{
+			responseDescribeLogGroups: defaultLogGroupsMock,
+			err:                       errors.New("error"),
+		}
+
+		logGroupsInterface, err := NewLogGroupsManager(detector, &mockClient)
+		if err != nil {
+			t.Fatalf("unexpected log groups manager error happened, got %v expected %v", err, nil)
+		}
+
+		logGroupsManager, ok := logGroupsInterface.(*LogGroupsManager)
+		if !ok {
+			t.Fatalf("unexpected log groups struct, got %s expected %s", reflect.TypeOf(logGroupsInterface), "*LogGroupsManager")
+		}
+
+		results, err := logGroupsManager.describeLogGroups(nil, nil)
+		if err == nil {
+			t.Fatalf("unexpected describe log groups error, return empty")
+		}
+
+		if len(results) != 0 {
+			t.Fatalf("unexpected log groups count, got %d expected %d", len(results), 0)
+		}
+	})
+}
+
+func TestDetectLogGroups(t *testing.T) {
+
+	cloudWatchMetrics := map[string]cloudwatch.GetMetricStatisticsOutput{
+		"IncomingBytes": {
+			Datapoints: []*cloudwatch.Datapoint{
+				{Sum: testutils.Float64Pointer(0)},
+			},
+		},
+	}
+
+	testCases := []struct {
+		name          string
+		metrics       []config.MetricConfig
+		expectedNames []string
+	}{
+		{"never expire and large", []config.MetricConfig{
+			{
+				Description: "Never expire stored GB",
+				Constraint: config.MetricConstraintConfig{
+					Operator: ">=",
+					Value:    50,
+				},
+			},
+		}, []string{"/aws/lambda/never-expire"}},
+		{"no ingestion", []config.MetricConfig{
+			{
+				Description: "No ingestion",
+				Data: []config.MetricDataConfiguration{
+					{
+						Name:      "IncomingBytes",
+						Statistic: "Sum",
+					},
+				},
+				Constraint: config.MetricConstraintConfig{
+					Operator: "==",
+					Value:    0,
+				},
+			},
+		}, []string{"/aws/lambda/never-expire", "/aws/lambda/retention"}},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+
+			collector := collectorTestutils.NewMockCollector()
+			mockCloudwatch := awsTestutils.NewMockCloudwatch(&cloudWatchMetrics)
+			mockPrice := awsTestutils.NewMockPricing(nil)
+			detector := awsTestutils.AWSManager(collector, mockCloudwatch, mockPrice, "us-east-1")
+
+			mockClient := MockAWSLogGroupsClient{
+				responseDescribeLogGroups: defaultLogGroupsMock,
+			}
+
+			logGroupsManager, err := NewLogGroupsManager(detector, &mockClient)
+			if err != nil {
+				t.Fatalf("unexpected log groups manager error happened, got %v expected %v", err, nil)
+			}
+
+			response, err := logGroupsManager.Detect(test.metrics)
+			if err != nil {
+				t.Fatalf("unexpected log groups detection error happened, got %v expected %v", err, nil)
+			}
+
+			logGroupsResponse, ok := response.([]DetectedLogGroup)
+			if !ok {
+				t.Fatalf("unexpected log groups struct, got %s expected %s", reflect.TypeOf(response), "[]DetectedLogGroup")
+			}
+
+			if len(logGroupsResponse) != len(test.expectedNames) {
+				t.Fatalf("unexpected log groups detection count, got %d expected %d", len(logGroupsResponse), len(test.expectedNames))
+			}
+
+			if len(collector.Events) != len(test.expectedNames) {
+				t.Fatalf("unexpected collector log groups events, got %d expected %d", len(collector.Events), len(test.expectedNames))
+			}
+
+			for i, logGroup := range logGroupsResponse {
+				if logGroup.Name != test.expectedNames[i] {
+					t.Fatalf("unexpected detected log group, got %s expected %s", logGroup.Name, test.expectedNames[i])
+				}
+			}
+		})
+	}
+
+	t.Run("detection event data", func(t *testing.T) {
+
+		collector := collectorTestutils.NewMockCollector()
+		mockCloudwatch := awsTestutils.NewMockCloudwatch(&cloudWatchMetrics)
+		mockPrice := awsTestutils.NewMockPricing(nil)
+		detector := awsTestutils.AWSManager(collector, mockCloudwatch, mockPrice, "us-east-1")
+
+		mockClient := MockAWSLogGroupsClient{
+			responseDescribeLogGroups: defaultLogGroupsMock,
+		}
+
+		logGroupsManager, _ := NewLogGroupsManager(detector, &mockClient)
+		response, _ := logGroupsManager.Detect(testCases[0].metrics)
+		logGroup := response.([]DetectedLogGroup)[0]
+
+		if !logGroup.NeverExpire {
+			t.Fatalf("unexpected never expire value, got %t expected %t", logGroup.NeverExpire, true)
+		}
+
+		if logGroup.PricePerMonth != 100 {
+			t.Fatalf("unexpected price per month, got %f expected %d", logGroup.PricePerMonth, 100)
+		}
+
+		if len(logGroup.Tag) != 1 {
+			t.Fatalf("unexpected tags count, got %d expected %d", len(logGroup.Tag), 1)
+		}
+	})
+
+	t.Run("detection error", func(t *testing.T) {
+
+		collector := collectorTestutils.NewMockCollector()
+		mockCloudwatch := awsTestutils.NewMockCloudwatch(nil)
+		mockPrice := awsTestutils.NewMockPricing(nil)
+		detector := awsTestutils.AWSManager(collector, mockCloudwatch, mockPrice, "us-east-1")
+
+		mockClient := MockAWSLogGroupsClient{
+			responseDescribeLogGroups: defaultLogGroupsMock,
+			err:                       errors.New("error"),
+		}
+
+		logGroupsManager, err := NewLogGroupsManager(detector, &mockClient)
+		if err != nil {
+			t.Fatalf("unexpected log groups manager error happened, got %v expected %v", err, nil)
+		}
+
+		_, err = logGroupsManager.Detect(awsTestutils.DefaultMetricConfig)
+		if err == nil {
+			t.Fatalf("unexpected detection log groups manager error, got nil expected error message")
+		}
+	})
+}
@@ -0,0 +1,508 @@
+package resources
+
+import (
+	"errors"
+	"finala/collector"
+	"finala/collector/aws/common"
+	"finala/collector/aws/register"
+	"finala/collector/config"
+	"fmt"
+	"strings"
+
+	awsClient "github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/pricing"
+	"github.com/aws/aws-sdk-go/service/rds"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// modernizationCategory is the summary category of the resources running on previous generation types
+	modernizationCategory = "modernization"
+
+	// modernizationEC2Instance describes an ec2 instance modernization
+	modernizationEC2Instance = "ec2_instance"
+
+	// modernizationEC2Volume describes an ec2 volume modernization
+	modernizationEC2Volume = "ec2_volume"
+
+	// modernizationRDSInstance describes a rds instance modernization
+	modernizationRDSInstance = "rds_instance"
+)
+
+// ec2PreviousGenerationFamilies maps previous generation ec2 families to their current generation equivalent
+var ec2PreviousGenerationFamilies = map[string]string{
+	"t1": "t3",
+	"t2": "t3",
+	"m1": "m5",
+	"m3": "m5",
+	"m4": "m5",
+	"c1": "c5",
+	"c3": "c5",
+	"c4": "c5",
+	"r3": "r5",
+	"r4": "r5",
+	"i2": "i3",
+	"d2": "d3",
+	"g2": "g4dn",
+	"g3": "g4dn",
+	"p2": "p3",
+}
+
+// rdsPreviousGenerationClasses maps previous generation rds class families to their current generation equivalent
+var rdsPreviousGenerationClasses = map[string]string{
+	"db.t2": "db.t3",
+	"db.m1": "db.m5",
+	"db.m3": "db.m5",
+	"db.m4": "db.m5",
+	"db.r3": "db.r5",
+	"db.r4": "db.r5",
+}
+
+// ModernizationClientDescreptor is an interface defining the aws ec2 and rds clients
+type ModernizationClientDescreptor interface {
+	DescribeInstances(*ec2.DescribeInstancesInput) (*ec2.DescribeInstancesOutput, error)
+	DescribeVolumes(*ec2.DescribeVolumesInput) (*ec2.DescribeVolumesOutput, error)
+	DescribeDBInstances(*rds.DescribeDBInstancesInput) (*rds.DescribeDBInstancesOutput, error)
+}
+
+// modernizationClient combines the aws ec2 and rds clients
+type modernizationClient struct {
+	*ec2.EC2
+	*rds.RDS
+}
+
+// ModernizationManager describes the previous generation resources struct
+type ModernizationManager struct {
+	client                ModernizationClientDescreptor
+	awsManager            common.AWSManager
+	ec2ServicePricingCode string
+	volumeManager         *EC2VolumeManager
+	rdsManager            *RDSManager
+	Name                  collector.ResourceIdentifier
+}
+
+// DetectedModernization defines a resource running on a previous generation type,
+// priced side by side with its current generation equivalent
+type DetectedModernization struct {
+	Metric                   string
+	Category                 string
+	ResourceType             string
+	CurrentType              string
+	RecommendedType          string
+	RecommendedPricePerMonth float64
+	SavingPerMonth           float64
+	collector.PriceDetectedFields
+}
+
+func init() {
+	register.Registry("modernization", NewModernizationManager)
+}
+
+// NewModernizationManager implements AWS GO SDK
+func NewModernizationManager(awsManager common.AWSManager, client interface{}) (common.ResourceDetection, error) {
+
+	if client == nil {
+		client = &modernizationClient{
+			EC2: ec2.New(awsManager.GetSession()),
+			RDS: rds.New(awsManager.GetSession()),
+		}
+	}
+
+	mmClient, ok := client.(ModernizationClientDescreptor)
+	if !ok {
+		return nil, errors.New("invalid modernization client")
+	}
+
+	return &ModernizationManager{
+		client:                mmClient,
+		awsManager:            awsManager,
+		ec2ServicePricingCode: "AmazonEC2",
+		volumeManager: &EC2VolumeManager{
+			awsManager:         awsManager,
+			servicePricingCode: "AmazonEC2",
+		},
+		rdsManager: &RDSManager{
+			awsManager:         awsManager,
+			servicePricingCode: "AmazonRDS",
+		},
+		Name: awsManager.GetResourceIdentifier("modernization"),
+	}, nil
+}
+
+// Detect lists the ec2 instances, gp2 volumes and rds instances running on previous generation types
+// which have a cheaper current generation equivalent. Utilization is not checked.
+func (mm *ModernizationManager) Detect(metrics []config.MetricConfig) (interface{}, error) {
+
+	log.WithFields(log.Fields{
+		"region":   mm.awsManager.GetRegion(),
+		"resource": "modernization",
+	}).Info("starting to analyze resource")
+
+	mm.awsManager.GetCollector().CollectStart(mm.Name)
+
+	detected := []DetectedModernization{}
+
+	// This resource support only one metric
+	var metricDescription string
+	if len(metrics) > 0 {
+		metricDescription = metrics[0].Description
+	}
+
+	instances, err := mm.describeInstances(nil, nil)
+	if err != nil {
+		mm.awsManager.GetCollector().CollectError(mm.Name, err)
+		return detected, err
+	}
+
+	volumes, err := mm.describeVolumes(nil, nil)
+	if err != nil {
+		mm.awsManager.GetCollector().CollectError(mm.Name, err)
+		return detected, err
+	}
+
+	dbInstances, err := mm.describeDBInstances(nil, nil)
+	if err != nil {
+		mm.awsManager.GetCollector().CollectError(mm.Name, err)
+		return detected, err
+	}
+
+	for _, instance := range instances {
+		modernization, ok := mm.getInstanceModernization(instance)
+		if ok {
+			detected = append(detected, mm.addResource(modernization, metricDescription))
+		}
+	}
+
+	for _, volume := range volumes {
+		modernization, ok := mm.getVolumeModernization(volume)
+		if ok {
+			detected = append(detected, mm.addResource(modernization, metricDescription))
+		}
+	}
+
+	for _, dbInstance := range dbInstances {
+		modernization, ok := mm.getDBInstanceModernization(dbInstance)
+		if ok {
+			detected = append(detected, mm.addResource(modernization, metricDescription))
+		}
+	}
+
+	mm.awsManager.GetCollector().CollectFinish(mm.Name)
+
+	return detected, nil
+}
+
+// addResource completes the detected modernization fields and sends it to the collector
+func (mm *ModernizationManager) addResource(modernization DetectedModernization, metricDescription string) DetectedModernization {
+
+	modernization.AccountDimensions = mm.awsManager.GetAccountDimensions()
+	modernization.Metric = metricDescription
+	modernization.Category = modernizationCategory
+	modernization.SavingPerMonth = modernization.PricePerMonth - modernization.RecommendedPricePerMonth
+
+	log.WithFields(log.Fields{
+		"resource_id":      modernization.ResourceID,
+		"resource_type":    modernization.ResourceType,
+		"current_type":     modernization.CurrentType,
+		"recommended_type": modernization.RecommendedType,
+		"region":           modernization.Region,
+	}).Info("Resource detected as running on a previous generation type")
+
+	mm.awsManager.GetCollector().AddResource(collector.EventCollector{
+		ResourceName: mm.Name,
+		Data:         modernization,
+	})
+
+	return modernization
+}
+
+// getInstanceModernization returns the ec2 instance current generation type when it runs on a previous generation type
+func (mm *ModernizationManager) getInstanceModernization(instance *ec2.Instance) (DetectedModernization, bool) {
+
+	recommendedType, ok := getCurrentGenerationType(*instance.InstanceType, ec2PreviousGenerationFamilies)
+	if !ok {
+		return DetectedModernization{}, false
+	}
+
+	platform := "Linux"
+	if instance.Platform != nil {
+		platform = *instance.Platform
+	}
+
+	price, recommendedPrice, ok := mm.getPrices(
+		getEC2InstanceTypePricingFilterInput(mm.ec2ServicePricingCode, *instance.InstanceType, platform),
+		getEC2InstanceTypePricingFilterInput(mm.ec2ServicePricingCode, recommendedType, platform),
+		*instance.InstanceId,
+	)
+	if !ok {
+		return DetectedModernization{}, false
+	}
+
+	tagsData := map[string]string{}
+	for _, tag := range instance.Tags {
+		tagsData[*tag.Key] = *tag.Value
+	}
+
+	return DetectedModernization{
+		ResourceType:             modernizationEC2Instance,
+		CurrentType:              *instance.InstanceType,
+		RecommendedType:          recommendedType,
+		RecommendedPricePerMonth: recommendedPrice * collector.TotalMonthHours,
+		PriceDetectedFields: collector.PriceDetectedFields{
+			ResourceID:    *instance.InstanceId,
+			ARN:           mm.awsManager.GetARN("ec2", fmt.Sprintf("instance/%s", *instance.InstanceId)),
+			ConsoleURL:    mm.awsManager.GetConsoleURL("ec2/home", fmt.Sprintf("InstanceDetails:instanceId=%s", *instance.InstanceId)),
+			LaunchTime:    awsClient.TimeValue(instance.LaunchTime),
+			PricePerHour:  price,
+			PricePerMonth: price * collector.TotalMonthHours,
+			Tag:           tagsData,
+		},
+	}, true
+}
+
+// getVolumeModernization returns the gp3 equivalent of a gp2 volume, provisioned with the same IOPS and throughput
+func (mm *ModernizationManager) getVolumeModernization(volume *ec2.Volume) (DetectedModernization, bool) {
+
+	if awsClient.StringValue(volume.VolumeType) != "gp2" {
+		return DetectedModernization{}, false
+	}
+
+	// gp2 volumes up to 170 GiB deliver up to 128 MiB/s, larger volumes up to 250 MiB/s
+	var throughput int64 = 128
+	if awsClient.Int64Value(volume.Size) > 170 {
+		throughput = 250
+	}
+
+	iops := awsClient.Int64Value(volume.Iops)
+	if iops < gp3BaselineIOPS {
+		iops = gp3BaselineIOPS
+	}
+
+	gp3Volume := &ec2.Volume{
+		VolumeId:   volume.VolumeId,
+		VolumeType: awsClient.String("gp3"),
+		Size:       volume.Size,
+		Iops:       awsClient.Int64(iops),
+		Throughput: awsClient.Int64(throughput),
+	}
+
+	storageFilters := []*pricing.Filter{
+		{
+			Type:  awsClient.String("TERM_MATCH"),
+			Field: awsClient.String("productFamily"),
+			Value: awsClient.String("Storage"),
+		},
+	}
+
+	basePrice, gp3BasePrice, ok := mm.getPrices(
+		mm.volumeManager.getBasePricingFilterInput(volume, storageFilters),
+		mm.volumeManager.getBasePricingFilterInput(gp3Volume, storageFilters),
+		*volume.VolumeId,
+	)
+	if !ok {
+		return DetectedModernization{}, false
+	}
+
+	pricePerMonth := mm.volumeManager.getCalculatedPrice(volume, basePrice)
+	recommendedPricePerMonth := mm.volumeManager.getCalculatedPrice(gp3Volume, gp3BasePrice)
+	if recommendedPricePerMonth >= pricePerMonth {
+		return DetectedModernization{}, false
+	}
+
+	tagsData := map[string]string{}
+	for _, tag := range volume.Tags {
+		tagsData[*tag.Key] = *tag.Value
+	}
+
+	return DetectedModernization{
+		ResourceType:             modernizationEC2Volume,
+		CurrentType:              "gp2",
+		RecommendedType:          "gp3",
+		RecommendedPricePerMonth: recommendedPricePerMonth,
+		PriceDetectedFields: collector.PriceDetectedFields{
+			ResourceID:    *volume.VolumeId,
+			ARN:           mm.awsManager.GetARN("ec2", fmt.Sprintf("volume/%s", *volume.VolumeId)),
+			ConsoleURL:    mm.awsManager.GetConsoleURL("ec2/home", fmt.Sprintf("VolumeDetails:volumeId=%s", *volume.VolumeId)),
+			LaunchTime:    awsClient.TimeValue(volume.CreateTime),
+			PricePerHour:  pricePerMonth / collector.TotalMonthHours,
+			PricePerMonth: pricePerMonth,
+			Tag:           tagsData,
+		},
+	}, true
+}
+
+// getDBInstanceModernization returns the rds instance current generation class when it runs on a previous generation class
+func (mm *ModernizationManager) getDBInstanceModernization(dbInstance *rds.DBInstance) (DetectedModernization, bool) {
+
+	recommendedClass, ok := getCurrentGenerationType(*dbInstance.DBInstanceClass, rdsPreviousGenerationClasses)
+	if !ok {
+		return DetectedModernization{}, false
+	}
+
+	recommendedInstance := *dbInstance
+	recommendedInstance.DBInstanceClass = awsClient.String(recommendedClass)
+
+	price, recommendedPrice, ok := mm.getPrices(
+		mm.rdsManager.getPricingInstanceFilterInput(dbInstance),
+		mm.rdsManager.getPricingInstanceFilterInput(&recommendedInstance),
+		*dbInstance.DBInstanceIdentifier,
+	)
+	if !ok {
+		return DetectedModernization{}, false
+	}
+
+	tagsData := map[string]string{}
+	for _, tag := range dbInstance.TagList {
+		tagsData[*tag.Key] = *tag.Value
+	}
+
+	return DetectedModernization{
+		ResourceType:             modernizationRDSInstance,
+		CurrentType:              *dbInstance.DBInstanceClass,
+		RecommendedType:          recommendedClass,
+		RecommendedPricePerMonth: recommendedPrice * collector.TotalMonthHours,
+		PriceDetectedFields: collector.PriceDetectedFields{
+			ResourceID:    awsClient.StringValue(dbInstance.DBInstanceArn),
+			ARN:           awsClient.StringValue(dbInstance.DBInstanceArn),
+			ConsoleURL:    mm.awsManager.GetConsoleURL("rds/home", fmt.Sprintf("database:id=%s;is-cluster=false", awsClient.StringValue(dbInstance.DBInstanceIdentifier))),
+			LaunchTime:    awsClient.TimeValue(dbInstance.InstanceCreateTime),
+			PricePerHour:  price,
+			PricePerMonth: price * collector.TotalMonthHours,
+			Tag:           tagsData,
+		},
+	}, true
+}
+
+// getPrices returns the current and recommended prices.
+// false is returned when one of the prices is missing or the recommended price is not cheaper.
+func (mm *ModernizationManager) getPrices(currentFilters, recommendedFilters pricing.GetProductsInput, resourceID string) (float64, float64, bool) {
+
+	price, err := mm.awsManager.GetPricingClient().GetPrice(currentFilters, "", mm.awsManager.GetRegion())
+	if err != nil {
+		log.WithError(err).WithField("resource_id", resourceID).Error("could not get resource current price")
+		return 0, 0, false
+	}
+
+	recommendedPrice, err := mm.awsManager.GetPricingClient().GetPrice(recommendedFilters, "", mm.awsManager.GetRegion())
+	if err != nil {
+		log.WithError(err).WithField("resource_id", resourceID).Debug("could not get resource current generation price")
+		return 0, 0, false
+	}
+
+	return price, recommendedPrice, recommendedPrice <= price
+}
+
+// getCurrentGenerationType returns the current generation type of a previous generation type in the given families
+func getCurrentGenerationType(currentType string, previousGenerationFamilies map[string]string) (string, bool) {
+
+	separator := strings.LastIndex(currentType, ".")
+	if separator == -1 {
+		return "", false
+	}
+
+	family, size := currentType[:separator], currentType[separator+1:]
+	currentGenerationFamily, found := previousGenerationFamilies[family]
+	if !found {
+		return "", false
+	}
+
+	return fmt.Sprintf("%s.%s", currentGenerationFamily, size), true
+}
+
+// describeInstances returns a list of the running ec2 instances
+func (mm *ModernizationManager) describeInstances(nextToken *string, instances []*ec2.Instance) ([]*ec2.Instance, error) {
+
+	input := &ec2.DescribeInstancesInput{
+		NextToken: nextToken,
+		Filters: []*ec2.Filter{
+			{
+				Name:   awsClient.String("instance-state-name"),
+				Values: []*string{awsClient.String("running")},
+			},
+		},
+	}
+
+	resp, err := mm.client.DescribeInstances(input)
+	if err != nil {
+		log.WithField("error", err).Error("could not describe ec2 instances")
+		return nil, err
+	}
+
+	if instances == nil {
+		instances = []*ec2.Instance{}
+	}
+
+	for _, reservations := range resp.Reservations {
+		instances = append(instances, reservations.Instances...)
+	}
+
+	if resp.NextToken != nil {
+		return mm.describeInstances(resp.NextToken, instances)
+	}
+
+	return instances, nil
+}
+
+// describeVolumes returns a list of the gp2 volumes
+func (mm *ModernizationManager) describeVolumes(nextToken *string, volumes []*ec2.Volume) ([]*ec2.Volume, error) {
+
+	input := &ec2.DescribeVolumesInput{
+		NextToken: nextToken,
+		Filters: []*ec2.Filter{
+			{
+				Name:   awsClient.String("volume-type"),
+				Values: []*string{awsClient.String("gp2")},
+			},
+		},
+	}
+
+	resp, err := mm.client.DescribeVolumes(input)
+	if err != nil {
+		log.WithField("error", err).Error("could not describe ec2 volumes")
+		return nil, err
+	}
+
+	if volumes == nil {
+		volumes = []*ec2.Volume{}
+	}
+
+	volumes = append(volumes, resp.Volumes...)
+
+	if resp.NextToken != nil {
+		return mm.describeVolumes(resp.NextToken, volumes)
+	}
+
+	return volumes, nil
+}
+
+// describeDBInstances returns a list of the rds instances
+func (mm *ModernizationManager) describeDBInstances(marker *string, dbInstances []*rds.DBInstance) ([]*rds.DBInstance, error) {
+
+	input := &rds.DescribeDBInstancesInput{
+		Marker: marker,
+	}
+
+	resp, err := mm.client.DescribeDBInstances(input)
+	if err != nil {
+		log.WithField("error", err).Error("could not describe rds instances")
+		return nil, err
+	}
+
+	if dbInstances == nil {
+		dbInstances = []*rds.DBInstance{}
+	}
+
+	for _, dbInstance := range resp.DBInstances {
+		// DocumentDB and Neptune instances are returned by the rds api as well
+		if *dbInstance.Engine != "docdb" && *dbInstance.Engine != "neptune" {
+			dbInstances = append(dbInstances, dbInstance)
+		}
+	}
+
+	if resp.Marker != nil {
+		return mm.describeDBInstances(resp.Marker, dbInstances)
+	}
+
+	return dbInstances, nil
+}
@@ -0,0 +1,221 @@
+package resources
+
+import (
+	"errors"
+	"finala/collector/aws/pricing"
+	awsTestutils "finala/collector/aws/testutils"
+	"finala/collector/config"
+	"finala/collector/testutils"
+	collectorTestutils "finala/collector/testutils"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	awsClient "github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/rds"
+)
+
+var defaultModernizationInstancesMock = ec2.DescribeInstancesOutput{
+	Reservations: []*ec2.Reservation{
+		{
+			Instances: []*ec2.Instance{
+				{
+					InstanceId:   awsClient.String("i-previous"),
+					InstanceType: awsClient.String("m4.large"),
+					LaunchTime:   testutils.TimePointer(time.Now()),
+				},
+				{
+					InstanceId:   awsClient.String("i-current"),
+					InstanceType: awsClient.String("m5.large"),
+					LaunchTime:   testutils.TimePointer(time.Now()),
+				},
+			},
+		},
+	},
+}
+
+var defaultModernizationVolumesMock = ec2.DescribeVolumesOutput{
+	Volumes: []*ec2.Volume{
+		{
+			VolumeId:   awsClient.String("vol-gp2"),
+			VolumeType: awsClient.String("gp2"),
+			Size:       awsClient.Int64(100),
+			Iops:       awsClient.Int64(300),
+			CreateTime: testutils.TimePointer(time.Now()),
+		},
+	},
+}
+
+var defaultModernizationDBInstancesMock = rds.DescribeDBInstancesOutput{
+	DBInstances: []*rds.DBInstance{
+		{
+			DBInstanceIdentifier: awsClient.String("db-previous"),
+			DBInstanceArn:        awsClient.String("arn:aws:rds:us-east-1:1234:db:db-previous"),
+			DBInstanceClass:      awsClient.String("db.m4.large"),
+			Engine:               awsClient.String("mysql"),
+			MultiAZ:              awsClient.Bool(false),
+			InstanceCreateTime:   testutils.TimePointer(time.Now()),
+		},
+		{
+			DBInstanceIdentifier: awsClient.String("docdb"),
+			DBInstanceClass:      awsClient.String("db.r4.large"),
+			Engine:               awsClient.String("docdb"),
+			MultiAZ:              awsClient.Bool(false),
+		},
+	},
+}
+
+var defaultModernizationPrices = map[string]float64{
+	"m4.large":                   0.1,
+	"m5.large":                   0.096,
+	"db.m4.large":                0.182,
+	"db.m5.large":                0.171,
+	"gp2":                        0.1,
+	"gp3":                        0.08,
+	"EBS:VolumeP-IOPS.gp3":       0.005,
+	"EBS:VolumeP-Throughput.gp3": 0.04,
+}
+
+type MockAWSModernizationClient struct {
+	err error
+}
+
+func (r *MockAWSModernizationClient) DescribeInstances(*ec2.DescribeInstancesInput) (*ec2.DescribeInstancesOutput, error) {
+	return &defaultModernizationInstancesMock, r.err
+}
+
+func (r *MockAWSModernizationClient) DescribeVolumes(*ec2.DescribeVolumesInput) (*ec2.DescribeVolumesOutput, error) {
+	return &defaultModernizationVolumesMock, r.err
+}
+
+func (r *MockAWSModernizationClient) DescribeDBInstances(*rds.DescribeDBInstancesInput) (*rds.DescribeDBInstancesOutput, error) {
+	return &defaultModernizationDBInstancesMock, r.err
+}
+
+func TestNewModernizationManager(t *testing.T) {
+
+	collector := collectorTestutils.NewMockCollector()
+	detector := awsTestutils.AWSManager(collector, nil, nil, "us-east-1")
+
+	modernizationManager, err := NewModernizationManager(detector, &MockEmptyClient{})
+	if err == nil {
+		t.Fatalf("unexpected error happened, got nil expected error")
+	}
+	if modernizationManager != nil {
+		t.Fatalf("unexpected modernization manager instance, got %v expected nil", reflect.TypeOf(modernizationManager))
+	}
+}
+
+func TestGetCurrentGenerationType(t *testing.T) {
+
+	testCases := []struct {
+		currentType   string
+		families      map[string]string
+		expectedType  string
+		expectedFound bool
+	}{
+		{"m4.large", ec2PreviousGenerationFamilies, "m5.large", true},
+		{"t2.micro", ec2PreviousGenerationFamilies, "t3.micro", true},
+		{"m5.large", ec2PreviousGenerationFamilies, "", false},
+		{"db.r4.2xlarge", rdsPreviousGenerationClasses, "db.r5.2xlarge", true},
+		{"db.r5.large", rdsPreviousGenerationClasses, "", false},
+		{"invalid", ec2PreviousGenerationFamilies, "", false},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.currentType, func(t *testing.T) {
+			currentGenerationType, found := getCurrentGenerationType(test.currentType, test.families)
+			if found != test.expectedFound || currentGenerationType != test.expectedType {
+				t.Fatalf("unexpected current generation type, got %s/%t expected %s/%t", currentGenerationType, found, test.expectedType, test.expectedFound)
+			}
+		})
+	}
+}
+
+func TestDetectModernization(t *testing.T) {
+
+	metrics := []config.MetricConfig{
+		{
+			Description: "Previous generation",
+			Enable:      true,
+		},
+	}
+
+	t.Run("detect", func(t *testing.T) {
+
+		collector := collectorTestutils.NewMockCollector()
+		mockPrice := pricing.NewPricingManager(&MockFilterPricingClient{prices: defaultModernizationPrices}, "us-east-1")
+		detector := awsTestutils.AWSManager(collector, nil, mockPrice, "us-east-1")
+
+		modernizationManager, err := NewModernizationManager(detector, &MockAWSModernizationClient{})
+		if err != nil {
+			t.Fatalf("unexpected modernization manager error happened, got %v expected %v", err, nil)
+		}
+
+		response, err := modernizationManager.Detect(metrics)
+		if err != nil {
+			t.Fatalf("unexpected modernization detection error happened, got %v expected %v", err, nil)
+		}
+
+		modernizationResponse, ok := response.([]DetectedModernization)
+		if !ok {
+			t.Fatalf("unexpected modernization struct, got %s expected %s", reflect.TypeOf(response), "[]DetectedModernization")
+		}
+
+		if len(collector.Events) != 3 {
+			t.Fatalf("unexpected collector modernization events, got %d expected %d", len(collector.Events), 3)
+		}
+
+		expected := []struct {
+			resourceID      string
+			resourceType    string
+			recommendedType string
+			saving          float64
+		}{
+			{"i-previous", modernizationEC2Instance, "m5.large", (0.1 - 0.096) * 730},
+			{"vol-gp2", modernizationEC2Volume, "gp3", 100*0.1 - (100*0.08 + 3*0.04)},
+			{"arn:aws:rds:us-east-1:1234:db:db-previous", modernizationRDSInstance, "db.m5.large", (0.182 - 0.171) * 730},
+		}
+
+		if len(modernizationResponse) != len(expected) {
+			t.Fatalf("unexpected modernization detection count, got %d expected %d", len(modernizationResponse), len(expected))
+		}
+
+		for i, modernization := range modernizationResponse {
+			if modernization.ResourceID != expected[i].resourceID || modernization.ResourceType != expected[i].resourceType {
+				t.Fatalf("unexpected detected resource, got %s/%s expected %s/%s", modernization.ResourceID, modernization.ResourceType, expected[i].resourceID, expected[i].resourceType)
+			}
+
+			if modernization.RecommendedType != expected[i].recommendedType {
+				t.Fatalf("unexpected recommended type, got %s expected %s", modernization.RecommendedType, expected[i].recommendedType)
+			}
+
+			if fmt.Sprintf("%.2f", modernization.SavingPerMonth) != fmt.Sprintf("%.2f", expected[i].saving) {
+				t.Fatalf("unexpected saving per month, got %f expected %f", modernization.SavingPerMonth, expected[i].saving)
+			}
+
+			if modernization.Category != modernizationCategory {
+				t.Fatalf("unexpected category, got %s expected %s", modernization.Category, modernizationCategory)
+			}
+		}
+	})
+
+	t.Run("detection error", func(t *testing.T) {
+
+		collector := collectorTestutils.NewMockCollector()
+		mockPrice := awsTestutils.NewMockPricing(nil)
+		detector := awsTestutils.AWSManager(collector, nil, mockPrice, "us-east-1")
+
+		modernizationManager, err := NewModernizationManager(detector, &MockAWSModernizationClient{err: errors.New("error")})
+		if err != nil {
+			t.Fatalf("unexpected modernization manager error happened, got %v expected %v", err, nil)
+		}
+
+		_, err = modernizationManager.Detect(metrics)
+		if err == nil {
+			t.Fatalf("unexpected detection modernization manager error, got nil expected error message")
+		}
+	})
+}
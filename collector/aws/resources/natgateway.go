@@ -34,7 +34,6 @@ type NatGatewayManager struct {
 
 // DetectedNATGateway defines the detected AWS NAT gateways
 type DetectedNATGateway struct {
-	Region   string
 	Metric   string
 	SubnetID string
 	VPCID    string
@@ -111,6 +110,21 @@ func (ngw *NatGatewayManager) Detect(metrics []config.MetricConfig) (interface{}
 	for _, natgateway := range natGateways {
 		log.WithField("gateway_id", *natgateway.NatGatewayId).Debug("checking NAT gateway")
 
+		tagsData := map[string]string{}
+		for _, tag := range natgateway.Tags {
+			tagsData[*tag.Key] = *tag.Value
+		}
+
+		ngw.awsManager.ExamineResource(ngw.Name, func() collector.PriceDetectedFields {
+			return collector.PriceDetectedFields{
+				LaunchTime:    *natgateway.CreateTime,
+				ResourceID:    *natgateway.NatGatewayId,
+				PricePerHour:  price,
+				PricePerMonth: price * collector.TotalMonthHours,
+				Tag:           tagsData,
+			}
+		})
+
 		for _, metric := range metrics {
 			log.WithFields(log.Fields{
 				"gateway_id":  *natgateway.NatGatewayId,
@@ -159,24 +173,19 @@ func (ngw *NatGatewayManager) Detect(metrics []config.MetricConfig) (interface{}
 					"region":              ngw.awsManager.GetRegion(),
 				}).Info("NAT gateway detected as unutilized resource")
 
-				tagsData := map[string]string{}
-				if err == nil {
-					for _, tag := range natgateway.Tags {
-						tagsData[*tag.Key] = *tag.Value
-					}
-				}
-
 				natGateway := DetectedNATGateway{
-					Region:   ngw.awsManager.GetRegion(),
 					Metric:   metric.Description,
 					SubnetID: *natgateway.SubnetId,
 					VPCID:    *natgateway.VpcId,
 					PriceDetectedFields: collector.PriceDetectedFields{
-						LaunchTime:    *natgateway.CreateTime,
-						ResourceID:    *natgateway.NatGatewayId,
-						PricePerHour:  price,
-						PricePerMonth: price * collector.TotalMonthHours,
-						Tag:           tagsData,
+						LaunchTime:        *natgateway.CreateTime,
+						ResourceID:        *natgateway.NatGatewayId,
+						ARN:               ngw.awsManager.GetARN("ec2", fmt.Sprintf("natgateway/%s", *natgateway.NatGatewayId)),
+						ConsoleURL:        ngw.awsManager.GetConsoleURL("vpc/home", fmt.Sprintf("NatGatewayDetails:natGatewayId=%s", *natgateway.NatGatewayId)),
+						PricePerHour:      price,
+						PricePerMonth:     price * collector.TotalMonthHours,
+						Tag:               tagsData,
+						AccountDimensions: ngw.awsManager.GetAccountDimensions(),
 					},
 				}
 
@@ -7,6 +7,7 @@ import (
 	"finala/collector/aws/register"
 	"finala/collector/config"
 	"finala/expression"
+	"fmt"
 	"time"
 
 	awsClient "github.com/aws/aws-sdk-go/aws"
@@ -34,7 +35,6 @@ type NeptuneManager struct {
 // DetectedAWSNeptune defines the detected AWS Neptune instances
 type DetectedAWSNeptune struct {
 	Metric       string
-	Region       string
 	InstanceType string
 	MultiAZ      bool
 	Engine       string
@@ -91,6 +91,16 @@ func (np *NeptuneManager) Detect(metrics []config.MetricConfig) (interface{}, er
 
 		price, _ := np.awsManager.GetPricingClient().GetPrice(np.getPricingFilterInput(instance), "", np.awsManager.GetRegion())
 
+		np.awsManager.ExamineResource(np.Name, func() collector.PriceDetectedFields {
+			return collector.PriceDetectedFields{
+				ResourceID:    *instance.DBInstanceArn,
+				LaunchTime:    *instance.InstanceCreateTime,
+				PricePerHour:  price,
+				PricePerMonth: price * collector.TotalMonthHours,
+				Tag:           np.getTags(instance.DBInstanceArn),
+			}
+		})
+
 		for _, metric := range metrics {
 			log.WithFields(log.Fields{
 				"name":        *instance.DBInstanceIdentifier,
@@ -139,29 +149,22 @@ func (np *NeptuneManager) Detect(metrics []config.MetricConfig) (interface{}, er
 					"region":              np.awsManager.GetRegion(),
 				}).Info("detected unutilized neptune resource")
 
-				tags, err := np.client.ListTagsForResource(&neptune.ListTagsForResourceInput{
-					ResourceName: instance.DBInstanceArn,
-				})
-
-				tagsData := map[string]string{}
-				if err == nil {
-					for _, tag := range tags.TagList {
-						tagsData[*tag.Key] = *tag.Value
-					}
-				}
+				tagsData := np.getTags(instance.DBInstanceArn)
 
 				neptune := DetectedAWSNeptune{
-					Region:       np.awsManager.GetRegion(),
 					Metric:       metric.Description,
 					InstanceType: *instance.DBInstanceClass,
 					MultiAZ:      *instance.MultiAZ,
 					Engine:       *instance.Engine,
 					PriceDetectedFields: collector.PriceDetectedFields{
-						ResourceID:    *instance.DBInstanceArn,
-						LaunchTime:    *instance.InstanceCreateTime,
-						PricePerHour:  price,
-						PricePerMonth: price * collector.TotalMonthHours,
-						Tag:           tagsData,
+						ResourceID:        *instance.DBInstanceArn,
+						ARN:               *instance.DBInstanceArn,
+						ConsoleURL:        np.awsManager.GetConsoleURL("neptune/home", fmt.Sprintf("database:id=%s;is-cluster=false", *instance.DBInstanceIdentifier)),
+						LaunchTime:        *instance.InstanceCreateTime,
+						PricePerHour:      price,
+						PricePerMonth:     price * collector.TotalMonthHours,
+						Tag:               tagsData,
+						AccountDimensions: np.awsManager.GetAccountDimensions(),
 					},
 				}
 
@@ -178,6 +181,24 @@ func (np *NeptuneManager) Detect(metrics []config.MetricConfig) (interface{}, er
 
 }
 
+// getTags returns the neptune instance tags
+func (np *NeptuneManager) getTags(resourceName *string) map[string]string {
+
+	tagsData := map[string]string{}
+	tags, err := np.client.ListTagsForResource(&neptune.ListTagsForResourceInput{
+		ResourceName: resourceName,
+	})
+	if err != nil {
+		log.WithError(err).WithField("resource", *resourceName).Error("could not list neptune instance tags")
+		return tagsData
+	}
+
+	for _, tag := range tags.TagList {
+		tagsData[*tag.Key] = *tag.Value
+	}
+	return tagsData
+}
+
 // getPricingFilterInput prepare Neptune pricing filter
 func (np *NeptuneManager) getPricingFilterInput(instance *neptune.DBInstance) pricing.GetProductsInput {
 
@@ -0,0 +1,190 @@
+package resources
+
+import (
+	"errors"
+	"finala/collector"
+	"finala/collector/aws/common"
+	"finala/collector/aws/register"
+	"finala/collector/config"
+	"fmt"
+
+	awsClient "github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	log "github.com/sirupsen/logrus"
+)
+
+// NetworkInterfaceClientDescriptor is an interface defining the aws network interfaces client
+type NetworkInterfaceClientDescriptor interface {
+	DescribeNetworkInterfaces(*ec2.DescribeNetworkInterfacesInput) (*ec2.DescribeNetworkInterfacesOutput, error)
+}
+
+// NetworkInterfaceManager describes the detached network interfaces struct
+type NetworkInterfaceManager struct {
+	client           NetworkInterfaceClientDescriptor
+	awsManager       common.AWSManager
+	elasticIPManager *ElasticIPManager
+	Name             collector.ResourceIdentifier
+}
+
+// DetectedNetworkInterface defines the detected AWS detached network interfaces.
+// Network interfaces are charged by their associated public IPv4 address only.
+type DetectedNetworkInterface struct {
+	Metric           string
+	SubnetID         string
+	VPCID            string
+	InterfaceType    string
+	PrivateIPAddress string
+	PublicIP         string
+	collector.PriceDetectedFields
+}
+
+func init() {
+	register.Registry("network_interfaces", NewNetworkInterfaceManager)
+}
+
+// NewNetworkInterfaceManager implements AWS GO SDK for ec2 network interfaces
+func NewNetworkInterfaceManager(awsManager common.AWSManager, client interface{}) (common.ResourceDetection, error) {
+
+	if client == nil {
+		client = ec2.New(awsManager.GetSession())
+	}
+
+	networkInterfaceClient, ok := client.(NetworkInterfaceClientDescriptor)
+	if !ok {
+		return nil, errors.New("invalid network interface client")
+	}
+
+	return &NetworkInterfaceManager{
+		client:     networkInterfaceClient,
+		awsManager: awsManager,
+		elasticIPManager: &ElasticIPManager{
+			awsManager:         awsManager,
+			servicePricingCode: "AmazonVPC",
+			rateCode:           "6YS6EN2CT7",
+		},
+		Name: awsManager.GetResourceIdentifier("network_interfaces"),
+	}, nil
+}
+
+// Detect lists the network interfaces which are not attached to any resource
+func (ni *NetworkInterfaceManager) Detect(metrics []config.MetricConfig) (interface{}, error) {
+
+	// This resource support only one metric
+	metric := metrics[0]
+
+	log.WithFields(log.Fields{
+		"region":   ni.awsManager.GetRegion(),
+		"resource": "network_interfaces",
+	}).Info("analyzing resource")
+
+	ni.awsManager.GetCollector().CollectStart(ni.Name)
+
+	detectedNetworkInterfaces := []DetectedNetworkInterface{}
+
+	networkInterfaces, err := ni.describeNetworkInterfaces(nil, nil)
+	if err != nil {
+		ni.awsManager.GetCollector().CollectError(ni.Name, err)
+		return detectedNetworkInterfaces, err
+	}
+
+	var publicIPPrice float64
+	for _, networkInterface := range networkInterfaces {
+		if networkInterface.Association != nil && networkInterface.Association.PublicIp != nil {
+			publicIPPrice, err = ni.awsManager.GetPricingClient().GetPrice(ni.elasticIPManager.getPricingFilterInput(), ni.elasticIPManager.rateCode, ni.awsManager.GetRegion())
+			if err != nil {
+				log.WithError(err).Error("could not get public ip price")
+			}
+			break
+		}
+	}
+
+	for _, networkInterface := range networkInterfaces {
+
+		var publicIP string
+		var pricePerHour float64
+		if networkInterface.Association != nil && networkInterface.Association.PublicIp != nil {
+			publicIP = *networkInterface.Association.PublicIp
+			pricePerHour = publicIPPrice
+		}
+
+		tagsData := map[string]string{}
+		for _, tag := range networkInterface.TagSet {
+			tagsData[*tag.Key] = *tag.Value
+		}
+
+		ni.awsManager.ExamineResource(ni.Name, func() collector.PriceDetectedFields {
+			return collector.PriceDetectedFields{
+				ResourceID:    *networkInterface.NetworkInterfaceId,
+				PricePerHour:  pricePerHour,
+				PricePerMonth: pricePerHour * collector.TotalMonthHours,
+				Tag:           tagsData,
+			}
+		})
+
+		log.WithFields(log.Fields{
+			"network_interface_id": *networkInterface.NetworkInterfaceId,
+			"vpc":                  *networkInterface.VpcId,
+			"region":               ni.awsManager.GetRegion(),
+		}).Info("Network interface detected as detached resource")
+
+		detectedNetworkInterface := DetectedNetworkInterface{
+			Metric:           metric.Description,
+			SubnetID:         awsClient.StringValue(networkInterface.SubnetId),
+			VPCID:            *networkInterface.VpcId,
+			InterfaceType:    awsClient.StringValue(networkInterface.InterfaceType),
+			PrivateIPAddress: awsClient.StringValue(networkInterface.PrivateIpAddress),
+			PublicIP:         publicIP,
+			PriceDetectedFields: collector.PriceDetectedFields{
+				ResourceID:        *networkInterface.NetworkInterfaceId,
+				ARN:               ni.awsManager.GetARN("ec2", fmt.Sprintf("network-interface/%s", *networkInterface.NetworkInterfaceId)),
+				ConsoleURL:        ni.awsManager.GetConsoleURL("ec2/home", fmt.Sprintf("NetworkInterface:networkInterfaceId=%s", *networkInterface.NetworkInterfaceId)),
+				PricePerHour:      pricePerHour,
+				PricePerMonth:     pricePerHour * collector.TotalMonthHours,
+				Tag:               tagsData,
+				AccountDimensions: ni.awsManager.GetAccountDimensions(),
+			},
+		}
+
+		ni.awsManager.GetCollector().AddResource(collector.EventCollector{
+			ResourceName: ni.Name,
+			Data:         detectedNetworkInterface,
+		})
+
+		detectedNetworkInterfaces = append(detectedNetworkInterfaces, detectedNetworkInterface)
+	}
+
+	ni.awsManager.GetCollector().CollectFinish(ni.Name)
+
+	return detectedNetworkInterfaces, nil
+}
+
+// describeNetworkInterfaces returns a list of the detached network interfaces
+func (ni *NetworkInterfaceManager) describeNetworkInterfaces(nextToken *string, networkInterfaces []*ec2.NetworkInterface) ([]*ec2.NetworkInterface, error) {
+	input := &ec2.DescribeNetworkInterfacesInput{
+		NextToken: nextToken,
+		Filters: []*ec2.Filter{
+			{
+				Name:   awsClient.String("status"),
+				Values: []*string{awsClient.String(ec2.NetworkInterfaceStatusAvailable)},
+			},
+		},
+	}
+
+	resp, err := ni.client.DescribeNetworkInterfaces(input)
+	if err != nil {
+		log.WithField("error", err).Error("could not describe network interfaces")
+		return nil, err
+	}
+
+	if networkInterfaces == nil {
+		networkInterfaces = []*ec2.NetworkInterface{}
+	}
+
+	networkInterfaces = append(networkInterfaces, resp.NetworkInterfaces...)
+
+	if resp.NextToken != nil {
+		return ni.describeNetworkInterfaces(resp.NextToken, networkInterfaces)
+	}
+
+	return networkInterfaces, nil
+}
@@ -0,0 +1,119 @@
+package resources
+
+import (
+	"errors"
+	awsTestutils "finala/collector/aws/testutils"
+	"finala/collector/config"
+	collectorTestutils "finala/collector/testutils"
+	"reflect"
+	"testing"
+
+	awsClient "github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+)
+
+var defaultNetworkInterfacesMock = ec2.DescribeNetworkInterfacesOutput{
+	NetworkInterfaces: []*ec2.NetworkInterface{
+		{
+			NetworkInterfaceId: awsClient.String("eni-1"),
+			SubnetId:           awsClient.String("subnet-1"),
+			VpcId:              awsClient.String("vpc-1"),
+			PrivateIpAddress:   awsClient.String("10.0.0.1"),
+		},
+		{
+			NetworkInterfaceId: awsClient.String("eni-2"),
+			SubnetId:           awsClient.String("subnet-1"),
+			VpcId:              awsClient.String("vpc-1"),
+			PrivateIpAddress:   awsClient.String("10.0.0.2"),
+			Association: &ec2.NetworkInterfaceAssociation{
+				PublicIp: awsClient.String("1.1.1.1"),
+			},
+		},
+	},
+}
+
+type MockAWSNetworkInterfaceClient struct {
+	err error
+}
+
+func (r *MockAWSNetworkInterfaceClient) DescribeNetworkInterfaces(*ec2.DescribeNetworkInterfacesInput) (*ec2.DescribeNetworkInterfacesOutput, error) {
+	return &defaultNetworkInterfacesMock, r.err
+}
+
+func TestNewNetworkInterfaceManager(t *testing.T) {
+
+	collector := collectorTestutils.NewMockCollector()
+	detector := awsTestutils.AWSManager(collector, nil, nil, "us-east-1")
+
+	networkInterfaceManager, err := NewNetworkInterfaceManager(detector, &MockEmptyClient{})
+	if err == nil {
+		t.Fatalf("unexpected error happened, got nil expected error")
+	}
+	if networkInterfaceManager != nil {
+		t.Fatalf("unexpected network interface manager instance, got %v expected nil", reflect.TypeOf(networkInterfaceManager))
+	}
+}
+
+func TestDetectNetworkInterfaces(t *testing.T) {
+
+	metrics := []config.MetricConfig{
+		{
+			Description: "Detached",
+			Enable:      true,
+		},
+	}
+
+	t.Run("detect network interfaces", func(t *testing.T) {
+		collector := collectorTestutils.NewMockCollector()
+		mockPrice := awsTestutils.NewMockPricing(nil)
+		detector := awsTestutils.AWSManager(collector, nil, mockPrice, "us-east-1")
+
+		networkInterfaceManager, err := NewNetworkInterfaceManager(detector, &MockAWSNetworkInterfaceClient{})
+		if err != nil {
+			t.Fatalf("unexpected network interface manager error happened, got %v expected %v", err, nil)
+		}
+
+		response, err := networkInterfaceManager.Detect(metrics)
+		if err != nil {
+			t.Fatalf("unexpected network interface detection error happened, got %v expected %v", err, nil)
+		}
+
+		networkInterfaceResponse, ok := response.([]DetectedNetworkInterface)
+		if !ok {
+			t.Fatalf("unexpected network interface struct, got %s expected %s", reflect.TypeOf(response), "[]DetectedNetworkInterface")
+		}
+
+		if len(networkInterfaceResponse) != 2 {
+			t.Fatalf("unexpected network interfaces detected, got %d expected %d", len(networkInterfaceResponse), 2)
+		}
+
+		if len(collector.Events) != 2 {
+			t.Fatalf("unexpected collector network interface events, got %d expected %d", len(collector.Events), 2)
+		}
+
+		// Only the network interface with a public ip is charged
+		if networkInterfaceResponse[0].PricePerHour != 0 {
+			t.Fatalf("unexpected private network interface price, got %f expected %f", networkInterfaceResponse[0].PricePerHour, 0.0)
+		}
+
+		if networkInterfaceResponse[1].PricePerHour != 1 {
+			t.Fatalf("unexpected public network interface price, got %f expected %f", networkInterfaceResponse[1].PricePerHour, 1.0)
+		}
+	})
+
+	t.Run("detection error", func(t *testing.T) {
+		collector := collectorTestutils.NewMockCollector()
+		mockPrice := awsTestutils.NewMockPricing(nil)
+		detector := awsTestutils.AWSManager(collector, nil, mockPrice, "us-east-1")
+
+		networkInterfaceManager, err := NewNetworkInterfaceManager(detector, &MockAWSNetworkInterfaceClient{err: errors.New("error")})
+		if err != nil {
+			t.Fatalf("unexpected network interface manager error happened, got %v expected %v", err, nil)
+		}
+
+		_, err = networkInterfaceManager.Detect(metrics)
+		if err == nil {
+			t.Fatalf("unexpected detection network interface manager error, got nil expected error message")
+		}
+	})
+}
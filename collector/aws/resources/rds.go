@@ -38,7 +38,6 @@ type RDSManager struct {
 // DetectedAWSRDS define the detected AWS RDS instances
 type DetectedAWSRDS struct {
 	Metric       string
-	Region       string
 	InstanceType string
 	MultiAZ      bool
 	Engine       string
@@ -134,6 +133,16 @@ func (r *RDSManager) Detect(metrics []config.MetricConfig) (interface{}, error)
 			"rds_AZ_multi":        *instance.MultiAZ,
 			"region":              r.awsManager.GetRegion()}).Debug("Found the following price list")
 
+		r.awsManager.ExamineResource(r.Name, func() collector.PriceDetectedFields {
+			return collector.PriceDetectedFields{
+				ResourceID:    *instance.DBInstanceArn,
+				LaunchTime:    *instance.InstanceCreateTime,
+				PricePerHour:  totalHourlyPrice,
+				PricePerMonth: totalHourlyPrice * collector.TotalMonthHours,
+				Tag:           r.getTags(instance.DBInstanceArn),
+			}
+		})
+
 		for _, metric := range metrics {
 			log.WithFields(log.Fields{
 				"name":        *instance.DBInstanceIdentifier,
@@ -183,29 +192,22 @@ func (r *RDSManager) Detect(metrics []config.MetricConfig) (interface{}, error)
 					"region":              r.awsManager.GetRegion(),
 				}).Info("RDS instance detected as unutilized resource")
 
-				tags, err := r.client.ListTagsForResource(&rds.ListTagsForResourceInput{
-					ResourceName: instance.DBInstanceArn,
-				})
-
-				tagsData := map[string]string{}
-				if err == nil {
-					for _, tag := range tags.TagList {
-						tagsData[*tag.Key] = *tag.Value
-					}
-				}
+				tagsData := r.getTags(instance.DBInstanceArn)
 
 				rds := DetectedAWSRDS{
-					Region:       r.awsManager.GetRegion(),
 					Metric:       metric.Description,
 					InstanceType: *instance.DBInstanceClass,
 					MultiAZ:      *instance.MultiAZ,
 					Engine:       *instance.Engine,
 					PriceDetectedFields: collector.PriceDetectedFields{
-						ResourceID:    *instance.DBInstanceArn,
-						LaunchTime:    *instance.InstanceCreateTime,
-						PricePerHour:  totalHourlyPrice,
-						PricePerMonth: totalHourlyPrice * collector.TotalMonthHours,
-						Tag:           tagsData,
+						ResourceID:        *instance.DBInstanceArn,
+						ARN:               *instance.DBInstanceArn,
+						ConsoleURL:        r.awsManager.GetConsoleURL("rds/home", fmt.Sprintf("database:id=%s;is-cluster=false", *instance.DBInstanceIdentifier)),
+						LaunchTime:        *instance.InstanceCreateTime,
+						PricePerHour:      totalHourlyPrice,
+						PricePerMonth:     totalHourlyPrice * collector.TotalMonthHours,
+						Tag:               tagsData,
+						AccountDimensions: r.awsManager.GetAccountDimensions(),
 					},
 				}
 
@@ -255,6 +257,24 @@ func (r *RDSManager) getHourlyStoragePrice(instance *rds.DBInstance, pricingRegi
 	return 0, ErrRDSStorageTypeNotFound
 }
 
+// getTags returns the rds instance tags
+func (r *RDSManager) getTags(resourceName *string) map[string]string {
+
+	tagsData := map[string]string{}
+	tags, err := r.client.ListTagsForResource(&rds.ListTagsForResourceInput{
+		ResourceName: resourceName,
+	})
+	if err != nil {
+		log.WithError(err).WithField("resource", *resourceName).Error("could not list rds instance tags")
+		return tagsData
+	}
+
+	for _, tag := range tags.TagList {
+		tagsData[*tag.Key] = *tag.Value
+	}
+	return tagsData
+}
+
 // getPricingFilterInput prepare document rds pricing filter
 func (r *RDSManager) getPricingInstanceFilterInput(instance *rds.DBInstance) pricing.GetProductsInput {
 
@@ -7,6 +7,7 @@ import (
 	"finala/collector/aws/register"
 	"finala/collector/config"
 	"finala/expression"
+	"fmt"
 	"time"
 
 	awsClient "github.com/aws/aws-sdk-go/aws"
@@ -33,7 +34,6 @@ type RedShiftManager struct {
 
 // DetectedRedShift define the detected AWS Elasticache clusters
 type DetectedRedShift struct {
-	Region        string
 	Metric        string
 	NodeType      string
 	NumberOfNodes int64
@@ -89,6 +89,22 @@ func (rdm *RedShiftManager) Detect(metrics []config.MetricConfig) (interface{},
 		log.WithField("cluster_id", *cluster.ClusterIdentifier).Debug("checking redshift")
 
 		price, _ := rdm.awsManager.GetPricingClient().GetPrice(rdm.getPricingFilterInput(cluster), "", rdm.awsManager.GetRegion())
+		clusterPrice := price * float64(*cluster.NumberOfNodes)
+
+		tagsData := map[string]string{}
+		for _, tag := range cluster.Tags {
+			tagsData[*tag.Key] = *tag.Value
+		}
+
+		rdm.awsManager.ExamineResource(rdm.Name, func() collector.PriceDetectedFields {
+			return collector.PriceDetectedFields{
+				LaunchTime:    *cluster.ClusterCreateTime,
+				ResourceID:    *cluster.ClusterIdentifier,
+				PricePerHour:  clusterPrice,
+				PricePerMonth: clusterPrice * collector.TotalMonthHours,
+				Tag:           tagsData,
+			}
+		})
 
 		for _, metric := range metrics {
 			log.WithFields(log.Fields{
@@ -128,7 +144,6 @@ func (rdm *RedShiftManager) Detect(metrics []config.MetricConfig) (interface{},
 			}
 
 			if expression {
-				clusterPrice := price * float64(*cluster.NumberOfNodes)
 
 				log.WithFields(log.Fields{
 					"metric_name":         metric.Description,
@@ -140,24 +155,19 @@ func (rdm *RedShiftManager) Detect(metrics []config.MetricConfig) (interface{},
 					"region":              rdm.awsManager.GetRegion(),
 				}).Info("Redshift cluster detected as unutilized resource")
 
-				tagsData := map[string]string{}
-				if err == nil {
-					for _, tag := range cluster.Tags {
-						tagsData[*tag.Key] = *tag.Value
-					}
-				}
-
 				redshift := DetectedRedShift{
-					Region:        rdm.awsManager.GetRegion(),
 					Metric:        metric.Description,
 					NodeType:      *cluster.NodeType,
 					NumberOfNodes: *cluster.NumberOfNodes,
 					PriceDetectedFields: collector.PriceDetectedFields{
-						LaunchTime:    *cluster.ClusterCreateTime,
-						ResourceID:    *cluster.ClusterIdentifier,
-						PricePerHour:  clusterPrice,
-						PricePerMonth: clusterPrice * collector.TotalMonthHours,
-						Tag:           tagsData,
+						LaunchTime:        *cluster.ClusterCreateTime,
+						ResourceID:        *cluster.ClusterIdentifier,
+						ARN:               rdm.awsManager.GetARN("redshift", fmt.Sprintf("cluster:%s", *cluster.ClusterIdentifier)),
+						ConsoleURL:        rdm.awsManager.GetConsoleURL("redshiftv2/home", fmt.Sprintf("cluster-details?cluster=%s", *cluster.ClusterIdentifier)),
+						PricePerHour:      clusterPrice,
+						PricePerMonth:     clusterPrice * collector.TotalMonthHours,
+						Tag:               tagsData,
+						AccountDimensions: rdm.awsManager.GetAccountDimensions(),
 					},
 				}
 
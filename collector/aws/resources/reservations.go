@@ -0,0 +1,943 @@
+package resources
+
+import (
+	"errors"
+	"finala/collector"
+	"finala/collector/aws/common"
+	"finala/collector/aws/register"
+	"finala/collector/config"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	awsClient "github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/costexplorer"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/elasticache"
+	"github.com/aws/aws-sdk-go/service/rds"
+	"github.com/aws/aws-sdk-go/service/savingsplans"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// unusedReservationCategory is the summary category of the reservations without matching running resources
+	unusedReservationCategory = "unused_reservation"
+
+	// reservationEC2Instance describes an ec2 reserved instance
+	reservationEC2Instance = "ec2_instance"
+
+	// reservationRDSInstance describes a rds reserved db instance
+	reservationRDSInstance = "rds_instance"
+
+	// reservationElastiCacheNode describes an elasticache reserved cache node
+	reservationElastiCacheNode = "elasticache_node"
+
+	// reservationSavingsPlan describes a savings plan
+	reservationSavingsPlan = "savings_plan"
+
+	// savingsPlansUtilizationDays is the number of the last days of the savings plans utilization
+	savingsPlansUtilizationDays = 7
+
+	// savingsPlansDateLayout is the date layout of the cost explorer time period
+	savingsPlansDateLayout = "2006-01-02"
+
+	// reservationActiveState is the state of the reservations which are currently billed
+	reservationActiveState = "active"
+
+	// reservationDBInstanceAvailableStatus is the status of the rds instances which use the reservations
+	reservationDBInstanceAvailableStatus = "available"
+
+	// reservationUnitsEpsilon is the precision of the normalized units of the size flexible reservations
+	reservationUnitsEpsilon = 1e-9
+)
+
+// sizeNormalizationFactors are the normalization factors of the instance sizes. A size flexible reservation matches the
+// instances of any size of its family by their normalized units, e.g. a large reservation matches two medium instances.
+// The sizes `<n>xlarge` are n times an xlarge
+var sizeNormalizationFactors = map[string]float64{
+	"nano":   0.25,
+	"micro":  0.5,
+	"small":  1,
+	"medium": 2,
+	"large":  4,
+	"xlarge": 8,
+}
+
+// ReservationsClientDescreptor is an interface defining the aws ec2, rds, elasticache, savings plans and cost explorer clients
+type ReservationsClientDescreptor interface {
+	DescribeReservedInstances(*ec2.DescribeReservedInstancesInput) (*ec2.DescribeReservedInstancesOutput, error)
+	DescribeInstances(*ec2.DescribeInstancesInput) (*ec2.DescribeInstancesOutput, error)
+	DescribeReservedDBInstances(*rds.DescribeReservedDBInstancesInput) (*rds.DescribeReservedDBInstancesOutput, error)
+	DescribeDBInstances(*rds.DescribeDBInstancesInput) (*rds.DescribeDBInstancesOutput, error)
+	DescribeReservedCacheNodes(*elasticache.DescribeReservedCacheNodesInput) (*elasticache.DescribeReservedCacheNodesOutput, error)
+	DescribeCacheClusters(*elasticache.DescribeCacheClustersInput) (*elasticache.DescribeCacheClustersOutput, error)
+	DescribeSavingsPlans(*savingsplans.DescribeSavingsPlansInput) (*savingsplans.DescribeSavingsPlansOutput, error)
+	GetSavingsPlansUtilizationDetails(*costexplorer.GetSavingsPlansUtilizationDetailsInput) (*costexplorer.GetSavingsPlansUtilizationDetailsOutput, error)
+}
+
+// reservationsClient combines the aws ec2, rds, elasticache, savings plans and cost explorer clients
+type reservationsClient struct {
+	*ec2.EC2
+	*rds.RDS
+	*elasticache.ElastiCache
+	*savingsplans.SavingsPlans
+	*costexplorer.CostExplorer
+}
+
+// ReservationsManager describes the reservations struct
+type ReservationsManager struct {
+	client     ReservationsClientDescreptor
+	awsManager common.AWSManager
+	Name       collector.ResourceIdentifier
+}
+
+// DetectedReservation defines an active reservation which is not fully used by the running resources.
+// The price fields are the wasted fee of the unused reserved capacity. UnusedCount is fractional when a size flexible
+// reservation is partially used by the instances of other sizes, and for a savings plan it is the unused commitment share
+type DetectedReservation struct {
+	Metric             string
+	Category           string
+	ResourceType       string
+	InstanceType       string
+	ProductDescription string
+	AvailabilityZone   string
+	MultiAZ            bool
+	SizeFlexible       bool
+	ReservedCount      int64
+	UnusedCount        float64
+	HourlyCommitment   float64
+	Utilization        float64
+	EndTime            time.Time
+	collector.PriceDetectedFields
+}
+
+func init() {
+	register.Registry("reservations", NewReservationsManager)
+}
+
+// NewReservationsManager implements AWS GO SDK
+func NewReservationsManager(awsManager common.AWSManager, client interface{}) (common.ResourceDetection, error) {
+
+	if client == nil {
+		client = &reservationsClient{
+			EC2:          ec2.New(awsManager.GetSession()),
+			RDS:          rds.New(awsManager.GetSession()),
+			ElastiCache:  elasticache.New(awsManager.GetSession()),
+			SavingsPlans: savingsplans.New(awsManager.GetSession()),
+			CostExplorer: costexplorer.New(awsManager.GetSession()),
+		}
+	}
+
+	rmClient, ok := client.(ReservationsClientDescreptor)
+	if !ok {
+		return nil, errors.New("invalid reservations client")
+	}
+
+	return &ReservationsManager{
+		client:     rmClient,
+		awsManager: awsManager,
+		Name:       awsManager.GetResourceIdentifier("reservations"),
+	}, nil
+}
+
+// Detect compares the active ec2, rds and elasticache reservations with the running resources of the region,
+// and reports the reservations which are not matched by enough running resources. The savings plans apply to the whole
+// account, they are reported once per account by their utilization
+func (rm *ReservationsManager) Detect(metrics []config.MetricConfig) (interface{}, error) {
+
+	log.WithFields(log.Fields{
+		"region":   rm.awsManager.GetRegion(),
+		"resource": "reservations",
+	}).Info("starting to analyze resource")
+
+	rm.awsManager.GetCollector().CollectStart(rm.Name)
+
+	detected := []DetectedReservation{}
+
+	// This resource support only one metric
+	var metricDescription string
+	if len(metrics) > 0 {
+		metricDescription = metrics[0].Description
+	}
+
+	ec2Reservations, err := rm.detectEC2Reservations()
+	if err != nil {
+		rm.awsManager.GetCollector().CollectError(rm.Name, err)
+		return detected, err
+	}
+
+	rdsReservations, err := rm.detectRDSReservations()
+	if err != nil {
+		rm.awsManager.GetCollector().CollectError(rm.Name, err)
+		return detected, err
+	}
+
+	elasticacheReservations, err := rm.detectElastiCacheReservations()
+	if err != nil {
+		rm.awsManager.GetCollector().CollectError(rm.Name, err)
+		return detected, err
+	}
+
+	savingsPlans := []DetectedReservation{}
+	savingsPlansName := collector.ResourceIdentifier(fmt.Sprintf("%s_%s", rm.awsManager.GetResourceIdentifier("savings_plans"), rm.awsManager.GetAccountDimensions().AccountID))
+	if !rm.awsManager.IsGlobalSet(savingsPlansName) {
+		rm.awsManager.SetGlobal(savingsPlansName)
+
+		savingsPlans, err = rm.detectSavingsPlans()
+		if err != nil {
+			rm.awsManager.GetCollector().CollectError(rm.Name, err)
+			return detected, err
+		}
+	}
+
+	reservations := append(append(ec2Reservations, rdsReservations...), elasticacheReservations...)
+	for _, reservation := range append(reservations, savingsPlans...) {
+		detected = append(detected, rm.addResource(reservation, metricDescription))
+	}
+
+	rm.awsManager.GetCollector().CollectFinish(rm.Name)
+
+	return detected, nil
+}
+
+// addResource completes the detected reservation fields and sends it to the collector
+func (rm *ReservationsManager) addResource(reservation DetectedReservation, metricDescription string) DetectedReservation {
+
+	reservation.AccountDimensions = rm.awsManager.GetAccountDimensions()
+	reservation.Metric = metricDescription
+	reservation.Category = unusedReservationCategory
+
+	log.WithFields(log.Fields{
+		"reservation_id": reservation.ResourceID,
+		"resource_type":  reservation.ResourceType,
+		"instance_type":  reservation.InstanceType,
+		"unused_count":   reservation.UnusedCount,
+		"region":         reservation.Region,
+	}).Info("Reservation detected as unused")
+
+	rm.awsManager.GetCollector().AddResource(collector.EventCollector{
+		ResourceName: rm.Name,
+		Data:         reservation,
+	})
+
+	return reservation
+}
+
+// detectEC2Reservations returns the ec2 reserved instances which are not matched by running instances.
+// Zonal reservations are matched first, regional reservations may match instances of any availability zone.
+// Regional Linux reservations of the default tenancy are size flexible, they match the instances of their size first,
+// and then the instances of the other sizes of their family
+func (rm *ReservationsManager) detectEC2Reservations() ([]DetectedReservation, error) {
+
+	detected := []DetectedReservation{}
+
+	resp, err := rm.client.DescribeReservedInstances(&ec2.DescribeReservedInstancesInput{
+		Filters: []*ec2.Filter{
+			{
+				Name:   awsClient.String("state"),
+				Values: []*string{awsClient.String(reservationActiveState)},
+			},
+		},
+	})
+	if err != nil {
+		log.WithField("error", err).Error("could not describe ec2 reserved instances")
+		return detected, err
+	}
+
+	instances, err := rm.describeInstances(nil, nil)
+	if err != nil {
+		return detected, err
+	}
+
+	// running instances count by instance type, platform and availability zone
+	running := map[string]float64{}
+	for _, instance := range instances {
+		var availabilityZone string
+		if instance.Placement != nil {
+			availabilityZone = awsClient.StringValue(instance.Placement.AvailabilityZone)
+		}
+		running[getEC2ReservationKey(*instance.InstanceType, getEC2InstancePlatform(instance), availabilityZone)]++
+	}
+
+	reservedInstances := resp.ReservedInstances
+	sort.SliceStable(reservedInstances, func(i, j int) bool {
+		return awsClient.StringValue(reservedInstances[i].Scope) == ec2.ScopeAvailabilityZone &&
+			awsClient.StringValue(reservedInstances[j].Scope) != ec2.ScopeAvailabilityZone
+	})
+
+	for _, reservedInstance := range reservedInstances {
+
+		platform := strings.TrimSuffix(awsClient.StringValue(reservedInstance.ProductDescription), " (Amazon VPC)")
+		var availabilityZone string
+		if awsClient.StringValue(reservedInstance.Scope) == ec2.ScopeAvailabilityZone {
+			availabilityZone = awsClient.StringValue(reservedInstance.AvailabilityZone)
+		}
+
+		// a regional reservation key prefix matches any availability zone
+		key := getEC2ReservationKey(*reservedInstance.InstanceType, platform, availabilityZone)
+		reservedCount := awsClient.Int64Value(reservedInstance.InstanceCount)
+		matchedCount := consumeReservation(running, float64(reservedCount), func(runningKey string) bool {
+			if availabilityZone == "" {
+				return strings.HasPrefix(runningKey, key)
+			}
+			return runningKey == key
+		}, func(string) float64 { return 1 })
+
+		family, factor := getSizeNormalizationFactor(*reservedInstance.InstanceType)
+		sizeFlexible := availabilityZone == "" && platform == "Linux/UNIX" && factor > 0 &&
+			awsClient.StringValue(reservedInstance.InstanceTenancy) != ec2.TenancyDedicated
+		if sizeFlexible {
+			matchedUnits := consumeReservation(running, (float64(reservedCount)-matchedCount)*factor, func(runningKey string) bool {
+				instanceType, runningPlatform, _ := parseEC2ReservationKey(runningKey)
+				runningFamily, _ := getSizeNormalizationFactor(instanceType)
+				return runningFamily == family && runningPlatform == platform
+			}, func(runningKey string) float64 {
+				instanceType, _, _ := parseEC2ReservationKey(runningKey)
+				_, runningFactor := getSizeNormalizationFactor(instanceType)
+				return runningFactor
+			})
+			matchedCount += matchedUnits / factor
+		}
+
+		unusedCount := float64(reservedCount) - matchedCount
+		if unusedCount*factorOrOne(factor) <= reservationUnitsEpsilon {
+			continue
+		}
+
+		recurringCharges := map[string]float64{}
+		for _, charge := range reservedInstance.RecurringCharges {
+			recurringCharges[awsClient.StringValue(charge.Frequency)] += awsClient.Float64Value(charge.Amount)
+		}
+
+		hourlyFee := getReservationHourlyFee(
+			awsClient.Float64Value(reservedInstance.FixedPrice),
+			awsClient.Float64Value(reservedInstance.UsagePrice),
+			awsClient.Int64Value(reservedInstance.Duration),
+			recurringCharges,
+		)
+
+		tagsData := map[string]string{}
+		for _, tag := range reservedInstance.Tags {
+			tagsData[*tag.Key] = *tag.Value
+		}
+
+		detected = append(detected, DetectedReservation{
+			ResourceType:       reservationEC2Instance,
+			InstanceType:       *reservedInstance.InstanceType,
+			ProductDescription: platform,
+			AvailabilityZone:   availabilityZone,
+			SizeFlexible:       sizeFlexible,
+			ReservedCount:      reservedCount,
+			UnusedCount:        unusedCount,
+			EndTime:            awsClient.TimeValue(reservedInstance.End),
+			PriceDetectedFields: collector.PriceDetectedFields{
+				ResourceID:    *reservedInstance.ReservedInstancesId,
+				ARN:           rm.awsManager.GetARN("ec2", fmt.Sprintf("reserved-instances/%s", *reservedInstance.ReservedInstancesId)),
+				ConsoleURL:    rm.awsManager.GetConsoleURL("ec2/home", fmt.Sprintf("ReservedInstances:reservedInstancesId=%s", *reservedInstance.ReservedInstancesId)),
+				LaunchTime:    awsClient.TimeValue(reservedInstance.Start),
+				PricePerHour:  hourlyFee * unusedCount,
+				PricePerMonth: hourlyFee * unusedCount * collector.TotalMonthHours,
+				Tag:           tagsData,
+			},
+		})
+	}
+
+	return detected, nil
+}
+
+// detectRDSReservations returns the rds reserved db instances which are not matched by available db instances.
+// The reservations of all the engines but sql server and license included oracle are size flexible, they match the db
+// instances of their class first, and then the db instances of the other sizes of their family
+func (rm *ReservationsManager) detectRDSReservations() ([]DetectedReservation, error) {
+
+	detected := []DetectedReservation{}
+
+	reservedDBInstances, err := rm.describeReservedDBInstances(nil, nil)
+	if err != nil {
+		return detected, err
+	}
+
+	dbInstances, err := rm.describeDBInstances(nil, nil)
+	if err != nil {
+		return detected, err
+	}
+
+	// available db instances count by class, multi az and engine. The stopped db instances do not use the reservations
+	running := map[string]float64{}
+	for _, dbInstance := range dbInstances {
+		if awsClient.StringValue(dbInstance.DBInstanceStatus) != reservationDBInstanceAvailableStatus {
+			continue
+		}
+		running[getRDSReservationKey(*dbInstance.DBInstanceClass, awsClient.BoolValue(dbInstance.MultiAZ), *dbInstance.Engine)]++
+	}
+
+	for _, reservedDBInstance := range reservedDBInstances {
+
+		productDescription := awsClient.StringValue(reservedDBInstance.ProductDescription)
+		key := getRDSReservationKey(*reservedDBInstance.DBInstanceClass, awsClient.BoolValue(reservedDBInstance.MultiAZ), productDescription)
+		reservedCount := awsClient.Int64Value(reservedDBInstance.DBInstanceCount)
+		matchedCount := consumeReservation(running, float64(reservedCount), func(runningKey string) bool {
+			return runningKey == key
+		}, func(string) float64 { return 1 })
+
+		family, factor := getSizeNormalizationFactor(*reservedDBInstance.DBInstanceClass)
+		sizeFlexible := factor > 0 && isRDSReservationSizeFlexible(productDescription)
+		if sizeFlexible {
+			_, multiAZ, engine := parseRDSReservationKey(key)
+			matchedUnits := consumeReservation(running, (float64(reservedCount)-matchedCount)*factor, func(runningKey string) bool {
+				runningClass, runningMultiAZ, runningEngine := parseRDSReservationKey(runningKey)
+				runningFamily, _ := getSizeNormalizationFactor(runningClass)
+				return runningFamily == family && runningMultiAZ == multiAZ && runningEngine == engine
+			}, func(runningKey string) float64 {
+				runningClass, _, _ := parseRDSReservationKey(runningKey)
+				_, runningFactor := getSizeNormalizationFactor(runningClass)
+				return runningFactor
+			})
+			matchedCount += matchedUnits / factor
+		}
+
+		unusedCount := float64(reservedCount) - matchedCount
+		if unusedCount*factorOrOne(factor) <= reservationUnitsEpsilon {
+			continue
+		}
+
+		recurringCharges := map[string]float64{}
+		for _, charge := range reservedDBInstance.RecurringCharges {
+			recurringCharges[awsClient.StringValue(charge.RecurringChargeFrequency)] += awsClient.Float64Value(charge.RecurringChargeAmount)
+		}
+
+		hourlyFee := getReservationHourlyFee(
+			awsClient.Float64Value(reservedDBInstance.FixedPrice),
+			awsClient.Float64Value(reservedDBInstance.UsagePrice),
+			awsClient.Int64Value(reservedDBInstance.Duration),
+			recurringCharges,
+		)
+
+		startTime := awsClient.TimeValue(reservedDBInstance.StartTime)
+
+		detected = append(detected, DetectedReservation{
+			ResourceType:       reservationRDSInstance,
+			InstanceType:       *reservedDBInstance.DBInstanceClass,
+			ProductDescription: awsClient.StringValue(reservedDBInstance.ProductDescription),
+			MultiAZ:            awsClient.BoolValue(reservedDBInstance.MultiAZ),
+			SizeFlexible:       sizeFlexible,
+			ReservedCount:      reservedCount,
+			UnusedCount:        unusedCount,
+			EndTime:            startTime.Add(time.Duration(awsClient.Int64Value(reservedDBInstance.Duration)) * time.Second),
+			PriceDetectedFields: collector.PriceDetectedFields{
+				ResourceID:    *reservedDBInstance.ReservedDBInstanceId,
+				ARN:           awsClient.StringValue(reservedDBInstance.ReservedDBInstanceArn),
+				ConsoleURL:    rm.awsManager.GetConsoleURL("rds/home", "reserved-instances:"),
+				LaunchTime:    startTime,
+				PricePerHour:  hourlyFee * unusedCount,
+				PricePerMonth: hourlyFee * unusedCount * collector.TotalMonthHours,
+				Tag:           map[string]string{},
+			},
+		})
+	}
+
+	return detected, nil
+}
+
+// detectElastiCacheReservations returns the elasticache reserved cache nodes which are not matched by cache nodes
+func (rm *ReservationsManager) detectElastiCacheReservations() ([]DetectedReservation, error) {
+
+	detected := []DetectedReservation{}
+
+	reservedCacheNodes, err := rm.describeReservedCacheNodes(nil, nil)
+	if err != nil {
+		return detected, err
+	}
+
+	cacheClusters, err := rm.describeCacheClusters(nil, nil)
+	if err != nil {
+		return detected, err
+	}
+
+	// cache nodes count by node type and engine
+	running := map[string]float64{}
+	for _, cacheCluster := range cacheClusters {
+		running[getElastiCacheReservationKey(*cacheCluster.CacheNodeType, *cacheCluster.Engine)] += float64(awsClient.Int64Value(cacheCluster.NumCacheNodes))
+	}
+
+	for _, reservedCacheNode := range reservedCacheNodes {
+
+		key := getElastiCacheReservationKey(*reservedCacheNode.CacheNodeType, awsClient.StringValue(reservedCacheNode.ProductDescription))
+		reservedCount := awsClient.Int64Value(reservedCacheNode.CacheNodeCount)
+		unusedCount := float64(reservedCount) - consumeReservation(running, float64(reservedCount), func(runningKey string) bool {
+			return runningKey == key
+		}, func(string) float64 { return 1 })
+		if unusedCount <= reservationUnitsEpsilon {
+			continue
+		}
+
+		recurringCharges := map[string]float64{}
+		for _, charge := range reservedCacheNode.RecurringCharges {
+			recurringCharges[awsClient.StringValue(charge.RecurringChargeFrequency)] += awsClient.Float64Value(charge.RecurringChargeAmount)
+		}
+
+		hourlyFee := getReservationHourlyFee(
+			awsClient.Float64Value(reservedCacheNode.FixedPrice),
+			awsClient.Float64Value(reservedCacheNode.UsagePrice),
+			awsClient.Int64Value(reservedCacheNode.Duration),
+			recurringCharges,
+		)
+
+		startTime := awsClient.TimeValue(reservedCacheNode.StartTime)
+
+		detected = append(detected, DetectedReservation{
+			ResourceType:       reservationElastiCacheNode,
+			InstanceType:       *reservedCacheNode.CacheNodeType,
+			ProductDescription: awsClient.StringValue(reservedCacheNode.ProductDescription),
+			ReservedCount:      reservedCount,
+			UnusedCount:        unusedCount,
+			EndTime:            startTime.Add(time.Duration(awsClient.Int64Value(reservedCacheNode.Duration)) * time.Second),
+			PriceDetectedFields: collector.PriceDetectedFields{
+				ResourceID:    *reservedCacheNode.ReservedCacheNodeId,
+				ARN:           awsClient.StringValue(reservedCacheNode.ReservationARN),
+				ConsoleURL:    rm.awsManager.GetConsoleURL("elasticache/home", "/reserved-nodes"),
+				LaunchTime:    startTime,
+				PricePerHour:  hourlyFee * unusedCount,
+				PricePerMonth: hourlyFee * unusedCount * collector.TotalMonthHours,
+				Tag:           map[string]string{},
+			},
+		})
+	}
+
+	return detected, nil
+}
+
+// detectSavingsPlans returns the active savings plans which did not use their whole commitment in the last days.
+// The wasted fee is the hourly commitment share which was not used
+func (rm *ReservationsManager) detectSavingsPlans() ([]DetectedReservation, error) {
+
+	detected := []DetectedReservation{}
+
+	savingsPlans, err := rm.describeSavingsPlans(nil, nil)
+	if err != nil {
+		return detected, err
+	}
+
+	// The cost explorer requests are billed, the utilization is requested only for accounts with savings plans
+	if len(savingsPlans) == 0 {
+		return detected, nil
+	}
+
+	now := time.Now().UTC()
+	utilizations, err := rm.getSavingsPlansUtilizationDetails(&costexplorer.DateInterval{
+		Start: awsClient.String(now.AddDate(0, 0, -savingsPlansUtilizationDays).Format(savingsPlansDateLayout)),
+		End:   awsClient.String(now.Format(savingsPlansDateLayout)),
+	}, nil, nil)
+	if err != nil {
+		return detected, err
+	}
+
+	for _, savingsPlan := range savingsPlans {
+
+		utilization, found := utilizations[awsClient.StringValue(savingsPlan.SavingsPlanArn)]
+		if !found {
+			continue
+		}
+
+		totalCommitment := parseReservationAmount(utilization.TotalCommitment)
+		unusedCommitment := parseReservationAmount(utilization.UnusedCommitment)
+		if totalCommitment <= 0 || unusedCommitment <= 0 {
+			continue
+		}
+
+		unusedShare := unusedCommitment / totalCommitment
+		hourlyCommitment := parseReservationAmount(savingsPlan.Commitment)
+
+		tagsData := map[string]string{}
+		for key, value := range savingsPlan.Tags {
+			tagsData[key] = awsClient.StringValue(value)
+		}
+
+		startTime, _ := time.Parse(time.RFC3339, awsClient.StringValue(savingsPlan.Start))
+		endTime, _ := time.Parse(time.RFC3339, awsClient.StringValue(savingsPlan.End))
+
+		detected = append(detected, DetectedReservation{
+			ResourceType:       reservationSavingsPlan,
+			InstanceType:       awsClient.StringValue(savingsPlan.Ec2InstanceFamily),
+			ProductDescription: awsClient.StringValue(savingsPlan.SavingsPlanType),
+			ReservedCount:      1,
+			UnusedCount:        unusedShare,
+			HourlyCommitment:   hourlyCommitment,
+			Utilization:        parseReservationAmount(utilization.UtilizationPercentage),
+			EndTime:            endTime,
+			PriceDetectedFields: collector.PriceDetectedFields{
+				ResourceID:    awsClient.StringValue(savingsPlan.SavingsPlanId),
+				ARN:           awsClient.StringValue(savingsPlan.SavingsPlanArn),
+				ConsoleURL:    rm.awsManager.GetConsoleURL("cost-management/home", "/savings-plans/inventory"),
+				LaunchTime:    startTime,
+				PricePerHour:  hourlyCommitment * unusedShare,
+				PricePerMonth: hourlyCommitment * unusedShare * collector.TotalMonthHours,
+				Tag:           tagsData,
+			},
+		})
+	}
+
+	return detected, nil
+}
+
+// parseReservationAmount returns the number of the given amount string, or 0 when the amount is not a number
+func parseReservationAmount(amount *string) float64 {
+	value, err := strconv.ParseFloat(awsClient.StringValue(amount), 64)
+	if err != nil {
+		return 0
+	}
+	return value
+}
+
+// consumeReservation subtracts up to the given units of the running resources of the matching keys, and returns the
+// matched units. unitsOf returns the units of a single running resource of the key, the resources without units are
+// not matched. The running resources may be partially consumed by the size flexible reservations
+func consumeReservation(running map[string]float64, units float64, match func(key string) bool, unitsOf func(key string) float64) float64 {
+
+	keys := []string{}
+	for key, count := range running {
+		if count > 0 && match(key) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	var matched float64
+	for _, key := range keys {
+		if units-matched <= reservationUnitsEpsilon {
+			break
+		}
+
+		keyUnits := unitsOf(key)
+		if keyUnits <= 0 {
+			continue
+		}
+
+		consumed := math.Min(running[key]*keyUnits, units-matched)
+		running[key] -= consumed / keyUnits
+		matched += consumed
+	}
+
+	return matched
+}
+
+// getSizeNormalizationFactor returns the family and the size normalization factor of the given instance type or db
+// instance class, e.g. m5 and 16 for m5.2xlarge. The factor is 0 when the size has no normalization factor, such as
+// the metal sizes
+func getSizeNormalizationFactor(instanceType string) (string, float64) {
+
+	separator := strings.LastIndex(instanceType, ".")
+	if separator == -1 {
+		return instanceType, 0
+	}
+	family, size := instanceType[:separator], instanceType[separator+1:]
+
+	if factor, found := sizeNormalizationFactors[size]; found {
+		return family, factor
+	}
+
+	if multiplier, found := strings.CutSuffix(size, "xlarge"); found {
+		count, err := strconv.Atoi(multiplier)
+		if err == nil && count > 0 {
+			return family, float64(count) * sizeNormalizationFactors["xlarge"]
+		}
+	}
+
+	return family, 0
+}
+
+// factorOrOne returns the given normalization factor, or 1 for the reservations without a normalization factor
+func factorOrOne(factor float64) float64 {
+	if factor > 0 {
+		return factor
+	}
+	return 1
+}
+
+// isRDSReservationSizeFlexible returns true when the reserved db instances of the given product description are size
+// flexible. The sql server and the license included oracle reservations match only their db instance class
+func isRDSReservationSizeFlexible(productDescription string) bool {
+	productDescription = strings.ToLower(productDescription)
+	if strings.HasPrefix(productDescription, "sqlserver") {
+		return false
+	}
+	return !(strings.HasPrefix(productDescription, "oracle") && strings.HasSuffix(productDescription, "(li)"))
+}
+
+// getReservationHourlyFee returns the hourly fee of a single reserved resource,
+// including the upfront price amortized over the reservation duration
+func getReservationHourlyFee(fixedPrice, usagePrice float64, durationSeconds int64, recurringCharges map[string]float64) float64 {
+
+	hourlyFee := usagePrice + recurringCharges["Hourly"]
+
+	durationHours := time.Duration(durationSeconds * int64(time.Second)).Hours()
+	if durationHours > 0 {
+		hourlyFee += fixedPrice / durationHours
+	}
+
+	return hourlyFee
+}
+
+// getEC2InstancePlatform returns the instance platform as described by the reserved instances product description
+func getEC2InstancePlatform(instance *ec2.Instance) string {
+
+	if instance.PlatformDetails != nil {
+		return *instance.PlatformDetails
+	}
+
+	if strings.EqualFold(awsClient.StringValue(instance.Platform), ec2.PlatformValuesWindows) {
+		return "Windows"
+	}
+
+	return "Linux/UNIX"
+}
+
+// getEC2ReservationKey returns the matching key of ec2 instances and reserved instances
+func getEC2ReservationKey(instanceType, platform, availabilityZone string) string {
+	return fmt.Sprintf("%s|%s|%s", instanceType, platform, availabilityZone)
+}
+
+// parseEC2ReservationKey returns the instance type, platform and availability zone of the given matching key
+func parseEC2ReservationKey(key string) (string, string, string) {
+	parts := strings.SplitN(key, "|", 3)
+	if len(parts) != 3 {
+		return key, "", ""
+	}
+	return parts[0], parts[1], parts[2]
+}
+
+// getRDSReservationKey returns the matching key of rds instances and reserved db instances.
+// Reserved db instances describe the engine with its license model, e.g. oracle-se2(li), and postgres as postgresql
+func getRDSReservationKey(instanceClass string, multiAZ bool, engine string) string {
+
+	engine = strings.ToLower(engine)
+	if separator := strings.Index(engine, "("); separator != -1 {
+		engine = engine[:separator]
+	}
+	if engine == "postgresql" {
+		engine = "postgres"
+	}
+
+	return fmt.Sprintf("%s|%t|%s", instanceClass, multiAZ, engine)
+}
+
+// parseRDSReservationKey returns the db instance class, multi az and engine of the given matching key
+func parseRDSReservationKey(key string) (string, bool, string) {
+	parts := strings.SplitN(key, "|", 3)
+	if len(parts) != 3 {
+		return key, false, ""
+	}
+	return parts[0], parts[1] == "true", parts[2]
+}
+
+// getElastiCacheReservationKey returns the matching key of cache nodes and reserved cache nodes
+func getElastiCacheReservationKey(nodeType, engine string) string {
+	return fmt.Sprintf("%s|%s", nodeType, strings.ToLower(engine))
+}
+
+// describeInstances returns a list of the running ec2 instances
+func (rm *ReservationsManager) describeInstances(nextToken *string, instances []*ec2.Instance) ([]*ec2.Instance, error) {
+
+	input := &ec2.DescribeInstancesInput{
+		NextToken: nextToken,
+		Filters: []*ec2.Filter{
+			{
+				Name:   awsClient.String("instance-state-name"),
+				Values: []*string{awsClient.String("running")},
+			},
+		},
+	}
+
+	resp, err := rm.client.DescribeInstances(input)
+	if err != nil {
+		log.WithField("error", err).Error("could not describe ec2 instances")
+		return nil, err
+	}
+
+	if instances == nil {
+		instances = []*ec2.Instance{}
+	}
+
+	for _, reservations := range resp.Reservations {
+		instances = append(instances, reservations.Instances...)
+	}
+
+	if resp.NextToken != nil {
+		return rm.describeInstances(resp.NextToken, instances)
+	}
+
+	return instances, nil
+}
+
+// describeReservedDBInstances returns a list of the active rds reserved db instances
+func (rm *ReservationsManager) describeReservedDBInstances(marker *string, reservedDBInstances []*rds.ReservedDBInstance) ([]*rds.ReservedDBInstance, error) {
+
+	input := &rds.DescribeReservedDBInstancesInput{
+		Marker: marker,
+	}
+
+	resp, err := rm.client.DescribeReservedDBInstances(input)
+	if err != nil {
+		log.WithField("error", err).Error("could not describe rds reserved db instances")
+		return nil, err
+	}
+
+	if reservedDBInstances == nil {
+		reservedDBInstances = []*rds.ReservedDBInstance{}
+	}
+
+	for _, reservedDBInstance := range resp.ReservedDBInstances {
+		if awsClient.StringValue(reservedDBInstance.State) == reservationActiveState {
+			reservedDBInstances = append(reservedDBInstances, reservedDBInstance)
+		}
+	}
+
+	if resp.Marker != nil {
+		return rm.describeReservedDBInstances(resp.Marker, reservedDBInstances)
+	}
+
+	return reservedDBInstances, nil
+}
+
+// describeDBInstances returns a list of the rds instances
+func (rm *ReservationsManager) describeDBInstances(marker *string, dbInstances []*rds.DBInstance) ([]*rds.DBInstance, error) {
+
+	input := &rds.DescribeDBInstancesInput{
+		Marker: marker,
+	}
+
+	resp, err := rm.client.DescribeDBInstances(input)
+	if err != nil {
+		log.WithField("error", err).Error("could not describe rds instances")
+		return nil, err
+	}
+
+	if dbInstances == nil {
+		dbInstances = []*rds.DBInstance{}
+	}
+
+	dbInstances = append(dbInstances, resp.DBInstances...)
+
+	if resp.Marker != nil {
+		return rm.describeDBInstances(resp.Marker, dbInstances)
+	}
+
+	return dbInstances, nil
+}
+
+// describeReservedCacheNodes returns a list of the active elasticache reserved cache nodes
+func (rm *ReservationsManager) describeReservedCacheNodes(marker *string, reservedCacheNodes []*elasticache.ReservedCacheNode) ([]*elasticache.ReservedCacheNode, error) {
+
+	input := &elasticache.DescribeReservedCacheNodesInput{
+		Marker: marker,
+	}
+
+	resp, err := rm.client.DescribeReservedCacheNodes(input)
+	if err != nil {
+		log.WithField("error", err).Error("could not describe elasticache reserved cache nodes")
+		return nil, err
+	}
+
+	if reservedCacheNodes == nil {
+		reservedCacheNodes = []*elasticache.ReservedCacheNode{}
+	}
+
+	for _, reservedCacheNode := range resp.ReservedCacheNodes {
+		if awsClient.StringValue(reservedCacheNode.State) == reservationActiveState {
+			reservedCacheNodes = append(reservedCacheNodes, reservedCacheNode)
+		}
+	}
+
+	if resp.Marker != nil {
+		return rm.describeReservedCacheNodes(resp.Marker, reservedCacheNodes)
+	}
+
+	return reservedCacheNodes, nil
+}
+
+// describeCacheClusters returns a list of the elasticache clusters
+func (rm *ReservationsManager) describeCacheClusters(marker *string, cacheClusters []*elasticache.CacheCluster) ([]*elasticache.CacheCluster, error) {
+
+	input := &elasticache.DescribeCacheClustersInput{
+		Marker: marker,
+	}
+
+	resp, err := rm.client.DescribeCacheClusters(input)
+	if err != nil {
+		log.WithField("error", err).Error("could not describe elasticache clusters")
+		return nil, err
+	}
+
+	if cacheClusters == nil {
+		cacheClusters = []*elasticache.CacheCluster{}
+	}
+
+	cacheClusters = append(cacheClusters, resp.CacheClusters...)
+
+	if resp.Marker != nil {
+		return rm.describeCacheClusters(resp.Marker, cacheClusters)
+	}
+
+	return cacheClusters, nil
+}
+
+// describeSavingsPlans returns a list of the active savings plans
+func (rm *ReservationsManager) describeSavingsPlans(nextToken *string, savingsPlans []*savingsplans.SavingsPlan) ([]*savingsplans.SavingsPlan, error) {
+
+	input := &savingsplans.DescribeSavingsPlansInput{
+		NextToken: nextToken,
+		States:    []*string{awsClient.String(savingsplans.SavingsPlanStateActive)},
+	}
+
+	resp, err := rm.client.DescribeSavingsPlans(input)
+	if err != nil {
+		log.WithField("error", err).Error("could not describe savings plans")
+		return nil, err
+	}
+
+	if savingsPlans == nil {
+		savingsPlans = []*savingsplans.SavingsPlan{}
+	}
+
+	savingsPlans = append(savingsPlans, resp.SavingsPlans...)
+
+	if awsClient.StringValue(resp.NextToken) != "" {
+		return rm.describeSavingsPlans(resp.NextToken, savingsPlans)
+	}
+
+	return savingsPlans, nil
+}
+
+// getSavingsPlansUtilizationDetails returns the utilization of the savings plans in the given time period by their arn
+func (rm *ReservationsManager) getSavingsPlansUtilizationDetails(timePeriod *costexplorer.DateInterval, nextToken *string, utilizations map[string]*costexplorer.SavingsPlansUtilization) (map[string]*costexplorer.SavingsPlansUtilization, error) {
+
+	input := &costexplorer.GetSavingsPlansUtilizationDetailsInput{
+		NextToken:  nextToken,
+		TimePeriod: timePeriod,
+	}
+
+	resp, err := rm.client.GetSavingsPlansUtilizationDetails(input)
+	if err != nil {
+		log.WithField("error", err).Error("could not get savings plans utilization details")
+		return nil, err
+	}
+
+	if utilizations == nil {
+		utilizations = map[string]*costexplorer.SavingsPlansUtilization{}
+	}
+
+	for _, detail := range resp.SavingsPlansUtilizationDetails {
+		if detail.Utilization != nil {
+			utilizations[awsClient.StringValue(detail.SavingsPlanArn)] = detail.Utilization
+		}
+	}
+
+	if awsClient.StringValue(resp.NextToken) != "" {
+		return rm.getSavingsPlansUtilizationDetails(timePeriod, resp.NextToken, utilizations)
+	}
+
+	return utilizations, nil
+}
@@ -0,0 +1,527 @@
+package resources
+
+import (
+	"errors"
+	awsTestutils "finala/collector/aws/testutils"
+	"finala/collector/config"
+	"finala/collector/testutils"
+	collectorTestutils "finala/collector/testutils"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	awsClient "github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/costexplorer"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/elasticache"
+	"github.com/aws/aws-sdk-go/service/rds"
+	"github.com/aws/aws-sdk-go/service/savingsplans"
+)
+
+var defaultReservedInstancesMock = ec2.DescribeReservedInstancesOutput{
+	ReservedInstances: []*ec2.ReservedInstances{
+		{
+			ReservedInstancesId: awsClient.String("ri-regional"),
+			InstanceType:        awsClient.String("m5.large"),
+			InstanceCount:       awsClient.Int64(3),
+			ProductDescription:  awsClient.String("Linux/UNIX (Amazon VPC)"),
+			Scope:               awsClient.String("Region"),
+			Duration:            awsClient.Int64(31536000),
+			FixedPrice:          awsClient.Float64(876),
+			UsagePrice:          awsClient.Float64(0),
+			RecurringCharges: []*ec2.RecurringCharge{
+				{Amount: awsClient.Float64(0.02), Frequency: awsClient.String("Hourly")},
+			},
+			Start: testutils.TimePointer(time.Now()),
+		},
+		{
+			ReservedInstancesId: awsClient.String("ri-zonal"),
+			InstanceType:        awsClient.String("m5.large"),
+			InstanceCount:       awsClient.Int64(1),
+			ProductDescription:  awsClient.String("Linux/UNIX"),
+			Scope:               awsClient.String("Availability Zone"),
+			AvailabilityZone:    awsClient.String("us-east-1a"),
+			Duration:            awsClient.Int64(31536000),
+			UsagePrice:          awsClient.Float64(0.06),
+			Start:               testutils.TimePointer(time.Now()),
+		},
+		{
+			ReservedInstancesId: awsClient.String("ri-used"),
+			InstanceType:        awsClient.String("c5.large"),
+			InstanceCount:       awsClient.Int64(1),
+			ProductDescription:  awsClient.String("Windows"),
+			Scope:               awsClient.String("Region"),
+			Duration:            awsClient.Int64(31536000),
+			UsagePrice:          awsClient.Float64(0.1),
+			Start:               testutils.TimePointer(time.Now()),
+		},
+	},
+}
+
+var defaultReservationsInstancesMock = ec2.DescribeInstancesOutput{
+	Reservations: []*ec2.Reservation{
+		{
+			Instances: []*ec2.Instance{
+				{
+					InstanceId:      awsClient.String("i-1"),
+					InstanceType:    awsClient.String("m5.large"),
+					PlatformDetails: awsClient.String("Linux/UNIX"),
+					Placement:       &ec2.Placement{AvailabilityZone: awsClient.String("us-east-1a")},
+				},
+				{
+					InstanceId:      awsClient.String("i-2"),
+					InstanceType:    awsClient.String("m5.large"),
+					PlatformDetails: awsClient.String("Linux/UNIX"),
+					Placement:       &ec2.Placement{AvailabilityZone: awsClient.String("us-east-1b")},
+				},
+				{
+					InstanceId:   awsClient.String("i-3"),
+					InstanceType: awsClient.String("c5.large"),
+					Platform:     awsClient.String("windows"),
+					Placement:    &ec2.Placement{AvailabilityZone: awsClient.String("us-east-1b")},
+				},
+				{
+					InstanceId:      awsClient.String("i-4"),
+					InstanceType:    awsClient.String("m5.medium"),
+					PlatformDetails: awsClient.String("Linux/UNIX"),
+					Placement:       &ec2.Placement{AvailabilityZone: awsClient.String("us-east-1c")},
+				},
+				{
+					InstanceId:   awsClient.String("i-5"),
+					InstanceType: awsClient.String("c5.medium"),
+					Platform:     awsClient.String("windows"),
+					Placement:    &ec2.Placement{AvailabilityZone: awsClient.String("us-east-1c")},
+				},
+			},
+		},
+	},
+}
+
+var defaultReservedDBInstancesMock = rds.DescribeReservedDBInstancesOutput{
+	ReservedDBInstances: []*rds.ReservedDBInstance{
+		{
+			ReservedDBInstanceId: awsClient.String("rds-unused"),
+			DBInstanceClass:      awsClient.String("db.r5.large"),
+			DBInstanceCount:      awsClient.Int64(2),
+			MultiAZ:              awsClient.Bool(false),
+			ProductDescription:   awsClient.String("postgresql"),
+			Duration:             awsClient.Int64(31536000),
+			UsagePrice:           awsClient.Float64(0),
+			RecurringCharges: []*rds.RecurringCharge{
+				{RecurringChargeAmount: awsClient.Float64(0.15), RecurringChargeFrequency: awsClient.String("Hourly")},
+			},
+			State:     awsClient.String("active"),
+			StartTime: testutils.TimePointer(time.Now()),
+		},
+		{
+			ReservedDBInstanceId: awsClient.String("rds-retired"),
+			DBInstanceClass:      awsClient.String("db.r5.large"),
+			DBInstanceCount:      awsClient.Int64(1),
+			ProductDescription:   awsClient.String("mysql"),
+			State:                awsClient.String("retired"),
+		},
+	},
+}
+
+var defaultReservationsDBInstancesMock = rds.DescribeDBInstancesOutput{
+	DBInstances: []*rds.DBInstance{
+		{
+			DBInstanceIdentifier: awsClient.String("db-1"),
+			DBInstanceClass:      awsClient.String("db.r5.large"),
+			MultiAZ:              awsClient.Bool(false),
+			Engine:               awsClient.String("postgres"),
+			DBInstanceStatus:     awsClient.String("available"),
+		},
+		{
+			DBInstanceIdentifier: awsClient.String("db-2"),
+			DBInstanceClass:      awsClient.String("db.r5.medium"),
+			MultiAZ:              awsClient.Bool(false),
+			Engine:               awsClient.String("postgres"),
+			DBInstanceStatus:     awsClient.String("available"),
+		},
+		{
+			DBInstanceIdentifier: awsClient.String("db-3"),
+			DBInstanceClass:      awsClient.String("db.r5.large"),
+			MultiAZ:              awsClient.Bool(false),
+			Engine:               awsClient.String("postgres"),
+			DBInstanceStatus:     awsClient.String("stopped"),
+		},
+	},
+}
+
+var defaultReservedCacheNodesMock = elasticache.DescribeReservedCacheNodesOutput{
+	ReservedCacheNodes: []*elasticache.ReservedCacheNode{
+		{
+			ReservedCacheNodeId: awsClient.String("cache-used"),
+			CacheNodeType:       awsClient.String("cache.m5.large"),
+			CacheNodeCount:      awsClient.Int64(2),
+			ProductDescription:  awsClient.String("redis"),
+			Duration:            awsClient.Int64(31536000),
+			UsagePrice:          awsClient.Float64(0.1),
+			State:               awsClient.String("active"),
+			StartTime:           testutils.TimePointer(time.Now()),
+		},
+	},
+}
+
+var defaultReservationsCacheClustersMock = elasticache.DescribeCacheClustersOutput{
+	CacheClusters: []*elasticache.CacheCluster{
+		{
+			CacheClusterId: awsClient.String("cluster-1"),
+			CacheNodeType:  awsClient.String("cache.m5.large"),
+			Engine:         awsClient.String("redis"),
+			NumCacheNodes:  awsClient.Int64(2),
+		},
+	},
+}
+
+var defaultSavingsPlansMock = savingsplans.DescribeSavingsPlansOutput{
+	SavingsPlans: []*savingsplans.SavingsPlan{
+		{
+			SavingsPlanId:   awsClient.String("sp-underused"),
+			SavingsPlanArn:  awsClient.String("arn:aws:savingsplans::000000000000:savingsplan/sp-underused"),
+			SavingsPlanType: awsClient.String("Compute"),
+			Commitment:      awsClient.String("2.0"),
+			Start:           awsClient.String("2025-01-01T00:00:00.000Z"),
+			End:             awsClient.String("2028-01-01T00:00:00.000Z"),
+			Tags:            map[string]*string{"team": awsClient.String("platform")},
+		},
+		{
+			SavingsPlanId:   awsClient.String("sp-used"),
+			SavingsPlanArn:  awsClient.String("arn:aws:savingsplans::000000000000:savingsplan/sp-used"),
+			SavingsPlanType: awsClient.String("EC2Instance"),
+			Commitment:      awsClient.String("1.0"),
+		},
+	},
+}
+
+var defaultSavingsPlansUtilizationMock = costexplorer.GetSavingsPlansUtilizationDetailsOutput{
+	SavingsPlansUtilizationDetails: []*costexplorer.SavingsPlansUtilizationDetail{
+		{
+			SavingsPlanArn: awsClient.String("arn:aws:savingsplans::000000000000:savingsplan/sp-underused"),
+			Utilization: &costexplorer.SavingsPlansUtilization{
+				TotalCommitment:       awsClient.String("336"),
+				UsedCommitment:        awsClient.String("252"),
+				UnusedCommitment:      awsClient.String("84"),
+				UtilizationPercentage: awsClient.String("75"),
+			},
+		},
+		{
+			SavingsPlanArn: awsClient.String("arn:aws:savingsplans::000000000000:savingsplan/sp-used"),
+			Utilization: &costexplorer.SavingsPlansUtilization{
+				TotalCommitment:       awsClient.String("168"),
+				UsedCommitment:        awsClient.String("168"),
+				UnusedCommitment:      awsClient.String("0"),
+				UtilizationPercentage: awsClient.String("100"),
+			},
+		},
+	},
+}
+
+type MockAWSReservationsClient struct {
+	err              error
+	savingsPlans     *savingsplans.DescribeSavingsPlansOutput
+	utilizationCalls int
+}
+
+func (r *MockAWSReservationsClient) DescribeReservedInstances(*ec2.DescribeReservedInstancesInput) (*ec2.DescribeReservedInstancesOutput, error) {
+	return &defaultReservedInstancesMock, r.err
+}
+
+func (r *MockAWSReservationsClient) DescribeInstances(*ec2.DescribeInstancesInput) (*ec2.DescribeInstancesOutput, error) {
+	return &defaultReservationsInstancesMock, r.err
+}
+
+func (r *MockAWSReservationsClient) DescribeReservedDBInstances(*rds.DescribeReservedDBInstancesInput) (*rds.DescribeReservedDBInstancesOutput, error) {
+	return &defaultReservedDBInstancesMock, r.err
+}
+
+func (r *MockAWSReservationsClient) DescribeDBInstances(*rds.DescribeDBInstancesInput) (*rds.DescribeDBInstancesOutput, error) {
+	return &defaultReservationsDBInstancesMock, r.err
+}
+
+func (r *MockAWSReservationsClient) DescribeReservedCacheNodes(*elasticache.DescribeReservedCacheNodesInput) (*elasticache.DescribeReservedCacheNodesOutput, error) {
+	return &defaultReservedCacheNodesMock, r.err
+}
+
+func (r *MockAWSReservationsClient) DescribeCacheClusters(*elasticache.DescribeCacheClustersInput) (*elasticache.DescribeCacheClustersOutput, error) {
+	return &defaultReservationsCacheClustersMock, r.err
+}
+
+func (r *MockAWSReservationsClient) DescribeSavingsPlans(*savingsplans.DescribeSavingsPlansInput) (*savingsplans.DescribeSavingsPlansOutput, error) {
+	if r.savingsPlans != nil {
+		return r.savingsPlans, r.err
+	}
+	return &defaultSavingsPlansMock, r.err
+}
+
+func (r *MockAWSReservationsClient) GetSavingsPlansUtilizationDetails(*costexplorer.GetSavingsPlansUtilizationDetailsInput) (*costexplorer.GetSavingsPlansUtilizationDetailsOutput, error) {
+	r.utilizationCalls++
+	return &defaultSavingsPlansUtilizationMock, r.err
+}
+
+func TestNewReservationsManager(t *testing.T) {
+
+	collector := collectorTestutils.NewMockCollector()
+	detector := awsTestutils.AWSManager(collector, nil, nil, "us-east-1")
+
+	reservationsManager, err := NewReservationsManager(detector, &MockEmptyClient{})
+	if err == nil {
+		t.Fatalf("unexpected error happened, got nil expected error")
+	}
+	if reservationsManager != nil {
+		t.Fatalf("unexpected reservations manager instance, got %v expected nil", reflect.TypeOf(reservationsManager))
+	}
+}
+
+func TestGetReservationHourlyFee(t *testing.T) {
+
+	testCases := []struct {
+		name             string
+		fixedPrice       float64
+		usagePrice       float64
+		duration         int64
+		recurringCharges map[string]float64
+		expectedFee      float64
+	}{
+		{"no upfront", 0, 0, 31536000, map[string]float64{"Hourly": 0.05}, 0.05},
+		{"all upfront", 876, 0, 31536000, map[string]float64{}, 0.1},
+		{"partial upfront", 438, 0.01, 31536000, map[string]float64{"Hourly": 0.02}, 0.08},
+		{"no duration", 876, 0.01, 0, map[string]float64{}, 0.01},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			fee := getReservationHourlyFee(test.fixedPrice, test.usagePrice, test.duration, test.recurringCharges)
+			if fmt.Sprintf("%.4f", fee) != fmt.Sprintf("%.4f", test.expectedFee) {
+				t.Fatalf("unexpected hourly fee, got %f expected %f", fee, test.expectedFee)
+			}
+		})
+	}
+}
+
+func TestGetRDSReservationKey(t *testing.T) {
+
+	if getRDSReservationKey("db.r5.large", true, "postgresql") != getRDSReservationKey("db.r5.large", true, "postgres") {
+		t.Fatalf("unexpected postgresql reservation key, expected to match the postgres engine")
+	}
+
+	if getRDSReservationKey("db.r5.large", false, "oracle-se2(li)") != getRDSReservationKey("db.r5.large", false, "oracle-se2") {
+		t.Fatalf("unexpected oracle reservation key, expected to match the oracle-se2 engine")
+	}
+
+	if getRDSReservationKey("db.r5.large", true, "mysql") == getRDSReservationKey("db.r5.large", false, "mysql") {
+		t.Fatalf("unexpected multi az reservation key, expected not to match a single az instance")
+	}
+}
+
+func TestGetSizeNormalizationFactor(t *testing.T) {
+
+	testCases := []struct {
+		instanceType   string
+		expectedFamily string
+		expectedFactor float64
+	}{
+		{"t3.nano", "t3", 0.25},
+		{"m5.large", "m5", 4},
+		{"m5.xlarge", "m5", 8},
+		{"m5.12xlarge", "m5", 96},
+		{"db.r5.2xlarge", "db.r5", 16},
+		{"m5.metal", "m5", 0},
+		{"m5", "m5", 0},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.instanceType, func(t *testing.T) {
+			family, factor := getSizeNormalizationFactor(test.instanceType)
+			if family != test.expectedFamily || factor != test.expectedFactor {
+				t.Fatalf("unexpected size normalization factor, got %s/%f expected %s/%f", family, factor, test.expectedFamily, test.expectedFactor)
+			}
+		})
+	}
+}
+
+func TestIsRDSReservationSizeFlexible(t *testing.T) {
+
+	testCases := []struct {
+		productDescription string
+		expected           bool
+	}{
+		{"postgresql", true},
+		{"aurora-mysql", true},
+		{"oracle-ee(byol)", true},
+		{"oracle-se2(li)", false},
+		{"sqlserver-se(li)", false},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.productDescription, func(t *testing.T) {
+			if isRDSReservationSizeFlexible(test.productDescription) != test.expected {
+				t.Fatalf("unexpected size flexible rds reservation, got %t expected %t", !test.expected, test.expected)
+			}
+		})
+	}
+}
+
+func TestConsumeReservation(t *testing.T) {
+
+	running := map[string]float64{"m5.large": 1, "m5.2xlarge": 1}
+	unitsOf := func(key string) float64 {
+		_, factor := getSizeNormalizationFactor(key)
+		return factor
+	}
+
+	// A 12 units reservation consumes three quarters of the 2xlarge instance
+	matched := consumeReservation(running, 12, func(string) bool { return true }, unitsOf)
+	if matched != 12 {
+		t.Fatalf("unexpected matched units, got %f expected %f", matched, 12.0)
+	}
+	if running["m5.large"] != 1 || running["m5.2xlarge"] != 0.25 {
+		t.Fatalf("unexpected running instances, got %v", running)
+	}
+
+	// The next reservation consumes the remaining units of the 2xlarge instance and the large instance
+	matched = consumeReservation(running, 16, func(string) bool { return true }, unitsOf)
+	if matched != 8 {
+		t.Fatalf("unexpected matched units, got %f expected %f", matched, 8.0)
+	}
+}
+
+func TestDetectReservations(t *testing.T) {
+
+	metrics := []config.MetricConfig{
+		{
+			Description: "Unused reservations",
+			Enable:      true,
+		},
+	}
+
+	t.Run("detect", func(t *testing.T) {
+
+		collector := collectorTestutils.NewMockCollector()
+		detector := awsTestutils.AWSManager(collector, nil, nil, "us-east-1")
+
+		reservationsManager, err := NewReservationsManager(detector, &MockAWSReservationsClient{})
+		if err != nil {
+			t.Fatalf("unexpected reservations manager error happened, got %v expected %v", err, nil)
+		}
+
+		response, err := reservationsManager.Detect(metrics)
+		if err != nil {
+			t.Fatalf("unexpected reservations detection error happened, got %v expected %v", err, nil)
+		}
+
+		reservationsResponse, ok := response.([]DetectedReservation)
+		if !ok {
+			t.Fatalf("unexpected reservations struct, got %s expected %s", reflect.TypeOf(response), "[]DetectedReservation")
+		}
+
+		if len(collector.Events) != 3 {
+			t.Fatalf("unexpected collector reservations events, got %d expected %d", len(collector.Events), 3)
+		}
+
+		// The zonal reservation uses the us-east-1a instance, the regional reservation uses the remaining large instance
+		// and half of its units on the medium instance. The windows reservation is not size flexible, and the stopped
+		// db instance does not use the rds reservation. The savings plan did not use a quarter of its commitment
+		expected := []struct {
+			reservationID string
+			resourceType  string
+			sizeFlexible  bool
+			unusedCount   float64
+			pricePerHour  float64
+		}{
+			{"ri-regional", reservationEC2Instance, true, 1.5, 1.5 * (0.1 + 0.02)},
+			{"rds-unused", reservationRDSInstance, true, 0.5, 0.5 * 0.15},
+			{"sp-underused", reservationSavingsPlan, false, 0.25, 0.25 * 2},
+		}
+
+		if len(reservationsResponse) != len(expected) {
+			t.Fatalf("unexpected reservations detection count, got %d expected %d", len(reservationsResponse), len(expected))
+		}
+
+		for i, reservation := range reservationsResponse {
+			if reservation.ResourceID != expected[i].reservationID || reservation.ResourceType != expected[i].resourceType {
+				t.Fatalf("unexpected detected reservation, got %s/%s expected %s/%s", reservation.ResourceID, reservation.ResourceType, expected[i].reservationID, expected[i].resourceType)
+			}
+
+			if reservation.UnusedCount != expected[i].unusedCount {
+				t.Fatalf("unexpected unused count, got %f expected %f", reservation.UnusedCount, expected[i].unusedCount)
+			}
+
+			if fmt.Sprintf("%.4f", reservation.PricePerHour) != fmt.Sprintf("%.4f", expected[i].pricePerHour) {
+				t.Fatalf("unexpected wasted price per hour, got %f expected %f", reservation.PricePerHour, expected[i].pricePerHour)
+			}
+
+			if reservation.SizeFlexible != expected[i].sizeFlexible {
+				t.Fatalf("unexpected size flexible reservation, got %t expected %t", reservation.SizeFlexible, expected[i].sizeFlexible)
+			}
+
+			if reservation.Category != unusedReservationCategory {
+				t.Fatalf("unexpected category, got %s expected %s", reservation.Category, unusedReservationCategory)
+			}
+		}
+	})
+
+	t.Run("savings plans once per account", func(t *testing.T) {
+
+		collector := collectorTestutils.NewMockCollector()
+		detector := awsTestutils.AWSManager(collector, nil, nil, "us-east-1")
+		client := &MockAWSReservationsClient{}
+
+		// The detector runs for every region of the account
+		for run := 0; run < 2; run++ {
+			reservationsManager, err := NewReservationsManager(detector, client)
+			if err != nil {
+				t.Fatalf("unexpected reservations manager error happened, got %v expected %v", err, nil)
+			}
+
+			_, err = reservationsManager.Detect(metrics)
+			if err != nil {
+				t.Fatalf("unexpected reservations detection error happened, got %v expected %v", err, nil)
+			}
+		}
+
+		if client.utilizationCalls != 1 {
+			t.Fatalf("unexpected savings plans utilization calls, got %d expected %d", client.utilizationCalls, 1)
+		}
+	})
+
+	t.Run("no savings plans", func(t *testing.T) {
+
+		collector := collectorTestutils.NewMockCollector()
+		detector := awsTestutils.AWSManager(collector, nil, nil, "us-east-1")
+		client := &MockAWSReservationsClient{savingsPlans: &savingsplans.DescribeSavingsPlansOutput{}}
+
+		reservationsManager, err := NewReservationsManager(detector, client)
+		if err != nil {
+			t.Fatalf("unexpected reservations manager error happened, got %v expected %v", err, nil)
+		}
+
+		_, err = reservationsManager.Detect(metrics)
+		if err != nil {
+			t.Fatalf("unexpected reservations detection error happened, got %v expected %v", err, nil)
+		}
+
+		// The cost explorer requests are billed, the utilization is not requested without savings plans
+		if client.utilizationCalls != 0 {
+			t.Fatalf("unexpected savings plans utilization calls, got %d expected %d", client.utilizationCalls, 0)
+		}
+	})
+
+	t.Run("detection error", func(t *testing.T) {
+
+		collector := collectorTestutils.NewMockCollector()
+		detector := awsTestutils.AWSManager(collector, nil, nil, "us-east-1")
+
+		reservationsManager, err := NewReservationsManager(detector, &MockAWSReservationsClient{err: errors.New("error")})
+		if err != nil {
+			t.Fatalf("unexpected reservations manager error happened, got %v expected %v", err, nil)
+		}
+
+		_, err = reservationsManager.Detect(metrics)
+		if err == nil {
+			t.Fatalf("unexpected detection reservations manager error, got nil expected error message")
+		}
+	})
+}
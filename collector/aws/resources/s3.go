@@ -0,0 +1,483 @@
+package resources
+
+import (
+	"errors"
+	"finala/collector"
+	"finala/collector/aws/common"
+	"finala/collector/aws/register"
+	"finala/collector/config"
+	"finala/expression"
+	"fmt"
+	"strings"
+	"time"
+
+	awsClient "github.com/aws/aws-sdk-go/aws"
+	awsCloudwatch "github.com/aws/aws-sdk-go/service/cloudwatch"
+	"github.com/aws/aws-sdk-go/service/pricing"
+	"github.com/aws/aws-sdk-go/service/s3"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// bytesInGB defines the number of bytes in one GB (the storage pricing unit)
+	bytesInGB = 1024 * 1024 * 1024
+
+	// s3StorageMetricsLookback defines how far back to look for the daily S3 storage metrics
+	s3StorageMetricsLookback = 72 * time.Hour
+
+	// s3EmptyBucketMetric describes the detection of a bucket without objects
+	s3EmptyBucketMetric = "Empty bucket"
+
+	// s3RequestMetricsFilterID defines the id of the request metrics configuration of the entire bucket
+	s3RequestMetricsFilterID = "EntireBucket"
+
+	// s3MultipartLifecycleMetric describes the detection of a bucket without an incomplete multipart upload lifecycle rule
+	s3MultipartLifecycleMetric = "No incomplete multipart upload lifecycle rule"
+)
+
+// S3ClientDescreptor is an interface defining the aws s3 client
+type S3ClientDescreptor interface {
+	ListBuckets(*s3.ListBucketsInput) (*s3.ListBucketsOutput, error)
+	GetBucketLocation(*s3.GetBucketLocationInput) (*s3.GetBucketLocationOutput, error)
+	GetBucketLifecycleConfiguration(*s3.GetBucketLifecycleConfigurationInput) (*s3.GetBucketLifecycleConfigurationOutput, error)
+	GetBucketTagging(*s3.GetBucketTaggingInput) (*s3.GetBucketTaggingOutput, error)
+	GetBucketMetricsConfiguration(*s3.GetBucketMetricsConfigurationInput) (*s3.GetBucketMetricsConfigurationOutput, error)
+	ListObjectsV2(*s3.ListObjectsV2Input) (*s3.ListObjectsV2Output, error)
+}
+
+// S3Manager describes S3 struct
+type S3Manager struct {
+	client             S3ClientDescreptor
+	awsManager         common.AWSManager
+	namespace          string
+	servicePricingCode string
+	Name               collector.ResourceIdentifier
+}
+
+// DetectedS3Bucket defines the detected AWS S3 bucket
+type DetectedS3Bucket struct {
+	Metric                        string
+	Name                          string
+	StandardStorageSizeBytes      float64
+	NumberOfObjects               float64
+	HasMultipartUploadLifecycle   bool
+	InfrequentAccessPricePerMonth float64
+	InfrequentAccessSaving        float64
+	GlacierPricePerMonth          float64
+	GlacierSaving                 float64
+	collector.PriceDetectedFields
+}
+
+// s3StoragePrices holds the price per GB-month of the S3 storage classes
+type s3StoragePrices struct {
+	standard         float64
+	infrequentAccess float64
+	glacier          float64
+}
+
+// s3StorageClassUsageTypes maps the storage classes to their pricing usage types
+var s3StorageClassUsageTypes = map[string]string{
+	"standard":         "TimedStorage-ByteHrs",
+	"infrequentAccess": "TimedStorage-SIA-ByteHrs",
+	"glacier":          "TimedStorage-GlacierByteHrs",
+}
+
+func init() {
+	register.Registry("s3", NewS3Manager)
+}
+
+// NewS3Manager implements AWS GO SDK
+func NewS3Manager(awsManager common.AWSManager, client interface{}) (common.ResourceDetection, error) {
+
+	if client == nil {
+		client = s3.New(awsManager.GetSession())
+	}
+
+	s3Client, ok := client.(S3ClientDescreptor)
+	if !ok {
+		return nil, errors.New("invalid s3 client")
+	}
+
+	return &S3Manager{
+		client:             s3Client,
+		awsManager:         awsManager,
+		namespace:          "AWS/S3",
+		servicePricingCode: "AmazonS3",
+		Name:               awsManager.GetResourceIdentifier("s3"),
+	}, nil
+}
+
+// Detect checks which S3 buckets are empty, cold or missing a multipart upload lifecycle rule. Each bucket is detected
+// once, with all the metrics it was detected by
+func (s *S3Manager) Detect(metrics []config.MetricConfig) (interface{}, error) {
+
+	log.WithFields(log.Fields{
+		"region":   s.awsManager.GetRegion(),
+		"resource": "s3",
+	}).Info("starting to analyze resource")
+
+	s.awsManager.GetCollector().CollectStart(s.Name)
+
+	detected := []DetectedS3Bucket{}
+
+	prices, err := s.getStoragePrices()
+	if err != nil {
+		log.WithError(err).WithField("region", s.awsManager.GetRegion()).Error("could not get s3 storage prices")
+		s.awsManager.GetCollector().CollectError(s.Name, err)
+		return detected, err
+	}
+
+	buckets, err := s.describeBuckets()
+	if err != nil {
+		s.awsManager.GetCollector().CollectError(s.Name, err)
+		return detected, err
+	}
+
+	now := time.Now()
+
+	for _, bucket := range buckets {
+		log.WithField("name", *bucket.Name).Debug("checking s3 bucket")
+
+		standardSize, _, err := s.getStorageMetric(bucket, "BucketSizeBytes", "StandardStorage", now)
+		if err != nil {
+			log.WithError(err).WithField("name", *bucket.Name).Error("could not get s3 bucket size")
+			continue
+		}
+
+		numberOfObjects, found, err := s.getStorageMetric(bucket, "NumberOfObjects", "AllStorageTypes", now)
+		if err != nil {
+			log.WithError(err).WithField("name", *bucket.Name).Error("could not get s3 bucket number of objects")
+			continue
+		}
+
+		// CloudWatch reports the storage metrics once a day, so a new bucket has no datapoints even when it has
+		// objects. The bucket is empty only when listing its objects returns none
+		empty := found && numberOfObjects == 0
+		if !found {
+			empty = s.isEmpty(bucket)
+		}
+
+		hasMultipartLifecycle := s.hasMultipartUploadLifecycle(bucket)
+		standardSizeGB := standardSize / bytesInGB
+
+		detectedBucket := DetectedS3Bucket{
+			Name:                          *bucket.Name,
+			StandardStorageSizeBytes:      standardSize,
+			NumberOfObjects:               numberOfObjects,
+			HasMultipartUploadLifecycle:   hasMultipartLifecycle,
+			InfrequentAccessPricePerMonth: standardSizeGB * prices.infrequentAccess,
+			GlacierPricePerMonth:          standardSizeGB * prices.glacier,
+			PriceDetectedFields: collector.PriceDetectedFields{
+				ResourceID:        *bucket.Name,
+				ARN:               common.GetGlobalARN(s.awsManager.GetRegion(), "s3", "", *bucket.Name),
+				ConsoleURL:        s.awsManager.GetConsoleURL(fmt.Sprintf("s3/buckets/%s", *bucket.Name), ""),
+				LaunchTime:        *bucket.CreationDate,
+				Tag:               s.getTags(bucket),
+				AccountDimensions: s.awsManager.GetAccountDimensions(),
+			},
+		}
+
+		s.awsManager.ExamineResource(s.Name, func() collector.PriceDetectedFields {
+			pricePerMonth := standardSizeGB * prices.standard
+			return collector.PriceDetectedFields{
+				ResourceID:    *bucket.Name,
+				LaunchTime:    *bucket.CreationDate,
+				PricePerHour:  pricePerMonth / collector.TotalMonthHours,
+				PricePerMonth: pricePerMonth,
+				Tag:           detectedBucket.Tag,
+			}
+		})
+
+		if empty {
+			log.WithFields(log.Fields{
+				"name":   *bucket.Name,
+				"region": s.awsManager.GetRegion(),
+			}).Info("S3 bucket detected as empty")
+			detected = append(detected, s.addDetection(detectedBucket, []string{s3EmptyBucketMetric}))
+			continue
+		}
+
+		// The bucket is reported once, with all the metrics it was detected by
+		detectedMetrics := []string{}
+
+		if standardSize > 0 && s.hasRequestMetrics(bucket) {
+			for _, metric := range metrics {
+				log.WithFields(log.Fields{
+					"name":        *bucket.Name,
+					"metric_name": metric.Description,
+				}).Debug("check metric")
+
+				period := int64(metric.Period.Seconds())
+				metricEndTime := now.Add(time.Duration(-metric.StartTime))
+				metricInput := awsCloudwatch.GetMetricStatisticsInput{
+					Namespace: &s.namespace,
+					Period:    &period,
+					StartTime: &metricEndTime,
+					EndTime:   &now,
+					Dimensions: []*awsCloudwatch.Dimension{
+						{
+							Name:  awsClient.String("BucketName"),
+							Value: bucket.Name,
+						},
+						{
+							Name:  awsClient.String("FilterId"),
+							Value: awsClient.String(s3RequestMetricsFilterID),
+						},
+					},
+				}
+
+				formulaValue, _, err := s.awsManager.GetCloudWatchClient().GetMetric(&metricInput, metric)
+				if err != nil {
+					log.WithError(err).WithFields(log.Fields{
+						"name":        *bucket.Name,
+						"metric_name": metric.Description,
+					}).Error("Could not get cloudwatch metric data")
+					continue
+				}
+
+				expression, err := expression.BoolExpression(formulaValue, metric.Constraint.Value, metric.Constraint.Operator)
+				if err != nil {
+					continue
+				}
+
+				if expression {
+
+					log.WithFields(log.Fields{
+						"metric_name":         metric.Description,
+						"constraint_operator": metric.Constraint.Operator,
+						"constraint_Value":    metric.Constraint.Value,
+						"formula_value":       formulaValue,
+						"name":                *bucket.Name,
+						"region":              s.awsManager.GetRegion(),
+					}).Info("S3 bucket detected as cold standard storage")
+
+					pricePerMonth := standardSizeGB * prices.standard
+					detectedBucket.PricePerMonth = pricePerMonth
+					detectedBucket.PricePerHour = pricePerMonth / collector.TotalMonthHours
+					detectedBucket.InfrequentAccessSaving = pricePerMonth - detectedBucket.InfrequentAccessPricePerMonth
+					detectedBucket.GlacierSaving = pricePerMonth - detectedBucket.GlacierPricePerMonth
+
+					detectedMetrics = append(detectedMetrics, metric.Description)
+				}
+			}
+		}
+
+		if !hasMultipartLifecycle {
+			log.WithFields(log.Fields{
+				"name":   *bucket.Name,
+				"region": s.awsManager.GetRegion(),
+			}).Info("S3 bucket detected without incomplete multipart upload lifecycle rule")
+			detectedMetrics = append(detectedMetrics, s3MultipartLifecycleMetric)
+		}
+
+		if len(detectedMetrics) > 0 {
+			detected = append(detected, s.addDetection(detectedBucket, detectedMetrics))
+		}
+	}
+
+	s.awsManager.GetCollector().CollectFinish(s.Name)
+
+	return detected, nil
+}
+
+// addDetection sends the detected bucket to the collector, with the metrics it was detected by
+func (s *S3Manager) addDetection(bucket DetectedS3Bucket, metrics []string) DetectedS3Bucket {
+
+	bucket.Metric = strings.Join(metrics, ", ")
+	s.awsManager.GetCollector().AddResource(collector.EventCollector{
+		ResourceName: s.Name,
+		Data:         bucket,
+	})
+
+	return bucket
+}
+
+// getStorageMetric returns the latest daily storage metric value of the given bucket, and false when the metric has
+// no datapoints
+func (s *S3Manager) getStorageMetric(bucket *s3.Bucket, metricName, storageType string, now time.Time) (float64, bool, error) {
+
+	period := int64((24 * time.Hour).Seconds())
+	startTime := now.Add(-s3StorageMetricsLookback)
+	metricInput := awsCloudwatch.GetMetricStatisticsInput{
+		Namespace: &s.namespace,
+		Period:    &period,
+		StartTime: &startTime,
+		EndTime:   &now,
+		Dimensions: []*awsCloudwatch.Dimension{
+			{
+				Name:  awsClient.String("BucketName"),
+				Value: bucket.Name,
+			},
+			{
+				Name:  awsClient.String("StorageType"),
+				Value: awsClient.String(storageType),
+			},
+		},
+	}
+
+	cloudWatchClient := s.awsManager.GetCloudWatchClient()
+	datapoints, err := cloudWatchClient.GetDatapoints(&metricInput, config.MetricDataConfiguration{
+		Name:      metricName,
+		Statistic: "Maximum",
+	})
+	if err != nil || len(datapoints) == 0 {
+		return 0, false, err
+	}
+
+	return cloudWatchClient.MaxDatapoint(&awsCloudwatch.GetMetricStatisticsOutput{Datapoints: datapoints}), true, nil
+}
+
+// isEmpty returns true when the bucket has no objects. A bucket which could not be listed is not reported as empty
+func (s *S3Manager) isEmpty(bucket *s3.Bucket) bool {
+
+	resp, err := s.client.ListObjectsV2(&s3.ListObjectsV2Input{
+		Bucket:  bucket.Name,
+		MaxKeys: awsClient.Int64(1),
+	})
+	if err != nil {
+		log.WithError(err).WithField("name", *bucket.Name).Debug("could not list s3 bucket objects")
+		return false
+	}
+
+	return len(resp.Contents) == 0
+}
+
+// hasRequestMetrics returns true when the bucket publishes the request metrics of the entire bucket. CloudWatch has no
+// request metrics of the other buckets, so they can not be detected as cold
+func (s *S3Manager) hasRequestMetrics(bucket *s3.Bucket) bool {
+
+	_, err := s.client.GetBucketMetricsConfiguration(&s3.GetBucketMetricsConfigurationInput{
+		Bucket: bucket.Name,
+		Id:     awsClient.String(s3RequestMetricsFilterID),
+	})
+	if err != nil {
+		log.WithError(err).WithField("name", *bucket.Name).Debug("s3 bucket has no request metrics configuration")
+		return false
+	}
+
+	return true
+}
+
+// hasMultipartUploadLifecycle returns true when the bucket has an enabled rule that aborts incomplete multipart uploads
+func (s *S3Manager) hasMultipartUploadLifecycle(bucket *s3.Bucket) bool {
+
+	resp, err := s.client.GetBucketLifecycleConfiguration(&s3.GetBucketLifecycleConfigurationInput{
+		Bucket: bucket.Name,
+	})
+	if err != nil {
+		log.WithError(err).WithField("name", *bucket.Name).Debug("could not get s3 bucket lifecycle configuration")
+		return false
+	}
+
+	for _, rule := range resp.Rules {
+		if rule.Status != nil && *rule.Status == s3.ExpirationStatusEnabled && rule.AbortIncompleteMultipartUpload != nil {
+			return true
+		}
+	}
+
+	return false
+}
+
+// getTags returns the bucket tags
+func (s *S3Manager) getTags(bucket *s3.Bucket) map[string]string {
+
+	tagsData := map[string]string{}
+	tags, err := s.client.GetBucketTagging(&s3.GetBucketTaggingInput{
+		Bucket: bucket.Name,
+	})
+	if err == nil {
+		for _, tag := range tags.TagSet {
+			tagsData[*tag.Key] = *tag.Value
+		}
+	}
+
+	return tagsData
+}
+
+// getStoragePrices returns the price per GB-month of standard, infrequent access and glacier storage
+func (s *S3Manager) getStoragePrices() (s3StoragePrices, error) {
+
+	prices := s3StoragePrices{}
+
+	pricingRegionPrefix, err := s.awsManager.GetPricingClient().GetRegionPrefix(s.awsManager.GetRegion())
+	if err != nil {
+		return prices, err
+	}
+
+	storageClassPrices := map[string]float64{}
+	for storageClass, usageType := range s3StorageClassUsageTypes {
+		pricingFilters := s.getPricingFilterInput(fmt.Sprintf("%s%s", pricingRegionPrefix, usageType))
+		price, err := s.awsManager.GetPricingClient().GetPrice(pricingFilters, "", s.awsManager.GetRegion())
+		if err != nil {
+			log.WithError(err).WithFields(log.Fields{
+				"storage_class": storageClass,
+				"price_filters": pricingFilters,
+			}).Error("could not get s3 storage class price")
+			return prices, err
+		}
+		storageClassPrices[storageClass] = price
+	}
+
+	prices.standard = storageClassPrices["standard"]
+	prices.infrequentAccess = storageClassPrices["infrequentAccess"]
+	prices.glacier = storageClassPrices["glacier"]
+
+	return prices, nil
+}
+
+// getPricingFilterInput prepares the s3 storage pricing filter
+func (s *S3Manager) getPricingFilterInput(usageType string) pricing.GetProductsInput {
+	return pricing.GetProductsInput{
+		ServiceCode: &s.servicePricingCode,
+		Filters: []*pricing.Filter{
+			{
+				Type:  awsClient.String("TERM_MATCH"),
+				Field: awsClient.String("productFamily"),
+				Value: awsClient.String("Storage"),
+			},
+			{
+				Type:  awsClient.String("TERM_MATCH"),
+				Field: awsClient.String("termType"),
+				Value: awsClient.String("OnDemand"),
+			},
+			{
+				Type:  awsClient.String("TERM_MATCH"),
+				Field: awsClient.String("usagetype"),
+				Value: awsClient.String(usageType),
+			},
+		},
+	}
+}
+
+// describeBuckets returns the list of buckets located in the current region
+func (s *S3Manager) describeBuckets() ([]*s3.Bucket, error) {
+
+	resp, err := s.client.ListBuckets(&s3.ListBucketsInput{})
+	if err != nil {
+		log.WithField("error", err).Error("could not list s3 buckets")
+		return nil, err
+	}
+
+	buckets := []*s3.Bucket{}
+	for _, bucket := range resp.Buckets {
+		location, err := s.client.GetBucketLocation(&s3.GetBucketLocationInput{
+			Bucket: bucket.Name,
+		})
+		if err != nil {
+			log.WithError(err).WithField("name", *bucket.Name).Error("could not get s3 bucket location")
+			continue
+		}
+
+		// Buckets in us-east-1 have a null location constraint
+		bucketRegion := "us-east-1"
+		if location.LocationConstraint != nil && *location.LocationConstraint != "" {
+			bucketRegion = *location.LocationConstraint
+		}
+
+		if bucketRegion == s.awsManager.GetRegion() {
+			buckets = append(buckets, bucket)
+		}
+	}
+
+	return buckets, nil
+}
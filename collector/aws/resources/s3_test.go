@@ -0,0 +1,356 @@
+package resources
+
+import (
+	"errors"
+	awsTestutils "finala/collector/aws/testutils"
+	"finala/collector/config"
+	"finala/collector/testutils"
+	collectorTestutils "finala/collector/testutils"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	awsClient "github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudwatch"
+	"github.com/aws/aws-sdk-go/service/s3"
+)
+
+var defaultS3BucketsMock = s3.ListBucketsOutput{
+	Buckets: []*s3.Bucket{
+		{
+			Name:         awsClient.String("bucket-1"),
+			CreationDate: testutils.TimePointer(time.Now()),
+		},
+		{
+			Name:         awsClient.String("bucket-2"),
+			CreationDate: testutils.TimePointer(time.Now()),
+		},
+	},
+}
+
+// defaultS3RequestMetrics defines the buckets which publish the request metrics of the entire bucket
+var defaultS3RequestMetrics = map[string]bool{
+	"bucket-1": true,
+	"bucket-2": true,
+}
+
+// defaultS3MetricConfig defines the cold bucket metric
+var defaultS3MetricConfig = []config.MetricConfig{
+	{
+		Description: "Get requests",
+		Data: []config.MetricDataConfiguration{
+			{
+				Name:      "TestMetric",
+				Statistic: "Sum",
+			},
+		},
+		Constraint: config.MetricConstraintConfig{
+			Operator: "==",
+			Value:    5,
+		},
+	},
+}
+
+type MockAWSS3Client struct {
+	responseListBuckets s3BucketLocations
+	lifecycleRules      map[string][]*s3.LifecycleRule
+	requestMetrics      map[string]bool
+	objects             map[string]int
+	err                 error
+}
+
+// s3BucketLocations holds the list buckets response and each bucket location
+type s3BucketLocations struct {
+	buckets   s3.ListBucketsOutput
+	locations map[string]string
+}
+
+func (r *MockAWSS3Client) ListBuckets(*s3.ListBucketsInput) (*s3.ListBucketsOutput, error) {
+	return &r.responseListBuckets.buckets, r.err
+}
+
+func (r *MockAWSS3Client) GetBucketLocation(input *s3.GetBucketLocationInput) (*s3.GetBucketLocationOutput, error) {
+	location, found := r.responseListBuckets.locations[*input.Bucket]
+	if !found {
+		return &s3.GetBucketLocationOutput{}, nil
+	}
+	return &s3.GetBucketLocationOutput{LocationConstraint: awsClient.String(location)}, nil
+}
+
+func (r *MockAWSS3Client) GetBucketLifecycleConfiguration(input *s3.GetBucketLifecycleConfigurationInput) (*s3.GetBucketLifecycleConfigurationOutput, error) {
+	rules, found := r.lifecycleRules[*input.Bucket]
+	if !found {
+		return nil, errors.New("NoSuchLifecycleConfiguration")
+	}
+	return &s3.GetBucketLifecycleConfigurationOutput{Rules: rules}, nil
+}
+
+func (r *MockAWSS3Client) GetBucketMetricsConfiguration(input *s3.GetBucketMetricsConfigurationInput) (*s3.GetBucketMetricsConfigurationOutput, error) {
+	if !r.requestMetrics[*input.Bucket] || *input.Id != "EntireBucket" {
+		return nil, errors.New("NoSuchConfiguration")
+	}
+	return &s3.GetBucketMetricsConfigurationOutput{
+		MetricsConfiguration: &s3.MetricsConfiguration{Id: input.Id},
+	}, nil
+}
+
+func (r *MockAWSS3Client) ListObjectsV2(input *s3.ListObjectsV2Input) (*s3.ListObjectsV2Output, error) {
+	objects := []*s3.Object{}
+	for i := 0; i < r.objects[*input.Bucket] && int64(i) < *input.MaxKeys; i++ {
+		objects = append(objects, &s3.Object{Key: awsClient.String(fmt.Sprintf("object-%d", i))})
+	}
+	return &s3.ListObjectsV2Output{Contents: objects}, nil
+}
+
+func (r *MockAWSS3Client) GetBucketTagging(*s3.GetBucketTaggingInput) (*s3.GetBucketTaggingOutput, error) {
+	return &s3.GetBucketTaggingOutput{
+		TagSet: []*s3.Tag{
+			{
+				Key:   awsClient.String("team"),
+				Value: awsClient.String("storage"),
+			},
+		},
+	}, nil
+}
+
+// newS3CloudwatchMetrics returns the bucket storage metrics. A negative number of objects has no datapoints
+func newS3CloudwatchMetrics(size, objects float64) map[string]cloudwatch.GetMetricStatisticsOutput {
+	numberOfObjects := []*cloudwatch.Datapoint{}
+	if objects >= 0 {
+		numberOfObjects = append(numberOfObjects, &cloudwatch.Datapoint{Maximum: testutils.Float64Pointer(objects)})
+	}
+	return map[string]cloudwatch.GetMetricStatisticsOutput{
+		"BucketSizeBytes": {
+			Datapoints: []*cloudwatch.Datapoint{
+				{Maximum: testutils.Float64Pointer(size)},
+			},
+		},
+		"NumberOfObjects": {
+			Datapoints: numberOfObjects,
+		},
+		"TestMetric": {
+			Datapoints: []*cloudwatch.Datapoint{
+				{Sum: testutils.Float64Pointer(5)},
+			},
+		},
+	}
+}
+
+func TestNewS3Manager(t *testing.T) {
+
+	collector := collectorTestutils.NewMockCollector()
+	detector := awsTestutils.AWSManager(collector, nil, nil, "us-east-1")
+
+	s3Manager, err := NewS3Manager(detector, &MockEmptyClient{})
+	if err == nil {
+		t.Fatalf("unexpected error happened, got nil expected error")
+	}
+	if s3Manager != nil {
+		t.Fatalf("unexpected s3 manager instance, got %v expected nil", reflect.TypeOf(s3Manager))
+	}
+}
+
+func TestDescribeS3Buckets(t *testing.T) {
+
+	collector := collectorTestutils.NewMockCollector()
+	detector := awsTestutils.AWSManager(collector, nil, nil, "us-east-1")
+
+	t.Run("valid", func(t *testing.T) {
+
+		mockClient := MockAWSS3Client{
+			responseListBuckets: s3BucketLocations{
+				buckets: defaultS3BucketsMock,
+				locations: map[string]string{
+					"bucket-2": "eu-west-1",
+				},
+			},
+		}
+
+		s3Interface, err := NewS3Manager(detector, &mockClient)
+		if err != nil {
+			t.Fatalf("unexpected s3 manager error happened, got %v expected %v", err, nil)
+		}
+
+		s3Manager, ok := s3Interface.(*S3Manager)
+		if !ok {
+			t.Fatalf("unexpected s3 struct, got %s expected %s", reflect.TypeOf(s3Interface), "*S3Manager")
+		}
+
+		result, err := s3Manager.describeBuckets()
+		if err != nil {
+			t.Fatalf("unexpected error happened, got %v expected %v", err, nil)
+		}
+
+		if len(result) != 1 {
+			t.Fatalf("unexpected s3 buckets count, got %d expected %d", len(result), 1)
+		}
+	})
+
+	t.Run("error", func(t *testing.T) {
+
+		mockClient := MockAWSS3Client{
+			responseListBuckets: s3BucketLocations{buckets: defaultS3BucketsMock},
+			err:                 errors.New("error"),
+		}
+
+		s3Interface, err := NewS3Manager(detector, &mockClient)
+		if err != nil {
+			t.Fatalf("unexpected s3 manager error happened, got %v expected %v", err, nil)
+		}
+
+		s3Manager, ok := s3Interface.(*S3Manager)
+		if !ok {
+			t.Fatalf("unexpected s3 struct, got %s expected %s", reflect.TypeOf(s3Interface), "*S3Manager")
+		}
+
+		_, err = s3Manager.describeBuckets()
+		if err == nil {
+			t.Fatalf("unexpected describe s3 buckets error, return empty")
+		}
+	})
+}
+
+func TestDetectS3(t *testing.T) {
+
+	coldMetric := defaultS3MetricConfig[0].Description
+	lifecycleRules := map[string][]*s3.LifecycleRule{
+		"bucket-1": {
+			{
+				Status:                         awsClient.String("Enabled"),
+				AbortIncompleteMultipartUpload: &s3.AbortIncompleteMultipartUpload{DaysAfterInitiation: awsClient.Int64(7)},
+			},
+		},
+	}
+
+	testCases := []struct {
+		name            string
+		size            float64
+		objects         float64
+		listedObjects   map[string]int
+		lifecycleRules  map[string][]*s3.LifecycleRule
+		requestMetrics  map[string]bool
+		expectedMetrics []string
+	}{
+		{"empty bucket", 0, 0, nil, nil, defaultS3RequestMetrics, []string{s3EmptyBucketMetric, s3EmptyBucketMetric}},
+		{"no objects datapoints", 0, -1, map[string]int{"bucket-1": 3}, lifecycleRules, defaultS3RequestMetrics, []string{s3EmptyBucketMetric}},
+		{"cold bucket without lifecycle", 10 * bytesInGB, 20, nil, nil, defaultS3RequestMetrics, []string{
+			fmt.Sprintf("%s, %s", coldMetric, s3MultipartLifecycleMetric),
+			fmt.Sprintf("%s, %s", coldMetric, s3MultipartLifecycleMetric),
+		}},
+		{"cold bucket with lifecycle", 10 * bytesInGB, 20, nil, lifecycleRules, defaultS3RequestMetrics, []string{
+			coldMetric,
+			fmt.Sprintf("%s, %s", coldMetric, s3MultipartLifecycleMetric),
+		}},
+		{"bucket without request metrics", 10 * bytesInGB, 20, nil, lifecycleRules, map[string]bool{"bucket-2": true}, []string{
+			fmt.Sprintf("%s, %s", coldMetric, s3MultipartLifecycleMetric),
+		}},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+
+			cloudWatchMetrics := newS3CloudwatchMetrics(test.size, test.objects)
+
+			collector := collectorTestutils.NewMockCollector()
+			mockCloudwatch := awsTestutils.NewMockCloudwatch(&cloudWatchMetrics)
+			mockPrice := awsTestutils.NewMockPricing(nil)
+			detector := awsTestutils.AWSManager(collector, mockCloudwatch, mockPrice, "us-east-1")
+
+			mockClient := MockAWSS3Client{
+				responseListBuckets: s3BucketLocations{buckets: defaultS3BucketsMock},
+				lifecycleRules:      test.lifecycleRules,
+				requestMetrics:      test.requestMetrics,
+				objects:             test.listedObjects,
+			}
+
+			s3Manager, err := NewS3Manager(detector, &mockClient)
+			if err != nil {
+				t.Fatalf("unexpected s3 manager error happened, got %v expected %v", err, nil)
+			}
+
+			response, err := s3Manager.Detect(defaultS3MetricConfig)
+			if err != nil {
+				t.Fatalf("unexpected s3 detection error happened, got %v expected %v", err, nil)
+			}
+
+			s3Response, ok := response.([]DetectedS3Bucket)
+			if !ok {
+				t.Fatalf("unexpected s3 struct, got %s expected %s", reflect.TypeOf(response), "[]DetectedS3Bucket")
+			}
+
+			if len(s3Response) != len(test.expectedMetrics) {
+				t.Fatalf("unexpected s3 detection count, got %d expected %d", len(s3Response), len(test.expectedMetrics))
+			}
+
+			if len(collector.Events) != len(test.expectedMetrics) {
+				t.Fatalf("unexpected collector s3 events, got %d expected %d", len(collector.Events), len(test.expectedMetrics))
+			}
+
+			for i, bucket := range s3Response {
+				if bucket.Metric != test.expectedMetrics[i] {
+					t.Fatalf("unexpected s3 detection metric, got %s expected %s", bucket.Metric, test.expectedMetrics[i])
+				}
+			}
+		})
+	}
+
+	t.Run("cold bucket pricing", func(t *testing.T) {
+
+		cloudWatchMetrics := newS3CloudwatchMetrics(10*bytesInGB, 20)
+
+		collector := collectorTestutils.NewMockCollector()
+		mockCloudwatch := awsTestutils.NewMockCloudwatch(&cloudWatchMetrics)
+		mockPrice := awsTestutils.NewMockPricing(nil)
+		detector := awsTestutils.AWSManager(collector, mockCloudwatch, mockPrice, "us-east-1")
+
+		mockClient := MockAWSS3Client{
+			responseListBuckets: s3BucketLocations{buckets: defaultS3BucketsMock},
+			requestMetrics:      defaultS3RequestMetrics,
+		}
+
+		s3Manager, err := NewS3Manager(detector, &mockClient)
+		if err != nil {
+			t.Fatalf("unexpected s3 manager error happened, got %v expected %v", err, nil)
+		}
+
+		response, _ := s3Manager.Detect(defaultS3MetricConfig)
+		s3Response := response.([]DetectedS3Bucket)
+
+		coldBucket := s3Response[0]
+		if coldBucket.PricePerMonth != 10 {
+			t.Fatalf("unexpected price per month, got %f expected %d", coldBucket.PricePerMonth, 10)
+		}
+
+		if coldBucket.GlacierPricePerMonth != 10 {
+			t.Fatalf("unexpected glacier price per month, got %f expected %d", coldBucket.GlacierPricePerMonth, 10)
+		}
+
+		if len(coldBucket.Tag) != 1 {
+			t.Fatalf("unexpected tags count, got %d expected %d", len(coldBucket.Tag), 1)
+		}
+	})
+
+	t.Run("detection error", func(t *testing.T) {
+
+		collector := collectorTestutils.NewMockCollector()
+		mockCloudwatch := awsTestutils.NewMockCloudwatch(nil)
+		mockPrice := awsTestutils.NewMockPricing(nil)
+		detector := awsTestutils.AWSManager(collector, mockCloudwatch, mockPrice, "us-east-1")
+
+		mockClient := MockAWSS3Client{
+			responseListBuckets: s3BucketLocations{buckets: defaultS3BucketsMock},
+			err:                 errors.New("error"),
+		}
+
+		s3Manager, err := NewS3Manager(detector, &mockClient)
+		if err != nil {
+			t.Fatalf("unexpected s3 manager error happened, got %v expected %v", err, nil)
+		}
+
+		_, err = s3Manager.Detect(awsTestutils.DefaultMetricConfig)
+		if err == nil {
+			t.Fatalf("unexpected detection s3 manager error, got nil expected error message")
+		}
+	})
+}
@@ -0,0 +1,474 @@
+package resources
+
+import (
+	"errors"
+	"finala/collector"
+	"finala/collector/aws/common"
+	"finala/collector/aws/register"
+	"finala/collector/config"
+	"finala/expression"
+	"fmt"
+	"time"
+
+	awsClient "github.com/aws/aws-sdk-go/aws"
+	awsCloudwatch "github.com/aws/aws-sdk-go/service/cloudwatch"
+	"github.com/aws/aws-sdk-go/service/pricing"
+	"github.com/aws/aws-sdk-go/service/sagemaker"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// sageMakerEndpointType describes a detected real-time inference endpoint
+	sageMakerEndpointType = "endpoint"
+
+	// sageMakerNotebookType describes a detected notebook instance
+	sageMakerNotebookType = "notebook"
+)
+
+// SageMakerClientDescreptor is an interface defining the aws sagemaker client
+type SageMakerClientDescreptor interface {
+	ListEndpoints(*sagemaker.ListEndpointsInput) (*sagemaker.ListEndpointsOutput, error)
+	DescribeEndpoint(*sagemaker.DescribeEndpointInput) (*sagemaker.DescribeEndpointOutput, error)
+	DescribeEndpointConfig(*sagemaker.DescribeEndpointConfigInput) (*sagemaker.DescribeEndpointConfigOutput, error)
+	ListNotebookInstances(*sagemaker.ListNotebookInstancesInput) (*sagemaker.ListNotebookInstancesOutput, error)
+	ListTags(*sagemaker.ListTagsInput) (*sagemaker.ListTagsOutput, error)
+}
+
+// SageMakerManager describes the sagemaker endpoints and notebook instances struct
+type SageMakerManager struct {
+	client             SageMakerClientDescreptor
+	awsManager         common.AWSManager
+	namespace          string
+	servicePricingCode string
+	prices             map[string]float64
+	Name               collector.ResourceIdentifier
+}
+
+// SageMakerInstances defines the instances count of a sagemaker instance type
+type SageMakerInstances struct {
+	InstanceType  string
+	InstanceCount int64
+}
+
+// DetectedSageMaker defines the detected AWS sagemaker endpoint or notebook instance
+type DetectedSageMaker struct {
+	Metric       string
+	Name         string
+	ResourceType string
+	Instances    []SageMakerInstances
+	collector.PriceDetectedFields
+}
+
+func init() {
+	register.Registry("sagemaker", NewSageMakerManager)
+}
+
+// NewSageMakerManager implements AWS GO SDK
+func NewSageMakerManager(awsManager common.AWSManager, client interface{}) (common.ResourceDetection, error) {
+
+	if client == nil {
+		client = sagemaker.New(awsManager.GetSession())
+	}
+
+	sageMakerClient, ok := client.(SageMakerClientDescreptor)
+	if !ok {
+		return nil, errors.New("invalid sagemaker client")
+	}
+
+	return &SageMakerManager{
+		client:             sageMakerClient,
+		awsManager:         awsManager,
+		namespace:          "AWS/SageMaker",
+		servicePricingCode: "AmazonSageMaker",
+		prices:             map[string]float64{},
+		Name:               awsManager.GetResourceIdentifier("sagemaker"),
+	}, nil
+}
+
+// Detect checks which sagemaker endpoints have no invocations and which notebook instances are running for too long.
+// Metrics with cloudwatch metrics are checked against the endpoints, and metrics without
+// cloudwatch metrics are checked against the running hours of the notebook instances.
+func (sm *SageMakerManager) Detect(metrics []config.MetricConfig) (interface{}, error) {
+
+	log.WithFields(log.Fields{
+		"region":   sm.awsManager.GetRegion(),
+		"resource": "sagemaker",
+	}).Info("starting to analyze resource")
+
+	sm.awsManager.GetCollector().CollectStart(sm.Name)
+
+	detected := []DetectedSageMaker{}
+
+	pricingRegionPrefix, err := sm.awsManager.GetPricingClient().GetRegionPrefix(sm.awsManager.GetRegion())
+	if err != nil {
+		log.WithError(err).WithFields(log.Fields{
+			"region": sm.awsManager.GetRegion(),
+		}).Error("Could not get pricing region prefix")
+		sm.awsManager.GetCollector().CollectError(sm.Name, err)
+		return detected, err
+	}
+
+	endpoints, err := sm.listEndpoints(nil, nil)
+	if err != nil {
+		sm.awsManager.GetCollector().CollectError(sm.Name, err)
+		return detected, err
+	}
+
+	notebooks, err := sm.listNotebookInstances(nil, nil)
+	if err != nil {
+		sm.awsManager.GetCollector().CollectError(sm.Name, err)
+		return detected, err
+	}
+
+	now := time.Now()
+
+	for _, endpointSummary := range endpoints {
+		log.WithField("name", *endpointSummary.EndpointName).Debug("checking sagemaker endpoint")
+
+		endpoint, err := sm.client.DescribeEndpoint(&sagemaker.DescribeEndpointInput{EndpointName: endpointSummary.EndpointName})
+		if err != nil {
+			log.WithError(err).WithField("name", *endpointSummary.EndpointName).Error("could not describe sagemaker endpoint")
+			continue
+		}
+
+		sm.awsManager.ExamineResource(sm.Name, func() collector.PriceDetectedFields {
+			price := sm.getInstancesHourlyPrice(fmt.Sprintf("%sHost", pricingRegionPrefix), sm.getEndpointInstances(endpoint))
+			return collector.PriceDetectedFields{
+				ResourceID:    *endpointSummary.EndpointArn,
+				LaunchTime:    awsClient.TimeValue(endpointSummary.CreationTime),
+				PricePerHour:  price,
+				PricePerMonth: price * collector.TotalMonthHours,
+				Tag:           sm.getTags(endpointSummary.EndpointArn),
+			}
+		})
+
+		for _, metric := range metrics {
+			if len(metric.Data) == 0 {
+				continue
+			}
+
+			log.WithFields(log.Fields{
+				"name":        *endpointSummary.EndpointName,
+				"metric_name": metric.Description,
+			}).Debug("check metric")
+
+			formulaValue, err := sm.getEndpointMetricValue(endpoint, metric, now)
+			if err != nil {
+				log.WithError(err).WithFields(log.Fields{
+					"name":        *endpointSummary.EndpointName,
+					"metric_name": metric.Description,
+				}).Error("Could not get cloudwatch metric data")
+				continue
+			}
+
+			expression, err := expression.BoolExpression(formulaValue, metric.Constraint.Value, metric.Constraint.Operator)
+			if err != nil {
+				log.WithField("error", err).Error("could not parse expression")
+				continue
+			}
+
+			if expression {
+
+				log.WithFields(log.Fields{
+					"metric_name":         metric.Description,
+					"constraint_operator": metric.Constraint.Operator,
+					"constraint_Value":    metric.Constraint.Value,
+					"formula_value":       formulaValue,
+					"name":                *endpointSummary.EndpointName,
+					"region":              sm.awsManager.GetRegion(),
+				}).Info("SageMaker endpoint detected as unutilized resource")
+
+				instances := sm.getEndpointInstances(endpoint)
+				price := sm.getInstancesHourlyPrice(fmt.Sprintf("%sHost", pricingRegionPrefix), instances)
+
+				detectedEndpoint := DetectedSageMaker{
+					Metric:       metric.Description,
+					Name:         *endpointSummary.EndpointName,
+					ResourceType: sageMakerEndpointType,
+					Instances:    instances,
+					PriceDetectedFields: collector.PriceDetectedFields{
+						ResourceID:        *endpointSummary.EndpointArn,
+						ARN:               *endpointSummary.EndpointArn,
+						ConsoleURL:        sm.awsManager.GetConsoleURL("sagemaker/home", fmt.Sprintf("/endpoints/%s", *endpointSummary.EndpointName)),
+						LaunchTime:        awsClient.TimeValue(endpointSummary.CreationTime),
+						PricePerHour:      price,
+						PricePerMonth:     price * collector.TotalMonthHours,
+						Tag:               sm.getTags(endpointSummary.EndpointArn),
+						AccountDimensions: sm.awsManager.GetAccountDimensions(),
+					},
+				}
+
+				sm.awsManager.GetCollector().AddResource(collector.EventCollector{
+					ResourceName: sm.Name,
+					Data:         detectedEndpoint,
+				})
+
+				detected = append(detected, detectedEndpoint)
+			}
+		}
+	}
+
+	for _, notebook := range notebooks {
+		log.WithField("name", *notebook.NotebookInstanceName).Debug("checking sagemaker notebook instance")
+
+		// The last modified time of an in service notebook instance is the time it was started
+		runningHours := now.Sub(awsClient.TimeValue(notebook.LastModifiedTime)).Hours()
+
+		instances := []SageMakerInstances{
+			{
+				InstanceType:  awsClient.StringValue(notebook.InstanceType),
+				InstanceCount: 1,
+			},
+		}
+
+		sm.awsManager.ExamineResource(sm.Name, func() collector.PriceDetectedFields {
+			price := sm.getInstancesHourlyPrice(fmt.Sprintf("%sNotebk", pricingRegionPrefix), instances)
+			return collector.PriceDetectedFields{
+				ResourceID:    *notebook.NotebookInstanceArn,
+				LaunchTime:    awsClient.TimeValue(notebook.CreationTime),
+				PricePerHour:  price,
+				PricePerMonth: price * collector.TotalMonthHours,
+				Tag:           sm.getTags(notebook.NotebookInstanceArn),
+			}
+		})
+
+		for _, metric := range metrics {
+			if len(metric.Data) != 0 {
+				continue
+			}
+
+			expression, err := expression.BoolExpression(runningHours, metric.Constraint.Value, metric.Constraint.Operator)
+			if err != nil {
+				log.WithField("error", err).Error("could not parse expression")
+				continue
+			}
+
+			if expression {
+
+				log.WithFields(log.Fields{
+					"metric_name":         metric.Description,
+					"constraint_operator": metric.Constraint.Operator,
+					"constraint_Value":    metric.Constraint.Value,
+					"running_hours":       runningHours,
+					"name":                *notebook.NotebookInstanceName,
+					"region":              sm.awsManager.GetRegion(),
+				}).Info("SageMaker notebook instance detected as unutilized resource")
+
+				price := sm.getInstancesHourlyPrice(fmt.Sprintf("%sNotebk", pricingRegionPrefix), instances)
+
+				detectedNotebook := DetectedSageMaker{
+					Metric:       metric.Description,
+					Name:         *notebook.NotebookInstanceName,
+					ResourceType: sageMakerNotebookType,
+					Instances:    instances,
+					PriceDetectedFields: collector.PriceDetectedFields{
+						ResourceID:        *notebook.NotebookInstanceArn,
+						ARN:               *notebook.NotebookInstanceArn,
+						ConsoleURL:        sm.awsManager.GetConsoleURL("sagemaker/home", fmt.Sprintf("/notebook-instances/%s", *notebook.NotebookInstanceName)),
+						LaunchTime:        awsClient.TimeValue(notebook.CreationTime),
+						PricePerHour:      price,
+						PricePerMonth:     price * collector.TotalMonthHours,
+						Tag:               sm.getTags(notebook.NotebookInstanceArn),
+						AccountDimensions: sm.awsManager.GetAccountDimensions(),
+					},
+				}
+
+				sm.awsManager.GetCollector().AddResource(collector.EventCollector{
+					ResourceName: sm.Name,
+					Data:         detectedNotebook,
+				})
+
+				detected = append(detected, detectedNotebook)
+			}
+		}
+	}
+
+	sm.awsManager.GetCollector().CollectFinish(sm.Name)
+
+	return detected, nil
+}
+
+// getEndpointMetricValue returns the sum of the metric values of all the endpoint production variants
+func (sm *SageMakerManager) getEndpointMetricValue(endpoint *sagemaker.DescribeEndpointOutput, metric config.MetricConfig, now time.Time) (float64, error) {
+
+	var formulaValue float64
+	for _, variant := range endpoint.ProductionVariants {
+		period := int64(metric.Period.Seconds())
+		metricEndTime := now.Add(time.Duration(-metric.StartTime))
+		metricInput := awsCloudwatch.GetMetricStatisticsInput{
+			Namespace: &sm.namespace,
+			Period:    &period,
+			StartTime: &metricEndTime,
+			EndTime:   &now,
+			Dimensions: []*awsCloudwatch.Dimension{
+				{
+					Name:  awsClient.String("EndpointName"),
+					Value: endpoint.EndpointName,
+				},
+				{
+					Name:  awsClient.String("VariantName"),
+					Value: variant.VariantName,
+				},
+			},
+		}
+
+		value, _, err := sm.awsManager.GetCloudWatchClient().GetMetric(&metricInput, metric)
+		if err != nil {
+			return 0, err
+		}
+		formulaValue += value
+	}
+
+	return formulaValue, nil
+}
+
+// getEndpointInstances returns the current instances of the endpoint production variants.
+// Serverless variants are not charged by instance and are skipped.
+func (sm *SageMakerManager) getEndpointInstances(endpoint *sagemaker.DescribeEndpointOutput) []SageMakerInstances {
+
+	instances := []SageMakerInstances{}
+
+	endpointConfig, err := sm.client.DescribeEndpointConfig(&sagemaker.DescribeEndpointConfigInput{
+		EndpointConfigName: endpoint.EndpointConfigName,
+	})
+	if err != nil {
+		log.WithError(err).WithField("name", *endpoint.EndpointName).Error("could not describe sagemaker endpoint config")
+		return instances
+	}
+
+	variantsInstanceType := map[string]string{}
+	for _, variant := range endpointConfig.ProductionVariants {
+		if variant.InstanceType != nil {
+			variantsInstanceType[*variant.VariantName] = *variant.InstanceType
+		}
+	}
+
+	for _, variant := range endpoint.ProductionVariants {
+		instanceType, found := variantsInstanceType[awsClient.StringValue(variant.VariantName)]
+		if !found {
+			continue
+		}
+		instances = append(instances, SageMakerInstances{
+			InstanceType:  instanceType,
+			InstanceCount: awsClient.Int64Value(variant.CurrentInstanceCount),
+		})
+	}
+
+	return instances
+}
+
+// getInstancesHourlyPrice returns the hourly price of the given instances for the usage type component
+func (sm *SageMakerManager) getInstancesHourlyPrice(usageTypeComponent string, instances []SageMakerInstances) float64 {
+
+	var price float64
+	for _, instance := range instances {
+		usageType := fmt.Sprintf("%s:%s", usageTypeComponent, instance.InstanceType)
+
+		instancePrice, found := sm.prices[usageType]
+		if !found {
+			var err error
+			pricingFilters := sm.getPricingFilterInput(usageType)
+			instancePrice, err = sm.awsManager.GetPricingClient().GetPrice(pricingFilters, "", sm.awsManager.GetRegion())
+			if err != nil {
+				log.WithError(err).WithFields(log.Fields{
+					"region":        sm.awsManager.GetRegion(),
+					"price_filters": pricingFilters,
+				}).Error("could not get sagemaker instance price")
+			}
+			sm.prices[usageType] = instancePrice
+		}
+
+		price += instancePrice * float64(instance.InstanceCount)
+	}
+
+	return price
+}
+
+// getTags returns the sagemaker resource tags
+func (sm *SageMakerManager) getTags(resourceArn *string) map[string]string {
+
+	tagsData := map[string]string{}
+	tags, err := sm.client.ListTags(&sagemaker.ListTagsInput{ResourceArn: resourceArn})
+	if err != nil {
+		log.WithError(err).WithField("arn", *resourceArn).Error("could not list sagemaker tags")
+		return tagsData
+	}
+
+	for _, tag := range tags.Tags {
+		tagsData[*tag.Key] = *tag.Value
+	}
+	return tagsData
+}
+
+// getPricingFilterInput prepares the sagemaker pricing filter of the given usage type
+func (sm *SageMakerManager) getPricingFilterInput(usageType string) pricing.GetProductsInput {
+	return pricing.GetProductsInput{
+		ServiceCode: &sm.servicePricingCode,
+		Filters: []*pricing.Filter{
+			{
+				Type:  awsClient.String("TERM_MATCH"),
+				Field: awsClient.String("termType"),
+				Value: awsClient.String("OnDemand"),
+			},
+			{
+				Type:  awsClient.String("TERM_MATCH"),
+				Field: awsClient.String("usagetype"),
+				Value: awsClient.String(usageType),
+			},
+		},
+	}
+}
+
+// listEndpoints returns a list of the in service sagemaker endpoints
+func (sm *SageMakerManager) listEndpoints(nextToken *string, endpoints []*sagemaker.EndpointSummary) ([]*sagemaker.EndpointSummary, error) {
+
+	input := &sagemaker.ListEndpointsInput{
+		NextToken:    nextToken,
+		StatusEquals: awsClient.String(sagemaker.EndpointStatusInService),
+	}
+
+	resp, err := sm.client.ListEndpoints(input)
+	if err != nil {
+		log.WithField("error", err).Error("could not list sagemaker endpoints")
+		return nil, err
+	}
+
+	if endpoints == nil {
+		endpoints = []*sagemaker.EndpointSummary{}
+	}
+
+	endpoints = append(endpoints, resp.Endpoints...)
+
+	if resp.NextToken != nil {
+		return sm.listEndpoints(resp.NextToken, endpoints)
+	}
+
+	return endpoints, nil
+}
+
+// listNotebookInstances returns a list of the in service sagemaker notebook instances
+func (sm *SageMakerManager) listNotebookInstances(nextToken *string, notebooks []*sagemaker.NotebookInstanceSummary) ([]*sagemaker.NotebookInstanceSummary, error) {
+
+	input := &sagemaker.ListNotebookInstancesInput{
+		NextToken:    nextToken,
+		StatusEquals: awsClient.String(sagemaker.NotebookInstanceStatusInService),
+	}
+
+	resp, err := sm.client.ListNotebookInstances(input)
+	if err != nil {
+		log.WithField("error", err).Error("could not list sagemaker notebook instances")
+		return nil, err
+	}
+
+	if notebooks == nil {
+		notebooks = []*sagemaker.NotebookInstanceSummary{}
+	}
+
+	notebooks = append(notebooks, resp.NotebookInstances...)
+
+	if resp.NextToken != nil {
+		return sm.listNotebookInstances(resp.NextToken, notebooks)
+	}
+
+	return notebooks, nil
+}
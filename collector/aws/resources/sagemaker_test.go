@@ -0,0 +1,231 @@
+package resources
+
+import (
+	"errors"
+	"finala/collector/aws/pricing"
+	awsTestutils "finala/collector/aws/testutils"
+	"finala/collector/config"
+	collectorTestutils "finala/collector/testutils"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	awsClient "github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudwatch"
+	"github.com/aws/aws-sdk-go/service/sagemaker"
+)
+
+var defaultSageMakerEndpointsMock = sagemaker.ListEndpointsOutput{
+	Endpoints: []*sagemaker.EndpointSummary{
+		{
+			EndpointName: awsClient.String("endpoint-1"),
+			EndpointArn:  awsClient.String("arn:aws:sagemaker:us-east-1:1:endpoint/endpoint-1"),
+			CreationTime: awsClient.Time(time.Now()),
+		},
+	},
+}
+
+var defaultSageMakerEndpointMock = sagemaker.DescribeEndpointOutput{
+	EndpointName:       awsClient.String("endpoint-1"),
+	EndpointConfigName: awsClient.String("endpoint-config-1"),
+	ProductionVariants: []*sagemaker.ProductionVariantSummary{
+		{
+			VariantName:          awsClient.String("variant-a"),
+			CurrentInstanceCount: awsClient.Int64(2),
+		},
+		{
+			VariantName:          awsClient.String("variant-b"),
+			CurrentInstanceCount: awsClient.Int64(1),
+		},
+	},
+}
+
+var defaultSageMakerEndpointConfigMock = sagemaker.DescribeEndpointConfigOutput{
+	ProductionVariants: []*sagemaker.ProductionVariant{
+		{
+			VariantName:  awsClient.String("variant-a"),
+			InstanceType: awsClient.String("ml.m5.large"),
+		},
+		{
+			VariantName:  awsClient.String("variant-b"),
+			InstanceType: awsClient.String("ml.g4dn.xlarge"),
+		},
+	},
+}
+
+var defaultSageMakerNotebooksMock = sagemaker.ListNotebookInstancesOutput{
+	NotebookInstances: []*sagemaker.NotebookInstanceSummary{
+		{
+			NotebookInstanceName: awsClient.String("notebook-old"),
+			NotebookInstanceArn:  awsClient.String("arn:aws:sagemaker:us-east-1:1:notebook-instance/notebook-old"),
+			InstanceType:         awsClient.String("ml.t3.medium"),
+			LastModifiedTime:     awsClient.Time(time.Now().Add(-72 * time.Hour)),
+		},
+		{
+			NotebookInstanceName: awsClient.String("notebook-new"),
+			NotebookInstanceArn:  awsClient.String("arn:aws:sagemaker:us-east-1:1:notebook-instance/notebook-new"),
+			InstanceType:         awsClient.String("ml.t3.medium"),
+			LastModifiedTime:     awsClient.Time(time.Now().Add(-2 * time.Hour)),
+		},
+	},
+}
+
+type MockAWSSageMakerClient struct {
+	err error
+}
+
+func (r *MockAWSSageMakerClient) ListEndpoints(*sagemaker.ListEndpointsInput) (*sagemaker.ListEndpointsOutput, error) {
+	return &defaultSageMakerEndpointsMock, r.err
+}
+
+func (r *MockAWSSageMakerClient) DescribeEndpoint(*sagemaker.DescribeEndpointInput) (*sagemaker.DescribeEndpointOutput, error) {
+	return &defaultSageMakerEndpointMock, r.err
+}
+
+func (r *MockAWSSageMakerClient) DescribeEndpointConfig(*sagemaker.DescribeEndpointConfigInput) (*sagemaker.DescribeEndpointConfigOutput, error) {
+	return &defaultSageMakerEndpointConfigMock, r.err
+}
+
+func (r *MockAWSSageMakerClient) ListNotebookInstances(*sagemaker.ListNotebookInstancesInput) (*sagemaker.ListNotebookInstancesOutput, error) {
+	return &defaultSageMakerNotebooksMock, r.err
+}
+
+func (r *MockAWSSageMakerClient) ListTags(*sagemaker.ListTagsInput) (*sagemaker.ListTagsOutput, error) {
+	return &sagemaker.ListTagsOutput{
+		Tags: []*sagemaker.Tag{
+			{Key: awsClient.String("team"), Value: awsClient.String("data")},
+		},
+	}, r.err
+}
+
+func TestNewSageMakerManager(t *testing.T) {
+
+	collector := collectorTestutils.NewMockCollector()
+	detector := awsTestutils.AWSManager(collector, nil, nil, "us-east-1")
+
+	sageMakerManager, err := NewSageMakerManager(detector, &MockEmptyClient{})
+	if err == nil {
+		t.Fatalf("unexpected error happened, got nil expected error")
+	}
+	if sageMakerManager != nil {
+		t.Fatalf("unexpected sagemaker manager instance, got %v expected nil", reflect.TypeOf(sageMakerManager))
+	}
+}
+
+func TestDetectSageMaker(t *testing.T) {
+
+	metrics := []config.MetricConfig{
+		{
+			Description: "Endpoint invocations",
+			Data: []config.MetricDataConfiguration{
+				{
+					Name:      "Invocations",
+					Statistic: "Sum",
+				},
+			},
+			Constraint: config.MetricConstraintConfig{
+				Operator: "==",
+				Value:    0,
+			},
+			Period:    24 * time.Hour,
+			StartTime: 168 * time.Hour,
+		},
+		{
+			Description: "Notebook running hours",
+			Constraint: config.MetricConstraintConfig{
+				Operator: ">=",
+				Value:    48,
+			},
+		},
+	}
+
+	t.Run("detect", func(t *testing.T) {
+
+		collector := collectorTestutils.NewMockCollector()
+		mockCloudwatch := awsTestutils.NewMockCloudwatch(&map[string]cloudwatch.GetMetricStatisticsOutput{
+			"Invocations": {
+				Datapoints: []*cloudwatch.Datapoint{
+					{Sum: awsClient.Float64(0)},
+				},
+			},
+		})
+		mockPrice := pricing.NewPricingManager(&MockFilterPricingClient{prices: map[string]float64{
+			"Host:ml.m5.large":    0.1,
+			"Host:ml.g4dn.xlarge": 0.7,
+			"Notebk:ml.t3.medium": 0.05,
+		}}, "us-east-1")
+		detector := awsTestutils.AWSManager(collector, mockCloudwatch, mockPrice, "us-east-1")
+
+		sageMakerManager, err := NewSageMakerManager(detector, &MockAWSSageMakerClient{})
+		if err != nil {
+			t.Fatalf("unexpected sagemaker manager error happened, got %v expected %v", err, nil)
+		}
+
+		response, err := sageMakerManager.Detect(metrics)
+		if err != nil {
+			t.Fatalf("unexpected sagemaker detection error happened, got %v expected %v", err, nil)
+		}
+
+		sageMakerResponse, ok := response.([]DetectedSageMaker)
+		if !ok {
+			t.Fatalf("unexpected sagemaker struct, got %s expected %s", reflect.TypeOf(response), "[]DetectedSageMaker")
+		}
+
+		if len(sageMakerResponse) != 2 {
+			t.Fatalf("unexpected sagemaker detected, got %d expected %d", len(sageMakerResponse), 2)
+		}
+
+		if len(collector.Events) != 2 {
+			t.Fatalf("unexpected collector sagemaker events, got %d expected %d", len(collector.Events), 2)
+		}
+
+		testCases := []struct {
+			name         string
+			resourceType string
+			pricePerHour float64
+		}{
+			{"endpoint-1", sageMakerEndpointType, 2*0.1 + 0.7},
+			{"notebook-old", sageMakerNotebookType, 0.05},
+		}
+
+		for i, test := range testCases {
+			detected := sageMakerResponse[i]
+			if detected.Name != test.name || detected.ResourceType != test.resourceType {
+				t.Fatalf("unexpected sagemaker resource, got %s/%s expected %s/%s", detected.Name, detected.ResourceType, test.name, test.resourceType)
+			}
+			if fmt.Sprintf("%.2f", detected.PricePerHour) != fmt.Sprintf("%.2f", test.pricePerHour) {
+				t.Fatalf("unexpected %s price per hour, got %f expected %f", test.name, detected.PricePerHour, test.pricePerHour)
+			}
+			if detected.Tag["team"] != "data" {
+				t.Fatalf("unexpected %s tags, got %v expected %s", test.name, detected.Tag, "team=data")
+			}
+		}
+
+		// All the described endpoints and notebooks are examined, regardless of their detection
+		if len(detector.ExaminedResources["aws_sagemaker"]) != 3 {
+			t.Fatalf("unexpected examined sagemaker resources, got %d expected %d", len(detector.ExaminedResources["aws_sagemaker"]), 3)
+		}
+	})
+
+	t.Run("detection error", func(t *testing.T) {
+
+		collector := collectorTestutils.NewMockCollector()
+		mockPrice := awsTestutils.NewMockPricing(nil)
+		detector := awsTestutils.AWSManager(collector, nil, mockPrice, "us-east-1")
+
+		sageMakerManager, err := NewSageMakerManager(detector, &MockAWSSageMakerClient{err: errors.New("error")})
+		if err != nil {
+			t.Fatalf("unexpected sagemaker manager error happened, got %v expected %v", err, nil)
+		}
+
+		_, err = sageMakerManager.Detect(metrics)
+		if err == nil {
+			t.Fatalf("unexpected detection sagemaker manager error, got nil expected error message")
+		}
+
+		if len(collector.EventsCollectionStatus) != 2 {
+			t.Fatalf("unexpected resource status events count, got %d expected %d", len(collector.EventsCollectionStatus), 2)
+		}
+	})
+}
@@ -0,0 +1,265 @@
+package resources
+
+import (
+	"errors"
+	"finala/collector"
+	"finala/collector/aws/common"
+	"finala/collector/aws/register"
+	"finala/collector/config"
+	"finala/expression"
+	"fmt"
+	"time"
+
+	awsClient "github.com/aws/aws-sdk-go/aws"
+	awsCloudwatch "github.com/aws/aws-sdk-go/service/cloudwatch"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/pricing"
+	log "github.com/sirupsen/logrus"
+)
+
+// TransitGatewayClientDescriptor is an interface defining the aws transit gateway client
+type TransitGatewayClientDescriptor interface {
+	DescribeTransitGatewayAttachments(*ec2.DescribeTransitGatewayAttachmentsInput) (*ec2.DescribeTransitGatewayAttachmentsOutput, error)
+}
+
+// TransitGatewayAttachmentManager describes the transit gateway attachments struct
+type TransitGatewayAttachmentManager struct {
+	client             TransitGatewayClientDescriptor
+	awsManager         common.AWSManager
+	namespace          string
+	servicePricingCode string
+	Name               collector.ResourceIdentifier
+}
+
+// DetectedTransitGatewayAttachment defines the detected AWS transit gateway attachments
+type DetectedTransitGatewayAttachment struct {
+	Metric           string
+	TransitGatewayID string
+	ResourceType     string
+	AttachedResource string
+	VPCID            string
+	collector.PriceDetectedFields
+}
+
+func init() {
+	register.Registry("tgw_attachments", NewTransitGatewayAttachmentManager)
+}
+
+// NewTransitGatewayAttachmentManager implements AWS GO SDK for ec2 transit gateway attachments
+func NewTransitGatewayAttachmentManager(awsManager common.AWSManager, client interface{}) (common.ResourceDetection, error) {
+
+	if client == nil {
+		client = ec2.New(awsManager.GetSession())
+	}
+
+	transitGatewayClient, ok := client.(TransitGatewayClientDescriptor)
+	if !ok {
+		return nil, errors.New("invalid transit gateway client")
+	}
+
+	return &TransitGatewayAttachmentManager{
+		client:             transitGatewayClient,
+		awsManager:         awsManager,
+		namespace:          "AWS/TransitGateway",
+		servicePricingCode: "AmazonVPC",
+		Name:               awsManager.GetResourceIdentifier("tgw_attachments"),
+	}, nil
+}
+
+// Detect checks if transit gateway attachments have no traffic
+func (tg *TransitGatewayAttachmentManager) Detect(metrics []config.MetricConfig) (interface{}, error) {
+
+	log.WithFields(log.Fields{
+		"region":   tg.awsManager.GetRegion(),
+		"resource": "tgw_attachments",
+	}).Info("analyzing resource")
+
+	tg.awsManager.GetCollector().CollectStart(tg.Name)
+
+	detectedAttachments := []DetectedTransitGatewayAttachment{}
+
+	pricingRegionPrefix, err := tg.awsManager.GetPricingClient().GetRegionPrefix(tg.awsManager.GetRegion())
+	if err != nil {
+		log.WithError(err).WithFields(log.Fields{
+			"region": tg.awsManager.GetRegion(),
+		}).Error("Could not get pricing region prefix")
+		tg.awsManager.GetCollector().CollectError(tg.Name, err)
+		return detectedAttachments, err
+	}
+
+	pricingFilters := tg.getPricingFilterInput(pricingRegionPrefix)
+	// Get transit gateway attachment pricing
+	price, err := tg.awsManager.GetPricingClient().GetPrice(pricingFilters, "", tg.awsManager.GetRegion())
+	if err != nil {
+		log.WithError(err).WithFields(log.Fields{
+			"region":        tg.awsManager.GetRegion(),
+			"price_filters": pricingFilters,
+		}).Error("could not get transit gateway attachment price")
+		tg.awsManager.GetCollector().CollectError(tg.Name, err)
+		return detectedAttachments, err
+	}
+
+	attachments, err := tg.describeTransitGatewayAttachments(nil, nil)
+	if err != nil {
+		tg.awsManager.GetCollector().CollectError(tg.Name, err)
+		return detectedAttachments, err
+	}
+
+	now := time.Now()
+
+	for _, attachment := range attachments {
+		log.WithField("attachment_id", *attachment.TransitGatewayAttachmentId).Debug("checking transit gateway attachment")
+
+		tagsData := map[string]string{}
+		for _, tag := range attachment.Tags {
+			tagsData[*tag.Key] = *tag.Value
+		}
+
+		tg.awsManager.ExamineResource(tg.Name, func() collector.PriceDetectedFields {
+			return collector.PriceDetectedFields{
+				LaunchTime:    awsClient.TimeValue(attachment.CreationTime),
+				ResourceID:    *attachment.TransitGatewayAttachmentId,
+				PricePerHour:  price,
+				PricePerMonth: price * collector.TotalMonthHours,
+				Tag:           tagsData,
+			}
+		})
+
+		for _, metric := range metrics {
+			log.WithFields(log.Fields{
+				"attachment_id": *attachment.TransitGatewayAttachmentId,
+				"metric_name":   metric.Description,
+			}).Debug("checking metric")
+
+			period := int64(metric.Period.Seconds())
+			metricEndTime := now.Add(time.Duration(-metric.StartTime))
+			metricInput := awsCloudwatch.GetMetricStatisticsInput{
+				Namespace:  &tg.namespace,
+				MetricName: &metric.Description,
+				Period:     &period,
+				StartTime:  &metricEndTime,
+				EndTime:    &now,
+				Dimensions: []*awsCloudwatch.Dimension{
+					{
+						Name:  awsClient.String("TransitGateway"),
+						Value: attachment.TransitGatewayId,
+					},
+					{
+						Name:  awsClient.String("TransitGatewayAttachment"),
+						Value: attachment.TransitGatewayAttachmentId,
+					},
+				},
+			}
+
+			formulaValue, _, err := tg.awsManager.GetCloudWatchClient().GetMetric(&metricInput, metric)
+			if err != nil {
+				log.WithError(err).WithFields(log.Fields{
+					"attachment_id": *attachment.TransitGatewayAttachmentId,
+					"metric_name":   metric.Description,
+				}).Error("Could not get cloudwatch metric data")
+				continue
+			}
+
+			expression, err := expression.BoolExpression(formulaValue, metric.Constraint.Value, metric.Constraint.Operator)
+			if err != nil {
+				log.WithField("error", err).Error("could not parse expression")
+				continue
+			}
+
+			if expression {
+				log.WithFields(log.Fields{
+					"metric_name":         metric.Description,
+					"constraint_operator": metric.Constraint.Operator,
+					"constraint_Value":    metric.Constraint.Value,
+					"formula_value":       formulaValue,
+					"attachment_id":       *attachment.TransitGatewayAttachmentId,
+					"region":              tg.awsManager.GetRegion(),
+				}).Info("Transit gateway attachment detected as unutilized resource")
+
+				var vpcID string
+				if awsClient.StringValue(attachment.ResourceType) == ec2.TransitGatewayAttachmentResourceTypeVpc {
+					vpcID = awsClient.StringValue(attachment.ResourceId)
+				}
+
+				detectedAttachment := DetectedTransitGatewayAttachment{
+					Metric:           metric.Description,
+					TransitGatewayID: *attachment.TransitGatewayId,
+					ResourceType:     awsClient.StringValue(attachment.ResourceType),
+					AttachedResource: awsClient.StringValue(attachment.ResourceId),
+					VPCID:            vpcID,
+					PriceDetectedFields: collector.PriceDetectedFields{
+						LaunchTime:        awsClient.TimeValue(attachment.CreationTime),
+						ResourceID:        *attachment.TransitGatewayAttachmentId,
+						ARN:               tg.awsManager.GetARN("ec2", fmt.Sprintf("transit-gateway-attachment/%s", *attachment.TransitGatewayAttachmentId)),
+						ConsoleURL:        tg.awsManager.GetConsoleURL("vpc/home", fmt.Sprintf("TransitGatewayAttachmentDetails:transitGatewayAttachmentId=%s", *attachment.TransitGatewayAttachmentId)),
+						PricePerHour:      price,
+						PricePerMonth:     price * collector.TotalMonthHours,
+						Tag:               tagsData,
+						AccountDimensions: tg.awsManager.GetAccountDimensions(),
+					},
+				}
+
+				tg.awsManager.GetCollector().AddResource(collector.EventCollector{
+					ResourceName: tg.Name,
+					Data:         detectedAttachment,
+				})
+
+				detectedAttachments = append(detectedAttachments, detectedAttachment)
+			}
+		}
+	}
+
+	tg.awsManager.GetCollector().CollectFinish(tg.Name)
+
+	return detectedAttachments, nil
+}
+
+// getPricingFilterInput prepares the right filter for transit gateway attachments
+func (tg *TransitGatewayAttachmentManager) getPricingFilterInput(pricingRegionPrefix string) pricing.GetProductsInput {
+	return pricing.GetProductsInput{
+		ServiceCode: &tg.servicePricingCode,
+		Filters: []*pricing.Filter{
+			{
+				Type:  awsClient.String("TERM_MATCH"),
+				Field: awsClient.String("termType"),
+				Value: awsClient.String("OnDemand"),
+			},
+			{
+				Type:  awsClient.String("TERM_MATCH"),
+				Field: awsClient.String("usagetype"),
+				Value: awsClient.String(fmt.Sprintf("%sTransitGateway-Hours", pricingRegionPrefix)),
+			},
+		},
+	}
+}
+
+// describeTransitGatewayAttachments returns a list of the available transit gateway attachments
+func (tg *TransitGatewayAttachmentManager) describeTransitGatewayAttachments(nextToken *string, attachments []*ec2.TransitGatewayAttachment) ([]*ec2.TransitGatewayAttachment, error) {
+	input := &ec2.DescribeTransitGatewayAttachmentsInput{
+		NextToken: nextToken,
+		Filters: []*ec2.Filter{
+			{
+				Name:   awsClient.String("state"),
+				Values: []*string{awsClient.String(ec2.TransitGatewayAttachmentStateAvailable)},
+			},
+		},
+	}
+
+	resp, err := tg.client.DescribeTransitGatewayAttachments(input)
+	if err != nil {
+		log.WithField("error", err).Error("could not describe transit gateway attachments")
+		return nil, err
+	}
+
+	if attachments == nil {
+		attachments = []*ec2.TransitGatewayAttachment{}
+	}
+
+	attachments = append(attachments, resp.TransitGatewayAttachments...)
+
+	if resp.NextToken != nil {
+		return tg.describeTransitGatewayAttachments(resp.NextToken, attachments)
+	}
+
+	return attachments, nil
+}
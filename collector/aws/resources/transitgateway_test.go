@@ -0,0 +1,112 @@
+package resources
+
+import (
+	"errors"
+	awsTestutils "finala/collector/aws/testutils"
+	collectorTestutils "finala/collector/testutils"
+	"reflect"
+	"testing"
+	"time"
+
+	awsClient "github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+)
+
+var defaultTransitGatewayAttachmentsMock = ec2.DescribeTransitGatewayAttachmentsOutput{
+	TransitGatewayAttachments: []*ec2.TransitGatewayAttachment{
+		{
+			TransitGatewayAttachmentId: awsClient.String("tgw-attach-1"),
+			TransitGatewayId:           awsClient.String("tgw-1"),
+			ResourceType:               awsClient.String("vpc"),
+			ResourceId:                 awsClient.String("vpc-1"),
+			CreationTime:               collectorTestutils.TimePointer(time.Now()),
+		},
+		{
+			TransitGatewayAttachmentId: awsClient.String("tgw-attach-2"),
+			TransitGatewayId:           awsClient.String("tgw-1"),
+			ResourceType:               awsClient.String("vpn"),
+			ResourceId:                 awsClient.String("vpn-1"),
+			CreationTime:               collectorTestutils.TimePointer(time.Now()),
+		},
+	},
+}
+
+type MockAWSTransitGatewayClient struct {
+	err error
+}
+
+func (r *MockAWSTransitGatewayClient) DescribeTransitGatewayAttachments(*ec2.DescribeTransitGatewayAttachmentsInput) (*ec2.DescribeTransitGatewayAttachmentsOutput, error) {
+	return &defaultTransitGatewayAttachmentsMock, r.err
+}
+
+func TestNewTransitGatewayAttachmentManager(t *testing.T) {
+
+	collector := collectorTestutils.NewMockCollector()
+	detector := awsTestutils.AWSManager(collector, nil, nil, "us-east-1")
+
+	attachmentManager, err := NewTransitGatewayAttachmentManager(detector, &MockEmptyClient{})
+	if err == nil {
+		t.Fatalf("unexpected error happened, got nil expected error")
+	}
+	if attachmentManager != nil {
+		t.Fatalf("unexpected transit gateway attachment manager instance, got %v expected nil", reflect.TypeOf(attachmentManager))
+	}
+}
+
+func TestDetectTransitGatewayAttachments(t *testing.T) {
+
+	t.Run("detect transit gateway attachments", func(t *testing.T) {
+		collector := collectorTestutils.NewMockCollector()
+		mockCloudwatch := awsTestutils.NewMockCloudwatch(nil)
+		mockPrice := awsTestutils.NewMockPricing(nil)
+		detector := awsTestutils.AWSManager(collector, mockCloudwatch, mockPrice, "us-east-1")
+
+		attachmentManager, err := NewTransitGatewayAttachmentManager(detector, &MockAWSTransitGatewayClient{})
+		if err != nil {
+			t.Fatalf("unexpected transit gateway attachment manager error happened, got %v expected %v", err, nil)
+		}
+
+		response, err := attachmentManager.Detect(awsTestutils.DefaultMetricConfig)
+		if err != nil {
+			t.Fatalf("unexpected transit gateway attachment detection error happened, got %v expected %v", err, nil)
+		}
+
+		attachmentResponse, ok := response.([]DetectedTransitGatewayAttachment)
+		if !ok {
+			t.Fatalf("unexpected transit gateway attachment struct, got %s expected %s", reflect.TypeOf(response), "[]DetectedTransitGatewayAttachment")
+		}
+
+		if len(attachmentResponse) != 2 {
+			t.Fatalf("unexpected transit gateway attachments detected, got %d expected %d", len(attachmentResponse), 2)
+		}
+
+		if len(collector.Events) != 2 {
+			t.Fatalf("unexpected collector transit gateway attachment events, got %d expected %d", len(collector.Events), 2)
+		}
+
+		if attachmentResponse[0].VPCID != "vpc-1" {
+			t.Fatalf("unexpected vpc attachment VPC id, got %s expected %s", attachmentResponse[0].VPCID, "vpc-1")
+		}
+
+		if attachmentResponse[1].VPCID != "" {
+			t.Fatalf("unexpected vpn attachment VPC id, got %s expected empty", attachmentResponse[1].VPCID)
+		}
+	})
+
+	t.Run("detection error", func(t *testing.T) {
+		collector := collectorTestutils.NewMockCollector()
+		mockCloudwatch := awsTestutils.NewMockCloudwatch(nil)
+		mockPrice := awsTestutils.NewMockPricing(nil)
+		detector := awsTestutils.AWSManager(collector, mockCloudwatch, mockPrice, "us-east-1")
+
+		attachmentManager, err := NewTransitGatewayAttachmentManager(detector, &MockAWSTransitGatewayClient{err: errors.New("error")})
+		if err != nil {
+			t.Fatalf("unexpected transit gateway attachment manager error happened, got %v expected %v", err, nil)
+		}
+
+		_, err = attachmentManager.Detect(awsTestutils.DefaultMetricConfig)
+		if err == nil {
+			t.Fatalf("unexpected detection transit gateway attachment manager error, got nil expected error message")
+		}
+	})
+}
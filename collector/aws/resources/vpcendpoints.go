@@ -0,0 +1,280 @@
+package resources
+
+import (
+	"errors"
+	"finala/collector"
+	"finala/collector/aws/common"
+	"finala/collector/aws/register"
+	"finala/collector/config"
+	"finala/expression"
+	"fmt"
+	"time"
+
+	awsClient "github.com/aws/aws-sdk-go/aws"
+	awsCloudwatch "github.com/aws/aws-sdk-go/service/cloudwatch"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/pricing"
+	log "github.com/sirupsen/logrus"
+)
+
+// VPCEndpointClientDescriptor is an interface defining the aws vpc endpoint client
+type VPCEndpointClientDescriptor interface {
+	DescribeVpcEndpoints(*ec2.DescribeVpcEndpointsInput) (*ec2.DescribeVpcEndpointsOutput, error)
+}
+
+// VPCEndpointManager describes the interface VPC endpoints struct
+type VPCEndpointManager struct {
+	client             VPCEndpointClientDescriptor
+	awsManager         common.AWSManager
+	namespace          string
+	servicePricingCode string
+	Name               collector.ResourceIdentifier
+}
+
+// DetectedVPCEndpoint defines the detected AWS interface VPC endpoints
+type DetectedVPCEndpoint struct {
+	Metric            string
+	ServiceName       string
+	VPCID             string
+	AvailabilityZones int
+	collector.PriceDetectedFields
+}
+
+func init() {
+	register.Registry("vpc_endpoints", NewVPCEndpointManager)
+}
+
+// NewVPCEndpointManager implements AWS GO SDK for ec2 VPC endpoints
+func NewVPCEndpointManager(awsManager common.AWSManager, client interface{}) (common.ResourceDetection, error) {
+
+	if client == nil {
+		client = ec2.New(awsManager.GetSession())
+	}
+
+	vpcEndpointClient, ok := client.(VPCEndpointClientDescriptor)
+	if !ok {
+		return nil, errors.New("invalid VPC endpoint client")
+	}
+
+	return &VPCEndpointManager{
+		client:             vpcEndpointClient,
+		awsManager:         awsManager,
+		namespace:          "AWS/PrivateLinkEndpoints",
+		servicePricingCode: "AmazonVPC",
+		Name:               awsManager.GetResourceIdentifier("vpc_endpoints"),
+	}, nil
+}
+
+// Detect checks if interface VPC endpoints are under utilized
+func (ve *VPCEndpointManager) Detect(metrics []config.MetricConfig) (interface{}, error) {
+
+	log.WithFields(log.Fields{
+		"region":   ve.awsManager.GetRegion(),
+		"resource": "vpc_endpoints",
+	}).Info("analyzing resource")
+
+	ve.awsManager.GetCollector().CollectStart(ve.Name)
+
+	detectedVPCEndpoints := []DetectedVPCEndpoint{}
+
+	pricingRegionPrefix, err := ve.awsManager.GetPricingClient().GetRegionPrefix(ve.awsManager.GetRegion())
+	if err != nil {
+		log.WithError(err).WithFields(log.Fields{
+			"region": ve.awsManager.GetRegion(),
+		}).Error("Could not get pricing region prefix")
+		ve.awsManager.GetCollector().CollectError(ve.Name, err)
+		return detectedVPCEndpoints, err
+	}
+
+	pricingFilters := ve.getPricingFilterInput(pricingRegionPrefix)
+	// Get the interface VPC endpoint hourly price of a single availability zone
+	price, err := ve.awsManager.GetPricingClient().GetPrice(pricingFilters, "", ve.awsManager.GetRegion())
+	if err != nil {
+		log.WithError(err).WithFields(log.Fields{
+			"region":        ve.awsManager.GetRegion(),
+			"price_filters": pricingFilters,
+		}).Error("could not get VPC endpoint price")
+		ve.awsManager.GetCollector().CollectError(ve.Name, err)
+		return detectedVPCEndpoints, err
+	}
+
+	vpcEndpoints, err := ve.describeVpcEndpoints(nil, nil)
+	if err != nil {
+		ve.awsManager.GetCollector().CollectError(ve.Name, err)
+		return detectedVPCEndpoints, err
+	}
+
+	now := time.Now()
+
+	for _, vpcEndpoint := range vpcEndpoints {
+		log.WithField("vpc_endpoint_id", *vpcEndpoint.VpcEndpointId).Debug("checking VPC endpoint")
+
+		tagsData := map[string]string{}
+		for _, tag := range vpcEndpoint.Tags {
+			tagsData[*tag.Key] = *tag.Value
+		}
+
+		// Interface endpoints are charged per availability zone they are provisioned in
+		availabilityZones := len(vpcEndpoint.SubnetIds)
+		pricePerHour := price * float64(availabilityZones)
+
+		ve.awsManager.ExamineResource(ve.Name, func() collector.PriceDetectedFields {
+			return collector.PriceDetectedFields{
+				LaunchTime:    awsClient.TimeValue(vpcEndpoint.CreationTimestamp),
+				ResourceID:    *vpcEndpoint.VpcEndpointId,
+				PricePerHour:  pricePerHour,
+				PricePerMonth: pricePerHour * collector.TotalMonthHours,
+				Tag:           tagsData,
+			}
+		})
+
+		for _, metric := range metrics {
+			log.WithFields(log.Fields{
+				"vpc_endpoint_id": *vpcEndpoint.VpcEndpointId,
+				"metric_name":     metric.Description,
+			}).Debug("checking metric")
+
+			period := int64(metric.Period.Seconds())
+			metricEndTime := now.Add(time.Duration(-metric.StartTime))
+			metricInput := awsCloudwatch.GetMetricStatisticsInput{
+				Namespace:  &ve.namespace,
+				MetricName: &metric.Description,
+				Period:     &period,
+				StartTime:  &metricEndTime,
+				EndTime:    &now,
+				Dimensions: []*awsCloudwatch.Dimension{
+					{
+						Name:  awsClient.String("Endpoint Type"),
+						Value: vpcEndpoint.VpcEndpointType,
+					},
+					{
+						Name:  awsClient.String("Service Name"),
+						Value: vpcEndpoint.ServiceName,
+					},
+					{
+						Name:  awsClient.String("VPC Endpoint Id"),
+						Value: vpcEndpoint.VpcEndpointId,
+					},
+					{
+						Name:  awsClient.String("VPC Id"),
+						Value: vpcEndpoint.VpcId,
+					},
+				},
+			}
+
+			formulaValue, _, err := ve.awsManager.GetCloudWatchClient().GetMetric(&metricInput, metric)
+			if err != nil {
+				log.WithError(err).WithFields(log.Fields{
+					"vpc_endpoint_id": *vpcEndpoint.VpcEndpointId,
+					"metric_name":     metric.Description,
+				}).Error("Could not get cloudwatch metric data")
+				continue
+			}
+
+			expression, err := expression.BoolExpression(formulaValue, metric.Constraint.Value, metric.Constraint.Operator)
+			if err != nil {
+				log.WithField("error", err).Error("could not parse expression")
+				continue
+			}
+
+			if expression {
+				log.WithFields(log.Fields{
+					"metric_name":         metric.Description,
+					"constraint_operator": metric.Constraint.Operator,
+					"constraint_Value":    metric.Constraint.Value,
+					"formula_value":       formulaValue,
+					"vpc_endpoint_id":     *vpcEndpoint.VpcEndpointId,
+					"vpc":                 *vpcEndpoint.VpcId,
+					"region":              ve.awsManager.GetRegion(),
+				}).Info("VPC endpoint detected as unutilized resource")
+
+				detectedVPCEndpoint := DetectedVPCEndpoint{
+					Metric:            metric.Description,
+					ServiceName:       *vpcEndpoint.ServiceName,
+					VPCID:             *vpcEndpoint.VpcId,
+					AvailabilityZones: availabilityZones,
+					PriceDetectedFields: collector.PriceDetectedFields{
+						LaunchTime:        awsClient.TimeValue(vpcEndpoint.CreationTimestamp),
+						ResourceID:        *vpcEndpoint.VpcEndpointId,
+						ARN:               ve.awsManager.GetARN("ec2", fmt.Sprintf("vpc-endpoint/%s", *vpcEndpoint.VpcEndpointId)),
+						ConsoleURL:        ve.awsManager.GetConsoleURL("vpc/home", fmt.Sprintf("EndpointDetails:vpcEndpointId=%s", *vpcEndpoint.VpcEndpointId)),
+						PricePerHour:      pricePerHour,
+						PricePerMonth:     pricePerHour * collector.TotalMonthHours,
+						Tag:               tagsData,
+						AccountDimensions: ve.awsManager.GetAccountDimensions(),
+					},
+				}
+
+				ve.awsManager.GetCollector().AddResource(collector.EventCollector{
+					ResourceName: ve.Name,
+					Data:         detectedVPCEndpoint,
+				})
+
+				detectedVPCEndpoints = append(detectedVPCEndpoints, detectedVPCEndpoint)
+			}
+		}
+	}
+
+	ve.awsManager.GetCollector().CollectFinish(ve.Name)
+
+	return detectedVPCEndpoints, nil
+}
+
+// getPricingFilterInput prepares the right filter for interface VPC endpoints
+func (ve *VPCEndpointManager) getPricingFilterInput(pricingRegionPrefix string) pricing.GetProductsInput {
+	return pricing.GetProductsInput{
+		ServiceCode: &ve.servicePricingCode,
+		Filters: []*pricing.Filter{
+			{
+				Type:  awsClient.String("TERM_MATCH"),
+				Field: awsClient.String("productFamily"),
+				Value: awsClient.String("VpcEndpoint"),
+			},
+			{
+				Type:  awsClient.String("TERM_MATCH"),
+				Field: awsClient.String("termType"),
+				Value: awsClient.String("OnDemand"),
+			},
+			{
+				Type:  awsClient.String("TERM_MATCH"),
+				Field: awsClient.String("usagetype"),
+				Value: awsClient.String(fmt.Sprintf("%sVpcEndpoint-Hours", pricingRegionPrefix)),
+			},
+		},
+	}
+}
+
+// describeVpcEndpoints returns a list of the available interface VPC endpoints
+func (ve *VPCEndpointManager) describeVpcEndpoints(nextToken *string, vpcEndpoints []*ec2.VpcEndpoint) ([]*ec2.VpcEndpoint, error) {
+	input := &ec2.DescribeVpcEndpointsInput{
+		NextToken: nextToken,
+		Filters: []*ec2.Filter{
+			{
+				Name:   awsClient.String("vpc-endpoint-type"),
+				Values: []*string{awsClient.String(ec2.VpcEndpointTypeInterface)},
+			},
+			{
+				Name:   awsClient.String("vpc-endpoint-state"),
+				Values: []*string{awsClient.String("available")},
+			},
+		},
+	}
+
+	resp, err := ve.client.DescribeVpcEndpoints(input)
+	if err != nil {
+		log.WithField("error", err).Error("could not describe VPC endpoints")
+		return nil, err
+	}
+
+	if vpcEndpoints == nil {
+		vpcEndpoints = []*ec2.VpcEndpoint{}
+	}
+
+	vpcEndpoints = append(vpcEndpoints, resp.VpcEndpoints...)
+
+	if resp.NextToken != nil {
+		return ve.describeVpcEndpoints(resp.NextToken, vpcEndpoints)
+	}
+
+	return vpcEndpoints, nil
+}
@@ -0,0 +1,113 @@
+package resources
+
+import (
+	"errors"
+	awsTestutils "finala/collector/aws/testutils"
+	collectorTestutils "finala/collector/testutils"
+	"reflect"
+	"testing"
+	"time"
+
+	awsClient "github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+)
+
+var defaultVPCEndpointsMock = ec2.DescribeVpcEndpointsOutput{
+	VpcEndpoints: []*ec2.VpcEndpoint{
+		{
+			VpcEndpointId:     awsClient.String("vpce-1"),
+			VpcEndpointType:   awsClient.String("Interface"),
+			ServiceName:       awsClient.String("com.amazonaws.us-east-1.ssm"),
+			VpcId:             awsClient.String("vpc-1"),
+			SubnetIds:         awsClient.StringSlice([]string{"subnet-1", "subnet-2"}),
+			CreationTimestamp: collectorTestutils.TimePointer(time.Now()),
+			Tags: []*ec2.Tag{
+				{
+					Key:   awsClient.String("team"),
+					Value: awsClient.String("testeam-1"),
+				},
+			},
+		},
+	},
+}
+
+type MockAWSVPCEndpointClient struct {
+	err error
+}
+
+func (r *MockAWSVPCEndpointClient) DescribeVpcEndpoints(*ec2.DescribeVpcEndpointsInput) (*ec2.DescribeVpcEndpointsOutput, error) {
+	return &defaultVPCEndpointsMock, r.err
+}
+
+func TestNewVPCEndpointManager(t *testing.T) {
+
+	collector := collectorTestutils.NewMockCollector()
+	detector := awsTestutils.AWSManager(collector, nil, nil, "us-east-1")
+
+	vpcEndpointManager, err := NewVPCEndpointManager(detector, &MockEmptyClient{})
+	if err == nil {
+		t.Fatalf("unexpected error happened, got nil expected error")
+	}
+	if vpcEndpointManager != nil {
+		t.Fatalf("unexpected VPC endpoint manager instance, got %v expected nil", reflect.TypeOf(vpcEndpointManager))
+	}
+}
+
+func TestDetectVPCEndpoints(t *testing.T) {
+
+	t.Run("detect VPC endpoints", func(t *testing.T) {
+		collector := collectorTestutils.NewMockCollector()
+		mockCloudwatch := awsTestutils.NewMockCloudwatch(nil)
+		mockPrice := awsTestutils.NewMockPricing(nil)
+		detector := awsTestutils.AWSManager(collector, mockCloudwatch, mockPrice, "us-east-1")
+
+		vpcEndpointManager, err := NewVPCEndpointManager(detector, &MockAWSVPCEndpointClient{})
+		if err != nil {
+			t.Fatalf("unexpected VPC endpoint manager error happened, got %v expected %v", err, nil)
+		}
+
+		response, err := vpcEndpointManager.Detect(awsTestutils.DefaultMetricConfig)
+		if err != nil {
+			t.Fatalf("unexpected VPC endpoint detection error happened, got %v expected %v", err, nil)
+		}
+
+		vpcEndpointResponse, ok := response.([]DetectedVPCEndpoint)
+		if !ok {
+			t.Fatalf("unexpected VPC endpoint struct, got %s expected %s", reflect.TypeOf(response), "[]DetectedVPCEndpoint")
+		}
+
+		if len(vpcEndpointResponse) != 1 {
+			t.Fatalf("unexpected VPC endpoints detected, got %d expected %d", len(vpcEndpointResponse), 1)
+		}
+
+		if len(collector.Events) != 1 {
+			t.Fatalf("unexpected collector VPC endpoint events, got %d expected %d", len(collector.Events), 1)
+		}
+
+		if vpcEndpointResponse[0].VPCID != "vpc-1" {
+			t.Fatalf("unexpected VPC id, got %s expected %s", vpcEndpointResponse[0].VPCID, "vpc-1")
+		}
+
+		// The endpoint is charged for each of its availability zones
+		if vpcEndpointResponse[0].PricePerHour != 2 {
+			t.Fatalf("unexpected price per hour, got %f expected %f", vpcEndpointResponse[0].PricePerHour, 2.0)
+		}
+	})
+
+	t.Run("detection error", func(t *testing.T) {
+		collector := collectorTestutils.NewMockCollector()
+		mockCloudwatch := awsTestutils.NewMockCloudwatch(nil)
+		mockPrice := awsTestutils.NewMockPricing(nil)
+		detector := awsTestutils.AWSManager(collector, mockCloudwatch, mockPrice, "us-east-1")
+
+		vpcEndpointManager, err := NewVPCEndpointManager(detector, &MockAWSVPCEndpointClient{err: errors.New("error")})
+		if err != nil {
+			t.Fatalf("unexpected VPC endpoint manager error happened, got %v expected %v", err, nil)
+		}
+
+		_, err = vpcEndpointManager.Detect(awsTestutils.DefaultMetricConfig)
+		if err == nil {
+			t.Fatalf("unexpected detection VPC endpoint manager error, got nil expected error message")
+		}
+	})
+}
@@ -0,0 +1,271 @@
+package resources
+
+import (
+	"errors"
+	"finala/collector"
+	"finala/collector/aws/common"
+	"finala/collector/aws/register"
+	"finala/collector/config"
+	"finala/expression"
+	"fmt"
+	"time"
+
+	awsClient "github.com/aws/aws-sdk-go/aws"
+	awsCloudwatch "github.com/aws/aws-sdk-go/service/cloudwatch"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/pricing"
+	log "github.com/sirupsen/logrus"
+)
+
+// VPNConnectionClientDescriptor is an interface defining the aws site-to-site VPN client
+type VPNConnectionClientDescriptor interface {
+	DescribeVpnConnections(*ec2.DescribeVpnConnectionsInput) (*ec2.DescribeVpnConnectionsOutput, error)
+	DescribeVpnGateways(*ec2.DescribeVpnGatewaysInput) (*ec2.DescribeVpnGatewaysOutput, error)
+}
+
+// VPNConnectionManager describes the site-to-site VPN connections struct
+type VPNConnectionManager struct {
+	client             VPNConnectionClientDescriptor
+	awsManager         common.AWSManager
+	namespace          string
+	servicePricingCode string
+	Name               collector.ResourceIdentifier
+}
+
+// DetectedVPNConnection defines the detected AWS site-to-site VPN connections
+type DetectedVPNConnection struct {
+	Metric            string
+	CustomerGatewayID string
+	VPNGatewayID      string
+	TransitGatewayID  string
+	VPCID             string
+	collector.PriceDetectedFields
+}
+
+func init() {
+	register.Registry("vpn_connections", NewVPNConnectionManager)
+}
+
+// NewVPNConnectionManager implements AWS GO SDK for ec2 site-to-site VPN connections
+func NewVPNConnectionManager(awsManager common.AWSManager, client interface{}) (common.ResourceDetection, error) {
+
+	if client == nil {
+		client = ec2.New(awsManager.GetSession())
+	}
+
+	vpnClient, ok := client.(VPNConnectionClientDescriptor)
+	if !ok {
+		return nil, errors.New("invalid VPN connection client")
+	}
+
+	return &VPNConnectionManager{
+		client:             vpnClient,
+		awsManager:         awsManager,
+		namespace:          "AWS/VPN",
+		servicePricingCode: "AmazonVPC",
+		Name:               awsManager.GetResourceIdentifier("vpn_connections"),
+	}, nil
+}
+
+// Detect checks if the site-to-site VPN connection tunnels are down
+func (vc *VPNConnectionManager) Detect(metrics []config.MetricConfig) (interface{}, error) {
+
+	log.WithFields(log.Fields{
+		"region":   vc.awsManager.GetRegion(),
+		"resource": "vpn_connections",
+	}).Info("analyzing resource")
+
+	vc.awsManager.GetCollector().CollectStart(vc.Name)
+
+	detectedVPNConnections := []DetectedVPNConnection{}
+
+	pricingRegionPrefix, err := vc.awsManager.GetPricingClient().GetRegionPrefix(vc.awsManager.GetRegion())
+	if err != nil {
+		log.WithError(err).WithFields(log.Fields{
+			"region": vc.awsManager.GetRegion(),
+		}).Error("Could not get pricing region prefix")
+		vc.awsManager.GetCollector().CollectError(vc.Name, err)
+		return detectedVPNConnections, err
+	}
+
+	pricingFilters := vc.getPricingFilterInput(pricingRegionPrefix)
+	// Get site-to-site VPN connection pricing
+	price, err := vc.awsManager.GetPricingClient().GetPrice(pricingFilters, "", vc.awsManager.GetRegion())
+	if err != nil {
+		log.WithError(err).WithFields(log.Fields{
+			"region":        vc.awsManager.GetRegion(),
+			"price_filters": pricingFilters,
+		}).Error("could not get VPN connection price")
+		vc.awsManager.GetCollector().CollectError(vc.Name, err)
+		return detectedVPNConnections, err
+	}
+
+	vpnConnections, err := vc.describeVpnConnections()
+	if err != nil {
+		vc.awsManager.GetCollector().CollectError(vc.Name, err)
+		return detectedVPNConnections, err
+	}
+
+	vpnGatewaysVPC, err := vc.describeVpnGatewaysVPC()
+	if err != nil {
+		vc.awsManager.GetCollector().CollectError(vc.Name, err)
+		return detectedVPNConnections, err
+	}
+
+	now := time.Now()
+
+	for _, vpnConnection := range vpnConnections {
+		log.WithField("vpn_connection_id", *vpnConnection.VpnConnectionId).Debug("checking VPN connection")
+
+		tagsData := map[string]string{}
+		for _, tag := range vpnConnection.Tags {
+			tagsData[*tag.Key] = *tag.Value
+		}
+
+		vc.awsManager.ExamineResource(vc.Name, func() collector.PriceDetectedFields {
+			return collector.PriceDetectedFields{
+				ResourceID:    *vpnConnection.VpnConnectionId,
+				PricePerHour:  price,
+				PricePerMonth: price * collector.TotalMonthHours,
+				Tag:           tagsData,
+			}
+		})
+
+		for _, metric := range metrics {
+			log.WithFields(log.Fields{
+				"vpn_connection_id": *vpnConnection.VpnConnectionId,
+				"metric_name":       metric.Description,
+			}).Debug("checking metric")
+
+			period := int64(metric.Period.Seconds())
+			metricEndTime := now.Add(time.Duration(-metric.StartTime))
+			metricInput := awsCloudwatch.GetMetricStatisticsInput{
+				Namespace:  &vc.namespace,
+				MetricName: &metric.Description,
+				Period:     &period,
+				StartTime:  &metricEndTime,
+				EndTime:    &now,
+				Dimensions: []*awsCloudwatch.Dimension{
+					{
+						Name:  awsClient.String("VpnId"),
+						Value: vpnConnection.VpnConnectionId,
+					},
+				},
+			}
+
+			formulaValue, _, err := vc.awsManager.GetCloudWatchClient().GetMetric(&metricInput, metric)
+			if err != nil {
+				log.WithError(err).WithFields(log.Fields{
+					"vpn_connection_id": *vpnConnection.VpnConnectionId,
+					"metric_name":       metric.Description,
+				}).Error("Could not get cloudwatch metric data")
+				continue
+			}
+
+			expression, err := expression.BoolExpression(formulaValue, metric.Constraint.Value, metric.Constraint.Operator)
+			if err != nil {
+				log.WithField("error", err).Error("could not parse expression")
+				continue
+			}
+
+			if expression {
+				log.WithFields(log.Fields{
+					"metric_name":         metric.Description,
+					"constraint_operator": metric.Constraint.Operator,
+					"constraint_Value":    metric.Constraint.Value,
+					"formula_value":       formulaValue,
+					"vpn_connection_id":   *vpnConnection.VpnConnectionId,
+					"region":              vc.awsManager.GetRegion(),
+				}).Info("VPN connection detected as unutilized resource")
+
+				detectedVPNConnection := DetectedVPNConnection{
+					Metric:            metric.Description,
+					CustomerGatewayID: awsClient.StringValue(vpnConnection.CustomerGatewayId),
+					VPNGatewayID:      awsClient.StringValue(vpnConnection.VpnGatewayId),
+					TransitGatewayID:  awsClient.StringValue(vpnConnection.TransitGatewayId),
+					VPCID:             vpnGatewaysVPC[awsClient.StringValue(vpnConnection.VpnGatewayId)],
+					PriceDetectedFields: collector.PriceDetectedFields{
+						ResourceID:        *vpnConnection.VpnConnectionId,
+						ARN:               vc.awsManager.GetARN("ec2", fmt.Sprintf("vpn-connection/%s", *vpnConnection.VpnConnectionId)),
+						ConsoleURL:        vc.awsManager.GetConsoleURL("vpc/home", fmt.Sprintf("VpnConnectionDetails:VpnConnectionId=%s", *vpnConnection.VpnConnectionId)),
+						PricePerHour:      price,
+						PricePerMonth:     price * collector.TotalMonthHours,
+						Tag:               tagsData,
+						AccountDimensions: vc.awsManager.GetAccountDimensions(),
+					},
+				}
+
+				vc.awsManager.GetCollector().AddResource(collector.EventCollector{
+					ResourceName: vc.Name,
+					Data:         detectedVPNConnection,
+				})
+
+				detectedVPNConnections = append(detectedVPNConnections, detectedVPNConnection)
+			}
+		}
+	}
+
+	vc.awsManager.GetCollector().CollectFinish(vc.Name)
+
+	return detectedVPNConnections, nil
+}
+
+// getPricingFilterInput prepares the right filter for site-to-site VPN connections
+func (vc *VPNConnectionManager) getPricingFilterInput(pricingRegionPrefix string) pricing.GetProductsInput {
+	return pricing.GetProductsInput{
+		ServiceCode: &vc.servicePricingCode,
+		Filters: []*pricing.Filter{
+			{
+				Type:  awsClient.String("TERM_MATCH"),
+				Field: awsClient.String("termType"),
+				Value: awsClient.String("OnDemand"),
+			},
+			{
+				Type:  awsClient.String("TERM_MATCH"),
+				Field: awsClient.String("usagetype"),
+				Value: awsClient.String(fmt.Sprintf("%sVPN-Usage-Hours:ipsec.1", pricingRegionPrefix)),
+			},
+		},
+	}
+}
+
+// describeVpnConnections returns a list of the available site-to-site VPN connections
+func (vc *VPNConnectionManager) describeVpnConnections() ([]*ec2.VpnConnection, error) {
+	input := &ec2.DescribeVpnConnectionsInput{
+		Filters: []*ec2.Filter{
+			{
+				Name:   awsClient.String("state"),
+				Values: []*string{awsClient.String(ec2.VpnStateAvailable)},
+			},
+		},
+	}
+
+	resp, err := vc.client.DescribeVpnConnections(input)
+	if err != nil {
+		log.WithField("error", err).Error("could not describe VPN connections")
+		return nil, err
+	}
+
+	return resp.VpnConnections, nil
+}
+
+// describeVpnGatewaysVPC returns the attached VPC id of the virtual private gateways, by gateway id
+func (vc *VPNConnectionManager) describeVpnGatewaysVPC() (map[string]string, error) {
+
+	resp, err := vc.client.DescribeVpnGateways(&ec2.DescribeVpnGatewaysInput{})
+	if err != nil {
+		log.WithField("error", err).Error("could not describe VPN gateways")
+		return nil, err
+	}
+
+	vpnGatewaysVPC := map[string]string{}
+	for _, vpnGateway := range resp.VpnGateways {
+		for _, vpcAttachment := range vpnGateway.VpcAttachments {
+			if awsClient.StringValue(vpcAttachment.State) == ec2.AttachmentStatusAttached {
+				vpnGatewaysVPC[*vpnGateway.VpnGatewayId] = awsClient.StringValue(vpcAttachment.VpcId)
+			}
+		}
+	}
+
+	return vpnGatewaysVPC, nil
+}
@@ -0,0 +1,120 @@
+package resources
+
+import (
+	"errors"
+	awsTestutils "finala/collector/aws/testutils"
+	collectorTestutils "finala/collector/testutils"
+	"reflect"
+	"testing"
+
+	awsClient "github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+)
+
+var defaultVPNConnectionsMock = ec2.DescribeVpnConnectionsOutput{
+	VpnConnections: []*ec2.VpnConnection{
+		{
+			VpnConnectionId:   awsClient.String("vpn-1"),
+			CustomerGatewayId: awsClient.String("cgw-1"),
+			VpnGatewayId:      awsClient.String("vgw-1"),
+		},
+	},
+}
+
+var defaultVPNGatewaysMock = ec2.DescribeVpnGatewaysOutput{
+	VpnGateways: []*ec2.VpnGateway{
+		{
+			VpnGatewayId: awsClient.String("vgw-1"),
+			VpcAttachments: []*ec2.VpcAttachment{
+				{
+					VpcId: awsClient.String("vpc-old"),
+					State: awsClient.String("detached"),
+				},
+				{
+					VpcId: awsClient.String("vpc-1"),
+					State: awsClient.String("attached"),
+				},
+			},
+		},
+	},
+}
+
+type MockAWSVPNConnectionClient struct {
+	err error
+}
+
+func (r *MockAWSVPNConnectionClient) DescribeVpnConnections(*ec2.DescribeVpnConnectionsInput) (*ec2.DescribeVpnConnectionsOutput, error) {
+	return &defaultVPNConnectionsMock, r.err
+}
+
+func (r *MockAWSVPNConnectionClient) DescribeVpnGateways(*ec2.DescribeVpnGatewaysInput) (*ec2.DescribeVpnGatewaysOutput, error) {
+	return &defaultVPNGatewaysMock, r.err
+}
+
+func TestNewVPNConnectionManager(t *testing.T) {
+
+	collector := collectorTestutils.NewMockCollector()
+	detector := awsTestutils.AWSManager(collector, nil, nil, "us-east-1")
+
+	vpnConnectionManager, err := NewVPNConnectionManager(detector, &MockEmptyClient{})
+	if err == nil {
+		t.Fatalf("unexpected error happened, got nil expected error")
+	}
+	if vpnConnectionManager != nil {
+		t.Fatalf("unexpected VPN connection manager instance, got %v expected nil", reflect.TypeOf(vpnConnectionManager))
+	}
+}
+
+func TestDetectVPNConnections(t *testing.T) {
+
+	t.Run("detect VPN connections", func(t *testing.T) {
+		collector := collectorTestutils.NewMockCollector()
+		mockCloudwatch := awsTestutils.NewMockCloudwatch(nil)
+		mockPrice := awsTestutils.NewMockPricing(nil)
+		detector := awsTestutils.AWSManager(collector, mockCloudwatch, mockPrice, "us-east-1")
+
+		vpnConnectionManager, err := NewVPNConnectionManager(detector, &MockAWSVPNConnectionClient{})
+		if err != nil {
+			t.Fatalf("unexpected VPN connection manager error happened, got %v expected %v", err, nil)
+		}
+
+		response, err := vpnConnectionManager.Detect(awsTestutils.DefaultMetricConfig)
+		if err != nil {
+			t.Fatalf("unexpected VPN connection detection error happened, got %v expected %v", err, nil)
+		}
+
+		vpnConnectionResponse, ok := response.([]DetectedVPNConnection)
+		if !ok {
+			t.Fatalf("unexpected VPN connection struct, got %s expected %s", reflect.TypeOf(response), "[]DetectedVPNConnection")
+		}
+
+		if len(vpnConnectionResponse) != 1 {
+			t.Fatalf("unexpected VPN connections detected, got %d expected %d", len(vpnConnectionResponse), 1)
+		}
+
+		if len(collector.Events) != 1 {
+			t.Fatalf("unexpected collector VPN connection events, got %d expected %d", len(collector.Events), 1)
+		}
+
+		if vpnConnectionResponse[0].VPCID != "vpc-1" {
+			t.Fatalf("unexpected VPC id, got %s expected %s", vpnConnectionResponse[0].VPCID, "vpc-1")
+		}
+	})
+
+	t.Run("detection error", func(t *testing.T) {
+		collector := collectorTestutils.NewMockCollector()
+		mockCloudwatch := awsTestutils.NewMockCloudwatch(nil)
+		mockPrice := awsTestutils.NewMockPricing(nil)
+		detector := awsTestutils.AWSManager(collector, mockCloudwatch, mockPrice, "us-east-1")
+
+		vpnConnectionManager, err := NewVPNConnectionManager(detector, &MockAWSVPNConnectionClient{err: errors.New("error")})
+		if err != nil {
+			t.Fatalf("unexpected VPN connection manager error happened, got %v expected %v", err, nil)
+		}
+
+		_, err = vpnConnectionManager.Detect(awsTestutils.DefaultMetricConfig)
+		if err == nil {
+			t.Fatalf("unexpected detection VPN connection manager error, got nil expected error message")
+		}
+	})
+}
@@ -5,6 +5,7 @@ import (
 	"finala/collector/aws/register"
 	_ "finala/collector/aws/resources"
 	"finala/collector/config"
+	"fmt"
 
 	"github.com/aws/aws-sdk-go/service/sts"
 	log "github.com/sirupsen/logrus"
@@ -17,25 +18,35 @@ const (
 
 // Analyze represents the aws analyze
 type Analyze struct {
-	cl            collector.CollectorDescriber
-	metricManager collector.MetricDescriptor
-	awsAccounts   []config.AWSAccount
-	global        map[string]struct{}
+	cl             collector.CollectorDescriber
+	metricManager  collector.MetricDescriptor
+	awsAccounts    []config.AWSAccount
+	tagsCompliance config.TagsComplianceConfig
+	inventory      config.InventoryConfig
+	global         map[string]struct{}
 }
 
 // NewAnalyzeManager will charge to execute aws resources
-func NewAnalyzeManager(cl collector.CollectorDescriber, metricsManager collector.MetricDescriptor, awsAccounts []config.AWSAccount) *Analyze {
+func NewAnalyzeManager(cl collector.CollectorDescriber, metricsManager collector.MetricDescriptor, awsAccounts []config.AWSAccount, tagsCompliance config.TagsComplianceConfig, inventory config.InventoryConfig) *Analyze {
 	return &Analyze{
-		cl:            cl,
-		metricManager: metricsManager,
-		awsAccounts:   awsAccounts,
-		global:        make(map[string]struct{}),
+		cl:             cl,
+		metricManager:  metricsManager,
+		awsAccounts:    awsAccounts,
+		tagsCompliance: tagsCompliance,
+		inventory:      inventory,
+		global:         make(map[string]struct{}),
 	}
 }
 
 // All will loop on all the aws provider settings, and check from the configuration of the metric should be reported
 func (app *Analyze) All() {
 
+	// The tags compliance findings are reported by all the detectors, under a single resource name
+	tagsComplianceName := collector.ResourceIdentifier(fmt.Sprintf("%s_%s", ResourcePrefix, collector.TagsComplianceName))
+	if app.tagsCompliance.Enable {
+		app.cl.CollectStart(tagsComplianceName)
+	}
+
 	for _, account := range app.awsAccounts {
 
 		awsAuth := NewAuth(account)
@@ -43,7 +54,7 @@ func (app *Analyze) All() {
 		stsManager := NewSTSManager(sts.New(globalsession, globalConfig))
 
 		for _, region := range account.Regions {
-			resourcesDetection := NewDetectorManager(awsAuth, app.cl, account, stsManager, app.global, region)
+			resourcesDetection := NewDetectorManager(awsAuth, app.cl, account, stsManager, app.global, app.tagsCompliance, app.inventory, region)
 			for resourceType, resourceDetector := range register.GetResources() {
 
 				resource, err := resourceDetector(resourcesDetection, nil)
@@ -67,4 +78,8 @@ func (app *Analyze) All() {
 			}
 		}
 	}
+
+	if app.tagsCompliance.Enable {
+		app.cl.CollectFinish(tagsComplianceName)
+	}
 }
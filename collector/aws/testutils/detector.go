@@ -3,6 +3,7 @@ package testutils
 import (
 	"finala/collector"
 	"finala/collector/aws/cloudwatch"
+	"finala/collector/aws/common"
 	"finala/collector/aws/pricing"
 	"fmt"
 
@@ -19,9 +20,11 @@ type MockAWSManager struct {
 	accountIdentity  *sts.GetCallerIdentityOutput
 	region           string
 	global           map[string]struct{}
+	// ExaminedResources holds the resources the detectors described, by resource name
+	ExaminedResources map[collector.ResourceIdentifier][]collector.PriceDetectedFields
 }
 
-func AWSManager(collector collector.CollectorDescriber, cloudWatchClient *cloudwatch.CloudwatchManager, priceClient *pricing.PricingManager, region string) *MockAWSManager {
+func AWSManager(collectorManager collector.CollectorDescriber, cloudWatchClient *cloudwatch.CloudwatchManager, priceClient *pricing.PricingManager, region string) *MockAWSManager {
 
 	accountID := "1234"
 	accountIdentity := &sts.GetCallerIdentityOutput{
@@ -29,12 +32,13 @@ func AWSManager(collector collector.CollectorDescriber, cloudWatchClient *cloudw
 	}
 
 	return &MockAWSManager{
-		collector:        collector,
-		cloudWatchClient: cloudWatchClient,
-		pricing:          priceClient,
-		accountIdentity:  accountIdentity,
-		region:           region,
-		global:           make(map[string]struct{}),
+		collector:         collectorManager,
+		cloudWatchClient:  cloudWatchClient,
+		pricing:           priceClient,
+		accountIdentity:   accountIdentity,
+		region:            region,
+		global:            make(map[string]struct{}),
+		ExaminedResources: make(map[collector.ResourceIdentifier][]collector.PriceDetectedFields),
 	}
 }
 
@@ -66,6 +70,21 @@ func (dm *MockAWSManager) GetAccountIdentity() *sts.GetCallerIdentityOutput {
 	return dm.accountIdentity
 }
 
+func (dm *MockAWSManager) GetAccountDimensions() collector.AccountDimensions {
+	return collector.AccountDimensions{
+		AccountID: *dm.accountIdentity.Account,
+		Region:    dm.region,
+	}
+}
+
+func (dm *MockAWSManager) GetARN(service, resource string) string {
+	return common.GetARN(dm.region, service, *dm.accountIdentity.Account, resource)
+}
+
+func (dm *MockAWSManager) GetConsoleURL(path, fragment string) string {
+	return common.GetConsoleURL(dm.region, path, fragment)
+}
+
 // SetGlobal marked resource as global
 func (dm *MockAWSManager) SetGlobal(resourceName collector.ResourceIdentifier) {
 	dm.global[string(resourceName)] = struct{}{}
@@ -77,3 +96,8 @@ func (dm *MockAWSManager) IsGlobalSet(resourceName collector.ResourceIdentifier)
 	return isExists
 
 }
+
+// ExamineResource saves the resource the detector described
+func (dm *MockAWSManager) ExamineResource(resourceName collector.ResourceIdentifier, getResource func() collector.PriceDetectedFields) {
+	dm.ExaminedResources[resourceName] = append(dm.ExaminedResources[resourceName], getResource())
+}
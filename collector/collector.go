@@ -4,10 +4,13 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"finala/events"
 	"finala/request"
 	"finala/visibility"
 	"fmt"
+	"io"
 	"net/http"
+	"net/url"
 	"sync"
 	"time"
 
@@ -18,13 +21,14 @@ import (
 type ResourceIdentifier string
 
 const (
-	eventServiceStatus    = "service_status"
-	eventResourceDetected = "resource_detected"
+	// apiKeyHeader is the request header of the collector api key
+	apiKeyHeader = "X-Collector-Key"
 )
 
 // CollectorDescriber describe the collector functions
 type CollectorDescriber interface {
 	AddResource(data EventCollector)
+	AddInventory(data EventCollector)
 	CollectStart(resourceName ResourceIdentifier)
 	CollectFinish(resourceName ResourceIdentifier)
 	CollectError(resourceName ResourceIdentifier, err error)
@@ -40,10 +44,12 @@ type CollectorManager struct {
 	sendInterval   time.Duration
 	executionID    string
 	apiEndpoint    string
+	apiKey         string
+	unauthorized   bool
 }
 
 // NewCollectorManager create new collector instance
-func NewCollectorManager(ctx context.Context, wg *sync.WaitGroup, req *request.HTTPClient, sendInterval time.Duration, name, apiEndpoint string) *CollectorManager {
+func NewCollectorManager(ctx context.Context, wg *sync.WaitGroup, req *request.HTTPClient, sendInterval time.Duration, name, apiEndpoint, apiKey string) *CollectorManager {
 
 	wg.Add(2)
 	executionID := fmt.Sprintf("%s_%v", name, time.Now().Unix())
@@ -56,6 +62,7 @@ func NewCollectorManager(ctx context.Context, wg *sync.WaitGroup, req *request.H
 		sendInterval:   sendInterval,
 		executionID:    executionID,
 		apiEndpoint:    apiEndpoint,
+		apiKey:         apiKey,
 	}
 
 	go func(collectorManager *CollectorManager) {
@@ -90,9 +97,58 @@ func NewCollectorManager(ctx context.Context, wg *sync.WaitGroup, req *request.H
 	return collectorManager
 }
 
+// StartExecution registers the collector execution with the given metadata, before the collector starts collecting
+func (cm *CollectorManager) StartExecution(start events.ExecutionStart) error {
+	buf, err := json.Marshal(start)
+	if err != nil {
+		return err
+	}
+	return cm.executionRequest("start", buf)
+}
+
+// FinishExecution closes the collector execution, after all the events were sent
+func (cm *CollectorManager) FinishExecution() error {
+	return cm.executionRequest("finish", nil)
+}
+
+// executionRequest sends the given execution lifecycle action to the api server
+func (cm *CollectorManager) executionRequest(action string, body []byte) error {
+
+	req, err := cm.request.Request("POST", fmt.Sprintf("%s/api/v1/executions/%s/%s", cm.apiEndpoint, cm.executionID, action), nil, bytes.NewBuffer(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(apiKeyHeader, cm.apiKey)
+	res, err := cm.request.DO(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK && res.StatusCode != http.StatusCreated {
+		return &request.HttpError{Status: res.Status, StatusCode: res.StatusCode}
+	}
+
+	log.WithFields(log.Fields{
+		"execution_id": cm.executionID,
+		"action":       action,
+	}).Info("collector execution updated")
+	return nil
+}
+
 // AddResource add resource data
 func (cm *CollectorManager) AddResource(data EventCollector) {
-	data.EventType = eventResourceDetected
+	data.EventType = events.EventResourceDetected
+	data.SchemaVersion = events.SchemaVersion
+	data.EventTime = time.Now().UnixNano()
+	cm.collectChan <- data
+}
+
+// AddInventory add resource inventory data
+func (cm *CollectorManager) AddInventory(data EventCollector) {
+	data.EventType = events.EventResourceInventory
+	data.SchemaVersion = events.SchemaVersion
 	data.EventTime = time.Now().UnixNano()
 	cm.collectChan <- data
 }
@@ -128,14 +184,24 @@ func (cm *CollectorManager) CollectError(resourceName ResourceIdentifier, err er
 	})
 }
 
+// Unauthorized returns true when the api rejected the collector key. The events are dropped once the key is rejected
+func (cm *CollectorManager) Unauthorized() bool {
+	cm.collectorMutex.RLock()
+	defer cm.collectorMutex.RUnlock()
+	return cm.unauthorized
+}
+
 // GetCollectorEvent returns current events list
 func (cm *CollectorManager) GetCollectorEvent() []EventCollector {
+	cm.collectorMutex.RLock()
+	defer cm.collectorMutex.RUnlock()
 	return cm.sendData
 }
 
 // updateServiceStatus add status on resource collector
 func (cm *CollectorManager) updateServiceStatus(data EventCollector) {
-	data.EventType = eventServiceStatus
+	data.EventType = events.EventServiceStatus
+	data.SchemaVersion = events.SchemaVersion
 	data.EventTime = time.Now().UnixNano()
 	cm.collectChan <- data
 }
@@ -143,19 +209,29 @@ func (cm *CollectorManager) updateServiceStatus(data EventCollector) {
 // collect append all the given event to the one array of events
 func (cm *CollectorManager) saveEvent(data EventCollector) {
 
-	cm.collectorMutex.RLock()
-	defer cm.collectorMutex.RUnlock()
+	cm.collectorMutex.Lock()
+	defer cm.collectorMutex.Unlock()
 	cm.sendData = append(cm.sendData, data)
 }
 
 // sendBulk will send all event data to to api server.
 func (cm *CollectorManager) sendBulk() bool {
 
-	cm.collectorMutex.RLock()
-	defer cm.collectorMutex.RUnlock()
+	// The events and the unauthorized flag are changed while sending, so the write lock is held
+	cm.collectorMutex.Lock()
+	defer cm.collectorMutex.Unlock()
+
+	// The api rejects every batch of a rejected collector key, so sending the events again never succeeds
+	if cm.unauthorized {
+		if len(cm.sendData) > 0 {
+			log.WithField("event_count", len(cm.sendData)).Warn("drop the collector events, the api rejected the collector key")
+		}
+		cm.sendData = []EventCollector{}
+		return false
+	}
 
 	status := cm.send(cm.sendData)
-	if status {
+	if status || cm.unauthorized {
 		cm.sendData = []EventCollector{}
 	}
 
@@ -167,8 +243,8 @@ func (cm *CollectorManager) sendBulk() bool {
 func (cm *CollectorManager) gracefulShutdown() {
 
 	time.Sleep(cm.sendInterval)
-	if len(cm.sendData) > 0 {
-		log.WithField("event_count", len(cm.sendData)).Info("Found more event to send")
+	if eventCount := len(cm.GetCollectorEvent()); eventCount > 0 {
+		log.WithField("event_count", eventCount).Info("Found more event to send")
 		cm.sendBulk()
 		cm.gracefulShutdown()
 	}
@@ -187,12 +263,14 @@ func (cm *CollectorManager) send(events []EventCollector) bool {
 	if err != nil {
 		log.Fatal(err)
 	}
-	req, err := cm.request.Request("POST", fmt.Sprintf("%s/api/v1/detect-events/%s", cm.apiEndpoint, cm.executionID), nil, bytes.NewBuffer(buf))
+	// The API responds after the events are stored, so a successful response means the batch is durable
+	req, err := cm.request.Request("POST", fmt.Sprintf("%s/api/v1/detect-events/%s", cm.apiEndpoint, cm.executionID), url.Values{"wait": {"true"}}, bytes.NewBuffer(buf))
 	if err != nil {
 		log.WithError(err).Error("could not create HTTP client request")
 		return false
 	}
 	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(apiKeyHeader, cm.apiKey)
 	defer visibility.Elapsed("api webserver request")()
 	res, err := cm.request.DO(req)
 
@@ -200,6 +278,39 @@ func (cm *CollectorManager) send(events []EventCollector) bool {
 		log.WithError(err).Error("could not send HTTP client request")
 		return false
 	}
+	defer res.Body.Close()
+
+	if res.StatusCode == http.StatusUnauthorized || res.StatusCode == http.StatusForbidden {
+		log.WithFields(log.Fields{
+			"status_code":  res.StatusCode,
+			"execution_id": cm.executionID,
+		}).Error("the api rejected the collector events, check api_server.api_key is a key issued to the collector name")
+		cm.unauthorized = true
+		return false
+	}
+
+	if res.StatusCode == http.StatusAccepted {
+		cm.logRejectedEvents(res.Body)
+	}
 
 	return res.StatusCode == http.StatusAccepted
 }
+
+// logRejectedEvents logs the events the api rejected by the event schema. The rejected events are not sent again
+func (cm *CollectorManager) logRejectedEvents(body io.Reader) {
+
+	var ingest events.IngestResponse
+	err := json.NewDecoder(body).Decode(&ingest)
+	if err != nil {
+		log.WithError(err).Warn("could not decode the api events response")
+		return
+	}
+
+	for _, eventErr := range ingest.Errors {
+		log.WithFields(log.Fields{
+			"execution_id":  cm.executionID,
+			"resource_name": eventErr.ResourceName,
+			"index":         eventErr.Index,
+		}).Error("the api rejected the collector event: ", eventErr.Error)
+	}
+}
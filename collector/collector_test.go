@@ -1,16 +1,18 @@
 package collector_test
 
 import (
-	// "bytes" // Removed unused import
 	"context"
 	"encoding/json"
 	"finala/collector"
+	"finala/events"
 	"finala/request"
 	"fmt"
+	// "bytes" // Removed unused import
 	"io"
 	"log"
 	"net/http"
-	// "net/http/httptest" // Removed unused import
+	"net/http/httptest"
+	"strings"
 	"sync"
 	"testing"
 	"time"
@@ -19,16 +21,22 @@ import (
 )
 
 type DetectEvents struct {
-	Name string
-	Data interface{}
+	SchemaVersion int
+	Name          string
+	Data          interface{}
 }
 
 type ReceivedData struct {
-	receivedCount    int
-	returnStatusCode int
+	receivedCount         int
+	receivedKey           string
+	receivedSchemaVersion int
+	receivedWait          string
+	returnStatusCode      int
 }
 
 func (rd *ReceivedData) HandleRequestHandler(resp http.ResponseWriter, req *http.Request) {
+	rd.receivedKey = req.Header.Get("X-Collector-Key")
+	rd.receivedWait = req.URL.Query().Get("wait")
 	resp.WriteHeader(rd.returnStatusCode)
 	buf, bodyErr := io.ReadAll(req.Body)
 	if bodyErr != nil {
@@ -44,6 +52,9 @@ func (rd *ReceivedData) HandleRequestHandler(resp http.ResponseWriter, req *http
 	}
 
 	rd.receivedCount = len(e)
+	for _, event := range e {
+		rd.receivedSchemaVersion = event.SchemaVersion
+	}
 
 }
 
@@ -51,7 +62,7 @@ func newCollector(wg *sync.WaitGroup, ctx context.Context, port int) *collector.
 
 	req := request.NewHTTPClient()
 	duration := time.Duration(time.Second * 1)
-	coll := collector.NewCollectorManager(ctx, wg, req, duration, "collector_name", fmt.Sprintf("http://127.0.0.1:%d", port))
+	coll := collector.NewCollectorManager(ctx, wg, req, duration, "collector_name", fmt.Sprintf("http://127.0.0.1:%d", port), "collector-key")
 	return coll
 }
 func TestAddEvent(t *testing.T) {
@@ -92,6 +103,18 @@ func TestAddEvent(t *testing.T) {
 		t.Fatalf("unexpected collector send data, got %d, expected %d", receivedData.receivedCount, 2)
 	}
 
+	if receivedData.receivedSchemaVersion != events.SchemaVersion {
+		t.Fatalf("unexpected event schema version, got %d, expected %d", receivedData.receivedSchemaVersion, events.SchemaVersion)
+	}
+
+	if receivedData.receivedWait != "true" {
+		t.Fatalf("unexpected collector wait for storage, got %q, expected %q", receivedData.receivedWait, "true")
+	}
+
+	if receivedData.receivedKey != "collector-key" {
+		t.Fatalf("unexpected collector key, got %q, expected %q", receivedData.receivedKey, "collector-key")
+	}
+
 	if len(coll.GetCollectorEvent()) != 0 {
 		t.Fatalf("unexpected collector clear events, got %d, expected %d", len(coll.GetCollectorEvent()), 0)
 	}
@@ -137,3 +160,103 @@ func TestAddEventServerUnavailable(t *testing.T) {
 	}
 
 }
+
+func TestAddEventUnauthorized(t *testing.T) {
+
+	var wg sync.WaitGroup
+	ctx, cancelFn := context.WithCancel(context.Background())
+	receivedData := ReceivedData{
+		returnStatusCode: http.StatusUnauthorized,
+	}
+
+	r := mux.NewRouter()
+	r.HandleFunc("/api/v1/detect-events/{executionID}", receivedData.HandleRequestHandler)
+	srv := httptest.NewServer(r)
+	defer srv.Close()
+
+	coll := collector.NewCollectorManager(ctx, &wg, request.NewHTTPClient(), time.Second, "collector_name", srv.URL, "other-key")
+
+	coll.CollectStart(collector.ResourceIdentifier("test"))
+	coll.AddResource(collector.EventCollector{
+		ResourceName: "test1",
+		Data:         "test data",
+	})
+	time.Sleep(time.Second * 2)
+
+	if !coll.Unauthorized() {
+		t.Fatalf("unexpected collector key accepted, expected the api to reject the collector key")
+	}
+
+	if len(coll.GetCollectorEvent()) != 0 {
+		t.Fatalf("unexpected collector rejected events, got %d, expected %d", len(coll.GetCollectorEvent()), 0)
+	}
+
+	coll.AddResource(collector.EventCollector{
+		ResourceName: "test2",
+		Data:         "test data",
+	})
+	cancelFn()
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second * 5):
+		t.Fatalf("unexpected collector shutdown retrying the rejected events")
+	}
+}
+
+func TestExecutionLifecycle(t *testing.T) {
+
+	var wg sync.WaitGroup
+	ctx, cancelFn := context.WithCancel(context.Background())
+	defer cancelFn()
+
+	actions := []string{}
+	var start events.ExecutionStart
+	r := mux.NewRouter()
+	r.HandleFunc("/api/v1/executions/{executionID}/{action}", func(resp http.ResponseWriter, req *http.Request) {
+		vars := mux.Vars(req)
+		if vars["executionID"] == "" || req.Header.Get("X-Collector-Key") != "collector-key" {
+			resp.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		actions = append(actions, vars["action"])
+		if vars["action"] == "start" {
+			if err := json.NewDecoder(req.Body).Decode(&start); err != nil {
+				resp.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			resp.WriteHeader(http.StatusCreated)
+			return
+		}
+		resp.WriteHeader(http.StatusOK)
+	})
+	srv := httptest.NewServer(r)
+	defer srv.Close()
+
+	coll := collector.NewCollectorManager(ctx, &wg, request.NewHTTPClient(), time.Second, "collector_name", srv.URL, "collector-key")
+	err := coll.StartExecution(events.ExecutionStart{Accounts: []string{"prod"}, ConfigHash: "abc", CollectorVersion: "1.0.0"})
+	if err != nil {
+		t.Fatalf("unexpected start execution error: %v", err)
+	}
+	err = coll.FinishExecution()
+	if err != nil {
+		t.Fatalf("unexpected finish execution error: %v", err)
+	}
+
+	if strings.Join(actions, ",") != "start,finish" {
+		t.Fatalf("unexpected execution actions, got %v, expected %v", actions, []string{"start", "finish"})
+	}
+	if start.ConfigHash != "abc" || start.CollectorVersion != "1.0.0" {
+		t.Fatalf("unexpected execution metadata, got %+v", start)
+	}
+
+	unauthorized := collector.NewCollectorManager(ctx, &wg, request.NewHTTPClient(), time.Second, "collector_name", srv.URL, "other-key")
+	if err := unauthorized.FinishExecution(); err == nil {
+		t.Fatalf("unexpected finish execution without a valid collector key")
+	}
+}
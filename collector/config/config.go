@@ -1,6 +1,8 @@
 package config
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"os"
 	"time"
 
@@ -43,16 +45,38 @@ type MetricConfig struct {
 	Constraint  MetricConstraintConfig    `yaml:"constraint"`
 }
 
+// RequiredTagConfig describe a required tag key and its allowed values.
+// Any value is allowed when AllowedValues is empty
+type RequiredTagConfig struct {
+	Key           string   `yaml:"key"`
+	AllowedValues []string `yaml:"allowed_values"`
+}
+
+// TagsComplianceConfig describe the required tags of all the described resources
+type TagsComplianceConfig struct {
+	Enable       bool                `yaml:"enable"`
+	RequiredTags []RequiredTagConfig `yaml:"required_tags"`
+}
+
+// InventoryConfig describe the inventory snapshot of all the described resources
+type InventoryConfig struct {
+	Enable bool `yaml:"enable"`
+}
+
 // ProviderConfig describe the available providers
 type ProviderConfig struct {
-	Accounts []AWSAccount              `yaml:"accounts"`
-	Metrics  map[string][]MetricConfig `yaml:"metrics"`
+	Accounts       []AWSAccount              `yaml:"accounts"`
+	TagsCompliance TagsComplianceConfig      `yaml:"tags_compliance"`
+	Inventory      InventoryConfig           `yaml:"inventory"`
+	Metrics        map[string][]MetricConfig `yaml:"metrics"`
 }
 
-// APIServerConfig descrive the api configuration
+// APIServerConfig descrive the api configuration.
+// The api key is issued by the api to the collector name, and is sent with the collector events
 type APIServerConfig struct {
 	BulkInterval time.Duration `yaml:"bulk_interval"`
 	Addr         string        `yaml:"address"`
+	APIKey       string        `yaml:"api_key"`
 }
 
 // CollectorConfig present the application config
@@ -63,6 +87,34 @@ type CollectorConfig struct {
 	Providers map[string]ProviderConfig `yaml:"providers"`
 }
 
+// Hash returns the sha256 hash of the configuration without its credentials,
+// so the executions of the same configuration can be told from the executions of a changed configuration
+func (c CollectorConfig) Hash() string {
+
+	c.APIServer.APIKey = ""
+	providers := make(map[string]ProviderConfig, len(c.Providers))
+	for name, provider := range c.Providers {
+		accounts := make([]AWSAccount, len(provider.Accounts))
+		for i, account := range provider.Accounts {
+			account.AccessKey = ""
+			account.SecretKey = ""
+			account.SessionToken = ""
+			accounts[i] = account
+		}
+		provider.Accounts = accounts
+		providers[name] = provider
+	}
+	c.Providers = providers
+
+	// The yaml encoder sorts the map keys, so the same configuration has the same hash
+	data, err := yaml.Marshal(c)
+	if err != nil {
+		return ""
+	}
+	hash := sha256.Sum256(data)
+	return hex.EncodeToString(hash[:])
+}
+
 // Load will load yaml file go struct
 func Load(location string) (CollectorConfig, error) {
 	config := CollectorConfig{}
@@ -85,5 +137,13 @@ func Load(location string) (CollectorConfig, error) {
 		config.APIServer.Addr = overrideAPIEndpoint
 	}
 
+	overrideAPIKey := os.Getenv("OVERRIDE_API_KEY")
+	if overrideAPIKey != "" {
+		log.WithFields(log.Fields{
+			"environment_variable": "OVERRIDE_API_KEY",
+		}).Info("override api key")
+		config.APIServer.APIKey = overrideAPIKey
+	}
+
 	return config, nil
 }
@@ -28,6 +28,53 @@ func TestConfig(t *testing.T) {
 		}
 	})
 
+	t.Run("api_key", func(t *testing.T) {
+		collectorConfig, err := config.Load(fmt.Sprintf("%s/testutil/mock/config.yaml", currentFolderPath))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if collectorConfig.APIServer.APIKey != "mock-api-key" {
+			t.Fatalf("unexpected api key, got %q expected %q", collectorConfig.APIServer.APIKey, "mock-api-key")
+		}
+	})
+
+	t.Run("api_key_override", func(t *testing.T) {
+		t.Setenv("OVERRIDE_API_KEY", "override-key")
+
+		collectorConfig, err := config.Load(fmt.Sprintf("%s/testutil/mock/config.yaml", currentFolderPath))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if collectorConfig.APIServer.APIKey != "override-key" {
+			t.Fatalf("unexpected api key, got %q expected %q", collectorConfig.APIServer.APIKey, "override-key")
+		}
+	})
+
+	t.Run("hash", func(t *testing.T) {
+		collectorConfig, err := config.Load(fmt.Sprintf("%s/testutil/mock/config.yaml", currentFolderPath))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		hash := collectorConfig.Hash()
+		if len(hash) != 64 {
+			t.Fatalf("unexpected config hash, got %q", hash)
+		}
+
+		// The credentials are not part of the hash, and the other settings are
+		t.Setenv("OVERRIDE_API_KEY", "override-key")
+		overridden, err := config.Load(fmt.Sprintf("%s/testutil/mock/config.yaml", currentFolderPath))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if overridden.Hash() != hash {
+			t.Fatalf("unexpected config hash of other credentials, got %q expected %q", overridden.Hash(), hash)
+		}
+		overridden.Name = "other"
+		if overridden.Hash() == hash {
+			t.Fatalf("unexpected config hash of other collector name, got the same hash")
+		}
+	})
+
 	t.Run("invalid_config", func(t *testing.T) {
 		_, err := config.Load(fmt.Sprintf("%s/testutil/mock/config1.yaml", currentFolderPath))
 
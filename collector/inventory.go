@@ -0,0 +1,7 @@
+package collector
+
+// ResourceInventory defines a resource the detectors described, regardless of its detection
+type ResourceInventory struct {
+	ResourceType ResourceIdentifier
+	PriceDetectedFields
+}
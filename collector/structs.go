@@ -27,19 +27,30 @@ type EventStatusData struct {
 	ErrorMessage string
 }
 
+// AccountDimensions describe the account and the region of the detected resource
+type AccountDimensions struct {
+	AccountID   string
+	AccountName string
+	Region      string
+}
+
 // PriceDetectedFields describe the pricing field
 type PriceDetectedFields struct {
 	ResourceID    string
+	ARN           string
+	ConsoleURL    string
 	LaunchTime    time.Time
 	PricePerHour  float64
 	PricePerMonth float64
 	Tag           map[string]string
+	AccountDimensions
 }
 
 // EventCollector collector event data structure
 type EventCollector struct {
-	EventType    string
-	ResourceName ResourceIdentifier
-	EventTime    int64
-	Data         interface{}
+	SchemaVersion int
+	EventType     string
+	ResourceName  ResourceIdentifier
+	EventTime     int64
+	Data          interface{}
 }
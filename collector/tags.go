@@ -0,0 +1,62 @@
+package collector
+
+import (
+	"finala/collector/config"
+	"sort"
+)
+
+const (
+	// TagsComplianceName describes the resource name of the tags compliance findings
+	TagsComplianceName = "tags_compliance"
+
+	// TagsComplianceMetric describes the detection of a resource without the required tags
+	TagsComplianceMetric = "Required tags"
+
+	// TagsComplianceCategory describes the summary category of the tags compliance findings
+	TagsComplianceCategory = "tags_compliance"
+)
+
+// DetectedTagsCompliance defines a described resource which is missing required tags,
+// or with tag values outside the allowed values
+type DetectedTagsCompliance struct {
+	Metric       string
+	Category     string
+	ResourceType ResourceIdentifier
+	MissingTags  []string
+	InvalidTags  map[string]string
+	PriceDetectedFields
+}
+
+// CheckRequiredTags returns the missing required tag keys, and the required tags with values outside the allowed values
+func CheckRequiredTags(requiredTags []config.RequiredTagConfig, tags map[string]string) ([]string, map[string]string) {
+
+	missingTags := []string{}
+	invalidTags := map[string]string{}
+
+	for _, requiredTag := range requiredTags {
+		value, found := tags[requiredTag.Key]
+		if !found || value == "" {
+			missingTags = append(missingTags, requiredTag.Key)
+			continue
+		}
+
+		if len(requiredTag.AllowedValues) == 0 {
+			continue
+		}
+
+		allowed := false
+		for _, allowedValue := range requiredTag.AllowedValues {
+			if value == allowedValue {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			invalidTags[requiredTag.Key] = value
+		}
+	}
+
+	sort.Strings(missingTags)
+
+	return missingTags, invalidTags
+}
@@ -0,0 +1,40 @@
+package collector_test
+
+import (
+	"finala/collector"
+	"finala/collector/config"
+	"reflect"
+	"testing"
+)
+
+func TestCheckRequiredTags(t *testing.T) {
+
+	requiredTags := []config.RequiredTagConfig{
+		{Key: "team"},
+		{Key: "environment", AllowedValues: []string{"production", "staging"}},
+	}
+
+	testCases := []struct {
+		name                string
+		tags                map[string]string
+		expectedMissingTags []string
+		expectedInvalidTags map[string]string
+	}{
+		{"compliant", map[string]string{"team": "data", "environment": "staging"}, []string{}, map[string]string{}},
+		{"untagged", map[string]string{}, []string{"environment", "team"}, map[string]string{}},
+		{"empty value", map[string]string{"team": "", "environment": "production"}, []string{"team"}, map[string]string{}},
+		{"invalid value", map[string]string{"team": "data", "environment": "dev"}, []string{}, map[string]string{"environment": "dev"}},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			missingTags, invalidTags := collector.CheckRequiredTags(requiredTags, test.tags)
+			if !reflect.DeepEqual(missingTags, test.expectedMissingTags) {
+				t.Fatalf("unexpected missing tags, got %v expected %v", missingTags, test.expectedMissingTags)
+			}
+			if !reflect.DeepEqual(invalidTags, test.expectedInvalidTags) {
+				t.Fatalf("unexpected invalid tags, got %v expected %v", invalidTags, test.expectedInvalidTags)
+			}
+		})
+	}
+}
@@ -7,6 +7,7 @@ import (
 type MockCollector struct {
 	EventsCollectionStatus []collector.EventCollector
 	Events                 []collector.EventCollector
+	Inventory              []collector.EventCollector
 }
 
 func NewMockCollector() *MockCollector {
@@ -18,6 +19,10 @@ func (mc *MockCollector) AddResource(data collector.EventCollector) {
 	mc.Events = append(mc.Events, data)
 }
 
+func (mc *MockCollector) AddInventory(data collector.EventCollector) {
+	mc.Inventory = append(mc.Inventory, data)
+}
+
 func (mc *MockCollector) GetCollectorEvent() []collector.EventCollector {
 	events := []collector.EventCollector{}
 	return events
@@ -0,0 +1,114 @@
+package events
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+const (
+	// EventServiceStatus describes the collection status of a resource type
+	EventServiceStatus = "service_status"
+
+	// EventResourceDetected describes a detected resource
+	EventResourceDetected = "resource_detected"
+
+	// EventResourceInventory describes a resource the detectors described, regardless of its detection
+	EventResourceInventory = "resource_inventory"
+)
+
+const (
+	// SchemaVersion is the event schema version the collectors send
+	SchemaVersion = 2
+
+	// MinSchemaVersion is the oldest event schema version the api accepts, so collectors can be upgraded after the api.
+	// Events without a schema version were sent by collectors older than the versioned schema, and are version 1
+	MinSchemaVersion = 1
+)
+
+// Event describes a collector event. Data holds the event type and resource type fields, and is validated by their schema
+type Event struct {
+	SchemaVersion int             `json:"SchemaVersion,omitempty"`
+	ResourceName  string          `json:"ResourceName"`
+	EventType     string          `json:"EventType"`
+	EventTime     int64           `json:"EventTime"`
+	Data          json.RawMessage `json:"Data"`
+}
+
+// ValidationError describes the schema violations of an event
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return strings.Join(e.Problems, "; ")
+}
+
+// EventError describes a rejected event of an events batch
+type EventError struct {
+	Index        int    `json:"index"`
+	ResourceName string `json:"resource_name,omitempty"`
+	Error        string `json:"error"`
+}
+
+// IngestResponse describes the api response to an events batch
+type IngestResponse struct {
+	Accepted int          `json:"accepted"`
+	Rejected int          `json:"rejected"`
+	Errors   []EventError `json:"errors"`
+}
+
+// Parse decodes the given raw event and validates it by the schema of its version, event type and resource type
+func Parse(raw json.RawMessage) (Event, error) {
+
+	event := Event{}
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.UseNumber()
+	err := decoder.Decode(&event)
+	if err != nil {
+		return event, &ValidationError{Problems: []string{fmt.Sprintf("invalid event: %v", err)}}
+	}
+
+	if event.SchemaVersion == 0 {
+		event.SchemaVersion = MinSchemaVersion
+	}
+
+	return event, Validate(event)
+}
+
+// Validate validates the event by the schema of its version, event type and resource type
+func Validate(event Event) error {
+
+	problems := []string{}
+	if event.SchemaVersion < MinSchemaVersion || event.SchemaVersion > SchemaVersion {
+		problems = append(problems, fmt.Sprintf("unsupported schema version %d, supported versions are %d to %d", event.SchemaVersion, MinSchemaVersion, SchemaVersion))
+		return &ValidationError{Problems: problems}
+	}
+	if event.ResourceName == "" {
+		problems = append(problems, "ResourceName is required")
+	}
+	if event.EventTime <= 0 {
+		problems = append(problems, "EventTime is required")
+	}
+
+	schema, err := SchemaOf(event.SchemaVersion, event.EventType, event.ResourceName)
+	if err != nil {
+		problems = append(problems, err.Error())
+		return &ValidationError{Problems: problems}
+	}
+
+	data := map[string]interface{}{}
+	decoder := json.NewDecoder(bytes.NewReader(event.Data))
+	decoder.UseNumber()
+	if len(event.Data) == 0 || decoder.Decode(&data) != nil || data == nil {
+		problems = append(problems, "Data must be an object")
+		return &ValidationError{Problems: problems}
+	}
+
+	problems = append(problems, schema.validate(data)...)
+	if len(problems) > 0 {
+		return &ValidationError{Problems: problems}
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+package events_test
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"finala/events"
+)
+
+func TestParse(t *testing.T) {
+
+	testCases := []struct {
+		name            string
+		raw             string
+		expectedVersion int
+		expectedProblem string
+	}{
+		{"current schema version", `{"SchemaVersion":2,"ResourceName":"aws_ec2","EventType":"service_status","EventTime":1,"Data":{"Status":0}}`, 2, ""},
+		{"without schema version", `{"ResourceName":"aws_ec2","EventType":"service_status","EventTime":1,"Data":{"Status":1,"ErrorMessage":"access denied"}}`, 1, ""},
+		{"unsupported schema version", `{"SchemaVersion":3,"ResourceName":"aws_ec2","EventType":"service_status","EventTime":1,"Data":{"Status":0}}`, 3, "unsupported schema version 3"},
+		{"missing resource name", `{"SchemaVersion":2,"EventType":"service_status","EventTime":1,"Data":{"Status":0}}`, 2, "ResourceName is required"},
+		{"missing event time", `{"SchemaVersion":2,"ResourceName":"aws_ec2","EventType":"service_status","Data":{"Status":0}}`, 2, "EventTime is required"},
+		{"unknown event type", `{"SchemaVersion":2,"ResourceName":"aws_ec2","EventType":"other","EventTime":1,"Data":{}}`, 2, `unknown event type "other"`},
+		{"data not an object", `{"SchemaVersion":2,"ResourceName":"aws_ec2","EventType":"service_status","EventTime":1,"Data":[1]}`, 2, "Data must be an object"},
+		{"missing data", `{"SchemaVersion":2,"ResourceName":"aws_ec2","EventType":"service_status","EventTime":1}`, 2, "Data must be an object"},
+		{"not an event", `"event"`, 0, "invalid event"},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			event, err := events.Parse(json.RawMessage(test.raw))
+			if event.SchemaVersion != test.expectedVersion {
+				t.Fatalf("unexpected schema version, got %d expected %d", event.SchemaVersion, test.expectedVersion)
+			}
+
+			if test.expectedProblem == "" {
+				if err != nil {
+					t.Fatalf("unexpected parse error: %v", err)
+				}
+				return
+			}
+
+			var validationErr *events.ValidationError
+			if !errors.As(err, &validationErr) {
+				t.Fatalf("unexpected parse error, got %v expected validation error", err)
+			}
+			if !strings.Contains(err.Error(), test.expectedProblem) {
+				t.Fatalf("unexpected validation error, got %q expected %q", err.Error(), test.expectedProblem)
+			}
+		})
+	}
+}
+
+func TestValidateReportsAllProblems(t *testing.T) {
+
+	err := events.Validate(events.Event{
+		SchemaVersion: 2,
+		EventType:     events.EventResourceDetected,
+		ResourceName:  "aws_rds",
+		Data:          json.RawMessage(`{"Metric":"Connections","PricePerMonth":"10","MultiAZ":"true","Region":"us-east-1"}`),
+	})
+
+	var validationErr *events.ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("unexpected validate error, got %v expected validation error", err)
+	}
+
+	expectedProblems := []string{
+		"EventTime is required",
+		"Data.ResourceID is required",
+		"Data.PricePerMonth must be a number",
+		"Data.MultiAZ must be a boolean",
+		"Data.AccountID is required",
+		"Data.AccountName is required",
+	}
+	if strings.Join(validationErr.Problems, "\n") != strings.Join(expectedProblems, "\n") {
+		t.Fatalf("unexpected validation problems, got %q expected %q", validationErr.Problems, expectedProblems)
+	}
+}
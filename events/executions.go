@@ -0,0 +1,9 @@
+package events
+
+// ExecutionStart describes the metadata the collector registers its execution with, before it starts collecting
+type ExecutionStart struct {
+	Accounts         []string `json:"accounts"`
+	Regions          []string `json:"regions"`
+	ConfigHash       string   `json:"config_hash"`
+	CollectorVersion string   `json:"collector_version"`
+}
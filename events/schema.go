@@ -0,0 +1,406 @@
+package events
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// FieldType describes the json type of an event data field
+type FieldType string
+
+const (
+	// TypeString is a json string
+	TypeString FieldType = "string"
+
+	// TypeNumber is a json number
+	TypeNumber FieldType = "number"
+
+	// TypeInteger is a json number without a fraction
+	TypeInteger FieldType = "integer"
+
+	// TypeBool is a json boolean
+	TypeBool FieldType = "boolean"
+
+	// TypeTime is a RFC 3339 time string
+	TypeTime FieldType = "time"
+
+	// TypeObject is a json object
+	TypeObject FieldType = "object"
+
+	// TypeArray is a json array
+	TypeArray FieldType = "array"
+)
+
+// Field describes an event data field. Required fields must be present and not null, other fields may be missing or null.
+// When values are given, the field json value must be one of them
+type Field struct {
+	Name     string
+	Type     FieldType
+	Required bool
+	Values   []string
+}
+
+// Schema describes the data fields of an event type and resource type. Fields not in the schema are allowed
+type Schema struct {
+	Version int
+	Fields  []Field
+}
+
+// accountFields are the account dimensions of the detected and inventory resources.
+// They were added in schema version 2, and are optional in version 1
+func accountFields(version int) []Field {
+	required := version >= 2
+	return []Field{
+		{Name: "AccountID", Type: TypeString, Required: required},
+		{Name: "AccountName", Type: TypeString, Required: required},
+		{Name: "Region", Type: TypeString, Required: required},
+	}
+}
+
+// priceFields are the fields of the resources with a price, the collector PriceDetectedFields
+var priceFields = []Field{
+	{Name: "ResourceID", Type: TypeString, Required: true},
+	{Name: "ARN", Type: TypeString},
+	{Name: "ConsoleURL", Type: TypeString},
+	{Name: "LaunchTime", Type: TypeTime},
+	{Name: "PricePerHour", Type: TypeNumber},
+	{Name: "PricePerMonth", Type: TypeNumber, Required: true},
+	{Name: "Tag", Type: TypeObject},
+}
+
+// metricField is the detection metric of the detected resources
+var metricField = Field{Name: "Metric", Type: TypeString, Required: true}
+
+// serviceStatusFields are the fields of the service status events, the status is fetch (0), error (1) or finish (2)
+var serviceStatusFields = []Field{
+	{Name: "Status", Type: TypeInteger, Required: true, Values: []string{"0", "1", "2"}},
+	{Name: "ErrorMessage", Type: TypeString},
+}
+
+// inventoryFields are the fields of the inventory events, besides the price and the account fields
+var inventoryFields = []Field{
+	{Name: "ResourceType", Type: TypeString, Required: true},
+}
+
+// detectedFields are the fields of each detected resource type, besides the metric, the price and the account fields.
+// Resource types without a price have no entry in priceless
+var detectedFields = map[string][]Field{
+	"aws_apigateway": {
+		{Name: "Name", Type: TypeString},
+		{Name: "RequestsPerMonth", Type: TypeNumber},
+		{Name: "PriceUnavailable", Type: TypeBool},
+	},
+	"aws_documentDB": {
+		{Name: "InstanceType", Type: TypeString},
+		{Name: "MultiAZ", Type: TypeBool},
+		{Name: "Engine", Type: TypeString},
+	},
+	"aws_dynamoDB": {
+		{Name: "Name", Type: TypeString},
+	},
+	"aws_ec2": {
+		{Name: "Name", Type: TypeString},
+		{Name: "InstanceType", Type: TypeString},
+		{Name: "MaxCPUUtilization", Type: TypeNumber},
+		{Name: "MaxNetworkGbps", Type: TypeNumber},
+		{Name: "RecommendedType", Type: TypeString},
+		{Name: "RecommendedPricePerMonth", Type: TypeNumber},
+		{Name: "RecommendedSavingPerMonth", Type: TypeNumber},
+	},
+	"aws_ec2_stopped": {
+		{Name: "Name", Type: TypeString},
+		{Name: "InstanceType", Type: TypeString},
+		{Name: "StoppedTime", Type: TypeTime},
+		{Name: "StoppedDays", Type: TypeNumber},
+		{Name: "VolumeIDs", Type: TypeArray},
+		{Name: "ElasticIPs", Type: TypeArray},
+		{Name: "VolumesPricePerMonth", Type: TypeNumber},
+		{Name: "ElasticIPsPricePerMonth", Type: TypeNumber},
+	},
+	"aws_ec2_volumes": {
+		{Name: "Type", Type: TypeString},
+		{Name: "Size", Type: TypeInteger},
+	},
+	"aws_ecs": {
+		{Name: "ClusterName", Type: TypeString},
+		{Name: "ServiceName", Type: TypeString},
+		{Name: "CapacityProvider", Type: TypeString},
+		{Name: "LaunchType", Type: TypeString},
+		{Name: "DesiredCount", Type: TypeInteger},
+		{Name: "CPU", Type: TypeNumber},
+		{Name: "MemoryGB", Type: TypeNumber},
+	},
+	"aws_eks": {
+		{Name: "ClusterName", Type: TypeString},
+		{Name: "Version", Type: TypeString},
+		{Name: "NodeGroup", Type: TypeString},
+		{Name: "InstanceTypes", Type: TypeArray},
+		{Name: "DesiredSize", Type: TypeInteger},
+	},
+	"aws_elastic_ip": {
+		{Name: "ResourceID", Type: TypeString},
+		{Name: "IP", Type: TypeString, Required: true},
+		{Name: "ARN", Type: TypeString},
+		{Name: "ConsoleURL", Type: TypeString},
+		{Name: "PricePerHour", Type: TypeNumber},
+		{Name: "PricePerMonth", Type: TypeNumber, Required: true},
+		{Name: "Tag", Type: TypeObject},
+	},
+	"aws_elasticache": {
+		{Name: "CacheEngine", Type: TypeString},
+		{Name: "CacheNodeType", Type: TypeString},
+		{Name: "CacheNodes", Type: TypeInteger},
+	},
+	"aws_elasticsearch": {
+		{Name: "InstanceType", Type: TypeString},
+		{Name: "InstanceCount", Type: TypeInteger},
+	},
+	"aws_elb": {},
+	"aws_elbv2": {
+		{Name: "Type", Type: TypeString},
+		{Name: "TargetGroups", Type: TypeArray},
+	},
+	"aws_emr": {
+		{Name: "Name", Type: TypeString},
+		{Name: "ClusterID", Type: TypeString},
+		{Name: "InstanceTypes", Type: TypeObject},
+	},
+	"aws_iam_users": {
+		{Name: "UserName", Type: TypeString, Required: true},
+		{Name: "AccessKey", Type: TypeString},
+		{Name: "LastUsedDate", Type: TypeTime},
+		{Name: "LastActivity", Type: TypeString},
+		{Name: "ARN", Type: TypeString},
+		{Name: "ConsoleURL", Type: TypeString},
+	},
+	"aws_kinesis": {},
+	"aws_lambda": {
+		{Name: "Name", Type: TypeString},
+		{Name: "Architecture", Type: TypeString},
+		{Name: "MemorySize", Type: TypeInteger},
+		{Name: "ProvisionedConcurrency", Type: TypeInteger},
+		{Name: "InvocationsPerMonth", Type: TypeNumber},
+		{Name: "GBSecondsPerMonth", Type: TypeNumber},
+		{Name: "PriceUnavailable", Type: TypeBool},
+	},
+	"aws_log_groups": {
+		{Name: "Name", Type: TypeString},
+		{Name: "StoredBytes", Type: TypeInteger},
+		{Name: "RetentionInDays", Type: TypeInteger},
+		{Name: "NeverExpire", Type: TypeBool},
+		{Name: "IncomingBytes", Type: TypeNumber},
+	},
+	"aws_modernization": {
+		{Name: "Category", Type: TypeString, Required: true},
+		{Name: "ResourceType", Type: TypeString},
+		{Name: "CurrentType", Type: TypeString},
+		{Name: "RecommendedType", Type: TypeString},
+		{Name: "RecommendedPricePerMonth", Type: TypeNumber},
+		{Name: "SavingPerMonth", Type: TypeNumber},
+	},
+	"aws_natgateway": {
+		{Name: "SubnetID", Type: TypeString},
+		{Name: "VPCID", Type: TypeString},
+	},
+	"aws_neptune": {
+		{Name: "InstanceType", Type: TypeString},
+		{Name: "MultiAZ", Type: TypeBool},
+		{Name: "Engine", Type: TypeString},
+	},
+	"aws_network_interfaces": {
+		{Name: "SubnetID", Type: TypeString},
+		{Name: "VPCID", Type: TypeString},
+		{Name: "InterfaceType", Type: TypeString},
+		{Name: "PrivateIPAddress", Type: TypeString},
+		{Name: "PublicIP", Type: TypeString},
+	},
+	"aws_rds": {
+		{Name: "InstanceType", Type: TypeString},
+		{Name: "MultiAZ", Type: TypeBool},
+		{Name: "Engine", Type: TypeString},
+	},
+	"aws_redshift": {
+		{Name: "NodeType", Type: TypeString},
+		{Name: "NumberOfNodes", Type: TypeInteger},
+	},
+	"aws_reservations": {
+		{Name: "Category", Type: TypeString, Required: true},
+		{Name: "ResourceType", Type: TypeString},
+		{Name: "InstanceType", Type: TypeString},
+		{Name: "ProductDescription", Type: TypeString},
+		{Name: "AvailabilityZone", Type: TypeString},
+		{Name: "MultiAZ", Type: TypeBool},
+		{Name: "SizeFlexible", Type: TypeBool},
+		{Name: "ReservedCount", Type: TypeInteger},
+		{Name: "UnusedCount", Type: TypeNumber},
+		{Name: "HourlyCommitment", Type: TypeNumber},
+		{Name: "Utilization", Type: TypeNumber},
+		{Name: "EndTime", Type: TypeTime},
+	},
+	"aws_s3": {
+		{Name: "Name", Type: TypeString},
+		{Name: "StandardStorageSizeBytes", Type: TypeNumber},
+		{Name: "NumberOfObjects", Type: TypeNumber},
+		{Name: "HasMultipartUploadLifecycle", Type: TypeBool},
+		{Name: "InfrequentAccessPricePerMonth", Type: TypeNumber},
+		{Name: "InfrequentAccessSaving", Type: TypeNumber},
+		{Name: "GlacierPricePerMonth", Type: TypeNumber},
+		{Name: "GlacierSaving", Type: TypeNumber},
+	},
+	"aws_sagemaker": {
+		{Name: "Name", Type: TypeString},
+		{Name: "ResourceType", Type: TypeString},
+		{Name: "Instances", Type: TypeArray},
+	},
+	"aws_tags_compliance": {
+		{Name: "Category", Type: TypeString, Required: true},
+		{Name: "ResourceType", Type: TypeString, Required: true},
+		{Name: "MissingTags", Type: TypeArray},
+		{Name: "InvalidTags", Type: TypeObject},
+	},
+	"aws_tgw_attachments": {
+		{Name: "TransitGatewayID", Type: TypeString},
+		{Name: "ResourceType", Type: TypeString},
+		{Name: "AttachedResource", Type: TypeString},
+		{Name: "VPCID", Type: TypeString},
+	},
+	"aws_vpc_endpoints": {
+		{Name: "ServiceName", Type: TypeString},
+		{Name: "VPCID", Type: TypeString},
+		{Name: "AvailabilityZones", Type: TypeInteger},
+	},
+	"aws_vpn_connections": {
+		{Name: "CustomerGatewayID", Type: TypeString},
+		{Name: "VPNGatewayID", Type: TypeString},
+		{Name: "TransitGatewayID", Type: TypeString},
+		{Name: "VPCID", Type: TypeString},
+	},
+}
+
+// pricelessResources are the detected resource types which describe their own fields instead of the price fields
+var pricelessResources = map[string]bool{
+	"aws_elastic_ip": true,
+	"aws_iam_users":  true,
+}
+
+// metriclessResources are the detected resource types without a detection metric
+var metriclessResources = map[string]bool{
+	"aws_iam_users": true,
+}
+
+// SchemaOf returns the data schema of the given schema version, event type and resource type.
+// Detected resources of resource types the api does not know are validated by the common detected resource fields
+func SchemaOf(version int, eventType, resourceName string) (Schema, error) {
+
+	fields := []Field{}
+	switch eventType {
+	case EventServiceStatus:
+		fields = append(fields, serviceStatusFields...)
+	case EventResourceInventory:
+		fields = append(fields, inventoryFields...)
+		fields = append(fields, priceFields...)
+		fields = append(fields, accountFields(version)...)
+	case EventResourceDetected:
+		if !metriclessResources[resourceName] {
+			fields = append(fields, metricField)
+		}
+		if !pricelessResources[resourceName] {
+			fields = append(fields, priceFields...)
+		}
+		fields = append(fields, detectedFields[resourceName]...)
+		fields = append(fields, accountFields(version)...)
+	default:
+		return Schema{}, fmt.Errorf("unknown event type %q", eventType)
+	}
+
+	return Schema{
+		Version: version,
+		Fields:  fields,
+	}, nil
+}
+
+// validate returns the schema violations of the given event data, decoded with json numbers
+func (s Schema) validate(data map[string]interface{}) []string {
+
+	problems := []string{}
+	for _, field := range s.Fields {
+		value, found := data[field.Name]
+		if !found || value == nil {
+			if field.Required {
+				problems = append(problems, fmt.Sprintf("Data.%s is required", field.Name))
+			}
+			continue
+		}
+
+		if !field.Type.matches(value) {
+			problems = append(problems, fmt.Sprintf("Data.%s must be %s", field.Name, field.Type.article()))
+			continue
+		}
+
+		if len(field.Values) > 0 && !containsValue(field.Values, fmt.Sprintf("%v", value)) {
+			problems = append(problems, fmt.Sprintf("Data.%s must be one of %s", field.Name, strings.Join(field.Values, ", ")))
+		}
+	}
+	return problems
+}
+
+// matches returns true if the given json value, decoded with json numbers, is of the field type
+func (t FieldType) matches(value interface{}) bool {
+	switch t {
+	case TypeString:
+		_, ok := value.(string)
+		return ok
+	case TypeNumber:
+		_, ok := value.(json.Number)
+		return ok
+	case TypeInteger:
+		number, ok := value.(json.Number)
+		if !ok {
+			return false
+		}
+		_, err := number.Int64()
+		return err == nil
+	case TypeBool:
+		_, ok := value.(bool)
+		return ok
+	case TypeTime:
+		str, ok := value.(string)
+		if !ok {
+			return false
+		}
+		_, err := time.Parse(time.RFC3339Nano, str)
+		return err == nil
+	case TypeObject:
+		_, ok := value.(map[string]interface{})
+		return ok
+	case TypeArray:
+		_, ok := value.([]interface{})
+		return ok
+	default:
+		return false
+	}
+}
+
+// article returns the field type with its indefinite article, for the validation messages
+func (t FieldType) article() string {
+	switch t {
+	case TypeInteger, TypeObject, TypeArray:
+		return "an " + string(t)
+	case TypeTime:
+		return "an RFC 3339 time"
+	default:
+		return "a " + string(t)
+	}
+}
+
+// containsValue returns true if the given values contain the given value
+func containsValue(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
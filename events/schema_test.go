@@ -0,0 +1,139 @@
+package events_test
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"finala/collector"
+	"finala/collector/aws/resources"
+	"finala/events"
+)
+
+var (
+	mockAccount = collector.AccountDimensions{AccountID: "123456789012", AccountName: "prod", Region: "us-east-1"}
+	mockPrice   = collector.PriceDetectedFields{
+		ResourceID:        "resource-1",
+		LaunchTime:        time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		PricePerHour:      0.1,
+		PricePerMonth:     73,
+		Tag:               map[string]string{"team": "finala"},
+		AccountDimensions: mockAccount,
+	}
+)
+
+// TestCollectorEventsContract validates the events the collector sends by the api schema of their event type and resource type
+func TestCollectorEventsContract(t *testing.T) {
+
+	testCases := []struct {
+		eventType    string
+		resourceName string
+		data         interface{}
+	}{
+		{events.EventServiceStatus, "aws_ec2", collector.EventStatusData{Status: collector.EventError, ErrorMessage: "access denied"}},
+		{events.EventResourceInventory, "aws_ec2", collector.ResourceInventory{ResourceType: "aws_ec2", PriceDetectedFields: mockPrice}},
+		{events.EventResourceDetected, "aws_tags_compliance", collector.DetectedTagsCompliance{Metric: collector.TagsComplianceMetric, Category: collector.TagsComplianceCategory, ResourceType: "aws_ec2", MissingTags: []string{"team"}, PriceDetectedFields: mockPrice}},
+		{events.EventResourceDetected, "aws_apigateway", resources.DetectedAPIGateway{Metric: "Count", Name: "api", RequestsPerMonth: 1, PriceDetectedFields: mockPrice}},
+		{events.EventResourceDetected, "aws_documentDB", resources.DetectedDocumentDB{Metric: "CPU", InstanceType: "db.r5.large", MultiAZ: true, Engine: "docdb", PriceDetectedFields: mockPrice}},
+		{events.EventResourceDetected, "aws_dynamoDB", resources.DetectedAWSDynamoDB{Metric: "Capacity", Name: "table", PriceDetectedFields: mockPrice}},
+		{events.EventResourceDetected, "aws_ec2", resources.DetectedEC2{Metric: "CPU", Name: "web", InstanceType: "m5.large", EC2Recommendation: resources.EC2Recommendation{MaxCPUUtilization: 3.5, RecommendedType: "m5.medium"}, PriceDetectedFields: mockPrice}},
+		{events.EventResourceDetected, "aws_ec2_stopped", resources.DetectedStoppedEC2{Metric: "Stopped", InstanceType: "m5.large", StoppedTime: time.Now(), VolumeIDs: []string{"vol-1"}, PriceDetectedFields: mockPrice}},
+		{events.EventResourceDetected, "aws_ec2_volumes", resources.DetectedAWSEC2Volume{Metric: "Unattached", ResourceID: "vol-1", Type: "gp2", Size: 100, PricePerMonth: 10, AccountDimensions: mockAccount}},
+		{events.EventResourceDetected, "aws_ecs", resources.DetectedECS{Metric: "CPU", ClusterName: "cluster", DesiredCount: 2, CPU: 0.5, PriceDetectedFields: mockPrice}},
+		{events.EventResourceDetected, "aws_eks", resources.DetectedEKS{Metric: "CPU", ClusterName: "cluster", InstanceTypes: []string{"m5.large"}, DesiredSize: 3, PriceDetectedFields: mockPrice}},
+		{events.EventResourceDetected, "aws_elastic_ip", resources.DetectedElasticIP{Metric: "Unattached", ResourceID: "1.1.1.1", IP: "1.1.1.1", PricePerHour: 0.005, PricePerMonth: 3.65, AccountDimensions: mockAccount}},
+		{events.EventResourceDetected, "aws_elasticache", resources.DetectedElasticache{Metric: "Connections", CacheNodes: 2, PriceDetectedFields: mockPrice}},
+		{events.EventResourceDetected, "aws_elasticsearch", resources.DetectedElasticSearch{Metric: "CPU", InstanceCount: 3, PriceDetectedFields: mockPrice}},
+		{events.EventResourceDetected, "aws_elb", resources.DetectedELB{Metric: "Requests", PriceDetectedFields: mockPrice}},
+		{events.EventResourceDetected, "aws_elbv2", resources.DetectedELBV2{Metric: "Requests", Type: "application", TargetGroups: []resources.ELBV2TargetGroup{{Name: "tg"}}, PriceDetectedFields: mockPrice}},
+		{events.EventResourceDetected, "aws_emr", resources.DetectedEMR{Metric: "Idle", ClusterID: "j-1", InstanceTypes: map[string]int64{"m5.xlarge": 2}, PriceDetectedFields: mockPrice}},
+		{events.EventResourceDetected, "aws_iam_users", resources.DetectedAWSLastActivity{UserName: "user", LastUsedDate: time.Now(), LastActivity: "90 days", AccountDimensions: mockAccount}},
+		{events.EventResourceDetected, "aws_kinesis", resources.DetectedKinesis{Metric: "Records", PriceDetectedFields: mockPrice}},
+		{events.EventResourceDetected, "aws_lambda", resources.DetectedAWSLambda{Metric: "Invocations", MemorySize: 128, PriceDetectedFields: mockPrice}},
+		{events.EventResourceDetected, "aws_log_groups", resources.DetectedLogGroup{Metric: "Retention", StoredBytes: 1024, NeverExpire: true, PriceDetectedFields: mockPrice}},
+		{events.EventResourceDetected, "aws_modernization", resources.DetectedModernization{Metric: "Generation", Category: "modernization", CurrentType: "m4.large", PriceDetectedFields: mockPrice}},
+		{events.EventResourceDetected, "aws_natgateway", resources.DetectedNATGateway{Metric: "Bytes", PriceDetectedFields: mockPrice}},
+		{events.EventResourceDetected, "aws_neptune", resources.DetectedAWSNeptune{Metric: "CPU", PriceDetectedFields: mockPrice}},
+		{events.EventResourceDetected, "aws_network_interfaces", resources.DetectedNetworkInterface{Metric: "Unattached", PriceDetectedFields: mockPrice}},
+		{events.EventResourceDetected, "aws_rds", resources.DetectedAWSRDS{Metric: "Connections", PriceDetectedFields: mockPrice}},
+		{events.EventResourceDetected, "aws_redshift", resources.DetectedRedShift{Metric: "CPU", NumberOfNodes: 2, PriceDetectedFields: mockPrice}},
+		{events.EventResourceDetected, "aws_reservations", resources.DetectedReservation{Metric: "Unused", Category: "reservations", ReservedCount: 2, UnusedCount: 1.5, EndTime: time.Now(), PriceDetectedFields: mockPrice}},
+		{events.EventResourceDetected, "aws_s3", resources.DetectedS3Bucket{Metric: "Storage", StandardStorageSizeBytes: 1e12, PriceDetectedFields: mockPrice}},
+		{events.EventResourceDetected, "aws_sagemaker", resources.DetectedSageMaker{Metric: "Idle", Instances: []resources.SageMakerInstances{{InstanceType: "ml.m5.large", InstanceCount: 1}}, PriceDetectedFields: mockPrice}},
+		{events.EventResourceDetected, "aws_tgw_attachments", resources.DetectedTransitGatewayAttachment{Metric: "Bytes", PriceDetectedFields: mockPrice}},
+		{events.EventResourceDetected, "aws_vpc_endpoints", resources.DetectedVPCEndpoint{Metric: "Bytes", AvailabilityZones: 2, PriceDetectedFields: mockPrice}},
+		{events.EventResourceDetected, "aws_vpn_connections", resources.DetectedVPNConnection{Metric: "Bytes", PriceDetectedFields: mockPrice}},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.eventType+"/"+test.resourceName, func(t *testing.T) {
+			event := collector.EventCollector{
+				SchemaVersion: events.SchemaVersion,
+				EventType:     test.eventType,
+				ResourceName:  collector.ResourceIdentifier(test.resourceName),
+				EventTime:     time.Now().UnixNano(),
+				Data:          test.data,
+			}
+			raw, err := json.Marshal(event)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if _, err := events.Parse(raw); err != nil {
+				t.Fatalf("unexpected collector event validation error: %v", err)
+			}
+		})
+	}
+}
+
+func TestSchemaOf(t *testing.T) {
+
+	testCases := []struct {
+		name            string
+		version         int
+		eventType       string
+		resourceName    string
+		data            string
+		expectedProblem string
+	}{
+		{"status out of range", 2, events.EventServiceStatus, "aws_ec2", `{"Status":3}`, "Data.Status must be one of 0, 1, 2"},
+		{"status not an integer", 2, events.EventServiceStatus, "aws_ec2", `{"Status":1.5}`, "Data.Status must be an integer"},
+		{"invalid launch time", 2, events.EventResourceDetected, "aws_ec2", `{"Metric":"CPU","ResourceID":"i-1","PricePerMonth":1,"LaunchTime":"yesterday","AccountID":"1","AccountName":"prod","Region":"us-east-1"}`, "Data.LaunchTime must be an RFC 3339 time"},
+		{"null required field", 2, events.EventResourceInventory, "aws_ec2", `{"ResourceType":null,"ResourceID":"i-1","PricePerMonth":1,"AccountID":"1","AccountName":"prod","Region":"us-east-1"}`, "Data.ResourceType is required"},
+		{"elastic ip without address", 2, events.EventResourceDetected, "aws_elastic_ip", `{"Metric":"Unattached","PricePerMonth":1,"AccountID":"1","AccountName":"prod","Region":"us-east-1"}`, "Data.IP is required"},
+		{"version 2 without account", 2, events.EventResourceDetected, "aws_ec2", `{"Metric":"CPU","ResourceID":"i-1","PricePerMonth":1}`, "Data.AccountID is required"},
+		{"version 1 without account", 1, events.EventResourceDetected, "aws_ec2", `{"Metric":"CPU","ResourceID":"i-1","PricePerMonth":1}`, ""},
+		{"unknown resource type", 2, events.EventResourceDetected, "aws_other", `{"Metric":"CPU","ResourceID":"other-1","PricePerMonth":1,"Size":"large","AccountID":"1","AccountName":"prod","Region":"us-east-1"}`, ""},
+		{"unknown resource type without price", 2, events.EventResourceDetected, "aws_other", `{"Metric":"CPU","ResourceID":"other-1","AccountID":"1","AccountName":"prod","Region":"us-east-1"}`, "Data.PricePerMonth is required"},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			err := events.Validate(events.Event{
+				SchemaVersion: test.version,
+				EventType:     test.eventType,
+				ResourceName:  test.resourceName,
+				EventTime:     1,
+				Data:          json.RawMessage(test.data),
+			})
+
+			if test.expectedProblem == "" {
+				if err != nil {
+					t.Fatalf("unexpected validate error: %v", err)
+				}
+				return
+			}
+
+			var validationErr *events.ValidationError
+			if !errors.As(err, &validationErr) || !strings.Contains(err.Error(), test.expectedProblem) {
+				t.Fatalf("unexpected validate error, got %v expected %q", err, test.expectedProblem)
+			}
+		})
+	}
+
+	if _, err := events.SchemaOf(events.SchemaVersion, "unknown", "aws_ec2"); err == nil {
+		t.Fatalf("unexpected schema of an unknown event type")
+	}
+}
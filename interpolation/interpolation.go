@@ -42,20 +42,29 @@ func min(a, b int) int {
 	return b
 }
 
+// ExtractTimestamp returns the unix time of the given execution id. The collectors name their executions
+// `<collector name>_<unix time>`, and the collector name may contain underscores
 func ExtractTimestamp(executionId string) (int64, error) {
-	executionArr := strings.Split(executionId, "_")
-	if len(executionArr) != 2 {
-		return 0, errors.New("unexpected executionId format")
+	_, timestamp, err := splitExecutionID(executionId)
+	if err != nil {
+		return 0, err
 	}
 
-	return strconv.ParseInt(executionArr[1], 10, 64)
+	return strconv.ParseInt(timestamp, 10, 64)
 }
 
+// ExtractExecutionName returns the collector name of the given execution id
 func ExtractExecutionName(executionId string) (string, error) {
-	executionArr := strings.Split(executionId, "_")
-	if len(executionArr) != 2 {
-		return "", errors.New("unexpected executionId format")
+	name, _, err := splitExecutionID(executionId)
+	return name, err
+}
+
+// splitExecutionID splits the given execution id to the collector name and the unix time, at the last underscore
+func splitExecutionID(executionId string) (string, string, error) {
+	separator := strings.LastIndex(executionId, "_")
+	if separator <= 0 || separator == len(executionId)-1 {
+		return "", "", errors.New("unexpected executionId format")
 	}
 
-	return executionArr[0], nil
+	return executionId[:separator], executionId[separator+1:], nil
 }
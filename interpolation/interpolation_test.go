@@ -2,7 +2,6 @@ package interpolation_test
 
 import (
 	"finala/interpolation"
-	"fmt"
 	"reflect"
 	"testing"
 
@@ -47,27 +46,54 @@ func TestChunkIterator(t *testing.T) {
 }
 
 func TestExtractTimestamp(t *testing.T) {
-	const index_prefix, timestamp = "general", 1595510218
-	index_name := fmt.Sprintf("%s_%d", index_prefix, timestamp)
-	extractedTimestamp, err := interpolation.ExtractTimestamp(index_name)
-	if err != nil {
-		t.Fatalf("error occured while running extractTimestamp, e: %s\n", err)
+	testCases := []struct {
+		executionID       string
+		expectedTimestamp int64
+		expectedErr       bool
+	}{
+		{"general_1595510218", 1595510218, false},
+		{"prod_us_east_1595510218", 1595510218, false},
+		{"general", 0, true},
+		{"general_", 0, true},
+		{"general_now", 0, true},
 	}
 
-	if extractedTimestamp != timestamp {
-		t.Errorf("extractedTimestamp %d is not equal to expected timestamp %d", extractedTimestamp, timestamp)
+	for _, test := range testCases {
+		t.Run(test.executionID, func(t *testing.T) {
+			extractedTimestamp, err := interpolation.ExtractTimestamp(test.executionID)
+			if (err != nil) != test.expectedErr {
+				t.Fatalf("unexpected extractTimestamp error, got %v expected error %t", err, test.expectedErr)
+			}
+
+			if extractedTimestamp != test.expectedTimestamp {
+				t.Errorf("extractedTimestamp %d is not equal to expected timestamp %d", extractedTimestamp, test.expectedTimestamp)
+			}
+		})
 	}
 }
 
 func TestExtractExecutionName(t *testing.T) {
-	const index_prefix, timestamp = "general", 1595510218
-	index_name := fmt.Sprintf("%s_%d", index_prefix, timestamp)
-	extractedExecutionName, err := interpolation.ExtractExecutionName(index_name)
-	if err != nil {
-		t.Fatalf("error occured while running extractExecutionName, e: %s\n", err)
+	testCases := []struct {
+		executionID  string
+		expectedName string
+		expectedErr  bool
+	}{
+		{"general_1595510218", "general", false},
+		{"prod_us_east_1595510218", "prod_us_east", false},
+		{"_1595510218", "", true},
+		{"general", "", true},
 	}
 
-	if extractedExecutionName != index_prefix {
-		t.Errorf("extractedExecutionName %s is not equal to expected timestamp %s", extractedExecutionName, index_prefix)
+	for _, test := range testCases {
+		t.Run(test.executionID, func(t *testing.T) {
+			extractedExecutionName, err := interpolation.ExtractExecutionName(test.executionID)
+			if (err != nil) != test.expectedErr {
+				t.Fatalf("unexpected extractExecutionName error, got %v expected error %t", err, test.expectedErr)
+			}
+
+			if extractedExecutionName != test.expectedName {
+				t.Errorf("extractedExecutionName %s is not equal to expected name %s", extractedExecutionName, test.expectedName)
+			}
+		})
 	}
 }
@@ -13,7 +13,9 @@ type NotifierReport struct {
 	UIAddr               string
 	NotifyByTag          NotifyByTag
 	ExecutionSummaryData map[string]*NotifierCollectorsSummary
-	Log                  log.Entry
+	// ExecutionResourcesData holds the detected resources of each resource name, sorted by their monthly price
+	ExecutionResourcesData map[string][]NotifierResource
+	Log                    log.Entry
 }
 
 // NotifyByTag will represent a list of tags and notify to list
@@ -36,6 +38,24 @@ type NotifierExecutionsResponse struct {
 	Time time.Time
 }
 
+// NotifierResource represents a detected resource with its aws console deep link
+type NotifierResource struct {
+	ResourceID    string            `json:"ResourceID"`
+	ARN           string            `json:"ARN"`
+	ConsoleURL    string            `json:"ConsoleURL"`
+	PricePerMonth float64           `json:"PricePerMonth"`
+	Tag           map[string]string `json:"Tag"`
+}
+
+// NotifierResourcesPage represents a page of the detected resources of a resource type
+type NotifierResourcesPage struct {
+	Resources []struct {
+		Data NotifierResource `json:"Data"`
+	} `json:"Resources"`
+	Page  int   `json:"Page"`
+	Pages int64 `json:"Pages"`
+}
+
 // NotifierCollectorsSummary represnets the response for the Collectors summary
 type NotifierCollectorsSummary struct {
 	ResourceName  string  `json:"ResourceName"`
@@ -9,10 +9,17 @@ import (
 	"gopkg.in/yaml.v2"
 )
 
+// APIAuthConfig describes the credentials the notifier logs in to the api with
+type APIAuthConfig struct {
+	Username string `yaml:"username"`
+	Password string `yaml:"password"`
+}
+
 // NotifierConfig describes the configuration for the notifier subcommand
 type NotifierConfig struct {
 	LogLevel            string                      `yaml:"log_level"`
 	APIServerAddr       string                      `yaml:"api_server_address"`
+	APIAuth             APIAuthConfig               `yaml:"api_auth"`
 	UIAddr              string                      `yaml:"ui_address"`
 	NotifiersConfigs    notifierCommon.ConfigByName `yaml:"notifiers"`
 	registeredNotifiers []notifierCommon.Notifier
@@ -29,6 +29,10 @@ func TestConfig(t *testing.T) {
 		if reflect.TypeOf(config).String() != "config.NotifierConfig" {
 			t.Fatalf("unexpected configuration data")
 		}
+
+		if config.APIAuth.Username != "admin" || config.APIAuth.Password != "password" {
+			t.Fatalf("unexpected api auth configuration, got %+v", config.APIAuth)
+		}
 	})
 
 	t.Run("invalid_config", func(t *testing.T) {
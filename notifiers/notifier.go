@@ -1,9 +1,13 @@
 package notifiers
 
 import (
+	"bytes"
 	"encoding/json"
 	notifierCommon "finala/notifiers/common"
+	"net/http"
 	"net/url"
+	"sort"
+	"strconv"
 
 	"finala/request"
 	"fmt"
@@ -15,6 +19,9 @@ type NotifierMaker func() notifierCommon.Notifier
 
 const notRegisteredTemplate = "notifier by the name %s was not registered"
 
+// resourcesPageLimit defines the number of detected resources requested in each page, the API maximum
+const resourcesPageLimit = 1000
+
 var registeredNotifiers = map[notifierCommon.NotifierName]NotifierMaker{}
 
 // DataFetcherManager will hold all the data for Finala notifier
@@ -22,6 +29,7 @@ type DataFetcherManager struct {
 	client      request.HTTPClientDescriber
 	log         log.Entry
 	apiEndpoint string
+	token       string
 }
 
 // NewDataFetcherManager will fetch all the data requests from Finala API.
@@ -33,9 +41,62 @@ func NewDataFetcherManager(client request.HTTPClientDescriber, log log.Entry, ap
 	}
 }
 
+// Login will authenticate the data fetcher against Finala API, the issued token is sent with all the following requests
+func (dfm *DataFetcherManager) Login(username, password string) error {
+	buf, err := json.Marshal(map[string]string{
+		"username": username,
+		"password": password,
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := dfm.client.Request("POST", fmt.Sprintf("%s/api/v1/auth/login", dfm.apiEndpoint), nil, bytes.NewBuffer(buf))
+	if err != nil {
+		dfm.log.WithError(err).Error("could not create HTTP client request")
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := dfm.client.DO(req)
+	if err != nil {
+		dfm.log.WithError(err).Error("could not send HTTP client request")
+		return err
+	}
+
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return &request.HttpError{Status: res.Status, StatusCode: res.StatusCode}
+	}
+
+	var loginResponse struct {
+		Token string `json:"token"`
+	}
+	err = json.NewDecoder(res.Body).Decode(&loginResponse)
+	if err != nil {
+		return err
+	}
+
+	dfm.token = loginResponse.Token
+	return nil
+}
+
+// request creates a Finala API request, authenticated by the login token
+func (dfm *DataFetcherManager) request(method string, url string, v url.Values) (*http.Request, error) {
+	req, err := dfm.client.Request(method, url, v, nil)
+	if err != nil {
+		return nil, err
+	}
+	if dfm.token != "" {
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", dfm.token))
+	}
+	return req, nil
+}
+
 // GetLatestExecution will get the Collector's latest execution
 func (dfm *DataFetcherManager) GetLatestExecution() (latestExecution string, err error) {
-	req, err := dfm.client.Request("GET", fmt.Sprintf("%s/api/v1/executions?querylimit=1", dfm.apiEndpoint), nil, nil)
+	req, err := dfm.request("GET", fmt.Sprintf("%s/api/v1/executions?querylimit=1", dfm.apiEndpoint), nil)
 	if err != nil {
 		dfm.log.WithError(err).Error("could not create HTTP client request")
 		return "", err
@@ -61,7 +122,7 @@ func (dfm *DataFetcherManager) GetExecutionSummary(executionID string, filterOpt
 	for filterName, filterValue := range filterOptions {
 		v.Set(filterName, filterValue)
 	}
-	req, err := dfm.client.Request("GET", fmt.Sprintf("%s/api/v1/summary/%s", dfm.apiEndpoint, executionID), v, nil)
+	req, err := dfm.request("GET", fmt.Sprintf("%s/api/v1/summary/%s", dfm.apiEndpoint, executionID), v)
 	if err != nil {
 		dfm.log.WithError(err).Error("could not create HTTP client request")
 		return nil, err
@@ -80,3 +141,74 @@ func (dfm *DataFetcherManager) GetExecutionSummary(executionID string, filterOpt
 
 	return executionSummary, err
 }
+
+// GetExecutionResources will get the Collector's execution detected resources of the given resource name,
+// which have all the given tags, sorted by their price. All the resources pages are requested
+func (dfm *DataFetcherManager) GetExecutionResources(executionID string, resourceName string, tags []notifierCommon.Tag) ([]notifierCommon.NotifierResource, error) {
+	resources := []notifierCommon.NotifierResource{}
+	for page := 1; ; page++ {
+		resourcesPage, err := dfm.getExecutionResourcesPage(executionID, resourceName, page)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, row := range resourcesPage.Resources {
+			if hasTags(row.Data.Tag, tags) {
+				resources = append(resources, row.Data)
+			}
+		}
+		if int64(page) >= resourcesPage.Pages || len(resourcesPage.Resources) == 0 {
+			break
+		}
+	}
+
+	sort.SliceStable(resources, func(i, j int) bool {
+		return resources[i].PricePerMonth > resources[j].PricePerMonth
+	})
+
+	return resources, nil
+}
+
+// getExecutionResourcesPage will get a page of the Collector's execution detected resources of the given resource name
+func (dfm *DataFetcherManager) getExecutionResourcesPage(executionID string, resourceName string, page int) (*notifierCommon.NotifierResourcesPage, error) {
+	v := url.Values{}
+	v.Set("executionID", executionID)
+	v.Set("page", strconv.Itoa(page))
+	v.Set("limit", strconv.Itoa(resourcesPageLimit))
+	v.Set("sort_by", "price")
+	v.Set("sort_order", "desc")
+	req, err := dfm.request("GET", fmt.Sprintf("%s/api/v1/resources/%s", dfm.apiEndpoint, resourceName), v)
+	if err != nil {
+		dfm.log.WithError(err).Error("could not create HTTP client request")
+		return nil, err
+	}
+
+	res, err := dfm.client.DO(req)
+	if err != nil {
+		dfm.log.WithError(err).Error("could not send HTTP client request")
+		return nil, err
+	}
+
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, &request.HttpError{Status: res.Status, StatusCode: res.StatusCode}
+	}
+
+	var resourcesPage notifierCommon.NotifierResourcesPage
+	err = json.NewDecoder(res.Body).Decode(&resourcesPage)
+	if err != nil {
+		return nil, err
+	}
+	return &resourcesPage, nil
+}
+
+// hasTags returns true if the resource tags contain all the given tags
+func hasTags(resourceTags map[string]string, tags []notifierCommon.Tag) bool {
+	for _, tag := range tags {
+		if resourceTags[tag.Name] != tag.Value {
+			return false
+		}
+	}
+	return true
+}
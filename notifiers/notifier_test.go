@@ -1,7 +1,9 @@
 package notifiers_test
 
 import (
+	"encoding/json"
 	"finala/notifiers"
+	"finala/notifiers/common"
 	"fmt"
 	"io"
 	"net/http"
@@ -47,22 +49,88 @@ const (
 		  "ErrorMessage": ""
 	  }
 	}`
+	expectedResourcesFirstPage = `{
+		"Resources": [
+		  {
+			"ResourceName": "aws_ec2",
+			"Data": {
+			  "ResourceID": "i-3",
+			  "PricePerMonth": 50,
+			  "Tag": {"team": "b"}
+			}
+		  },
+		  {
+			"ResourceName": "aws_ec2",
+			"Data": {
+			  "ResourceID": "i-2",
+			  "ConsoleURL": "https://console.aws.amazon.com/ec2/home?region=us-east-1#InstanceDetails:instanceId=i-2",
+			  "PricePerMonth": 30,
+			  "Tag": {"team": "a"}
+			}
+		  }
+		],
+		"Page": 1,
+		"Limit": 1000,
+		"Total": 3,
+		"Pages": 2
+	  }`
+	expectedResourcesSecondPage = `{
+		"Resources": [
+		  {
+			"ResourceName": "aws_ec2",
+			"Data": {
+			  "ResourceID": "i-1",
+			  "ConsoleURL": "https://console.aws.amazon.com/ec2/home?region=us-east-1#InstanceDetails:instanceId=i-1",
+			  "PricePerMonth": 10,
+			  "Tag": {"team": "a"}
+			}
+		  }
+		],
+		"Page": 2,
+		"Limit": 1000,
+		"Total": 3,
+		"Pages": 2
+	  }`
 )
 
 type dataFetcherMockClient struct {
-	Error error
+	Error          error
+	Authorizations []string
+	ResourcesPages []string
 }
 
 func (mc *dataFetcherMockClient) DO(r *http.Request) (*http.Response, error) {
 	var newBody io.ReadCloser
+	mc.Authorizations = append(mc.Authorizations, r.Header.Get("Authorization"))
 	switch r.URL.Path {
+	case "/api/v1/auth/login":
+		var credentials map[string]string
+		if err := json.NewDecoder(r.Body).Decode(&credentials); err != nil || credentials["password"] != "password" {
+			return &http.Response{
+				Status:     "401 Unauthorized",
+				StatusCode: http.StatusUnauthorized,
+				Body:       io.NopCloser(strings.NewReader(`{"error":"Invalid username or password"}`)),
+			}, nil
+		}
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       io.NopCloser(strings.NewReader(`{"token":"token-bla"}`)),
+		}, nil
 	case "/api/v1/executions":
 		newBody = io.NopCloser(strings.NewReader(expectedLatestExecutionsResponse))
 	case fmt.Sprintf("/api/v1/summary/%s", expectedLatestExecutionID):
 		newBody = io.NopCloser(strings.NewReader(expectedSummaryResponse))
+	case "/api/v1/resources/aws_ec2":
+		mc.ResourcesPages = append(mc.ResourcesPages, r.URL.Query().Get("page"))
+		if r.URL.Query().Get("page") == "2" {
+			newBody = io.NopCloser(strings.NewReader(expectedResourcesSecondPage))
+		} else {
+			newBody = io.NopCloser(strings.NewReader(expectedResourcesFirstPage))
+		}
 	}
 	return &http.Response{
-		Body: newBody,
+		StatusCode: http.StatusOK,
+		Body:       newBody,
 	}, nil
 }
 
@@ -90,6 +158,35 @@ func MockDataFetcherManager() *notifiers.DataFetcherManager {
 	return mockDataFetcherManager
 }
 
+func TestLogin(t *testing.T) {
+	log := log.WithField("test", "testNotifier")
+
+	t.Run("valid credentials", func(t *testing.T) {
+		client := MockClient()
+		dataFetcher := notifiers.NewDataFetcherManager(client, *log, "http://finala-api")
+		err := dataFetcher.Login("admin", "password")
+		if err != nil {
+			t.Fatalf("unexpected login error, got %v expected nil", err)
+		}
+
+		_, err = dataFetcher.GetLatestExecution()
+		if err != nil {
+			t.Fatalf("unexpected error, got %v expected nil", err)
+		}
+		if client.Authorizations[len(client.Authorizations)-1] != "Bearer token-bla" {
+			t.Fatalf("unexpected authorization header, got %q want %q", client.Authorizations[len(client.Authorizations)-1], "Bearer token-bla")
+		}
+	})
+
+	t.Run("invalid credentials", func(t *testing.T) {
+		dataFetcher := notifiers.NewDataFetcherManager(MockClient(), *log, "http://finala-api")
+		err := dataFetcher.Login("admin", "foo")
+		if err == nil {
+			t.Fatalf("unexpected login error, got nil expected error")
+		}
+	})
+}
+
 func TestGetLatestExecution(t *testing.T) {
 	dataFetcher := MockDataFetcherManager()
 	latestExecution, _ := dataFetcher.GetLatestExecution()
@@ -110,3 +207,22 @@ func TestGetExecutionSummary(t *testing.T) {
 		}
 	})
 }
+
+func TestGetExecutionResources(t *testing.T) {
+	log := log.WithField("test", "testNotifier")
+	client := MockClient()
+	dataFetcher := notifiers.NewDataFetcherManager(client, *log, "http://finala-api")
+	resources, err := dataFetcher.GetExecutionResources(expectedLatestExecutionID, "aws_ec2", []common.Tag{{Name: "team", Value: "a"}})
+	if err != nil {
+		t.Fatalf("unexpected error, got %v expected nil", err)
+	}
+	if strings.Join(client.ResourcesPages, ",") != "1,2" {
+		t.Fatalf("unexpected requested resources pages, got %v want %v", client.ResourcesPages, []string{"1", "2"})
+	}
+	if len(resources) != 2 {
+		t.Fatalf("unexpected value of resources items, got %d want %d", len(resources), 2)
+	}
+	if resources[0].ResourceID != "i-2" || resources[0].ConsoleURL == "" {
+		t.Fatalf("unexpected most expensive resource, got %+v want %s", resources[0], "i-2")
+	}
+}
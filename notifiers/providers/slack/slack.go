@@ -27,6 +27,8 @@ const (
 	greenMessageColor = "#2EB67D"
 	// blueMessageColor color to use
 	blueMessageColor = "#3aa3e3"
+	// maxResourcesToPresent is the number of the most expensive resources linked to the aws console per resource name
+	maxResourcesToPresent = 5
 )
 
 // NewManager returns the notifier
@@ -106,9 +108,10 @@ func (sm *Manager) prepareAttachment(message common.NotifierReport, elasticSearc
 			Fields: []slackApi.AttachmentField{
 				{
 					Title: strings.ToUpper(executionData.ResourceName),
-					Value: fmt.Sprintf("Potential Saving: <%s|$%s>",
+					Value: fmt.Sprintf("Potential Saving: <%s|$%s>%s",
 						resourceLink,
-						humanize.Commaf(math.Floor(executionData.TotalSpent))),
+						humanize.Commaf(math.Floor(executionData.TotalSpent)),
+						sm.formatResourcesLinks(message.ExecutionResourcesData[executionData.ResourceName])),
 					Short: false,
 				},
 			},
@@ -128,6 +131,22 @@ func (sm *Manager) prepareAttachment(message common.NotifierReport, elasticSearc
 	return slackAttachments
 }
 
+// formatResourcesLinks returns the most expensive resources, linked to the aws console, one per line
+func (sm *Manager) formatResourcesLinks(resources []notifierCommon.NotifierResource) string {
+	resourcesLinks := ""
+	for i, resource := range resources {
+		if i == maxResourcesToPresent {
+			break
+		}
+		if resource.ConsoleURL == "" {
+			resourcesLinks += fmt.Sprintf("\n• %s $%s", resource.ResourceID, humanize.Commaf(math.Floor(resource.PricePerMonth)))
+			continue
+		}
+		resourcesLinks += fmt.Sprintf("\n• <%s|%s> $%s", resource.ConsoleURL, resource.ResourceID, humanize.Commaf(math.Floor(resource.PricePerMonth)))
+	}
+	return resourcesLinks
+}
+
 // Send all slack Notifications to users and channels
 func (sm *Manager) Send(message notifierCommon.NotifierReport) {
 	message.Log.WithField("notify_by_tags", sm.config.NotifyByTags).
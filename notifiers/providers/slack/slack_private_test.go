@@ -155,6 +155,25 @@ func TestFormatTagsElasticSearchQuery(t *testing.T) {
 	})
 }
 
+func TestFormatResourcesLinks(t *testing.T) {
+	slackManager := Manager{}
+	resources := []common.NotifierResource{
+		{ResourceID: "i-1", ConsoleURL: "https://console.aws.amazon.com/ec2/home?region=us-east-1#InstanceDetails:instanceId=i-1", PricePerMonth: 1500},
+		{ResourceID: "i-2", PricePerMonth: 10},
+	}
+	for i := 0; i < maxResourcesToPresent; i++ {
+		resources = append(resources, common.NotifierResource{ResourceID: fmt.Sprintf("i-%d", i+3)})
+	}
+
+	resourcesLinks := slackManager.formatResourcesLinks(resources)
+	expectedResourcesLinks := "\n• <https://console.aws.amazon.com/ec2/home?region=us-east-1#InstanceDetails:instanceId=i-1|i-1> $1,500\n• i-2 $10\n• i-3 $0\n• i-4 $0\n• i-5 $0"
+	t.Run("check resources links", func(t *testing.T) {
+		if resourcesLinks != expectedResourcesLinks {
+			t.Fatalf("unexpected resources links, got %q expected %q", resourcesLinks, expectedResourcesLinks)
+		}
+	})
+}
+
 func TestBuildSendURL(t *testing.T) {
 	baseURL := "http://127.0.0.1"
 	executionID := "general_123123"
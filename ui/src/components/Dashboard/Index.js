@@ -126,6 +126,7 @@ const DashboardIndex = ({
                 variant="outlined"
                 onClick={() => {
                   localStorage.removeItem("finalaAuthToken");
+                  localStorage.removeItem("finalaUserRole");
                   navigate("/login");
                 }}
                 className={classes.logoutButton}
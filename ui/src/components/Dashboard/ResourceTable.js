@@ -137,6 +137,16 @@ const ResourceTable = ({
           <span>{Moment(data).format("YYYY-MM-DD HH:mm")}</span>
         );
         break;
+      case "ConsoleURL":
+        renderr = (data) =>
+          data ? (
+            <a href={data} target="_blank" rel="noopener noreferrer">
+              Open in console
+            </a>
+          ) : (
+            <span />
+          );
+        break;
       default:
         renderr = (data) => <span>{`${data}`}</span>;
     }
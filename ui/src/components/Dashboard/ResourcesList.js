@@ -42,6 +42,15 @@ const useStyles = makeStyles(() => ({
   costSavingTitle: {
     color: "#d69e2e",
   },
+  modernizationTitle: {
+    color: "#3182ce",
+  },
+  reservationTitle: {
+    color: "#805ad5",
+  },
+  tagsComplianceTitle: {
+    color: "#dd6b20",
+  },
   unusedTitle: {
     color: "#38a169",
   },
@@ -100,15 +109,33 @@ const ResourcesList = ({ resources, setResource, setFilters, filters }) => {
   const navigate = useNavigate();
 
   // Separate resources by category
+  const modernizationResources = Object.values(resources || {})
+    .filter((resource) => resource.Category === "modernization")
+    .sort((a, b) => (b.TotalSpent || 0) - (a.TotalSpent || 0));
+
+  const reservationResources = Object.values(resources || {})
+    .filter((resource) => resource.Category === "unused_reservation")
+    .sort((a, b) => (b.TotalSpent || 0) - (a.TotalSpent || 0));
+
+  const tagsComplianceResources = Object.values(resources || {})
+    .filter((resource) => resource.Category === "tags_compliance")
+    .sort((a, b) => (b.TotalSpent || 0) - (a.TotalSpent || 0));
+
   const costSavingResources = Object.values(resources || {})
     .filter((resource) => 
-      resource.Category === "potential_cost_saving" || 
-      (resource.TotalSpent && resource.TotalSpent > 0)
+      resource.Category !== "modernization" &&
+      resource.Category !== "unused_reservation" &&
+      resource.Category !== "tags_compliance" &&
+      (resource.Category === "potential_cost_saving" || 
+      (resource.TotalSpent && resource.TotalSpent > 0))
     )
     .sort((a, b) => (b.TotalSpent || 0) - (a.TotalSpent || 0));
 
   const unusedResources = Object.values(resources || {})
     .filter((resource) => 
+      resource.Category !== "modernization" &&
+      resource.Category !== "unused_reservation" &&
+      resource.Category !== "tags_compliance" &&
       (resource.Category === "unused_resource" || 
        (!resource.TotalSpent || resource.TotalSpent === 0)) &&
       (resource.ResourceCount && resource.ResourceCount > 0)
@@ -184,6 +211,42 @@ const ResourcesList = ({ resources, setResource, setFilters, filters }) => {
           🗑️ Unused Resources
         </Typography>
         {renderResourceChips(unusedResources, true)}
+
+        {reservationResources.length > 0 && (
+          <>
+            <Divider className={classes.divider} />
+
+            {/* Unused Reservations Section */}
+            <Typography className={`${classes.sectionTitle} ${classes.reservationTitle}`}>
+              📄 Unused Reservations
+            </Typography>
+            {renderResourceChips(reservationResources, false)}
+          </>
+        )}
+
+        {tagsComplianceResources.length > 0 && (
+          <>
+            <Divider className={classes.divider} />
+
+            {/* Tags Compliance Section */}
+            <Typography className={`${classes.sectionTitle} ${classes.tagsComplianceTitle}`}>
+              🏷️ Untagged Spend
+            </Typography>
+            {renderResourceChips(tagsComplianceResources, false)}
+          </>
+        )}
+
+        {modernizationResources.length > 0 && (
+          <>
+            <Divider className={classes.divider} />
+
+            {/* Modernization Resources Section */}
+            <Typography className={`${classes.sectionTitle} ${classes.modernizationTitle}`}>
+              🔄 Previous Generation Resources
+            </Typography>
+            {renderResourceChips(modernizationResources, false)}
+          </>
+        )}
       </CardContent>
     </Card>
   );
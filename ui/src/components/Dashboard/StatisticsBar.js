@@ -58,7 +58,12 @@ const useStyles = makeStyles(() => ({
 const StatisticsBar = ({ resources }) => {
   const classes = useStyles();
 
-  const collectors = Object.values(resources || {});
+  // Modernization and tags compliance resources are priced by their current cost, not by their saving
+  const collectors = Object.values(resources || {}).filter(
+    (collector) =>
+      collector.Category !== "modernization" &&
+      collector.Category !== "tags_compliance"
+  );
 
   const totalSpent = collectors.reduce((sum, collector) => {
     return sum + (collector.TotalSpent || 0);
@@ -7,11 +7,53 @@ import { SettingsService } from "services/settings.service";
 import { titleDirective } from "utils/Title";
 import { getHistory, setHistory } from "../utils/History";
 
-let fetchTimeoutRequest = false;
-let fetchTableTimeoutRequest = false;
 let initTimeoutRequest = false;
 let lastFiltersSearched = "[]";
 
+// The scanning execution progress stream, which refreshes the resources when the collector sends events
+let executionStream = false;
+let executionStreamID = "";
+let streamRefreshTimeoutRequest = false;
+let onExecutionStreamEvent = () => {};
+
+// The events of a collector arrive in batches, so the resources are refreshed once per batch
+const StreamRefreshDelay = 1000;
+
+// The resources are refreshed by this delay when the stream was closed, until the stream is opened again
+const StreamRetryDelay = 5000;
+
+/**
+ * Subscribe to the progress events of the given execution, unless it is already subscribed
+ * @param  {string} executionID Scanning Execution
+ */
+const openExecutionStream = (executionID) => {
+  if (
+    executionStream &&
+    executionStreamID === executionID &&
+    executionStream.readyState !== window.EventSource.CLOSED
+  ) {
+    return;
+  }
+  closeExecutionStream();
+  executionStreamID = executionID;
+  executionStream = ResourcesService.SubscribeExecution(
+    executionID,
+    (eventType) => onExecutionStreamEvent(eventType),
+  );
+};
+
+/**
+ * Close the execution progress stream
+ */
+const closeExecutionStream = () => {
+  clearTimeout(streamRefreshTimeoutRequest);
+  if (executionStream) {
+    executionStream.close();
+  }
+  executionStream = false;
+  executionStreamID = "";
+};
+
 /**
  * will show a scanning message if some of the resources are still in progress
  * {
@@ -25,7 +67,6 @@ let lastFiltersSearched = "[]";
  * @param  {func} setIsResourceListLoading  update isLoading state for resources
  * @param  {func} setIsResourceTableLoading  update isLoading state for resources table
  *
- * @param  {array} resources  Resources List
  * @param  {bool} isScanning indicate if the system is in scan mode
  * @param  {array} filters  Filters List
  *
@@ -45,7 +86,6 @@ const DataFacotry = ({
   setIsResourceTableLoading,
 
   filters,
-  resources,
   setIsAppLoading,
   setIsScanning,
 }) => {
@@ -114,7 +154,6 @@ const DataFacotry = ({
    * @param  {array} filters  Filters List
    */
   const onCurrentExecutionChanged = async (currentExecution, filters = []) => {
-    clearTimeout(fetchTimeoutRequest);
     setIsResourceListLoading(true);
     await getResources(currentExecution, filters);
     setIsResourceListLoading(false);
@@ -137,7 +176,6 @@ const DataFacotry = ({
     currentExecution,
     filters = [],
   ) => {
-    clearTimeout(fetchTableTimeoutRequest);
     setIsResourceTableLoading(true);
     await getResourceTable(currentResource, currentExecution, filters);
     setIsResourceTableLoading(false);
@@ -154,18 +192,14 @@ const DataFacotry = ({
       filters,
     ).catch(() => false);
 
+    // The resources of a scanning execution are refreshed by its progress events
     const scanningResource = getScanningResource(ResourcesList);
     if (scanningResource) {
       setIsScanning(true);
+      openExecutionStream(currentExecution);
     } else {
       setIsScanning(false);
-    }
-
-    if (scanningResource) {
-      fetchTimeoutRequest = setTimeout(
-        () => getResources(currentExecution, filters),
-        5000,
-      );
+      closeExecutionStream();
     }
 
     setResources(ResourcesList);
@@ -182,7 +216,6 @@ const DataFacotry = ({
     currentExecution,
     filters = [],
   ) => {
-    clearTimeout(fetchTableTimeoutRequest);
     const ResourceRows = await ResourcesService.GetContent(
       currentResource,
       currentExecution,
@@ -190,30 +223,62 @@ const DataFacotry = ({
     ).catch(() => []);
 
     let rows = [];
-    if (ResourceRows && ResourceRows.length) {
-      rows = ResourceRows.map((row) => row.Data);
+    if (
+      ResourceRows &&
+      ResourceRows.Resources &&
+      ResourceRows.Resources.length
+    ) {
+      rows = ResourceRows.Resources.map((row) => row.Data);
     }
     setCurrentResourceData(rows);
 
-    const resourceInfo = resources[currentResource];
-    // resource in scanning mode - refresh
-    if (resourceInfo && resourceInfo.Status == 0) {
-      fetchTableTimeoutRequest = setTimeout(
-        () => getResourceTable(currentResource, currentExecution, filters),
-        5000,
-      );
-    } else {
-      clearTimeout(fetchTableTimeoutRequest);
-    }
-
     return true;
   };
 
+  /**
+   * Will refresh the resources list and the resource table of the scanning execution, once per events batch
+   * @param  {string} eventType Received stream event type
+   * @param  {string} currentExecution Current Selected Execution
+   * @param  {string} currentResource Current Selected Resource
+   * @param  {array} filters  Filters List
+   */
+  const refreshScanningExecution = (
+    eventType,
+    currentExecution,
+    currentResource,
+    filters = [],
+  ) => {
+    clearTimeout(streamRefreshTimeoutRequest);
+    streamRefreshTimeoutRequest = setTimeout(
+      async () => {
+        await getResources(currentExecution, filters);
+        if (currentResource) {
+          await getResourceTable(currentResource, currentExecution, filters);
+        }
+      },
+      eventType === "error" ? StreamRetryDelay : StreamRefreshDelay,
+    );
+  };
+
+  /**
+   * The stream events refresh the current selection
+   */
+  useEffect(() => {
+    onExecutionStreamEvent = (eventType) =>
+      refreshScanningExecution(
+        eventType,
+        currentExecution,
+        currentResource,
+        filters,
+      );
+  });
+
   /**
    * Initial Load - set baseURL using Settings Api
    */
   useEffect(() => {
     init();
+    return closeExecutionStream;
   }, []);
 
   /**
@@ -266,7 +331,6 @@ DataFacotry.propTypes = {
   setCurrentExecution: PropTypes.func,
 
   currentResource: PropTypes.string,
-  resources: PropTypes.object,
   filters: PropTypes.array,
   currentExecution: PropTypes.string,
 
@@ -276,7 +340,6 @@ DataFacotry.propTypes = {
 };
 
 const mapStateToProps = (state) => ({
-  resources: state.resources.resources,
   currentResource: state.resources.currentResource,
   currentExecution: state.executions.current,
   filters: state.filters.filters,
@@ -26,6 +26,27 @@ const LoginPage = () => {
   const [apiBaseUrl, setApiBaseUrl] = useState("");
   const [configLoading, setConfigLoading] = useState(true);
   const [configError, setConfigError] = useState("");
+  const [providers, setProviders] = useState({ password: true, oidc: false });
+
+  // The API redirects back from single sign-on with the token or the error in the url fragment
+  useEffect(() => {
+    const params = new URLSearchParams(window.location.hash.substring(1));
+    if (!params.has("token") && !params.has("error")) {
+      return;
+    }
+    window.history.replaceState(
+      null,
+      "",
+      window.location.pathname + window.location.search,
+    );
+    if (params.get("token")) {
+      localStorage.setItem("finalaAuthToken", params.get("token"));
+      localStorage.setItem("finalaUserRole", params.get("role") || "");
+      navigate("/");
+    } else {
+      setError(params.get("error"));
+    }
+  }, [navigate]);
 
   useEffect(() => {
     const fetchApiConfig = async () => {
@@ -58,6 +79,30 @@ const LoginPage = () => {
     fetchApiConfig();
   }, []);
 
+  useEffect(() => {
+    if (!apiBaseUrl) {
+      return;
+    }
+    const fetchProviders = async () => {
+      try {
+        const response = await fetch(`${apiBaseUrl}/api/v1/auth/providers`);
+        if (response.ok) {
+          setProviders(await response.json());
+        }
+      } catch (err) {
+        /* eslint-disable no-console */
+        console.error("Error fetching sign-in providers:", err);
+        /* eslint-enable no-console */
+      }
+    };
+
+    fetchProviders();
+  }, [apiBaseUrl]);
+
+  const handleSingleSignOn = () => {
+    window.location.assign(`${apiBaseUrl}/api/v1/auth/oidc/login`);
+  };
+
   const handleLogin = async (event) => {
     event.preventDefault();
 
@@ -117,6 +162,7 @@ const LoginPage = () => {
         const data = JSON.parse(responseText);
         if (data.token) {
           localStorage.setItem("finalaAuthToken", data.token);
+          localStorage.setItem("finalaUserRole", data.role || "");
           setUsername("");
           setPassword("");
           navigate("/");
@@ -204,79 +250,104 @@ const LoginPage = () => {
             {configError}
           </Alert>
         )}
-        <Box component="form" onSubmit={handleLogin} sx={{ width: "100%" }}>
-          <TextField
-            margin="normal"
-            required
-            fullWidth
-            id="username"
-            label="Username"
-            name="username"
-            autoComplete="username"
-            autoFocus
-            value={username}
-            onChange={(e) => setUsername(e.target.value)}
-            disabled={loading || !!configError}
-            sx={{
-              "& .MuiOutlinedInput-root": {
-                "&.Mui-focused fieldset": {
-                  borderColor: "#DC143C",
-                },
-              },
-              "& .MuiInputLabel-root.Mui-focused": {
-                color: "#DC143C",
-              },
-            }}
-          />
-          <TextField
-            margin="normal"
-            required
-            fullWidth
-            name="password"
-            label="Password"
-            type="password"
-            id="password"
-            autoComplete="current-password"
-            value={password}
-            onChange={(e) => setPassword(e.target.value)}
-            disabled={loading || !!configError}
-            sx={{
-              "& .MuiOutlinedInput-root": {
-                "&.Mui-focused fieldset": {
-                  borderColor: "#DC143C",
-                },
-              },
-              "& .MuiInputLabel-root.Mui-focused": {
-                color: "#DC143C",
-              },
-            }}
-          />
-          {error && (
-            <Alert severity="error" sx={{ width: "100%", mt: 2, mb: 1 }}>
-              {error}
-            </Alert>
-          )}
+        {providers.oidc && (
           <Button
-            type="submit"
             fullWidth
-            variant="contained"
+            variant="outlined"
+            onClick={handleSingleSignOn}
+            disabled={loading || !!configError}
             sx={{
-              mt: 3,
-              mb: 2,
-              bgcolor: "#DC143C",
+              mb: providers.password ? 1 : 0,
+              color: "#DC143C",
+              borderColor: "#DC143C",
               "&:hover": {
-                bgcolor: "#B01030",
+                borderColor: "#B01030",
               },
             }}
-            disabled={loading || !!configError}
           >
-            {loading ? (
-              <CircularProgress size={24} color="inherit" />
-            ) : (
-              "Log In"
-            )}
+            Sign in with SSO
           </Button>
-        </Box>
+        )}
+        {!providers.password && error && (
+          <Alert severity="error" sx={{ width: "100%", mt: 2 }}>
+            {error}
+          </Alert>
+        )}
+        {providers.password && (
+          <Box component="form" onSubmit={handleLogin} sx={{ width: "100%" }}>
+            <TextField
+              margin="normal"
+              required
+              fullWidth
+              id="username"
+              label="Username"
+              name="username"
+              autoComplete="username"
+              autoFocus
+              value={username}
+              onChange={(e) => setUsername(e.target.value)}
+              disabled={loading || !!configError}
+              sx={{
+                "& .MuiOutlinedInput-root": {
+                  "&.Mui-focused fieldset": {
+                    borderColor: "#DC143C",
+                  },
+                },
+                "& .MuiInputLabel-root.Mui-focused": {
+                  color: "#DC143C",
+                },
+              }}
+            />
+            <TextField
+              margin="normal"
+              required
+              fullWidth
+              name="password"
+              label="Password"
+              type="password"
+              id="password"
+              autoComplete="current-password"
+              value={password}
+              onChange={(e) => setPassword(e.target.value)}
+              disabled={loading || !!configError}
+              sx={{
+                "& .MuiOutlinedInput-root": {
+                  "&.Mui-focused fieldset": {
+                    borderColor: "#DC143C",
+                  },
+                },
+                "& .MuiInputLabel-root.Mui-focused": {
+                  color: "#DC143C",
+                },
+              }}
+            />
+            {error && (
+              <Alert severity="error" sx={{ width: "100%", mt: 2, mb: 1 }}>
+                {error}
+              </Alert>
+            )}
+            <Button
+              type="submit"
+              fullWidth
+              variant="contained"
+              sx={{
+                mt: 3,
+                mb: 2,
+                bgcolor: "#DC143C",
+                "&:hover": {
+                  bgcolor: "#B01030",
+                },
+              }}
+              disabled={loading || !!configError}
+            >
+              {loading ? (
+                <CircularProgress size={24} color="inherit" />
+              ) : (
+                "Log In"
+              )}
+            </Button>
+          </Box>
+        )}
       </Paper>
     </Container>
   );
@@ -16,6 +16,7 @@ class Http {
   request(url, action, customRequestOptions = {}) {
     let defaultRequestOptions = {
       method: action,
+      headers: authorizationHeaders(),
     };
     merge(defaultRequestOptions, customRequestOptions);
     let fullUrl = "";
@@ -29,6 +30,28 @@ class Http {
   }
 }
 
+/**
+ * Authorization headers of the logged in user
+ *
+ * @returns {object}
+ */
+export function authorizationHeaders() {
+  const token = authorizationToken();
+  if (!token) {
+    return {};
+  }
+  return { Authorization: `Bearer ${token}` };
+}
+
+/**
+ * Token of the logged in user
+ *
+ * @returns {string|null}
+ */
+export function authorizationToken() {
+  return localStorage.getItem("finalaAuthToken");
+}
+
 /**
  * Manage http request response
  *
@@ -36,6 +59,13 @@ class Http {
  * @returns {object}
  */
 function handleResponse(response) {
+  if (response.status == 401) {
+    // The token is missing, expired or was signed by a revoked key
+    localStorage.removeItem("finalaAuthToken");
+    localStorage.removeItem("finalaUserRole");
+    window.location.assign("/login");
+    return Promise.reject(response);
+  }
   return response.json().then((result) => {
     if (response.status == 200) {
       return result;
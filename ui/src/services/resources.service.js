@@ -1,11 +1,24 @@
-import { http } from "./request.service";
+import { authorizationToken, http } from "./request.service";
 
 export const ResourcesService = {
   GetExecutions,
   Summary,
   GetContent,
+  SubscribeExecution,
 };
 
+// The progress events of an execution stream
+const ExecutionStreamEvents = [
+  "service_status",
+  "resource_detected",
+  "execution_finished",
+  "resync",
+];
+
+// The resources table pages and sorts on the client, so it reads all the pages of the resources, by the largest
+// page the API returns
+const MaxResourcesPageLimit = 1000;
+
 /**
  *
  * @param {array} filters filters list
@@ -68,18 +81,72 @@ function Summary(executionID, filters = []) {
  * @param {string} name resource name
  * @param {string} executionID execution id to query
  * @param {array} filters filters list
+ * @param {number} page page to query, starts at 1
  */
-function GetContent(name, executionID, filters = []) {
+function getContentPage(name, executionID, filters, page) {
   const params = {
-    ...{ executionID },
+    ...{ executionID, page, limit: MaxResourcesPageLimit },
     ...getTransformedFilters(filters),
   };
   const searchParams = new window.URLSearchParams(params).toString();
 
-  return http
-    .send(`api/v1/resources/${name}?${searchParams}`, `get`)
-    .then(this.handleResponse)
-    .then((response) => {
-      return response;
-    });
+  return http.send(`api/v1/resources/${name}?${searchParams}`, `get`);
+}
+
+/**
+ *
+ * @param {string} name resource name
+ * @param {string} executionID execution id to query
+ * @param {array} filters filters list
+ * @returns all the pages of the resources, with the total number of resources
+ */
+async function GetContent(name, executionID, filters = []) {
+  const resources = [];
+  let response;
+  let page = 1;
+  do {
+    response = await getContentPage(name, executionID, filters, page);
+    if (!response || !response.Resources) {
+      break;
+    }
+    resources.push(...response.Resources);
+    page++;
+  } while (page <= response.Pages);
+
+  return {
+    Resources: resources,
+    Total: response && response.Total ? response.Total : resources.length,
+  };
+}
+
+/**
+ * Subscribe to the progress events of the execution. EventSource can not send the authorization header,
+ * so the token is sent in the access_token query parameter
+ *
+ * @param {string} executionID execution to subscribe
+ * @param {func} onEvent called with the type of each received event, or with error when the stream was closed
+ * @returns {EventSource} the execution stream, which the caller closes
+ */
+function SubscribeExecution(executionID, onEvent) {
+  const params = new window.URLSearchParams();
+  const token = authorizationToken();
+  if (token) {
+    params.set("access_token", token);
+  }
+
+  const stream = new window.EventSource(
+    `${http.baseURL}/api/v1/executions/${encodeURIComponent(
+      executionID,
+    )}/events?${params.toString()}`,
+  );
+  ExecutionStreamEvents.forEach((eventType) => {
+    stream.addEventListener(eventType, () => onEvent(eventType));
+  });
+  stream.onerror = () => {
+    // The browser reconnects a dropped stream by itself, but not a rejected one
+    if (stream.readyState === window.EventSource.CLOSED) {
+      onEvent("error");
+    }
+  };
+  return stream;
 }